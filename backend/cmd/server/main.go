@@ -2,9 +2,10 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -13,50 +14,65 @@ import (
 	"github.com/k8s-service-optimizer/backend/pkg/api"
 	"github.com/k8s-service-optimizer/backend/pkg/collector"
 	"github.com/k8s-service-optimizer/backend/pkg/optimizer"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
 )
 
 func main() {
-	log.Println("Starting k8s-service-optimizer API server...")
-
-	// Load configuration from environment variables
+	// Load configuration from environment variables, then log at its level from here on
 	config := loadConfig()
+	slog.SetDefault(newLogger(config.LogLevel))
+	slog.Info("Starting k8s-service-optimizer API server",
+		"port", config.Port, "log_level", config.LogLevel, "update_interval", config.UpdateInterval,
+		"cluster", config.ClusterName, "federation", config.FederationToken != "")
 
 	// Create Kubernetes client
-	log.Println("Connecting to Kubernetes cluster...")
+	slog.Info("Connecting to Kubernetes cluster")
 	k8sClient, err := k8s.NewClient()
 	if err != nil {
-		log.Fatalf("Failed to create Kubernetes client: %v", err)
+		slog.Error("Failed to create Kubernetes client", "error", err)
+		os.Exit(1)
 	}
-	log.Println("Successfully connected to Kubernetes cluster")
+	slog.Info("Successfully connected to Kubernetes cluster")
 
 	// Create metrics collector
-	log.Println("Initializing metrics collector...")
-	mc := collector.New(k8sClient)
+	slog.Debug("Initializing metrics collector")
+	collectorConfig := collector.DefaultConfig()
+	collectorConfig.CollectWorkingSet = getEnvBool("COLLECT_WORKING_SET", false)
+	collectorConfig.CollectCPUThrottling = getEnvBool("COLLECT_CPU_THROTTLING", false)
+	mc := collector.NewWithConfig(k8sClient, collectorConfig)
 
 	// Set namespaces to monitor (from env or default)
 	namespaces := getNamespaces()
 	mc.SetNamespaces(namespaces)
-	log.Printf("Monitoring namespaces: %v", namespaces)
+	slog.Info("Monitoring namespaces", "namespaces", namespaces)
 
 	// Start the collector
 	if err := mc.Start(); err != nil {
-		log.Fatalf("Failed to start metrics collector: %v", err)
+		slog.Error("Failed to start metrics collector", "error", err)
+		os.Exit(1)
 	}
 	defer mc.Stop()
-	log.Println("Metrics collector started")
+
+	// The optimizer and analyzer share one pricing provider, so rates updated through the API
+	// apply to both
+	prices := pricing.DefaultProvider()
 
 	// Create optimizer
-	log.Println("Initializing optimizer engine...")
-	opt := optimizer.New(k8sClient, mc)
-	log.Println("Optimizer engine initialized")
+	slog.Debug("Initializing optimizer engine")
+	optimizerConfig := optimizer.DefaultConfig()
+	optimizerConfig.Pricing = prices
+	opt := optimizer.NewWithConfig(k8sClient, mc, optimizerConfig)
+	slog.Debug("Optimizer engine initialized")
 
 	// Create analyzer
-	log.Println("Initializing analyzer...")
-	an := analyzer.New(mc)
-	log.Println("Analyzer initialized")
+	slog.Debug("Initializing analyzer")
+	analyzerConfig := analyzer.DefaultConfig()
+	analyzerConfig.Pricing = prices
+	an := analyzer.NewWithClient(mc, k8sClient, analyzerConfig)
+	slog.Debug("Analyzer initialized")
 
 	// Create API server
-	log.Println("Initializing API server...")
+	slog.Debug("Initializing API server")
 	srv := api.NewServerWithConfig(k8sClient, mc, opt, an, config)
 
 	// Channel to listen for interrupt signals
@@ -66,8 +82,7 @@ func main() {
 	// Start server in a goroutine
 	serverErrors := make(chan error, 1)
 	go func() {
-		log.Printf("API server listening on port %s", config.Port)
-		log.Println("Press Ctrl+C to stop the server")
+		slog.Info("API server listening", "port", config.Port)
 		serverErrors <- srv.Start()
 	}()
 
@@ -75,11 +90,12 @@ func main() {
 	select {
 	case err := <-serverErrors:
 		if err != nil {
-			log.Fatalf("Server error: %v", err)
+			slog.Error("Server error", "error", err)
+			os.Exit(1)
 		}
 
 	case <-sigint:
-		log.Println("\nReceived interrupt signal, shutting down gracefully...")
+		slog.Info("Received interrupt signal, shutting down gracefully")
 
 		// Create a deadline for shutdown
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -87,13 +103,13 @@ func main() {
 
 		// Shutdown the API server
 		if err := srv.Shutdown(shutdownCtx); err != nil {
-			log.Printf("Server shutdown error: %v", err)
+			slog.Error("Server shutdown error", "error", err)
 		}
 
 		// Stop the metrics collector
 		mc.Stop()
 
-		log.Println("Shutdown complete")
+		slog.Info("Shutdown complete")
 	}
 }
 
@@ -104,18 +120,41 @@ func loadConfig() *api.Config {
 	updateInterval := getEnvDuration("UPDATE_INTERVAL", 5*time.Second)
 
 	config := &api.Config{
-		Port:           port,
-		EnableCORS:     true,
-		LogLevel:       logLevel,
-		UpdateInterval: updateInterval,
+		Port:             port,
+		EnableCORS:       true,
+		LogLevel:         logLevel,
+		UpdateInterval:   updateInterval,
+		ResponseCacheTTL: getEnvDuration("RESPONSE_CACHE_TTL", 30*time.Second),
+		OverviewCacheTTL: getEnvDuration("OVERVIEW_CACHE_TTL", 15*time.Second),
+		RateLimit: api.RateLimit{
+			PerSecond: getEnvFloat("RATE_LIMIT_RPS", 20),
+			Burst:     getEnvInt("RATE_LIMIT_BURST", 40),
+		},
+		HeavyRateLimit: api.RateLimit{
+			PerSecond: getEnvFloat("HEAVY_RATE_LIMIT_RPS", 1),
+			Burst:     getEnvInt("HEAVY_RATE_LIMIT_BURST", 5),
+		},
+		InformerResync:     getEnvDuration("INFORMER_RESYNC", 10*time.Minute),
+		Namespaces:         getNamespaces(),
+		StreamAnomalies:    getEnvBool("STREAM_ANOMALIES", true),
+		AllowConfigUpdates: getEnvBool("ALLOW_CONFIG_UPDATES", false),
+		ClusterName:        getEnv("CLUSTER_NAME", "default"),
+		FederationToken:    os.Getenv("FEDERATION_TOKEN"),
 	}
 
-	log.Printf("Configuration loaded: port=%s, log_level=%s, update_interval=%s",
-		config.Port, config.LogLevel, config.UpdateInterval)
-
 	return config
 }
 
+// newLogger builds a text logger that drops messages below the given level ("debug", "info",
+// "warn" or "error"); unknown levels fall back to info
+func newLogger(level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: lvl}))
+}
+
 // getEnv gets an environment variable with a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -130,7 +169,40 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
-		log.Printf("Warning: invalid duration for %s: %s, using default: %s", key, value, defaultValue)
+		slog.Warn("Invalid duration, using default", "key", key, "value", value, "default", defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvBool gets a boolean from environment variable with a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+		slog.Warn("Invalid boolean, using default", "key", key, "value", value, "default", defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvFloat gets a float from environment variable with a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+		slog.Warn("Invalid number, using default", "key", key, "value", value, "default", defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvInt gets an integer from environment variable with a default value
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+		slog.Warn("Invalid integer, using default", "key", key, "value", value, "default", defaultValue)
 	}
 	return defaultValue
 }
@@ -0,0 +1,156 @@
+package k8s
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ClusterCache serves namespaces, nodes, pods, deployments and ReplicaSets from shared informers,
+// so that cluster-wide listings read a local copy kept current by watches instead of listing
+// every namespace on the API server per request
+type ClusterCache struct {
+	factory     informers.SharedInformerFactory
+	namespaces  corelisters.NamespaceLister
+	nodes       corelisters.NodeLister
+	pods        corelisters.PodLister
+	deployments appslisters.DeploymentLister
+	replicaSets appslisters.ReplicaSetLister
+	synced      []cache.InformerSynced
+}
+
+// NewClusterCache creates a cache over the client's cluster, resyncing informers every resync
+func (c *Client) NewClusterCache(resync time.Duration) *ClusterCache {
+	factory := informers.NewSharedInformerFactory(c.Clientset, resync)
+
+	namespaces := factory.Core().V1().Namespaces()
+	nodes := factory.Core().V1().Nodes()
+	pods := factory.Core().V1().Pods()
+	deployments := factory.Apps().V1().Deployments()
+	replicaSets := factory.Apps().V1().ReplicaSets()
+
+	return &ClusterCache{
+		factory:     factory,
+		namespaces:  namespaces.Lister(),
+		nodes:       nodes.Lister(),
+		pods:        pods.Lister(),
+		deployments: deployments.Lister(),
+		replicaSets: replicaSets.Lister(),
+		synced: []cache.InformerSynced{
+			namespaces.Informer().HasSynced,
+			nodes.Informer().HasSynced,
+			pods.Informer().HasSynced,
+			deployments.Informer().HasSynced,
+			replicaSets.Informer().HasSynced,
+		},
+	}
+}
+
+// Start starts the informers and blocks until their initial listings are cached or stopCh closes
+func (cc *ClusterCache) Start(stopCh <-chan struct{}) error {
+	cc.factory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, cc.synced...) {
+		return fmt.Errorf("cluster cache stopped before syncing")
+	}
+	return nil
+}
+
+// HasSynced reports whether every informer has completed its initial listing
+func (cc *ClusterCache) HasSynced() bool {
+	for _, synced := range cc.synced {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Namespaces returns the cached namespaces
+func (cc *ClusterCache) Namespaces() ([]corev1.Namespace, error) {
+	cached, err := cc.namespaces.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]corev1.Namespace, len(cached))
+	for i, namespace := range cached {
+		namespaces[i] = *namespace
+	}
+	return namespaces, nil
+}
+
+// Nodes returns the cached nodes
+func (cc *ClusterCache) Nodes() ([]corev1.Node, error) {
+	cached, err := cc.nodes.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]corev1.Node, len(cached))
+	for i, node := range cached {
+		nodes[i] = *node
+	}
+	return nodes, nil
+}
+
+// Pods returns the cached pods in a namespace
+func (cc *ClusterCache) Pods(namespace string) ([]corev1.Pod, error) {
+	cached, err := cc.pods.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return podValues(cached), nil
+}
+
+// Deployments returns the cached deployments in a namespace
+func (cc *ClusterCache) Deployments(namespace string) ([]appsv1.Deployment, error) {
+	cached, err := cc.deployments.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]appsv1.Deployment, len(cached))
+	for i, deployment := range cached {
+		deployments[i] = *deployment
+	}
+	return deployments, nil
+}
+
+// DeploymentPods returns the cached pods owned by a deployment, matched through its ReplicaSets
+// like Client.DeploymentPods
+func (cc *ClusterCache) DeploymentPods(deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	cachedReplicaSets, err := cc.replicaSets.ReplicaSets(deployment.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	replicaSets := make([]appsv1.ReplicaSet, len(cachedReplicaSets))
+	for i, rs := range cachedReplicaSets {
+		replicaSets[i] = *rs
+	}
+
+	cachedPods, err := cc.pods.Pods(deployment.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return controlledPods(deployment, replicaSets, podValues(cachedPods)), nil
+}
+
+// podValues copies cached pod pointers into a slice of values
+func podValues(cached []*corev1.Pod) []corev1.Pod {
+	pods := make([]corev1.Pod, len(cached))
+	for i, pod := range cached {
+		pods[i] = *pod
+	}
+	return pods
+}
@@ -11,9 +11,9 @@ import (
 
 // Client wraps Kubernetes clients
 type Client struct {
-	Clientset       *kubernetes.Clientset
-	MetricsClient   *versioned.Clientset
-	Config          *rest.Config
+	Clientset     kubernetes.Interface
+	MetricsClient versioned.Interface
+	Config        *rest.Config
 }
 
 // NewClient creates a new Kubernetes client
@@ -0,0 +1,139 @@
+package k8s
+
+import (
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// HPAMinReplicas returns the lower replica bound of an HPA. The field is optional and defaults to 1
+// when unset.
+func HPAMinReplicas(hpa *autoscalingv2.HorizontalPodAutoscaler) int32 {
+	if hpa.Spec.MinReplicas == nil {
+		return 1
+	}
+	return *hpa.Spec.MinReplicas
+}
+
+// HPAMetricTargets returns every metric an HPA scales on with its target, paired with the current
+// value the HPA last reported for the same metric
+func HPAMetricTargets(hpa *autoscalingv2.HorizontalPodAutoscaler) []models.HPAMetricTarget {
+	targets := make([]models.HPAMetricTarget, 0, len(hpa.Spec.Metrics))
+	for _, spec := range hpa.Spec.Metrics {
+		name, target, ok := metricSpecTarget(spec)
+		if !ok {
+			continue
+		}
+
+		entry := models.HPAMetricTarget{
+			Type:       string(spec.Type),
+			Name:       name,
+			TargetType: string(target.Type),
+			Target:     metricTargetValue(target),
+		}
+		for _, status := range hpa.Status.CurrentMetrics {
+			if statusName, current, ok := metricStatusCurrent(status); ok && status.Type == spec.Type && statusName == name {
+				entry.Current = metricCurrentValue(current, target.Type)
+				break
+			}
+		}
+		targets = append(targets, entry)
+	}
+	return targets
+}
+
+// HPAMetricName returns the name identifying a metric spec: the resource for resource metrics,
+// otherwise the custom metric's name
+func HPAMetricName(spec autoscalingv2.MetricSpec) string {
+	name, _, _ := metricSpecTarget(spec)
+	return name
+}
+
+// HPAMetricTarget returns a pointer to the target of a metric spec, or nil for unknown source types
+func HPAMetricTarget(spec *autoscalingv2.MetricSpec) *autoscalingv2.MetricTarget {
+	switch {
+	case spec.Resource != nil:
+		return &spec.Resource.Target
+	case spec.ContainerResource != nil:
+		return &spec.ContainerResource.Target
+	case spec.Pods != nil:
+		return &spec.Pods.Target
+	case spec.Object != nil:
+		return &spec.Object.Target
+	case spec.External != nil:
+		return &spec.External.Target
+	}
+	return nil
+}
+
+// metricSpecTarget returns the name and target of a metric spec
+func metricSpecTarget(spec autoscalingv2.MetricSpec) (string, autoscalingv2.MetricTarget, bool) {
+	switch {
+	case spec.Resource != nil:
+		return string(spec.Resource.Name), spec.Resource.Target, true
+	case spec.ContainerResource != nil:
+		return string(spec.ContainerResource.Name), spec.ContainerResource.Target, true
+	case spec.Pods != nil:
+		return spec.Pods.Metric.Name, spec.Pods.Target, true
+	case spec.Object != nil:
+		return spec.Object.Metric.Name, spec.Object.Target, true
+	case spec.External != nil:
+		return spec.External.Metric.Name, spec.External.Target, true
+	}
+	return "", autoscalingv2.MetricTarget{}, false
+}
+
+// metricStatusCurrent returns the name and current value of a metric status
+func metricStatusCurrent(status autoscalingv2.MetricStatus) (string, autoscalingv2.MetricValueStatus, bool) {
+	switch {
+	case status.Resource != nil:
+		return string(status.Resource.Name), status.Resource.Current, true
+	case status.ContainerResource != nil:
+		return string(status.ContainerResource.Name), status.ContainerResource.Current, true
+	case status.Pods != nil:
+		return status.Pods.Metric.Name, status.Pods.Current, true
+	case status.Object != nil:
+		return status.Object.Metric.Name, status.Object.Current, true
+	case status.External != nil:
+		return status.External.Metric.Name, status.External.Current, true
+	}
+	return "", autoscalingv2.MetricValueStatus{}, false
+}
+
+// metricTargetValue returns a target as a number: the utilization percentage or the quantity
+func metricTargetValue(target autoscalingv2.MetricTarget) float64 {
+	switch target.Type {
+	case autoscalingv2.UtilizationMetricType:
+		if target.AverageUtilization != nil {
+			return float64(*target.AverageUtilization)
+		}
+	case autoscalingv2.AverageValueMetricType:
+		return quantityValue(target.AverageValue)
+	case autoscalingv2.ValueMetricType:
+		return quantityValue(target.Value)
+	}
+	return 0
+}
+
+// metricCurrentValue returns the current value in the unit of the given target type
+func metricCurrentValue(current autoscalingv2.MetricValueStatus, targetType autoscalingv2.MetricTargetType) float64 {
+	switch targetType {
+	case autoscalingv2.UtilizationMetricType:
+		if current.AverageUtilization != nil {
+			return float64(*current.AverageUtilization)
+		}
+	case autoscalingv2.AverageValueMetricType:
+		return quantityValue(current.AverageValue)
+	case autoscalingv2.ValueMetricType:
+		return quantityValue(current.Value)
+	}
+	return 0
+}
+
+// quantityValue returns a quantity as a float, or 0 when unset
+func quantityValue(quantity *resource.Quantity) float64 {
+	if quantity == nil {
+		return 0
+	}
+	return quantity.AsApproximateFloat64()
+}
@@ -0,0 +1,55 @@
+package k8s
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DeploymentPods returns the pods owned by a deployment. Deployments own pods through
+// ReplicaSets, so pods are matched by their controlling ReplicaSet rather than by labels
+// alone, which keeps deployments that share labels (e.g. canary and stable) apart.
+func (c *Client) DeploymentPods(ctx context.Context, deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	selector := metav1.FormatLabelSelector(deployment.Spec.Selector)
+
+	rsList, err := c.Clientset.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	podList, err := c.Clientset.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return controlledPods(deployment, rsList.Items, podList.Items), nil
+}
+
+// controlledPods returns the pods controlled by those of the ReplicaSets the deployment controls
+func controlledPods(deployment *appsv1.Deployment, replicaSets []appsv1.ReplicaSet, candidates []corev1.Pod) []corev1.Pod {
+	owned := make(map[types.UID]bool)
+	for i := range replicaSets {
+		if metav1.IsControlledBy(&replicaSets[i], deployment) {
+			owned[replicaSets[i].UID] = true
+		}
+	}
+
+	pods := make([]corev1.Pod, 0, len(candidates))
+	if len(owned) == 0 {
+		return pods
+	}
+	for _, pod := range candidates {
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owned[owner.UID] {
+			pods = append(pods, pod)
+		}
+	}
+
+	return pods
+}
@@ -0,0 +1,11 @@
+package k8s
+
+// DesiredReplicas returns the replica count of a Deployment or StatefulSet spec. The field is
+// optional and the API server defaults it to 1, but objects built elsewhere (fakes, manifests not
+// yet applied) may leave it nil.
+func DesiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
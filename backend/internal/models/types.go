@@ -1,14 +1,25 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // PodMetrics represents resource usage metrics for a pod
 type PodMetrics struct {
-	Name      string
-	Namespace string
-	CPU       int64 // millicores
-	Memory    int64 // bytes
-	Timestamp time.Time
+	Name       string
+	Namespace  string
+	CPU        int64 // millicores
+	Memory     int64 // bytes
+	Containers []ContainerMetrics
+	Timestamp  time.Time
+}
+
+// ContainerMetrics represents resource usage metrics for a single container in a pod
+type ContainerMetrics struct {
+	Name   string
+	CPU    int64 // millicores
+	Memory int64 // bytes
 }
 
 // NodeMetrics represents resource usage metrics for a node
@@ -21,15 +32,27 @@ type NodeMetrics struct {
 
 // HPAMetrics represents HPA status and metrics
 type HPAMetrics struct {
-	Name             string
-	Namespace        string
-	CurrentReplicas  int32
-	DesiredReplicas  int32
-	MinReplicas      int32
-	MaxReplicas      int32
-	TargetCPU        int32
-	CurrentCPU       int32
-	Timestamp        time.Time
+	Name            string
+	Namespace       string
+	CurrentReplicas int32
+	DesiredReplicas int32
+	MinReplicas     int32
+	MaxReplicas     int32
+	TargetCPU       int32
+	CurrentCPU      int32
+	TargetMemory    int32 // target average memory utilization, 0 if the HPA doesn't scale on memory
+	CurrentMemory   int32
+	Metrics         []HPAMetricTarget // every metric the HPA scales on, including custom and external metrics
+	Timestamp       time.Time
+}
+
+// HPAMetricTarget is one metric an HPA scales on, with its target and the latest observed value
+type HPAMetricTarget struct {
+	Type       string  // autoscaling/v2 metric source type: "Resource", "ContainerResource", "Pods", "Object" or "External"
+	Name       string  // resource name such as "cpu" or "memory", or the custom metric's name
+	TargetType string  // "Utilization", "AverageValue" or "Value"
+	Target     float64 // utilization percentage for Utilization targets, otherwise the target quantity
+	Current    float64 // latest observed value in the same unit as Target; 0 until the HPA reports it
 }
 
 // TimeSeriesData represents a time series of metric values
@@ -43,30 +66,90 @@ type TimeSeriesData struct {
 type DataPoint struct {
 	Timestamp time.Time
 	Value     float64
+	Gap       bool // sentinel marking missing data; Value is meaningless
+}
+
+// MarshalJSON encodes gap markers with a null value so that charts break the
+// line instead of interpolating across missing data
+func (p DataPoint) MarshalJSON() ([]byte, error) {
+	if !p.Gap {
+		return json.Marshal(struct {
+			Timestamp time.Time
+			Value     float64
+		}{p.Timestamp, p.Value})
+	}
+
+	return json.Marshal(struct {
+		Timestamp time.Time
+		Value     *float64
+		Gap       bool
+	}{p.Timestamp, nil, true})
 }
 
 // Analysis represents the analysis result for a deployment
 type Analysis struct {
-	Namespace   string
-	Deployment  string
-	CPUUsage    ResourceAnalysis
-	MemoryUsage ResourceAnalysis
-	Replicas    ReplicaAnalysis
-	HealthScore float64
-	Timestamp   time.Time
+	Namespace        string
+	Deployment       string // workload name, whatever its kind
+	Kind             string // Deployment, StatefulSet or DaemonSet
+	CPUUsage         ResourceAnalysis
+	MemoryUsage      ResourceAnalysis
+	Replicas         ReplicaAnalysis
+	HealthScore      float64
+	RestartCount     int32
+	RestartBreakdown map[string]int // restarts by cause: OOMKilled, Error, Completed, CrashLoopBackOff, ...
+	OOMKillCount     int32          // containers last terminated by the OOM killer
+	Protected        bool           // matched a protected workload pattern; analyzed for health only
+	LatencyCritical  bool           // annotated or named latency-critical; recommended Guaranteed QoS
+	QoSClass         string         // QoS class of the pods: Guaranteed, Burstable or BestEffort
+	CPUThrottleRatio float64        // share of CFS periods throttled at the CPU limit; 0 without throttling data
+	Provisional      bool           // based on less history than normally required; treat with caution
+	TooNew           bool           // created too recently to analyze; only the configuration is reported
+	Window           time.Duration  // time window the analysis covers
+	DataPointCount   int            // CPU samples the analysis is based on
+	Confidence       float64        // 0-1; discounted for churn, few pods and samples spanning little of the window
+	Trends           TrendAnalysis  // per-pod usage trends over the window
+	Timestamp        time.Time
+}
+
+// TrendAnalysis holds the linear trends of per-pod CPU and memory usage
+type TrendAnalysis struct {
+	CPU    ResourceTrend
+	Memory ResourceTrend
+}
+
+// ResourceTrend is the linear trend of a resource's usage over an analysis window
+type ResourceTrend struct {
+	SlopePerDay   float64 // change per day, in millicores or bytes
+	PercentPerDay float64 // SlopePerDay as a percentage of average usage
+	Direction     string  // "increasing", "decreasing" or "stable"
+	RSquared      float64 // 0-1 fit of the trend line; low values mean the slope is mostly noise
+}
+
+// ContainerAnalysis represents the analysis of a single container across a deployment's pods
+type ContainerAnalysis struct {
+	Namespace       string
+	Deployment      string
+	Container       string
+	CPUUsage        ResourceAnalysis
+	MemoryUsage     ResourceAnalysis
+	Provisional     bool
+	Recommendations []Recommendation
+	Timestamp       time.Time
 }
 
 // ResourceAnalysis represents analysis of CPU or memory usage
 type ResourceAnalysis struct {
-	Requested     int64
-	Current       int64
-	P50           int64
-	P95           int64
-	P99           int64
-	Average       int64
-	Max           int64
-	Utilization   float64 // percentage
-	Efficiency    float64 // 0-100 score
+	Requested   int64
+	Overhead    int64 // per-pod RuntimeClass overhead on top of Requested
+	Effective   int64 // Requested + Overhead; what each pod reserves on its node
+	Current     int64
+	P50         int64
+	P95         int64
+	P99         int64
+	Average     int64
+	Max         int64
+	Utilization float64 // percentage
+	Efficiency  float64 // 0-100 score
 }
 
 // ReplicaAnalysis represents analysis of replica usage
@@ -79,17 +162,87 @@ type ReplicaAnalysis struct {
 
 // Recommendation represents an optimization recommendation
 type Recommendation struct {
-	ID              string
-	Type            string // "resource", "hpa", "scaling"
-	Namespace       string
-	Deployment      string
-	Priority        string // "high", "medium", "low"
-	Description     string
-	CurrentConfig   interface{}
-	RecommendedConfig interface{}
-	EstimatedSavings float64
-	Impact          string
-	CreatedAt       time.Time
+	ID                 string
+	Type               string // "resource", "hpa", "scaling"
+	Namespace          string
+	Deployment         string // workload name, whatever its kind
+	Kind               string // Deployment, StatefulSet or DaemonSet; empty means Deployment
+	Container          string // set when the recommendation targets a single container
+	Priority           string // "high", "medium", "low"
+	Description        string
+	CurrentConfig      interface{}
+	RecommendedConfig  interface{}
+	EstimatedSavings   float64
+	CashSavings        float64 // part of EstimatedSavings that reduces the bill beyond committed capacity
+	ReclaimableSavings float64 // part of EstimatedSavings absorbed by committed capacity; frees capacity only
+	BreakEvenDays      float64 // days of EstimatedSavings needed to recover the cost of applying; 0 without savings
+	Impact             string
+	Risk               string   // "low", "medium", "high"
+	ImpactScore        float64  // 0-100 composite of savings, risk and health improvement, used for ranking
+	Confidence         float64  // 0-1 confidence in the analysis behind the recommendation
+	Rationale          []string // the concrete values and thresholds that triggered the recommendation
+	ConflictsWith      []string // IDs of recommendations that over-shoot when applied together with this one
+	CreatedAt          time.Time
+	AppliedAt          time.Time   // zero until the recommendation is applied to the cluster
+	PreviousConfig     interface{} // live configuration captured just before applying, used to revert
+	AppliedConfig      interface{} // live configuration captured just after applying, used to detect drift
+	RevertedAt         time.Time   // zero unless an applied recommendation was reverted
+}
+
+// RecommendationPatch describes the change applying a recommendation makes to a Kubernetes object
+type RecommendationPatch struct {
+	RecommendationID string
+	Kind             string // "Deployment" or "HorizontalPodAutoscaler"
+	Namespace        string
+	Name             string
+	PatchType        string // Kubernetes patch content type
+	Patch            string // JSON patch body
+	DryRun           bool   // the patch was computed but not sent
+	AppliedAt        time.Time
+}
+
+// HPASimulation represents the projected behavior of a proposed HPA configuration
+// replayed against a deployment's historical CPU usage
+type HPASimulation struct {
+	Namespace            string
+	Deployment           string
+	MinReplicas          int32
+	MaxReplicas          int32
+	TargetCPU            int32
+	Trajectory           []SimulatedReplicas
+	ScalingEvents        int
+	AverageReplicas      float64
+	PeakReplicas         int32
+	CurrentMonthlyCost   float64 // cost of the deployment at its current replica count
+	EstimatedMonthlyCost float64 // cost at the simulated average replica count
+	Timestamp            time.Time
+}
+
+// WhatIfSimulation projects the cluster's monthly cost if a set of recommendations were applied
+type WhatIfSimulation struct {
+	RecommendationIDs    []string // recommendations counted in the projection, in request order
+	CurrentMonthlyCost   float64  // requested resources of every analyzed deployment
+	ProjectedMonthlyCost float64  // current cost less the savings of the counted recommendations
+	Delta                float64  // projected minus current; negative when the set saves money
+	Conflicts            []WhatIfConflict
+	Timestamp            time.Time
+}
+
+// WhatIfConflict explains a recommendation of a what-if simulation that was not counted, or that
+// over-shoots when applied together with another
+type WhatIfConflict struct {
+	RecommendationID string
+	ConflictsWith    string // ID of the other recommendation, if any
+	Reason           string
+	Counted          bool // whether the recommendation's savings are included in the projection
+}
+
+// SimulatedReplicas represents the simulated replica count at one point of an HPA simulation
+type SimulatedReplicas struct {
+	Timestamp   time.Time
+	CPUUsage    int64   // millicores summed across pods
+	Utilization float64 // percentage of requested CPU before scaling
+	Replicas    int32
 }
 
 // TrafficAnalysis represents traffic pattern analysis
@@ -103,6 +256,11 @@ type TrafficAnalysis struct {
 	P99Latency    float64
 	Anomalies     []Anomaly
 	Timestamp     time.Time
+
+	// RequestRateSource and LatencySource tell whether the figures were measured from recorded
+	// traffic metrics ("measured") or estimated from CPU usage ("cpu_estimate")
+	RequestRateSource string
+	LatencySource     string
 }
 
 // Anomaly represents a detected anomaly
@@ -110,6 +268,9 @@ type Anomaly struct {
 	Type        string
 	Severity    string
 	Description string
+	Resource    string
+	Metric      string
+	Fingerprint string // stable identity of the anomaly across detections, used to acknowledge it
 	DetectedAt  time.Time
 	Value       float64
 	Expected    float64
@@ -121,21 +282,153 @@ type CostBreakdown struct {
 	Namespace       string
 	CPUCost         float64
 	MemoryCost      float64
+	StorageCost     float64 // PersistentVolumeClaims mounted by the service's pods
+	NetworkCost     float64 // estimated egress
 	TotalCost       float64
 	WastedCost      float64
 	EfficiencyScore float64
 	Timestamp       time.Time
 }
 
+// NamespaceCost represents the summed cost of every service in a namespace
+type NamespaceCost struct {
+	Namespace  string
+	TotalCost  float64
+	WastedCost float64
+	Services   []CostBreakdown
+	Timestamp  time.Time
+}
+
 // ResourcePrediction represents predicted resource needs
 type ResourcePrediction struct {
-	Service       string
-	Namespace     string
-	Hours         int
-	PredictedCPU  int64
+	Service         string
+	Namespace       string
+	Hours           int
+	PredictedCPU    int64
 	PredictedMemory int64
-	Confidence    float64
-	Timestamp     time.Time
+	// Best and worst case bounds around the predictions, widening with the horizon; size for the
+	// high bounds to cover the worst case
+	PredictedCPULow     int64
+	PredictedCPUHigh    int64
+	PredictedMemoryLow  int64
+	PredictedMemoryHigh int64
+	Confidence          float64
+	Seasonal            bool          // a repeating pattern was detected and predictions follow its phase
+	SeasonalPeriod      time.Duration // length of the detected pattern, e.g. 24h
+	Timestamp           time.Time
+}
+
+// QuotaPressure represents how close a namespace is to its ResourceQuota limits
+type QuotaPressure struct {
+	Namespace      string
+	Resources      []QuotaResourceUsage
+	MaxUtilization float64 // highest used/hard ratio across all quota resources
+	NearLimit      bool
+	Timestamp      time.Time
+}
+
+// QuotaResourceUsage represents usage of a single resource tracked by a ResourceQuota
+type QuotaResourceUsage struct {
+	Quota       string
+	Resource    string
+	Hard        int64 // millicores for CPU resources, bytes for memory, otherwise a count
+	Used        int64
+	Headroom    int64
+	Utilization float64 // 0-1 ratio of used to hard
+}
+
+// DeploymentSummary represents the latest cached figures for a deployment
+type DeploymentSummary struct {
+	Namespace           string
+	Deployment          string
+	Analyzed            bool    // false when only recommendations are known; scores and costs are then unset
+	EfficiencyScore     float64 // 0-100
+	MonthlyCost         float64 // cost of the requested resources across all replicas
+	WastedCost          float64 // monthly cost of requested but unused resources across all replicas
+	RecommendationCount int     // recommendations not yet applied
+	Timestamp           time.Time
+}
+
+// NodeSizingRecommendation suggests a cheaper node mix for a consistently under-used node pool
+type NodeSizingRecommendation struct {
+	InstanceType            string // current instance type; "unknown" for unlabeled nodes
+	CurrentNodes            int
+	CPUUtilization          float64 // P95 CPU usage over pool schedulable capacity (0-1)
+	MemoryUtilization       float64 // P95 memory usage over pool schedulable capacity (0-1)
+	DaemonSetCPU            int64   // millicores reserved per node by DaemonSet pods
+	DaemonSetMemory         int64   // bytes reserved per node by DaemonSet pods
+	CPUHeadroom             int64   // millicores schedulable across the pool beyond P95 usage
+	MemoryHeadroom          int64   // bytes schedulable across the pool beyond P95 usage
+	RecommendedInstanceType string
+	RecommendedNodes        int
+	CurrentMonthlyCost      float64
+	RecommendedMonthlyCost  float64
+	EstimatedSavings        float64 // monthly
+	Rationale               []string
+	Timestamp               time.Time
+}
+
+// NodeConsolidation estimates how many nodes the cluster's pod requests need when bin-packed onto
+// as few nodes as possible, and which nodes could then be drained
+type NodeConsolidation struct {
+	CurrentNodes       int // schedulable nodes
+	TargetNodes        int
+	CPURequested       int64 // millicores requested by movable pods
+	MemoryRequested    int64 // bytes requested by movable pods
+	Candidates         []ConsolidationCandidate
+	CurrentMonthlyCost float64
+	TargetMonthlyCost  float64
+	EstimatedSavings   float64 // monthly
+	Rationale          []string
+	Timestamp          time.Time
+}
+
+// ConsolidationCandidate is a node whose pods fit on the remaining nodes
+type ConsolidationCandidate struct {
+	Node            string
+	InstanceType    string
+	Pods            int   // movable pods to reschedule
+	CPURequested    int64 // millicores requested by those pods
+	MemoryRequested int64 // bytes requested by those pods
+	MonthlyCost     float64
+}
+
+// IdleWorkload is a workload whose CPU stays near zero and whose memory is flat, with what it costs
+type IdleWorkload struct {
+	Namespace        string
+	Deployment       string // workload name, whatever its kind
+	Kind             string
+	Replicas         int32
+	CPUP95           int64         // millicores per pod
+	MemoryAverage    int64         // bytes per pod
+	MonthlyCost      float64       // cost of all replicas, saved by scaling to zero
+	Window           time.Duration // time window the usage covers
+	RecommendationID string        // the idle recommendation, if it was not dismissed
+}
+
+// VPARecommendation holds Vertical Pod Autoscaler style bounds for each container of a deployment
+type VPARecommendation struct {
+	Namespace  string
+	Deployment string
+	Containers []VPAContainerRecommendation
+	Buffer     float64 // multiplier applied to P95 and P99 usage for the target and upper bound
+	Timestamp  time.Time
+}
+
+// VPAContainerRecommendation is the recommendation for one container: lower bound = P50 usage,
+// target = P95 usage x buffer, upper bound = P99 usage x buffer
+type VPAContainerRecommendation struct {
+	Container   string
+	LowerBound  VPAResources
+	Target      VPAResources
+	UpperBound  VPAResources
+	Provisional bool // based on fewer data points than normally required
+}
+
+// VPAResources is a CPU and memory amount
+type VPAResources struct {
+	CPU    int64 // millicores
+	Memory int64 // bytes
 }
 
 // ClusterOverview represents overall cluster status
@@ -0,0 +1,27 @@
+package parallel
+
+import "sync"
+
+// ForEach calls fn for every index in [0, n) with at most concurrency calls running at once, and
+// returns when all have finished. A concurrency below 1 runs one call at a time. Callers collect
+// results by writing slot i of a slice sized n, which needs no locking.
+func ForEach(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
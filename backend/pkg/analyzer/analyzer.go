@@ -1,7 +1,9 @@
 package analyzer
 
 import (
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
 )
 
 // New creates a new analyzer with default configuration
@@ -13,6 +15,29 @@ func New(client collector.MetricsCollector) Analyzer {
 func NewWithConfig(client collector.MetricsCollector, config Config) Analyzer {
 	return &analyzer{
 		client: client,
-		config: config,
+		config: withDefaultPricing(config),
 	}
 }
+
+// NewWithClient creates a new analyzer that can also query the Kubernetes API,
+// which is required for cluster-aware features such as fractional node cost
+func NewWithClient(client collector.MetricsCollector, k8sClient *k8s.Client, config Config) Analyzer {
+	return &analyzer{
+		client:    client,
+		k8sClient: k8sClient,
+		config:    withDefaultPricing(config),
+	}
+}
+
+// GetConfig returns the analyzer configuration
+func (a *analyzer) GetConfig() Config {
+	return a.config
+}
+
+// withDefaultPricing fills in the default pricing provider when none is configured
+func withDefaultPricing(config Config) Config {
+	if config.Pricing == nil {
+		config.Pricing = pricing.DefaultProvider()
+	}
+	return config
+}
@@ -1,16 +1,30 @@
 package analyzer
 
 import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 // mockCollector is a mock implementation of MetricsCollector for testing
 type mockCollector struct {
 	timeSeriesData map[string]models.TimeSeriesData
 	percentiles    map[string]percentileData
+	podMetrics     []models.PodMetrics
 }
 
 type percentileData struct {
@@ -26,10 +40,16 @@ func newMockCollector() *mockCollector {
 	}
 }
 
-func (m *mockCollector) Start() error                                      { return nil }
-func (m *mockCollector) Stop()                                             {}
+func (m *mockCollector) Start() error { return nil }
+func (m *mockCollector) Stop()        {}
 func (m *mockCollector) CollectPodMetrics(namespace string) ([]models.PodMetrics, error) {
-	return []models.PodMetrics{}, nil
+	var pods []models.PodMetrics
+	for _, pod := range m.podMetrics {
+		if pod.Namespace == namespace {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
 }
 func (m *mockCollector) CollectNodeMetrics() ([]models.NodeMetrics, error) {
 	return []models.NodeMetrics{}, nil
@@ -50,6 +70,10 @@ func (m *mockCollector) GetTimeSeriesData(resource, metric string, duration time
 	}, nil
 }
 
+func (m *mockCollector) GetTimeSeriesDataWithGaps(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
+	return m.GetTimeSeriesData(resource, metric, duration)
+}
+
 func (m *mockCollector) GetResourcePercentiles(resource, metric string, duration time.Duration) (p50, p95, p99 float64, err error) {
 	key := resource + "/" + metric
 	if data, ok := m.percentiles[key]; ok {
@@ -81,13 +105,12 @@ func TestNew(t *testing.T) {
 func TestNewWithConfig(t *testing.T) {
 	mc := newMockCollector()
 	config := Config{
-		CPUCostPerVCPUHour:  0.05,
-		MemoryCostPerGBHour: 0.006,
-		AnomalyThreshold:    2.5,
-		SpikeThreshold:      1.5,
-		DropThreshold:       0.6,
-		MinDataPoints:       5,
-		TrendHistoryDays:    10,
+		Pricing:          pricing.NewStaticProvider(pricing.Rates{CPUCostPerVCPUHour: 0.05, MemoryCostPerGBHour: 0.006}, nil),
+		AnomalyThreshold: 2.5,
+		SpikeThreshold:   1.5,
+		DropThreshold:    0.6,
+		MinDataPoints:    5,
+		TrendHistoryDays: 10,
 	}
 
 	an := NewWithConfig(mc, config)
@@ -95,10 +118,16 @@ func TestNewWithConfig(t *testing.T) {
 		t.Fatal("Expected non-nil analyzer")
 	}
 
+	// A config without a pricing provider gets the default rates
+	defaulted := NewWithConfig(mc, Config{}).(*analyzer)
+	if rate := defaulted.config.Pricing.MemoryCostPerGBHour(""); rate != 0.004 {
+		t.Errorf("Expected default memory cost 0.004, got %f", rate)
+	}
+
 	// Verify it's using custom config
 	analyzer := an.(*analyzer)
-	if analyzer.config.CPUCostPerVCPUHour != 0.05 {
-		t.Errorf("Expected CPU cost 0.05, got %f", analyzer.config.CPUCostPerVCPUHour)
+	if rate := analyzer.config.Pricing.CPUCostPerVCPUHour(""); rate != 0.05 {
+		t.Errorf("Expected CPU cost 0.05, got %f", rate)
 	}
 }
 
@@ -106,12 +135,12 @@ func TestNewWithConfig(t *testing.T) {
 func TestDefaultConfig(t *testing.T) {
 	config := DefaultConfig()
 
-	if config.CPUCostPerVCPUHour != 0.03 {
-		t.Errorf("Expected CPU cost 0.03, got %f", config.CPUCostPerVCPUHour)
+	if rate := config.Pricing.CPUCostPerVCPUHour(""); rate != 0.03 {
+		t.Errorf("Expected CPU cost 0.03, got %f", rate)
 	}
 
-	if config.MemoryCostPerGBHour != 0.004 {
-		t.Errorf("Expected memory cost 0.004, got %f", config.MemoryCostPerGBHour)
+	if rate := config.Pricing.MemoryCostPerGBHour(""); rate != 0.004 {
+		t.Errorf("Expected memory cost 0.004, got %f", rate)
 	}
 
 	if config.AnomalyThreshold != 3.0 {
@@ -169,6 +198,52 @@ func TestAnalyzeTrafficPatterns(t *testing.T) {
 	}
 }
 
+// TestAnalyzeTrafficPatternsMeasured tests that recorded request and latency metrics are preferred
+// over the CPU estimate and that the source of each figure is reported
+func TestAnalyzeTrafficPatternsMeasured(t *testing.T) {
+	mc := newMockCollector()
+	an := New(mc)
+
+	now := time.Now()
+	var cpu, requests, latency []models.DataPoint
+	for i := 0; i < 20; i++ {
+		ts := now.Add(-time.Duration(20-i) * time.Minute)
+		cpu = append(cpu, models.DataPoint{Timestamp: ts, Value: 500})
+		requests = append(requests, models.DataPoint{Timestamp: ts, Value: 120})
+		latency = append(latency, models.DataPoint{Timestamp: ts, Value: float64(20 + i)})
+	}
+	mc.addTimeSeriesData("pod/nginx", "cpu", cpu)
+
+	// CPU only: both figures are estimates
+	traffic, err := an.AnalyzeTrafficPatterns("default", "nginx", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to analyze traffic: %v", err)
+	}
+	if traffic.RequestRateSource != TrafficSourceCPUEstimate || traffic.LatencySource != TrafficSourceCPUEstimate {
+		t.Errorf("Expected CPU estimates, got request rate from %q and latency from %q", traffic.RequestRateSource, traffic.LatencySource)
+	}
+	if traffic.RequestRate != 50 {
+		t.Errorf("Expected estimated request rate of 50 (500m / 10), got %f", traffic.RequestRate)
+	}
+
+	mc.addTimeSeriesData("pod/nginx", collector.MetricRequests, requests)
+	mc.addTimeSeriesData("pod/nginx", collector.MetricLatencyMs, latency)
+
+	traffic, err = an.AnalyzeTrafficPatterns("default", "nginx", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to analyze traffic: %v", err)
+	}
+	if traffic.RequestRateSource != TrafficSourceMeasured || traffic.LatencySource != TrafficSourceMeasured {
+		t.Errorf("Expected measured figures, got request rate from %q and latency from %q", traffic.RequestRateSource, traffic.LatencySource)
+	}
+	if traffic.RequestRate != 120 {
+		t.Errorf("Expected measured request rate of 120, got %f", traffic.RequestRate)
+	}
+	if traffic.P50Latency < 20 || traffic.P99Latency > 39 || traffic.P50Latency >= traffic.P99Latency {
+		t.Errorf("Expected latency percentiles from the 20-39ms samples, got P50 %f and P99 %f", traffic.P50Latency, traffic.P99Latency)
+	}
+}
+
 // TestAnalyzeTrafficPatternsNoData tests with no data
 func TestAnalyzeTrafficPatternsNoData(t *testing.T) {
 	mc := newMockCollector()
@@ -259,6 +334,200 @@ func TestCalculateServiceCostNoData(t *testing.T) {
 	}
 }
 
+// TestServicePodAggregationStaggered tests that pods scraped at different times within each
+// collection pass are summed into the service total rather than reported as single-pod samples
+func TestServicePodAggregationStaggered(t *testing.T) {
+	mc := newMockCollector()
+	start := time.Now().Add(-time.Hour).Truncate(15 * time.Second)
+	staggered := func(offset time.Duration, value float64) []models.DataPoint {
+		points := make([]models.DataPoint, 40)
+		for i := range points {
+			points[i] = models.DataPoint{Timestamp: start.Add(time.Duration(i)*15*time.Second + offset), Value: value}
+		}
+		return points
+	}
+	for i, offset := range []time.Duration{0, 7 * time.Second, 14900 * time.Millisecond} {
+		pod := fmt.Sprintf("web-abc-%d", i)
+		mc.podMetrics = append(mc.podMetrics, models.PodMetrics{Name: pod, Namespace: "default"})
+		mc.addTimeSeriesData("pod/"+pod, "cpu", staggered(offset, 100))
+		mc.addTimeSeriesData("pod/"+pod, "memory", staggered(offset, 256*1024*1024))
+	}
+	an := New(mc).(*analyzer)
+
+	points, err := an.aggregatePodMetrics(an.findPodsByService("default", "web"), "cpu", 2*time.Hour, aggregateSum)
+	if err != nil {
+		t.Fatalf("aggregatePodMetrics failed: %v", err)
+	}
+	if len(points) != 40 {
+		t.Fatalf("Expected one point per collection interval, got %d", len(points))
+	}
+	for _, point := range points {
+		if point.Value != 300 {
+			t.Errorf("Expected every interval to sum all three pods to 300, got %.0f at %s", point.Value, point.Timestamp)
+		}
+	}
+
+	// A pod that goes away stops counting once its last sample is two intervals old
+	mc.addTimeSeriesData("pod/web-abc-2", "cpu", staggered(14900*time.Millisecond, 100)[:20])
+	points, err = an.aggregatePodMetrics(an.findPodsByService("default", "web"), "cpu", 2*time.Hour, aggregateSum)
+	if err != nil {
+		t.Fatalf("aggregatePodMetrics failed: %v", err)
+	}
+	if first, last := points[0].Value, points[len(points)-1].Value; first != 300 || last != 200 {
+		t.Errorf("Expected 300 while all pods run and 200 after one is gone, got %.0f and %.0f", first, last)
+	}
+}
+
+// TestServicePodAggregation tests that service cost and traffic cover every pod of the service, found
+// by their generated names, rather than a series named after the service
+func TestServicePodAggregation(t *testing.T) {
+	mc := newMockCollector()
+	now := time.Now()
+	steady := func(value float64) []models.DataPoint {
+		points := make([]models.DataPoint, 20)
+		for i := range points {
+			points[i] = models.DataPoint{Timestamp: now.Add(-time.Duration(20-i) * time.Minute), Value: value}
+		}
+		return points
+	}
+	for _, pod := range []string{"nginx-abc-123", "nginx-abc-456", "nginx-api-def-789"} {
+		mc.podMetrics = append(mc.podMetrics, models.PodMetrics{Name: pod, Namespace: "default"})
+		mc.addTimeSeriesData("pod/"+pod, "cpu", steady(400))
+		mc.addTimeSeriesData("pod/"+pod, "memory", steady(512*1024*1024))
+	}
+	mc.addTimeSeriesData("pod/nginx-abc-123", collector.MetricLatencyMs, steady(10))
+	mc.addTimeSeriesData("pod/nginx-abc-456", collector.MetricLatencyMs, steady(50))
+	mc.addTimeSeriesData("pod/single", "cpu", steady(800))
+	mc.addTimeSeriesData("pod/single", "memory", steady(1024*1024*1024))
+	an := New(mc).(*analyzer)
+
+	if pods := an.findPodsByService("default", "nginx"); !slices.Equal(pods, []string{"pod/nginx-abc-123", "pod/nginx-abc-456"}) {
+		t.Errorf("Expected the two nginx deployment pods, got %v", pods)
+	}
+
+	// Two pods cost as much as one pod using their combined resources
+	cost, err := an.CalculateServiceCost("default", "nginx")
+	if err != nil {
+		t.Fatalf("CalculateServiceCost failed: %v", err)
+	}
+	single, err := an.CalculateServiceCost("default", "single")
+	if err != nil {
+		t.Fatalf("CalculateServiceCost failed: %v", err)
+	}
+	if cost.TotalCost == 0 || cost.TotalCost != single.TotalCost {
+		t.Errorf("Expected nginx to cost %.2f like one pod with its combined usage, got %.2f", single.TotalCost, cost.TotalCost)
+	}
+
+	// Request rates are summed; latency percentiles span both pods
+	traffic, err := an.AnalyzeTrafficPatterns("default", "nginx", time.Hour)
+	if err != nil {
+		t.Fatalf("AnalyzeTrafficPatterns failed: %v", err)
+	}
+	if traffic.RequestRate != 80 {
+		t.Errorf("Expected a CPU-estimated request rate of 80 from 800m across pods, got %.1f", traffic.RequestRate)
+	}
+	if traffic.P50Latency < 10 || traffic.P99Latency != 50 {
+		t.Errorf("Expected latency percentiles over both pods, got P50 %.1f, P99 %.1f", traffic.P50Latency, traffic.P99Latency)
+	}
+}
+
+// TestCalculateNamespaceCost tests that namespace cost sums the per-service costs of every deployment
+func TestCalculateNamespaceCost(t *testing.T) {
+	mc := newMockCollector()
+	now := time.Now()
+	objects := []runtime.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shop"}},
+	}
+	for i, name := range []string{"web", "api", "idle"} {
+		objects = append(objects, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}})
+		if name == "idle" {
+			continue
+		}
+		var points []models.DataPoint
+		for j := 0; j < 10; j++ {
+			points = append(points, models.DataPoint{Timestamp: now.Add(-time.Duration(j) * time.Minute), Value: float64(200 * (i + 1))})
+		}
+		mc.addTimeSeriesData("pod/"+name, "cpu", points)
+	}
+
+	an := NewWithClient(mc, &k8s.Client{Clientset: fake.NewClientset(objects...)}, DefaultConfig())
+
+	nsCost, err := an.CalculateNamespaceCost("default")
+	if err != nil {
+		t.Fatalf("Failed to calculate namespace cost: %v", err)
+	}
+
+	if len(nsCost.Services) != 3 {
+		t.Fatalf("Expected 3 services in default, got %d", len(nsCost.Services))
+	}
+
+	var total, wasted float64
+	for _, service := range nsCost.Services {
+		cost, err := an.CalculateServiceCost("default", service.Service)
+		if err != nil {
+			t.Fatalf("Failed to calculate cost for %s: %v", service.Service, err)
+		}
+		if service.TotalCost != cost.TotalCost {
+			t.Errorf("Expected %s to cost %.2f as priced on its own, got %.2f", service.Service, cost.TotalCost, service.TotalCost)
+		}
+		total += cost.TotalCost
+		wasted += cost.WastedCost
+	}
+
+	if nsCost.TotalCost <= 0 || math.Abs(nsCost.TotalCost-total) > 0.01 {
+		t.Errorf("Expected total cost %.2f, got %.2f", total, nsCost.TotalCost)
+	}
+	if math.Abs(nsCost.WastedCost-wasted) > 0.01 {
+		t.Errorf("Expected wasted cost %.2f, got %.2f", wasted, nsCost.WastedCost)
+	}
+
+	if _, err := New(mc).CalculateNamespaceCost("default"); err == nil {
+		t.Error("Expected an error without a Kubernetes client")
+	}
+}
+
+// TestGetCostTrends tests that cost trends cover the duration in 6h windows, oldest first,
+// with zero-cost points for windows without data
+func TestGetCostTrends(t *testing.T) {
+	mc := newMockCollector()
+	an := New(mc)
+
+	// Data only in the most recent and the oldest of four windows
+	now := time.Now()
+	cpuPoints := []models.DataPoint{
+		{Timestamp: now.Add(-23 * time.Hour), Value: 400},
+		{Timestamp: now.Add(-22 * time.Hour), Value: 420},
+		{Timestamp: now.Add(-2 * time.Hour), Value: 200},
+		{Timestamp: now.Add(-1 * time.Hour), Value: 210},
+	}
+	mc.addTimeSeriesData("pod/nginx", "cpu", cpuPoints)
+
+	trends, err := an.GetCostTrends("default", "nginx", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to get cost trends: %v", err)
+	}
+
+	if len(trends) != 4 {
+		t.Fatalf("Expected 4 samples for 24h, got %d", len(trends))
+	}
+
+	for i := 1; i < len(trends); i++ {
+		if gap := trends[i].Timestamp.Sub(trends[i-1].Timestamp); gap != 6*time.Hour {
+			t.Errorf("Expected samples 6h apart oldest first, got gap %v at %d", gap, i)
+		}
+	}
+
+	if trends[0].TotalCost <= trends[3].TotalCost {
+		t.Errorf("Expected the oldest window to cost more than the newest, got %f and %f", trends[0].TotalCost, trends[3].TotalCost)
+	}
+
+	for _, i := range []int{1, 2} {
+		if trends[i].TotalCost != 0 || trends[i].EfficiencyScore != 100 {
+			t.Errorf("Expected zero-cost point for empty window %d, got %+v", i, trends[i])
+		}
+	}
+}
+
 // TestDetectAnomalies tests anomaly detection
 func TestDetectAnomalies(t *testing.T) {
 	mc := newMockCollector()
@@ -320,6 +589,369 @@ func TestDetectAnomaliesNoData(t *testing.T) {
 	}
 }
 
+// TestDetectAnomaliesMethods tests method selection and that a spike reported by both the
+// Z-score and spike detectors is collapsed into one anomaly
+func TestDetectAnomaliesMethods(t *testing.T) {
+	mc := newMockCollector()
+	an := New(mc)
+
+	now := time.Now()
+	values := []float64{100, 105, 102, 98, 103, 500, 101, 99, 104, 100, 102}
+	points := make([]models.DataPoint, len(values))
+	for i, value := range values {
+		points[i] = models.DataPoint{Timestamp: now.Add(time.Duration(i-len(values)) * time.Minute), Value: value}
+	}
+	mc.addTimeSeriesData("pod/nginx", "cpu", points)
+
+	countByType := func(anomalies []models.Anomaly) map[string]int {
+		counts := make(map[string]int)
+		for _, a := range anomalies {
+			counts[a.Type]++
+		}
+		return counts
+	}
+
+	// Each detector on its own flags the spike
+	for _, method := range []string{AnomalyMethodZScore, AnomalyMethodSpike} {
+		anomalies, err := an.DetectAnomaliesWithMethods("pod/nginx", "cpu", time.Hour, []string{method})
+		if err != nil {
+			t.Fatalf("Failed to detect anomalies with %s: %v", method, err)
+		}
+		if countByType(anomalies)[string(AnomalySpike)] != 1 {
+			t.Errorf("Expected %s to flag the spike, got %+v", method, anomalies)
+		}
+	}
+
+	// Together they report it once, alongside the drop that follows it
+	anomalies, err := an.DetectAnomalies("pod/nginx", "cpu", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to detect anomalies: %v", err)
+	}
+	counts := countByType(anomalies)
+	if counts[string(AnomalySpike)] != 1 || counts[string(AnomalyDrop)] != 1 {
+		t.Errorf("Expected one spike and one drop after deduplication, got %+v", anomalies)
+	}
+	for _, a := range anomalies {
+		if a.Fingerprint == "" || a.Resource != "pod/nginx" {
+			t.Errorf("Expected anomalies to be attributed to pod/nginx, got %+v", a)
+		}
+	}
+
+	// Methods that do not fire report nothing
+	anomalies, err = an.DetectAnomaliesWithMethods("pod/nginx", "cpu", time.Hour, []string{AnomalyMethodDrift})
+	if err != nil {
+		t.Fatalf("Failed to detect anomalies with drift: %v", err)
+	}
+	if len(anomalies) != 0 {
+		t.Errorf("Expected no drift in a stable series, got %+v", anomalies)
+	}
+
+	if _, err := an.DetectAnomaliesWithMethods("pod/nginx", "cpu", time.Hour, []string{"fourier"}); err == nil {
+		t.Error("Expected an error for an unknown detection method")
+	}
+}
+
+// TestDetectAnomaliesMovingAverageAndRateOfChange tests that the moving-average and rate-of-change
+// detectors run only when selected and catch series the default methods miss
+func TestDetectAnomaliesMovingAverageAndRateOfChange(t *testing.T) {
+	mc := newMockCollector()
+	an := New(mc)
+
+	series := func(values []float64) []models.DataPoint {
+		now := time.Now()
+		points := make([]models.DataPoint, len(values))
+		for i, value := range values {
+			points[i] = models.DataPoint{Timestamp: now.Add(time.Duration(i-len(values)) * time.Minute), Value: value}
+		}
+		return points
+	}
+
+	// A plateau 60% above the baseline: too small for spikes and Z-scores, and centred so the
+	// halves compared for drift match, but far from the preceding moving average
+	var plateau []float64
+	for _, level := range []float64{100, 160, 100} {
+		for i := 0; i < 10; i++ {
+			plateau = append(plateau, level)
+		}
+	}
+	mc.addTimeSeriesData("pod/plateau", "cpu", series(plateau))
+
+	// A slow ramp whose slope briefly steepens
+	var ramp []float64
+	value := 1000.0
+	for i := 0; i < 30; i++ {
+		if i == 15 {
+			value += 60
+		} else {
+			value += 10
+		}
+		ramp = append(ramp, value)
+	}
+	mc.addTimeSeriesData("pod/ramp", "cpu", series(ramp))
+
+	tests := []struct {
+		resource string
+		method   string
+	}{
+		{"pod/plateau", AnomalyMethodMovingAvg},
+		{"pod/ramp", AnomalyMethodRateChange},
+	}
+	for _, tt := range tests {
+		anomalies, err := an.DetectAnomalies(tt.resource, "cpu", time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to detect anomalies for %s: %v", tt.resource, err)
+		}
+		if len(anomalies) != 0 {
+			t.Errorf("Expected the default methods to miss %s, got %+v", tt.resource, anomalies)
+		}
+
+		anomalies, err = an.DetectAnomaliesWithMethods(tt.resource, "cpu", time.Hour, []string{tt.method})
+		if err != nil {
+			t.Fatalf("Failed to detect anomalies for %s with %s: %v", tt.resource, tt.method, err)
+		}
+		if len(anomalies) == 0 {
+			t.Errorf("Expected %s to catch %s", tt.method, tt.resource)
+		}
+	}
+
+	// The moving-average window comes from the configuration
+	config := DefaultConfig()
+	config.MovingAverageWindow = 40
+	anomalies, err := NewWithConfig(mc, config).DetectAnomaliesWithMethods("pod/plateau", "cpu", time.Hour, []string{AnomalyMethodMovingAvg})
+	if err != nil {
+		t.Fatalf("Failed to detect anomalies: %v", err)
+	}
+	if len(anomalies) != 0 {
+		t.Errorf("Expected no moving-average anomalies with a window longer than the series, got %d", len(anomalies))
+	}
+}
+
+// TestDetectSaturationAnomalies tests that usage sustained near a pod's limit is reported as
+// saturation, graded by how close it is to the limit, while statistical detectors see nothing
+func TestDetectSaturationAnomalies(t *testing.T) {
+	limitedPod := func(name string, limits corev1.ResourceList) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:      "app",
+				Resources: corev1.ResourceRequirements{Limits: limits},
+			}}},
+		}
+	}
+	clientset := fake.NewClientset(
+		limitedPod("web-1", corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		}),
+		limitedPod("unlimited-1", nil),
+	)
+
+	mc := newMockCollector()
+	steady := func(value float64) []models.DataPoint {
+		now := time.Now()
+		points := make([]models.DataPoint, 30)
+		for i := range points {
+			points[i] = models.DataPoint{Timestamp: now.Add(time.Duration(i-30) * time.Minute), Value: value}
+		}
+		return points
+	}
+	const limit = 512 * 1024 * 1024
+	mc.addTimeSeriesData("pod/web-1", "memory", steady(0.96*limit))
+	mc.addTimeSeriesData("pod/web-1", "cpu", steady(500))
+	mc.addTimeSeriesData("pod/unlimited-1", "memory", steady(0.95*limit))
+
+	an := NewWithClient(mc, &k8s.Client{Clientset: clientset}, DefaultConfig())
+
+	anomalies, err := an.DetectAnomalies("pod/web-1", "memory", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to detect anomalies: %v", err)
+	}
+	if len(anomalies) != 1 || anomalies[0].Type != string(AnomalySaturation) {
+		t.Fatalf("Expected a single saturation anomaly for memory at 96%% of its limit, got %+v", anomalies)
+	}
+	// 96% consumes over half the headroom between the 90% threshold and the limit
+	if anomalies[0].Severity != string(SeverityHigh) {
+		t.Errorf("Expected high severity at 96%% of the limit, got %s", anomalies[0].Severity)
+	}
+	if !strings.Contains(anomalies[0].Description, "OOM kill") {
+		t.Errorf("Expected the description to mention the OOM-kill risk, got %q", anomalies[0].Description)
+	}
+
+	// CPU at half its limit and pods without limits are not saturated
+	for _, tt := range []struct{ resource, metric string }{{"pod/web-1", "cpu"}, {"pod/unlimited-1", "memory"}} {
+		anomalies, err := an.DetectAnomalies(tt.resource, tt.metric, time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to detect anomalies for %s %s: %v", tt.resource, tt.metric, err)
+		}
+		if len(anomalies) != 0 {
+			t.Errorf("Expected no anomalies for %s %s, got %+v", tt.resource, tt.metric, anomalies)
+		}
+	}
+
+	if got := saturationSeverity(0.99, 0.9); got != SeverityCritical {
+		t.Errorf("Expected critical severity at 99%% of the limit, got %s", got)
+	}
+	if got := saturationSeverity(0.91, 0.9); got != SeverityLow {
+		t.Errorf("Expected low severity just above the threshold, got %s", got)
+	}
+}
+
+// TestNodePoolPricing tests that pods are priced at the rates of their node's pool
+func TestNodePoolPricing(t *testing.T) {
+	newNode := func(name, instanceType string, taints ...corev1.Taint) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{instanceTypeLabel: instanceType}},
+			Spec:       corev1.NodeSpec{Taints: taints},
+		}
+	}
+	newPod := func(name, node string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}
+	}
+	objects := []runtime.Object{
+		newNode("spot-1", "m5.xlarge", corev1.Taint{Key: "cloud.google.com/gke-spot", Value: "true", Effect: corev1.TaintEffectNoSchedule}),
+		newNode("ondemand-1", "m5.xlarge"),
+		newNode("other-1", "c5.large"),
+		newPod("web-spot", "spot-1"),
+		newPod("web-ondemand", "ondemand-1"),
+		newPod("web-other", "other-1"),
+	}
+
+	mc := newMockCollector()
+	now := time.Now()
+	for _, pod := range []string{"web-spot", "web-ondemand", "web-other"} {
+		var cpuPoints, memPoints []models.DataPoint
+		for i := 0; i < 20; i++ {
+			ts := now.Add(-time.Duration(i) * time.Minute)
+			cpuPoints = append(cpuPoints, models.DataPoint{Timestamp: ts, Value: 1000})
+			memPoints = append(memPoints, models.DataPoint{Timestamp: ts, Value: 2 * 1024 * 1024 * 1024})
+		}
+		mc.addTimeSeriesData("pod/"+pod, "cpu", cpuPoints)
+		mc.addTimeSeriesData("pod/"+pod, "memory", memPoints)
+	}
+
+	config := DefaultConfig()
+	config.NodePoolPricing = map[string]pricing.Rates{
+		SpotNodePool: {CPUCostPerVCPUHour: 0.009, MemoryCostPerGBHour: 0.0012},
+		"m5.xlarge":  {CPUCostPerVCPUHour: 0.03, MemoryCostPerGBHour: 0.004},
+	}
+	k8sClient := &k8s.Client{Clientset: fake.NewClientset(objects...)}
+	an := NewWithClient(mc, k8sClient, config).(*analyzer)
+
+	costs := make(map[string]float64)
+	for _, pod := range []string{"web-spot", "web-ondemand", "web-other"} {
+		cost, err := an.CalculateServiceCost("default", pod)
+		if err != nil {
+			t.Fatalf("CalculateServiceCost(%s) failed: %v", pod, err)
+		}
+		costs[pod] = cost.TotalCost
+	}
+
+	// The spot taint takes precedence over the m5.xlarge instance type
+	if ratio := costs["web-spot"] / costs["web-ondemand"]; math.Abs(ratio-0.3) > 0.01 {
+		t.Errorf("Expected spot pod at ~30%% of on-demand cost, got %.2f vs %.2f", costs["web-spot"], costs["web-ondemand"])
+	}
+
+	// c5.large has no pool pricing, so it falls back to the flat rates, which match m5.xlarge here
+	if costs["web-other"] != costs["web-ondemand"] {
+		t.Errorf("Expected unpriced pool at the flat rate %.2f, got %.2f", costs["web-ondemand"], costs["web-other"])
+	}
+
+	// Pods without a known node use the flat rates
+	if rates := an.serviceRates(an.lookupServicePods("default", "missing")); rates != an.flatRates() {
+		t.Errorf("Expected flat rates for an unknown pod, got %+v", rates)
+	}
+
+	// Without pool or per-node pricing, nodes aren't looked up at all
+	clientset := fake.NewClientset(objects...)
+	flat := NewWithClient(mc, &k8s.Client{Clientset: clientset}, DefaultConfig()).(*analyzer)
+	pods := flat.lookupServicePods("default", "web-spot")
+	clientset.ClearActions()
+	if rates := flat.serviceRates(pods); rates != flat.flatRates() {
+		t.Errorf("Expected flat rates without pool pricing, got %+v", rates)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetResource().Resource == "nodes" {
+			t.Errorf("Expected no node lookups with flat pricing, got %s", action.GetVerb())
+		}
+	}
+}
+
+// TestStorageAndNetworkCost tests that claimed storage and egress are priced into the total cost
+func TestStorageAndNetworkCost(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-db-0", Namespace: "default"},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase:    corev1.ClaimBound,
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("50Gi")},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-db-0"},
+				},
+			}},
+		},
+	}
+
+	mc := newMockCollector()
+	now := time.Now()
+	var cpuPoints, memPoints, txPoints []models.DataPoint
+	for i := 0; i <= 120; i++ {
+		ts := now.Add(-time.Duration(120-i) * time.Minute)
+		cpuPoints = append(cpuPoints, models.DataPoint{Timestamp: ts, Value: 200})
+		memPoints = append(memPoints, models.DataPoint{Timestamp: ts, Value: 512 * 1024 * 1024})
+		// 1 GiB transmitted per hour
+		txPoints = append(txPoints, models.DataPoint{Timestamp: ts, Value: float64(i) / 60 * 1024 * 1024 * 1024})
+	}
+	mc.addTimeSeriesData("pod/db-0", "cpu", cpuPoints)
+	mc.addTimeSeriesData("pod/db-0", "memory", memPoints)
+
+	k8sClient := &k8s.Client{Clientset: fake.NewClientset(pvc, pod)}
+	an := NewWithClient(mc, k8sClient, DefaultConfig()).(*analyzer)
+
+	// Without claims' prices or transmit metrics only compute is charged
+	computeOnly, err := an.CalculateServiceCost("default", "db-0")
+	if err != nil {
+		t.Fatalf("CalculateServiceCost failed: %v", err)
+	}
+	if computeOnly.NetworkCost != 0 {
+		t.Errorf("Expected no network cost without transmit metrics, got %.2f", computeOnly.NetworkCost)
+	}
+
+	// 50GB at $0.10 per GB-month
+	if computeOnly.StorageCost != 5.0 {
+		t.Errorf("Expected storage cost 5.00, got %.2f", computeOnly.StorageCost)
+	}
+
+	mc.addTimeSeriesData("pod/db-0", networkTxMetric, txPoints)
+	cost, err := an.CalculateServiceCost("default", "db-0")
+	if err != nil {
+		t.Fatalf("CalculateServiceCost failed: %v", err)
+	}
+
+	// 1GB per hour is 720GB per month at $0.09 per GB
+	if math.Abs(cost.NetworkCost-64.8) > 0.01 {
+		t.Errorf("Expected network cost ~64.80, got %.2f", cost.NetworkCost)
+	}
+
+	expected := cost.CPUCost + cost.MemoryCost + cost.StorageCost + cost.NetworkCost
+	if math.Abs(cost.TotalCost-expected) > 0.02 {
+		t.Errorf("Expected total cost %.2f to sum all four dimensions, got %.2f", expected, cost.TotalCost)
+	}
+
+	// Services without claims keep a zero storage cost
+	if gb := an.serviceStorageGB(an.lookupServicePods("default", "missing")); gb != 0 {
+		t.Errorf("Expected no storage for an unknown service, got %.2fGB", gb)
+	}
+}
+
 // TestPredictResourceNeeds tests resource prediction
 func TestPredictResourceNeeds(t *testing.T) {
 	mc := newMockCollector()
@@ -369,6 +1001,64 @@ func TestPredictResourceNeeds(t *testing.T) {
 	if prediction.Confidence < 0 || prediction.Confidence > 1 {
 		t.Errorf("Expected confidence between 0 and 1, got %f", prediction.Confidence)
 	}
+
+	// The range brackets the prediction and widens with the horizon
+	if prediction.PredictedCPUHigh <= prediction.PredictedCPU || prediction.PredictedMemoryHigh <= prediction.PredictedMemory {
+		t.Errorf("Expected high bounds above the predictions, got CPU %d > %d, memory %d > %d",
+			prediction.PredictedCPUHigh, prediction.PredictedCPU, prediction.PredictedMemoryHigh, prediction.PredictedMemory)
+	}
+	if prediction.PredictedCPULow < 0 || prediction.PredictedCPULow > prediction.PredictedCPU ||
+		prediction.PredictedMemoryLow < 0 || prediction.PredictedMemoryLow > prediction.PredictedMemory {
+		t.Errorf("Expected non-negative low bounds below the predictions, got CPU %d, memory %d",
+			prediction.PredictedCPULow, prediction.PredictedMemoryLow)
+	}
+
+	longer, err := an.PredictResourceNeeds("default", "nginx", 72)
+	if err != nil {
+		t.Fatalf("Failed to predict resources: %v", err)
+	}
+	if longer.PredictedCPUHigh-longer.PredictedCPU <= prediction.PredictedCPUHigh-prediction.PredictedCPU {
+		t.Errorf("Expected a wider range 72h ahead, got +%d vs +%d at 24h",
+			longer.PredictedCPUHigh-longer.PredictedCPU, prediction.PredictedCPUHigh-prediction.PredictedCPU)
+	}
+}
+
+// TestPredictResourceNeedsSeasonal tests that a 24h-periodic series is predicted from the same
+// phase of the previous cycle rather than from the trend line
+func TestPredictResourceNeedsSeasonal(t *testing.T) {
+	mc := newMockCollector()
+	an := New(mc)
+
+	// A week of hourly samples following a daily sine wave
+	daily := func(hour int, base, amplitude float64) float64 {
+		return base + amplitude*math.Sin(2*math.Pi*float64(hour)/24)
+	}
+	now := time.Now()
+	var cpuPoints, memPoints []models.DataPoint
+	for h := 0; h < 168; h++ {
+		ts := now.Add(-time.Duration(167-h) * time.Hour)
+		cpuPoints = append(cpuPoints, models.DataPoint{Timestamp: ts, Value: daily(h, 500, 300)})
+		memPoints = append(memPoints, models.DataPoint{Timestamp: ts, Value: daily(h, 512*1024*1024, 128*1024*1024)})
+	}
+	mc.addTimeSeriesData("pod/web", "cpu", cpuPoints)
+	mc.addTimeSeriesData("pod/web", "memory", memPoints)
+
+	for _, hours := range []int{6, 12} {
+		prediction, err := an.PredictResourceNeeds("default", "web", hours)
+		if err != nil {
+			t.Fatalf("Failed to predict resources: %v", err)
+		}
+
+		if !prediction.Seasonal || prediction.SeasonalPeriod != 24*time.Hour {
+			t.Fatalf("Expected a 24h seasonal pattern, got seasonal=%t period=%v", prediction.Seasonal, prediction.SeasonalPeriod)
+		}
+
+		// The series ends at hour 167, so the prediction should match hour 167+hours of the cycle
+		expected := daily(167+hours, 500, 300)
+		if math.Abs(float64(prediction.PredictedCPU)-expected) > 10 {
+			t.Errorf("Expected predicted CPU %dh ahead to follow the daily cycle at %.0f, got %d", hours, expected, prediction.PredictedCPU)
+		}
+	}
 }
 
 // TestCalculateWaste tests waste calculation
@@ -487,3 +1177,85 @@ func TestRoundTo2Decimals(t *testing.T) {
 		}
 	}
 }
+
+// TestFractionalNodeCost tests that pods on a densely packed node are charged their node share
+func TestFractionalNodeCost(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{instanceTypeLabel: "m5.xlarge"},
+		},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("16Gi"),
+			},
+		},
+	}
+
+	// Eight pods fill the node's allocatable CPU and memory
+	objects := []runtime.Object{node}
+	for i := 0; i < 8; i++ {
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("web-%d", i), Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName: "node-1",
+				Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		})
+	}
+
+	mc := newMockCollector()
+	now := time.Now()
+	var cpuPoints, memPoints []models.DataPoint
+	for i := 0; i < 20; i++ {
+		ts := now.Add(-time.Duration(i) * time.Minute)
+		cpuPoints = append(cpuPoints, models.DataPoint{Timestamp: ts, Value: 300})
+		memPoints = append(memPoints, models.DataPoint{Timestamp: ts, Value: 1024 * 1024 * 1024})
+	}
+	mc.addTimeSeriesData("pod/web-0", "cpu", cpuPoints)
+	mc.addTimeSeriesData("pod/web-0", "memory", memPoints)
+
+	config := DefaultConfig()
+	config.FractionalNodeCost = true
+	config.NodeHourlyPrices = map[string]float64{"m5.xlarge": 0.10}
+
+	k8sClient := &k8s.Client{Clientset: fake.NewClientset(objects...)}
+	an := NewWithClient(mc, k8sClient, config).(*analyzer)
+
+	pods := an.lookupServicePods("default", "web-0")
+	share, err := an.calculateNodeShare(pods, &pods.objects[0])
+	if err != nil {
+		t.Fatalf("calculateNodeShare failed: %v", err)
+	}
+	if share.CPUFraction != 0.125 || share.MemoryFraction != 0.125 {
+		t.Errorf("Expected 1/8 share of the node, got cpu=%.3f memory=%.3f", share.CPUFraction, share.MemoryFraction)
+	}
+
+	cost, err := an.CalculateServiceCost("default", "web-0")
+	if err != nil {
+		t.Fatalf("CalculateServiceCost failed: %v", err)
+	}
+
+	// Naive calculation prices the same requests at flat per-unit rates
+	_, _, naiveTotal := an.calculateCostForResources(500, 2*1024*1024*1024)
+
+	if cost.TotalCost >= naiveTotal {
+		t.Errorf("Expected fractional cost below flat-rate cost %.2f, got %.2f", naiveTotal, cost.TotalCost)
+	}
+
+	// Monthly share of an $0.10/hour node split eight ways
+	expected := 0.10 / 8 * 24 * 30
+	if math.Abs(cost.TotalCost-expected) > 0.02 {
+		t.Errorf("Expected fractional cost ~%.2f, got %.2f", expected, cost.TotalCost)
+	}
+}
@@ -3,6 +3,7 @@ package analyzer
 import (
 	"fmt"
 	"math"
+	"slices"
 	"time"
 
 	"github.com/k8s-service-optimizer/backend/internal/models"
@@ -10,6 +11,24 @@ import (
 
 // DetectAnomalies detects anomalies in metrics
 func (a *analyzer) DetectAnomalies(resource, metric string, duration time.Duration) ([]models.Anomaly, error) {
+	return a.DetectAnomaliesWithMethods(resource, metric, duration, nil)
+}
+
+// DetectAnomaliesWithMethods detects anomalies in metrics using the given detection methods, or the
+// default methods when none are given. Anomalies of the same type that several methods report within
+// AnomalyDedupWindow of each other are collapsed into the most severe one.
+func (a *analyzer) DetectAnomaliesWithMethods(resource, metric string, duration time.Duration, methods []string) ([]models.Anomaly, error) {
+	if len(methods) == 0 {
+		methods = DefaultAnomalyMethods
+	}
+	selected := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		if !slices.Contains(AnomalyMethods, method) {
+			return nil, fmt.Errorf("unknown anomaly detection method %q", method)
+		}
+		selected[method] = true
+	}
+
 	// Get time series data
 	data, err := a.client.GetTimeSeriesData(resource, metric, duration)
 	if err != nil {
@@ -28,15 +47,84 @@ func (a *analyzer) DetectAnomalies(resource, metric string, duration time.Durati
 	stdDev := math.Sqrt(variance)
 
 	// Detect different types of anomalies
-	anomalies = append(anomalies, a.detectZScoreAnomalies(data.Points, mean, stdDev)...)
-	anomalies = append(anomalies, a.detectSpikeAnomalies(data.Points, mean)...)
-	anomalies = append(anomalies, a.detectDropAnomalies(data.Points, mean)...)
-	anomalies = append(anomalies, a.detectDriftAnomalies(data.Points, mean)...)
-	anomalies = append(anomalies, a.detectOscillationAnomalies(data.Points, mean, stdDev)...)
+	if selected[AnomalyMethodZScore] {
+		anomalies = append(anomalies, a.detectZScoreAnomalies(data.Points, mean, stdDev)...)
+	}
+	if selected[AnomalyMethodSpike] {
+		anomalies = append(anomalies, a.detectSpikeAnomalies(data.Points, mean)...)
+	}
+	if selected[AnomalyMethodDrop] {
+		anomalies = append(anomalies, a.detectDropAnomalies(data.Points, mean)...)
+	}
+	if selected[AnomalyMethodDrift] {
+		anomalies = append(anomalies, a.detectDriftAnomalies(data.Points, mean)...)
+	}
+	if selected[AnomalyMethodOscillation] {
+		anomalies = append(anomalies, a.detectOscillationAnomalies(data.Points, mean, stdDev)...)
+	}
+	if selected[AnomalyMethodMovingAvg] {
+		anomalies = append(anomalies, a.detectMovingAverageAnomalies(data.Points, a.config.MovingAverageWindow)...)
+	}
+	if selected[AnomalyMethodRateChange] {
+		anomalies = append(anomalies, a.detectRateOfChangeAnomalies(data.Points)...)
+	}
+	if selected[AnomalyMethodSaturation] {
+		if threshold, ok := a.saturationThreshold(metric); ok {
+			if limit, ok := a.podLimit(resource, metric); ok {
+				anomalies = append(anomalies, a.detectSaturationAnomalies(data.Points, metric, limit, threshold)...)
+			}
+		}
+	}
+
+	anomalies = dedupeAnomalies(anomalies, a.config.AnomalyDedupWindow)
+
+	for i := range anomalies {
+		anomalies[i].Resource = resource
+		anomalies[i].Metric = metric
+		anomalies[i].Fingerprint = AnomalyFingerprint(resource, metric, anomalies[i].Type)
+	}
 
 	return anomalies, nil
 }
 
+// AnomalyFingerprint identifies an anomaly by what it affects rather than when it was detected,
+// so repeated detections of the same problem share a fingerprint
+func AnomalyFingerprint(resource, metric, anomalyType string) string {
+	return fmt.Sprintf("%s:%s:%s", resource, metric, anomalyType)
+}
+
+// severityRank orders anomaly severities from least to most severe
+var severityRank = map[string]int{
+	string(SeverityLow):      1,
+	string(SeverityMedium):   2,
+	string(SeverityHigh):     3,
+	string(SeverityCritical): 4,
+}
+
+// dedupeAnomalies collapses anomalies of the same type detected within window of each other,
+// keeping the most severe and, among equals, the first reported
+func dedupeAnomalies(anomalies []models.Anomaly, window time.Duration) []models.Anomaly {
+	deduped := make([]models.Anomaly, 0, len(anomalies))
+	for _, anomaly := range anomalies {
+		duplicate := false
+		for i := range deduped {
+			kept := &deduped[i]
+			if kept.Type != anomaly.Type || absDuration(kept.DetectedAt.Sub(anomaly.DetectedAt)) > window {
+				continue
+			}
+			if severityRank[anomaly.Severity] > severityRank[kept.Severity] {
+				*kept = anomaly
+			}
+			duplicate = true
+			break
+		}
+		if !duplicate {
+			deduped = append(deduped, anomaly)
+		}
+	}
+	return deduped
+}
+
 // detectZScoreAnomalies detects anomalies using Z-score method
 func (a *analyzer) detectZScoreAnomalies(points []models.DataPoint, mean, stdDev float64) []models.Anomaly {
 	anomalies := []models.Anomaly{}
@@ -249,7 +337,7 @@ func (a *analyzer) determineSpikeDropSeverity(ratio float64) anomalySeverity {
 func (a *analyzer) detectMovingAverageAnomalies(points []models.DataPoint, windowSize int) []models.Anomaly {
 	anomalies := []models.Anomaly{}
 
-	if len(points) < windowSize {
+	if windowSize < 1 || len(points) < windowSize {
 		return anomalies
 	}
 
@@ -1,68 +1,206 @@
 package analyzer
 
 import (
-	"context"
 	"fmt"
 	"math"
 	"strings"
 	"time"
 
 	"github.com/k8s-service-optimizer/backend/internal/models"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
 )
 
-// CalculateServiceCost calculates the cost for a specific service
+// costTrendSampleInterval is the width of each window sampled by GetCostTrends
+const costTrendSampleInterval = 6 * time.Hour
+
+// CalculateServiceCost calculates the cost for a specific service, summing the usage of all its pods
 func (a *analyzer) CalculateServiceCost(namespace, service string) (*models.CostBreakdown, error) {
 	// Get resource requests and usage for the service
 	// We need to find all pods for this service
-	resource := fmt.Sprintf("pod/%s", service)
+	pods := a.lookupServicePods(namespace, service)
 
 	// Calculate time window (use 24 hours for cost calculation)
 	duration := 24 * time.Hour
 
 	// Get CPU usage data
-	cpuData, err := a.client.GetTimeSeriesData(resource, "cpu", duration)
+	cpuPoints, err := a.aggregatePodMetrics(pods.resources, "cpu", duration, aggregateSum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CPU data: %w", err)
 	}
 
 	// Get memory usage data
-	memData, err := a.client.GetTimeSeriesData(resource, "memory", duration)
+	memPoints, err := a.aggregatePodMetrics(pods.resources, "memory", duration, aggregateSum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory data: %w", err)
+	}
+
+	// If no data, compute costs are zero
+	var cost *models.CostBreakdown
+	if len(cpuPoints) == 0 && len(memPoints) == 0 {
+		cost = zeroCost(namespace, service, time.Now())
+	} else {
+		cost = a.calculateCost(namespace, service, cpuPoints, memPoints, a.serviceRates(pods), a.nodeShareFor(pods), time.Now())
+	}
+
+	// Storage and egress are priced on top of compute, and stay zero without claims or transmit metrics
+	a.addStorageAndNetworkCost(cost, a.serviceStorageGB(pods), a.networkTxSeries(pods.resources, duration))
+
+	return cost, nil
+}
+
+// networkTxSeries returns each pod's transmitted-bytes counter over the duration, or nil if it
+// isn't collected. Counters are kept per pod so each pod's resets are detected.
+func (a *analyzer) networkTxSeries(pods []string, duration time.Duration) [][]models.DataPoint {
+	series, err := a.podSeries(pods, networkTxMetric, duration)
+	if err != nil {
+		return nil
+	}
+	return series
+}
+
+// GetCostTrends samples a service's cost over the duration in consecutive 6h windows, oldest first.
+// Each point is priced from the window's P95 usage, summed across the service's pods, and stamped
+// with the window's end; windows without data are reported as zero-cost points.
+func (a *analyzer) GetCostTrends(namespace, service string, duration time.Duration) ([]models.CostBreakdown, error) {
+	pods := a.lookupServicePods(namespace, service)
+
+	numSamples := int(duration / costTrendSampleInterval)
+	if numSamples < 1 {
+		numSamples = 1
+	}
+	duration = time.Duration(numSamples) * costTrendSampleInterval
+
+	cpuHistory, err := a.aggregatePodMetrics(pods.resources, "cpu", duration, aggregateSum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CPU data: %w", err)
+	}
+
+	memHistory, err := a.aggregatePodMetrics(pods.resources, "memory", duration, aggregateSum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get memory data: %w", err)
 	}
 
-	// If no data, return zero cost
-	if len(cpuData.Points) == 0 && len(memData.Points) == 0 {
-		return &models.CostBreakdown{
-			Service:         service,
-			Namespace:       namespace,
-			CPUCost:         0,
-			MemoryCost:      0,
-			TotalCost:       0,
-			WastedCost:      0,
-			EfficiencyScore: 100,
-			Timestamp:       time.Now(),
-		}, nil
+	txSeries := a.networkTxSeries(pods.resources, duration)
+
+	// The node share, rates and claims reflect the pods' current placement, so look them up once
+	// for all windows
+	var share *nodeShare
+	rates := a.flatRates()
+	storageGB := 0.0
+	if len(cpuHistory) > 0 || len(memHistory) > 0 {
+		share = a.nodeShareFor(pods)
+		rates = a.serviceRates(pods)
+		storageGB = a.serviceStorageGB(pods)
+	}
+
+	now := time.Now()
+	trends := make([]models.CostBreakdown, 0, numSamples)
+	for i := numSamples - 1; i >= 0; i-- {
+		end := now.Add(-time.Duration(i) * costTrendSampleInterval)
+		start := end.Add(-costTrendSampleInterval)
+
+		cpuPoints := pointsInWindow(cpuHistory, start, end)
+		memPoints := pointsInWindow(memHistory, start, end)
+		if len(cpuPoints) == 0 && len(memPoints) == 0 {
+			trends = append(trends, *zeroCost(namespace, service, end))
+			continue
+		}
+
+		cost := a.calculateCost(namespace, service, cpuPoints, memPoints, rates, share, end)
+		txWindows := make([][]models.DataPoint, len(txSeries))
+		for i, txPoints := range txSeries {
+			txWindows[i] = pointsInWindow(txPoints, start, end)
+		}
+		a.addStorageAndNetworkCost(cost, storageGB, txWindows)
+		trends = append(trends, *cost)
+	}
+
+	return trends, nil
+}
+
+// nodeShareFor returns the service pods' combined share of their nodes when fractional node cost is
+// enabled, or nil to price at the flat rates. Only the requests, summed, and the rates, averaged
+// weighted by request, are set, so the service is charged the sum of its pods' shares. Pods whose
+// share can't be calculated are left out.
+func (a *analyzer) nodeShareFor(pods *servicePods) *nodeShare {
+	if !a.config.FractionalNodeCost || a.k8sClient == nil {
+		return nil
+	}
+
+	var combined *nodeShare
+	var cpuCost, memoryCost float64
+	for i := range pods.objects {
+		share, err := a.calculateNodeShare(pods, &pods.objects[i])
+		if err != nil {
+			continue
+		}
+		if combined == nil {
+			combined = &nodeShare{}
+		}
+		combined.CPURequested += share.CPURequested
+		combined.MemoryRequested += share.MemoryRequested
+		cpuCost += share.CPUCostPerVCPUHour * float64(share.CPURequested)
+		memoryCost += share.MemoryCostPerGBHour * float64(share.MemoryRequested)
+	}
+	if combined == nil {
+		return nil
+	}
+
+	if combined.CPURequested > 0 {
+		combined.CPUCostPerVCPUHour = cpuCost / float64(combined.CPURequested)
+	}
+	if combined.MemoryRequested > 0 {
+		combined.MemoryCostPerGBHour = memoryCost / float64(combined.MemoryRequested)
+	}
+	return combined
+}
+
+// pointsInWindow returns the points with timestamps in (start, end]
+func pointsInWindow(points []models.DataPoint, start, end time.Time) []models.DataPoint {
+	var window []models.DataPoint
+	for _, point := range points {
+		if point.Timestamp.After(start) && !point.Timestamp.After(end) {
+			window = append(window, point)
+		}
+	}
+	return window
+}
+
+// zeroCost is the cost breakdown reported when there is no usage data
+func zeroCost(namespace, service string, timestamp time.Time) *models.CostBreakdown {
+	return &models.CostBreakdown{
+		Service:         service,
+		Namespace:       namespace,
+		CPUCost:         0,
+		MemoryCost:      0,
+		TotalCost:       0,
+		WastedCost:      0,
+		EfficiencyScore: 100,
+		Timestamp:       timestamp,
 	}
+}
 
+// calculateCost prices a service from its CPU and memory usage points. With a node share the pod's
+// actual requests are priced at its share of the node; otherwise requests are estimated from P95 usage
+// and priced at the rates of the service's node pool.
+func (a *analyzer) calculateCost(namespace, service string, cpuPoints, memPoints []models.DataPoint, rates pricing.Rates, share *nodeShare, timestamp time.Time) *models.CostBreakdown {
 	// Calculate P95 usage (what's actually needed)
 	cpuP95 := 0.0
 	memP95 := 0.0
 
-	if len(cpuData.Points) > 0 {
-		_, cpuP95, _, _ = a.calculatePercentiles(cpuData.Points)
+	if len(cpuPoints) > 0 {
+		_, cpuP95, _, _ = a.calculatePercentiles(cpuPoints)
 	}
 
-	if len(memData.Points) > 0 {
-		_, memP95, _, _ = a.calculatePercentiles(memData.Points)
+	if len(memPoints) > 0 {
+		_, memP95, _, _ = a.calculatePercentiles(memPoints)
 	}
 
 	// Get requested resources (what we're paying for)
 	// For now, we'll estimate based on max usage + buffer
 	// In a real implementation, we'd query the pod spec
-	cpuRequested := cpuP95 * 1.3  // 30% buffer
-	memRequested := memP95 * 1.3  // 30% buffer
+	cpuRequested := cpuP95 * 1.3 // 30% buffer
+	memRequested := memP95 * 1.3 // 30% buffer
 
 	// If we have very low usage, set a minimum request
 	if cpuRequested < 100 {
@@ -74,18 +212,29 @@ func (a *analyzer) CalculateServiceCost(namespace, service string) (*models.Cost
 
 	// Calculate costs
 	// Monthly cost formula:
-	// CPU: (cpu_millicores / 1000) × CPUCostPerVCPUHour × 24 × 30
-	// Memory: (memory_bytes / (1024^3)) × MemoryCostPerGBHour × 24 × 30
+	// CPU: (cpu_millicores / 1000) × CPU rate per vCPU-hour × 24 × 30
+	// Memory: (memory_bytes / (1024^3)) × memory rate per GB-hour × 24 × 30
 
 	hoursPerMonth := 24.0 * 30.0
 
+	cpuRate := rates.CPUCostPerVCPUHour
+	memRate := rates.MemoryCostPerGBHour
+
+	// With fractional node cost, price the pod's actual requests at its share of the node
+	if share != nil {
+		cpuRequested = float64(share.CPURequested)
+		memRequested = float64(share.MemoryRequested)
+		cpuRate = share.CPUCostPerVCPUHour
+		memRate = share.MemoryCostPerGBHour
+	}
+
 	// CPU cost
 	cpuVCores := cpuRequested / 1000.0
-	cpuCost := cpuVCores * a.config.CPUCostPerVCPUHour * hoursPerMonth
+	cpuCost := cpuVCores * cpuRate * hoursPerMonth
 
 	// Memory cost (convert bytes to GB)
 	memGB := memRequested / (1024.0 * 1024.0 * 1024.0)
-	memCost := memGB * a.config.MemoryCostPerGBHour * hoursPerMonth
+	memCost := memGB * memRate * hoursPerMonth
 
 	totalCost := cpuCost + memCost
 
@@ -95,10 +244,10 @@ func (a *analyzer) CalculateServiceCost(namespace, service string) (*models.Cost
 
 	// Calculate wasted cost
 	cpuWasteVCores := cpuWaste / 1000.0
-	cpuWasteCost := cpuWasteVCores * a.config.CPUCostPerVCPUHour * hoursPerMonth
+	cpuWasteCost := cpuWasteVCores * cpuRate * hoursPerMonth
 
 	memWasteGB := memWaste / (1024.0 * 1024.0 * 1024.0)
-	memWasteCost := memWasteGB * a.config.MemoryCostPerGBHour * hoursPerMonth
+	memWasteCost := memWasteGB * memRate * hoursPerMonth
 
 	wastedCost := cpuWasteCost + memWasteCost
 
@@ -118,52 +267,28 @@ func (a *analyzer) CalculateServiceCost(namespace, service string) (*models.Cost
 		TotalCost:       roundTo2Decimals(totalCost),
 		WastedCost:      roundTo2Decimals(wastedCost),
 		EfficiencyScore: roundTo2Decimals(efficiencyScore),
-		Timestamp:       time.Now(),
-	}, nil
-}
-
-// GetCostTrends gets cost trends over time
-func (a *analyzer) GetCostTrends(namespace string, duration time.Duration) ([]models.CostBreakdown, error) {
-	// For cost trends, we calculate cost at multiple time points
-	// We'll sample at intervals throughout the duration
-
-	// Sample every 6 hours
-	sampleInterval := 6 * time.Hour
-	numSamples := int(duration / sampleInterval)
-
-	if numSamples < 1 {
-		numSamples = 1
+		Timestamp:       timestamp,
 	}
-
-	trends := make([]models.CostBreakdown, 0, numSamples)
-
-	// For now, we'll return a single current cost
-	// In a full implementation, we would calculate historical costs
-	// by using time-windowed queries
-
-	// This is a simplified version - we'd need to track service names
-	// For now, return empty if we can't determine services
-	return trends, nil
 }
 
-// CalculateWaste calculates wasted resources (over-provisioning)
+// CalculateWaste calculates wasted resources (over-provisioning) across all of a service's pods
 func (a *analyzer) CalculateWaste(namespace, service string) (float64, error) {
-	resource := fmt.Sprintf("pod/%s", service)
+	pods := a.findPodsByService(namespace, service)
 	duration := 24 * time.Hour
 
 	// Get CPU usage data
-	cpuData, err := a.client.GetTimeSeriesData(resource, "cpu", duration)
+	cpuData, err := a.aggregatePodMetrics(pods, "cpu", duration, aggregateSum)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get CPU data: %w", err)
 	}
 
 	// Get memory usage data
-	memData, err := a.client.GetTimeSeriesData(resource, "memory", duration)
+	memData, err := a.aggregatePodMetrics(pods, "memory", duration, aggregateSum)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get memory data: %w", err)
 	}
 
-	if len(cpuData.Points) == 0 && len(memData.Points) == 0 {
+	if len(cpuData) == 0 && len(memData) == 0 {
 		return 0, nil
 	}
 
@@ -171,12 +296,12 @@ func (a *analyzer) CalculateWaste(namespace, service string) (float64, error) {
 	cpuP95 := 0.0
 	memP95 := 0.0
 
-	if len(cpuData.Points) > 0 {
-		_, cpuP95, _, _ = a.calculatePercentiles(cpuData.Points)
+	if len(cpuData) > 0 {
+		_, cpuP95, _, _ = a.calculatePercentiles(cpuData)
 	}
 
-	if len(memData.Points) > 0 {
-		_, memP95, _, _ = a.calculatePercentiles(memData.Points)
+	if len(memData) > 0 {
+		_, memP95, _, _ = a.calculatePercentiles(memData)
 	}
 
 	// Estimate requested resources (30% buffer above P95)
@@ -201,13 +326,6 @@ func roundTo2Decimals(value float64) float64 {
 	return math.Round(value*100) / 100
 }
 
-// getServicePods retrieves all pods for a service (helper function)
-func (a *analyzer) getServicePods(namespace, service string) ([]string, error) {
-	// In a full implementation, we would query the k8s API for pods with matching labels
-	// For now, we use naming convention
-	return []string{service}, nil
-}
-
 // getResourceRequests gets the resource requests for a pod
 func (a *analyzer) getResourceRequests(namespace, podName string) (cpuMillis int64, memBytes int64, err error) {
 	// This would query the k8s API to get pod spec
@@ -215,17 +333,18 @@ func (a *analyzer) getResourceRequests(namespace, podName string) (cpuMillis int
 	return 0, 0, fmt.Errorf("not implemented")
 }
 
-// calculateCostForResources calculates cost for given resource amounts
+// calculateCostForResources calculates cost for given resource amounts at the default rates
 func (a *analyzer) calculateCostForResources(cpuMillis, memBytes int64) (cpuCost, memCost, totalCost float64) {
 	hoursPerMonth := 24.0 * 30.0
+	rates := a.flatRates()
 
 	// CPU cost
 	cpuVCores := float64(cpuMillis) / 1000.0
-	cpuCost = cpuVCores * a.config.CPUCostPerVCPUHour * hoursPerMonth
+	cpuCost = cpuVCores * rates.CPUCostPerVCPUHour * hoursPerMonth
 
 	// Memory cost
 	memGB := float64(memBytes) / (1024.0 * 1024.0 * 1024.0)
-	memCost = memGB * a.config.MemoryCostPerGBHour * hoursPerMonth
+	memCost = memGB * rates.MemoryCostPerGBHour * hoursPerMonth
 
 	totalCost = cpuCost + memCost
 
@@ -242,7 +361,3 @@ func extractServiceName(resourceName string) string {
 	}
 	return resourceName
 }
-
-// Dummy context for future k8s API calls
-var _ = context.Background()
-var _ = metav1.ListOptions{}
@@ -8,6 +8,7 @@ import (
 	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/pkg/analyzer"
 	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
 )
 
 // Example demonstrates basic usage of the analyzer
@@ -215,8 +216,10 @@ func ExampleConfig() {
 
 	// Create custom configuration
 	config := analyzer.Config{
-		CPUCostPerVCPUHour:  0.04,   // Higher CPU cost
-		MemoryCostPerGBHour: 0.005,  // Higher memory cost
+		Pricing: pricing.NewStaticProvider(pricing.Rates{
+			CPUCostPerVCPUHour:  0.04,  // Higher CPU cost
+			MemoryCostPerGBHour: 0.005, // Higher memory cost
+		}, nil),
 		AnomalyThreshold:    2.5,    // More sensitive anomaly detection
 		SpikeThreshold:      1.8,    // Lower spike threshold
 		DropThreshold:       0.6,    // Higher drop threshold
@@ -228,8 +231,8 @@ func ExampleConfig() {
 	an := analyzer.NewWithConfig(mc, config)
 
 	fmt.Printf("Analyzer created with custom config\n")
-	fmt.Printf("CPU Cost: $%.3f/vCPU-hour\n", config.CPUCostPerVCPUHour)
-	fmt.Printf("Memory Cost: $%.4f/GB-hour\n", config.MemoryCostPerGBHour)
+	fmt.Printf("CPU Cost: $%.3f/vCPU-hour\n", config.Pricing.CPUCostPerVCPUHour(""))
+	fmt.Printf("Memory Cost: $%.4f/GB-hour\n", config.Pricing.MemoryCostPerGBHour(""))
 
 	_ = an // Use analyzer
 }
@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/internal/parallel"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CalculateNamespaceCost sums the cost of every deployment in a namespace. Services are priced
+// concurrently with CalculateServiceCost; services that fail to price are logged and skipped.
+// Requires a Kubernetes client (see NewWithClient).
+func (a *analyzer) CalculateNamespaceCost(namespace string) (*models.NamespaceCost, error) {
+	if a.k8sClient == nil {
+		return nil, fmt.Errorf("namespace cost requires a Kubernetes client")
+	}
+
+	deployments, err := a.k8sClient.Clientset.AppsV1().Deployments(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments in namespace %s: %w", namespace, err)
+	}
+
+	type result struct {
+		cost *models.CostBreakdown
+		err  error
+	}
+	results := make([]result, len(deployments.Items))
+	parallel.ForEach(len(deployments.Items), a.config.CostConcurrency, func(i int) {
+		cost, err := a.CalculateServiceCost(namespace, deployments.Items[i].Name)
+		results[i] = result{cost: cost, err: err}
+	})
+
+	namespaceCost := &models.NamespaceCost{
+		Namespace: namespace,
+		Services:  make([]models.CostBreakdown, 0, len(results)),
+		Timestamp: time.Now(),
+	}
+	for i, r := range results {
+		if r.err != nil {
+			// Log error but continue with other services
+			slog.Warn("Failed to calculate service cost", "namespace", namespace, "service", deployments.Items[i].Name, "error", r.err)
+			continue
+		}
+		namespaceCost.TotalCost += r.cost.TotalCost
+		namespaceCost.WastedCost += r.cost.WastedCost
+		namespaceCost.Services = append(namespaceCost.Services, *r.cost)
+	}
+
+	namespaceCost.TotalCost = roundTo2Decimals(namespaceCost.TotalCost)
+	namespaceCost.WastedCost = roundTo2Decimals(namespaceCost.WastedCost)
+
+	return namespaceCost, nil
+}
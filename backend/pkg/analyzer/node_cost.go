@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// instanceTypeLabel is the well-known node label holding the cloud instance type
+const instanceTypeLabel = "node.kubernetes.io/instance-type"
+
+// nodeShare holds a pod's requests and the effective per-unit rates derived from
+// its share of the hosting node's price
+type nodeShare struct {
+	Node                string
+	NodeHourlyPrice     float64
+	CPURequested        int64 // millicores
+	MemoryRequested     int64 // bytes
+	CPUFraction         float64
+	MemoryFraction      float64
+	CPUCostPerVCPUHour  float64
+	MemoryCostPerGBHour float64
+}
+
+// calculateNodeShare prices a pod as its request fraction of the node it runs on.
+// The node price is split between CPU and memory in proportion to the flat rates,
+// then each part is divided among pods by their share of the node's summed requests.
+func (a *analyzer) calculateNodeShare(pods *servicePods, pod *corev1.Pod) (*nodeShare, error) {
+	if pod.Spec.NodeName == "" {
+		return nil, fmt.Errorf("pod %s/%s is not scheduled", pod.Namespace, pod.Name)
+	}
+
+	node, err := a.node(pods, pod.Spec.NodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeCPU, nodeMemory, err := a.nodeRequests(pods, node.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	podCPU, podMemory := podRequests(pod)
+	if nodeCPU == 0 || nodeMemory == 0 {
+		return nil, fmt.Errorf("node %s has no resource requests", node.Name)
+	}
+
+	allocCPU := node.Status.Allocatable.Cpu().MilliValue()
+	allocMemory := node.Status.Allocatable.Memory().Value()
+
+	// Split the node price into CPU and memory parts using the ratio of the node pool's rates
+	rates := a.nodeRates(node)
+	flatCPUPrice := float64(allocCPU) / 1000.0 * rates.CPUCostPerVCPUHour
+	flatMemoryPrice := float64(allocMemory) / (1024.0 * 1024.0 * 1024.0) * rates.MemoryCostPerGBHour
+	if flatCPUPrice+flatMemoryPrice == 0 {
+		return nil, fmt.Errorf("node %s has no allocatable resources", node.Name)
+	}
+
+	nodePrice, ok := a.config.NodeHourlyPrices[node.Labels[instanceTypeLabel]]
+	if !ok {
+		nodePrice = flatCPUPrice + flatMemoryPrice
+	}
+	cpuPrice := nodePrice * flatCPUPrice / (flatCPUPrice + flatMemoryPrice)
+	memoryPrice := nodePrice - cpuPrice
+
+	share := &nodeShare{
+		Node:            node.Name,
+		NodeHourlyPrice: nodePrice,
+		CPURequested:    podCPU,
+		MemoryRequested: podMemory,
+		CPUFraction:     float64(podCPU) / float64(nodeCPU),
+		MemoryFraction:  float64(podMemory) / float64(nodeMemory),
+	}
+
+	// Convert the pod's share back into per-unit rates so waste is priced consistently
+	if podCPU > 0 {
+		share.CPUCostPerVCPUHour = cpuPrice * share.CPUFraction / (float64(podCPU) / 1000.0)
+	}
+	if podMemory > 0 {
+		share.MemoryCostPerGBHour = memoryPrice * share.MemoryFraction / (float64(podMemory) / (1024.0 * 1024.0 * 1024.0))
+	}
+
+	return share, nil
+}
+
+// nodeRequests sums the requests of all running pods on a node, listing them on first use
+func (a *analyzer) nodeRequests(pods *servicePods, nodeName string) (cpuMillis, memBytes int64, err error) {
+	if requests, ok := pods.nodeRequests[nodeName]; ok {
+		return requests[0], requests[1], nil
+	}
+
+	list, err := a.k8sClient.Clientset.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list pods on node: %w", err)
+	}
+
+	for i := range list.Items {
+		p := &list.Items[i]
+		if p.Spec.NodeName != nodeName || isTerminated(p) {
+			continue
+		}
+		cpu, memory := podRequests(p)
+		cpuMillis += cpu
+		memBytes += memory
+	}
+	pods.nodeRequests[nodeName] = &[2]int64{cpuMillis, memBytes}
+	return cpuMillis, memBytes, nil
+}
+
+// podRequests sums the CPU (millicores) and memory (bytes) requests of a pod's containers,
+// plus the RuntimeClass overhead admitted into the pod spec
+func podRequests(pod *corev1.Pod) (cpuMillis int64, memBytes int64) {
+	for _, container := range pod.Spec.Containers {
+		cpuMillis += container.Resources.Requests.Cpu().MilliValue()
+		memBytes += container.Resources.Requests.Memory().Value()
+	}
+	cpuMillis += pod.Spec.Overhead.Cpu().MilliValue()
+	memBytes += pod.Spec.Overhead.Memory().Value()
+	return cpuMillis, memBytes
+}
+
+// isTerminated reports whether a pod no longer holds node resources
+func isTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SpotNodePool is the NodePoolPricing key for nodes carrying a spot taint, such as
+// cloud.google.com/gke-spot or kubernetes.azure.com/scalesetpriority=spot
+const SpotNodePool = "spot"
+
+// flatRates returns the pricing provider's default rates, used when a pod's node is unknown
+func (a *analyzer) flatRates() pricing.Rates {
+	return pricing.NodeRates(a.config.Pricing, "")
+}
+
+// nodeRates returns the rates of the pool a node belongs to. A spot-tainted node is in the spot pool
+// when that pool is priced, otherwise its pool is the value of its NodePoolLabel. Nodes in unpriced
+// pools use the pricing provider's rates for the node.
+func (a *analyzer) nodeRates(node *corev1.Node) pricing.Rates {
+	if isSpotNode(node) {
+		if rates, ok := a.config.NodePoolPricing[SpotNodePool]; ok {
+			return rates
+		}
+	}
+
+	label := a.config.NodePoolLabel
+	if label == "" {
+		label = instanceTypeLabel
+	}
+	if pool, ok := node.Labels[label]; ok {
+		if rates, ok := a.config.NodePoolPricing[pool]; ok {
+			return rates
+		}
+	}
+
+	return pricing.NodeRates(a.config.Pricing, node.Name)
+}
+
+// hasFlatRates reports whether every node is priced at the default rates: no node pools are priced
+// and the provider is static with no per-node rates
+func (a *analyzer) hasFlatRates() bool {
+	if len(a.config.NodePoolPricing) > 0 {
+		return false
+	}
+	static, ok := a.config.Pricing.(*pricing.StaticProvider)
+	return ok && len(static.Nodes) == 0
+}
+
+// serviceRates returns the per-unit rates of a service, averaging the rates of the nodes its pods
+// run on so each pod is weighted by its node's price. Pods whose node can't be looked up are priced
+// at the provider's default rates. Nodes aren't looked up when every node has the same rates.
+func (a *analyzer) serviceRates(pods *servicePods) pricing.Rates {
+	if a.k8sClient == nil || len(pods.objects) == 0 || a.hasFlatRates() {
+		return a.flatRates()
+	}
+
+	var total pricing.Rates
+	for i := range pods.objects {
+		rates := a.podRates(pods, &pods.objects[i])
+		total.CPUCostPerVCPUHour += rates.CPUCostPerVCPUHour
+		total.MemoryCostPerGBHour += rates.MemoryCostPerGBHour
+	}
+
+	return pricing.Rates{
+		CPUCostPerVCPUHour:  total.CPUCostPerVCPUHour / float64(len(pods.objects)),
+		MemoryCostPerGBHour: total.MemoryCostPerGBHour / float64(len(pods.objects)),
+	}
+}
+
+// podRates returns the rates of the node a pod is scheduled on, or the default rates if it is unknown
+func (a *analyzer) podRates(pods *servicePods, pod *corev1.Pod) pricing.Rates {
+	if pod.Spec.NodeName == "" {
+		return a.flatRates()
+	}
+
+	node, err := a.node(pods, pod.Spec.NodeName)
+	if err != nil {
+		return a.flatRates()
+	}
+
+	return a.nodeRates(node)
+}
+
+// isSpotNode reports whether a node carries a spot taint, matched on "spot" in the taint's key or value
+func isSpotNode(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if strings.Contains(strings.ToLower(taint.Key), SpotNodePool) ||
+			strings.Contains(strings.ToLower(taint.Value), SpotNodePool) {
+			return true
+		}
+	}
+	return false
+}
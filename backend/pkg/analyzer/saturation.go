@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// saturationThreshold returns the fraction of the limit above which a metric is saturated
+func (a *analyzer) saturationThreshold(metric string) (float64, bool) {
+	switch metric {
+	case "cpu":
+		return a.config.CPUSaturationThreshold, a.config.CPUSaturationThreshold > 0
+	case "memory":
+		return a.config.MemorySaturationThreshold, a.config.MemorySaturationThreshold > 0
+	default:
+		return 0, false
+	}
+}
+
+// podLimit looks up the CPU (millicores) or memory (bytes) limit of a "pod/<name>" resource, summed
+// across its containers. It reports false without a Kubernetes client, when the pod cannot be found,
+// or when any container is unlimited.
+func (a *analyzer) podLimit(resource, metric string) (float64, bool) {
+	podName, ok := strings.CutPrefix(resource, "pod/")
+	if !ok || a.k8sClient == nil {
+		return 0, false
+	}
+
+	pods, err := a.k8sClient.Clientset.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{
+		FieldSelector: "metadata.name=" + podName,
+	})
+	if err != nil {
+		return 0, false
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Name != podName || len(pod.Spec.Containers) == 0 {
+			continue
+		}
+
+		var limit float64
+		for _, container := range pod.Spec.Containers {
+			quantity, ok := container.Resources.Limits[corev1.ResourceName(metric)]
+			if !ok {
+				return 0, false
+			}
+			if metric == "cpu" {
+				limit += float64(quantity.MilliValue())
+			} else {
+				limit += float64(quantity.Value())
+			}
+		}
+		return limit, limit > 0
+	}
+
+	return 0, false
+}
+
+// detectSaturationAnomalies flags sustained usage above a fraction of the limit, which statistical
+// detectors miss when usage is always high: memory near its limit risks OOM kills and CPU near its
+// limit is throttled. Each stretch above the threshold is reported once, at its peak, with severity
+// growing with how much of the headroom above the threshold it consumes.
+func (a *analyzer) detectSaturationAnomalies(points []models.DataPoint, metric string, limit, threshold float64) []models.Anomaly {
+	anomalies := []models.Anomaly{}
+	if limit <= 0 || threshold <= 0 || threshold >= 1 {
+		return anomalies
+	}
+
+	risk := "CPU throttling"
+	if metric == "memory" {
+		risk = "OOM kill"
+	}
+
+	for i := 0; i < len(points); i++ {
+		if points[i].Value <= threshold*limit {
+			continue
+		}
+
+		// Follow the stretch above the threshold to its end, tracking its peak
+		start, peak := i, points[i]
+		for i+1 < len(points) && points[i+1].Value > threshold*limit {
+			i++
+			if points[i].Value > peak.Value {
+				peak = points[i]
+			}
+		}
+		ratio := peak.Value / limit
+
+		anomalies = append(anomalies, models.Anomaly{
+			Type:     string(AnomalySaturation),
+			Severity: string(saturationSeverity(ratio, threshold)),
+			Description: fmt.Sprintf("Usage at %.1f%% of limit for %s (threshold %.0f%%): %s risk",
+				ratio*100, points[i].Timestamp.Sub(points[start].Timestamp).Round(time.Second), threshold*100, risk),
+			DetectedAt: peak.Timestamp,
+			Value:      peak.Value,
+			Expected:   threshold * limit,
+		})
+	}
+
+	return anomalies
+}
+
+// saturationSeverity grades saturation by the share of the headroom between the threshold and the
+// limit that usage has consumed
+func saturationSeverity(ratio, threshold float64) anomalySeverity {
+	consumed := (ratio - threshold) / (1 - threshold)
+	switch {
+	case consumed >= 0.75:
+		return SeverityCritical
+	case consumed >= 0.5:
+		return SeverityHigh
+	case consumed >= 0.25:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
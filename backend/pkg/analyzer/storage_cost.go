@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// networkTxMetric is the cumulative count of bytes a pod has transmitted, used to estimate egress cost
+const networkTxMetric = "network_tx_bytes"
+
+// bytesPerGB converts bytes to GB (1024 MB = 1 GB)
+const bytesPerGB = 1024.0 * 1024.0 * 1024.0
+
+// serviceStorageGB sums the capacity of the PersistentVolumeClaims mounted by a service's pods.
+// Claims shared by several pods are counted once. It returns 0 without a Kubernetes client.
+func (a *analyzer) serviceStorageGB(pods *servicePods) float64 {
+	if a.k8sClient == nil || a.config.StorageCostPerGBMonth == 0 {
+		return 0
+	}
+
+	ctx := context.Background()
+	namespace := pods.namespace
+	claims := make(map[string]bool)
+	total := 0.0
+	for _, pod := range pods.objects {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil || claims[volume.PersistentVolumeClaim.ClaimName] {
+				continue
+			}
+			claims[volume.PersistentVolumeClaim.ClaimName] = true
+
+			pvc, err := a.k8sClient.Clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, volume.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			total += float64(claimSize(pvc)) / bytesPerGB
+		}
+	}
+
+	return total
+}
+
+// servicePodObjects returns the pods of the deployment named service, or the pod named service
+// when there is no such deployment
+func (a *analyzer) servicePodObjects(ctx context.Context, namespace, service string) ([]corev1.Pod, error) {
+	deployment, err := a.k8sClient.Clientset.AppsV1().Deployments(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err == nil {
+		// A deployment without a selector matches no pods
+		if deployment.Spec.Selector == nil {
+			return nil, nil
+		}
+		return a.k8sClient.DeploymentPods(ctx, deployment)
+	}
+
+	pod, err := a.k8sClient.Clientset.CoreV1().Pods(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pods for %s/%s: %w", namespace, service, err)
+	}
+	return []corev1.Pod{*pod}, nil
+}
+
+// claimSize returns the bytes of a claim's bound capacity, or of its request while it is pending
+func claimSize(pvc *corev1.PersistentVolumeClaim) int64 {
+	if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		return capacity.Value()
+	}
+	if request, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		return request.Value()
+	}
+	return 0
+}
+
+// monthlyEgressGB extrapolates the bytes transmitted over a cumulative counter's points to a 30-day
+// month. Counter resets are detected as decreases and the value after a reset is counted as new bytes.
+func monthlyEgressGB(points []models.DataPoint) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+
+	transmitted := 0.0
+	for i := 1; i < len(points); i++ {
+		delta := points[i].Value - points[i-1].Value
+		if delta < 0 {
+			delta = points[i].Value
+		}
+		transmitted += delta
+	}
+
+	span := points[len(points)-1].Timestamp.Sub(points[0].Timestamp)
+	if span <= 0 {
+		return 0
+	}
+
+	month := 30 * 24 * time.Hour
+	return transmitted / bytesPerGB * float64(month) / float64(span)
+}
+
+// addStorageAndNetworkCost adds the monthly cost of a service's storage and egress, summed over its
+// pods' transmit counters, to its breakdown. Both stay zero when there are no claims or no transmit metrics.
+func (a *analyzer) addStorageAndNetworkCost(cost *models.CostBreakdown, storageGB float64, txSeries [][]models.DataPoint) {
+	egressGB := 0.0
+	for _, txPoints := range txSeries {
+		egressGB += monthlyEgressGB(txPoints)
+	}
+
+	storageCost := storageGB * a.config.StorageCostPerGBMonth
+	networkCost := egressGB * a.config.NetworkCostPerGB
+
+	cost.StorageCost = roundTo2Decimals(storageCost)
+	cost.NetworkCost = roundTo2Decimals(networkCost)
+	cost.TotalCost = roundTo2Decimals(cost.TotalCost + storageCost + networkCost)
+}
@@ -1,92 +1,97 @@
 package analyzer
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// AnalyzeTrafficPatterns analyzes traffic patterns for a service
+// AnalyzeTrafficPatterns analyzes traffic patterns for a service across all of its pods. Request
+// rates are summed over the pods, while latency percentiles are taken over every pod's samples.
 func (a *analyzer) AnalyzeTrafficPatterns(namespace, service string, duration time.Duration) (*models.TrafficAnalysis, error) {
-	// Get pod metrics for the service
-	// Since we're working with pod metrics, we need to find pods belonging to the service
-	// Pod naming convention: service-name-xxxxx
-	resource := fmt.Sprintf("pod/%s", service)
+	pods := a.findPodsByService(namespace, service)
 
-	// Get CPU time series to estimate request rate
-	cpuData, err := a.client.GetTimeSeriesData(resource, "cpu", duration)
+	// Get the service's total CPU to estimate request rate
+	cpuPoints, err := a.aggregatePodMetrics(pods, "cpu", duration, aggregateSum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CPU data: %w", err)
 	}
 
-	// If no data found, try to find pods with service prefix
-	if len(cpuData.Points) == 0 {
-		// Try to find any pod that starts with the service name
-		resource = fmt.Sprintf("pod/%s-", service)
-		cpuData, err = a.client.GetTimeSeriesData(resource, "cpu", duration)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get CPU data for service pods: %w", err)
+	analysis := &models.TrafficAnalysis{
+		Service:   service,
+		Namespace: namespace,
+		Anomalies: []models.Anomaly{},
+		Timestamp: time.Now(),
+	}
+
+	// Prefer measured request rate and latency when they have been recorded for the service
+	if requestPoints, err := a.aggregatePodMetrics(pods, collector.MetricRequests, duration, aggregateSum); err == nil && len(requestPoints) > 0 {
+		analysis.RequestRate = math.Max(0, a.calculateAverage(requestPoints))
+		analysis.RequestRateSource = TrafficSourceMeasured
+	}
+	if latencyPoints, err := a.aggregatePodMetrics(pods, collector.MetricLatencyMs, duration, aggregateCombine); err == nil && len(latencyPoints) > 0 {
+		p50, p95, p99, err := a.calculatePercentiles(latencyPoints)
+		if err == nil {
+			analysis.P50Latency = math.Max(0, p50)
+			analysis.P95Latency = math.Max(0, p95)
+			analysis.P99Latency = math.Max(0, p99)
+			analysis.LatencySource = TrafficSourceMeasured
 		}
 	}
 
-	if len(cpuData.Points) < a.config.MinDataPoints {
-		return &models.TrafficAnalysis{
-			Service:     service,
-			Namespace:   namespace,
-			RequestRate: 0,
-			ErrorRate:   0,
-			P50Latency:  0,
-			P95Latency:  0,
-			P99Latency:  0,
-			Anomalies:   []models.Anomaly{},
-			Timestamp:   time.Now(),
-		}, nil
+	if len(cpuPoints) < a.config.MinDataPoints {
+		return analysis, nil
 	}
 
-	// Calculate percentiles for CPU (will be used for latency estimation)
-	p50, p95, p99, err := a.calculatePercentiles(cpuData.Points)
+	// Calculate per-pod percentiles for CPU (will be used for latency estimation)
+	podCPUPoints, err := a.aggregatePodMetrics(pods, "cpu", duration, aggregateCombine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CPU data: %w", err)
+	}
+	p50, p95, p99, err := a.calculatePercentiles(podCPUPoints)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate percentiles: %w", err)
 	}
 
-	// Estimate request rate from CPU usage
+	// Without measured traffic, estimate request rate from CPU usage
 	// Assumption: Higher CPU = more requests
 	// Average CPU in millicores / 10 = requests per second (rough estimate)
-	avgCPU := a.calculateAverage(cpuData.Points)
-	requestRate := avgCPU / 10.0
+	if analysis.RequestRateSource == "" {
+		avgCPU := a.calculateAverage(cpuPoints)
+		analysis.RequestRate = math.Max(0, avgCPU/10.0)
+		analysis.RequestRateSource = TrafficSourceCPUEstimate
+	}
 
 	// Estimate error rate from pod restart patterns
 	// We'll detect anomalies in CPU which might indicate crashes/restarts
-	errorRate := a.estimateErrorRate(cpuData.Points)
+	analysis.ErrorRate = a.estimateErrorRate(cpuPoints)
 
-	// Estimate latency from CPU saturation
+	// Without measured latency, estimate it from CPU saturation
 	// Higher CPU utilization = higher latency
 	// P50 CPU / 10 = P50 latency in ms (rough estimate)
-	p50Latency := p50 / 10.0
-	p95Latency := p95 / 10.0
-	p99Latency := p99 / 10.0
+	if analysis.LatencySource == "" {
+		analysis.P50Latency = math.Max(0, p50/10.0)
+		analysis.P95Latency = math.Max(0, p95/10.0)
+		analysis.P99Latency = math.Max(0, p99/10.0)
+		analysis.LatencySource = TrafficSourceCPUEstimate
+	}
 
-	// Detect anomalies in the traffic pattern
-	anomalies, err := a.DetectAnomalies(resource, "cpu", duration)
-	if err != nil {
-		// Don't fail if anomaly detection fails
-		anomalies = []models.Anomaly{}
-	}
-
-	return &models.TrafficAnalysis{
-		Service:     service,
-		Namespace:   namespace,
-		RequestRate: math.Max(0, requestRate),
-		ErrorRate:   errorRate,
-		P50Latency:  math.Max(0, p50Latency),
-		P95Latency:  math.Max(0, p95Latency),
-		P99Latency:  math.Max(0, p99Latency),
-		Anomalies:   anomalies,
-		Timestamp:   time.Now(),
-	}, nil
+	// Detect anomalies in each pod's traffic pattern; don't fail if anomaly detection fails
+	for _, pod := range pods {
+		if anomalies, err := a.DetectAnomalies(pod, "cpu", duration); err == nil {
+			analysis.Anomalies = append(analysis.Anomalies, anomalies...)
+		}
+	}
+
+	return analysis, nil
 }
 
 // estimateErrorRate estimates error rate from CPU patterns
@@ -226,38 +231,192 @@ func (a *analyzer) detectPeriodicity(points []models.DataPoint) bool {
 	return variance > 0 && maxCorrelation > variance*0.5
 }
 
-// findPodsByService finds all pods belonging to a service
-func (a *analyzer) findPodsByService(namespace, service string, duration time.Duration) ([]string, error) {
-	// This is a helper to find pods that match the service name
-	// In practice, we would query the metrics store for all pods with the service prefix
-	// For now, we return a simple pattern
-	return []string{fmt.Sprintf("pod/%s", service)}, nil
+// podAggregation selects how aggregatePodMetrics combines the series of a service's pods
+type podAggregation int
+
+const (
+	// aggregateSum adds up the values pods report at the same time, giving the service's total
+	aggregateSum podAggregation = iota
+
+	// aggregateCombine pools every pod's samples, giving the per-pod distribution that
+	// percentiles are taken over
+	aggregateCombine
+)
+
+// servicePods is a service's pods, looked up once per request and shared by the usage, rate, node
+// share and storage calculations so none of them queries the API for the pods again
+type servicePods struct {
+	namespace string
+	resources []string     // metric resources, "pod/<name>"
+	objects   []corev1.Pod // empty without a Kubernetes client
+
+	// Nodes and the summed requests of the pods on them, fetched at most once per node; nil
+	// entries are nodes that couldn't be fetched
+	nodes        map[string]*corev1.Node
+	nodeRequests map[string]*[2]int64
+}
+
+// findPodsByService returns the metric resources ("pod/<name>") of every pod belonging to a service
+func (a *analyzer) findPodsByService(namespace, service string) []string {
+	return a.lookupServicePods(namespace, service).resources
+}
+
+// lookupServicePods finds the pods belonging to a service. With a Kubernetes client these are the
+// pods selected by the deployment named service, or the pod named service. Otherwise they are the
+// namespace's pods named after the service, as generated for Deployments (<service>-<hash>-<id>) and
+// StatefulSets (<service>-<ordinal>). When no pods are found, pod/<service> is the only resource so
+// series recorded under the service name are still read.
+func (a *analyzer) lookupServicePods(namespace, service string) *servicePods {
+	pods := &servicePods{
+		namespace:    namespace,
+		nodes:        make(map[string]*corev1.Node),
+		nodeRequests: make(map[string]*[2]int64),
+	}
+	if a.k8sClient != nil {
+		if objects, err := a.servicePodObjects(context.Background(), namespace, service); err == nil {
+			pods.objects = objects
+			for _, pod := range objects {
+				pods.resources = append(pods.resources, fmt.Sprintf("pod/%s", pod.Name))
+			}
+		}
+	} else if podMetrics, err := a.client.CollectPodMetrics(namespace); err == nil {
+		for _, pod := range podMetrics {
+			resource := fmt.Sprintf("pod/%s", pod.Name)
+			if pod.Name == service || matchesService(resource, service) {
+				pods.resources = append(pods.resources, resource)
+			}
+		}
+	}
+
+	if len(pods.resources) == 0 {
+		pods.resources = []string{fmt.Sprintf("pod/%s", service)}
+	}
+	sort.Strings(pods.resources)
+	return pods
 }
 
-// aggregatePodMetrics aggregates metrics from multiple pods
-func (a *analyzer) aggregatePodMetrics(podResources []string, metric string, duration time.Duration) ([]models.DataPoint, error) {
-	if len(podResources) == 0 {
-		return []models.DataPoint{}, nil
+// node returns a node the service's pods run on, fetching it on first use
+func (a *analyzer) node(pods *servicePods, name string) (*corev1.Node, error) {
+	node, ok := pods.nodes[name]
+	if !ok {
+		var err error
+		node, err = a.k8sClient.Clientset.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			node = nil
+		}
+		pods.nodes[name] = node
 	}
+	if node == nil {
+		return nil, fmt.Errorf("failed to get node %s", name)
+	}
+	return node, nil
+}
 
-	// For simplicity, we'll use the first pod's metrics
-	// In a full implementation, we would aggregate across all pods
-	data, err := a.client.GetTimeSeriesData(podResources[0], metric, duration)
+// aggregatePodMetrics aggregates a metric across the series of several pods, oldest point first
+func (a *analyzer) aggregatePodMetrics(podResources []string, metric string, duration time.Duration, mode podAggregation) ([]models.DataPoint, error) {
+	series, err := a.podSeries(podResources, metric, duration)
 	if err != nil {
-		return []models.DataPoint{}, err
+		return nil, err
+	}
+	if len(series) == 1 {
+		return series[0], nil
+	}
+
+	var points []models.DataPoint
+	switch mode {
+	case aggregateSum:
+		points = sumPodSeries(series, a.config.CollectionInterval)
+	case aggregateCombine:
+		for _, podPoints := range series {
+			points = append(points, podPoints...)
+		}
 	}
 
-	return data.Points, nil
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points, nil
 }
 
-// Helper function to check if a resource name matches a service
+// sumPodSeries adds up pod series into one point per interval-wide bucket. Each pod's sample is
+// stamped by metrics-server at its own scrape time, so pods rarely share a timestamp; a bucket sums
+// every pod's latest sample up to the bucket's end that is at most two intervals old, so a pod whose
+// sample falls just past a bucket boundary still counts in the bucket before it. Pods without a
+// recent sample, because they didn't exist yet or had gone, add nothing. Buckets are stamped with their start.
+func sumPodSeries(series [][]models.DataPoint, interval time.Duration) []models.DataPoint {
+	if interval <= 0 {
+		interval = defaultCollectionInterval
+	}
+
+	buckets := make(map[time.Time]bool)
+	sorted := make([][]models.DataPoint, len(series))
+	for i, podPoints := range series {
+		for _, point := range podPoints {
+			if point.Gap {
+				continue
+			}
+			sorted[i] = append(sorted[i], point)
+			buckets[point.Timestamp.Truncate(interval)] = true
+		}
+		sort.SliceStable(sorted[i], func(a, b int) bool {
+			return sorted[i][a].Timestamp.Before(sorted[i][b].Timestamp)
+		})
+	}
+
+	starts := make([]time.Time, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	next := make([]int, len(sorted)) // per pod, the first sample after the current bucket's end
+	points := make([]models.DataPoint, 0, len(starts))
+	for _, start := range starts {
+		end := start.Add(interval)
+		staleBefore := end.Add(-2 * interval)
+		total := 0.0
+		for i, podPoints := range sorted {
+			for next[i] < len(podPoints) && podPoints[next[i]].Timestamp.Before(end) {
+				next[i]++
+			}
+			if next[i] == 0 {
+				continue
+			}
+			if latest := podPoints[next[i]-1]; latest.Timestamp.After(staleBefore) {
+				total += latest.Value
+			}
+		}
+		points = append(points, models.DataPoint{Timestamp: start, Value: total})
+	}
+	return points
+}
+
+// podSeries returns each pod's points of a metric, in the order of podResources
+func (a *analyzer) podSeries(podResources []string, metric string, duration time.Duration) ([][]models.DataPoint, error) {
+	series := make([][]models.DataPoint, 0, len(podResources))
+	for _, resource := range podResources {
+		data, err := a.client.GetTimeSeriesData(resource, metric, duration)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, data.Points)
+	}
+	return series, nil
+}
+
+// matchesService reports whether a pod resource is named like a pod generated for the service:
+// "pod/<service>-<hash>-<id>" for a Deployment, or "pod/<service>-<suffix>" for a StatefulSet or
+// DaemonSet. Pods of other workloads whose names merely start with the service name, such as
+// "pod/<service>-api-<hash>-<id>", don't match.
 func matchesService(resource, service string) bool {
-	// Resource format: "pod/service-name-xxxxx"
-	parts := strings.Split(resource, "/")
-	if len(parts) != 2 {
+	podName, ok := strings.CutPrefix(resource, "pod/")
+	if !ok {
 		return false
 	}
 
-	podName := parts[1]
-	return strings.HasPrefix(podName, service+"-")
+	suffix, ok := strings.CutPrefix(podName, service+"-")
+	if !ok || suffix == "" {
+		return false
+	}
+	return strings.Count(suffix, "-") <= 1
 }
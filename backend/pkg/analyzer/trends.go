@@ -8,26 +8,26 @@ import (
 	"github.com/k8s-service-optimizer/backend/internal/models"
 )
 
-// PredictResourceNeeds predicts future resource requirements
+// PredictResourceNeeds predicts future resource requirements of a service, summed across its pods
 func (a *analyzer) PredictResourceNeeds(namespace, service string, hours int) (*models.ResourcePrediction, error) {
-	resource := fmt.Sprintf("pod/%s", service)
+	pods := a.findPodsByService(namespace, service)
 
 	// Use trend history from config
 	duration := time.Duration(a.config.TrendHistoryDays) * 24 * time.Hour
 
 	// Get CPU usage data
-	cpuData, err := a.client.GetTimeSeriesData(resource, "cpu", duration)
+	cpuPoints, err := a.aggregatePodMetrics(pods, "cpu", duration, aggregateSum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CPU data: %w", err)
 	}
 
 	// Get memory usage data
-	memData, err := a.client.GetTimeSeriesData(resource, "memory", duration)
+	memPoints, err := a.aggregatePodMetrics(pods, "memory", duration, aggregateSum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get memory data: %w", err)
 	}
 
-	if len(cpuData.Points) < a.config.MinDataPoints && len(memData.Points) < a.config.MinDataPoints {
+	if len(cpuPoints) < a.config.MinDataPoints && len(memPoints) < a.config.MinDataPoints {
 		return &models.ResourcePrediction{
 			Service:         service,
 			Namespace:       namespace,
@@ -39,55 +39,95 @@ func (a *analyzer) PredictResourceNeeds(namespace, service string, hours int) (*
 		}, nil
 	}
 
-	// Calculate trends for CPU
-	cpuTrend := a.calculateTrend(cpuData.Points)
-	memTrend := a.calculateTrend(memData.Points)
+	// Predict each resource from its seasonal pattern when it has one, otherwise from its linear trend
+	cpu := a.predictSeries(cpuPoints, hours)
+	mem := a.predictSeries(memPoints, hours)
 
-	// Get current values (latest or average)
-	currentCPU := 0.0
-	currentMem := 0.0
+	// Calculate confidence (average of CPU and memory)
+	confidence := (cpu.confidence + mem.confidence) / 2.0
 
-	if len(cpuData.Points) > 0 {
-		currentCPU = cpuData.Points[len(cpuData.Points)-1].Value
+	// Apply safety factor for low confidence, to the range as well as the prediction
+	safety := 1.0
+	if confidence < 0.5 {
+		// If confidence is low, add safety buffer
+		safety = 1.2
 	}
-	if len(memData.Points) > 0 {
-		currentMem = memData.Points[len(memData.Points)-1].Value
+
+	prediction := &models.ResourcePrediction{
+		Service:             service,
+		Namespace:           namespace,
+		Hours:               hours,
+		PredictedCPU:        int64(cpu.value * safety),
+		PredictedMemory:     int64(mem.value * safety),
+		PredictedCPULow:     int64(cpu.low * safety),
+		PredictedCPUHigh:    int64(cpu.high * safety),
+		PredictedMemoryLow:  int64(mem.low * safety),
+		PredictedMemoryHigh: int64(mem.high * safety),
+		Confidence:          roundTo2Decimals(confidence),
+		Timestamp:           time.Now(),
 	}
 
-	// Predict future values using linear regression
-	// Future value = current_value + (slope × time_delta)
-	timeDeltaHours := float64(hours)
+	// Report the CPU period, or the memory period when only memory is seasonal
+	switch {
+	case cpu.seasonal:
+		prediction.Seasonal, prediction.SeasonalPeriod = true, cpu.period
+	case mem.seasonal:
+		prediction.Seasonal, prediction.SeasonalPeriod = true, mem.period
+	}
 
-	predictedCPU := currentCPU + (cpuTrend.Slope * timeDeltaHours)
-	predictedMem := currentMem + (memTrend.Slope * timeDeltaHours)
+	return prediction, nil
+}
 
-	// Ensure predictions are non-negative
-	predictedCPU = math.Max(0, predictedCPU)
-	predictedMem = math.Max(0, predictedMem)
+// seriesPrediction is the predicted value of one resource, its best/worst case range, and how it
+// was derived
+type seriesPrediction struct {
+	value      float64
+	low, high  float64
+	confidence float64
+	seasonal   bool
+	period     time.Duration
+}
 
-	// Calculate confidence based on R² (average of CPU and memory)
-	confidence := (cpuTrend.RSquared + memTrend.RSquared) / 2.0
+// predictSeries predicts a series hoursAhead using predictWithSeasonality when it is seasonal, and
+// otherwise extends its linear trend from the latest value, with R² as the confidence. The range
+// around the prediction comes from forecastRange.
+func (a *analyzer) predictSeries(points []models.DataPoint, hoursAhead int) seriesPrediction {
+	if len(points) == 0 {
+		return seriesPrediction{}
+	}
 
-	// Apply safety factor for low confidence
-	if confidence < 0.5 {
-		// If confidence is low, add safety buffer
-		predictedCPU *= 1.2
-		predictedMem *= 1.2
-	}
-
-	return &models.ResourcePrediction{
-		Service:         service,
-		Namespace:       namespace,
-		Hours:           hours,
-		PredictedCPU:    int64(predictedCPU),
-		PredictedMemory: int64(predictedMem),
-		Confidence:      roundTo2Decimals(confidence),
-		Timestamp:       time.Now(),
-	}, nil
+	var prediction seriesPrediction
+	if hasSeason, period := a.detectSeasonality(points); hasSeason {
+		value, confidence := a.predictWithSeasonality(points, hoursAhead)
+		prediction = seriesPrediction{value: math.Max(0, value), confidence: confidence, seasonal: true, period: period}
+	} else {
+		// Future value = current_value + (slope × time_delta)
+		trend := a.calculateTrend(points)
+		current := points[len(points)-1].Value
+		prediction = seriesPrediction{
+			value:      math.Max(0, current+trend.Slope*float64(hoursAhead)),
+			confidence: trend.RSquared,
+		}
+	}
+
+	prediction.low, prediction.high = a.forecastRange(points, prediction.value, float64(hoursAhead))
+	return prediction
 }
 
 // calculateTrend calculates trend using simple linear regression
 func (a *analyzer) calculateTrend(points []models.DataPoint) trendData {
+	return linearRegression(points)
+}
+
+// LinearTrend fits a least-squares line through a series and returns its slope in value units per
+// hour, and R² in [0, 1] as how well the line explains the series
+func LinearTrend(points []models.DataPoint) (slopePerHour, rSquared float64) {
+	trend := linearRegression(points)
+	return trend.Slope, trend.RSquared
+}
+
+// linearRegression fits a line through a series against hours since its first point
+func linearRegression(points []models.DataPoint) trendData {
 	if len(points) < 2 {
 		return trendData{
 			Slope:      0,
@@ -186,17 +226,17 @@ func (a *analyzer) calculateConfidence(points []models.DataPoint, trend trendDat
 	return math.Min(1.0, confidence)
 }
 
-// forecastRange provides best/worst case predictions
-func (a *analyzer) forecastRange(points []models.DataPoint, trend trendData, hoursAhead float64) (best, worst float64) {
+// forecastRange provides best/worst case predictions around a point prediction hoursAhead. The
+// spread grows with the square root of the horizon in days, as errors accumulate the further out
+// the forecast reaches.
+func (a *analyzer) forecastRange(points []models.DataPoint, predicted, hoursAhead float64) (best, worst float64) {
 	if len(points) == 0 {
 		return 0, 0
 	}
 
-	// Calculate standard error
+	// Calculate standard error, widened for the horizon
 	stdDev := math.Sqrt(a.calculateVariance(points, a.calculateAverage(points)))
-
-	currentValue := points[len(points)-1].Value
-	predicted := a.predictValue(trend, currentValue, hoursAhead)
+	stdDev *= math.Sqrt(1 + math.Max(0, hoursAhead)/24)
 
 	// Best case: prediction - std dev
 	// Worst case: prediction + 2*std dev (to be safe)
@@ -258,8 +298,9 @@ func (a *analyzer) detectSeasonality(points []models.DataPoint) (hasSeason bool,
 	return false, 0
 }
 
-// exponentialSmoothing applies exponential smoothing for prediction
-func (a *analyzer) exponentialSmoothing(points []models.DataPoint, alpha float64) []float64 {
+// ExponentialSmoothing applies exponential smoothing to a series. Smaller alpha values smooth
+// more heavily; alpha of 1 returns the raw values.
+func ExponentialSmoothing(points []models.DataPoint, alpha float64) []float64 {
 	if len(points) == 0 {
 		return []float64{}
 	}
@@ -292,25 +333,39 @@ func (a *analyzer) predictWithSeasonality(points []models.DataPoint, hoursAhead
 	}
 
 	// With seasonality, use historical pattern
-	periodHours := int(period.Hours())
-	if periodHours == 0 {
-		periodHours = 24 // Default to daily
+	if period <= 0 {
+		period = 24 * time.Hour // Default to daily
 	}
 
-	// Find comparable historical point
-	offsetInPeriod := hoursAhead % periodHours
-	historicalIndex := len(points) - periodHours + offsetInPeriod
+	// Step the target time back by whole periods into the observed data, then use the closest sample
+	first := points[0].Timestamp
+	last := points[len(points)-1].Timestamp
+	target := last.Add(time.Duration(hoursAhead) * time.Hour)
+	for target.After(last) {
+		target = target.Add(-period)
+	}
 
-	if historicalIndex >= 0 && historicalIndex < len(points) {
-		prediction = points[historicalIndex].Value
-		confidence = 0.7 // Moderate confidence for seasonal prediction
-	} else {
+	if target.Before(first) {
 		// Fall back to average
-		prediction = a.calculateAverage(points)
-		confidence = 0.5
+		return a.calculateAverage(points), 0.5
+	}
+
+	closest := points[0]
+	for _, point := range points[1:] {
+		if absDuration(point.Timestamp.Sub(target)) < absDuration(closest.Timestamp.Sub(target)) {
+			closest = point
+		}
 	}
 
-	return prediction, confidence
+	return closest.Value, 0.7 // Moderate confidence for seasonal prediction
+}
+
+// absDuration returns the absolute value of a duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
 }
 
 // min returns the minimum of two integers
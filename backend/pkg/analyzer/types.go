@@ -3,8 +3,10 @@ package analyzer
 import (
 	"time"
 
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/internal/models"
 	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
 )
 
 // Analyzer defines the interface for traffic and cost analysis
@@ -15,26 +17,58 @@ type Analyzer interface {
 	// CalculateServiceCost calculates the cost for a specific service
 	CalculateServiceCost(namespace, service string) (*models.CostBreakdown, error)
 
+	// CalculateNamespaceCost sums the cost of every deployment in a namespace
+	CalculateNamespaceCost(namespace string) (*models.NamespaceCost, error)
+
 	// DetectAnomalies detects anomalies in metrics
 	DetectAnomalies(resource, metric string, duration time.Duration) ([]models.Anomaly, error)
 
+	// DetectAnomaliesWithMethods detects anomalies in metrics using only the given detection methods
+	DetectAnomaliesWithMethods(resource, metric string, duration time.Duration, methods []string) ([]models.Anomaly, error)
+
 	// PredictResourceNeeds predicts future resource requirements
 	PredictResourceNeeds(namespace, service string, hours int) (*models.ResourcePrediction, error)
 
-	// GetCostTrends gets cost trends over time
-	GetCostTrends(namespace string, duration time.Duration) ([]models.CostBreakdown, error)
+	// GetCostTrends samples a service's cost over the duration in 6h windows, oldest first
+	GetCostTrends(namespace, service string, duration time.Duration) ([]models.CostBreakdown, error)
 
 	// CalculateWaste calculates wasted resources (over-provisioning)
 	CalculateWaste(namespace, service string) (float64, error)
+
+	// GetConfig returns the analyzer configuration
+	GetConfig() Config
 }
 
 // Config holds analyzer configuration
 type Config struct {
-	// CPUCostPerVCPUHour is the cost per vCPU-hour (1000 millicores = 1 vCPU)
-	CPUCostPerVCPUHour float64
+	// Pricing supplies the CPU and memory rates of the nodes pods run on; nil uses
+	// pricing.DefaultProvider
+	Pricing pricing.PricingProvider
+
+	// FractionalNodeCost attributes to each pod its request share of the hosting node's price
+	// instead of flat per-unit rates. Requires a Kubernetes client (see NewWithClient).
+	FractionalNodeCost bool
+
+	// NodeHourlyPrices maps node instance types (node.kubernetes.io/instance-type) to hourly prices.
+	// Nodes without a listed price are priced from their allocatable resources at the flat rates.
+	NodeHourlyPrices map[string]float64
+
+	// NodePoolPricing maps node pools to per-unit rates, so pods on cheaper pools such as spot
+	// instances are priced lower. A node is in the SpotNodePool pool if it carries a spot taint and
+	// that pool is priced, otherwise in the pool named by its NodePoolLabel value. Pods on nodes in
+	// unpriced pools, or whose node is unknown, use the Pricing provider. Requires a Kubernetes client.
+	NodePoolPricing map[string]pricing.Rates
+
+	// NodePoolLabel is the node label naming a node's pool in NodePoolPricing
+	NodePoolLabel string
 
-	// MemoryCostPerGBHour is the cost per GB-hour (1024 MB = 1 GB)
-	MemoryCostPerGBHour float64
+	// StorageCostPerGBMonth is the monthly cost per GB of PersistentVolumeClaim capacity mounted by
+	// a service's pods; 0 disables storage cost. Requires a Kubernetes client.
+	StorageCostPerGBMonth float64
+
+	// NetworkCostPerGB is the cost per GB of egress, estimated from the "network_tx_bytes" counter the
+	// collector stores with CollectWorkingSet; 0 disables network cost
+	NetworkCostPerGB float64
 
 	// AnomalyThreshold is the Z-score threshold for anomaly detection
 	AnomalyThreshold float64
@@ -48,34 +82,73 @@ type Config struct {
 	// MinDataPoints is the minimum number of data points required for analysis
 	MinDataPoints int
 
+	// CollectionInterval is how often the collector samples pod metrics. Service totals add up the
+	// pods' samples in buckets of this width, since each pod is stamped with its own scrape time.
+	CollectionInterval time.Duration
+
 	// TrendHistoryDays is the number of days to use for trend analysis
 	TrendHistoryDays int
+
+	// CostConcurrency is how many services CalculateNamespaceCost prices at once
+	CostConcurrency int
+
+	// AnomalyDedupWindow is how close in time anomalies of the same type reported by different
+	// detection methods must be to be collapsed into one
+	AnomalyDedupWindow time.Duration
+
+	// MovingAverageWindow is how many preceding points the moving-average anomaly detector averages
+	MovingAverageWindow int
+
+	// CPUSaturationThreshold is the fraction of a pod's CPU limit above which usage is reported as
+	// saturated, since CPU is throttled at the limit; 0 disables
+	CPUSaturationThreshold float64
+
+	// MemorySaturationThreshold is the fraction of a pod's memory limit above which usage is reported
+	// as saturated, since the pod is OOM-killed at the limit; 0 disables
+	MemorySaturationThreshold float64
 }
 
 // DefaultConfig returns default analyzer configuration
 func DefaultConfig() Config {
 	return Config{
-		CPUCostPerVCPUHour:  0.03,   // $0.03 per vCPU-hour
-		MemoryCostPerGBHour: 0.004,  // $0.004 per GB-hour
-		AnomalyThreshold:    3.0,    // 3 standard deviations
-		SpikeThreshold:      2.0,    // 2x normal
-		DropThreshold:       0.5,    // 0.5x normal
-		MinDataPoints:       10,     // Minimum points for meaningful analysis
-		TrendHistoryDays:    7,      // 7 days of history
+		Pricing:                   pricing.DefaultProvider(),
+		AnomalyThreshold:          3.0, // 3 standard deviations
+		SpikeThreshold:            2.0, // 2x normal
+		DropThreshold:             0.5, // 0.5x normal
+		MinDataPoints:             10,  // Minimum points for meaningful analysis
+		TrendHistoryDays:          7,   // 7 days of history
+		CostConcurrency:           8,   // Services priced at once per namespace
+		NodePoolLabel:             instanceTypeLabel,
+		StorageCostPerGBMonth:     0.10, // $0.10 per GB-month
+		NetworkCostPerGB:          0.09, // $0.09 per GB of egress
+		AnomalyDedupWindow:        30 * time.Second,
+		CollectionInterval:        defaultCollectionInterval,
+		MovingAverageWindow:       10,
+		CPUSaturationThreshold:    0.8, // 80% of the CPU limit
+		MemorySaturationThreshold: 0.9, // 90% of the memory limit
 	}
 }
 
+// defaultCollectionInterval matches the collector's default CollectionInterval
+const defaultCollectionInterval = 15 * time.Second
+
 // trafficPattern represents the detected traffic pattern
 type trafficPattern string
 
 const (
-	PatternSteady    trafficPattern = "steady"
-	PatternSpiking   trafficPattern = "spiking"
-	PatternPeriodic  trafficPattern = "periodic"
-	PatternDeclining trafficPattern = "declining"
+	PatternSteady     trafficPattern = "steady"
+	PatternSpiking    trafficPattern = "spiking"
+	PatternPeriodic   trafficPattern = "periodic"
+	PatternDeclining  trafficPattern = "declining"
 	PatternIncreasing trafficPattern = "increasing"
 )
 
+// Sources of the request rate and latency reported in a traffic analysis
+const (
+	TrafficSourceMeasured    = "measured"     // from recorded request and latency metrics
+	TrafficSourceCPUEstimate = "cpu_estimate" // derived from CPU usage when no traffic metrics exist
+)
+
 // anomalyType represents the type of anomaly
 type anomalyType string
 
@@ -85,8 +158,45 @@ const (
 	AnomalyDrift       anomalyType = "drift"
 	AnomalyOscillation anomalyType = "oscillation"
 	AnomalyErrorSpike  anomalyType = "error_spike"
+	AnomalySaturation  anomalyType = "saturation"
+)
+
+// Anomaly detection methods selectable with DetectAnomaliesWithMethods
+const (
+	AnomalyMethodZScore      = "zscore"
+	AnomalyMethodSpike       = "spike"
+	AnomalyMethodDrop        = "drop"
+	AnomalyMethodDrift       = "drift"
+	AnomalyMethodOscillation = "oscillation"
+	AnomalyMethodMovingAvg   = "moving_average"
+	AnomalyMethodRateChange  = "rate_of_change"
+	AnomalyMethodSaturation  = "saturation"
 )
 
+// AnomalyMethods lists every anomaly detection method, in the order they are run
+var AnomalyMethods = []string{
+	AnomalyMethodZScore,
+	AnomalyMethodSpike,
+	AnomalyMethodDrop,
+	AnomalyMethodDrift,
+	AnomalyMethodOscillation,
+	AnomalyMethodMovingAvg,
+	AnomalyMethodRateChange,
+	AnomalyMethodSaturation,
+}
+
+// DefaultAnomalyMethods are the methods run when none are selected. Saturation is only detected for
+// pods whose limits can be looked up (see NewWithClient). The moving-average and
+// rate-of-change detectors overlap heavily with these and only run when selected.
+var DefaultAnomalyMethods = []string{
+	AnomalyMethodZScore,
+	AnomalyMethodSpike,
+	AnomalyMethodDrop,
+	AnomalyMethodDrift,
+	AnomalyMethodOscillation,
+	AnomalyMethodSaturation,
+}
+
 // anomalySeverity represents the severity of an anomaly
 type anomalySeverity string
 
@@ -108,5 +218,6 @@ type trendData struct {
 // analyzer implements the Analyzer interface
 type analyzer struct {
 	client    collector.MetricsCollector
+	k8sClient *k8s.Client
 	config    Config
 }
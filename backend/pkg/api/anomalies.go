@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// anomalyStreamWindow is the window scanned for anomalies on each broadcast tick, and how long
+// broadcast anomalies are remembered
+const anomalyStreamWindow = time.Hour
+
+// AcknowledgeAnomalyRequest represents a request to snooze an anomaly
+type AcknowledgeAnomalyRequest struct {
+	Fingerprint string `json:"fingerprint"`
+	Duration    string `json:"duration"` // snooze duration, e.g. "1h"
+}
+
+// AcknowledgeAnomalyResponse represents the response for acknowledging an anomaly
+type AcknowledgeAnomalyResponse struct {
+	Fingerprint  string    `json:"fingerprint"`
+	SnoozedUntil time.Time `json:"snoozed_until"`
+}
+
+// anomalyStore tracks acknowledged anomalies and when their snooze expires
+type anomalyStore struct {
+	mu   sync.Mutex
+	acks map[string]time.Time // fingerprint -> snoozed until
+	now  func() time.Time
+}
+
+// newAnomalyStore creates an empty anomaly store
+func newAnomalyStore() *anomalyStore {
+	return &anomalyStore{
+		acks: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+// Acknowledge snoozes an anomaly fingerprint for the given duration
+func (st *anomalyStore) Acknowledge(fingerprint string, duration time.Duration) time.Time {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	until := st.now().Add(duration)
+	st.acks[fingerprint] = until
+	return until
+}
+
+// IsSnoozed reports whether an anomaly fingerprint is currently acknowledged
+func (st *anomalyStore) IsSnoozed(fingerprint string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	until, ok := st.acks[fingerprint]
+	if !ok {
+		return false
+	}
+	if !st.now().Before(until) {
+		delete(st.acks, fingerprint)
+		return false
+	}
+	return true
+}
+
+// Filter returns the anomalies that are not currently snoozed
+func (st *anomalyStore) Filter(anomalies []models.Anomaly) []models.Anomaly {
+	visible := make([]models.Anomaly, 0, len(anomalies))
+	for _, anomaly := range anomalies {
+		if anomaly.Fingerprint != "" && st.IsSnoozed(anomaly.Fingerprint) {
+			continue
+		}
+		visible = append(visible, anomaly)
+	}
+	return visible
+}
+
+// handleAcknowledgeAnomaly handles snoozing an anomaly until its acknowledgement expires
+func (s *Server) handleAcknowledgeAnomaly(w http.ResponseWriter, r *http.Request) {
+	var req AcknowledgeAnomalyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.Fingerprint == "" {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", "fingerprint is required")
+		return
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid snooze duration %q", req.Duration))
+		return
+	}
+
+	until := s.anomalies.Acknowledge(req.Fingerprint, duration)
+
+	respondWithSuccess(w, AcknowledgeAnomalyResponse{
+		Fingerprint:  req.Fingerprint,
+		SnoozedUntil: until,
+	})
+}
+
+// broadcastLog remembers which anomalies have been broadcast, so each detection is pushed once
+type broadcastLog struct {
+	mu   sync.Mutex
+	sent map[string]time.Time // broadcast key -> detected at
+}
+
+// newBroadcastLog creates an empty broadcast log
+func newBroadcastLog() *broadcastLog {
+	return &broadcastLog{sent: make(map[string]time.Time)}
+}
+
+// broadcastKey identifies a single detection of an anomaly by type, resource, metric and detection time
+func broadcastKey(anomaly models.Anomaly) string {
+	return fmt.Sprintf("%s/%s/%s/%d", anomaly.Type, anomaly.Resource, anomaly.Metric, anomaly.DetectedAt.UnixNano())
+}
+
+// Unsent records the anomalies and returns those not broadcast before. Entries detected before
+// cutoff are evicted first: detection no longer reaches back that far, so they cannot recur.
+func (l *broadcastLog) Unsent(anomalies []models.Anomaly, cutoff time.Time) []models.Anomaly {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, detectedAt := range l.sent {
+		if detectedAt.Before(cutoff) {
+			delete(l.sent, key)
+		}
+	}
+
+	var unsent []models.Anomaly
+	for _, anomaly := range anomalies {
+		if anomaly.DetectedAt.Before(cutoff) {
+			continue
+		}
+		key := broadcastKey(anomaly)
+		if _, ok := l.sent[key]; ok {
+			continue
+		}
+		l.sent[key] = anomaly.DetectedAt
+		unsent = append(unsent, anomaly)
+	}
+	return unsent
+}
+
+// Size returns the number of remembered broadcasts
+func (l *broadcastLog) Size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.sent)
+}
+
+// detectDeploymentAnomalies runs CPU and memory anomaly detection over the pods of every deployment
+// in the monitored namespaces, dropping snoozed anomalies
+func (s *Server) detectDeploymentAnomalies(ctx context.Context) []models.Anomaly {
+	var detected []models.Anomaly
+	for _, namespace := range s.config.Namespaces {
+		deployments, err := s.listDeployments(ctx, namespace)
+		if err != nil {
+			slog.Warn("Failed to list deployments for anomaly broadcast", "namespace", namespace, "error", err)
+			continue
+		}
+
+		for i := range deployments {
+			pods, err := s.deploymentPods(ctx, &deployments[i])
+			if err != nil {
+				continue
+			}
+			for _, pod := range pods {
+				resource := fmt.Sprintf("pod/%s", pod.Name)
+				for _, metric := range []string{"cpu", "memory"} {
+					anomalies, err := s.analyzer.DetectAnomalies(resource, metric, anomalyStreamWindow)
+					if err != nil {
+						continue
+					}
+					detected = append(detected, s.anomalies.Filter(anomalies)...)
+				}
+			}
+		}
+	}
+	return detected
+}
@@ -1,12 +1,304 @@
 package api
 
 import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/analyzer"
+	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	"github.com/k8s-service-optimizer/backend/pkg/optimizer"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
+// mockOptimizer is a mock implementation of optimizer.Optimizer for testing
+type mockOptimizer struct {
+	recommendations []models.Recommendation
+	summaries       []models.DeploymentSummary
+	nodeSizing      []models.NodeSizingRecommendation
+	analysisErrors  map[string]error // keyed by namespace/name
+	idle            []models.IdleWorkload
+	vpa             *models.VPARecommendation
+	config          *optimizer.Config // returned by GetConfig; nil returns the default configuration
+	commands        map[string]string // returned by RecommendationCommand, keyed by recommendation ID
+	revertErrors    map[string]error  // returned by RevertRecommendation, keyed by recommendation ID
+}
+
+func (m *mockOptimizer) AnalyzeDeployment(namespace, name string) (*models.Analysis, error) {
+	if err := m.analysisErrors[namespace+"/"+name]; err != nil {
+		return nil, err
+	}
+	return &models.Analysis{Namespace: namespace, Deployment: name}, nil
+}
+func (m *mockOptimizer) AnalyzeDeploymentWithWindow(namespace, name string, window time.Duration) (*models.Analysis, error) {
+	return &models.Analysis{Namespace: namespace, Deployment: name, Window: window}, nil
+}
+func (m *mockOptimizer) AnalyzeWorkload(namespace, kind, name string) (*models.Analysis, error) {
+	return &models.Analysis{Namespace: namespace, Deployment: name, Kind: kind}, nil
+}
+func (m *mockOptimizer) GenerateRecommendations(analysis *models.Analysis) ([]models.Recommendation, error) {
+	return m.recommendations, nil
+}
+func (m *mockOptimizer) CalculateEfficiencyScore(namespace, name string) (float64, error) {
+	return 0, nil
+}
+func (m *mockOptimizer) EstimateCostSavings(recommendation *models.Recommendation) (float64, error) {
+	return recommendation.EstimatedSavings, nil
+}
+func (m *mockOptimizer) ApplyRecommendation(recommendationID string, dryRun bool) (*models.RecommendationPatch, error) {
+	return nil, m.unknownRecommendation(recommendationID)
+}
+func (m *mockOptimizer) RecommendationManifest(recommendationID string) (string, error) {
+	return "", m.unknownRecommendation(recommendationID)
+}
+func (m *mockOptimizer) RecommendationCommand(recommendationID string) (string, error) {
+	if command, ok := m.commands[recommendationID]; ok {
+		return command, nil
+	}
+	return "", m.unknownRecommendation(recommendationID)
+}
+func (m *mockOptimizer) RevertRecommendation(recommendationID string) error {
+	if err, ok := m.revertErrors[recommendationID]; ok {
+		return err
+	}
+	return m.unknownRecommendation(recommendationID)
+}
+
+// unknownRecommendation returns ErrNotFound for IDs the mock doesn't hold, like the optimizer, and a
+// generic error for the rest
+func (m *mockOptimizer) unknownRecommendation(recommendationID string) error {
+	for _, rec := range m.recommendations {
+		if rec.ID == recommendationID {
+			return fmt.Errorf("not implemented")
+		}
+	}
+	return fmt.Errorf("recommendation %w: %s", optimizer.ErrNotFound, recommendationID)
+}
+func (m *mockOptimizer) DismissRecommendation(recommendationID string) error {
+	for i, rec := range m.recommendations {
+		if rec.ID == recommendationID {
+			m.recommendations = append(m.recommendations[:i], m.recommendations[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("recommendation not found: %s", recommendationID)
+}
+func (m *mockOptimizer) GetAllRecommendations() ([]models.Recommendation, error) {
+	return m.recommendations, nil
+}
+func (m *mockOptimizer) GetActiveRecommendations() ([]models.Recommendation, error) {
+	var active []models.Recommendation
+	for _, rec := range m.recommendations {
+		if rec.AppliedAt.IsZero() {
+			active = append(active, rec)
+		}
+	}
+	return active, nil
+}
+func (m *mockOptimizer) GetRecommendationStats() map[string]interface{} {
+	byType := map[string]int{"resource": 0, "hpa": 0}
+	priorities := map[string]int{}
+	deployments := map[string]bool{}
+	total, savings := 0, 0.0
+	for _, rec := range m.recommendations {
+		if !rec.AppliedAt.IsZero() {
+			continue
+		}
+		total++
+		byType[rec.Type]++
+		priorities[rec.Priority]++
+		deployments[rec.Namespace+"/"+rec.Deployment] = true
+		savings += rec.EstimatedSavings
+	}
+	return map[string]interface{}{
+		"total":                total,
+		"high_priority":        priorities["high"],
+		"medium_priority":      priorities["medium"],
+		"low_priority":         priorities["low"],
+		"by_type":              byType,
+		"total_savings":        savings,
+		"cash_savings":         savings,
+		"reclaimable_savings":  0.0,
+		"affected_deployments": len(deployments),
+	}
+}
+func (m *mockOptimizer) GetTotalPotentialSavings() (float64, error) {
+	total := 0.0
+	for _, rec := range m.recommendations {
+		if rec.AppliedAt.IsZero() {
+			total += rec.EstimatedSavings
+		}
+	}
+	return total, nil
+}
+func (m *mockOptimizer) AnalyzeContainer(namespace, name, container string) (*models.ContainerAnalysis, error) {
+	return &models.ContainerAnalysis{Namespace: namespace, Deployment: name, Container: container}, nil
+}
+func (m *mockOptimizer) SimulateRecommendations(recommendationIDs []string) (*models.WhatIfSimulation, error) {
+	return &models.WhatIfSimulation{RecommendationIDs: recommendationIDs}, nil
+}
+func (m *mockOptimizer) SimulateHPA(namespace, name string, proposed optimizer.HPAConfig) (*models.HPASimulation, error) {
+	return &models.HPASimulation{
+		Namespace:   namespace,
+		Deployment:  name,
+		MinReplicas: proposed.MinReplicas,
+		MaxReplicas: proposed.MaxReplicas,
+		TargetCPU:   proposed.TargetCPU,
+	}, nil
+}
+
+func (m *mockOptimizer) GetDeploymentSummaries() []models.DeploymentSummary {
+	return m.summaries
+}
+func (m *mockOptimizer) RecommendNodeSizing() ([]models.NodeSizingRecommendation, error) {
+	return m.nodeSizing, nil
+}
+func (m *mockOptimizer) RecommendNodeConsolidation() (*models.NodeConsolidation, error) {
+	return nil, nil
+}
+func (m *mockOptimizer) FindIdleWorkloads(namespaces []string) ([]models.IdleWorkload, error) {
+	return m.idle, nil
+}
+func (m *mockOptimizer) RecommendVPA(namespace, name string) (*models.VPARecommendation, error) {
+	if err := m.analysisErrors[namespace+"/"+name]; err != nil {
+		return nil, err
+	}
+	return m.vpa, nil
+}
+func (m *mockOptimizer) GetConfig() optimizer.Config {
+	if m.config != nil {
+		return *m.config
+	}
+	return optimizer.DefaultConfig()
+}
+func (m *mockOptimizer) UpdateConfig(update optimizer.ConfigUpdate) (optimizer.Config, error) {
+	return optimizer.DefaultConfig(), nil
+}
+
+// mockCollector is a mock implementation of collector.MetricsCollector for testing
+type mockCollector struct {
+	nodeMetrics    []models.NodeMetrics
+	nodeMetricsErr error
+	pods           []models.PodMetrics
+	series         map[string][]models.DataPoint // keyed by resource/metric
+	seriesErrors   map[string]error              // keyed by resource/metric
+	queries        atomic.Int64                  // time series reads
+}
+
+func (m *mockCollector) Start() error { return nil }
+func (m *mockCollector) Stop()        {}
+func (m *mockCollector) CollectPodMetrics(namespace string) ([]models.PodMetrics, error) {
+	pods := []models.PodMetrics{}
+	for _, pod := range m.pods {
+		if pod.Namespace == namespace {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+func (m *mockCollector) CollectNodeMetrics() ([]models.NodeMetrics, error) {
+	return m.nodeMetrics, m.nodeMetricsErr
+}
+func (m *mockCollector) CollectHPAMetrics(namespace string) ([]models.HPAMetrics, error) {
+	return []models.HPAMetrics{}, nil
+}
+func (m *mockCollector) GetTimeSeriesData(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
+	m.queries.Add(1)
+	if err := m.seriesErrors[resource+"/"+metric]; err != nil {
+		return models.TimeSeriesData{}, err
+	}
+	cutoff := time.Now().Add(-duration)
+	points := []models.DataPoint{}
+	for _, point := range m.series[resource+"/"+metric] {
+		if point.Timestamp.After(cutoff) {
+			points = append(points, point)
+		}
+	}
+	return models.TimeSeriesData{Resource: resource, Metric: metric, Points: points}, nil
+}
+func (m *mockCollector) GetTimeSeriesDataWithGaps(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
+	return m.GetTimeSeriesData(resource, metric, duration)
+}
+func (m *mockCollector) GetResourcePercentiles(resource, metric string, duration time.Duration) (p50, p95, p99 float64, err error) {
+	return 0, 0, 0, nil
+}
+
+// mockAnalyzer is a mock implementation of analyzer.Analyzer for testing
+type mockAnalyzer struct {
+	anomalies map[string][]models.Anomaly // keyed by resource/metric
+}
+
+func (m *mockAnalyzer) AnalyzeTrafficPatterns(namespace, service string, duration time.Duration) (*models.TrafficAnalysis, error) {
+	return &models.TrafficAnalysis{Service: service, Namespace: namespace}, nil
+}
+func (m *mockAnalyzer) CalculateServiceCost(namespace, service string) (*models.CostBreakdown, error) {
+	return &models.CostBreakdown{Service: service, Namespace: namespace}, nil
+}
+func (m *mockAnalyzer) CalculateNamespaceCost(namespace string) (*models.NamespaceCost, error) {
+	return &models.NamespaceCost{Namespace: namespace}, nil
+}
+func (m *mockAnalyzer) DetectAnomalies(resource, metric string, duration time.Duration) ([]models.Anomaly, error) {
+	return m.anomalies[resource+"/"+metric], nil
+}
+func (m *mockAnalyzer) DetectAnomaliesWithMethods(resource, metric string, duration time.Duration, methods []string) ([]models.Anomaly, error) {
+	return m.anomalies[resource+"/"+metric], nil
+}
+func (m *mockAnalyzer) PredictResourceNeeds(namespace, service string, hours int) (*models.ResourcePrediction, error) {
+	return &models.ResourcePrediction{Service: service, Namespace: namespace, Hours: hours}, nil
+}
+func (m *mockAnalyzer) GetCostTrends(namespace, service string, duration time.Duration) ([]models.CostBreakdown, error) {
+	return []models.CostBreakdown{}, nil
+}
+func (m *mockAnalyzer) GetConfig() analyzer.Config {
+	return analyzer.DefaultConfig()
+}
+func (m *mockAnalyzer) CalculateWaste(namespace, service string) (float64, error) {
+	return 0, nil
+}
+
+// newTestResourceRecommendation returns a CPU right-sizing recommendation
+func newTestResourceRecommendation() models.Recommendation {
+	return models.Recommendation{
+		ID:          "rec-1",
+		Type:        "resource",
+		Namespace:   "default",
+		Deployment:  "web",
+		Priority:    "high",
+		Description: "Reduce CPU request from 1 to 250m",
+		CurrentConfig: map[string]interface{}{
+			"cpu_request": "1",
+			"cpu_limit":   "2",
+		},
+		RecommendedConfig: map[string]interface{}{
+			"cpu_request": "250m",
+			"cpu_limit":   "500m",
+		},
+		EstimatedSavings: 16.2,
+		Impact:           "Low risk - reducing over-provisioned resources",
+		CreatedAt:        time.Now(),
+	}
+}
+
 // TestRespondWithSuccess tests the respondWithSuccess function
 func TestRespondWithSuccess(t *testing.T) {
 	w := httptest.NewRecorder()
@@ -40,6 +332,37 @@ func TestRespondWithError(t *testing.T) {
 	}
 }
 
+// TestLoggingMiddleware tests request logging levels and that failures carry the request ID
+func TestLoggingMiddleware(t *testing.T) {
+	var buf strings.Builder
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	defer slog.SetDefault(previous)
+
+	handler := requestIDMiddleware(loggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			respondWithError(w, http.StatusInternalServerError, "METRICS_ERROR", "collector down")
+			return
+		}
+		respondWithSuccess(w, nil)
+	})))
+
+	req := httptest.NewRequest("GET", "/fail", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	logs := buf.String()
+	for _, want := range []string{"level=ERROR", "request_id=req-42", "error_code=METRICS_ERROR", `error="collector down"`} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("Expected %q in request log, got %s", want, logs)
+		}
+	}
+	if strings.Contains(logs, "path=/health") {
+		t.Errorf("Expected health checks to be logged below info level, got %s", logs)
+	}
+}
+
 // TestParseTimeSeriesQueryParams tests the parseTimeSeriesQueryParams function
 func TestParseTimeSeriesQueryParams(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/v1/metrics/timeseries?resource=node/worker-1&metric=cpu&duration=1h", nil)
@@ -78,6 +401,23 @@ func TestParseAnomalyQueryParams(t *testing.T) {
 	if params.Duration != 24*time.Hour {
 		t.Errorf("Expected duration 24h, got %v", params.Duration)
 	}
+	if params.Methods != nil {
+		t.Errorf("Expected all methods by default, got %v", params.Methods)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/anomalies?resource=pod/test&methods=zscore,%20Spike,drift", nil)
+	params, err = parseAnomalyQueryParams(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(params.Methods, []string{"zscore", "spike", "drift"}) {
+		t.Errorf("Expected methods [zscore spike drift], got %v", params.Methods)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/anomalies?resource=pod/test&methods=zscore,fourier", nil)
+	if _, err := parseAnomalyQueryParams(req); err == nil {
+		t.Error("Expected an error for an unknown detection method")
+	}
 }
 
 // TestWebSocketHubCreation tests creating a WebSocket hub
@@ -114,3 +454,1624 @@ func TestGetClientCount(t *testing.T) {
 		t.Errorf("Expected 0 clients, got %d", count)
 	}
 }
+
+// TestWebSocketTopicSubscriptions tests that clients only receive the topics they subscribed to
+func TestWebSocketTopicSubscriptions(t *testing.T) {
+	hub := NewWebSocketHub()
+	go hub.Run()
+
+	recommendationsOnly := &Client{hub: hub, send: make(chan []byte, 16)}
+	everything := &Client{hub: hub, send: make(chan []byte, 16)}
+	hub.register <- recommendationsOnly
+	hub.register <- everything
+
+	recommendationsOnly.handleMessage([]byte(`{"action":"subscribe","topics":["recommendations"]}`))
+
+	hub.Broadcast("metrics_update", map[string]interface{}{"type": "nodes"})
+	hub.Broadcast("recommendation_new", map[string]interface{}{"count": 1})
+
+	receive := func(client *Client) []string {
+		var types []string
+		timeout := time.After(time.Second)
+		for len(types) < 2 {
+			select {
+			case data := <-client.send:
+				var message WebSocketMessage
+				if err := json.Unmarshal(data, &message); err != nil {
+					t.Fatalf("Failed to decode message: %v", err)
+				}
+				types = append(types, message.Type)
+			case <-timeout:
+				return types
+			}
+		}
+		return types
+	}
+
+	if got := receive(everything); len(got) != 2 || got[0] != "metrics_update" || got[1] != "recommendation_new" {
+		t.Errorf("Expected a client without a subscription to receive every message, got %v", got)
+	}
+	if got := receive(recommendationsOnly); len(got) != 1 || got[0] != "recommendation_new" {
+		t.Errorf("Expected only recommendation_new for a recommendations subscriber, got %v", got)
+	}
+
+	// Unsubscribing from everything but status leaves only status updates
+	everything.handleMessage([]byte(`{"action":"unsubscribe","topics":["metrics","recommendations","anomalies"]}`))
+	hub.Broadcast("metrics_update", map[string]interface{}{"type": "nodes"})
+	hub.Broadcast("status_update", map[string]interface{}{"status": "operational"})
+	if got := receive(everything); len(got) != 1 || got[0] != "status_update" {
+		t.Errorf("Expected only status_update after unsubscribing, got %v", got)
+	}
+}
+
+// TestBroadcastAnomaliesUpdate tests that new anomalies are broadcast once and repeats are skipped
+func TestBroadcastAnomaliesUpdate(t *testing.T) {
+	replicas := int32(1)
+	labels := map[string]string{"app": "web"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+		},
+	}
+	rs, owners := newReplicaSetFor(deployment)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: labels, OwnerReferences: owners},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	now := time.Now()
+	spike := models.Anomaly{Type: "spike", Severity: "high", Resource: "pod/web-1", Metric: "cpu", DetectedAt: now.Add(-time.Minute)}
+	stale := models.Anomaly{Type: "spike", Severity: "high", Resource: "pod/web-1", Metric: "cpu", DetectedAt: now.Add(-2 * anomalyStreamWindow)}
+	analyzer := &mockAnalyzer{anomalies: map[string][]models.Anomaly{"pod/web-1/cpu": {spike, stale}}}
+
+	server := NewServer(&k8s.Client{Clientset: fake.NewClientset(deployment, rs, pod)}, nil, nil, analyzer)
+	events := server.events.Subscribe()
+
+	broadcastCount := func() int {
+		server.broadcastAnomaliesUpdate()
+		select {
+		case message := <-events:
+			if message.Type != "anomaly_detected" {
+				t.Fatalf("Expected an anomaly_detected message, got %s", message.Type)
+			}
+			return message.Data.(map[string]interface{})["count"].(int)
+		default:
+			return 0
+		}
+	}
+
+	if count := broadcastCount(); count != 1 {
+		t.Errorf("Expected the recent anomaly to be broadcast, got %d", count)
+	}
+	if count := broadcastCount(); count != 0 {
+		t.Errorf("Expected an already broadcast anomaly not to be sent again, got %d", count)
+	}
+
+	// A later detection of the same kind is new
+	later := spike
+	later.DetectedAt = now
+	analyzer.anomalies["pod/web-1/cpu"] = append(analyzer.anomalies["pod/web-1/cpu"], later)
+	if count := broadcastCount(); count != 1 {
+		t.Errorf("Expected the later detection to be broadcast, got %d", count)
+	}
+	if size := server.broadcasts.Size(); size != 2 {
+		t.Errorf("Expected only anomalies within the stream window to be remembered, got %d", size)
+	}
+
+	// Anomaly streaming can be turned off
+	analyzer.anomalies["pod/web-1/memory"] = []models.Anomaly{{Type: "leak", Resource: "pod/web-1", Metric: "memory", DetectedAt: now}}
+	server.config.StreamAnomalies = false
+	if count := broadcastCount(); count != 0 {
+		t.Errorf("Expected no broadcast with anomaly streaming disabled, got %d", count)
+	}
+}
+
+// TestHandleBatchAnalysis tests analyzing several deployments in one request
+func TestHandleBatchAnalysis(t *testing.T) {
+	server := NewServer(nil, nil, &mockOptimizer{
+		analysisErrors: map[string]error{"default/missing": fmt.Errorf("deployment not found")},
+	}, nil)
+
+	body := `[{"namespace":"default","deployment":"web"},{"namespace":"prod","deployment":"api"},` +
+		`{"namespace":"default","deployment":"missing"},{"namespace":"default","deployment":"web"}]`
+	req := httptest.NewRequest("POST", "/api/v1/analysis/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.handleBatchAnalysis(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data BatchAnalysisResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	results := response.Data.Results
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results for 3 distinct deployments, got %d", len(results))
+	}
+	for _, key := range []string{"default/web", "prod/api"} {
+		if result := results[key]; result.Analysis == nil || result.Error != "" {
+			t.Errorf("Expected an analysis for %s, got %+v", key, result)
+		}
+	}
+	if result := results["default/missing"]; result.Analysis != nil || !strings.Contains(result.Error, "not found") {
+		t.Errorf("Expected a per-item error for default/missing, got %+v", result)
+	}
+
+	// Empty, oversized and incomplete batches are rejected
+	oversized := make([]BatchAnalysisItem, maxBatchAnalysisItems+1)
+	for i := range oversized {
+		oversized[i] = BatchAnalysisItem{Namespace: "default", Deployment: fmt.Sprintf("web-%d", i)}
+	}
+	oversizedBody, _ := json.Marshal(oversized)
+	for _, body := range []string{`[]`, string(oversizedBody), `[{"namespace":"default"}]`, `{`} {
+		w := httptest.NewRecorder()
+		server.handleBatchAnalysis(w, httptest.NewRequest("POST", "/api/v1/analysis/batch", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for an invalid batch, got %d", http.StatusBadRequest, w.Code)
+		}
+	}
+
+	// Bodies too large for any valid batch are refused before they are decoded
+	huge := `[{"namespace":"default","deployment":"` + strings.Repeat("x", maxBatchAnalysisBytes) + `"}]`
+	w = httptest.NewRecorder()
+	server.handleBatchAnalysis(w, httptest.NewRequest("POST", "/api/v1/analysis/batch", strings.NewReader(huge)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status code %d for an oversized body, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
+
+// TestHandleRecommendationExport tests exporting filtered recommendations as CSV and JSON
+func TestHandleRecommendationExport(t *testing.T) {
+	low := newTestResourceRecommendation()
+	low.ID = "rec-2"
+	low.Priority = "low"
+	server := NewServer(nil, nil, &mockOptimizer{
+		recommendations: []models.Recommendation{newTestResourceRecommendation(), low},
+	}, nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/recommendations/export?format=csv&priority=high", nil)
+	w := httptest.NewRecorder()
+	server.handleRecommendationExport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("Expected CSV content type, got %s", contentType)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, `attachment; filename="recommendations-`) ||
+		!strings.HasSuffix(disposition, `.csv"`) {
+		t.Errorf("Expected a CSV attachment, got %s", disposition)
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 2 || !slices.Equal(rows[0], exportColumns) {
+		t.Fatalf("Expected a header and the high-priority recommendation, got %v", rows)
+	}
+	if row := rows[1]; row[0] != "rec-1" || row[6] != "16.20" || row[9] != `{"cpu_limit":"500m","cpu_request":"250m"}` {
+		t.Errorf("Unexpected row: %v", row)
+	}
+
+	// JSON exports every recommendation when unfiltered
+	req = httptest.NewRequest("GET", "/api/v1/recommendations/export?format=json", nil)
+	w = httptest.NewRecorder()
+	server.handleRecommendationExport(w, req)
+
+	var exported []ExportedRecommendation
+	if err := json.NewDecoder(w.Body).Decode(&exported); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if len(exported) != 2 || exported[0].ID != "rec-1" || exported[1].CurrentConfig != `{"cpu_limit":"2","cpu_request":"1"}` {
+		t.Errorf("Unexpected JSON export: %+v", exported)
+	}
+
+	// Unsupported formats are rejected
+	w = httptest.NewRecorder()
+	server.handleRecommendationExport(w, httptest.NewRequest("GET", "/api/v1/recommendations/export?format=xlsx", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unsupported format, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestHandleRecommendationTicket tests exporting a recommendation as markdown
+func TestHandleRecommendationTicket(t *testing.T) {
+	command := `kubectl patch statefulset/web -n default --type strategic -p '{"spec":{}}'`
+	mock := &mockOptimizer{
+		recommendations: []models.Recommendation{newTestResourceRecommendation()},
+		commands:        map[string]string{"rec-1": command},
+	}
+	server := NewServer(nil, nil, mock, nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/recommendations/rec-1/ticket", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "rec-1"})
+	w := httptest.NewRecorder()
+
+	server.handleRecommendationTicket(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/markdown") {
+		t.Errorf("Expected markdown content type, got %s", contentType)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"| Setting | Current | Recommended |",
+		"| cpu_request | 1 | 250m |",
+		"| cpu_limit | 2 | 500m |",
+		"$16.20/month",
+		"```sh\n" + command + "\n```",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected ticket to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	// A command that cannot be computed is explained instead
+	mock.commands = nil
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/recommendations/rec-1/ticket", nil), map[string]string{"id": "rec-1"})
+	w = httptest.NewRecorder()
+	server.handleRecommendationTicket(w, req)
+	if body := w.Body.String(); !strings.Contains(body, "The apply command could not be computed: not implemented") {
+		t.Errorf("Expected the command error in the ticket, got:\n%s", body)
+	}
+
+	// Unknown IDs return 404
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/recommendations/missing/ticket", nil), map[string]string{"id": "missing"})
+	w = httptest.NewRecorder()
+	server.handleRecommendationTicket(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for unknown ID, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestHandleSimulateRecommendations tests validating the recommendations of a what-if simulation
+func TestHandleSimulateRecommendations(t *testing.T) {
+	server := NewServer(nil, nil, &mockOptimizer{
+		recommendations: []models.Recommendation{newTestResourceRecommendation()},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	server.handleSimulateRecommendations(w, httptest.NewRequest("POST", "/api/v1/simulate", strings.NewReader(`{"recommendation_ids": ["rec-1"]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	for body, want := range map[string]int{
+		`{"recommendation_ids": []}`:          http.StatusBadRequest,
+		`not json`:                            http.StatusBadRequest,
+		`{"recommendation_ids": ["missing"]}`: http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		server.handleSimulateRecommendations(w, httptest.NewRequest("POST", "/api/v1/simulate", strings.NewReader(body)))
+		if w.Code != want {
+			t.Errorf("Expected status code %d for %s, got %d", want, body, w.Code)
+		}
+	}
+}
+
+// TestHandleDataCoverage tests reporting accumulated pod history per monitored namespace
+func TestHandleDataCoverage(t *testing.T) {
+	now := time.Now()
+	series := func(n int) []models.DataPoint {
+		points := make([]models.DataPoint, n)
+		for i := range points {
+			points[i] = models.DataPoint{Timestamp: now.Add(time.Duration(i-n) * 15 * time.Second), Value: 100}
+		}
+		return points
+	}
+	mc := &mockCollector{
+		pods: []models.PodMetrics{
+			{Name: "web-0", Namespace: "default"},
+			{Name: "web-1", Namespace: "default"},
+			{Name: "web-2", Namespace: "default"},
+			{Name: "batch-1", Namespace: "jobs"},
+		},
+		series: map[string][]models.DataPoint{
+			"pod/web-1/cpu":   series(12),
+			"pod/web-2/cpu":   series(3),
+			"pod/batch-1/cpu": series(4),
+		},
+		// The first pod's history can't be read, which must not pin the minimum at 0
+		seriesErrors: map[string]error{"pod/web-0/cpu": fmt.Errorf("store unavailable")},
+	}
+	// The minimum comes from the running optimizer's configuration
+	config := optimizer.DefaultConfig()
+	config.MinimumDataPoints = 8
+	server := NewServerWithConfig(nil, mc, &mockOptimizer{config: &config}, nil, &Config{Namespaces: []string{"default", "jobs"}})
+
+	w := httptest.NewRecorder()
+	server.handleDataCoverage(w, httptest.NewRequest("GET", "/api/v1/status/data-coverage", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data DataCoverageResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !response.Data.Ready || len(response.Data.Namespaces) != 2 {
+		t.Fatalf("Expected ready coverage for 2 namespaces, got %+v", response.Data)
+	}
+
+	def, jobs := response.Data.Namespaces[0], response.Data.Namespaces[1]
+	if response.Data.MinimumDataPoints != 8 {
+		t.Errorf("Expected the optimizer's minimum of 8 data points, got %d", response.Data.MinimumDataPoints)
+	}
+	if !def.Ready || def.Pods != 3 || def.PodsWithSufficientData != 1 || def.MinDataPoints != 3 || def.MaxDataPoints != 12 || def.ETA != "" {
+		t.Errorf("Unexpected default namespace coverage: %+v", def)
+	}
+	// 4 more samples at the observed 15s interval
+	if jobs.Ready || jobs.ETA != "1m0s" {
+		t.Errorf("Expected jobs namespace to need 1m0s more history, got %+v", jobs)
+	}
+}
+
+// TestHandleVPARecommendation tests the VPA RecommendedPodResources structure and field mapping
+func TestHandleVPARecommendation(t *testing.T) {
+	mock := &mockOptimizer{
+		vpa: &models.VPARecommendation{
+			Namespace:  "default",
+			Deployment: "web",
+			Buffer:     1.2,
+			Containers: []models.VPAContainerRecommendation{{
+				Container:  "app",
+				LowerBound: models.VPAResources{CPU: 100, Memory: 128 * 1024 * 1024},
+				Target:     models.VPAResources{CPU: 250, Memory: 256 * 1024 * 1024},
+				UpperBound: models.VPAResources{CPU: 500, Memory: 512 * 1024 * 1024},
+			}},
+		},
+		analysisErrors: map[string]error{
+			"default/new": fmt.Errorf("%w: no container has usage history", optimizer.ErrInsufficientData),
+		},
+	}
+	server := NewServer(nil, nil, mock, nil)
+
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/analysis/default/web/vpa", nil),
+		map[string]string{"namespace": "default", "deployment": "web"})
+	w := httptest.NewRecorder()
+	server.handleVPARecommendation(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data struct {
+			Recommendation struct {
+				ContainerRecommendations []map[string]interface{} `json:"containerRecommendations"`
+			} `json:"recommendation"`
+			FieldMapping map[string]string `json:"fieldMapping"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	containers := response.Data.Recommendation.ContainerRecommendations
+	if len(containers) != 1 || containers[0]["containerName"] != "app" {
+		t.Fatalf("Expected one recommendation for container app, got %+v", containers)
+	}
+	expected := map[string]map[string]interface{}{
+		"lowerBound":     {"cpu": "100m", "memory": "128Mi"},
+		"target":         {"cpu": "250m", "memory": "256Mi"},
+		"upperBound":     {"cpu": "500m", "memory": "512Mi"},
+		"uncappedTarget": {"cpu": "250m", "memory": "256Mi"},
+	}
+	for field, want := range expected {
+		got, _ := containers[0][field].(map[string]interface{})
+		if got["cpu"] != want["cpu"] || got["memory"] != want["memory"] {
+			t.Errorf("Expected %s %v, got %v", field, want, got)
+		}
+		if response.Data.FieldMapping[field] == "" {
+			t.Errorf("Expected field mapping for %s", field)
+		}
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/analysis/default/new/vpa", nil),
+		map[string]string{"namespace": "default", "deployment": "new"})
+	w = httptest.NewRecorder()
+	server.handleVPARecommendation(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 without usage history, got %d", w.Code)
+	}
+}
+
+// TestNilReplicas tests that deployments without spec.replicas are reported with the default of 1
+func TestNilReplicas(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+	server := NewServer(&k8s.Client{Clientset: fake.NewClientset(deployment)}, &mockCollector{}, &mockOptimizer{}, &mockAnalyzer{})
+
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/services/default/web", nil),
+		map[string]string{"namespace": "default", "name": "web"})
+	w := httptest.NewRecorder()
+	server.handleServiceDetail(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data models.ServiceDetail `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Data.Replicas != 1 {
+		t.Errorf("Expected nil replicas to default to 1, got %d", response.Data.Replicas)
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/deployments/default/web", nil),
+		map[string]string{"namespace": "default", "name": "web"})
+	w = httptest.NewRecorder()
+	server.handleDeploymentDetail(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d for deployment detail, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+// TestMetricsServerUnavailable tests that status and readiness report a missing metrics API
+func TestMetricsServerUnavailable(t *testing.T) {
+	mc := &mockCollector{nodeMetricsErr: fmt.Errorf("failed to get node metrics: %w", collector.ErrMetricsAPIUnavailable)}
+	server := NewServer(&k8s.Client{Clientset: fake.NewClientset()}, mc, &mockOptimizer{}, &mockAnalyzer{})
+
+	w := httptest.NewRecorder()
+	server.handleStatus(w, httptest.NewRequest("GET", "/api/v1/status", nil))
+	var status struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if available, ok := status.Data["metrics_server_available"].(bool); !ok || available {
+		t.Errorf("Expected metrics_server_available false, got %v", status.Data["metrics_server_available"])
+	}
+
+	w = httptest.NewRecorder()
+	server.handleReady(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "METRICS_API_UNAVAILABLE") || !strings.Contains(w.Body.String(), "metrics-server") {
+		t.Errorf("Expected a metrics-server diagnosis, got %s", w.Body.String())
+	}
+
+	mc.nodeMetricsErr = nil
+	w = httptest.NewRecorder()
+	server.handleStatus(w, httptest.NewRequest("GET", "/api/v1/status", nil))
+	if !strings.Contains(w.Body.String(), `"metrics_server_available":true`) {
+		t.Errorf("Expected metrics_server_available true, got %s", w.Body.String())
+	}
+}
+
+// TestHandleRecommendationSummary tests the dashboard summary of recommendations
+func TestHandleRecommendationSummary(t *testing.T) {
+	opt := &mockOptimizer{recommendations: []models.Recommendation{
+		{ID: "1", Type: "resource", Namespace: "default", Deployment: "web", Priority: "high", EstimatedSavings: 10},
+		{ID: "2", Type: "hpa", Namespace: "default", Deployment: "web", Priority: "low", EstimatedSavings: 5},
+		{ID: "3", Type: "resource", Namespace: "prod", Deployment: "web", Priority: "high", EstimatedSavings: 20},
+		// Applied recommendations are not counted
+		{ID: "4", Type: "resource", Namespace: "prod", Deployment: "api", Priority: "high", EstimatedSavings: 40, AppliedAt: time.Now()},
+	}}
+	server := NewServer(&k8s.Client{Clientset: fake.NewClientset()}, &mockCollector{}, opt, &mockAnalyzer{})
+
+	w := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/recommendations/summary", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data RecommendationSummaryResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	summary := response.Data
+	if summary.Total != 3 || summary.HighPriority != 2 || summary.LowPriority != 1 {
+		t.Errorf("Expected 3 recommendations, 2 high and 1 low priority, got %+v", summary)
+	}
+	if summary.ByType["resource"] != 2 || summary.ByType["hpa"] != 1 {
+		t.Errorf("Expected 2 resource and 1 hpa recommendations, got %v", summary.ByType)
+	}
+	if summary.TotalPotentialSavings != 35 {
+		t.Errorf("Expected total potential savings 35, got %v", summary.TotalPotentialSavings)
+	}
+	if summary.AffectedDeployments != 2 {
+		t.Errorf("Expected 2 affected deployments, got %d", summary.AffectedDeployments)
+	}
+}
+
+// TestHandleSimulateHPA tests decoding and validating a proposed HPA configuration
+func TestHandleSimulateHPA(t *testing.T) {
+	server := NewServer(nil, nil, &mockOptimizer{}, nil)
+	vars := map[string]string{"namespace": "default", "name": "web"}
+
+	body := strings.NewReader(`{"min_replicas": 2, "max_replicas": 6, "target_cpu": 70}`)
+	req := mux.SetURLVars(httptest.NewRequest("POST", "/api/v1/simulate/hpa/default/web", body), vars)
+	w := httptest.NewRecorder()
+	server.handleSimulateHPA(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data models.HPASimulation `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Data.MinReplicas != 2 || response.Data.MaxReplicas != 6 || response.Data.TargetCPU != 70 {
+		t.Errorf("Expected proposed config to be passed through, got %+v", response.Data)
+	}
+
+	// Invalid configurations return 400
+	body = strings.NewReader(`{"min_replicas": 0, "max_replicas": 6, "target_cpu": 70}`)
+	req = mux.SetURLVars(httptest.NewRequest("POST", "/api/v1/simulate/hpa/default/web", body), vars)
+	w = httptest.NewRecorder()
+	server.handleSimulateHPA(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for invalid config, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestHandleReport tests the JSON and HTML cluster optimization reports
+func TestHandleReport(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	)
+
+	webRec := newTestResourceRecommendation()
+	cartRec := newTestResourceRecommendation()
+	cartRec.ID = "rec-2"
+	cartRec.Namespace = "shop"
+	cartRec.Deployment = "cart"
+	cartRec.Description = "Reduce <memory> & CPU"
+	cartRec.EstimatedSavings = 40
+	appliedRec := newTestResourceRecommendation()
+	appliedRec.ID = "rec-3"
+	appliedRec.Deployment = "api"
+	appliedRec.EstimatedSavings = 100
+	appliedRec.AppliedAt = time.Now()
+
+	server := NewServer(
+		&k8s.Client{Clientset: clientset},
+		&mockCollector{nodeMetrics: []models.NodeMetrics{{Name: "node-1", CPU: 500, Memory: 1024}}},
+		&mockOptimizer{recommendations: []models.Recommendation{webRec, cartRec, appliedRec}},
+		&mockAnalyzer{anomalies: map[string][]models.Anomaly{
+			"pod/web-1/cpu": {{Type: "spike", Severity: "high"}},
+		}},
+	)
+
+	req := httptest.NewRequest("GET", "/api/v1/report?format=json", nil)
+	w := httptest.NewRecorder()
+	server.handleReport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, "attachment") {
+		t.Errorf("Expected attachment disposition, got %q", disposition)
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &sections); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	for _, section := range []string{"overview", "namespaces", "top_wasteful_workloads", "anomalies", "total_projected_savings"} {
+		if _, ok := sections[section]; !ok {
+			t.Errorf("Expected report to include section %q", section)
+		}
+	}
+
+	var report ClusterReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	// The applied recommendation's savings are already realised
+	if report.TotalProjectedSavings != 56.2 {
+		t.Errorf("Expected total projected savings 56.2, got %.2f", report.TotalProjectedSavings)
+	}
+	if len(report.Recommendations) != 2 {
+		t.Errorf("Expected the 2 active recommendations in the report, got %d", len(report.Recommendations))
+	}
+	if len(report.Namespaces) != 2 || report.Namespaces[0].Namespace != "shop" {
+		t.Errorf("Expected namespaces ordered by savings with shop first, got %+v", report.Namespaces)
+	}
+	if len(report.TopWastefulWorkloads) == 0 || report.TopWastefulWorkloads[0].Deployment != "cart" {
+		t.Errorf("Expected cart to be the most wasteful workload, got %+v", report.TopWastefulWorkloads)
+	}
+	if report.Anomalies.Total != 1 || report.Anomalies.BySeverity["high"] != 1 {
+		t.Errorf("Expected one high severity anomaly, got %+v", report.Anomalies)
+	}
+
+	// HTML variant renders without template errors and escapes content
+	req = httptest.NewRequest("GET", "/api/v1/report?format=html", nil)
+	w = httptest.NewRecorder()
+	server.handleReport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d for HTML report, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Expected HTML content type, got %s", contentType)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<h2>Overview</h2>", "<h2>Top Wasteful Workloads</h2>", "shop/cart", "$56.20/month", "Reduce &lt;memory&gt; &amp; CPU"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected HTML report to contain %q", want)
+		}
+	}
+
+	// Unknown formats are rejected
+	req = httptest.NewRequest("GET", "/api/v1/report?format=pdf", nil)
+	w = httptest.NewRecorder()
+	server.handleReport(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for unsupported format, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestAcknowledgeAnomaly tests that acknowledged anomalies stay hidden until the snooze lapses
+func TestAcknowledgeAnomaly(t *testing.T) {
+	server := NewServer(nil, nil, &mockOptimizer{}, &mockAnalyzer{anomalies: map[string][]models.Anomaly{
+		"pod/web-1/cpu": {
+			{Type: "spike", Severity: "high", Fingerprint: "pod/web-1:cpu:spike"},
+			{Type: "drift", Severity: "low", Fingerprint: "pod/web-1:cpu:drift"},
+		},
+	}})
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	server.anomalies.now = func() time.Time { return clock }
+
+	listAnomalies := func() []models.Anomaly {
+		w := httptest.NewRecorder()
+		server.handleAnomalies(w, httptest.NewRequest("GET", "/api/v1/anomalies?resource=pod/web-1", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data []models.Anomaly `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data
+	}
+
+	if got := len(listAnomalies()); got != 2 {
+		t.Fatalf("Expected 2 anomalies before acknowledging, got %d", got)
+	}
+
+	body := strings.NewReader(`{"fingerprint": "pod/web-1:cpu:spike", "duration": "1h"}`)
+	w := httptest.NewRecorder()
+	server.handleAcknowledgeAnomaly(w, httptest.NewRequest("POST", "/api/v1/anomalies/ack", body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	anomalies := listAnomalies()
+	if len(anomalies) != 1 || anomalies[0].Type != "drift" {
+		t.Errorf("Expected only the drift anomaly while the spike is snoozed, got %+v", anomalies)
+	}
+
+	clock = clock.Add(61 * time.Minute)
+	if got := len(listAnomalies()); got != 2 {
+		t.Errorf("Expected the spike to reappear after the snooze lapsed, got %d anomalies", got)
+	}
+
+	// Invalid durations are rejected
+	w = httptest.NewRecorder()
+	server.handleAcknowledgeAnomaly(w, httptest.NewRequest("POST", "/api/v1/anomalies/ack", strings.NewReader(`{"fingerprint": "x", "duration": "soon"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for invalid duration, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestHandleContainerAnalysis tests analysis and recommendations scoped to a named container
+func TestHandleContainerAnalysis(t *testing.T) {
+	replicas := int32(3)
+	labels := map[string]string{"app": "web"}
+	container := func(name, cpu, memory string) corev1.Container {
+		return corev1.Container{
+			Name: name,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		}
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					container("app", "500m", "512Mi"),
+					container("envoy", "1", "256Mi"),
+				}},
+			},
+		},
+	}
+	rs, owners := newReplicaSetFor(deployment)
+	clientset := fake.NewClientset(deployment, rs)
+
+	mc := &mockCollector{series: make(map[string][]models.DataPoint)}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("web-%d", i), Namespace: "default", Labels: labels, OwnerReferences: owners},
+			Spec:       deployment.Spec.Template.Spec,
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		if _, err := clientset.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+
+		// The app container is well sized; envoy uses a tenth of its CPU request
+		for j := 0; j < 20; j++ {
+			ts := now.Add(-time.Duration(20-j) * time.Minute)
+			mc.series["pod/"+pod.Name+"/container/app/cpu"] = append(mc.series["pod/"+pod.Name+"/container/app/cpu"], models.DataPoint{Timestamp: ts, Value: 400})
+			mc.series["pod/"+pod.Name+"/container/app/memory"] = append(mc.series["pod/"+pod.Name+"/container/app/memory"], models.DataPoint{Timestamp: ts, Value: 420 * 1024 * 1024})
+			mc.series["pod/"+pod.Name+"/container/envoy/cpu"] = append(mc.series["pod/"+pod.Name+"/container/envoy/cpu"], models.DataPoint{Timestamp: ts, Value: 100})
+			mc.series["pod/"+pod.Name+"/container/envoy/memory"] = append(mc.series["pod/"+pod.Name+"/container/envoy/memory"], models.DataPoint{Timestamp: ts, Value: 200 * 1024 * 1024})
+		}
+	}
+
+	k8sClient := &k8s.Client{Clientset: clientset}
+	opt := optimizer.NewWithConfig(k8sClient, mc, optimizer.DefaultConfig())
+	server := NewServer(k8sClient, mc, opt, &mockAnalyzer{})
+
+	req := httptest.NewRequest("GET", "/api/v1/analysis/default/web/containers/envoy", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default", "deployment": "web", "container": "envoy"})
+	w := httptest.NewRecorder()
+	server.handleContainerAnalysis(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data models.ContainerAnalysis `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	analysis := response.Data
+	if analysis.Container != "envoy" || analysis.CPUUsage.Requested != 1000 || analysis.CPUUsage.P95 != 100 {
+		t.Errorf("Expected envoy-scoped CPU analysis (1000m requested, 100m P95), got %+v", analysis.CPUUsage)
+	}
+	if len(analysis.Recommendations) == 0 {
+		t.Fatal("Expected a recommendation for the over-provisioned envoy container")
+	}
+	for _, rec := range analysis.Recommendations {
+		if rec.Container != "envoy" || !strings.HasPrefix(rec.Description, "Container envoy: ") {
+			t.Errorf("Expected recommendation scoped to envoy, got container %q: %s", rec.Container, rec.Description)
+		}
+	}
+
+	// Unknown containers are reported as errors
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/analysis/default/web/containers/missing", nil),
+		map[string]string{"namespace": "default", "deployment": "web", "container": "missing"})
+	w = httptest.NewRecorder()
+	server.handleContainerAnalysis(w, req)
+	if w.Code == http.StatusOK {
+		t.Error("Expected an error for an unknown container")
+	}
+}
+
+// TestHandleAnalysisErrors tests mapping typed optimizer errors to HTTP status codes
+func TestHandleAnalysisErrors(t *testing.T) {
+	server := NewServer(nil, nil, &mockOptimizer{analysisErrors: map[string]error{
+		"default/missing": fmt.Errorf("failed to analyze deployment: %w", optimizer.ErrNotFound),
+		"default/new":     fmt.Errorf("failed to analyze deployment: %w: got 2, need at least 10", optimizer.ErrInsufficientData),
+		"default/broken":  fmt.Errorf("failed to analyze deployment: connection refused"),
+	}}, nil)
+
+	for name, want := range map[string]int{
+		"missing": http.StatusNotFound,
+		"new":     http.StatusUnprocessableEntity,
+		"broken":  http.StatusInternalServerError,
+		"web":     http.StatusOK,
+	} {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/analysis/default/"+name, nil), map[string]string{"namespace": "default", "service": name})
+		w := httptest.NewRecorder()
+		server.handleAnalysis(w, req)
+		if w.Code != want {
+			t.Errorf("Expected status code %d for %s, got %d: %s", want, name, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestHandleConfig tests viewing and updating the optimizer configuration at runtime
+func TestHandleConfig(t *testing.T) {
+	k8sClient := &k8s.Client{Clientset: fake.NewClientset()}
+	mc := &mockCollector{}
+	opt := optimizer.NewWithConfig(k8sClient, mc, optimizer.DefaultConfig())
+	server := NewServerWithConfig(k8sClient, mc, opt, &mockAnalyzer{}, &Config{})
+
+	w := httptest.NewRecorder()
+	server.handleGetConfig(w, httptest.NewRequest("GET", "/api/v1/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	update := `{"CPUOverProvisionedThreshold": 0.4, "OverProvisionedBuffer": 1.1}`
+	w = httptest.NewRecorder()
+	server.handleUpdateConfig(w, httptest.NewRequest("PUT", "/api/v1/config", strings.NewReader(update)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d while updates are disabled, got %d", http.StatusForbidden, w.Code)
+	}
+
+	server.config.AllowConfigUpdates = true
+	for body, want := range map[string]int{
+		`{"OptimalUtilizationMin": 0.95}`:         http.StatusBadRequest, // above the maximum
+		`{"CPUUnderProvisionedThreshold": 1.5}`:   http.StatusBadRequest,
+		`{"UnderProvisionedBuffer": 0.5}`:         http.StatusBadRequest,
+		`{"AnalysisDuration": 3600000000000}`:     http.StatusBadRequest, // not runtime-tunable
+		`{"CPUCostPerVCPUHour": -1}`:              http.StatusBadRequest,
+		`not json`:                                http.StatusBadRequest,
+		update:                                    http.StatusOK,
+		`{"CPUCostPerVCPUHour": 0.05}`:            http.StatusOK,
+		`{"OptimalUtilizationMax": 0.85}`:         http.StatusOK,
+		`{"MemoryOverProvisionedThreshold": 0.3}`: http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		server.handleUpdateConfig(w, httptest.NewRequest("PUT", "/api/v1/config", strings.NewReader(body)))
+		if w.Code != want {
+			t.Errorf("Expected status code %d for %s, got %d: %s", want, body, w.Code, w.Body.String())
+		}
+	}
+
+	config := opt.GetConfig()
+	if config.CPUOverProvisionedThreshold != 0.4 || config.OverProvisionedBuffer != 1.1 || config.OptimalUtilizationMax != 0.85 ||
+		config.Pricing.CPUCostPerVCPUHour("") != 0.05 || config.OptimalUtilizationMin != optimizer.DefaultConfig().OptimalUtilizationMin {
+		t.Errorf("Expected only the valid updates applied, got %+v", config)
+	}
+}
+
+// TestRateLimitMiddleware tests per-route token buckets with tighter limits on heavy routes
+func TestRateLimitMiddleware(t *testing.T) {
+	server := NewServerWithConfig(nil, &mockCollector{}, &mockOptimizer{}, &mockAnalyzer{}, &Config{
+		RateLimit:      RateLimit{PerSecond: 100, Burst: 3},
+		HeavyRateLimit: RateLimit{PerSecond: 0.1, Burst: 1},
+	})
+	router := server.setupRoutes()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	if w := get("/api/v1/recommendations"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	w := get("/api/v1/recommendations/export?format=json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// The heavy route's single token is spent; its bucket refills in 10s
+	w = get("/api/v1/recommendations/export?format=json")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "10" {
+		t.Errorf("Expected Retry-After 10, got %q", retryAfter)
+	}
+
+	// Other routes have their own buckets
+	if w := get("/api/v1/recommendations"); w.Code != http.StatusOK {
+		t.Errorf("Expected the lightweight route unaffected, got %d", w.Code)
+	}
+	// Health checks are not limited
+	for i := 0; i < 5; i++ {
+		if w := get("/health"); w.Code != http.StatusOK {
+			t.Fatalf("Expected health checks unlimited, got %d", w.Code)
+		}
+	}
+}
+
+// TestHandleAnalysisWindows tests comparing analyses of the same service over several windows
+func TestHandleAnalysisWindows(t *testing.T) {
+	replicas := int32(1)
+	labels := map[string]string{"app": "web"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+							corev1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					},
+				}}},
+			},
+		},
+	}
+	rs, owners := newReplicaSetFor(deployment)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: labels, OwnerReferences: owners},
+		Spec:       deployment.Spec.Template.Spec,
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	clientset := fake.NewClientset(deployment, rs, pod)
+
+	// Six days of samples every five minutes
+	mc := &mockCollector{series: make(map[string][]models.DataPoint)}
+	now := time.Now()
+	for ts := now.Add(-6 * 24 * time.Hour); ts.Before(now); ts = ts.Add(5 * time.Minute) {
+		mc.series["pod/web-1/cpu"] = append(mc.series["pod/web-1/cpu"], models.DataPoint{Timestamp: ts, Value: 200})
+		mc.series["pod/web-1/memory"] = append(mc.series["pod/web-1/memory"], models.DataPoint{Timestamp: ts, Value: 256 * 1024 * 1024})
+	}
+
+	k8sClient := &k8s.Client{Clientset: clientset}
+	opt := optimizer.NewWithConfig(k8sClient, mc, optimizer.DefaultConfig())
+	server := NewServer(k8sClient, mc, opt, &mockAnalyzer{})
+
+	req := httptest.NewRequest("GET", "/api/v1/analysis/default/web/windows?windows=1h,24h,7d", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default", "service": "web"})
+	w := httptest.NewRecorder()
+	server.handleAnalysisWindows(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data WindowComparisonResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	windows := response.Data.Windows
+	expected := []struct {
+		label  string
+		window time.Duration
+	}{{"1h", time.Hour}, {"24h", 24 * time.Hour}, {"7d", 7 * 24 * time.Hour}}
+	if len(windows) != len(expected) {
+		t.Fatalf("Expected %d window analyses, got %d", len(expected), len(windows))
+	}
+	for i, want := range expected {
+		if windows[i].Window != want.label || windows[i].Analysis.Window != want.window {
+			t.Errorf("Window %d: expected %s (%v), got %s (%v)", i, want.label, want.window, windows[i].Window, windows[i].Analysis.Window)
+		}
+		if i > 0 && windows[i].Analysis.DataPointCount <= windows[i-1].Analysis.DataPointCount {
+			t.Errorf("Expected %s to include more data points than %s, got %d <= %d", want.label, expected[i-1].label,
+				windows[i].Analysis.DataPointCount, windows[i-1].Analysis.DataPointCount)
+		}
+	}
+
+	// Malformed windows are rejected
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/analysis/default/web/windows?windows=1h,soon", nil),
+		map[string]string{"namespace": "default", "service": "web"})
+	w = httptest.NewRecorder()
+	server.handleAnalysisWindows(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid window, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// The single-service analysis takes an optional window too
+	for _, tt := range []struct {
+		query  string
+		window time.Duration
+	}{{"", 7 * 24 * time.Hour}, {"?window=1h", time.Hour}, {"?window=2d", 48 * time.Hour}} {
+		req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/analysis/default/web"+tt.query, nil),
+			map[string]string{"namespace": "default", "service": "web"})
+		w = httptest.NewRecorder()
+		server.handleAnalysis(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected status code %d, got %d: %s", tt.query, http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var analysis struct {
+			Data models.Analysis `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&analysis); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if analysis.Data.Window != tt.window {
+			t.Errorf("%q: expected window %v, got %v", tt.query, tt.window, analysis.Data.Window)
+		}
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/analysis/default/web?window=-1h", nil),
+		map[string]string{"namespace": "default", "service": "web"})
+	w = httptest.NewRecorder()
+	server.handleAnalysis(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for a negative window, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestOverviewCache tests sharing, aging and background refresh of the cached cluster overview
+func TestOverviewCache(t *testing.T) {
+	cache := newOverviewCache(15 * time.Second)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	var computations atomic.Int64
+	release := make(chan struct{})
+	compute := func() (*models.ClusterOverview, error) {
+		<-release
+		return &models.ClusterOverview{TotalNodes: int(computations.Add(1))}, nil
+	}
+
+	// Concurrent first requests share one computation
+	results := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			overview, _, err := cache.Get(compute)
+			if err != nil {
+				t.Errorf("Get failed: %v", err)
+			}
+			results <- overview.TotalNodes
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	for i := 0; i < 5; i++ {
+		if got := <-results; got != 1 {
+			t.Errorf("Expected the shared first overview, got computation %d", got)
+		}
+	}
+
+	now = now.Add(10 * time.Second)
+	if overview, age, _ := cache.Get(compute); overview.TotalNodes != 1 || age != 10*time.Second {
+		t.Errorf("Expected the cached overview aged 10s, got computation %d aged %s", overview.TotalNodes, age)
+	}
+
+	// Past the TTL the stale copy is served while a background refresh runs
+	now = now.Add(10 * time.Second)
+	if overview, age, _ := cache.Get(compute); overview.TotalNodes != 1 || age != 20*time.Second {
+		t.Errorf("Expected the stale overview aged 20s, got computation %d aged %s", overview.TotalNodes, age)
+	}
+	refreshed := func() bool {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return cache.loading == nil && cache.overview.TotalNodes == 2
+	}
+	for deadline := time.Now().Add(time.Second); !refreshed() && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if overview, age, _ := cache.Get(compute); overview.TotalNodes != 2 || age != 0 {
+		t.Errorf("Expected the refreshed overview, got computation %d aged %s", overview.TotalNodes, age)
+	}
+
+	// Long after the TTL, failures surface instead of a very stale overview
+	now = now.Add(time.Hour)
+	_, _, err := cache.Get(func() (*models.ClusterOverview, error) {
+		return nil, &APIError{Code: "METRICS_ERROR", Message: "collector down"}
+	})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "METRICS_ERROR" {
+		t.Errorf("Expected the computation's error, got %v", err)
+	}
+}
+
+// TestResponseCache tests that repeated analysis requests within the TTL reuse the cached response
+// and that ?refresh=true recomputes it
+func TestResponseCache(t *testing.T) {
+	replicas := int32(1)
+	labels := map[string]string{"app": "web"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+					},
+				}}},
+			},
+		},
+	}
+	rs, owners := newReplicaSetFor(deployment)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: labels, OwnerReferences: owners},
+		Spec:       deployment.Spec.Template.Spec,
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	k8sClient := &k8s.Client{Clientset: fake.NewClientset(deployment, rs, pod)}
+
+	mc := &mockCollector{series: make(map[string][]models.DataPoint)}
+	now := time.Now()
+	for i := 30; i > 0; i-- {
+		mc.series["pod/web-1/cpu"] = append(mc.series["pod/web-1/cpu"], models.DataPoint{Timestamp: now.Add(-time.Duration(i) * time.Minute), Value: 200})
+	}
+
+	server := NewServer(k8sClient, mc, optimizer.NewWithConfig(k8sClient, mc, optimizer.DefaultConfig()), &mockAnalyzer{})
+
+	analyze := func(url string) {
+		req := mux.SetURLVars(httptest.NewRequest("GET", url, nil), map[string]string{"namespace": "default", "service": "web"})
+		w := httptest.NewRecorder()
+		server.handleAnalysis(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	analyze("/api/v1/analysis/default/web")
+	queries := mc.queries.Load()
+	if queries == 0 {
+		t.Fatal("Expected the first request to read metrics")
+	}
+
+	analyze("/api/v1/analysis/default/web")
+	if got := mc.queries.Load(); got != queries {
+		t.Errorf("Expected a repeated request within the TTL to reuse the cached analysis, got %d metric reads after %d", got, queries)
+	}
+
+	analyze("/api/v1/analysis/default/web?refresh=true")
+	if got := mc.queries.Load(); got <= queries {
+		t.Errorf("Expected refresh=true to recompute the analysis, got %d metric reads after %d", got, queries)
+	}
+}
+
+// TestHandlePrometheusMetrics tests the Prometheus text exposition of per-deployment gauges
+func TestHandlePrometheusMetrics(t *testing.T) {
+	opt := &mockOptimizer{summaries: []models.DeploymentSummary{
+		{Namespace: "default", Deployment: "web", Analyzed: true, EfficiencyScore: 72.5, MonthlyCost: 43.2, WastedCost: 10, RecommendationCount: 2},
+		{Namespace: "prod", Deployment: `we"ird`, RecommendationCount: 1},
+	}}
+	server := NewServer(nil, &mockCollector{}, opt, &mockAnalyzer{})
+
+	req := httptest.NewRequest("GET", "/metrics/prometheus", nil)
+	w := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != prometheusContentType {
+		t.Errorf("Expected content type %q, got %q", prometheusContentType, contentType)
+	}
+
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE k8s_optimizer_efficiency_score gauge",
+		`k8s_optimizer_efficiency_score{namespace="default",deployment="web"} 72.5`,
+		`k8s_optimizer_monthly_cost_dollars{namespace="default",deployment="web"} 43.2`,
+		`k8s_optimizer_wasted_monthly_cost_dollars{namespace="default",deployment="web"} 10`,
+		`k8s_optimizer_recommendations{namespace="default",deployment="web"} 2`,
+		`k8s_optimizer_recommendations{namespace="prod",deployment="we\"ird"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected exposition to contain %q, got:\n%s", line, body)
+		}
+	}
+
+	// Deployments without a cached analysis only report their recommendation count
+	if strings.Contains(body, `k8s_optimizer_efficiency_score{namespace="prod"`) {
+		t.Errorf("Expected no efficiency score for an unanalyzed deployment, got:\n%s", body)
+	}
+}
+
+// TestHandleDismissRecommendation tests dismissing recommendations over DELETE
+func TestHandleDismissRecommendation(t *testing.T) {
+	opt := &mockOptimizer{recommendations: []models.Recommendation{newTestResourceRecommendation()}}
+	server := NewServer(nil, &mockCollector{}, opt, &mockAnalyzer{})
+	router := server.setupRoutes()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/recommendations/rec-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(opt.recommendations) != 0 {
+		t.Errorf("Expected the recommendation to be dismissed, got %+v", opt.recommendations)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/recommendations/rec-1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown recommendation, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// Applying, reverting and rendering an unknown recommendation are 404s too
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/api/v1/recommendations/rec-1/apply", nil),
+		httptest.NewRequest("POST", "/api/v1/recommendations/rec-1/revert", nil),
+		httptest.NewRequest("GET", "/api/v1/recommendations/rec-1/manifest", nil),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for %s %s, got %d: %s", http.StatusNotFound, req.Method, req.URL.Path, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestHandleRevertConflicts tests that reverting an unapplied or drifted recommendation is a 409
+func TestHandleRevertConflicts(t *testing.T) {
+	opt := &mockOptimizer{revertErrors: map[string]error{
+		"unapplied": fmt.Errorf("recommendation unapplied %w", optimizer.ErrNotApplied),
+		"drifted":   fmt.Errorf("refusing to revert: default/web %w of recommendation drifted", optimizer.ErrDrifted),
+	}}
+	router := NewServer(nil, &mockCollector{}, opt, &mockAnalyzer{}).setupRoutes()
+
+	for id := range opt.revertErrors {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/recommendations/"+id+"/revert", nil))
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status code %d reverting %s, got %d: %s", http.StatusConflict, id, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestHandleRecommendationsQuery tests filtering, sorting and pagination of the recommendations list
+func TestHandleRecommendationsQuery(t *testing.T) {
+	opt := &mockOptimizer{recommendations: []models.Recommendation{
+		{ID: "a", Namespace: "default", Type: "resource", Priority: "low", EstimatedSavings: 40},
+		{ID: "b", Namespace: "default", Type: "hpa", Priority: "high", EstimatedSavings: 5},
+		{ID: "c", Namespace: "prod", Type: "resource", Priority: "medium", EstimatedSavings: 80},
+		{ID: "d", Namespace: "default", Type: "resource", Priority: "high", EstimatedSavings: 20},
+	}}
+	server := NewServer(nil, &mockCollector{}, opt, &mockAnalyzer{})
+	router := server.setupRoutes()
+
+	list := func(query string) ([]string, int) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/recommendations"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %q, got %d: %s", http.StatusOK, query, w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data  []models.Recommendation `json:"data"`
+			Total int                     `json:"total"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		ids := make([]string, len(response.Data))
+		for i, rec := range response.Data {
+			ids[i] = rec.ID
+		}
+		return ids, response.Total
+	}
+
+	tests := []struct {
+		query string
+		ids   []string
+		total int
+	}{
+		{"", []string{"d", "b", "c", "a"}, 4},
+		{"?sort=savings", []string{"c", "a", "d", "b"}, 4},
+		{"?namespace=default&type=resource", []string{"d", "a"}, 2},
+		{"?priority=high", []string{"d", "b"}, 2},
+		{"?min_savings=20", []string{"d", "c", "a"}, 3},
+		{"?limit=2&offset=1", []string{"b", "c"}, 4},
+		{"?offset=10", []string{}, 4},
+		{"?type=event-driven", []string{}, 0},
+		{"?type=idle", []string{}, 0},
+	}
+	for _, tt := range tests {
+		ids, total := list(tt.query)
+		if !slices.Equal(ids, tt.ids) || total != tt.total {
+			t.Errorf("Query %q: expected %v (total %d), got %v (total %d)", tt.query, tt.ids, tt.total, ids, total)
+		}
+	}
+
+	for _, query := range []string{"?type=bogus", "?priority=urgent", "?sort=name", "?limit=-1", "?min_savings=lots"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/recommendations"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+// TestHandleFederationExport tests that the export requires the federation token and carries the
+// cluster name and every recommendation
+func TestHandleFederationExport(t *testing.T) {
+	applied := newTestResourceRecommendation()
+	applied.ID = "rec-applied"
+	applied.AppliedAt = time.Now()
+	applied.Kind = "StatefulSet"
+	opt := &mockOptimizer{
+		recommendations: []models.Recommendation{newTestResourceRecommendation(), applied},
+		summaries: []models.DeploymentSummary{
+			{Namespace: "default", Deployment: "web", Analyzed: true, EfficiencyScore: 60, MonthlyCost: 100, WastedCost: 40},
+		},
+	}
+	server := NewServerWithConfig(nil, &mockCollector{}, opt, &mockAnalyzer{}, &Config{
+		ClusterName:     "prod-eu-1",
+		FederationToken: "secret",
+	})
+	router := server.setupRoutes()
+
+	export := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/federation/export", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+		if w := export(authorization); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status code %d for authorization %q, got %d", http.StatusUnauthorized, authorization, w.Code)
+		}
+	}
+
+	w := export("Bearer secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["schema_version"] != "v1" || body["cluster_name"] != "prod-eu-1" {
+		t.Errorf("Expected schema v1 for cluster prod-eu-1, got %v and %v", body["schema_version"], body["cluster_name"])
+	}
+
+	recs, _ := body["recommendations"].([]interface{})
+	if len(recs) != 2 {
+		t.Fatalf("Expected both recommendations in the export, got %d", len(recs))
+	}
+	for i, id := range []string{"rec-1", "rec-applied"} {
+		rec := recs[i].(map[string]interface{})
+		if rec["id"] != id || rec["namespace"] != "default" || rec["recommended_config"] == nil {
+			t.Errorf("Expected recommendation %s with its namespace and config, got %v", id, rec)
+		}
+	}
+	if _, ok := recs[1].(map[string]interface{})["applied_at"]; !ok {
+		t.Error("Expected the applied recommendation to carry applied_at")
+	}
+	// Recommendations without a kind target Deployments
+	for i, kind := range []string{"Deployment", "StatefulSet"} {
+		if got := recs[i].(map[string]interface{})["kind"]; got != kind {
+			t.Errorf("Expected recommendation %d to have kind %s, got %v", i, kind, got)
+		}
+	}
+
+	deployments, _ := body["deployments"].([]interface{})
+	if len(deployments) != 1 || deployments[0].(map[string]interface{})["efficiency_score"] != 60.0 {
+		t.Errorf("Expected the web deployment summary, got %v", body["deployments"])
+	}
+	totals := body["totals"].(map[string]interface{})
+	if totals["monthly_cost"] != 100.0 || totals["wasted_monthly_cost"] != 40.0 {
+		t.Errorf("Expected totals from the deployment summaries, got %v", totals)
+	}
+
+	// Without a configured token the export is disabled
+	server.config.FederationToken = ""
+	if w := export("Bearer secret"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d when federation is disabled, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+// TestClusterCacheListings tests that once the cluster cache has synced, the overview and
+// deployment listings are served from it instead of listing on the API server per request
+func TestClusterCacheListings(t *testing.T) {
+	newClientset := func() *fake.Clientset {
+		var objects []runtime.Object
+		for _, namespace := range []string{"default", "shop", "billing"} {
+			replicas := int32(2)
+			labels := map[string]string{"app": "web"}
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+				},
+			}
+			rs, owners := newReplicaSetFor(deployment)
+			objects = append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}, deployment, rs)
+			for _, name := range []string{"web-1", "web-2"} {
+				objects = append(objects, &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels, OwnerReferences: owners},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				})
+			}
+		}
+		objects = append(objects, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+		return fake.NewClientset(objects...)
+	}
+
+	countLists := func(clientset *fake.Clientset) int {
+		lists := 0
+		for _, action := range clientset.Actions() {
+			if action.GetVerb() == "list" {
+				lists++
+			}
+		}
+		return lists
+	}
+
+	const requests = 5
+	serve := func(server *Server) {
+		for i := 0; i < requests; i++ {
+			w := httptest.NewRecorder()
+			server.handleClusterOverview(w, httptest.NewRequest("GET", "/api/v1/cluster/overview", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var response struct{ Data models.ClusterOverview }
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode overview: %v", err)
+			}
+			if response.Data.TotalPods != 6 || len(response.Data.Namespaces) != 3 {
+				t.Errorf("Expected 6 pods across 3 namespaces, got %d across %v", response.Data.TotalPods, response.Data.Namespaces)
+			}
+
+			w = httptest.NewRecorder()
+			server.handleListDeployments(w, httptest.NewRequest("GET", "/api/v1/deployments", nil))
+			var deployments struct{ Data []map[string]interface{} }
+			if err := json.Unmarshal(w.Body.Bytes(), &deployments); err != nil {
+				t.Fatalf("Failed to decode deployments: %v", err)
+			}
+			if len(deployments.Data) != 3 {
+				t.Fatalf("Expected 3 deployments, got %d", len(deployments.Data))
+			}
+			for _, deployment := range deployments.Data {
+				if deployment["healthScore"] != 100.0 {
+					t.Errorf("Expected both pods of %s/%s to be matched, got health score %v", deployment["namespace"], deployment["name"], deployment["healthScore"])
+				}
+			}
+		}
+	}
+
+	collector := &mockCollector{nodeMetrics: []models.NodeMetrics{{Name: "node-1"}}}
+
+	// Without the cache every request lists namespaces, nodes, pods and deployments
+	uncachedClientset := newClientset()
+	uncached := NewServerWithConfig(&k8s.Client{Clientset: uncachedClientset}, collector, &mockOptimizer{}, &mockAnalyzer{}, &Config{})
+	serve(uncached)
+	uncachedLists := countLists(uncachedClientset)
+
+	cachedClientset := newClientset()
+	cached := NewServerWithConfig(&k8s.Client{Clientset: cachedClientset}, collector, &mockOptimizer{}, &mockAnalyzer{}, &Config{
+		InformerResync: time.Hour,
+	})
+	defer cached.cancel()
+	if err := cached.cluster.Start(cached.ctx.Done()); err != nil {
+		t.Fatalf("Failed to sync cluster cache: %v", err)
+	}
+	syncLists := countLists(cachedClientset)
+
+	serve(cached)
+	if got := countLists(cachedClientset); got != syncLists {
+		t.Errorf("Expected cached listings to make no API list calls, got %d after the %d made to sync", got-syncLists, syncLists)
+	}
+	if uncachedLists < requests*syncLists {
+		t.Errorf("Expected uncached listings to list per request (%d list calls), compared to %d to sync the cache", uncachedLists, syncLists)
+	}
+}
+
+// TestHandleStream tests that the Server-Sent Events stream carries the periodic and status
+// broadcasts and ends when the client disconnects or the server shuts down
+func TestHandleStream(t *testing.T) {
+	server := NewServerWithConfig(nil, &mockCollector{nodeMetrics: []models.NodeMetrics{{Name: "node-1"}}}, &mockOptimizer{}, &mockAnalyzer{}, &Config{
+		UpdateInterval: 10 * time.Millisecond,
+	})
+	go server.wsHub.Run()
+	go server.startUpdateBroadcaster()
+	ts := httptest.NewServer(server.setupRoutes())
+	defer ts.Close()
+
+	connect := func(ctx context.Context) (*http.Response, *bufio.Reader) {
+		req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/v1/stream", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to open stream: %v", err)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+			t.Fatalf("Expected text/event-stream, got %q", contentType)
+		}
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	// nextEvent reads events until one of the wanted type arrives
+	nextEvent := func(reader *bufio.Reader, want string) WebSocketMessage {
+		event := ""
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Stream ended before a %s event: %v", want, err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				event = name
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok && event == want {
+				var message WebSocketMessage
+				if err := json.Unmarshal([]byte(data), &message); err != nil {
+					t.Fatalf("Failed to decode %s event: %v", want, err)
+				}
+				return message
+			}
+		}
+	}
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	resp, reader := connect(ctx)
+
+	if message := nextEvent(reader, "metrics_update"); message.Type != "metrics_update" {
+		t.Errorf("Expected a metrics_update message, got %q", message.Type)
+	}
+	server.BroadcastStatusUpdate("degraded", "metrics server slow")
+	status := nextEvent(reader, "status_update")
+	if data, _ := status.Data.(map[string]interface{}); data["status"] != "degraded" {
+		t.Errorf("Expected the degraded status, got %v", status.Data)
+	}
+
+	// Disconnecting unsubscribes the stream
+	disconnect()
+	resp.Body.Close()
+	deadline := time.Now().Add(time.Second)
+	for server.events.Count() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if count := server.events.Count(); count != 0 {
+		t.Errorf("Expected the stream to unsubscribe on disconnect, got %d subscribers", count)
+	}
+
+	// Shutting the server down ends open streams
+	resp, reader = connect(context.Background())
+	defer resp.Body.Close()
+	server.cancel()
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Errorf("Expected the stream to end cleanly on shutdown, got %v", err)
+	}
+}
+
+// newReplicaSetFor returns a ReplicaSet controlled by the deployment and the owner references
+// its pods carry
+func newReplicaSetFor(deployment *appsv1.Deployment) (*appsv1.ReplicaSet, []metav1.OwnerReference) {
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            deployment.Name + "-rs",
+			Namespace:       deployment.Namespace,
+			Labels:          deployment.Spec.Template.Labels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+		},
+	}
+	return rs, []metav1.OwnerReference{*metav1.NewControllerRef(rs, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))}
+}
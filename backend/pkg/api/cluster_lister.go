@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clusterCacheReady reports whether cluster-wide listings can be served from the informer cache
+func (s *Server) clusterCacheReady() bool {
+	return s.cluster != nil && s.cluster.HasSynced()
+}
+
+// listNamespaces lists namespaces from the cluster cache, or the API server until it has synced
+func (s *Server) listNamespaces(ctx context.Context) ([]corev1.Namespace, error) {
+	if s.clusterCacheReady() {
+		return s.cluster.Namespaces()
+	}
+	namespaces, err := s.k8sClient.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return namespaces.Items, nil
+}
+
+// listNodes lists nodes from the cluster cache, or the API server until it has synced
+func (s *Server) listNodes(ctx context.Context) ([]corev1.Node, error) {
+	if s.clusterCacheReady() {
+		return s.cluster.Nodes()
+	}
+	nodes, err := s.k8sClient.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Items, nil
+}
+
+// listPods lists a namespace's pods from the cluster cache, or the API server until it has synced
+func (s *Server) listPods(ctx context.Context, namespace string) ([]corev1.Pod, error) {
+	if s.clusterCacheReady() {
+		return s.cluster.Pods(namespace)
+	}
+	pods, err := s.k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// listDeployments lists a namespace's deployments from the cluster cache, or the API server until it has synced
+func (s *Server) listDeployments(ctx context.Context, namespace string) ([]appsv1.Deployment, error) {
+	if s.clusterCacheReady() {
+		return s.cluster.Deployments(namespace)
+	}
+	deployments, err := s.k8sClient.Clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return deployments.Items, nil
+}
+
+// deploymentPods returns the pods owned by a deployment from the cluster cache, or the API server
+// until it has synced
+func (s *Server) deploymentPods(ctx context.Context, deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	if s.clusterCacheReady() {
+		return s.cluster.DeploymentPods(deployment)
+	}
+	return s.k8sClient.DeploymentPods(ctx, deployment)
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// renderTicketMarkdown renders a recommendation as a markdown ticket body
+// suitable for pasting into GitHub or Jira, with the kubectl command that
+// applies it, or the error that prevented computing one
+func renderTicketMarkdown(rec models.Recommendation, command string, commandErr error) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## [%s] %s optimization for %s/%s\n\n",
+		strings.ToUpper(rec.Priority), rec.Type, rec.Namespace, rec.Deployment)
+
+	b.WriteString("### Problem\n\n")
+	fmt.Fprintf(&b, "%s\n\n", rec.Description)
+	for _, reason := range rec.Rationale {
+		fmt.Fprintf(&b, "- %s\n", reason)
+	}
+	if len(rec.Rationale) > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("### Configuration\n\n")
+	b.WriteString("| Setting | Current | Recommended |\n")
+	b.WriteString("|---------|---------|-------------|\n")
+
+	current := configToMap(rec.CurrentConfig)
+	recommended := configToMap(rec.RecommendedConfig)
+	for _, key := range configKeys(current, recommended) {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", key, formatConfigValue(current[key]), formatConfigValue(recommended[key]))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("### Impact\n\n")
+	fmt.Fprintf(&b, "- **Estimated savings:** $%.2f/month\n", rec.EstimatedSavings)
+	fmt.Fprintf(&b, "- **Risk:** %s\n", rec.Impact)
+	fmt.Fprintf(&b, "- **Priority:** %s\n\n", rec.Priority)
+
+	b.WriteString("### How to apply\n\n")
+	switch {
+	case commandErr != nil:
+		fmt.Fprintf(&b, "The apply command could not be computed: %v\n\n", commandErr)
+	case command != "":
+		fmt.Fprintf(&b, "```sh\n%s\n```\n\n", command)
+	default:
+		b.WriteString("This recommendation has no direct apply command; see the problem summary above.\n\n")
+	}
+
+	fmt.Fprintf(&b, "_Recommendation ID: %s, generated %s_\n", rec.ID, rec.CreatedAt.UTC().Format("2006-01-02 15:04 MST"))
+
+	return b.String()
+}
+
+// configToMap returns a recommendation config as a map, or an empty map if it has another shape
+func configToMap(config interface{}) map[string]interface{} {
+	if m, ok := config.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+// configKeys returns the sorted union of keys in the given config maps
+func configKeys(configs ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, config := range configs {
+		for key := range config {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatConfigValue formats a config value for display, using "-" for missing values
+func formatConfigValue(value interface{}) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// respondWithText sends a plain-text response with the given content type
+func respondWithText(w http.ResponseWriter, statusCode int, contentType string, body string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	w.Write([]byte(body))
+}
+
+// exportColumns are the columns of a recommendation export, in order
+var exportColumns = []string{
+	"id", "namespace", "deployment", "type", "priority", "description", "estimated_savings",
+	"impact", "current_config", "recommended_config", "created_at",
+}
+
+// ExportedRecommendation is a recommendation flattened for spreadsheets, with its configs
+// serialized as JSON
+type ExportedRecommendation struct {
+	ID                string  `json:"id"`
+	Namespace         string  `json:"namespace"`
+	Deployment        string  `json:"deployment"`
+	Type              string  `json:"type"`
+	Priority          string  `json:"priority"`
+	Description       string  `json:"description"`
+	EstimatedSavings  float64 `json:"estimated_savings"`
+	Impact            string  `json:"impact"`
+	CurrentConfig     string  `json:"current_config"`
+	RecommendedConfig string  `json:"recommended_config"`
+	CreatedAt         string  `json:"created_at"`
+}
+
+// exportRecommendation flattens a recommendation for export
+func exportRecommendation(rec models.Recommendation) ExportedRecommendation {
+	return ExportedRecommendation{
+		ID:                rec.ID,
+		Namespace:         rec.Namespace,
+		Deployment:        rec.Deployment,
+		Type:              rec.Type,
+		Priority:          rec.Priority,
+		Description:       rec.Description,
+		EstimatedSavings:  rec.EstimatedSavings,
+		Impact:            rec.Impact,
+		CurrentConfig:     serializeConfig(rec.CurrentConfig),
+		RecommendedConfig: serializeConfig(rec.RecommendedConfig),
+		CreatedAt:         rec.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// serializeConfig serializes a recommendation config as compact JSON with sorted keys, or an
+// empty string if there is none
+func serializeConfig(config interface{}) string {
+	if config == nil {
+		return ""
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// writeRecommendationsCSV streams recommendations as CSV with a header row
+func writeRecommendationsCSV(w http.ResponseWriter, recommendations []models.Recommendation) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(exportColumns)
+	for _, rec := range recommendations {
+		row := exportRecommendation(rec)
+		writer.Write([]string{
+			row.ID, row.Namespace, row.Deployment, row.Type, row.Priority, row.Description,
+			strconv.FormatFloat(row.EstimatedSavings, 'f', 2, 64),
+			row.Impact, row.CurrentConfig, row.RecommendedConfig, row.CreatedAt,
+		})
+	}
+	writer.Flush()
+}
+
+// writeRecommendationsJSON streams recommendations as a JSON array, one flattened recommendation at a time
+func writeRecommendationsJSON(w http.ResponseWriter, recommendations []models.Recommendation) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	w.Write([]byte("["))
+	for i, rec := range recommendations {
+		if i > 0 {
+			w.Write([]byte(","))
+		}
+		encoder.Encode(exportRecommendation(rec))
+	}
+	w.Write([]byte("]\n"))
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// federationSchemaVersion identifies the layout of FederationExport. Bump it on any
+// incompatible change so aggregators can tell exports apart.
+const federationSchemaVersion = "v1"
+
+// FederationExport is this cluster's optimization state in the stable schema pulled by a
+// central aggregator
+type FederationExport struct {
+	SchemaVersion   string                    `json:"schema_version"`
+	ClusterName     string                    `json:"cluster_name"`
+	GeneratedAt     time.Time                 `json:"generated_at"`
+	Deployments     []FederatedDeployment     `json:"deployments"`
+	Recommendations []FederatedRecommendation `json:"recommendations"`
+	Totals          FederatedTotals           `json:"totals"`
+}
+
+// FederatedDeployment is the latest analysis and cost of one deployment
+type FederatedDeployment struct {
+	Namespace           string    `json:"namespace"`
+	Deployment          string    `json:"deployment"`
+	Analyzed            bool      `json:"analyzed"`
+	EfficiencyScore     float64   `json:"efficiency_score"`
+	MonthlyCost         float64   `json:"monthly_cost"`
+	WastedMonthlyCost   float64   `json:"wasted_monthly_cost"`
+	RecommendationCount int       `json:"recommendation_count"`
+	AnalyzedAt          time.Time `json:"analyzed_at"`
+}
+
+// FederatedRecommendation is one recommendation of the cluster
+type FederatedRecommendation struct {
+	ID                string      `json:"id"`
+	Namespace         string      `json:"namespace"`
+	Kind              string      `json:"kind"` // Deployment, StatefulSet or DaemonSet
+	Deployment        string      `json:"deployment"`
+	Container         string      `json:"container,omitempty"`
+	Type              string      `json:"type"`
+	Priority          string      `json:"priority"`
+	Risk              string      `json:"risk"`
+	Description       string      `json:"description"`
+	CurrentConfig     interface{} `json:"current_config"`
+	RecommendedConfig interface{} `json:"recommended_config"`
+	EstimatedSavings  float64     `json:"estimated_savings"`
+	ImpactScore       float64     `json:"impact_score"`
+	Confidence        float64     `json:"confidence"`
+	CreatedAt         time.Time   `json:"created_at"`
+	AppliedAt         *time.Time  `json:"applied_at,omitempty"`
+}
+
+// FederatedTotals sums the cluster's costs and savings
+type FederatedTotals struct {
+	MonthlyCost       float64 `json:"monthly_cost"`
+	WastedMonthlyCost float64 `json:"wasted_monthly_cost"`
+	EstimatedSavings  float64 `json:"estimated_savings"` // from recommendations not yet applied
+}
+
+// handleFederationExport handles exporting this cluster's analyses, recommendations and costs for a
+// central aggregator. It requires the configured federation token as a bearer token.
+func (s *Server) handleFederationExport(w http.ResponseWriter, r *http.Request) {
+	if s.config.FederationToken == "" {
+		respondWithError(w, http.StatusForbidden, "FEDERATION_DISABLED", "Federation export is disabled: no federation token is configured")
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.FederationToken)) != 1 {
+		respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "A valid federation bearer token is required")
+		return
+	}
+
+	recommendations, err := s.optimizer.GetAllRecommendations()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to get recommendations: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, buildFederationExport(s.config.ClusterName, s.optimizer.GetDeploymentSummaries(), recommendations))
+}
+
+// buildFederationExport converts deployment summaries and recommendations into the federation schema
+func buildFederationExport(clusterName string, summaries []models.DeploymentSummary, recommendations []models.Recommendation) *FederationExport {
+	export := &FederationExport{
+		SchemaVersion:   federationSchemaVersion,
+		ClusterName:     clusterName,
+		GeneratedAt:     time.Now(),
+		Deployments:     make([]FederatedDeployment, 0, len(summaries)),
+		Recommendations: make([]FederatedRecommendation, 0, len(recommendations)),
+	}
+
+	for _, summary := range summaries {
+		export.Deployments = append(export.Deployments, FederatedDeployment{
+			Namespace:           summary.Namespace,
+			Deployment:          summary.Deployment,
+			Analyzed:            summary.Analyzed,
+			EfficiencyScore:     summary.EfficiencyScore,
+			MonthlyCost:         summary.MonthlyCost,
+			WastedMonthlyCost:   summary.WastedCost,
+			RecommendationCount: summary.RecommendationCount,
+			AnalyzedAt:          summary.Timestamp,
+		})
+		export.Totals.MonthlyCost += summary.MonthlyCost
+		export.Totals.WastedMonthlyCost += summary.WastedCost
+	}
+
+	for _, rec := range recommendations {
+		// Recommendations without a kind predate it and target Deployments
+		if rec.Kind == "" {
+			rec.Kind = "Deployment"
+		}
+		federated := FederatedRecommendation{
+			ID:                rec.ID,
+			Namespace:         rec.Namespace,
+			Kind:              rec.Kind,
+			Deployment:        rec.Deployment,
+			Container:         rec.Container,
+			Type:              rec.Type,
+			Priority:          rec.Priority,
+			Risk:              rec.Risk,
+			Description:       rec.Description,
+			CurrentConfig:     rec.CurrentConfig,
+			RecommendedConfig: rec.RecommendedConfig,
+			EstimatedSavings:  rec.EstimatedSavings,
+			ImpactScore:       rec.ImpactScore,
+			Confidence:        rec.Confidence,
+			CreatedAt:         rec.CreatedAt,
+		}
+		if !rec.AppliedAt.IsZero() {
+			appliedAt := rec.AppliedAt
+			federated.AppliedAt = &appliedAt
+		} else {
+			export.Totals.EstimatedSavings += rec.EstimatedSavings
+		}
+		export.Recommendations = append(export.Recommendations, federated)
+	}
+
+	return export
+}
@@ -2,16 +2,21 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/internal/parallel"
+	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	"github.com/k8s-service-optimizer/backend/pkg/optimizer"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 )
 
 // handleHealth handles the health check endpoint
@@ -25,8 +30,14 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
 	// Try to collect node metrics to verify collector is working
 	_, err := s.collector.CollectNodeMetrics()
+	if errors.Is(err, collector.ErrMetricsAPIUnavailable) {
+		respondWithError(w, http.StatusServiceUnavailable, "METRICS_API_UNAVAILABLE",
+			"The metrics API (metrics.k8s.io) is not available; install metrics-server and check that "+
+				"`kubectl get apiservice v1beta1.metrics.k8s.io` reports Available: "+err.Error())
+		return
+	}
 	if err != nil {
-		respondWithError(w, http.StatusServiceUnavailable, "NOT_READY", "Metrics collector is not responding")
+		respondWithError(w, http.StatusServiceUnavailable, "NOT_READY", "Metrics collector is not responding: "+err.Error())
 		return
 	}
 
@@ -45,32 +56,162 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	collectorRunning := err == nil
 
 	status := StatusResponse{
-		Version:          "1.0.0",
-		Uptime:           uptime.String(),
-		CollectorRunning: collectorRunning,
-		Timestamp:        time.Now(),
+		Version:                "1.0.0",
+		Uptime:                 uptime.String(),
+		CollectorRunning:       collectorRunning,
+		MetricsServerAvailable: !errors.Is(err, collector.ErrMetricsAPIUnavailable),
+		Timestamp:              time.Now(),
 	}
 
 	respondWithSuccess(w, status)
 }
 
-// handleClusterOverview handles the cluster overview endpoint
+// handleDataCoverage reports per namespace how much CPU history has accumulated for each pod and
+// how long until analyses stop failing for lack of data points. The minimum and window are those of
+// the running optimizer.
+func (s *Server) handleDataCoverage(w http.ResponseWriter, r *http.Request) {
+	config := s.optimizer.GetConfig()
+	minimum := config.MinimumDataPoints
+	window := config.AnalysisDuration
+
+	response := DataCoverageResponse{
+		MinimumDataPoints: minimum,
+		Namespaces:        []NamespaceCoverage{},
+		Timestamp:         time.Now(),
+	}
+	for _, namespace := range s.config.Namespaces {
+		coverage := s.namespaceCoverage(namespace, minimum, window)
+		response.Ready = response.Ready || coverage.Ready
+		response.Namespaces = append(response.Namespaces, coverage)
+	}
+
+	respondWithSuccess(w, response)
+}
+
+// namespaceCoverage counts the CPU samples of every pod in a namespace
+func (s *Server) namespaceCoverage(namespace string, minimum int, window time.Duration) NamespaceCoverage {
+	coverage := NamespaceCoverage{Namespace: namespace}
+	pods, err := s.collector.CollectPodMetrics(namespace)
+	if err != nil {
+		coverage.Error = fmt.Sprintf("Failed to collect pod metrics: %v", err)
+		return coverage
+	}
+
+	var best []models.DataPoint
+	counted := 0
+	for _, pod := range pods {
+		data, err := s.collector.GetTimeSeriesData(fmt.Sprintf("pod/%s", pod.Name), "cpu", window)
+		if err != nil {
+			continue
+		}
+		points := len(data.Points)
+		if counted == 0 || points < coverage.MinDataPoints {
+			coverage.MinDataPoints = points
+		}
+		counted++
+		if points > coverage.MaxDataPoints {
+			coverage.MaxDataPoints = points
+			best = data.Points
+		}
+		if points >= minimum {
+			coverage.PodsWithSufficientData++
+		}
+	}
+	coverage.Pods = len(pods)
+	coverage.Ready = coverage.PodsWithSufficientData > 0
+
+	// Extrapolate the remaining samples at the best-covered pod's observed collection interval
+	if !coverage.Ready && len(best) >= 2 {
+		interval := best[len(best)-1].Timestamp.Sub(best[0].Timestamp) / time.Duration(len(best)-1)
+		coverage.ETA = (time.Duration(minimum-len(best)) * interval).Round(time.Second).String()
+	}
+
+	return coverage
+}
+
+// handleGetConfig handles returning the running optimizer and analyzer configuration
+func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	response := ConfigResponse{
+		Optimizer:      s.optimizer.GetConfig(),
+		UpdatesEnabled: s.config.AllowConfigUpdates,
+	}
+	if s.analyzer != nil {
+		response.Analyzer = s.analyzer.GetConfig()
+	}
+
+	respondWithSuccess(w, response)
+}
+
+// handleUpdateConfig handles changing optimizer thresholds, buffers and cost rates at runtime.
+// Cached responses are dropped so the next request reflects the new values.
+func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.config.AllowConfigUpdates {
+		respondWithError(w, http.StatusForbidden, "CONFIG_UPDATES_DISABLED", "Runtime configuration updates are disabled")
+		return
+	}
+
+	var update optimizer.ConfigUpdate
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid configuration update: %v", err))
+		return
+	}
+
+	config, err := s.optimizer.UpdateConfig(update)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid configuration update: %v", err))
+		return
+	}
+	s.responses.Clear()
+
+	response := ConfigResponse{Optimizer: config, UpdatesEnabled: true}
+	if s.analyzer != nil {
+		response.Analyzer = s.analyzer.GetConfig()
+	}
+	respondWithSuccess(w, response)
+}
+
+// handleClusterOverview handles the cluster overview endpoint. The overview is served from a
+// short-lived cache, with its age, since computing it counts pods across every namespace.
 func (s *Server) handleClusterOverview(w http.ResponseWriter, r *http.Request) {
+	overview, age, err := s.overview.Get(s.computeClusterOverview)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			respondWithError(w, http.StatusInternalServerError, apiErr.Code, apiErr.Message)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondWithSuccess(w, ClusterOverviewResponse{
+		ClusterOverview: *overview,
+		CacheAgeSeconds: age.Seconds(),
+	})
+}
+
+// computeClusterOverview collects node metrics and namespaces and builds the cluster overview
+func (s *Server) computeClusterOverview() (*models.ClusterOverview, error) {
 	// Get node metrics
 	nodeMetrics, err := s.collector.CollectNodeMetrics()
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "METRICS_ERROR", fmt.Sprintf("Failed to collect node metrics: %v", err))
-		return
+		return nil, &APIError{Code: "METRICS_ERROR", Message: fmt.Sprintf("Failed to collect node metrics: %v", err)}
 	}
 
 	// Get all namespaces
 	ctx := context.Background()
-	namespaces, err := s.k8sClient.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	namespaces, err := s.listNamespaces(ctx)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "K8S_ERROR", fmt.Sprintf("Failed to list namespaces: %v", err))
-		return
+		return nil, &APIError{Code: "K8S_ERROR", Message: fmt.Sprintf("Failed to list namespaces: %v", err)}
 	}
 
+	return s.buildClusterOverview(ctx, nodeMetrics, namespaces), nil
+}
+
+// buildClusterOverview summarizes node metrics, capacity and pod health across the given namespaces
+func (s *Server) buildClusterOverview(ctx context.Context, nodeMetrics []models.NodeMetrics, namespaces []corev1.Namespace) *models.ClusterOverview {
 	// Count healthy nodes
 	healthyNodes := 0
 	var totalCPUCapacity, totalCPUUsage, totalMemCapacity, totalMemUsage int64
@@ -81,9 +222,9 @@ func (s *Server) handleClusterOverview(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get node details for capacity
-	nodes, err := s.k8sClient.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := s.listNodes(ctx)
 	if err == nil {
-		for _, node := range nodes.Items {
+		for _, node := range nodes {
 			cpu := node.Status.Capacity.Cpu()
 			mem := node.Status.Capacity.Memory()
 			if cpu != nil {
@@ -98,11 +239,11 @@ func (s *Server) handleClusterOverview(w http.ResponseWriter, r *http.Request) {
 	// Count total pods across all namespaces
 	totalPods := 0
 	healthyPods := 0
-	for _, ns := range namespaces.Items {
-		pods, err := s.k8sClient.Clientset.CoreV1().Pods(ns.Name).List(ctx, metav1.ListOptions{})
+	for _, ns := range namespaces {
+		pods, err := s.listPods(ctx, ns.Name)
 		if err == nil {
-			totalPods += len(pods.Items)
-			for _, pod := range pods.Items {
+			totalPods += len(pods)
+			for _, pod := range pods {
 				if pod.Status.Phase == "Running" {
 					healthyPods++
 				}
@@ -111,12 +252,12 @@ func (s *Server) handleClusterOverview(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build namespace list
-	namespaceList := make([]string, len(namespaces.Items))
-	for i, ns := range namespaces.Items {
+	namespaceList := make([]string, len(namespaces))
+	for i, ns := range namespaces {
 		namespaceList[i] = ns.Name
 	}
 
-	overview := models.ClusterOverview{
+	return &models.ClusterOverview{
 		TotalNodes:     len(nodeMetrics),
 		HealthyNodes:   healthyNodes,
 		TotalPods:      totalPods,
@@ -128,8 +269,41 @@ func (s *Server) handleClusterOverview(w http.ResponseWriter, r *http.Request) {
 		Namespaces:     namespaceList,
 		Timestamp:      time.Now(),
 	}
+}
+
+// handleNodeSizing handles suggesting fewer or cheaper nodes for under-used node pools
+func (s *Server) handleNodeSizing(w http.ResponseWriter, r *http.Request) {
+	recommendations, err := s.optimizer.RecommendNodeSizing()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to recommend node sizing: %v", err))
+		return
+	}
+
+	respondWithSuccess(w, recommendations)
+}
+
+// handleNodeConsolidation handles estimating how many nodes could be drained by bin-packing pods
+// onto fewer nodes. The data is null when no node can be removed.
+func (s *Server) handleNodeConsolidation(w http.ResponseWriter, r *http.Request) {
+	consolidation, err := s.optimizer.RecommendNodeConsolidation()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to recommend node consolidation: %v", err))
+		return
+	}
+
+	respondWithSuccess(w, consolidation)
+}
+
+// handleIdleWorkloads handles listing workloads in the monitored namespaces that use almost no CPU
+// and hold flat memory, most expensive first
+func (s *Server) handleIdleWorkloads(w http.ResponseWriter, r *http.Request) {
+	idle, err := s.optimizer.FindIdleWorkloads(s.config.Namespaces)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to find idle workloads: %v", err))
+		return
+	}
 
-	respondWithSuccess(w, overview)
+	respondWithSuccess(w, idle)
 }
 
 // handleListServices handles listing all services
@@ -149,7 +323,7 @@ func (s *Server) handleListServices(w http.ResponseWriter, r *http.Request) {
 	for _, ns := range namespaces.Items {
 		services, err := s.k8sClient.Clientset.CoreV1().Services(ns.Name).List(ctx, metav1.ListOptions{})
 		if err != nil {
-			log.Printf("Warning: failed to list services in namespace %s: %v", ns.Name, err)
+			slog.Warn("Failed to list services", "namespace", ns.Name, "error", err)
 			continue
 		}
 
@@ -185,9 +359,9 @@ func (s *Server) handleServiceDetail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get analysis from optimizer
-	analysis, err := s.optimizer.AnalyzeDeployment(namespace, name)
+	analysis, err := s.cachedAnalysis(r, namespace, name, 0)
 	if err != nil {
-		log.Printf("Warning: failed to analyze deployment %s/%s: %v", namespace, name, err)
+		slog.Warn("Failed to analyze deployment", "namespace", namespace, "name", name, "error", err)
 		analysis = &models.Analysis{
 			Namespace:  namespace,
 			Deployment: name,
@@ -196,9 +370,9 @@ func (s *Server) handleServiceDetail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get traffic analysis
-	traffic, err := s.analyzer.AnalyzeTrafficPatterns(namespace, name, 24*time.Hour)
+	traffic, err := s.cachedTraffic(r, namespace, name, 24*time.Hour)
 	if err != nil {
-		log.Printf("Warning: failed to analyze traffic for %s/%s: %v", namespace, name, err)
+		slog.Warn("Failed to analyze traffic", "namespace", namespace, "name", name, "error", err)
 		traffic = &models.TrafficAnalysis{
 			Service:   name,
 			Namespace: namespace,
@@ -207,9 +381,9 @@ func (s *Server) handleServiceDetail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get cost breakdown
-	cost, err := s.analyzer.CalculateServiceCost(namespace, name)
+	cost, err := s.cachedCost(r, namespace, name)
 	if err != nil {
-		log.Printf("Warning: failed to calculate cost for %s/%s: %v", namespace, name, err)
+		slog.Warn("Failed to calculate cost", "namespace", namespace, "name", name, "error", err)
 		cost = &models.CostBreakdown{
 			Service:   name,
 			Namespace: namespace,
@@ -218,13 +392,11 @@ func (s *Server) handleServiceDetail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get pods for this deployment
-	pods, err := s.k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("app=%s", name),
-	})
+	pods, err := s.k8sClient.DeploymentPods(ctx, deployment)
 
 	var podInfos []models.PodInfo
 	if err == nil {
-		for _, pod := range pods.Items {
+		for _, pod := range pods {
 			restarts := int32(0)
 			if len(pod.Status.ContainerStatuses) > 0 {
 				restarts = pod.Status.ContainerStatuses[0].RestartCount
@@ -246,7 +418,7 @@ func (s *Server) handleServiceDetail(w http.ResponseWriter, r *http.Request) {
 		Name:        name,
 		Namespace:   namespace,
 		Type:        "Deployment",
-		Replicas:    *deployment.Spec.Replicas,
+		Replicas:    k8s.DesiredReplicas(deployment.Spec.Replicas),
 		HealthScore: analysis.HealthScore,
 		CPUUsage:    analysis.CPUUsage,
 		MemoryUsage: analysis.MemoryUsage,
@@ -311,7 +483,12 @@ func (s *Server) handleTimeSeries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	timeSeriesData, err := s.collector.GetTimeSeriesData(params.Resource, params.Metric, params.Duration)
+	getTimeSeries := s.collector.GetTimeSeriesData
+	if params.Gaps {
+		getTimeSeries = s.collector.GetTimeSeriesDataWithGaps
+	}
+
+	timeSeriesData, err := getTimeSeries(params.Resource, params.Metric, params.Duration)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "METRICS_ERROR", fmt.Sprintf("Failed to get time series data: %v", err))
 		return
@@ -328,7 +505,138 @@ func (s *Server) handleRecommendations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondWithSuccess(w, recommendations)
+	params, err := parseRecommendationQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", err.Error())
+		return
+	}
+
+	filtered := filterRecommendations(recommendations, params)
+	sortRecommendations(filtered, params.Sort)
+
+	respondWithPage(w, paginateRecommendations(filtered, params.Offset, params.Limit), len(filtered))
+}
+
+// sortRecommendations sorts recommendations in place by the given sort parameter, by priority by default
+func sortRecommendations(recommendations []models.Recommendation, sort string) {
+	switch sort {
+	case "savings":
+		optimizer.SortBySavings(recommendations)
+	case "created_at":
+		optimizer.SortByCreatedAt(recommendations)
+	case "impact":
+		optimizer.SortByPriority(recommendations)
+		optimizer.SortByImpact(recommendations)
+	default:
+		optimizer.SortByPriority(recommendations)
+	}
+}
+
+// filterRecommendations returns the recommendations matching every filter in params
+func filterRecommendations(recommendations []models.Recommendation, params *RecommendationQueryParams) []models.Recommendation {
+	filtered := make([]models.Recommendation, 0, len(recommendations))
+	for _, rec := range recommendations {
+		if params.Namespace != "" && rec.Namespace != params.Namespace {
+			continue
+		}
+		if params.Type != "" && rec.Type != params.Type {
+			continue
+		}
+		if params.Priority != "" && rec.Priority != params.Priority {
+			continue
+		}
+		if rec.EstimatedSavings < params.MinSavings {
+			continue
+		}
+		filtered = append(filtered, rec)
+	}
+	return filtered
+}
+
+// paginateRecommendations returns the page starting at offset, with at most limit recommendations (all if limit is 0)
+func paginateRecommendations(items []models.Recommendation, offset, limit int) []models.Recommendation {
+	if offset >= len(items) {
+		return []models.Recommendation{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// handleRecommendationExport handles downloading every recommendation matching the list filters as
+// CSV or JSON, unpaginated
+func (s *Server) handleRecommendationExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Unsupported export format %q (expected csv or json)", format))
+		return
+	}
+
+	params, err := parseRecommendationQueryParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", err.Error())
+		return
+	}
+
+	recommendations, err := s.optimizer.GetAllRecommendations()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to get recommendations: %v", err))
+		return
+	}
+
+	filtered := filterRecommendations(recommendations, params)
+	sortRecommendations(filtered, params.Sort)
+
+	filename := fmt.Sprintf("recommendations-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		writeRecommendationsJSON(w, filtered)
+		return
+	}
+	writeRecommendationsCSV(w, filtered)
+}
+
+// handleRecommendationSummary returns recommendation counts and savings without the recommendations
+// themselves, for dashboard headline numbers
+func (s *Server) handleRecommendationSummary(w http.ResponseWriter, r *http.Request) {
+	savings, err := s.optimizer.GetTotalPotentialSavings()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to get potential savings: %v", err))
+		return
+	}
+
+	stats := s.optimizer.GetRecommendationStats()
+	count := func(key string) int {
+		n, _ := stats[key].(int)
+		return n
+	}
+	amount := func(key string) float64 {
+		v, _ := stats[key].(float64)
+		return v
+	}
+	byType, _ := stats["by_type"].(map[string]int)
+	if byType == nil {
+		byType = map[string]int{}
+	}
+
+	respondWithSuccess(w, RecommendationSummaryResponse{
+		Total:                 count("total"),
+		HighPriority:          count("high_priority"),
+		MediumPriority:        count("medium_priority"),
+		LowPriority:           count("low_priority"),
+		ByType:                byType,
+		TotalSavings:          amount("total_savings"),
+		CashSavings:           amount("cash_savings"),
+		ReclaimableSavings:    amount("reclaimable_savings"),
+		TotalPotentialSavings: savings,
+		AffectedDeployments:   count("affected_deployments"),
+	})
 }
 
 // handleRecommendationByID handles getting a specific recommendation
@@ -336,22 +644,79 @@ func (s *Server) handleRecommendationByID(w http.ResponseWriter, r *http.Request
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	// Get all recommendations and find the one with the matching ID
-	recommendations, err := s.optimizer.GetAllRecommendations()
+	rec, err := s.findRecommendation(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to get recommendations: %v", err))
+		return
+	}
+
+	if rec == nil {
+		respondWithError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Recommendation not found: %s", id))
+		return
+	}
+
+	respondWithSuccess(w, rec)
+}
+
+// handleRecommendationTicket handles exporting a recommendation as a markdown ticket
+func (s *Server) handleRecommendationTicket(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	rec, err := s.findRecommendation(id)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to get recommendations: %v", err))
 		return
 	}
 
-	// Find recommendation by ID
+	if rec == nil {
+		respondWithError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Recommendation not found: %s", id))
+		return
+	}
+
+	command, err := s.optimizer.RecommendationCommand(id)
+	respondWithText(w, http.StatusOK, "text/markdown; charset=utf-8", renderTicketMarkdown(*rec, command, err))
+}
+
+// handleRecommendationManifest handles exporting a recommendation as a YAML patch manifest
+func (s *Server) handleRecommendationManifest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	rec, err := s.findRecommendation(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to get recommendations: %v", err))
+		return
+	}
+
+	if rec == nil {
+		respondWithError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Recommendation not found: %s", id))
+		return
+	}
+
+	manifest, err := s.optimizer.RecommendationManifest(id)
+	if err != nil {
+		respondWithAnalysisError(w, "MANIFEST_ERROR", "Failed to build manifest", err)
+		return
+	}
+
+	respondWithText(w, http.StatusOK, "application/yaml", manifest)
+}
+
+// findRecommendation returns the recommendation with the given ID, or nil if none matches
+func (s *Server) findRecommendation(id string) (*models.Recommendation, error) {
+	recommendations, err := s.optimizer.GetAllRecommendations()
+	if err != nil {
+		return nil, err
+	}
+
 	for _, rec := range recommendations {
 		if rec.ID == id {
-			respondWithSuccess(w, rec)
-			return
+			return &rec, nil
 		}
 	}
 
-	respondWithError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Recommendation not found: %s", id))
+	return nil, nil
 }
 
 // handleApplyRecommendation handles applying a recommendation
@@ -359,9 +724,11 @@ func (s *Server) handleApplyRecommendation(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	err := s.optimizer.ApplyRecommendation(id)
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	patch, err := s.optimizer.ApplyRecommendation(id, dryRun)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "APPLY_FAILED", fmt.Sprintf("Failed to apply recommendation: %v", err))
+		respondWithAnalysisError(w, "APPLY_FAILED", "Failed to apply recommendation", err)
 		return
 	}
 
@@ -369,20 +736,227 @@ func (s *Server) handleApplyRecommendation(w http.ResponseWriter, r *http.Reques
 		Status:  "applied",
 		ID:      id,
 		Message: "Recommendation applied successfully",
+		Patch:   patch,
+	}
+	if dryRun {
+		response.Status = "dry_run"
+		response.Message = "Patch computed but not applied"
 	}
 
 	respondWithSuccess(w, response)
 }
 
+// handleRevertRecommendation handles reverting an applied recommendation
+func (s *Server) handleRevertRecommendation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := s.optimizer.RevertRecommendation(id); err != nil {
+		respondWithAnalysisError(w, "REVERT_FAILED", "Failed to revert recommendation", err)
+		return
+	}
+
+	response := ApplyRecommendationResponse{
+		Status:  "reverted",
+		ID:      id,
+		Message: "Recommendation reverted successfully",
+	}
+
+	respondWithSuccess(w, response)
+}
+
+// handleDismissRecommendation handles dismissing a reviewed recommendation
+func (s *Server) handleDismissRecommendation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	rec, err := s.findRecommendation(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to get recommendations: %v", err))
+		return
+	}
+	if rec == nil {
+		respondWithError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Recommendation not found: %s", id))
+		return
+	}
+
+	if err := s.optimizer.DismissRecommendation(id); err != nil {
+		respondWithAnalysisError(w, "DISMISS_FAILED", "Failed to dismiss recommendation", err)
+		return
+	}
+
+	response := ApplyRecommendationResponse{
+		Status:  "dismissed",
+		ID:      id,
+		Message: "Recommendation dismissed successfully",
+	}
+
+	respondWithSuccess(w, response)
+}
+
+// handleSimulateHPA handles simulating a proposed HPA configuration against historical CPU usage
+func (s *Server) handleSimulateHPA(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	var proposed optimizer.HPAConfig
+	if err := json.NewDecoder(r.Body).Decode(&proposed); err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid HPA configuration: %v", err))
+		return
+	}
+	if err := proposed.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid HPA configuration: %v", err))
+		return
+	}
+
+	simulation, err := s.optimizer.SimulateHPA(namespace, name, proposed)
+	if err != nil {
+		respondWithAnalysisError(w, "SIMULATION_ERROR", "Failed to simulate HPA", err)
+		return
+	}
+
+	respondWithSuccess(w, simulation)
+}
+
+// handleSimulateRecommendations handles projecting the cluster's monthly cost if a set of
+// recommendations were applied. Nothing is applied.
+func (s *Server) handleSimulateRecommendations(w http.ResponseWriter, r *http.Request) {
+	var request SimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if len(request.RecommendationIDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", "recommendation_ids must list at least one recommendation")
+		return
+	}
+
+	for _, id := range request.RecommendationIDs {
+		rec, err := s.findRecommendation(id)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "OPTIMIZER_ERROR", fmt.Sprintf("Failed to get recommendations: %v", err))
+			return
+		}
+		if rec == nil {
+			respondWithError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Recommendation not found: %s", id))
+			return
+		}
+	}
+
+	simulation, err := s.optimizer.SimulateRecommendations(request.RecommendationIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "SIMULATION_ERROR", fmt.Sprintf("Failed to simulate recommendations: %v", err))
+		return
+	}
+
+	respondWithSuccess(w, simulation)
+}
+
 // handleAnalysis handles getting analysis for a specific service
 func (s *Server) handleAnalysis(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	namespace := vars["namespace"]
 	service := vars["service"]
 
-	analysis, err := s.optimizer.AnalyzeDeployment(namespace, service)
+	window, err := parseAnalysisWindow(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid window: %v", err))
+		return
+	}
+
+	analysis, err := s.cachedAnalysis(r, namespace, service, window)
+	if err != nil {
+		respondWithAnalysisError(w, "ANALYSIS_ERROR", "Failed to analyze service", err)
+		return
+	}
+
+	respondWithSuccess(w, analysis)
+}
+
+// handleAnalysisWindows handles comparing a service's analysis across several time windows
+func (s *Server) handleAnalysisWindows(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	service := vars["service"]
+
+	labels, windows, err := parseAnalysisWindows(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid windows: %v", err))
+		return
+	}
+
+	response := WindowComparisonResponse{
+		Namespace: namespace,
+		Service:   service,
+		Windows:   make([]WindowAnalysis, 0, len(windows)),
+	}
+	for i, window := range windows {
+		analysis, err := s.cachedAnalysis(r, namespace, service, window)
+		if err != nil {
+			respondWithAnalysisError(w, "ANALYSIS_ERROR", fmt.Sprintf("Failed to analyze service over %s", labels[i]), err)
+			return
+		}
+		response.Windows = append(response.Windows, WindowAnalysis{Window: labels[i], Analysis: analysis})
+	}
+
+	respondWithSuccess(w, response)
+}
+
+// handleBatchAnalysis handles analyzing several deployments in one request. Deployments are analyzed
+// concurrently; a deployment that fails to analyze is reported in its result rather than failing the batch.
+func (s *Server) handleBatchAnalysis(w http.ResponseWriter, r *http.Request) {
+	items, err := parseBatchAnalysisRequest(w, r)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", err.Error())
+		return
+	}
+
+	// Duplicates in the request are analyzed once
+	seen := make(map[string]bool, len(items))
+	unique := make([]BatchAnalysisItem, 0, len(items))
+	for _, item := range items {
+		if key := item.Namespace + "/" + item.Deployment; !seen[key] {
+			seen[key] = true
+			unique = append(unique, item)
+		}
+	}
+
+	results := make([]BatchAnalysisResult, len(unique))
+	parallel.ForEach(len(unique), batchAnalysisConcurrency, func(i int) {
+		item := unique[i]
+		results[i] = BatchAnalysisResult{Namespace: item.Namespace, Deployment: item.Deployment}
+		analysis, err := s.cachedAnalysis(r, item.Namespace, item.Deployment, 0)
+		if err != nil {
+			results[i].Error = err.Error()
+			return
+		}
+		results[i].Analysis = analysis
+	})
+
+	response := BatchAnalysisResponse{Results: make(map[string]BatchAnalysisResult, len(results))}
+	for _, result := range results {
+		response.Results[result.Namespace+"/"+result.Deployment] = result
+	}
+
+	respondWithSuccess(w, response)
+}
+
+// handleContainerAnalysis handles getting the analysis and recommendations for a single container
+func (s *Server) handleContainerAnalysis(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	deployment := vars["deployment"]
+	container := vars["container"]
+
+	analysis, err := s.optimizer.AnalyzeContainer(namespace, deployment, container)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "ANALYSIS_ERROR", fmt.Sprintf("Failed to analyze service: %v", err))
+		respondWithAnalysisError(w, "ANALYSIS_ERROR", "Failed to analyze container", err)
 		return
 	}
 
@@ -404,7 +978,7 @@ func (s *Server) handleTraffic(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	traffic, err := s.analyzer.AnalyzeTrafficPatterns(namespace, service, duration)
+	traffic, err := s.cachedTraffic(r, namespace, service, duration)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "TRAFFIC_ERROR", fmt.Sprintf("Failed to analyze traffic: %v", err))
 		return
@@ -419,7 +993,7 @@ func (s *Server) handleCost(w http.ResponseWriter, r *http.Request) {
 	namespace := vars["namespace"]
 	service := vars["service"]
 
-	cost, err := s.analyzer.CalculateServiceCost(namespace, service)
+	cost, err := s.cachedCost(r, namespace, service)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "COST_ERROR", fmt.Sprintf("Failed to calculate cost: %v", err))
 		return
@@ -428,6 +1002,47 @@ func (s *Server) handleCost(w http.ResponseWriter, r *http.Request) {
 	respondWithSuccess(w, cost)
 }
 
+// handleNamespaceCost handles getting the summed cost of every service in a namespace
+func (s *Server) handleNamespaceCost(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	value, err := s.responses.Get(responseCacheKey("cost", namespace), wantsRefresh(r), func() (interface{}, error) {
+		return s.analyzer.CalculateNamespaceCost(namespace)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "COST_ERROR", fmt.Sprintf("Failed to calculate namespace cost: %v", err))
+		return
+	}
+
+	respondWithSuccess(w, value)
+}
+
+// handleCostTrends handles getting a service's cost sampled over time
+func (s *Server) handleCostTrends(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	service := vars["service"]
+
+	// Default duration is 7 days
+	duration := 7 * 24 * time.Hour
+	if durationStr := r.URL.Query().Get("duration"); durationStr != "" {
+		parsed, err := parseWindowDuration(durationStr)
+		if err != nil || parsed <= 0 {
+			respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Invalid duration: %s", durationStr))
+			return
+		}
+		duration = parsed
+	}
+
+	trends, err := s.analyzer.GetCostTrends(namespace, service, duration)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "COST_ERROR", fmt.Sprintf("Failed to calculate cost trends: %v", err))
+		return
+	}
+
+	respondWithSuccess(w, trends)
+}
+
 // handleAnomalies handles getting detected anomalies
 func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
 	params, err := parseAnomalyQueryParams(r)
@@ -448,13 +1063,14 @@ func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
 		metric = "cpu"
 	}
 
-	anomalies, err := s.analyzer.DetectAnomalies(params.Resource, metric, params.Duration)
+	anomalies, err := s.analyzer.DetectAnomaliesWithMethods(params.Resource, metric, params.Duration, params.Methods)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "ANOMALY_ERROR", fmt.Sprintf("Failed to detect anomalies: %v", err))
 		return
 	}
 
-	respondWithSuccess(w, anomalies)
+	// Hide acknowledged anomalies until their snooze expires
+	respondWithSuccess(w, s.anomalies.Filter(anomalies))
 }
 
 // handleListDeployments handles listing all deployments with metrics
@@ -462,7 +1078,7 @@ func (s *Server) handleListDeployments(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 
 	// Get all namespaces
-	namespaces, err := s.k8sClient.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	namespaces, err := s.listNamespaces(ctx)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "K8S_ERROR", fmt.Sprintf("Failed to list namespaces: %v", err))
 		return
@@ -470,28 +1086,25 @@ func (s *Server) handleListDeployments(w http.ResponseWriter, r *http.Request) {
 
 	var allDeployments []map[string]interface{}
 
-	for _, ns := range namespaces.Items {
-		deployments, err := s.k8sClient.Clientset.AppsV1().Deployments(ns.Name).List(ctx, metav1.ListOptions{})
+	for _, ns := range namespaces {
+		deployments, err := s.listDeployments(ctx, ns.Name)
 		if err != nil {
-			log.Printf("Warning: failed to list deployments in namespace %s: %v", ns.Name, err)
+			slog.Warn("Failed to list deployments", "namespace", ns.Name, "error", err)
 			continue
 		}
 
-		for _, deploy := range deployments.Items {
+		for _, deploy := range deployments {
 			// Get pod metrics for this deployment
 			podMetrics, _ := s.collector.CollectPodMetrics(ns.Name)
 
 			var totalCPU, totalMemory int64
 			var healthyPods int32
 
-			// Match pods to deployment by label selector
+			// Match pods to deployment through its ReplicaSets
 			if deploy.Spec.Selector != nil && len(deploy.Spec.Selector.MatchLabels) > 0 {
-				labelSelector := labels.SelectorFromSet(deploy.Spec.Selector.MatchLabels)
-				pods, _ := s.k8sClient.Clientset.CoreV1().Pods(ns.Name).List(ctx, metav1.ListOptions{
-					LabelSelector: labelSelector.String(),
-				})
+				pods, _ := s.deploymentPods(ctx, &deploy)
 
-				for _, pod := range pods.Items {
+				for _, pod := range pods {
 					if pod.Status.Phase == "Running" {
 						healthyPods++
 					}
@@ -505,10 +1118,7 @@ func (s *Server) handleListDeployments(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
-			replicas := int32(0)
-			if deploy.Spec.Replicas != nil {
-				replicas = *deploy.Spec.Replicas
-			}
+			replicas := k8s.DesiredReplicas(deploy.Spec.Replicas)
 
 			healthScore := 0.0
 			if replicas > 0 {
@@ -571,13 +1181,10 @@ func (s *Server) handleDeploymentDetail(w http.ResponseWriter, r *http.Request)
 	var runningPods int
 
 	if deployment.Spec.Selector != nil && len(deployment.Spec.Selector.MatchLabels) > 0 {
-		labelSelector := labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels)
-		podList, err := s.k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-			LabelSelector: labelSelector.String(),
-		})
+		podList, err := s.k8sClient.DeploymentPods(ctx, deployment)
 
 		if err == nil {
-			for _, pod := range podList.Items {
+			for _, pod := range podList {
 				if pod.Status.Phase == "Running" {
 					runningPods++
 				}
@@ -599,10 +1206,7 @@ func (s *Server) handleDeploymentDetail(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	replicas := int32(0)
-	if deployment.Spec.Replicas != nil {
-		replicas = *deployment.Spec.Replicas
-	}
+	replicas := k8s.DesiredReplicas(deployment.Spec.Replicas)
 
 	// Calculate health score
 	healthScore := 0.0
@@ -2,7 +2,8 @@ package api
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
 	"time"
@@ -31,23 +32,47 @@ func loggingMiddleware(next http.Handler) http.Handler {
 		// Call the next handler
 		next.ServeHTTP(lrw, r)
 
-		// Log the request
-		duration := time.Since(start)
-		log.Printf("[%s] %s %s %d %s %s",
-			requestID,
-			r.Method,
-			r.URL.Path,
-			lrw.statusCode,
-			duration,
-			r.RemoteAddr,
-		)
+		// Log the request; server errors carry the handler's error message, and health checks and
+		// scrapes only show up at debug level
+		attrs := []any{
+			"request_id", requestID,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", lrw.statusCode,
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+		}
+		switch {
+		case lrw.statusCode >= http.StatusInternalServerError:
+			var response APIResponse
+			if json.Unmarshal(lrw.errorBody, &response) == nil && response.Error != nil {
+				attrs = append(attrs, "error_code", response.Error.Code, "error", response.Error.Message)
+			}
+			slog.Error("Request failed", attrs...)
+		case quietPaths[r.URL.Path]:
+			slog.Debug("Request", attrs...)
+		default:
+			slog.Info("Request", attrs...)
+		}
 	})
 }
 
-// loggingResponseWriter wraps http.ResponseWriter to capture status code
+// quietPaths are polled by probes and scrapers and only logged at debug level
+var quietPaths = map[string]bool{
+	"/health":             true,
+	"/ready":              true,
+	"/metrics/prometheus": true,
+}
+
+// maxLoggedErrorBody caps how much of a failed response is kept for the request log
+const maxLoggedErrorBody = 4096
+
+// loggingResponseWriter wraps http.ResponseWriter to capture status code and the body of
+// server error responses
 type loggingResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	errorBody  []byte
 }
 
 func (lrw *loggingResponseWriter) WriteHeader(code int) {
@@ -55,6 +80,18 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+func (lrw *loggingResponseWriter) Write(b []byte) (int, error) {
+	if lrw.statusCode >= http.StatusInternalServerError && len(lrw.errorBody) < maxLoggedErrorBody {
+		lrw.errorBody = append(lrw.errorBody, b[:min(len(b), maxLoggedErrorBody-len(lrw.errorBody))]...)
+	}
+	return lrw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer so http.ResponseController can flush streamed responses
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
 // corsMiddleware adds CORS headers
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -107,7 +144,7 @@ func recoveryMiddleware(next http.Handler) http.Handler {
 			if err := recover(); err != nil {
 				// Log the panic
 				requestID := getRequestID(r.Context())
-				log.Printf("[%s] PANIC: %v\n%s", requestID, err, debug.Stack())
+				slog.Error("Panic while serving request", "request_id", requestID, "panic", err, "stack", string(debug.Stack()))
 
 				// Return 500 error
 				respondWithError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "An internal server error occurred")
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// overviewStaleFactor is how many TTLs old a cached overview may be and still be served while it
+// is refreshed in the background; older overviews, e.g. after an idle spell, are recomputed inline
+const overviewStaleFactor = 4
+
+// overviewCache holds the latest cluster overview. Fresh copies are served as is; stale ones are
+// served while a single background refresh runs, and concurrent first requests share one computation.
+type overviewCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	overview   *models.ClusterOverview
+	computedAt time.Time
+	loading    chan struct{} // closed when the in-flight computation finishes; nil when idle
+	err        error         // error of the last computation, for requests that waited on it
+	now        func() time.Time
+}
+
+// newOverviewCache creates an overview cache; a non-positive TTL disables caching
+func newOverviewCache(ttl time.Duration) *overviewCache {
+	return &overviewCache{ttl: ttl, now: time.Now}
+}
+
+// Get returns the cached overview and its age, computing it inline when there is none or it is
+// too stale, and refreshing it in the background when it is merely past its TTL
+func (c *overviewCache) Get(compute func() (*models.ClusterOverview, error)) (*models.ClusterOverview, time.Duration, error) {
+	if c.ttl <= 0 {
+		overview, err := compute()
+		return overview, 0, err
+	}
+
+	c.mu.Lock()
+	if c.overview != nil {
+		age := c.now().Sub(c.computedAt)
+		if age < c.ttl {
+			overview := c.overview
+			c.mu.Unlock()
+			return overview, age, nil
+		}
+		if age < overviewStaleFactor*c.ttl {
+			if c.loading == nil {
+				c.start(compute)
+			}
+			overview := c.overview
+			c.mu.Unlock()
+			return overview, age, nil
+		}
+	}
+
+	// Nothing usable is cached: wait for the in-flight computation, or run one
+	loading := c.loading
+	if loading == nil {
+		loading = c.start(compute)
+	}
+	c.mu.Unlock()
+	<-loading
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, 0, c.err
+	}
+	return c.overview, c.now().Sub(c.computedAt), nil
+}
+
+// start runs compute in a new goroutine and returns the channel closed when it finishes. Failed
+// background refreshes keep the previous overview. Callers must hold the lock.
+func (c *overviewCache) start(compute func() (*models.ClusterOverview, error)) chan struct{} {
+	loading := make(chan struct{})
+	c.loading = loading
+	go func() {
+		overview, err := compute()
+
+		c.mu.Lock()
+		if err == nil {
+			c.overview, c.computedAt = overview, c.now()
+		}
+		c.err = err
+		c.loading = nil
+		c.mu.Unlock()
+		close(loading)
+	}()
+	return loading
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// prometheusContentType is the content type of the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// prometheusGauge describes a per-deployment gauge and how to read it from a summary
+type prometheusGauge struct {
+	name         string
+	help         string
+	analyzedOnly bool // omitted for deployments without a cached analysis
+	value        func(models.DeploymentSummary) float64
+}
+
+// deploymentGauges are the per-deployment gauges exported for scraping
+var deploymentGauges = []prometheusGauge{
+	{
+		name:         "k8s_optimizer_efficiency_score",
+		help:         "Latest efficiency score of the deployment (0-100).",
+		analyzedOnly: true,
+		value:        func(s models.DeploymentSummary) float64 { return s.EfficiencyScore },
+	},
+	{
+		name:         "k8s_optimizer_monthly_cost_dollars",
+		help:         "Estimated monthly cost of the deployment's requested resources.",
+		analyzedOnly: true,
+		value:        func(s models.DeploymentSummary) float64 { return s.MonthlyCost },
+	},
+	{
+		name:         "k8s_optimizer_wasted_monthly_cost_dollars",
+		help:         "Estimated monthly cost of requested but unused resources.",
+		analyzedOnly: true,
+		value:        func(s models.DeploymentSummary) float64 { return s.WastedCost },
+	},
+	{
+		name:  "k8s_optimizer_recommendations",
+		help:  "Number of recommendations for the deployment that have not been applied.",
+		value: func(s models.DeploymentSummary) float64 { return float64(s.RecommendationCount) },
+	},
+}
+
+// handlePrometheusMetrics exposes the latest cached per-deployment figures in the Prometheus text format
+func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	w.WriteHeader(http.StatusOK)
+	writePrometheusGauges(w, s.optimizer.GetDeploymentSummaries())
+}
+
+// writePrometheusGauges writes each gauge family with one sample per deployment
+func writePrometheusGauges(w io.Writer, summaries []models.DeploymentSummary) {
+	for _, gauge := range deploymentGauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", gauge.name, gauge.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", gauge.name)
+		for _, summary := range summaries {
+			if gauge.analyzedOnly && !summary.Analyzed {
+				continue
+			}
+			fmt.Fprintf(w, "%s{namespace=\"%s\",deployment=\"%s\"} %s\n", gauge.name,
+				escapeLabelValue(summary.Namespace), escapeLabelValue(summary.Deployment),
+				strconv.FormatFloat(gauge.value(summary), 'g', -1, 64))
+		}
+	}
+}
+
+// labelValueEscaper escapes backslashes, double quotes and newlines in label values
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes a label value for the text exposition format
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
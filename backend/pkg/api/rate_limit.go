@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit is a token bucket: requests are admitted at PerSecond on average, with bursts of up to
+// Burst requests. A non-positive PerSecond disables limiting.
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// heavyRoutes aggregate across every namespace, deployment or pod and are held to HeavyRateLimit
+var heavyRoutes = map[string]bool{
+	"/api/v1/cluster/overview":       true,
+	"/api/v1/cluster/node-sizing":    true,
+	"/api/v1/cluster/consolidation":  true,
+	"/api/v1/idle":                   true,
+	"/api/v1/services":               true,
+	"/api/v1/deployments":            true,
+	"/api/v1/cost/{namespace}":       true,
+	"/api/v1/analysis/batch":         true,
+	"/api/v1/recommendations/export": true,
+	"/api/v1/report":                 true,
+	"/api/v1/federation/export":      true,
+}
+
+// routeLimiter keeps one token bucket per route, so a busy endpoint cannot starve the others
+type routeLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	normal   RateLimit
+	heavy    RateLimit
+}
+
+// newRouteLimiter creates a limiter applying heavy to heavyRoutes and normal to every other route
+func newRouteLimiter(normal, heavy RateLimit) *routeLimiter {
+	return &routeLimiter{
+		limiters: make(map[string]*rate.Limiter),
+		normal:   normal,
+		heavy:    heavy,
+	}
+}
+
+// limiter returns the bucket of a route, or nil when the route is not limited
+func (l *routeLimiter) limiter(method, route string) *rate.Limiter {
+	limit := l.normal
+	if heavyRoutes[route] {
+		limit = l.heavy
+	}
+	if limit.PerSecond <= 0 {
+		return nil
+	}
+
+	key := method + " " + route
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit.PerSecond), max(limit.Burst, 1))
+		l.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// rateLimitMiddleware rejects requests beyond their route's rate with 429 and a Retry-After header
+// giving the whole seconds until a token is available
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		limiter := s.limiter.limiter(r.Method, route)
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reservation := limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			respondWithError(w, http.StatusTooManyRequests, "RATE_LIMITED",
+				fmt.Sprintf("Too many requests to %s; retry after %ds", route, retryAfter))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
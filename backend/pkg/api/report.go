@@ -0,0 +1,269 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+const (
+	// reportTopWorkloads is the number of most wasteful workloads listed in a report
+	reportTopWorkloads = 10
+
+	// reportAnomalyWindow is the window scanned for anomalies when building a report
+	reportAnomalyWindow = 24 * time.Hour
+)
+
+// ClusterReport is a downloadable, cluster-wide optimization report
+type ClusterReport struct {
+	GeneratedAt           time.Time               `json:"generated_at"`
+	Overview              *models.ClusterOverview `json:"overview"`
+	Namespaces            []NamespaceSavings      `json:"namespaces"`
+	TopWastefulWorkloads  []WorkloadSavings       `json:"top_wasteful_workloads"`
+	Anomalies             AnomalySummary          `json:"anomalies"`
+	Recommendations       []models.Recommendation `json:"recommendations"`
+	TotalProjectedSavings float64                 `json:"total_projected_savings"`
+}
+
+// NamespaceSavings summarizes recommendations and projected savings for a namespace
+type NamespaceSavings struct {
+	Namespace        string  `json:"namespace"`
+	Recommendations  int     `json:"recommendations"`
+	EstimatedSavings float64 `json:"estimated_savings"`
+}
+
+// WorkloadSavings summarizes recommendations and projected savings for a deployment
+type WorkloadSavings struct {
+	Namespace        string  `json:"namespace"`
+	Deployment       string  `json:"deployment"`
+	Recommendations  int     `json:"recommendations"`
+	EstimatedSavings float64 `json:"estimated_savings"`
+}
+
+// AnomalySummary counts anomalies detected across the cluster
+type AnomalySummary struct {
+	Total      int            `json:"total"`
+	BySeverity map[string]int `json:"by_severity"`
+	ByType     map[string]int `json:"by_type"`
+}
+
+// handleReport handles downloading a full cluster optimization report
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "html" {
+		respondWithError(w, http.StatusBadRequest, "INVALID_PARAMS", fmt.Sprintf("Unsupported report format %q (expected json or html)", format))
+		return
+	}
+
+	// Reports are shared through the response cache, so repeated downloads don't rescan every pod
+	var code string
+	value, err := s.responses.Get(responseCacheKey("report"), wantsRefresh(r), func() (interface{}, error) {
+		report, failedCode, err := s.buildReport()
+		code = failedCode
+		return report, err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, code, err.Error())
+		return
+	}
+	report := value.(*ClusterReport)
+
+	filename := fmt.Sprintf("optimization-report-%s.%s", report.GeneratedAt.UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "html" {
+		var buf bytes.Buffer
+		if err := reportTemplate.Execute(&buf, report); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "REPORT_ERROR", fmt.Sprintf("Failed to render report: %v", err))
+			return
+		}
+		respondWithText(w, http.StatusOK, "text/html; charset=utf-8", buf.String())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// buildReport assembles a cluster report, returning the API error code to report on failure
+func (s *Server) buildReport() (*ClusterReport, string, error) {
+	// Get node metrics
+	nodeMetrics, err := s.collector.CollectNodeMetrics()
+	if err != nil {
+		return nil, "METRICS_ERROR", fmt.Errorf("Failed to collect node metrics: %v", err)
+	}
+
+	// Get all namespaces
+	ctx := context.Background()
+	namespaces, err := s.listNamespaces(ctx)
+	if err != nil {
+		return nil, "K8S_ERROR", fmt.Errorf("Failed to list namespaces: %v", err)
+	}
+
+	// Applied, dismissed and superseded recommendations hold no savings left to realise
+	recommendations, err := s.optimizer.GetActiveRecommendations()
+	if err != nil {
+		return nil, "OPTIMIZER_ERROR", fmt.Errorf("Failed to get recommendations: %v", err)
+	}
+
+	report := &ClusterReport{
+		GeneratedAt:     time.Now(),
+		Overview:        s.buildClusterOverview(ctx, nodeMetrics, namespaces),
+		Recommendations: recommendations,
+	}
+	summarizeSavings(report, recommendations)
+	report.Anomalies = s.summarizeAnomalies(ctx, report.Overview.Namespaces)
+
+	return report, "", nil
+}
+
+// summarizeSavings aggregates recommendation savings per namespace and per workload
+func summarizeSavings(report *ClusterReport, recommendations []models.Recommendation) {
+	namespaces := make(map[string]*NamespaceSavings)
+	workloads := make(map[string]*WorkloadSavings)
+
+	for _, rec := range recommendations {
+		ns, ok := namespaces[rec.Namespace]
+		if !ok {
+			ns = &NamespaceSavings{Namespace: rec.Namespace}
+			namespaces[rec.Namespace] = ns
+		}
+		ns.Recommendations++
+		ns.EstimatedSavings += rec.EstimatedSavings
+
+		key := rec.Namespace + "/" + rec.Deployment
+		workload, ok := workloads[key]
+		if !ok {
+			workload = &WorkloadSavings{Namespace: rec.Namespace, Deployment: rec.Deployment}
+			workloads[key] = workload
+		}
+		workload.Recommendations++
+		workload.EstimatedSavings += rec.EstimatedSavings
+
+		report.TotalProjectedSavings += rec.EstimatedSavings
+	}
+
+	report.Namespaces = make([]NamespaceSavings, 0, len(namespaces))
+	for _, ns := range namespaces {
+		report.Namespaces = append(report.Namespaces, *ns)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		if report.Namespaces[i].EstimatedSavings != report.Namespaces[j].EstimatedSavings {
+			return report.Namespaces[i].EstimatedSavings > report.Namespaces[j].EstimatedSavings
+		}
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+
+	report.TopWastefulWorkloads = make([]WorkloadSavings, 0, len(workloads))
+	for _, workload := range workloads {
+		report.TopWastefulWorkloads = append(report.TopWastefulWorkloads, *workload)
+	}
+	sort.Slice(report.TopWastefulWorkloads, func(i, j int) bool {
+		a, b := report.TopWastefulWorkloads[i], report.TopWastefulWorkloads[j]
+		if a.EstimatedSavings != b.EstimatedSavings {
+			return a.EstimatedSavings > b.EstimatedSavings
+		}
+		return a.Namespace+"/"+a.Deployment < b.Namespace+"/"+b.Deployment
+	})
+	if len(report.TopWastefulWorkloads) > reportTopWorkloads {
+		report.TopWastefulWorkloads = report.TopWastefulWorkloads[:reportTopWorkloads]
+	}
+}
+
+// summarizeAnomalies counts CPU and memory anomalies for all pods in the given namespaces
+func (s *Server) summarizeAnomalies(ctx context.Context, namespaces []string) AnomalySummary {
+	summary := AnomalySummary{
+		BySeverity: make(map[string]int),
+		ByType:     make(map[string]int),
+	}
+
+	for _, namespace := range namespaces {
+		pods, err := s.listPods(ctx, namespace)
+		if err != nil {
+			continue
+		}
+
+		for _, pod := range pods {
+			resource := fmt.Sprintf("pod/%s", pod.Name)
+			for _, metric := range []string{"cpu", "memory"} {
+				anomalies, err := s.analyzer.DetectAnomalies(resource, metric, reportAnomalyWindow)
+				if err != nil {
+					continue
+				}
+				for _, anomaly := range s.anomalies.Filter(anomalies) {
+					summary.Total++
+					summary.BySeverity[anomaly.Severity]++
+					summary.ByType[anomaly.Type]++
+				}
+			}
+		}
+	}
+
+	return summary
+}
+
+// reportTemplate renders a ClusterReport as a standalone HTML page
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"money": func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Cluster Optimization Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+th { background: #f4f4f4; }
+</style>
+</head>
+<body>
+<h1>Cluster Optimization Report</h1>
+<p>Generated {{.GeneratedAt.UTC.Format "2006-01-02 15:04 MST"}}</p>
+
+<h2>Overview</h2>
+<table>
+<tr><th>Nodes</th><td>{{.Overview.HealthyNodes}} / {{.Overview.TotalNodes}} healthy</td></tr>
+<tr><th>Pods</th><td>{{.Overview.HealthyPods}} / {{.Overview.TotalPods}} running</td></tr>
+<tr><th>CPU</th><td>{{.Overview.CPUUsage}}m used of {{.Overview.CPUCapacity}}m</td></tr>
+<tr><th>Memory</th><td>{{.Overview.MemoryUsage}} bytes used of {{.Overview.MemoryCapacity}} bytes</td></tr>
+<tr><th>Total projected savings</th><td>{{money .TotalProjectedSavings}}/month</td></tr>
+</table>
+
+<h2>Savings by Namespace</h2>
+<table>
+<tr><th>Namespace</th><th>Recommendations</th><th>Estimated savings</th></tr>
+{{range .Namespaces}}<tr><td>{{.Namespace}}</td><td>{{.Recommendations}}</td><td>{{money .EstimatedSavings}}/month</td></tr>
+{{else}}<tr><td colspan="3">No recommendations</td></tr>
+{{end}}</table>
+
+<h2>Top Wasteful Workloads</h2>
+<table>
+<tr><th>Workload</th><th>Recommendations</th><th>Estimated savings</th></tr>
+{{range .TopWastefulWorkloads}}<tr><td>{{.Namespace}}/{{.Deployment}}</td><td>{{.Recommendations}}</td><td>{{money .EstimatedSavings}}/month</td></tr>
+{{else}}<tr><td colspan="3">No wasteful workloads found</td></tr>
+{{end}}</table>
+
+<h2>Anomalies</h2>
+<p>{{.Anomalies.Total}} anomalies detected in the last 24 hours.</p>
+{{if .Anomalies.Total}}<table>
+<tr><th>Severity</th><th>Count</th></tr>
+{{range $severity, $count := .Anomalies.BySeverity}}<tr><td>{{$severity}}</td><td>{{$count}}</td></tr>
+{{end}}</table>{{end}}
+
+<h2>Recommendations</h2>
+<table>
+<tr><th>Priority</th><th>Workload</th><th>Type</th><th>Description</th><th>Estimated savings</th></tr>
+{{range .Recommendations}}<tr><td>{{.Priority}}</td><td>{{.Namespace}}/{{.Deployment}}</td><td>{{.Type}}</td><td>{{.Description}}</td><td>{{money .EstimatedSavings}}/month</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// responseCache reuses computed per-service responses for a short TTL, since the metrics behind
+// them change slowly and recomputing an analysis re-reads every pod's time series
+type responseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedResponse
+	now     func() time.Time
+}
+
+// cachedResponse is a computed response and when it expires
+type cachedResponse struct {
+	value   interface{}
+	expires time.Time
+}
+
+// newResponseCache creates a response cache; a non-positive TTL disables caching
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		entries: make(map[string]cachedResponse),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached value for key, computing and storing it when missing, expired or when
+// refresh is set. Errors are not cached.
+func (c *responseCache) Get(key string, refresh bool, compute func() (interface{}, error)) (interface{}, error) {
+	if c.ttl <= 0 {
+		return compute()
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && !refresh && c.now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	value, err := compute()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cachedResponse{value: value, expires: c.now().Add(c.ttl)}
+	c.evictExpired()
+	c.mu.Unlock()
+
+	return value, nil
+}
+
+// Clear drops every cached response
+func (c *responseCache) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]cachedResponse)
+	c.mu.Unlock()
+}
+
+// evictExpired drops expired entries; callers must hold the lock
+func (c *responseCache) evictExpired() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// responseCacheKey joins the parts identifying a cached response, e.g. analysis/default/web/7d
+func responseCacheKey(parts ...string) string {
+	return strings.Join(parts, "/")
+}
+
+// wantsRefresh reports whether the request asks to bypass the response cache with ?refresh=true
+func wantsRefresh(r *http.Request) bool {
+	refresh, err := strconv.ParseBool(r.URL.Query().Get("refresh"))
+	return err == nil && refresh
+}
+
+// cachedAnalysis returns the service's analysis over the configured window, or over window when non-zero
+func (s *Server) cachedAnalysis(r *http.Request, namespace, service string, window time.Duration) (*models.Analysis, error) {
+	windowKey := "default"
+	if window > 0 {
+		windowKey = window.String()
+	}
+
+	value, err := s.responses.Get(responseCacheKey("analysis", namespace, service, windowKey), wantsRefresh(r), func() (interface{}, error) {
+		if window > 0 {
+			return s.optimizer.AnalyzeDeploymentWithWindow(namespace, service, window)
+		}
+		return s.optimizer.AnalyzeDeployment(namespace, service)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*models.Analysis), nil
+}
+
+// cachedTraffic returns the service's traffic analysis over duration
+func (s *Server) cachedTraffic(r *http.Request, namespace, service string, duration time.Duration) (*models.TrafficAnalysis, error) {
+	value, err := s.responses.Get(responseCacheKey("traffic", namespace, service, duration.String()), wantsRefresh(r), func() (interface{}, error) {
+		return s.analyzer.AnalyzeTrafficPatterns(namespace, service, duration)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*models.TrafficAnalysis), nil
+}
+
+// cachedCost returns the service's cost breakdown
+func (s *Server) cachedCost(r *http.Request, namespace, service string) (*models.CostBreakdown, error) {
+	value, err := s.responses.Get(responseCacheKey("cost", namespace, service), wantsRefresh(r), func() (interface{}, error) {
+		return s.analyzer.CalculateServiceCost(namespace, service)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*models.CostBreakdown), nil
+}
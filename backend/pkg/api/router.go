@@ -9,32 +9,46 @@ func (s *Server) setupRoutes() *mux.Router {
 	r := mux.NewRouter()
 
 	// Apply middleware in order:
-	// 1. Recovery (catch panics)
-	// 2. Logging (log requests)
-	// 3. CORS (handle CORS)
-	// 4. Request ID (add request ID)
+	// 1. Request ID (add request ID, so panics and request logs carry it)
+	// 2. Recovery (catch panics)
+	// 3. Logging (log requests)
+	// 4. CORS (handle CORS)
+	r.Use(requestIDMiddleware)
 	r.Use(recoveryMiddleware)
 	r.Use(loggingMiddleware)
 	if s.config.EnableCORS {
 		r.Use(corsMiddleware)
 	}
-	r.Use(requestIDMiddleware)
 
 	// Health endpoints (no /api prefix)
 	r.HandleFunc("/health", s.handleHealth).Methods("GET")
 	r.HandleFunc("/ready", s.handleReady).Methods("GET")
 
+	// Prometheus scrape endpoint (no /api prefix)
+	r.HandleFunc("/metrics/prometheus", s.handlePrometheusMetrics).Methods("GET")
+
 	// WebSocket endpoint (no /api prefix)
 	r.HandleFunc("/ws/updates", s.handleWebSocket)
 
 	// API v1 routes
 	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(s.rateLimitMiddleware)
 
 	// Status
 	api.HandleFunc("/status", s.handleStatus).Methods("GET")
+	api.HandleFunc("/status/data-coverage", s.handleDataCoverage).Methods("GET")
+
+	// Runtime configuration
+	api.HandleFunc("/config", s.handleGetConfig).Methods("GET")
+	api.HandleFunc("/config", s.handleUpdateConfig).Methods("PUT")
+
+	// Server-Sent Events alternative to the WebSocket endpoint
+	api.HandleFunc("/stream", s.handleStream).Methods("GET")
 
 	// Cluster & Services
 	api.HandleFunc("/cluster/overview", s.handleClusterOverview).Methods("GET")
+	api.HandleFunc("/cluster/node-sizing", s.handleNodeSizing).Methods("GET")
+	api.HandleFunc("/cluster/consolidation", s.handleNodeConsolidation).Methods("GET")
 	api.HandleFunc("/services", s.handleListServices).Methods("GET")
 	api.HandleFunc("/services/{namespace}/{name}", s.handleServiceDetail).Methods("GET")
 
@@ -50,14 +64,38 @@ func (s *Server) setupRoutes() *mux.Router {
 
 	// Optimization
 	api.HandleFunc("/recommendations", s.handleRecommendations).Methods("GET")
+	api.HandleFunc("/recommendations/export", s.handleRecommendationExport).Methods("GET")
+	api.HandleFunc("/recommendations/summary", s.handleRecommendationSummary).Methods("GET")
 	api.HandleFunc("/recommendations/{id}", s.handleRecommendationByID).Methods("GET")
+	api.HandleFunc("/recommendations/{id}", s.handleDismissRecommendation).Methods("DELETE")
 	api.HandleFunc("/recommendations/{id}/apply", s.handleApplyRecommendation).Methods("POST")
+	api.HandleFunc("/recommendations/{id}/revert", s.handleRevertRecommendation).Methods("POST")
+	api.HandleFunc("/recommendations/{id}/ticket", s.handleRecommendationTicket).Methods("GET")
+	api.HandleFunc("/recommendations/{id}/manifest", s.handleRecommendationManifest).Methods("GET")
+	api.HandleFunc("/idle", s.handleIdleWorkloads).Methods("GET")
+
+	// Simulation
+	api.HandleFunc("/simulate", s.handleSimulateRecommendations).Methods("POST")
+	api.HandleFunc("/simulate/hpa/{namespace}/{name}", s.handleSimulateHPA).Methods("POST")
 
 	// Analysis
+	api.HandleFunc("/analysis/batch", s.handleBatchAnalysis).Methods("POST")
 	api.HandleFunc("/analysis/{namespace}/{service}", s.handleAnalysis).Methods("GET")
+	api.HandleFunc("/analysis/{namespace}/{service}/windows", s.handleAnalysisWindows).Methods("GET")
+	api.HandleFunc("/analysis/{namespace}/{deployment}/containers/{container}", s.handleContainerAnalysis).Methods("GET")
+	api.HandleFunc("/analysis/{namespace}/{deployment}/vpa", s.handleVPARecommendation).Methods("GET")
 	api.HandleFunc("/traffic/{namespace}/{service}", s.handleTraffic).Methods("GET")
+	api.HandleFunc("/cost/{namespace}", s.handleNamespaceCost).Methods("GET")
 	api.HandleFunc("/cost/{namespace}/{service}", s.handleCost).Methods("GET")
+	api.HandleFunc("/cost/{namespace}/{service}/trends", s.handleCostTrends).Methods("GET")
 	api.HandleFunc("/anomalies", s.handleAnomalies).Methods("GET")
+	api.HandleFunc("/anomalies/ack", s.handleAcknowledgeAnomaly).Methods("POST")
+
+	// Reports
+	api.HandleFunc("/report", s.handleReport).Methods("GET")
+
+	// Federation
+	api.HandleFunc("/federation/export", s.handleFederationExport).Methods("GET")
 
 	return r
 }
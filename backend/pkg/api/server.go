@@ -3,7 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -21,6 +21,13 @@ type Server struct {
 	k8sClient  *k8s.Client
 	httpServer *http.Server
 	wsHub      *WebSocketHub
+	events     *eventBroker
+	anomalies  *anomalyStore
+	broadcasts *broadcastLog
+	responses  *responseCache
+	limiter    *routeLimiter
+	overview   *overviewCache
+	cluster    *k8s.ClusterCache
 	config     *Config
 	startTime  time.Time
 	ctx        context.Context
@@ -30,10 +37,14 @@ type Server struct {
 // NewServer creates a new API server
 func NewServer(k8sClient *k8s.Client, collector collector.MetricsCollector, optimizer optimizer.Optimizer, analyzer analyzer.Analyzer) *Server {
 	return NewServerWithConfig(k8sClient, collector, optimizer, analyzer, &Config{
-		Port:           "8080",
-		EnableCORS:     true,
-		LogLevel:       "info",
-		UpdateInterval: 5 * time.Second,
+		Port:             "8080",
+		EnableCORS:       true,
+		LogLevel:         "info",
+		UpdateInterval:   5 * time.Second,
+		ResponseCacheTTL: 30 * time.Second,
+		InformerResync:   10 * time.Minute,
+		Namespaces:       []string{"default"},
+		StreamAnomalies:  true,
 	})
 }
 
@@ -41,16 +52,28 @@ func NewServer(k8sClient *k8s.Client, collector collector.MetricsCollector, opti
 func NewServerWithConfig(k8sClient *k8s.Client, collector collector.MetricsCollector, optimizer optimizer.Optimizer, analyzer analyzer.Analyzer, config *Config) *Server {
 	ctx, cancel := context.WithCancel(context.Background())
 
+	var cluster *k8s.ClusterCache
+	if k8sClient != nil && config.InformerResync > 0 {
+		cluster = k8sClient.NewClusterCache(config.InformerResync)
+	}
+
 	return &Server{
-		collector: collector,
-		optimizer: optimizer,
-		analyzer:  analyzer,
-		k8sClient: k8sClient,
-		wsHub:     NewWebSocketHub(),
-		config:    config,
-		startTime: time.Now(),
-		ctx:       ctx,
-		cancel:    cancel,
+		collector:  collector,
+		optimizer:  optimizer,
+		analyzer:   analyzer,
+		k8sClient:  k8sClient,
+		wsHub:      NewWebSocketHub(),
+		events:     newEventBroker(),
+		anomalies:  newAnomalyStore(),
+		broadcasts: newBroadcastLog(),
+		responses:  newResponseCache(config.ResponseCacheTTL),
+		limiter:    newRouteLimiter(config.RateLimit, config.HeavyRateLimit),
+		overview:   newOverviewCache(config.OverviewCacheTTL),
+		cluster:    cluster,
+		config:     config,
+		startTime:  time.Now(),
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 }
 
@@ -58,11 +81,22 @@ func NewServerWithConfig(k8sClient *k8s.Client, collector collector.MetricsColle
 func (s *Server) Start() error {
 	// Start the WebSocket hub
 	go s.wsHub.Run()
-	log.Println("WebSocket hub started")
+	slog.Info("WebSocket hub started")
 
 	// Start the periodic update broadcaster
 	go s.startUpdateBroadcaster()
-	log.Println("Update broadcaster started")
+	slog.Info("Update broadcaster started")
+
+	// Sync the cluster cache in the background; listings fall back to the API server until it has
+	if s.cluster != nil {
+		go func() {
+			if err := s.cluster.Start(s.ctx.Done()); err != nil {
+				slog.Warn("Cluster cache sync failed", "error", err)
+				return
+			}
+			slog.Info("Cluster cache synced")
+		}()
+	}
 
 	// Setup routes
 	router := s.setupRoutes()
@@ -76,7 +110,7 @@ func (s *Server) Start() error {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	log.Printf("Starting API server on port %s", s.config.Port)
+	slog.Info("Starting API server", "port", s.config.Port)
 
 	// Start server (blocking)
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -88,7 +122,7 @@ func (s *Server) Start() error {
 
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
-	log.Println("Shutting down API server...")
+	slog.Info("Shutting down API server")
 
 	// Cancel the context to stop background goroutines
 	s.cancel()
@@ -100,7 +134,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
-	log.Println("API server stopped")
+	slog.Info("API server stopped")
 	return nil
 }
 
@@ -112,12 +146,12 @@ func (s *Server) startUpdateBroadcaster() {
 	for {
 		select {
 		case <-s.ctx.Done():
-			log.Println("Update broadcaster stopped")
+			slog.Info("Update broadcaster stopped")
 			return
 
 		case <-ticker.C:
 			// Only broadcast if there are connected clients
-			if s.wsHub.GetClientCount() == 0 {
+			if s.wsHub.GetClientCount() == 0 && s.events.Count() == 0 {
 				continue
 			}
 
@@ -133,52 +167,67 @@ func (s *Server) startUpdateBroadcaster() {
 	}
 }
 
-// broadcastMetricsUpdate broadcasts metrics updates to all WebSocket clients
+// broadcast sends a message to all WebSocket clients and Server-Sent Events subscribers
+func (s *Server) broadcast(messageType string, data interface{}) {
+	s.wsHub.Broadcast(messageType, data)
+	s.events.Publish(messageType, data)
+}
+
+// broadcastMetricsUpdate broadcasts metrics updates to all WebSocket and stream clients
 func (s *Server) broadcastMetricsUpdate() {
 	// Get node metrics
 	nodeMetrics, err := s.collector.CollectNodeMetrics()
 	if err != nil {
-		log.Printf("Warning: failed to collect node metrics for broadcast: %v", err)
+		slog.Warn("Failed to collect node metrics for broadcast", "error", err)
 		return
 	}
 
 	// Broadcast node metrics
-	s.wsHub.Broadcast("metrics_update", map[string]interface{}{
+	s.broadcast("metrics_update", map[string]interface{}{
 		"type":    "nodes",
 		"metrics": nodeMetrics,
 	})
 }
 
-// broadcastRecommendationsUpdate broadcasts new recommendations to all WebSocket clients
+// broadcastRecommendationsUpdate broadcasts new recommendations to all WebSocket and stream clients
 func (s *Server) broadcastRecommendationsUpdate() {
 	recommendations, err := s.optimizer.GetAllRecommendations()
 	if err != nil {
-		log.Printf("Warning: failed to get recommendations for broadcast: %v", err)
+		slog.Warn("Failed to get recommendations for broadcast", "error", err)
 		return
 	}
 
 	// Only broadcast if there are recommendations
 	if len(recommendations) > 0 {
-		s.wsHub.Broadcast("recommendation_new", map[string]interface{}{
+		s.broadcast("recommendation_new", map[string]interface{}{
 			"count":           len(recommendations),
 			"recommendations": recommendations,
 		})
 	}
 }
 
-// broadcastAnomaliesUpdate broadcasts detected anomalies to all WebSocket clients
+// broadcastAnomaliesUpdate broadcasts anomalies detected in monitored deployments since the last
+// tick to all WebSocket and stream clients. Anomalies already broadcast are not sent again.
 func (s *Server) broadcastAnomaliesUpdate() {
-	// This is a simplified implementation
-	// In a real system, you would track which anomalies have already been broadcast
-	// and only send new ones
+	if !s.config.StreamAnomalies || s.k8sClient == nil || s.analyzer == nil {
+		return
+	}
+
+	detected := s.detectDeploymentAnomalies(s.ctx)
+	unsent := s.broadcasts.Unsent(detected, time.Now().Add(-anomalyStreamWindow))
 
-	// For now, we skip this to avoid spamming clients
-	// Anomalies can be fetched via the REST API endpoint when needed
+	// Only broadcast if there are new anomalies
+	if len(unsent) > 0 {
+		s.broadcast("anomaly_detected", map[string]interface{}{
+			"count":     len(unsent),
+			"anomalies": unsent,
+		})
+	}
 }
 
-// BroadcastStatusUpdate broadcasts a status update to all WebSocket clients
+// BroadcastStatusUpdate broadcasts a status update to all WebSocket and stream clients
 func (s *Server) BroadcastStatusUpdate(status string, message string) {
-	s.wsHub.Broadcast("status_update", map[string]interface{}{
+	s.broadcast("status_update", map[string]interface{}{
 		"status":  status,
 		"message": message,
 	})
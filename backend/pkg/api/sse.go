@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// eventBroker fans broadcasts out to Server-Sent Events subscribers
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan WebSocketMessage]struct{}
+}
+
+// newEventBroker creates an event broker without subscribers
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[chan WebSocketMessage]struct{})}
+}
+
+// Subscribe registers a new subscriber and returns the channel its events arrive on
+func (b *eventBroker) Subscribe() chan WebSocketMessage {
+	ch := make(chan WebSocketMessage, 16)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes a subscriber
+func (b *eventBroker) Unsubscribe(ch chan WebSocketMessage) {
+	b.mu.Lock()
+	delete(b.subscribers, ch)
+	b.mu.Unlock()
+}
+
+// Publish sends a message to every subscriber. Subscribers that are not keeping up miss the message
+// rather than stalling the broadcast.
+func (b *eventBroker) Publish(messageType string, data interface{}) {
+	message := WebSocketMessage{
+		Type:      messageType,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+}
+
+// Count returns the number of subscribers
+func (b *eventBroker) Count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// handleStream streams the WebSocket broadcasts as Server-Sent Events, for clients behind proxies
+// that break WebSocket upgrades. Each event is named after the message type and carries the same
+// JSON message the WebSocket clients receive.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// The stream outlives the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		respondWithError(w, http.StatusInternalServerError, "STREAM_ERROR", fmt.Sprintf("Failed to start stream: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	// Send the headers and an opening comment right away so the client sees the stream open
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		slog.Warn("Streaming is not supported by the response writer", "error", err)
+		return
+	}
+
+	events := s.events.Subscribe()
+	defer s.events.Unsubscribe(events)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-s.ctx.Done():
+			return
+
+		case message := <-events:
+			data, err := json.Marshal(message)
+			if err != nil {
+				slog.Error("Error marshaling stream event", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Type, data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
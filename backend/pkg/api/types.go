@@ -2,8 +2,17 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/analyzer"
+	"github.com/k8s-service-optimizer/backend/pkg/optimizer"
 )
 
 // Config holds the server configuration
@@ -12,12 +21,49 @@ type Config struct {
 	EnableCORS     bool
 	LogLevel       string
 	UpdateInterval time.Duration // For WebSocket updates (e.g., 5s)
+
+	// ResponseCacheTTL is how long per-service analysis, traffic and cost responses are reused
+	// before being recomputed; 0 disables the cache
+	ResponseCacheTTL time.Duration
+
+	// RateLimit caps the request rate of each API route; HeavyRateLimit applies instead to routes
+	// that aggregate across the whole cluster, such as the cluster overview and deployment listing.
+	// Requests over the limit get 429 with Retry-After; a zero rate disables limiting.
+	RateLimit      RateLimit
+	HeavyRateLimit RateLimit
+
+	// OverviewCacheTTL is how long a computed cluster overview is served before being refreshed in
+	// the background; 0 recomputes it on every request
+	OverviewCacheTTL time.Duration
+
+	// InformerResync is the resync period of the informers that cache namespaces, nodes, pods and
+	// deployments for cluster-wide listings; 0 lists from the API server on every request
+	InformerResync time.Duration
+
+	// Namespaces are the monitored namespaces whose deployments are scanned for anomaly broadcasts
+	Namespaces []string
+
+	// StreamAnomalies broadcasts newly detected anomalies as anomaly_detected messages on every
+	// update tick; noisy clusters can disable it
+	StreamAnomalies bool
+
+	// ClusterName identifies this cluster in federation exports
+	ClusterName string
+
+	// AllowConfigUpdates enables PUT /api/v1/config, which changes optimizer thresholds, buffers
+	// and cost rates at runtime; production deployments can leave it off
+	AllowConfigUpdates bool
+
+	// FederationToken is the bearer token a central aggregator must present to pull the
+	// federation export; the export is disabled when empty
+	FederationToken string
 }
 
 // APIResponse is the standard response wrapper for all API endpoints
 type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
+	Total   *int        `json:"total,omitempty"` // total matching items before pagination, for list endpoints
 	Error   *APIError   `json:"error,omitempty"`
 }
 
@@ -27,6 +73,11 @@ type APIError struct {
 	Message string `json:"message"`
 }
 
+// Error returns the error message, so handlers' helpers can return an APIError with its code
+func (e *APIError) Error() string {
+	return e.Message
+}
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
 	Status string `json:"status"`
@@ -40,10 +91,46 @@ type ReadyResponse struct {
 
 // StatusResponse represents the system status
 type StatusResponse struct {
-	Version      string    `json:"version"`
-	Uptime       string    `json:"uptime"`
-	CollectorRunning bool  `json:"collector_running"`
-	Timestamp    time.Time `json:"timestamp"`
+	Version                string    `json:"version"`
+	Uptime                 string    `json:"uptime"`
+	CollectorRunning       bool      `json:"collector_running"`
+	MetricsServerAvailable bool      `json:"metrics_server_available"`
+	Timestamp              time.Time `json:"timestamp"`
+}
+
+// ClusterOverviewResponse is the cluster overview with how many seconds ago it was computed
+type ClusterOverviewResponse struct {
+	models.ClusterOverview
+	CacheAgeSeconds float64
+}
+
+// DataCoverageResponse reports how much metric history has accumulated in the monitored namespaces
+type DataCoverageResponse struct {
+	MinimumDataPoints int                 `json:"minimum_data_points"`
+	Ready             bool                `json:"ready"` // at least one pod has enough history
+	Namespaces        []NamespaceCoverage `json:"namespaces"`
+	Timestamp         time.Time           `json:"timestamp"`
+}
+
+// NamespaceCoverage is the accumulated history of one namespace's pods
+type NamespaceCoverage struct {
+	Namespace              string `json:"namespace"`
+	Pods                   int    `json:"pods"`
+	PodsWithSufficientData int    `json:"pods_with_sufficient_data"`
+	MinDataPoints          int    `json:"min_data_points"`
+	MaxDataPoints          int    `json:"max_data_points"`
+	Ready                  bool   `json:"ready"`
+	// ETA is how long until the best-covered pod reaches the minimum, estimated from its sampling
+	// interval; empty when ready or when fewer than two samples exist
+	ETA   string `json:"eta,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ConfigResponse is the running optimizer and analyzer configuration
+type ConfigResponse struct {
+	Optimizer      optimizer.Config `json:"optimizer"`
+	Analyzer       analyzer.Config  `json:"analyzer"`
+	UpdatesEnabled bool             `json:"updates_enabled"`
 }
 
 // TimeSeriesQueryParams represents query parameters for time series data
@@ -51,19 +138,95 @@ type TimeSeriesQueryParams struct {
 	Resource string        `json:"resource"`
 	Metric   string        `json:"metric"`
 	Duration time.Duration `json:"duration"`
+	Gaps     bool          `json:"gaps"`
 }
 
 // AnomalyQueryParams represents query parameters for anomaly detection
 type AnomalyQueryParams struct {
 	Resource string        `json:"resource"`
 	Duration time.Duration `json:"duration"`
+	Methods  []string      `json:"methods"`
+}
+
+// RecommendationQueryParams represents filtering, sorting and pagination parameters for recommendations
+type RecommendationQueryParams struct {
+	Namespace  string
+	Type       string
+	Priority   string
+	MinSavings float64
+	Sort       string
+	Limit      int // 0 returns every matching recommendation
+	Offset     int
+}
+
+// RecommendationSummaryResponse holds the headline recommendation figures for dashboards. Every
+// field is always present, and ByType has a key per recommendation type even when its count is 0.
+type RecommendationSummaryResponse struct {
+	Total                 int            `json:"total"`
+	HighPriority          int            `json:"high_priority"`
+	MediumPriority        int            `json:"medium_priority"`
+	LowPriority           int            `json:"low_priority"`
+	ByType                map[string]int `json:"by_type"`
+	TotalSavings          float64        `json:"total_savings"`
+	CashSavings           float64        `json:"cash_savings"`
+	ReclaimableSavings    float64        `json:"reclaimable_savings"`
+	TotalPotentialSavings float64        `json:"total_potential_savings"`
+	AffectedDeployments   int            `json:"affected_deployments"`
 }
 
 // ApplyRecommendationResponse represents the response for applying a recommendation
 type ApplyRecommendationResponse struct {
-	Status string `json:"status"`
-	ID     string `json:"id"`
-	Message string `json:"message,omitempty"`
+	Status  string                      `json:"status"`
+	ID      string                      `json:"id"`
+	Message string                      `json:"message,omitempty"`
+	Patch   *models.RecommendationPatch `json:"patch,omitempty"`
+}
+
+// WindowAnalysis is a deployment analysis over one labeled time window
+type WindowAnalysis struct {
+	Window   string           `json:"window"`
+	Analysis *models.Analysis `json:"analysis"`
+}
+
+// WindowComparisonResponse represents analyses of one service over several time windows
+type WindowComparisonResponse struct {
+	Namespace string           `json:"namespace"`
+	Service   string           `json:"service"`
+	Windows   []WindowAnalysis `json:"windows"`
+}
+
+// SimulateRequest lists the recommendations of a what-if cost simulation
+type SimulateRequest struct {
+	RecommendationIDs []string `json:"recommendation_ids"`
+}
+
+// Limits on POST /api/v1/analysis/batch
+const (
+	maxBatchAnalysisItems    = 50 // deployments accepted in one request
+	batchAnalysisConcurrency = 8  // deployments analyzed at once
+
+	// maxBatchAnalysisBytes bounds the request body so oversized batches are refused before they
+	// are decoded; an item's namespace and name are at most 63 and 253 characters
+	maxBatchAnalysisBytes = maxBatchAnalysisItems * 512
+)
+
+// BatchAnalysisItem identifies one deployment in a batch analysis request
+type BatchAnalysisItem struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+}
+
+// BatchAnalysisResult is the analysis of one deployment in a batch, or why it failed
+type BatchAnalysisResult struct {
+	Namespace  string           `json:"namespace"`
+	Deployment string           `json:"deployment"`
+	Analysis   *models.Analysis `json:"analysis,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// BatchAnalysisResponse holds the batch results keyed by namespace/deployment
+type BatchAnalysisResponse struct {
+	Results map[string]BatchAnalysisResult `json:"results"`
 }
 
 // WebSocketMessage represents a WebSocket message
@@ -84,6 +247,16 @@ func respondWithSuccess(w http.ResponseWriter, data interface{}) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
+// respondWithPage sends a success response for one page of a list along with the total number of items
+func respondWithPage(w http.ResponseWriter, data interface{}, total int) {
+	response := APIResponse{
+		Success: true,
+		Data:    data,
+		Total:   &total,
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
 // respondWithError sends an error response
 func respondWithError(w http.ResponseWriter, statusCode int, code string, message string) {
 	response := APIResponse{
@@ -96,6 +269,21 @@ func respondWithError(w http.ResponseWriter, statusCode int, code string, messag
 	respondWithJSON(w, statusCode, response)
 }
 
+// respondWithAnalysisError sends an analysis failure, distinguishing a missing workload (404) and
+// too little collected history (422) from genuine server faults (500)
+func respondWithAnalysisError(w http.ResponseWriter, code string, message string, err error) {
+	switch {
+	case errors.Is(err, optimizer.ErrNotFound):
+		respondWithError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("%s: %v", message, err))
+	case errors.Is(err, optimizer.ErrInsufficientData):
+		respondWithError(w, http.StatusUnprocessableEntity, "INSUFFICIENT_DATA", fmt.Sprintf("%s: %v", message, err))
+	case errors.Is(err, optimizer.ErrNotApplied), errors.Is(err, optimizer.ErrDrifted):
+		respondWithError(w, http.StatusConflict, "CONFLICT", fmt.Sprintf("%s: %v", message, err))
+	default:
+		respondWithError(w, http.StatusInternalServerError, code, fmt.Sprintf("%s: %v", message, err))
+	}
+}
+
 // respondWithJSON sends a JSON response
 func respondWithJSON(w http.ResponseWriter, statusCode int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -124,10 +312,20 @@ func parseTimeSeriesQueryParams(r *http.Request) (*TimeSeriesQueryParams, error)
 		duration = parsedDuration
 	}
 
+	gaps := false
+	if gapsStr := r.URL.Query().Get("gaps"); gapsStr != "" {
+		parsedGaps, err := strconv.ParseBool(gapsStr)
+		if err != nil {
+			return nil, err
+		}
+		gaps = parsedGaps
+	}
+
 	return &TimeSeriesQueryParams{
 		Resource: resource,
 		Metric:   metric,
 		Duration: duration,
+		Gaps:     gaps,
 	}, nil
 }
 
@@ -146,8 +344,149 @@ func parseAnomalyQueryParams(r *http.Request) (*AnomalyQueryParams, error) {
 		duration = parsedDuration
 	}
 
+	// Run the default detection methods unless a comma separated selection is given
+	var methods []string
+	if methodsStr := r.URL.Query().Get("methods"); methodsStr != "" {
+		for _, method := range strings.Split(methodsStr, ",") {
+			method = strings.ToLower(strings.TrimSpace(method))
+			if !slices.Contains(analyzer.AnomalyMethods, method) {
+				return nil, fmt.Errorf("invalid methods %q: must be a comma separated list of %s", methodsStr, strings.Join(analyzer.AnomalyMethods, ", "))
+			}
+			methods = append(methods, method)
+		}
+	}
+
 	return &AnomalyQueryParams{
 		Resource: resource,
 		Duration: duration,
+		Methods:  methods,
 	}, nil
 }
+
+// recommendationTypes, recommendationPriorities and recommendationSorts are the accepted values of
+// the corresponding recommendation query parameters
+var (
+	recommendationTypes      = []string{"resource", "hpa", "scaling", "quota", "event-driven", "idle"}
+	recommendationPriorities = []string{"high", "medium", "low"}
+	recommendationSorts      = []string{"priority", "savings", "created_at", "impact"}
+)
+
+// parseRecommendationQueryParams extracts recommendation query parameters from the request
+func parseRecommendationQueryParams(r *http.Request) (*RecommendationQueryParams, error) {
+	query := r.URL.Query()
+	params := &RecommendationQueryParams{
+		Namespace: query.Get("namespace"),
+		Type:      query.Get("type"),
+		Priority:  query.Get("priority"),
+		Sort:      query.Get("sort"),
+	}
+
+	if params.Type != "" && !slices.Contains(recommendationTypes, params.Type) {
+		return nil, fmt.Errorf("unsupported type %q", params.Type)
+	}
+	if params.Priority != "" && !slices.Contains(recommendationPriorities, params.Priority) {
+		return nil, fmt.Errorf("unsupported priority %q", params.Priority)
+	}
+	if params.Sort != "" && !slices.Contains(recommendationSorts, params.Sort) {
+		return nil, fmt.Errorf("unsupported sort %q", params.Sort)
+	}
+
+	if minSavingsStr := query.Get("min_savings"); minSavingsStr != "" {
+		minSavings, err := strconv.ParseFloat(minSavingsStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min_savings %q", minSavingsStr)
+		}
+		params.MinSavings = minSavings
+	}
+
+	for name, target := range map[string]*int{"limit": &params.Limit, "offset": &params.Offset} {
+		valueStr := query.Get(name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", name)
+		}
+		*target = value
+	}
+
+	return params, nil
+}
+
+// defaultAnalysisWindows are compared when no windows query parameter is given
+var defaultAnalysisWindows = []string{"1h", "24h", "7d"}
+
+// parseWindowDuration parses a Go duration, additionally accepting a whole number of days such as "7d"
+func parseWindowDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// parseAnalysisWindows extracts the comma-separated analysis windows from the request
+func parseAnalysisWindows(r *http.Request) ([]string, []time.Duration, error) {
+	labels := append([]string(nil), defaultAnalysisWindows...)
+	if windowsStr := r.URL.Query().Get("windows"); windowsStr != "" {
+		labels = strings.Split(windowsStr, ",")
+	}
+
+	windows := make([]time.Duration, len(labels))
+	for i, label := range labels {
+		labels[i] = strings.TrimSpace(label)
+		window, err := parseWindowDuration(labels[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if window <= 0 {
+			return nil, nil, fmt.Errorf("window %q must be positive", labels[i])
+		}
+		windows[i] = window
+	}
+
+	return labels, windows, nil
+}
+
+// parseAnalysisWindow extracts the optional analysis window from the request; 0 means the
+// optimizer's configured window
+func parseAnalysisWindow(r *http.Request) (time.Duration, error) {
+	windowStr := r.URL.Query().Get("window")
+	if windowStr == "" {
+		return 0, nil
+	}
+
+	window, err := parseWindowDuration(windowStr)
+	if err != nil {
+		return 0, err
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("window %q must be positive", windowStr)
+	}
+	return window, nil
+}
+
+// parseBatchAnalysisRequest decodes and validates the deployments of a batch analysis request.
+// Bodies over maxBatchAnalysisBytes fail with an *http.MaxBytesError.
+func parseBatchAnalysisRequest(w http.ResponseWriter, r *http.Request) ([]BatchAnalysisItem, error) {
+	var items []BatchAnalysisItem
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchAnalysisBytes)).Decode(&items); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("at least one deployment is required")
+	}
+	if len(items) > maxBatchAnalysisItems {
+		return nil, fmt.Errorf("at most %d deployments can be analyzed per request, got %d", maxBatchAnalysisItems, len(items))
+	}
+	for i, item := range items {
+		if item.Namespace == "" || item.Deployment == "" {
+			return nil, fmt.Errorf("item %d: namespace and deployment are required", i)
+		}
+	}
+	return items, nil
+}
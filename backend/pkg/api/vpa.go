@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// VPAResponse carries a deployment's recommendation in the shape of the Vertical Pod Autoscaler's
+// status.recommendation, so it can be compared field by field with a VPA in recommendation mode
+type VPAResponse struct {
+	Namespace      string                  `json:"namespace"`
+	Deployment     string                  `json:"deployment"`
+	Recommendation RecommendedPodResources `json:"recommendation"`
+
+	// FieldMapping explains how each VPA field is computed from the collected usage
+	FieldMapping map[string]string `json:"fieldMapping"`
+
+	// ProvisionalContainers lists containers whose bounds rest on less history than normally required
+	ProvisionalContainers []string `json:"provisionalContainers,omitempty"`
+}
+
+// RecommendedPodResources mirrors the VPA RecommendedPodResources type
+type RecommendedPodResources struct {
+	ContainerRecommendations []RecommendedContainerResources `json:"containerRecommendations"`
+}
+
+// RecommendedContainerResources mirrors the VPA RecommendedContainerResources type
+type RecommendedContainerResources struct {
+	ContainerName  string              `json:"containerName"`
+	Target         corev1.ResourceList `json:"target"`
+	LowerBound     corev1.ResourceList `json:"lowerBound"`
+	UpperBound     corev1.ResourceList `json:"upperBound"`
+	UncappedTarget corev1.ResourceList `json:"uncappedTarget"`
+}
+
+// handleVPARecommendation handles getting per-container VPA-style recommendations for a deployment
+func (s *Server) handleVPARecommendation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	deployment := vars["deployment"]
+
+	recommendation, err := s.optimizer.RecommendVPA(namespace, deployment)
+	if err != nil {
+		respondWithAnalysisError(w, "ANALYSIS_ERROR", "Failed to compute VPA recommendation", err)
+		return
+	}
+
+	respondWithSuccess(w, toVPAResponse(recommendation))
+}
+
+// toVPAResponse converts a VPA recommendation to the VPA JSON structure
+func toVPAResponse(recommendation *models.VPARecommendation) VPAResponse {
+	response := VPAResponse{
+		Namespace:  recommendation.Namespace,
+		Deployment: recommendation.Deployment,
+		Recommendation: RecommendedPodResources{
+			ContainerRecommendations: make([]RecommendedContainerResources, 0, len(recommendation.Containers)),
+		},
+		FieldMapping: map[string]string{
+			"lowerBound":     "P50 usage",
+			"target":         fmt.Sprintf("P95 usage x %.2f buffer, rounded up", recommendation.Buffer),
+			"upperBound":     fmt.Sprintf("P99 usage x %.2f buffer, rounded up", recommendation.Buffer),
+			"uncappedTarget": "same as target; no container resource policy caps are applied",
+		},
+	}
+
+	for _, container := range recommendation.Containers {
+		target := vpaResourceList(container.Target)
+		response.Recommendation.ContainerRecommendations = append(response.Recommendation.ContainerRecommendations,
+			RecommendedContainerResources{
+				ContainerName:  container.Container,
+				Target:         target,
+				LowerBound:     vpaResourceList(container.LowerBound),
+				UpperBound:     vpaResourceList(container.UpperBound),
+				UncappedTarget: target,
+			})
+		if container.Provisional {
+			response.ProvisionalContainers = append(response.ProvisionalContainers, container.Container)
+		}
+	}
+	return response
+}
+
+// vpaResourceList converts CPU and memory amounts to a resource list, which serializes as VPA
+// quantities such as "250m" and "256Mi"
+func vpaResourceList(resources models.VPAResources) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(resources.CPU, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(resources.Memory, resource.BinarySI),
+	}
+}
@@ -2,7 +2,7 @@ package api
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -18,12 +18,35 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// WebSocket topics clients can subscribe to
+const (
+	TopicMetrics         = "metrics"
+	TopicRecommendations = "recommendations"
+	TopicAnomalies       = "anomalies"
+	TopicStatus          = "status"
+)
+
+// messageTopics maps each broadcast message type to its topic
+var messageTopics = map[string]string{
+	"metrics_update":     TopicMetrics,
+	"recommendation_new": TopicRecommendations,
+	"anomaly_detected":   TopicAnomalies,
+	"status_update":      TopicStatus,
+}
+
+// Subscription actions clients send, e.g. {"action":"subscribe","topics":["recommendations"]}
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+)
+
 // WebSocketHub manages WebSocket connections
 type WebSocketHub struct {
-	clients    map[*Client]bool
-	broadcast  chan []byte
-	register   chan *Client
-	unregister chan *Client
+	clients       map[*Client]bool
+	broadcast     chan hubMessage
+	register      chan *Client
+	unregister    chan *Client
+	subscriptions chan subscriptionChange
 }
 
 // Client represents a WebSocket client connection
@@ -31,15 +54,38 @@ type Client struct {
 	hub  *WebSocketHub
 	conn *websocket.Conn
 	send chan []byte
+
+	// topics the client receives; nil until it first subscribes or unsubscribes, meaning every
+	// topic. Only the hub's Run goroutine touches it.
+	topics map[string]bool
+}
+
+// hubMessage is an encoded message and the topic it is delivered on
+type hubMessage struct {
+	topic string
+	data  []byte
+}
+
+// subscriptionRequest is a subscribe or unsubscribe message sent by a client
+type subscriptionRequest struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics"`
+}
+
+// subscriptionChange is a client's subscription request on its way to the hub
+type subscriptionChange struct {
+	client  *Client
+	request subscriptionRequest
 }
 
 // NewWebSocketHub creates a new WebSocket hub
 func NewWebSocketHub() *WebSocketHub {
 	return &WebSocketHub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:       make(map[*Client]bool),
+		broadcast:     make(chan hubMessage, 256),
+		register:      make(chan *Client),
+		unregister:    make(chan *Client),
+		subscriptions: make(chan subscriptionChange),
 	}
 }
 
@@ -49,20 +95,28 @@ func (h *WebSocketHub) Run() {
 		select {
 		case client := <-h.register:
 			h.clients[client] = true
-			log.Printf("WebSocket client connected, total clients: %d", len(h.clients))
+			slog.Debug("WebSocket client connected", "clients", len(h.clients))
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
-				log.Printf("WebSocket client disconnected, total clients: %d", len(h.clients))
+				slog.Debug("WebSocket client disconnected", "clients", len(h.clients))
+			}
+
+		case change := <-h.subscriptions:
+			if _, ok := h.clients[change.client]; ok {
+				change.client.applySubscription(change.request)
 			}
 
 		case message := <-h.broadcast:
-			// Broadcast message to all connected clients
+			// Broadcast message to the connected clients subscribed to its topic
 			for client := range h.clients {
+				if !client.subscribedTo(message.topic) {
+					continue
+				}
 				select {
-				case client.send <- message:
+				case client.send <- message.data:
 				default:
 					// Client's send channel is full, close the connection
 					close(client.send)
@@ -83,11 +137,53 @@ func (h *WebSocketHub) Broadcast(messageType string, data interface{}) {
 
 	jsonData, err := json.Marshal(message)
 	if err != nil {
-		log.Printf("Error marshaling WebSocket message: %v", err)
+		slog.Error("Error marshaling WebSocket message", "error", err)
+		return
+	}
+
+	h.broadcast <- hubMessage{topic: messageTopics[messageType], data: jsonData}
+}
+
+// subscribedTo reports whether the client receives messages on topic. Clients that never sent a
+// subscription receive everything, as do messages without a topic.
+func (c *Client) subscribedTo(topic string) bool {
+	return c.topics == nil || topic == "" || c.topics[topic]
+}
+
+// applySubscription updates the client's topics. The first subscribe narrows the client from every
+// topic to the requested ones; the first unsubscribe removes topics from the full set.
+func (c *Client) applySubscription(request subscriptionRequest) {
+	if c.topics == nil {
+		c.topics = make(map[string]bool)
+		if request.Action == ActionUnsubscribe {
+			for _, topic := range messageTopics {
+				c.topics[topic] = true
+			}
+		}
+	}
+
+	for _, topic := range request.Topics {
+		if request.Action == ActionSubscribe {
+			c.topics[topic] = true
+		} else {
+			delete(c.topics, topic)
+		}
+	}
+}
+
+// handleMessage forwards a valid subscription request from the client to the hub
+func (c *Client) handleMessage(message []byte) {
+	var request subscriptionRequest
+	if err := json.Unmarshal(message, &request); err != nil {
+		slog.Debug("Ignoring malformed WebSocket message", "error", err)
+		return
+	}
+	if request.Action != ActionSubscribe && request.Action != ActionUnsubscribe {
+		slog.Debug("Ignoring WebSocket message with unknown action", "action", request.Action)
 		return
 	}
 
-	h.broadcast <- jsonData
+	c.hub.subscriptions <- subscriptionChange{client: c, request: request}
 }
 
 // GetClientCount returns the number of connected clients
@@ -109,14 +205,15 @@ func (c *Client) readPump() {
 	})
 
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
+				slog.Warn("WebSocket error", "error", err)
 			}
 			break
 		}
-		// We don't process incoming messages from clients (one-way communication)
+		// Clients only send subscription requests
+		c.handleMessage(message)
 	}
 }
 
@@ -168,7 +265,7 @@ func (c *Client) writePump() {
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		slog.Warn("WebSocket upgrade error", "error", err)
 		return
 	}
 
@@ -0,0 +1,233 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// ArchiveSchemaVersion is the version of the archive format written by ExportSnapshot
+const ArchiveSchemaVersion = 1
+
+// MetricsArchive is a self-describing snapshot of stored series for long-term archival
+type MetricsArchive struct {
+	SchemaVersion int              `json:"schema_version"`
+	Namespace     string           `json:"namespace,omitempty"` // empty for a snapshot of the whole store
+	Start         time.Time        `json:"start"`               // earliest point in the archive
+	End           time.Time        `json:"end"`                 // latest point in the archive
+	CreatedAt     time.Time        `json:"created_at"`
+	Series        []ArchivedSeries `json:"series"`
+}
+
+// ArchivedSeries is one series in a metrics archive
+type ArchivedSeries struct {
+	Resource string             `json:"resource"`
+	Metric   string             `json:"metric"`
+	Points   []models.DataPoint `json:"points"`
+}
+
+// ArchiveSink stores encoded metrics archives under a name
+type ArchiveSink interface {
+	WriteArchive(name string, data []byte) error
+}
+
+// DirSink writes archives as files in a local directory
+type DirSink struct {
+	Dir string
+}
+
+// NewDirSink creates a sink writing archives to dir
+func NewDirSink(dir string) *DirSink {
+	return &DirSink{Dir: dir}
+}
+
+// WriteArchive writes the archive to a file named name in the directory, replacing it atomically
+func (d *DirSink) WriteArchive(name string, data []byte) error {
+	if err := os.MkdirAll(d.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(d.Dir, name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(d.Dir, name))
+}
+
+// EncodeArchive writes an archive as gzip-compressed JSON
+func EncodeArchive(w io.Writer, archive *MetricsArchive) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		gz.Close()
+		return fmt.Errorf("failed to encode archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// DecodeArchive reads a gzip-compressed JSON archive, rejecting schema versions it doesn't know
+func DecodeArchive(r io.Reader) (*MetricsArchive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archive: %w", err)
+	}
+	defer gz.Close()
+
+	var archive MetricsArchive
+	if err := json.NewDecoder(gz).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("failed to decode archive: %w", err)
+	}
+	if archive.SchemaVersion != ArchiveSchemaVersion {
+		return nil, fmt.Errorf("unsupported archive schema version %d", archive.SchemaVersion)
+	}
+
+	return &archive, nil
+}
+
+// archive copies the series accepted by include into a metrics archive
+func (s *metricsStore) archive(include func(metricKey) bool) *MetricsArchive {
+	archive := &MetricsArchive{
+		SchemaVersion: ArchiveSchemaVersion,
+		CreatedAt:     time.Now(),
+		Series:        []ArchivedSeries{},
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for key, points := range s.data {
+		if len(points) == 0 || !include(key) {
+			continue
+		}
+		archive.Series = append(archive.Series, ArchivedSeries{
+			Resource: key.Resource,
+			Metric:   key.Metric,
+			Points:   append([]models.DataPoint(nil), points...),
+		})
+
+		for _, point := range points {
+			if archive.Start.IsZero() || point.Timestamp.Before(archive.Start) {
+				archive.Start = point.Timestamp
+			}
+			if point.Timestamp.After(archive.End) {
+				archive.End = point.Timestamp
+			}
+		}
+	}
+
+	return archive
+}
+
+// podName returns the pod a store resource such as "pod/web-1" or "pod/web-1/container/app"
+// belongs to, or "" for node and HPA resources
+func podName(resource string) string {
+	name, ok := strings.CutPrefix(resource, "pod/")
+	if !ok {
+		return ""
+	}
+	name, _, _ = strings.Cut(name, "/")
+	return name
+}
+
+// recordPodNamespace remembers which namespace a pod belongs to, so snapshots can be split per namespace
+func (c *Collector) recordPodNamespace(pod, namespace string) {
+	c.podNamespacesMu.Lock()
+	defer c.podNamespacesMu.Unlock()
+	c.podNamespaces[pod] = namespace
+}
+
+// ExportSnapshot writes the pod series of a namespace, or the whole store when namespace is empty,
+// to the sink as a compressed archive. It returns the archive's name.
+func (c *Collector) ExportSnapshot(sink ArchiveSink, namespace string) (string, error) {
+	include := func(metricKey) bool { return true }
+	if namespace != "" {
+		c.podNamespacesMu.RLock()
+		defer c.podNamespacesMu.RUnlock()
+		include = func(key metricKey) bool {
+			pod := podName(key.Resource)
+			return pod != "" && c.podNamespaces[pod] == namespace
+		}
+	}
+
+	archive := c.store.archive(include)
+	archive.Namespace = namespace
+
+	var buf bytes.Buffer
+	if err := EncodeArchive(&buf, archive); err != nil {
+		return "", err
+	}
+
+	scope := namespace
+	if scope == "" {
+		scope = "cluster"
+	}
+	name := fmt.Sprintf("%s-%s.json.gz", scope, archive.CreatedAt.UTC().Format("20060102T150405Z"))
+	if err := sink.WriteArchive(name, buf.Bytes()); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// ImportSnapshot adds the series of an archive to the store, dropping points older than the
+// retention period of their metric. It returns the number of points loaded.
+func (c *Collector) ImportSnapshot(archive *MetricsArchive) int {
+	entries := make([]metricsEntry, len(archive.Series))
+	for i, series := range archive.Series {
+		entries[i] = metricsEntry{
+			Key:    metricKey{Resource: series.Resource, Metric: series.Metric},
+			Points: series.Points,
+		}
+	}
+	return c.store.loadEntries(entries)
+}
+
+// snapshotLoop runs the periodic export of archives to the snapshot directory
+func (c *Collector) snapshotLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.config.SnapshotInterval)
+	defer ticker.Stop()
+
+	sink := NewDirSink(c.config.SnapshotDir)
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.exportSnapshots(sink)
+		}
+	}
+}
+
+// exportSnapshots exports one archive per monitored namespace, or one of the whole store
+func (c *Collector) exportSnapshots(sink ArchiveSink) {
+	scopes := []string{""}
+	if c.config.SnapshotPerNamespace {
+		scopes = c.namespaces
+	}
+
+	for _, namespace := range scopes {
+		if _, err := c.ExportSnapshot(sink, namespace); err != nil {
+			slog.Error("Error exporting metrics snapshot", "namespace", namespace, "error", err)
+		}
+	}
+}
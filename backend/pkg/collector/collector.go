@@ -2,9 +2,11 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/k8s-service-optimizer/backend/internal/k8s"
@@ -16,6 +18,7 @@ type Collector struct {
 	client     *k8s.Client
 	k8s        *k8sCollector
 	store      *metricsStore
+	cache      *queryCache
 	config     Config
 	ctx        context.Context
 	cancel     context.CancelFunc
@@ -23,6 +26,16 @@ type Collector struct {
 	running    bool
 	runningMu  sync.RWMutex
 	namespaces []string // Namespaces to monitor
+
+	// Namespace of each pod seen, used to split snapshots per namespace
+	podNamespaces   map[string]string
+	podNamespacesMu sync.RWMutex
+
+	// CFS counters of each namespace/pod at the previous collection pass, for throttling ratios
+	cfsCounters map[string]cfsSample
+
+	// Set while the last collection pass found the metrics API missing or down
+	metricsAPIUnavailable atomic.Bool
 }
 
 // New creates a new metrics collector with default configuration
@@ -33,15 +46,19 @@ func New(client *k8s.Client) *Collector {
 // NewWithConfig creates a new metrics collector with custom configuration
 func NewWithConfig(client *k8s.Client, config Config) *Collector {
 	ctx, cancel := context.WithCancel(context.Background())
+	tiers := rollupTiers(config.RawRetention, config.MinuteRetention)
 
 	return &Collector{
 		client:     client,
 		k8s:        newK8sCollector(client),
-		store:      newMetricsStore(config.RetentionPeriod),
+		store:      newPersistentMetricsStore(config.RetentionPeriod, config.MetricRetention, config.MaxSeries, tiers, config.PersistencePath),
+		cache:      newQueryCache(config.QueryCacheTTL),
 		config:     config,
 		ctx:        ctx,
 		cancel:     cancel,
 		namespaces: []string{"default"}, // Default namespace, can be extended
+
+		podNamespaces: make(map[string]string),
 	}
 }
 
@@ -68,8 +85,20 @@ func (c *Collector) Start() error {
 	c.wg.Add(1)
 	go c.cleanupLoop()
 
-	log.Printf("Metrics collector started (interval: %v, retention: %v)",
-		c.config.CollectionInterval, c.config.RetentionPeriod)
+	// Start snapshot goroutine
+	if c.config.PersistencePath != "" && c.config.PersistInterval > 0 {
+		c.wg.Add(1)
+		go c.persistLoop()
+	}
+
+	// Start archive export goroutine
+	if c.config.SnapshotDir != "" && c.config.SnapshotInterval > 0 {
+		c.wg.Add(1)
+		go c.snapshotLoop()
+	}
+
+	slog.Info("Metrics collector started",
+		"interval", c.config.CollectionInterval, "retention", c.config.RetentionPeriod)
 
 	return nil
 }
@@ -86,8 +115,13 @@ func (c *Collector) Stop() {
 
 	c.cancel()
 	c.wg.Wait()
+	c.cache.clear()
+
+	if c.config.PersistencePath != "" {
+		c.persist()
+	}
 
-	log.Println("Metrics collector stopped")
+	slog.Info("Metrics collector stopped")
 }
 
 // IsRunning returns whether the collector is currently running
@@ -97,26 +131,59 @@ func (c *Collector) IsRunning() bool {
 	return c.running
 }
 
-// collectionLoop runs the periodic metrics collection
+// collectionLoop runs the periodic metrics collection, backing off while the metrics API is unavailable
 func (c *Collector) collectionLoop() {
 	defer c.wg.Done()
 
-	ticker := time.NewTicker(c.config.CollectionInterval)
-	defer ticker.Stop()
-
 	// Collect immediately on start
 	c.collectAllMetrics()
 
+	delay := c.config.CollectionInterval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			c.collectAllMetrics()
+			delay = c.nextCollectionDelay(delay)
+			timer.Reset(delay)
 		}
 	}
 }
 
+// nextCollectionDelay returns the wait before the next collection pass: CollectionInterval while the
+// metrics API answers, otherwise twice the previous wait up to MaxCollectionBackoff
+func (c *Collector) nextCollectionDelay(previous time.Duration) time.Duration {
+	interval := c.config.CollectionInterval
+	if !c.metricsAPIUnavailable.Load() || c.config.MaxCollectionBackoff <= interval {
+		return interval
+	}
+	return min(max(previous*2, interval), c.config.MaxCollectionBackoff)
+}
+
+// MetricsAPIAvailable reports whether the last collection pass reached the metrics API
+func (c *Collector) MetricsAPIAvailable() bool {
+	return !c.metricsAPIUnavailable.Load()
+}
+
+// setMetricsAPIAvailable records whether the metrics API answered, logging only when that changes
+// so a cluster without metrics-server does not flood the log with the same error every pass
+func (c *Collector) setMetricsAPIAvailable(available bool, err error) {
+	wasUnavailable := c.metricsAPIUnavailable.Swap(!available)
+	switch {
+	case !available && !wasUnavailable:
+		slog.Warn("Metrics API unavailable; is metrics-server installed and its APIService (v1beta1.metrics.k8s.io) Available? Backing off collection",
+			"error", err, "max_interval", c.config.MaxCollectionBackoff)
+	case !available:
+		slog.Debug("Metrics API still unavailable", "error", err)
+	case wasUnavailable:
+		slog.Info("Metrics API available again; resuming collection", "interval", c.config.CollectionInterval)
+	}
+}
+
 // cleanupLoop runs the periodic cleanup of old data
 func (c *Collector) cleanupLoop() {
 	defer c.wg.Done()
@@ -129,91 +196,193 @@ func (c *Collector) cleanupLoop() {
 		case <-c.ctx.Done():
 			return
 		case <-ticker.C:
+			if rolled := c.store.Rollup(); rolled > 0 {
+				slog.Debug("Rolled up raw data points into aggregates", "points", rolled)
+			}
 			removed := c.store.Cleanup()
 			if removed > 0 {
-				log.Printf("Cleaned up %d old data points, current store size: %d", removed, c.store.Size())
+				slog.Debug("Cleaned up old data points", "removed", removed, "store_size", c.store.Size())
 			}
 		}
 	}
 }
 
+// persistLoop runs the periodic snapshot of the store to disk
+func (c *Collector) persistLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.config.PersistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.persist()
+		}
+	}
+}
+
+// persist snapshots the store to the configured path
+func (c *Collector) persist() {
+	if err := c.store.Snapshot(c.config.PersistencePath); err != nil {
+		slog.Error("Error persisting metrics snapshot", "error", err)
+	}
+}
+
 // collectAllMetrics collects all metrics from all monitored namespaces
 func (c *Collector) collectAllMetrics() {
 	timestamp := time.Now()
 
-	// Collect node metrics (cluster-wide)
+	// Points of the whole pass are stored together, taking the store's write lock once
+	var batch metricsBatch
+
+	// Collect node metrics (cluster-wide). Without the metrics API there are no pod metrics either,
+	// so those are skipped until it returns.
 	nodeMetrics, err := c.CollectNodeMetrics()
-	if err != nil {
-		log.Printf("Error collecting node metrics: %v", err)
-	} else {
-		c.storeNodeMetrics(nodeMetrics, timestamp)
+	metricsAPIAvailable := !errors.Is(err, ErrMetricsAPIUnavailable)
+	c.setMetricsAPIAvailable(metricsAPIAvailable, err)
+	if err == nil {
+		c.storeNodeMetrics(&batch, nodeMetrics, timestamp)
+	} else if metricsAPIAvailable {
+		slog.Error("Error collecting node metrics", "error", err)
 	}
 
 	// Collect pod and HPA metrics for each namespace
 	for _, namespace := range c.namespaces {
 		// Collect pod metrics
-		podMetrics, err := c.CollectPodMetrics(namespace)
-		if err != nil {
-			log.Printf("Error collecting pod metrics for namespace %s: %v", namespace, err)
-		} else {
-			c.storePodMetrics(podMetrics, timestamp)
+		if metricsAPIAvailable {
+			podMetrics, err := c.CollectPodMetrics(namespace)
+			if err != nil {
+				slog.Error("Error collecting pod metrics", "namespace", namespace, "error", err)
+			} else {
+				c.storePodMetrics(&batch, podMetrics, timestamp)
+			}
 		}
 
 		// Collect HPA metrics
 		hpaMetrics, err := c.CollectHPAMetrics(namespace)
 		if err != nil {
-			log.Printf("Error collecting HPA metrics for namespace %s: %v", namespace, err)
+			slog.Error("Error collecting HPA metrics", "namespace", namespace, "error", err)
+		} else {
+			c.storeHPAMetrics(&batch, hpaMetrics, timestamp)
+		}
+	}
+	// Collect working-set memory from kubelets
+	if c.config.CollectWorkingSet {
+		samples, err := c.k8s.CollectWorkingSetMemory()
+		if err != nil {
+			slog.Warn("Error collecting working-set memory", "error", err)
+		} else {
+			c.storeWorkingSetMemory(&batch, samples)
+		}
+	}
+	// Collect CPU throttling from kubelets
+	if c.config.CollectCPUThrottling {
+		samples, err := c.k8s.CollectCFSCounters()
+		if err != nil {
+			slog.Warn("Error collecting CPU throttling", "error", err)
 		} else {
-			c.storeHPAMetrics(hpaMetrics, timestamp)
+			c.storeCPUThrottling(&batch, samples, timestamp)
 		}
 	}
+	c.store.StoreBatch(batch)
+
+	// Cached reads predate this collection pass
+	c.cache.clear()
 }
 
-// storePodMetrics stores pod metrics in the time-series store
-func (c *Collector) storePodMetrics(metrics []models.PodMetrics, timestamp time.Time) {
+// storePodMetrics adds pod metrics to a batch for the time-series store
+func (c *Collector) storePodMetrics(batch *metricsBatch, metrics []models.PodMetrics, timestamp time.Time) {
 	for _, metric := range metrics {
 		resource := fmt.Sprintf("pod/%s", metric.Name)
+		c.recordPodNamespace(metric.Name, metric.Namespace)
 
 		// Store CPU metric (convert to float64)
-		c.store.Store(resource, "cpu", float64(metric.CPU), metric.Timestamp)
+		batch.add(resource, "cpu", float64(metric.CPU), metric.Timestamp)
 
 		// Store Memory metric (convert to float64)
-		c.store.Store(resource, "memory", float64(metric.Memory), metric.Timestamp)
+		batch.add(resource, "memory", float64(metric.Memory), metric.Timestamp)
+
+		// Store per-container metrics so sidecars can be analyzed separately
+		for _, container := range metric.Containers {
+			containerResource := ContainerResource(metric.Name, container.Name)
+			batch.add(containerResource, "cpu", float64(container.CPU), metric.Timestamp)
+			batch.add(containerResource, "memory", float64(container.Memory), metric.Timestamp)
+		}
 	}
 }
 
-// storeNodeMetrics stores node metrics in the time-series store
-func (c *Collector) storeNodeMetrics(metrics []models.NodeMetrics, timestamp time.Time) {
+// storeWorkingSetMemory adds working-set memory and transmitted-bytes samples for pods in monitored
+// namespaces to a batch
+func (c *Collector) storeWorkingSetMemory(batch *metricsBatch, samples []workingSetSample) {
+	monitored := make(map[string]bool, len(c.namespaces))
+	for _, namespace := range c.namespaces {
+		monitored[namespace] = true
+	}
+
+	for _, sample := range samples {
+		if !monitored[sample.Namespace] {
+			continue
+		}
+		resource := fmt.Sprintf("pod/%s", sample.Pod)
+		if sample.WorkingSetBytes != nil {
+			batch.add(resource, "memory_working_set", float64(*sample.WorkingSetBytes), sample.Timestamp)
+		}
+		if sample.TxBytes != nil {
+			batch.add(resource, "network_tx_bytes", float64(*sample.TxBytes), sample.TxTimestamp)
+		}
+	}
+}
+
+// storeNodeMetrics adds node metrics to a batch for the time-series store
+func (c *Collector) storeNodeMetrics(batch *metricsBatch, metrics []models.NodeMetrics, timestamp time.Time) {
 	for _, metric := range metrics {
 		resource := fmt.Sprintf("node/%s", metric.Name)
 
 		// Store CPU metric (convert to float64)
-		c.store.Store(resource, "cpu", float64(metric.CPU), metric.Timestamp)
+		batch.add(resource, "cpu", float64(metric.CPU), metric.Timestamp)
 
 		// Store Memory metric (convert to float64)
-		c.store.Store(resource, "memory", float64(metric.Memory), metric.Timestamp)
+		batch.add(resource, "memory", float64(metric.Memory), metric.Timestamp)
 	}
 }
 
-// storeHPAMetrics stores HPA metrics in the time-series store
-func (c *Collector) storeHPAMetrics(metrics []models.HPAMetrics, timestamp time.Time) {
+// storeHPAMetrics adds HPA metrics to a batch for the time-series store
+func (c *Collector) storeHPAMetrics(batch *metricsBatch, metrics []models.HPAMetrics, timestamp time.Time) {
 	for _, metric := range metrics {
 		resource := fmt.Sprintf("hpa/%s", metric.Name)
 
 		// Store current replicas
-		c.store.Store(resource, "current_replicas", float64(metric.CurrentReplicas), metric.Timestamp)
+		batch.add(resource, "current_replicas", float64(metric.CurrentReplicas), metric.Timestamp)
 
 		// Store desired replicas
-		c.store.Store(resource, "desired_replicas", float64(metric.DesiredReplicas), metric.Timestamp)
+		batch.add(resource, "desired_replicas", float64(metric.DesiredReplicas), metric.Timestamp)
 
 		// Store target CPU
-		c.store.Store(resource, "target_cpu", float64(metric.TargetCPU), metric.Timestamp)
+		batch.add(resource, "target_cpu", float64(metric.TargetCPU), metric.Timestamp)
 
 		// Store current CPU
-		c.store.Store(resource, "current_cpu", float64(metric.CurrentCPU), metric.Timestamp)
+		batch.add(resource, "current_cpu", float64(metric.CurrentCPU), metric.Timestamp)
+
+		// Store memory target and current value for memory-scaled HPAs
+		if metric.TargetMemory > 0 {
+			batch.add(resource, "target_memory", float64(metric.TargetMemory), metric.Timestamp)
+			batch.add(resource, "current_memory", float64(metric.CurrentMemory), metric.Timestamp)
+		}
 	}
 }
 
+// RecordTraffic stores a measured request rate (requests per second) and request latency
+// (milliseconds) sample for a resource such as "pod/web-1", fed from an ingress, service mesh
+// or application exporter. The metrics API does not report traffic, so these series only exist
+// when recorded here.
+func (c *Collector) RecordTraffic(resource string, requestsPerSecond, latencyMs float64, timestamp time.Time) {
+	c.store.Store(resource, MetricRequests, requestsPerSecond, timestamp)
+	c.store.Store(resource, MetricLatencyMs, latencyMs, timestamp)
+}
+
 // CollectPodMetrics collects current pod metrics for a namespace
 func (c *Collector) CollectPodMetrics(namespace string) ([]models.PodMetrics, error) {
 	return c.k8s.CollectPodMetrics(namespace)
@@ -229,9 +398,27 @@ func (c *Collector) CollectHPAMetrics(namespace string) ([]models.HPAMetrics, er
 	return c.k8s.CollectHPAMetrics(namespace)
 }
 
-// GetTimeSeriesData retrieves time-series data for a resource/metric
+// GetTimeSeriesData retrieves time-series data for a resource/metric, reusing a read made within
+// QueryCacheTTL since the last collection pass
 func (c *Collector) GetTimeSeriesData(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
-	return c.store.GetTimeSeriesData(resource, metric, duration)
+	key := seriesCacheKey(resource, metric, duration)
+	if data, ok := c.cache.getSeries(key); ok {
+		return data, nil
+	}
+
+	data, err := c.store.GetTimeSeriesData(resource, metric, duration)
+	if err != nil {
+		return data, err
+	}
+	c.cache.putSeries(key, data)
+	return data, nil
+}
+
+// GetTimeSeriesDataWithGaps retrieves time-series data for a resource/metric,
+// marking intervals longer than GapThreshold collection intervals as gaps
+func (c *Collector) GetTimeSeriesDataWithGaps(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
+	maxInterval := time.Duration(float64(c.config.CollectionInterval) * c.config.GapThreshold)
+	return c.store.GetTimeSeriesDataWithGaps(resource, metric, duration, maxInterval)
 }
 
 // GetResourcePercentiles calculates percentiles for a resource metric
@@ -244,6 +431,16 @@ func (c *Collector) GetStoreSize() int {
 	return c.store.Size()
 }
 
+// GetSeriesCount returns the number of series in the metrics store
+func (c *Collector) GetSeriesCount() int {
+	return c.store.SeriesCount()
+}
+
+// GetEvictedSeriesCount returns how many series were evicted to stay within MaxSeries
+func (c *Collector) GetEvictedSeriesCount() uint64 {
+	return c.store.Evictions()
+}
+
 // GetStoredMetricKeys returns all metric keys currently in the store
 func (c *Collector) GetStoredMetricKeys() []string {
 	keys := c.store.Keys()
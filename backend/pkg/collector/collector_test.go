@@ -1,10 +1,33 @@
 package collector
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
 )
 
 // TestMetricsStore tests the basic functionality of the metrics store
@@ -100,6 +123,42 @@ func TestMetricsStoreCleanup(t *testing.T) {
 	}
 }
 
+// TestMetricsStoreCleanupPerMetricRetention tests that per-metric retention overrides prune each
+// metric on its own schedule
+func TestMetricsStoreCleanupPerMetricRetention(t *testing.T) {
+	store := newMetricsStore(24 * time.Hour)
+	store.metricRetention = map[string]time.Duration{
+		"cpu":              time.Hour,
+		"current_replicas": 7 * 24 * time.Hour,
+	}
+
+	now := time.Now()
+	for _, age := range []time.Duration{30 * time.Minute, 2 * time.Hour, 3 * 24 * time.Hour, 10 * 24 * time.Hour} {
+		store.Store("pod/web-1", "cpu", 100, now.Add(-age))
+		store.Store("pod/web-1", "memory", 100, now.Add(-age))
+		store.Store("hpa/web", "current_replicas", 3, now.Add(-age))
+	}
+
+	// cpu keeps 1 of 4 points, memory (default 24h) 2, current_replicas (7 days) 3
+	if removed := store.Cleanup(); removed != 6 {
+		t.Errorf("Expected 6 points removed, got %d", removed)
+	}
+
+	for _, tc := range []struct {
+		resource, metric string
+		expected         int
+	}{
+		{"pod/web-1", "cpu", 1},
+		{"pod/web-1", "memory", 2},
+		{"hpa/web", "current_replicas", 3},
+	} {
+		data, _ := store.GetTimeSeriesData(tc.resource, tc.metric, 30*24*time.Hour)
+		if len(data.Points) != tc.expected {
+			t.Errorf("Expected %d %s points after cleanup, got %d", tc.expected, tc.metric, len(data.Points))
+		}
+	}
+}
+
 // TestCalculatePercentile tests the percentile calculation function
 func TestCalculatePercentile(t *testing.T) {
 	tests := []struct {
@@ -255,3 +314,692 @@ func TestStoreBatch(t *testing.T) {
 		t.Errorf("Expected 2 points for pod/test1 cpu, got %d", len(tsData.Points))
 	}
 }
+
+// TestGetTimeSeriesDataWithGaps tests that stalled collection is marked with a gap
+func TestGetTimeSeriesDataWithGaps(t *testing.T) {
+	store := newMetricsStore(24 * time.Hour)
+
+	start := time.Now().Add(-30 * time.Minute)
+	store.Store("pod/test-pod", "cpu", 100.0, start)
+	store.Store("pod/test-pod", "cpu", 110.0, start.Add(15*time.Second))
+	// Collection stalls for ten minutes
+	store.Store("pod/test-pod", "cpu", 120.0, start.Add(10*time.Minute+15*time.Second))
+	store.Store("pod/test-pod", "cpu", 130.0, start.Add(10*time.Minute+30*time.Second))
+
+	tsData, err := store.GetTimeSeriesDataWithGaps("pod/test-pod", "cpu", 1*time.Hour, 45*time.Second)
+	if err != nil {
+		t.Fatalf("GetTimeSeriesDataWithGaps failed: %v", err)
+	}
+
+	if len(tsData.Points) != 5 {
+		t.Fatalf("Expected 5 points (4 samples + 1 gap), got %d", len(tsData.Points))
+	}
+
+	for i, point := range tsData.Points {
+		if point.Gap != (i == 2) {
+			t.Errorf("Point %d: expected gap=%v, got %v", i, i == 2, point.Gap)
+		}
+	}
+
+	gap := tsData.Points[2]
+	if !gap.Timestamp.After(tsData.Points[1].Timestamp) || !gap.Timestamp.Before(tsData.Points[3].Timestamp) {
+		t.Errorf("Expected gap marker between %v and %v, got %v",
+			tsData.Points[1].Timestamp, tsData.Points[3].Timestamp, gap.Timestamp)
+	}
+
+	encoded, err := json.Marshal(gap)
+	if err != nil {
+		t.Fatalf("Failed to marshal gap marker: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"Value":null`) {
+		t.Errorf("Expected gap marker to encode a null value, got %s", encoded)
+	}
+
+	// Without gap detection the series is returned untouched
+	plain, _ := store.GetTimeSeriesData("pod/test-pod", "cpu", 1*time.Hour)
+	if len(plain.Points) != 4 {
+		t.Errorf("Expected 4 points without gap markers, got %d", len(plain.Points))
+	}
+}
+
+// TestScrapeKubeletsTimeout tests that a hung kubelet is skipped after the scrape timeout instead of
+// stalling collection from the other nodes
+func TestScrapeKubeletsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/nodes":
+			json.NewEncoder(w).Encode(corev1.NodeList{Items: []corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "hung"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "healthy"}},
+			}})
+		case "/api/v1/nodes/hung/proxy/stats/summary":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		case "/api/v1/nodes/healthy/proxy/stats/summary":
+			w.Write([]byte(`{"pods": [{"podRef": {"name": "web-1", "namespace": "default"},
+				"memory": {"time": "2024-01-01T00:00:00Z", "workingSetBytes": 1024}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create clientset: %v", err)
+	}
+	kc := newK8sCollector(&k8s.Client{Clientset: clientset})
+	kc.scrapeTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	samples, err := kc.CollectWorkingSetMemory()
+	if err != nil {
+		t.Fatalf("CollectWorkingSetMemory failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the hung kubelet to time out, collection took %v", elapsed)
+	}
+	if len(samples) != 1 || samples[0].Pod != "web-1" {
+		t.Errorf("Expected the healthy node's sample only, got %+v", samples)
+	}
+}
+
+// TestParseWorkingSetSummary tests extracting working-set memory from a kubelet summary
+func TestParseWorkingSetSummary(t *testing.T) {
+	summary := []byte(`{
+		"node": {"nodeName": "node-1"},
+		"pods": [
+			{"podRef": {"name": "web-1", "namespace": "default"},
+			 "memory": {"time": "2024-01-01T00:00:00Z", "usageBytes": 943718400, "workingSetBytes": 314572800}},
+			{"podRef": {"name": "batch-1", "namespace": "jobs"},
+			 "memory": {"time": "2024-01-01T00:00:00Z", "workingSetBytes": 1048576},
+			 "network": {"time": "2024-01-01T00:00:00Z", "txBytes": 2048}},
+			{"podRef": {"name": "egress-1", "namespace": "default"},
+			 "network": {"time": "2024-01-01T00:00:00Z", "rxBytes": 4096, "txBytes": 8192}},
+			{"podRef": {"name": "starting-1", "namespace": "default"}}
+		]
+	}`)
+
+	samples, err := parseWorkingSetSummary(summary)
+	if err != nil {
+		t.Fatalf("parseWorkingSetSummary failed: %v", err)
+	}
+
+	if len(samples) != 3 {
+		t.Fatalf("Expected 3 samples (pods without stats skipped), got %d", len(samples))
+	}
+
+	if samples[0].Pod != "web-1" || samples[0].WorkingSetBytes == nil || *samples[0].WorkingSetBytes != 314572800 {
+		t.Errorf("Expected web-1 with 300Mi working set, got %+v", samples[0])
+	}
+
+	// Only monitored namespaces are stored
+	c := NewWithConfig(nil, DefaultConfig())
+	var batch metricsBatch
+	c.storeWorkingSetMemory(&batch, samples)
+	c.store.StoreBatch(batch)
+
+	data, _ := c.GetTimeSeriesData("pod/web-1", "memory_working_set", 100000*time.Hour)
+	if len(data.Points) != 1 {
+		t.Errorf("Expected 1 working-set point for web-1, got %d", len(data.Points))
+	}
+	data, _ = c.GetTimeSeriesData("pod/batch-1", "memory_working_set", 100000*time.Hour)
+	if len(data.Points) != 0 {
+		t.Errorf("Expected no working-set points for unmonitored namespace, got %d", len(data.Points))
+	}
+
+	// Transmitted bytes are stored even for pods without memory stats
+	data, _ = c.GetTimeSeriesData("pod/egress-1", "network_tx_bytes", 100000*time.Hour)
+	if len(data.Points) != 1 || data.Points[0].Value != 8192 {
+		t.Errorf("Expected one 8192-byte transmit point for egress-1, got %+v", data.Points)
+	}
+	data, _ = c.GetTimeSeriesData("pod/egress-1", "memory_working_set", 100000*time.Hour)
+	if len(data.Points) != 0 {
+		t.Errorf("Expected no working-set points for egress-1, got %d", len(data.Points))
+	}
+}
+
+// TestMetricsStoreMaxSeries tests that the least-recently-written series is evicted at the cap
+func TestMetricsStoreMaxSeries(t *testing.T) {
+	store := newBoundedMetricsStore(24*time.Hour, 3)
+
+	now := time.Now()
+	store.Store("pod/a", "cpu", 1, now)
+	store.Store("pod/b", "cpu", 1, now)
+	store.Store("pod/c", "cpu", 1, now)
+
+	// Writing to pod/a makes pod/b the least recently written series
+	store.Store("pod/a", "cpu", 2, now.Add(time.Second))
+	store.Store("pod/d", "cpu", 1, now)
+
+	if store.SeriesCount() != 3 {
+		t.Errorf("Expected 3 series at the cap, got %d", store.SeriesCount())
+	}
+
+	data, _ := store.GetTimeSeriesData("pod/b", "cpu", time.Hour)
+	if len(data.Points) != 0 {
+		t.Error("Expected least recently written series pod/b to be evicted")
+	}
+	for _, resource := range []string{"pod/a", "pod/c", "pod/d"} {
+		data, _ := store.GetTimeSeriesData(resource, "cpu", time.Hour)
+		if len(data.Points) == 0 {
+			t.Errorf("Expected series %s to be kept", resource)
+		}
+	}
+
+	// Batches are subject to the same limit
+	store.StoreBatch([]metricsEntry{
+		{Key: metricKey{Resource: "pod/e", Metric: "cpu"}, Points: []models.DataPoint{{Timestamp: now, Value: 1}}},
+		{Key: metricKey{Resource: "pod/f", Metric: "cpu"}, Points: []models.DataPoint{{Timestamp: now, Value: 1}}},
+	})
+
+	if store.SeriesCount() != 3 {
+		t.Errorf("Expected 3 series after batch, got %d", store.SeriesCount())
+	}
+	if store.Evictions() != 3 {
+		t.Errorf("Expected 3 evictions, got %d", store.Evictions())
+	}
+
+	data, _ = store.GetTimeSeriesData("pod/c", "cpu", time.Hour)
+	if len(data.Points) != 0 {
+		t.Error("Expected pod/c to be evicted by the batch")
+	}
+}
+
+// TestMetricsStoreSnapshot tests that points survive a snapshot and reload into a fresh store
+func TestMetricsStoreSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.gob")
+	store := newMetricsStore(time.Hour)
+
+	now := time.Now()
+	store.Store("pod/web-1", "cpu", 100, now.Add(-2*time.Minute))
+	store.Store("pod/web-1", "cpu", 200, now.Add(-time.Minute))
+	store.Store("pod/web-1", "memory", 1024, now)
+	store.Store("pod/stale", "cpu", 1, now.Add(-2*time.Hour))
+
+	if err := store.Snapshot(path); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	restored := newPersistentMetricsStore(time.Hour, nil, 0, nil, path)
+
+	data, _ := restored.GetTimeSeriesData("pod/web-1", "cpu", time.Hour)
+	if len(data.Points) != 2 || data.Points[0].Value != 100 || data.Points[1].Value != 200 {
+		t.Errorf("Expected both CPU points to survive, got %+v", data.Points)
+	}
+	if !data.Points[1].Timestamp.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Expected timestamps to survive, got %v", data.Points[1].Timestamp)
+	}
+	data, _ = restored.GetTimeSeriesData("pod/web-1", "memory", time.Hour)
+	if len(data.Points) != 1 {
+		t.Errorf("Expected the memory point to survive, got %d", len(data.Points))
+	}
+
+	// Points outside the retention period are dropped on load
+	if restored.SeriesCount() != 2 || restored.Size() != 3 {
+		t.Errorf("Expected 2 series and 3 points after load, got %d series and %d points",
+			restored.SeriesCount(), restored.Size())
+	}
+
+	// A missing snapshot leaves the store empty
+	if empty := newPersistentMetricsStore(time.Hour, nil, 0, nil, filepath.Join(t.TempDir(), "missing.gob")); empty.Size() != 0 {
+		t.Errorf("Expected an empty store without a snapshot, got %d points", empty.Size())
+	}
+}
+
+// TestMetricsStoreRollup tests that old points are rolled up into per-minute and per-hour aggregates
+// that reads still cover
+func TestMetricsStoreRollup(t *testing.T) {
+	tiers := rollupTiers(time.Hour, 6*time.Hour)
+	store := newMetricsStore(24 * time.Hour)
+	store.tiers = tiers
+
+	// 12 hours of samples every 15s, spiking to 1000m once every 5 minutes
+	now := time.Now()
+	var values []float64
+	for i := 0; i < 12*240; i++ {
+		value := 100.0
+		if i%20 == 0 {
+			value = 1000
+		}
+		store.Store("pod/web-1", "cpu", value, now.Add(-time.Duration(i)*15*time.Second))
+		values = append(values, value)
+	}
+	sort.Float64s(values)
+	exactP95 := calculatePercentile(values, 95)
+	recent, _ := store.GetTimeSeriesData("pod/web-1", "cpu", 30*time.Minute)
+
+	rolled := store.Rollup()
+	if rolled == 0 {
+		t.Fatal("Expected points to be rolled up")
+	}
+
+	// About an hour of raw points, up to six hours of minutes and six hours of hours remain
+	if size := store.Size(); size > 650 {
+		t.Errorf("Expected rollups to shrink the store from %d points to under 650 entries, got %d", 12*240, size)
+	}
+	if store.SeriesCount() != 1 {
+		t.Errorf("Expected the series to remain, got %d series", store.SeriesCount())
+	}
+
+	// Recent reads are still raw
+	data, _ := store.GetTimeSeriesData("pod/web-1", "cpu", 30*time.Minute)
+	if !reflect.DeepEqual(data.Points, recent.Points) {
+		t.Errorf("Expected %d raw points in the last 30 minutes, got %d", len(recent.Points), len(data.Points))
+	}
+
+	// Long reads include the rolled-up history, in order and without spurious gaps
+	data, _ = store.GetTimeSeriesData("pod/web-1", "cpu", 13*time.Hour)
+	if first := data.Points[0].Timestamp; now.Sub(first) < 11*time.Hour {
+		t.Errorf("Expected history from 12 hours ago, oldest point is %v old", now.Sub(first))
+	}
+	for i := 1; i < len(data.Points); i++ {
+		if data.Points[i].Timestamp.Before(data.Points[i-1].Timestamp) {
+			t.Fatalf("Expected points in time order, got %v before %v", data.Points[i-1].Timestamp, data.Points[i].Timestamp)
+		}
+	}
+	gapped, _ := store.GetTimeSeriesDataWithGaps("pod/web-1", "cpu", 13*time.Hour, 45*time.Second)
+	for _, point := range gapped.Points {
+		if point.Gap {
+			t.Fatalf("Expected no gaps across rolled-up history, got one at %v", point.Timestamp)
+		}
+	}
+
+	// Percentiles over rolled-up history err high, never low
+	_, p95, p99, err := store.GetResourcePercentiles("pod/web-1", "cpu", 13*time.Hour)
+	if err != nil {
+		t.Fatalf("GetResourcePercentiles failed: %v", err)
+	}
+	if p95 < exactP95 || p99 != 1000 {
+		t.Errorf("Expected P95 >= %.0f and P99 of 1000, got %.0f and %.0f", exactP95, p95, p99)
+	}
+
+	// Aggregates survive a snapshot
+	path := filepath.Join(t.TempDir(), "metrics.gob")
+	if err := store.Snapshot(path); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	restored := newPersistentMetricsStore(24*time.Hour, nil, 0, tiers, path)
+	if restored.Size() != store.Size() {
+		t.Errorf("Expected %d entries after reload, got %d", store.Size(), restored.Size())
+	}
+
+	// Aggregates expire with the retention period like raw points
+	store.retentionPeriod = 3 * time.Hour
+	store.Cleanup()
+	data, _ = store.GetTimeSeriesData("pod/web-1", "cpu", 13*time.Hour)
+	if oldest := now.Sub(data.Points[0].Timestamp); oldest > 3*time.Hour {
+		t.Errorf("Expected nothing older than the retention period, oldest point is %v old", oldest)
+	}
+}
+
+// TestExportSnapshot tests that archives exported to a directory re-import with the same series
+func TestExportSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	c := NewWithConfig(nil, DefaultConfig())
+
+	now := time.Now().Truncate(time.Second)
+	var batch metricsBatch
+	c.storePodMetrics(&batch, []models.PodMetrics{
+		{Name: "web-1", Namespace: "default", CPU: 100, Memory: 1024, Timestamp: now.Add(-time.Minute)},
+		{Name: "web-1", Namespace: "default", CPU: 150, Memory: 2048, Timestamp: now},
+		{Name: "db-1", Namespace: "data", CPU: 500, Memory: 4096, Timestamp: now},
+	}, now)
+	c.storeNodeMetrics(&batch, []models.NodeMetrics{{Name: "worker-1", CPU: 2000, Memory: 8192, Timestamp: now}}, now)
+	c.store.StoreBatch(batch)
+
+	read := func(name string) *MetricsArchive {
+		t.Helper()
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to open archive %s: %v", name, err)
+		}
+		defer file.Close()
+		archive, err := DecodeArchive(file)
+		if err != nil {
+			t.Fatalf("DecodeArchive failed: %v", err)
+		}
+		return archive
+	}
+
+	name, err := c.ExportSnapshot(NewDirSink(dir), "")
+	if err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if !strings.HasPrefix(name, "cluster-") || !strings.HasSuffix(name, ".json.gz") {
+		t.Errorf("Expected a cluster archive name, got %s", name)
+	}
+
+	archive := read(name)
+	if archive.SchemaVersion != ArchiveSchemaVersion || archive.Namespace != "" {
+		t.Errorf("Expected schema version %d for the whole store, got %d/%q", ArchiveSchemaVersion, archive.SchemaVersion, archive.Namespace)
+	}
+	if !archive.Start.Equal(now.Add(-time.Minute)) || !archive.End.Equal(now) {
+		t.Errorf("Expected the archive to span %v-%v, got %v-%v", now.Add(-time.Minute), now, archive.Start, archive.End)
+	}
+	if len(archive.Series) != c.GetSeriesCount() {
+		t.Errorf("Expected %d series in the archive, got %d", c.GetSeriesCount(), len(archive.Series))
+	}
+
+	// Re-importing reproduces every series point for point
+	restored := NewWithConfig(nil, DefaultConfig())
+	if loaded := restored.ImportSnapshot(archive); loaded != c.GetStoreSize() {
+		t.Errorf("Expected %d points imported, got %d", c.GetStoreSize(), loaded)
+	}
+	for _, key := range c.store.Keys() {
+		want, _ := c.GetTimeSeriesData(key.Resource, key.Metric, time.Hour)
+		got, _ := restored.GetTimeSeriesData(key.Resource, key.Metric, time.Hour)
+		if len(got.Points) != len(want.Points) {
+			t.Fatalf("Expected %d points for %s/%s, got %d", len(want.Points), key.Resource, key.Metric, len(got.Points))
+		}
+		for i := range want.Points {
+			if !got.Points[i].Timestamp.Equal(want.Points[i].Timestamp) || got.Points[i].Value != want.Points[i].Value {
+				t.Errorf("Expected point %d of %s/%s to be %+v, got %+v", i, key.Resource, key.Metric, want.Points[i], got.Points[i])
+			}
+		}
+	}
+
+	// A namespace archive holds only that namespace's pod series
+	name, err = c.ExportSnapshot(NewDirSink(dir), "data")
+	if err != nil {
+		t.Fatalf("ExportSnapshot(data) failed: %v", err)
+	}
+	archive = read(name)
+	if archive.Namespace != "data" || len(archive.Series) != 2 {
+		t.Fatalf("Expected the cpu and memory series of db-1 in the data archive, got %+v", archive.Series)
+	}
+	for _, series := range archive.Series {
+		if series.Resource != "pod/db-1" {
+			t.Errorf("Expected only pod/db-1 in the data archive, got %s", series.Resource)
+		}
+	}
+}
+
+// testMetricSource is a metric source reporting CPU in a fixed unit
+type testMetricSource CPUUnit
+
+func (s testMetricSource) Name() string     { return "test" }
+func (s testMetricSource) CPUUnit() CPUUnit { return CPUUnit(s) }
+
+// TestIngestSamplesNormalizesCPU tests that CPU reported in cores is stored as millicores
+func TestIngestSamplesNormalizesCPU(t *testing.T) {
+	c := NewWithConfig(nil, DefaultConfig())
+	now := time.Now()
+
+	if err := c.IngestSamples(testMetricSource(CPUUnitCores), []Sample{
+		{Resource: "pod/web-1", Metric: "cpu", Value: 0.25, Timestamp: now},
+		{Resource: "pod/web-1", Metric: "memory", Value: 1024, Timestamp: now},
+	}); err != nil {
+		t.Fatalf("IngestSamples failed: %v", err)
+	}
+	if err := c.IngestSamples(testMetricSource(CPUUnitMillicores), []Sample{
+		{Resource: "pod/web-2", Metric: "cpu", Value: 250, Timestamp: now},
+	}); err != nil {
+		t.Fatalf("IngestSamples failed: %v", err)
+	}
+
+	for _, resource := range []string{"pod/web-1", "pod/web-2"} {
+		data, _ := c.GetTimeSeriesData(resource, "cpu", time.Hour)
+		if len(data.Points) != 1 || data.Points[0].Value != 250 {
+			t.Errorf("Expected 250 millicores stored for %s, got %+v", resource, data.Points)
+		}
+	}
+
+	// Only CPU is rescaled
+	data, _ := c.GetTimeSeriesData("pod/web-1", "memory", time.Hour)
+	if len(data.Points) != 1 || data.Points[0].Value != 1024 {
+		t.Errorf("Expected memory to be stored unchanged, got %+v", data.Points)
+	}
+
+	// A source without a known unit is rejected before anything is stored
+	if err := c.IngestSamples(testMetricSource(""), []Sample{
+		{Resource: "pod/web-3", Metric: "cpu", Value: 1, Timestamp: now},
+	}); err == nil {
+		t.Error("Expected an error for a source without a CPU unit")
+	}
+	if data, _ := c.GetTimeSeriesData("pod/web-3", "cpu", time.Hour); len(data.Points) != 0 {
+		t.Error("Expected no samples stored from a source without a CPU unit")
+	}
+}
+
+// TestCPUThrottling tests parsing cAdvisor CFS counters and storing the throttled share between passes
+func TestCPUThrottling(t *testing.T) {
+	cadvisor := func(periods, throttled int) []byte {
+		return []byte(fmt.Sprintf(`# HELP container_cpu_cfs_periods_total Number of elapsed enforcement period intervals.
+# TYPE container_cpu_cfs_periods_total counter
+container_cpu_cfs_periods_total{container="",namespace="default",pod="web-1"} 99999 1700000000000
+container_cpu_cfs_periods_total{container="POD",namespace="default",pod="web-1"} 99999 1700000000000
+container_cpu_cfs_periods_total{container="app",namespace="default",pod="web-1"} %d 1700000000000
+container_cpu_cfs_periods_total{container="proxy",namespace="default",pod="web-1"} %d 1700000000000
+container_cpu_cfs_throttled_periods_total{container="app",namespace="default",pod="web-1"} %d 1700000000000
+container_cpu_cfs_throttled_periods_total{container="proxy",namespace="default",pod="web-1"} 0 1700000000000
+container_cpu_cfs_periods_total{container="job",namespace="batch",pod="job-1"} 500 1700000000000
+container_cpu_usage_seconds_total{container="app",namespace="default",pod="web-1"} 12.5 1700000000000
+`, periods, periods, throttled))
+	}
+
+	samples := parseCFSCounters(cadvisor(1000, 100))
+	if len(samples) != 2 {
+		t.Fatalf("Expected counters for 2 pods, got %+v", samples)
+	}
+	if samples[0].Pod != "web-1" || samples[0].Periods != 2000 || samples[0].ThrottledPeriods != 100 {
+		t.Errorf("Expected web-1 with 2000 periods and 100 throttled across its containers, got %+v", samples[0])
+	}
+
+	c := NewWithConfig(nil, DefaultConfig())
+	now := time.Now()
+
+	// The first pass only records the counters; later passes store the throttled share since then
+	var batch metricsBatch
+	c.storeCPUThrottling(&batch, samples, now.Add(-time.Minute))
+	c.storeCPUThrottling(&batch, parseCFSCounters(cadvisor(1500, 400)), now)
+	c.store.StoreBatch(batch)
+
+	data, _ := c.GetTimeSeriesData("pod/web-1", MetricCPUThrottle, time.Hour)
+	if len(data.Points) != 1 || data.Points[0].Value != 0.3 {
+		t.Errorf("Expected one throttle ratio of 0.3 (300 of 1000 periods), got %+v", data.Points)
+	}
+	data, _ = c.GetTimeSeriesData("pod/job-1", MetricCPUThrottle, time.Hour)
+	if len(data.Points) != 0 {
+		t.Errorf("Expected no throttling for an unmonitored namespace, got %+v", data.Points)
+	}
+
+	// Counter resets are skipped rather than stored as negative throttling
+	batch = nil
+	c.storeCPUThrottling(&batch, parseCFSCounters(cadvisor(10, 1)), now.Add(time.Minute))
+	if len(batch) != 0 {
+		t.Errorf("Expected nothing stored after a counter reset, got %+v", batch)
+	}
+}
+
+// TestQueryCache tests that pod lists and series reads are reused within the TTL and dropped on
+// refresh
+func TestQueryCache(t *testing.T) {
+	labels := map[string]string{"app": "web"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+	}
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-rs",
+			Namespace:       "default",
+			UID:             "web-rs-uid",
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+		},
+	}
+	clientset := fake.NewClientset(rs, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-1",
+			Namespace:       "default",
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(rs, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))},
+		},
+	})
+	var lists int
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists++
+		return false, nil, nil
+	})
+
+	c := NewWithConfig(&k8s.Client{Clientset: clientset}, DefaultConfig())
+	c.SetNamespaces([]string{})
+
+	for i := 0; i < 3; i++ {
+		pods, err := c.ListDeploymentPods(deployment)
+		if err != nil {
+			t.Fatalf("ListDeploymentPods failed: %v", err)
+		}
+		if len(pods) != 1 {
+			t.Fatalf("Expected 1 pod, got %d", len(pods))
+		}
+	}
+	if lists != 1 {
+		t.Errorf("Expected one pod list within the TTL, got %d", lists)
+	}
+
+	now := time.Now()
+	c.store.Store("pod/web-1", "cpu", 100, now.Add(-time.Minute))
+	if data, _ := c.GetTimeSeriesData("pod/web-1", "cpu", time.Hour); len(data.Points) != 1 {
+		t.Fatalf("Expected 1 point, got %d", len(data.Points))
+	}
+
+	// A newly stored point is not visible until the cache is refreshed
+	c.store.Store("pod/web-1", "cpu", 200, now)
+	if data, _ := c.GetTimeSeriesData("pod/web-1", "cpu", time.Hour); len(data.Points) != 1 {
+		t.Errorf("Expected the cached read with 1 point, got %d", len(data.Points))
+	}
+
+	c.Refresh()
+	if data, _ := c.GetTimeSeriesData("pod/web-1", "cpu", time.Hour); len(data.Points) != 2 {
+		t.Errorf("Expected 2 points after refresh, got %d", len(data.Points))
+	}
+	if _, err := c.ListDeploymentPods(deployment); err != nil || lists != 2 {
+		t.Errorf("Expected the pod list to be fetched again after refresh, got %d lists (err: %v)", lists, err)
+	}
+
+}
+
+// BenchmarkStoreIngestion compares storing a collection pass one point at a time against a single
+// batch, while another goroutine reads from the store as analyses do
+func BenchmarkStoreIngestion(b *testing.B) {
+	const pods = 1000
+	now := time.Now()
+	metrics := make([]models.PodMetrics, pods)
+	for i := range metrics {
+		metrics[i] = models.PodMetrics{Name: fmt.Sprintf("web-%d", i), Namespace: "default", CPU: 100, Memory: 1024, Timestamp: now}
+	}
+
+	run := func(b *testing.B, ingest func(store *metricsStore)) {
+		store := newMetricsStore(time.Hour)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					store.GetTimeSeriesData("pod/web-0", "cpu", time.Hour)
+				}
+			}
+		}()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ingest(store)
+		}
+	}
+
+	b.Run("per-point", func(b *testing.B) {
+		run(b, func(store *metricsStore) {
+			for _, metric := range metrics {
+				resource := "pod/" + metric.Name
+				store.Store(resource, "cpu", float64(metric.CPU), metric.Timestamp)
+				store.Store(resource, "memory", float64(metric.Memory), metric.Timestamp)
+			}
+		})
+	})
+
+	b.Run("batched", func(b *testing.B) {
+		c := NewWithConfig(nil, DefaultConfig())
+		run(b, func(store *metricsStore) {
+			var batch metricsBatch
+			c.storePodMetrics(&batch, metrics, now)
+			store.StoreBatch(batch)
+		})
+	})
+}
+
+// TestCollectHPAMetricsNilMinReplicas tests that an HPA relying on the default minReplicas is
+// collected with a minimum of 1 rather than panicking
+func TestCollectHPAMetricsNilMinReplicas(t *testing.T) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MaxReplicas:    5,
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 2, DesiredReplicas: 2},
+	}
+	c := NewWithConfig(&k8s.Client{Clientset: fake.NewClientset(hpa)}, DefaultConfig())
+
+	metrics, err := c.CollectHPAMetrics("default")
+	if err != nil {
+		t.Fatalf("CollectHPAMetrics failed: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("Expected 1 HPA, got %d", len(metrics))
+	}
+	if metrics[0].MinReplicas != 1 || metrics[0].MaxReplicas != 5 {
+		t.Errorf("Expected replicas 1-5, got %d-%d", metrics[0].MinReplicas, metrics[0].MaxReplicas)
+	}
+}
+
+// TestMetricsAPIUnavailable tests that a missing metrics API is detected and backs off collection
+func TestMetricsAPIUnavailable(t *testing.T) {
+	metricsClient := metricsfake.NewSimpleClientset()
+	metricsClient.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: action.GetResource().Resource}, "")
+	})
+	config := DefaultConfig()
+	c := NewWithConfig(&k8s.Client{Clientset: fake.NewClientset(), MetricsClient: metricsClient}, config)
+
+	if _, err := c.CollectNodeMetrics(); !errors.Is(err, ErrMetricsAPIUnavailable) {
+		t.Errorf("Expected ErrMetricsAPIUnavailable from node metrics, got %v", err)
+	}
+	if _, err := c.CollectPodMetrics("default"); !errors.Is(err, ErrMetricsAPIUnavailable) {
+		t.Errorf("Expected ErrMetricsAPIUnavailable from pod metrics, got %v", err)
+	}
+
+	if !c.MetricsAPIAvailable() {
+		t.Error("Expected metrics API to be assumed available before the first pass")
+	}
+	if delay := c.nextCollectionDelay(config.CollectionInterval); delay != config.CollectionInterval {
+		t.Errorf("Expected %v while available, got %v", config.CollectionInterval, delay)
+	}
+
+	c.collectAllMetrics()
+	if c.MetricsAPIAvailable() {
+		t.Fatal("Expected metrics API to be reported unavailable after a failed pass")
+	}
+
+	delay := config.CollectionInterval
+	for _, want := range []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		delay = c.nextCollectionDelay(delay)
+		if delay != want {
+			t.Errorf("Expected backoff %v, got %v", want, delay)
+		}
+	}
+
+	c.setMetricsAPIAvailable(true, nil)
+	if delay := c.nextCollectionDelay(delay); delay != config.CollectionInterval {
+		t.Errorf("Expected %v after recovery, got %v", config.CollectionInterval, delay)
+	}
+}
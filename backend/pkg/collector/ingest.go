@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// CPUUnit is the unit a metric source reports CPU usage in
+type CPUUnit string
+
+// CPU units a metric source can declare
+const (
+	CPUUnitMillicores CPUUnit = "millicores" // as reported by the metrics API
+	CPUUnitCores      CPUUnit = "cores"      // fractional cores, as reported by Prometheus
+)
+
+// MetricSource is an external source of metrics, such as Prometheus, whose samples are fed to the
+// store with IngestSamples. Each source declares its CPU unit so that stored CPU is always millicores.
+type MetricSource interface {
+	// Name identifies the source in errors and logs
+	Name() string
+
+	// CPUUnit is the unit the source reports "cpu" samples in
+	CPUUnit() CPUUnit
+}
+
+// Sample is a single metric value reported by a metric source
+type Sample struct {
+	Resource  string // e.g., "pod/echo-demo-xxx", "node/worker-1"
+	Metric    string // e.g., "cpu", "memory"
+	Value     float64
+	Timestamp time.Time
+}
+
+// normalizeCPU converts a CPU value in unit to millicores
+func normalizeCPU(value float64, unit CPUUnit) (float64, error) {
+	switch unit {
+	case CPUUnitMillicores:
+		return value, nil
+	case CPUUnitCores:
+		return value * 1000, nil
+	default:
+		return 0, fmt.Errorf("unknown CPU unit %q", unit)
+	}
+}
+
+// IngestSamples stores samples from an external metric source, converting CPU to millicores
+// according to the source's declared unit. Nothing is stored if the source declares an unknown unit.
+func (c *Collector) IngestSamples(source MetricSource, samples []Sample) error {
+	unit := source.CPUUnit()
+	if _, err := normalizeCPU(0, unit); err != nil {
+		return fmt.Errorf("metric source %s: %w", source.Name(), err)
+	}
+
+	entries := make([]metricsEntry, 0, len(samples))
+	for _, sample := range samples {
+		value := sample.Value
+		if sample.Metric == "cpu" {
+			value, _ = normalizeCPU(value, unit)
+		}
+		entries = append(entries, metricsEntry{
+			Key:    metricKey{Resource: sample.Resource, Metric: sample.Metric},
+			Points: []models.DataPoint{{Timestamp: sample.Timestamp, Value: value}},
+		})
+	}
+
+	c.store.StoreBatch(entries)
+
+	// Cached reads predate the ingested samples
+	c.cache.clear()
+
+	return nil
+}
@@ -2,24 +2,47 @@ package collector
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/internal/parallel"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ErrMetricsAPIUnavailable is wrapped by pod and node metrics errors when the metrics.k8s.io API
+// is not registered or not serving, which almost always means metrics-server is not installed or
+// is unhealthy
+var ErrMetricsAPIUnavailable = errors.New("metrics API (metrics.k8s.io) is not available")
+
+// metricsAPIError wraps errors showing that the metrics API itself is missing (404, no REST
+// mapping) or down (503 from the API aggregator) with ErrMetricsAPIUnavailable
+func metricsAPIError(err error) error {
+	if apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) || meta.IsNoMatchError(err) {
+		return fmt.Errorf("%w: %v", ErrMetricsAPIUnavailable, err)
+	}
+	return err
+}
+
 // k8sCollector handles the actual collection of metrics from Kubernetes
 type k8sCollector struct {
-	client *k8s.Client
+	client        *k8s.Client
+	scrapeTimeout time.Duration // per-node deadline of kubelet scrapes
 }
 
 // newK8sCollector creates a new Kubernetes metrics collector
 func newK8sCollector(client *k8s.Client) *k8sCollector {
 	return &k8sCollector{
-		client: client,
+		client:        client,
+		scrapeTimeout: kubeletScrapeTimeout,
 	}
 }
 
@@ -28,9 +51,12 @@ func (c *k8sCollector) CollectPodMetrics(namespace string) ([]models.PodMetrics,
 	ctx := context.Background()
 
 	// Get pod metrics from metrics API
+	if c.client.MetricsClient == nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %w", ErrMetricsAPIUnavailable)
+	}
 	podMetricsList, err := c.client.MetricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pod metrics: %w", err)
+		return nil, fmt.Errorf("failed to get pod metrics: %w", metricsAPIError(err))
 	}
 
 	var metrics []models.PodMetrics
@@ -39,6 +65,7 @@ func (c *k8sCollector) CollectPodMetrics(namespace string) ([]models.PodMetrics,
 		// Sum up resources across all containers in the pod
 		var totalCPU int64
 		var totalMemory int64
+		containers := make([]models.ContainerMetrics, 0, len(podMetrics.Containers))
 
 		for _, container := range podMetrics.Containers {
 			// CPU is in nanocores, convert to millicores
@@ -48,14 +75,21 @@ func (c *k8sCollector) CollectPodMetrics(namespace string) ([]models.PodMetrics,
 			// Memory is in bytes
 			memBytes := container.Usage.Memory().Value()
 			totalMemory += memBytes
+
+			containers = append(containers, models.ContainerMetrics{
+				Name:   container.Name,
+				CPU:    cpuNano,
+				Memory: memBytes,
+			})
 		}
 
 		metrics = append(metrics, models.PodMetrics{
-			Name:      podMetrics.Name,
-			Namespace: podMetrics.Namespace,
-			CPU:       totalCPU,
-			Memory:    totalMemory,
-			Timestamp: podMetrics.Timestamp.Time,
+			Name:       podMetrics.Name,
+			Namespace:  podMetrics.Namespace,
+			CPU:        totalCPU,
+			Memory:     totalMemory,
+			Containers: containers,
+			Timestamp:  podMetrics.Timestamp.Time,
 		})
 	}
 
@@ -67,9 +101,12 @@ func (c *k8sCollector) CollectNodeMetrics() ([]models.NodeMetrics, error) {
 	ctx := context.Background()
 
 	// Get node metrics from metrics API
+	if c.client.MetricsClient == nil {
+		return nil, fmt.Errorf("failed to get node metrics: %w", ErrMetricsAPIUnavailable)
+	}
 	nodeMetricsList, err := c.client.MetricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node metrics: %w", err)
+		return nil, fmt.Errorf("failed to get node metrics: %w", metricsAPIError(err))
 	}
 
 	var metrics []models.NodeMetrics
@@ -118,29 +155,24 @@ func (c *k8sCollector) CollectHPAMetrics(namespace string) ([]models.HPAMetrics,
 			Namespace:       hpa.Namespace,
 			CurrentReplicas: hpa.Status.CurrentReplicas,
 			DesiredReplicas: hpa.Status.DesiredReplicas,
-			MinReplicas:     *hpa.Spec.MinReplicas,
+			MinReplicas:     k8s.HPAMinReplicas(&hpa),
 			MaxReplicas:     hpa.Spec.MaxReplicas,
 			Timestamp:       timestamp,
 		}
 
-		// Extract CPU target and current values from metrics
-		for _, metric := range hpa.Spec.Metrics {
-			if metric.Type == autoscalingv2.ResourceMetricSourceType &&
-				metric.Resource != nil &&
-				metric.Resource.Name == "cpu" &&
-				metric.Resource.Target.AverageUtilization != nil {
-				hpaMetric.TargetCPU = *metric.Resource.Target.AverageUtilization
-				break
+		// Extract every metric the HPA scales on, keeping CPU and memory utilization at hand
+		hpaMetric.Metrics = k8s.HPAMetricTargets(&hpa)
+		for _, target := range hpaMetric.Metrics {
+			if target.Type != string(autoscalingv2.ResourceMetricSourceType) || target.TargetType != string(autoscalingv2.UtilizationMetricType) {
+				continue
 			}
-		}
-
-		for _, currentMetric := range hpa.Status.CurrentMetrics {
-			if currentMetric.Type == autoscalingv2.ResourceMetricSourceType &&
-				currentMetric.Resource != nil &&
-				currentMetric.Resource.Name == "cpu" &&
-				currentMetric.Resource.Current.AverageUtilization != nil {
-				hpaMetric.CurrentCPU = *currentMetric.Resource.Current.AverageUtilization
-				break
+			switch target.Name {
+			case "cpu":
+				hpaMetric.TargetCPU = int32(target.Target)
+				hpaMetric.CurrentCPU = int32(target.Current)
+			case "memory":
+				hpaMetric.TargetMemory = int32(target.Target)
+				hpaMetric.CurrentMemory = int32(target.Current)
 			}
 		}
 
@@ -149,3 +181,118 @@ func (c *k8sCollector) CollectHPAMetrics(namespace string) ([]models.HPAMetrics,
 
 	return metrics, nil
 }
+
+// workingSetSample is a pod's working-set memory and transmitted bytes as reported by the kubelet.
+// Either may be nil when the kubelet has no stats for it yet.
+type workingSetSample struct {
+	Namespace       string
+	Pod             string
+	WorkingSetBytes *int64
+	Timestamp       time.Time
+	TxBytes         *int64 // cumulative bytes transmitted over the pod's interfaces
+	TxTimestamp     time.Time
+}
+
+// kubeletSummary is the subset of the kubelet /stats/summary response used for working-set memory
+// and network egress
+type kubeletSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Memory *struct {
+			Time            time.Time `json:"time"`
+			WorkingSetBytes *int64    `json:"workingSetBytes"`
+		} `json:"memory"`
+		Network *struct {
+			Time    time.Time `json:"time"`
+			TxBytes *int64    `json:"txBytes"`
+		} `json:"network"`
+	} `json:"pods"`
+}
+
+// Limits on scraping kubelets through the API server's node proxy, so that one hung kubelet cannot
+// stall a collection pass
+const (
+	kubeletScrapeTimeout     = 10 * time.Second // per node
+	kubeletScrapeConcurrency = 8                // nodes scraped at once
+)
+
+// scrapeKubelets fetches suffix from every node's kubelet through the node proxy, scraping up to
+// kubeletScrapeConcurrency nodes at once with a scrapeTimeout deadline each. Nodes whose
+// kubelet is unreachable or too slow are skipped; responses follow the node listing order.
+func (c *k8sCollector) scrapeKubelets(ctx context.Context, suffix string) ([][]byte, error) {
+	nodes, err := c.client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	responses := make([][]byte, len(nodes.Items))
+	parallel.ForEach(len(nodes.Items), kubeletScrapeConcurrency, func(i int) {
+		nodeCtx, cancel := context.WithTimeout(ctx, c.scrapeTimeout)
+		defer cancel()
+
+		data, err := c.client.Clientset.CoreV1().RESTClient().Get().
+			Resource("nodes").Name(nodes.Items[i].Name).SubResource("proxy").Suffix(suffix).
+			DoRaw(nodeCtx)
+		if err != nil {
+			slog.Debug("Skipping unreachable kubelet", "node", nodes.Items[i].Name, "path", suffix, "error", err)
+			return
+		}
+		responses[i] = data
+	})
+
+	return slices.DeleteFunc(responses, func(data []byte) bool { return data == nil }), nil
+}
+
+// CollectWorkingSetMemory reads pod working-set memory (usage minus reclaimable page cache) and
+// transmitted bytes from every node's kubelet summary API
+func (c *k8sCollector) CollectWorkingSetMemory() ([]workingSetSample, error) {
+	responses, err := c.scrapeKubelets(context.Background(), "stats/summary")
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []workingSetSample
+	for _, data := range responses {
+		nodeSamples, err := parseWorkingSetSummary(data)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, nodeSamples...)
+	}
+
+	return samples, nil
+}
+
+// parseWorkingSetSummary extracts per-pod working-set memory and transmitted bytes from a kubelet
+// summary response
+func parseWorkingSetSummary(data []byte) ([]workingSetSample, error) {
+	var summary kubeletSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet summary: %w", err)
+	}
+
+	var samples []workingSetSample
+	for _, pod := range summary.Pods {
+		sample := workingSetSample{
+			Namespace: pod.PodRef.Namespace,
+			Pod:       pod.PodRef.Name,
+		}
+		if pod.Memory != nil {
+			sample.WorkingSetBytes = pod.Memory.WorkingSetBytes
+			sample.Timestamp = pod.Memory.Time
+		}
+		if pod.Network != nil {
+			sample.TxBytes = pod.Network.TxBytes
+			sample.TxTimestamp = pod.Network.Time
+		}
+		if sample.WorkingSetBytes == nil && sample.TxBytes == nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
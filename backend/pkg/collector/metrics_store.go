@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"container/list"
 	"fmt"
 	"sort"
 	"sync"
@@ -14,14 +15,72 @@ type metricsStore struct {
 	mu              sync.RWMutex
 	data            map[metricKey][]models.DataPoint
 	retentionPeriod time.Duration
+	metricRetention map[string]time.Duration // per-metric overrides of retentionPeriod
+
+	// Older points rolled up into per-bucket aggregates, finest tier first; no tiers keeps raw points only
+	tiers   []rollupTier
+	rollups map[metricKey][][]rollupPoint
+
+	// Series cardinality limit, tracked in least-recently-written order
+	maxSeries int
+	lru       *list.List // front = most recently written
+	lruIndex  map[metricKey]*list.Element
+	evictions uint64
 }
 
-// newMetricsStore creates a new metrics store
+// newMetricsStore creates a new metrics store with no series limit
 func newMetricsStore(retentionPeriod time.Duration) *metricsStore {
+	return newBoundedMetricsStore(retentionPeriod, 0)
+}
+
+// newBoundedMetricsStore creates a new metrics store that holds at most maxSeries series,
+// evicting the least-recently-written series when full. A maxSeries of 0 means unlimited.
+func newBoundedMetricsStore(retentionPeriod time.Duration, maxSeries int) *metricsStore {
 	return &metricsStore{
 		data:            make(map[metricKey][]models.DataPoint),
 		retentionPeriod: retentionPeriod,
+		rollups:         make(map[metricKey][][]rollupPoint),
+		maxSeries:       maxSeries,
+		lru:             list.New(),
+		lruIndex:        make(map[metricKey]*list.Element),
+	}
+}
+
+// retentionFor returns how long points of a metric are kept
+func (s *metricsStore) retentionFor(metric string) time.Duration {
+	if retention, ok := s.metricRetention[metric]; ok {
+		return retention
+	}
+	return s.retentionPeriod
+}
+
+// touch marks a series as just written, evicting the least-recently-written
+// series if adding a new one would exceed the limit. Caller must hold the write lock.
+func (s *metricsStore) touch(key metricKey) {
+	if elem, ok := s.lruIndex[key]; ok {
+		s.lru.MoveToFront(elem)
+		return
+	}
+
+	if s.maxSeries > 0 {
+		for len(s.lruIndex) >= s.maxSeries {
+			oldest := s.lru.Back()
+			s.forget(oldest.Value.(metricKey))
+			s.evictions++
+		}
 	}
+
+	s.lruIndex[key] = s.lru.PushFront(key)
+}
+
+// forget removes a series and its LRU entry. Caller must hold the write lock.
+func (s *metricsStore) forget(key metricKey) {
+	if elem, ok := s.lruIndex[key]; ok {
+		s.lru.Remove(elem)
+		delete(s.lruIndex, key)
+	}
+	delete(s.data, key)
+	delete(s.rollups, key)
 }
 
 // Store adds a metric data point to the store
@@ -39,6 +98,7 @@ func (s *metricsStore) Store(resource, metric string, value float64, timestamp t
 		Value:     value,
 	}
 
+	s.touch(key)
 	s.data[key] = append(s.data[key], point)
 }
 
@@ -48,11 +108,13 @@ func (s *metricsStore) StoreBatch(entries []metricsEntry) {
 	defer s.mu.Unlock()
 
 	for _, entry := range entries {
+		s.touch(entry.Key)
 		s.data[entry.Key] = append(s.data[entry.Key], entry.Points...)
 	}
 }
 
-// GetTimeSeriesData retrieves time-series data for a resource/metric within a duration
+// GetTimeSeriesData retrieves time-series data for a resource/metric within a duration. Rolled-up
+// history is returned as one point per bucket, at the bucket's start and average.
 func (s *metricsStore) GetTimeSeriesData(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -62,8 +124,10 @@ func (s *metricsStore) GetTimeSeriesData(resource, metric string, duration time.
 		Metric:   metric,
 	}
 
-	allPoints, exists := s.data[key]
-	if !exists || len(allPoints) == 0 {
+	cutoff := time.Now().Add(-duration)
+	allPoints := s.data[key]
+	aggregates := s.rollupsSince(key, cutoff)
+	if len(allPoints) == 0 && len(aggregates) == 0 {
 		return models.TimeSeriesData{
 			Resource: resource,
 			Metric:   metric,
@@ -72,9 +136,11 @@ func (s *metricsStore) GetTimeSeriesData(resource, metric string, duration time.
 	}
 
 	// Filter points within the duration
-	cutoff := time.Now().Add(-duration)
 	var filteredPoints []models.DataPoint
 
+	for _, aggregate := range aggregates {
+		filteredPoints = append(filteredPoints, models.DataPoint{Timestamp: aggregate.Timestamp, Value: aggregate.Avg})
+	}
 	for _, point := range allPoints {
 		if point.Timestamp.After(cutoff) {
 			filteredPoints = append(filteredPoints, point)
@@ -93,7 +159,52 @@ func (s *metricsStore) GetTimeSeriesData(resource, metric string, duration time.
 	}, nil
 }
 
-// GetResourcePercentiles calculates percentiles for a resource metric
+// GetTimeSeriesDataWithGaps retrieves time-series data and inserts a gap marker
+// between any two consecutive points further apart than maxInterval. Rolled-up buckets
+// are a resolution apart, so intervals starting in rolled-up history get that much slack.
+func (s *metricsStore) GetTimeSeriesDataWithGaps(resource, metric string, duration, maxInterval time.Duration) (models.TimeSeriesData, error) {
+	data, err := s.GetTimeSeriesData(resource, metric, duration)
+	if err != nil {
+		return data, err
+	}
+
+	rolledUntil, resolution := s.rolledUpUntil(metricKey{Resource: resource, Metric: metric})
+	data.Points = insertGapMarkers(data.Points, func(prev time.Time) time.Duration {
+		if prev.Before(rolledUntil) {
+			return maxInterval + resolution
+		}
+		return maxInterval
+	})
+	return data, nil
+}
+
+// insertGapMarkers returns a copy of the sorted points with a gap marker placed halfway
+// between any two consecutive points further apart than the maxInterval of the earlier one
+func insertGapMarkers(points []models.DataPoint, maxInterval func(prev time.Time) time.Duration) []models.DataPoint {
+	if len(points) < 2 {
+		return points
+	}
+
+	result := make([]models.DataPoint, 0, len(points))
+	for i, point := range points {
+		if i > 0 {
+			prev := points[i-1].Timestamp
+			interval := point.Timestamp.Sub(prev)
+			if limit := maxInterval(prev); limit > 0 && interval > limit {
+				result = append(result, models.DataPoint{
+					Timestamp: prev.Add(interval / 2),
+					Gap:       true,
+				})
+			}
+		}
+		result = append(result, point)
+	}
+
+	return result
+}
+
+// GetResourcePercentiles calculates percentiles for a resource metric. Over rolled-up history, each
+// bucket stands for its points at its average (P50), P95 (P95) or maximum (P99), so percentiles err high.
 func (s *metricsStore) GetResourcePercentiles(resource, metric string, duration time.Duration) (p50, p95, p99 float64, err error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -103,8 +214,8 @@ func (s *metricsStore) GetResourcePercentiles(resource, metric string, duration
 		Metric:   metric,
 	}
 
-	allPoints, exists := s.data[key]
-	if !exists || len(allPoints) == 0 {
+	allPoints := s.data[key]
+	if len(allPoints) == 0 && len(s.rollups[key]) == 0 {
 		return 0, 0, 0, fmt.Errorf("no data found for resource %s metric %s", resource, metric)
 	}
 
@@ -117,11 +228,19 @@ func (s *metricsStore) GetResourcePercentiles(resource, metric string, duration
 			values = append(values, point.Value)
 		}
 	}
+	aggregates := s.rollupsSince(key, cutoff)
 
-	if len(values) == 0 {
+	if len(values) == 0 && len(aggregates) == 0 {
 		return 0, 0, 0, fmt.Errorf("no data found for resource %s metric %s within duration %v", resource, metric, duration)
 	}
 
+	if len(aggregates) > 0 {
+		p50 = rolledUpPercentile(values, aggregates, 50, func(a rollupPoint) float64 { return a.Avg })
+		p95 = rolledUpPercentile(values, aggregates, 95, func(a rollupPoint) float64 { return a.P95 })
+		p99 = rolledUpPercentile(values, aggregates, 99, func(a rollupPoint) float64 { return a.Max })
+		return p50, p95, p99, nil
+	}
+
 	// Sort values for percentile calculation
 	sort.Float64s(values)
 
@@ -156,15 +275,17 @@ func calculatePercentile(sortedValues []float64, percentile float64) float64 {
 	return sortedValues[lower]*(1-weight) + sortedValues[upper]*weight
 }
 
-// Cleanup removes data older than the retention period
+// Cleanup removes data, raw or rolled up, older than the retention period of its metric. It returns
+// the number of raw points and aggregates removed.
 func (s *metricsStore) Cleanup() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	cutoff := time.Now().Add(-s.retentionPeriod)
+	now := time.Now()
 	removedCount := 0
 
 	for key, points := range s.data {
+		cutoff := now.Add(-s.retentionFor(key.Metric))
 		var kept []models.DataPoint
 
 		for _, point := range points {
@@ -175,9 +296,23 @@ func (s *metricsStore) Cleanup() int {
 			}
 		}
 
-		if len(kept) == 0 {
+		rolledUp := false
+		for i, tier := range s.rollups[key] {
+			var keptAggregates []rollupPoint
+			for _, aggregate := range tier {
+				if aggregate.Timestamp.After(cutoff) {
+					keptAggregates = append(keptAggregates, aggregate)
+				} else {
+					removedCount++
+				}
+			}
+			s.rollups[key][i] = keptAggregates
+			rolledUp = rolledUp || len(keptAggregates) > 0
+		}
+
+		if len(kept) == 0 && !rolledUp {
 			// Remove the entire key if no points remain
-			delete(s.data, key)
+			s.forget(key)
 		} else {
 			s.data[key] = kept
 		}
@@ -186,7 +321,7 @@ func (s *metricsStore) Cleanup() int {
 	return removedCount
 }
 
-// Size returns the total number of data points in the store
+// Size returns the total number of raw data points and rolled-up aggregates in the store
 func (s *metricsStore) Size() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -195,9 +330,30 @@ func (s *metricsStore) Size() int {
 	for _, points := range s.data {
 		total += len(points)
 	}
+	for _, tiers := range s.rollups {
+		for _, tier := range tiers {
+			total += len(tier)
+		}
+	}
 	return total
 }
 
+// SeriesCount returns the number of series in the store
+func (s *metricsStore) SeriesCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.data)
+}
+
+// Evictions returns the number of series evicted to stay within the series limit
+func (s *metricsStore) Evictions() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.evictions
+}
+
 // Keys returns all metric keys in the store
 func (s *metricsStore) Keys() []metricKey {
 	s.mu.RLock()
@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// newPersistentMetricsStore creates a bounded metrics store with the given rollup tiers and reloads the
+// snapshot at path, if any. A missing or unreadable snapshot leaves the store empty rather than failing startup.
+func newPersistentMetricsStore(retentionPeriod time.Duration, metricRetention map[string]time.Duration, maxSeries int, tiers []rollupTier, path string) *metricsStore {
+	store := newBoundedMetricsStore(retentionPeriod, maxSeries)
+	store.metricRetention = metricRetention
+	store.tiers = tiers
+	if path == "" {
+		return store
+	}
+
+	loaded, err := store.Load(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		slog.Error("Failed to load metrics snapshot", "path", path, "error", err)
+	default:
+		slog.Info("Loaded metrics snapshot", "points", loaded, "path", path)
+	}
+
+	return store
+}
+
+// Snapshot writes all series to path. Series are copied under the read lock and encoded after it is
+// released, so writers are only blocked for the copy. The file is replaced atomically.
+func (s *metricsStore) Snapshot(path string) error {
+	s.mu.RLock()
+	entries := make([]metricsEntry, 0, len(s.data))
+	for key, points := range s.data {
+		entry := metricsEntry{
+			Key:    key,
+			Points: append([]models.DataPoint(nil), points...),
+		}
+		for _, tier := range s.rollups[key] {
+			entry.Rollups = append(entry.Rollups, append([]rollupPoint(nil), tier...))
+		}
+		entries = append(entries, entry)
+	}
+	s.mu.RUnlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(entries); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Load adds the series from a snapshot at path to the store, dropping points older than the
+// retention period of their metric. It returns the number of points loaded.
+func (s *metricsStore) Load(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var entries []metricsEntry
+	if err := gob.NewDecoder(file).Decode(&entries); err != nil {
+		return 0, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	return s.loadEntries(entries), nil
+}
+
+// loadEntries adds series to the store, dropping points older than the retention period of their
+// metric. Aggregates of tiers the store doesn't have are dropped too. It returns the number of
+// points and aggregates loaded.
+func (s *metricsStore) loadEntries(entries []metricsEntry) int {
+	now := time.Now()
+	loaded := 0
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		cutoff := now.Add(-s.retentionFor(entry.Key.Metric))
+		var kept []models.DataPoint
+		for _, point := range entry.Points {
+			if point.Timestamp.After(cutoff) {
+				kept = append(kept, point)
+			}
+		}
+		var keptRollups [][]rollupPoint
+		rolledUp := 0
+		for i, tier := range entry.Rollups {
+			if i >= len(s.tiers) {
+				break
+			}
+			var keptAggregates []rollupPoint
+			for _, aggregate := range tier {
+				if aggregate.Timestamp.After(cutoff) {
+					keptAggregates = append(keptAggregates, aggregate)
+				}
+			}
+			keptRollups = append(keptRollups, keptAggregates)
+			rolledUp += len(keptAggregates)
+		}
+		if len(kept) == 0 && rolledUp == 0 {
+			continue
+		}
+
+		s.touch(entry.Key)
+		s.data[entry.Key] = append(s.data[entry.Key], kept...)
+		if rolledUp > 0 {
+			tiers := s.seriesRollups(entry.Key)
+			for i, aggregates := range keptRollups {
+				tiers[i] = mergeRollups(tiers[i], aggregates)
+			}
+		}
+		loaded += len(kept) + rolledUp
+	}
+
+	return loaded
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// queryCache memoizes deployment pod lists and time series reads for a short TTL so that
+// back-to-back analyses of the same deployment share one collection pass
+type queryCache struct {
+	ttl    time.Duration
+	mu     sync.Mutex
+	pods   map[string]cachedPods
+	series map[string]cachedSeries
+}
+
+// cachedPods is a memoized pod list
+type cachedPods struct {
+	pods    []corev1.Pod
+	expires time.Time
+}
+
+// cachedSeries is a memoized time series read
+type cachedSeries struct {
+	data    models.TimeSeriesData
+	expires time.Time
+}
+
+// newQueryCache creates a query cache; a ttl of 0 disables caching
+func newQueryCache(ttl time.Duration) *queryCache {
+	return &queryCache{
+		ttl:    ttl,
+		pods:   make(map[string]cachedPods),
+		series: make(map[string]cachedSeries),
+	}
+}
+
+// getPods returns a copy of the cached pod list for key, if still fresh
+func (q *queryCache) getPods(key string) ([]corev1.Pod, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.pods[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return append([]corev1.Pod(nil), entry.pods...), true
+}
+
+// putPods caches a copy of a pod list under key
+func (q *queryCache) putPods(key string, pods []corev1.Pod) {
+	if q.ttl <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pods[key] = cachedPods{pods: append([]corev1.Pod(nil), pods...), expires: time.Now().Add(q.ttl)}
+}
+
+// getSeries returns a copy of the cached series for key, if still fresh
+func (q *queryCache) getSeries(key string) (models.TimeSeriesData, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.series[key]
+	if !ok || time.Now().After(entry.expires) {
+		return models.TimeSeriesData{}, false
+	}
+	data := entry.data
+	data.Points = append([]models.DataPoint(nil), entry.data.Points...)
+	return data, true
+}
+
+// putSeries caches a copy of a series under key
+func (q *queryCache) putSeries(key string, data models.TimeSeriesData) {
+	if q.ttl <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	data.Points = append([]models.DataPoint(nil), data.Points...)
+	q.series[key] = cachedSeries{data: data, expires: time.Now().Add(q.ttl)}
+}
+
+// clear drops every cached entry
+func (q *queryCache) clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pods = make(map[string]cachedPods)
+	q.series = make(map[string]cachedSeries)
+}
+
+// seriesCacheKey identifies a time series read
+func seriesCacheKey(resource, metric string, duration time.Duration) string {
+	return fmt.Sprintf("%s/%s/%s", resource, metric, duration)
+}
+
+// ListDeploymentPods lists the pods owned by a deployment, reusing a list fetched within QueryCacheTTL
+func (c *Collector) ListDeploymentPods(deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	key := fmt.Sprintf("%s/%s", deployment.Namespace, deployment.Name)
+	if pods, ok := c.cache.getPods(key); ok {
+		return pods, nil
+	}
+
+	pods, err := c.client.DeploymentPods(context.Background(), deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.putPods(key, pods)
+	return pods, nil
+}
+
+// Refresh drops all cached pod lists and time series so the next reads go to the source
+func (c *Collector) Refresh() {
+	c.cache.clear()
+}
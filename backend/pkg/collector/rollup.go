@@ -0,0 +1,266 @@
+package collector
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// rollupTier aggregates points older than After into buckets of Resolution
+type rollupTier struct {
+	After      time.Duration
+	Resolution time.Duration
+}
+
+// rollupPoint summarizes the points of one bucket, starting at Timestamp
+type rollupPoint struct {
+	Timestamp time.Time
+	Count     int
+	Min       float64
+	Avg       float64
+	Max       float64
+	P95       float64
+}
+
+// rollupTiers returns the tiers for the configured raw and per-minute retention: raw points roll up
+// into per-minute aggregates after rawRetention, and those into per-hour aggregates after
+// minuteRetention. A rawRetention of 0 disables rollups; a minuteRetention of 0 keeps per-minute
+// aggregates for the whole retention period.
+func rollupTiers(rawRetention, minuteRetention time.Duration) []rollupTier {
+	if rawRetention <= 0 {
+		return nil
+	}
+
+	tiers := []rollupTier{{After: rawRetention, Resolution: time.Minute}}
+	if minuteRetention > rawRetention {
+		tiers = append(tiers, rollupTier{After: minuteRetention, Resolution: time.Hour})
+	}
+	return tiers
+}
+
+// Rollup replaces raw points older than the first tier's age with per-bucket aggregates, and rolls each
+// tier's aggregates into the next tier once they are older than its age. Only whole buckets are rolled
+// up. It returns the number of raw points rolled up.
+func (s *metricsStore) Rollup() int {
+	if len(s.tiers) == 0 {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	rolled := 0
+
+	for key, points := range s.data {
+		tier := s.tiers[0]
+		cutoff := now.Add(-tier.After).Truncate(tier.Resolution)
+
+		var kept []models.DataPoint
+		buckets := make(map[time.Time][]float64)
+		for _, point := range points {
+			if point.Timestamp.Before(cutoff) {
+				bucket := point.Timestamp.Truncate(tier.Resolution)
+				buckets[bucket] = append(buckets[bucket], point.Value)
+				rolled++
+			} else {
+				kept = append(kept, point)
+			}
+		}
+		if len(buckets) == 0 {
+			continue
+		}
+
+		aggregates := make([]rollupPoint, 0, len(buckets))
+		for bucket, values := range buckets {
+			aggregates = append(aggregates, summarize(bucket, values))
+		}
+
+		tiers := s.seriesRollups(key)
+		tiers[0] = mergeRollups(tiers[0], aggregates)
+		s.data[key] = kept
+	}
+
+	// Roll each tier's old aggregates into the next, coarser tier
+	for key, tiers := range s.rollups {
+		for i := 1; i < len(s.tiers) && i < len(tiers); i++ {
+			tier := s.tiers[i]
+			cutoff := now.Add(-tier.After).Truncate(tier.Resolution)
+
+			var kept []rollupPoint
+			buckets := make(map[time.Time][]rollupPoint)
+			for _, aggregate := range tiers[i-1] {
+				if aggregate.Timestamp.Before(cutoff) {
+					bucket := aggregate.Timestamp.Truncate(tier.Resolution)
+					buckets[bucket] = append(buckets[bucket], aggregate)
+				} else {
+					kept = append(kept, aggregate)
+				}
+			}
+			if len(buckets) == 0 {
+				continue
+			}
+
+			aggregates := make([]rollupPoint, 0, len(buckets))
+			for bucket, parts := range buckets {
+				aggregates = append(aggregates, combineRollups(bucket, parts))
+			}
+			tiers[i-1] = kept
+			tiers[i] = mergeRollups(tiers[i], aggregates)
+		}
+		s.rollups[key] = tiers
+	}
+
+	return rolled
+}
+
+// seriesRollups returns the per-tier aggregates of a series, creating them if needed. Caller must
+// hold the write lock.
+func (s *metricsStore) seriesRollups(key metricKey) [][]rollupPoint {
+	tiers := s.rollups[key]
+	if len(tiers) < len(s.tiers) {
+		tiers = append(tiers, make([][]rollupPoint, len(s.tiers)-len(tiers))...)
+		s.rollups[key] = tiers
+	}
+	return tiers
+}
+
+// rollupsSince returns the aggregates of a series, of every tier, whose buckets start after cutoff.
+// Caller must hold the read lock.
+func (s *metricsStore) rollupsSince(key metricKey, cutoff time.Time) []rollupPoint {
+	var result []rollupPoint
+	for _, tier := range s.rollups[key] {
+		for _, aggregate := range tier {
+			if aggregate.Timestamp.After(cutoff) {
+				result = append(result, aggregate)
+			}
+		}
+	}
+	return result
+}
+
+// rolledUpUntil returns when the newest aggregate of a series ends, and the coarsest resolution of
+// its aggregates. Before that time points are bucket starts up to that resolution apart.
+func (s *metricsStore) rolledUpUntil(key metricKey) (time.Time, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var until time.Time
+	var resolution time.Duration
+	for i, tier := range s.rollups[key] {
+		if len(tier) == 0 || i >= len(s.tiers) {
+			continue
+		}
+		if end := tier[len(tier)-1].Timestamp.Add(s.tiers[i].Resolution); end.After(until) {
+			until = end
+		}
+		if s.tiers[i].Resolution > resolution {
+			resolution = s.tiers[i].Resolution
+		}
+	}
+	return until, resolution
+}
+
+// summarize aggregates the raw values of one bucket
+func summarize(bucket time.Time, values []float64) rollupPoint {
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return rollupPoint{
+		Timestamp: bucket,
+		Count:     len(values),
+		Min:       values[0],
+		Avg:       sum / float64(len(values)),
+		Max:       values[len(values)-1],
+		P95:       calculatePercentile(values, 95),
+	}
+}
+
+// combineRollups aggregates finer aggregates into one bucket. The combined P95 is the 95th
+// percentile of the parts' P95s, since the raw values are no longer available.
+func combineRollups(bucket time.Time, parts []rollupPoint) rollupPoint {
+	combined := rollupPoint{Timestamp: bucket, Min: math.Inf(1), Max: math.Inf(-1)}
+
+	sum := 0.0
+	p95s := make([]float64, len(parts))
+	for i, part := range parts {
+		combined.Count += part.Count
+		combined.Min = math.Min(combined.Min, part.Min)
+		combined.Max = math.Max(combined.Max, part.Max)
+		sum += part.Avg * float64(part.Count)
+		p95s[i] = part.P95
+	}
+
+	sort.Float64s(p95s)
+	combined.Avg = sum / float64(combined.Count)
+	combined.P95 = calculatePercentile(p95s, 95)
+	return combined
+}
+
+// mergeRollups adds aggregates to a tier sorted by bucket, combining those for a bucket already present,
+// e.g. when late samples are rolled up after their bucket
+func mergeRollups(tier, added []rollupPoint) []rollupPoint {
+	index := make(map[time.Time]int, len(tier))
+	for i, aggregate := range tier {
+		index[aggregate.Timestamp] = i
+	}
+
+	for _, aggregate := range added {
+		if i, ok := index[aggregate.Timestamp]; ok {
+			tier[i] = combineRollups(aggregate.Timestamp, []rollupPoint{tier[i], aggregate})
+			continue
+		}
+		index[aggregate.Timestamp] = len(tier)
+		tier = append(tier, aggregate)
+	}
+
+	sort.Slice(tier, func(i, j int) bool {
+		return tier[i].Timestamp.Before(tier[j].Timestamp)
+	})
+	return tier
+}
+
+// weightedSample is a value standing for weight points
+type weightedSample struct {
+	value  float64
+	weight float64
+}
+
+// rolledUpPercentile calculates a percentile over raw values and aggregates, each aggregate standing for
+// its points at the statistic stat picks. Picking a statistic at or above the percentile makes
+// percentiles over rolled-up history err high rather than low.
+func rolledUpPercentile(values []float64, aggregates []rollupPoint, percentile float64, stat func(rollupPoint) float64) float64 {
+	samples := make([]weightedSample, 0, len(values)+len(aggregates))
+	total := 0.0
+	for _, v := range values {
+		samples = append(samples, weightedSample{value: v, weight: 1})
+		total++
+	}
+	for _, aggregate := range aggregates {
+		samples = append(samples, weightedSample{value: stat(aggregate), weight: float64(aggregate.Count)})
+		total += float64(aggregate.Count)
+	}
+	if len(samples) == 0 {
+		return 0
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].value < samples[j].value
+	})
+
+	threshold := percentile / 100.0 * total
+	cumulative := 0.0
+	for _, sample := range samples {
+		cumulative += sample.weight
+		if cumulative >= threshold {
+			return sample.value
+		}
+	}
+	return samples[len(samples)-1].value
+}
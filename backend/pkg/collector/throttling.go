@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetricCPUThrottle is the share (0-1) of a pod's CFS scheduling periods in which it was throttled at
+// its CPU limit. Collected from kubelets with CollectCPUThrottling, or ingested from a metric source.
+const MetricCPUThrottle = "cpu_throttle"
+
+// cfsSample is a pod's cumulative CFS period counters, summed across its containers
+type cfsSample struct {
+	Namespace        string
+	Pod              string
+	Periods          float64
+	ThrottledPeriods float64
+}
+
+// cadvisorLabel matches a label="value" pair of a Prometheus text-format sample
+var cadvisorLabel = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// CollectCFSCounters reads per-pod CFS period counters from every node's kubelet cAdvisor endpoint
+func (c *k8sCollector) CollectCFSCounters() ([]cfsSample, error) {
+	responses, err := c.scrapeKubelets(context.Background(), "metrics/cadvisor")
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []cfsSample
+	for _, data := range responses {
+		samples = append(samples, parseCFSCounters(data)...)
+	}
+
+	return samples, nil
+}
+
+// parseCFSCounters extracts per-pod container_cpu_cfs_periods_total and
+// container_cpu_cfs_throttled_periods_total from cAdvisor metrics in the Prometheus text format.
+// Pod-level cgroups and pause containers are skipped so containers aren't counted twice.
+func parseCFSCounters(data []byte) []cfsSample {
+	pods := make(map[string]*cfsSample)
+	var order []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		name, rest, ok := strings.Cut(line, "{")
+		if !ok || (name != "container_cpu_cfs_periods_total" && name != "container_cpu_cfs_throttled_periods_total") {
+			continue
+		}
+		labelText, valueText, ok := strings.Cut(rest, "}")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(valueText)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+
+		labels := make(map[string]string)
+		for _, match := range cadvisorLabel.FindAllStringSubmatch(labelText, -1) {
+			labels[match[1]] = match[2]
+		}
+		if labels["pod"] == "" || labels["container"] == "" || labels["container"] == "POD" {
+			continue
+		}
+
+		key := labels["namespace"] + "/" + labels["pod"]
+		sample, seen := pods[key]
+		if !seen {
+			sample = &cfsSample{Namespace: labels["namespace"], Pod: labels["pod"]}
+			pods[key] = sample
+			order = append(order, key)
+		}
+		if name == "container_cpu_cfs_periods_total" {
+			sample.Periods += value
+		} else {
+			sample.ThrottledPeriods += value
+		}
+	}
+
+	samples := make([]cfsSample, 0, len(order))
+	for _, key := range order {
+		samples = append(samples, *pods[key])
+	}
+	return samples
+}
+
+// storeCPUThrottling adds the throttled share of each monitored pod's CFS periods since the previous
+// pass to a batch. Pods seen for the first time, or whose counters reset, only record their counters.
+func (c *Collector) storeCPUThrottling(batch *metricsBatch, samples []cfsSample, timestamp time.Time) {
+	monitored := make(map[string]bool, len(c.namespaces))
+	for _, namespace := range c.namespaces {
+		monitored[namespace] = true
+	}
+
+	previous := c.cfsCounters
+	c.cfsCounters = make(map[string]cfsSample, len(samples))
+	for _, sample := range samples {
+		if !monitored[sample.Namespace] {
+			continue
+		}
+		key := sample.Namespace + "/" + sample.Pod
+		c.cfsCounters[key] = sample
+
+		prev, ok := previous[key]
+		if !ok {
+			continue
+		}
+		periods := sample.Periods - prev.Periods
+		throttled := sample.ThrottledPeriods - prev.ThrottledPeriods
+		if periods <= 0 || throttled < 0 {
+			continue
+		}
+		batch.add(fmt.Sprintf("pod/%s", sample.Pod), MetricCPUThrottle, throttled/periods, timestamp)
+	}
+}
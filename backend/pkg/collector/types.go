@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/k8s-service-optimizer/backend/internal/models"
@@ -26,6 +27,10 @@ type MetricsCollector interface {
 	// GetTimeSeriesData retrieves time-series data for a resource/metric
 	GetTimeSeriesData(resource, metric string, duration time.Duration) (models.TimeSeriesData, error)
 
+	// GetTimeSeriesDataWithGaps retrieves time-series data with gap markers
+	// inserted wherever collection stalled
+	GetTimeSeriesDataWithGaps(resource, metric string, duration time.Duration) (models.TimeSeriesData, error)
+
 	// GetResourcePercentiles calculates percentiles for a resource metric
 	GetResourcePercentiles(resource, metric string, duration time.Duration) (p50, p95, p99 float64, err error)
 }
@@ -38,19 +43,97 @@ type Config struct {
 	// RetentionPeriod is how long to keep metrics in memory
 	RetentionPeriod time.Duration
 
+	// MetricRetention overrides RetentionPeriod for individual metrics, e.g. keeping cheap
+	// "current_replicas" samples for weeks while high-resolution "cpu" expires sooner
+	MetricRetention map[string]time.Duration
+
 	// CleanupInterval is how often to run cleanup of old data
 	CleanupInterval time.Duration
+
+	// MaxCollectionBackoff caps the collection interval while the metrics API is unavailable: the
+	// interval doubles after every pass that finds it missing, up to this value, and returns to
+	// CollectionInterval once it answers again. Values at or below CollectionInterval disable backoff.
+	MaxCollectionBackoff time.Duration
+
+	// GapThreshold is the multiple of CollectionInterval after which the
+	// space between two points is reported as a gap
+	GapThreshold float64
+
+	// MaxSeries caps the number of stored series; when reached, the series written to least
+	// recently is evicted to make room. 0 disables the limit.
+	MaxSeries int
+
+	// CollectWorkingSet additionally reads per-pod working-set memory and transmitted bytes from each
+	// node's kubelet summary API (requires nodes/proxy access) and stores them as "memory_working_set"
+	// and the cumulative "network_tx_bytes"
+	CollectWorkingSet bool
+
+	// CollectCPUThrottling additionally reads CFS period counters from each node's kubelet cAdvisor
+	// endpoint (requires nodes/proxy access) and stores the throttled share of each pod's periods
+	// between collection passes as "cpu_throttle"
+	CollectCPUThrottling bool
+
+	// PersistencePath is a file the store is snapshotted to every PersistInterval and on Stop, and
+	// reloaded from on startup so restarts keep their history. Empty disables persistence.
+	PersistencePath string
+
+	// PersistInterval is how often to snapshot the store when PersistencePath is set
+	PersistInterval time.Duration
+
+	// SnapshotDir is a directory that compressed, self-describing JSON archives of the store are
+	// exported to every SnapshotInterval, for archival beyond RetentionPeriod. Empty disables export.
+	SnapshotDir string
+
+	// SnapshotInterval is how often to export an archive when SnapshotDir is set
+	SnapshotInterval time.Duration
+
+	// SnapshotPerNamespace exports one archive of pod series per monitored namespace instead of
+	// one archive of the whole store
+	SnapshotPerNamespace bool
+
+	// RawRetention is how long raw points are kept before being rolled up into per-minute
+	// aggregates (min, avg, max and p95), bounding memory whatever the retention period.
+	// 0 keeps raw points for the whole retention period.
+	RawRetention time.Duration
+
+	// MinuteRetention is how long per-minute aggregates are kept before being rolled up
+	// into per-hour aggregates. 0 keeps them for the whole retention period.
+	MinuteRetention time.Duration
+
+	// QueryCacheTTL is how long deployment pod lists and time series reads are reused, so that
+	// analyses of the same deployment in quick succession share one collection pass. Cached
+	// entries are also dropped after every collection pass. 0 disables the cache.
+	QueryCacheTTL time.Duration
 }
 
 // DefaultConfig returns default collector configuration
 func DefaultConfig() Config {
 	return Config{
-		CollectionInterval: 15 * time.Second,
-		RetentionPeriod:    24 * time.Hour,
-		CleanupInterval:    1 * time.Hour,
+		CollectionInterval:   15 * time.Second,
+		RetentionPeriod:      24 * time.Hour,
+		CleanupInterval:      1 * time.Hour,
+		MaxCollectionBackoff: 5 * time.Minute,
+		GapThreshold:         3,
+		MaxSeries:            50000,
+		PersistInterval:      5 * time.Minute,
+		SnapshotInterval:     time.Hour,
+		QueryCacheTTL:        10 * time.Second,
+		RawRetention:         time.Hour,
+		MinuteRetention:      24 * time.Hour,
 	}
 }
 
+// Metric keys for measured application traffic, recorded with RecordTraffic
+const (
+	MetricRequests  = "requests"   // request rate, in requests per second
+	MetricLatencyMs = "latency_ms" // request latency, in milliseconds
+)
+
+// ContainerResource returns the store resource name for a container's metrics
+func ContainerResource(pod, container string) string {
+	return fmt.Sprintf("pod/%s/container/%s", pod, container)
+}
+
 // metricKey represents a unique identifier for a metric
 type metricKey struct {
 	Resource string // e.g., "pod/echo-demo-xxx", "node/worker-1"
@@ -59,6 +142,18 @@ type metricKey struct {
 
 // metricsEntry stores time-series data for a specific metric
 type metricsEntry struct {
-	Key    metricKey
-	Points []models.DataPoint
+	Key     metricKey
+	Points  []models.DataPoint
+	Rollups [][]rollupPoint // rolled-up aggregates per tier, finest first
+}
+
+// metricsBatch accumulates points to be written with a single StoreBatch call
+type metricsBatch []metricsEntry
+
+// add appends a data point to the batch
+func (b *metricsBatch) add(resource, metric string, value float64, timestamp time.Time) {
+	*b = append(*b, metricsEntry{
+		Key:    metricKey{Resource: resource, Metric: metric},
+		Points: []models.DataPoint{{Timestamp: timestamp, Value: value}},
+	})
 }
@@ -0,0 +1,98 @@
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// advisoryAnnotationPrefix prefixes every advisory annotation written to a deployment
+const advisoryAnnotationPrefix = "optimizer.k8s.io/recommended-"
+
+// advisoryAnnotationNames maps recommended resource config keys to advisory annotation names
+var advisoryAnnotationNames = map[string]string{
+	"cpu_request":    "cpu",
+	"cpu_limit":      "cpu-limit",
+	"memory_request": "memory",
+	"memory_limit":   "memory-limit",
+	"gpu":            "gpu",
+}
+
+// advisoryAnnotations returns the advisory annotations describing the recommended resources of a
+// deployment. Container-scoped recommendations are suffixed with the container name.
+func advisoryAnnotations(recommendations []models.Recommendation) map[string]string {
+	annotations := make(map[string]string)
+	for _, rec := range recommendations {
+		if rec.Type != string(RecommendationTypeResource) {
+			continue
+		}
+		config, ok := rec.RecommendedConfig.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for key, value := range config {
+			name, ok := advisoryAnnotationNames[key]
+			if !ok {
+				continue
+			}
+			key := advisoryAnnotationPrefix + name
+			if rec.Container != "" {
+				key += "." + rec.Container
+			}
+			annotations[key] = fmt.Sprint(value)
+		}
+	}
+	return annotations
+}
+
+// writeAdvisoryAnnotations records the current recommendations as annotations on the deployment without
+// touching its spec. Advisory annotations that are no longer recommended are removed, and nothing is
+// written when the annotations are already up to date.
+func (opt *OptimizerEngine) writeAdvisoryAnnotations(namespace, name string, recommendations []models.Recommendation) error {
+	ctx := context.Background()
+	deployments := opt.k8sClient.Clientset.AppsV1().Deployments(namespace)
+
+	deployment, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	desired := advisoryAnnotations(recommendations)
+	current := make(map[string]string)
+	for key, value := range deployment.Annotations {
+		if strings.HasPrefix(key, advisoryAnnotationPrefix) {
+			current[key] = value
+		}
+	}
+	if maps.Equal(current, desired) {
+		return nil
+	}
+
+	// A null value removes the annotation in a merge patch
+	annotations := make(map[string]interface{}, len(desired)+len(current))
+	for key := range current {
+		annotations[key] = nil
+	}
+	for key, value := range desired {
+		annotations[key] = value
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal annotation patch: %w", err)
+	}
+
+	if _, err := deployments.Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch deployment annotations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,451 @@
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// resourceConfigKeys maps recommended resource config keys to the container resource they set
+var resourceConfigKeys = []struct {
+	key      string
+	limit    bool
+	resource corev1.ResourceName
+}{
+	{"cpu_request", false, corev1.ResourceCPU},
+	{"cpu_limit", true, corev1.ResourceCPU},
+	{"memory_request", false, corev1.ResourceMemory},
+	{"memory_limit", true, corev1.ResourceMemory},
+	{"gpu", false, gpuResourceName},
+	{"gpu", true, gpuResourceName},
+}
+
+// buildRecommendationPatch computes the patch that applies a recommendation to its target object
+func (opt *OptimizerEngine) buildRecommendationPatch(rec *models.Recommendation) (*models.RecommendationPatch, error) {
+	config, ok := rec.RecommendedConfig.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("recommendation %s has no recommended configuration", rec.ID)
+	}
+	return opt.buildConfigPatch(rec, config)
+}
+
+// buildConfigPatch computes the patch that sets a recommendation's target object to config
+func (opt *OptimizerEngine) buildConfigPatch(rec *models.Recommendation, config map[string]interface{}) (*models.RecommendationPatch, error) {
+	switch recommendationType(rec.Type) {
+	case RecommendationTypeResource:
+		return opt.buildResourcePatch(rec, config)
+	case RecommendationTypeHPA:
+		return opt.buildHPAPatch(rec, config)
+	default:
+		return nil, fmt.Errorf("%s recommendations cannot be applied automatically", rec.Type)
+	}
+}
+
+// recommendationKind returns the kind of workload a recommendation targets. Recommendations
+// without a kind predate it and target Deployments.
+func recommendationKind(rec *models.Recommendation) string {
+	if rec.Kind == "" {
+		return WorkloadKindDeployment
+	}
+	return rec.Kind
+}
+
+// buildResourcePatch computes a strategic merge patch setting the target container's resources in
+// the pod template of the recommendation's workload. Keys mapped to nil remove that request or limit.
+func (opt *OptimizerEngine) buildResourcePatch(rec *models.Recommendation, config map[string]interface{}) (*models.RecommendationPatch, error) {
+	w, err := opt.analyzer.getWorkload(rec.Namespace, recommendationKind(rec), rec.Deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := opt.targetContainer(w, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make(map[corev1.ResourceName]interface{})
+	limits := make(map[corev1.ResourceName]interface{})
+	for _, field := range resourceConfigKeys {
+		raw, present := config[field.key]
+		if !present {
+			continue
+		}
+
+		var value interface{}
+		if raw != nil {
+			str, ok := raw.(string)
+			if !ok || str == "" {
+				continue
+			}
+			quantity, err := resource.ParseQuantity(str)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", field.key, str, err)
+			}
+			// A zero limit means no limit was recommended
+			if quantity.IsZero() {
+				continue
+			}
+			value = quantity.String()
+		}
+
+		if field.limit {
+			limits[field.resource] = value
+		} else {
+			requests[field.resource] = value
+		}
+	}
+	if len(requests) == 0 && len(limits) == 0 {
+		return nil, fmt.Errorf("recommendation %s does not change any resources", rec.ID)
+	}
+
+	resources := make(map[string]interface{})
+	if len(requests) > 0 {
+		resources["requests"] = requests
+	}
+	if len(limits) > 0 {
+		resources["limits"] = limits
+	}
+
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []map[string]interface{}{{
+						"name":      container.Name,
+						"resources": resources,
+					}},
+				},
+			},
+		},
+	}
+
+	return newRecommendationPatch(rec, w.Kind, w.Name, patch)
+}
+
+// buildHPAPatch computes a strategic merge patch setting the replica bounds and CPU target of the
+// HPA scaling the recommendation's workload
+func (opt *OptimizerEngine) buildHPAPatch(rec *models.Recommendation, config map[string]interface{}) (*models.RecommendationPatch, error) {
+	hpa, err := opt.findWorkloadHPA(rec.Namespace, recommendationKind(rec), rec.Deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := make(map[string]interface{})
+	if minReplicas, ok := configInt32(config, "min_replicas"); ok {
+		spec["minReplicas"] = minReplicas
+	}
+	if maxReplicas, ok := configInt32(config, "max_replicas"); ok {
+		spec["maxReplicas"] = maxReplicas
+	}
+	if targetCPU, ok := configInt32(config, "target_cpu"); ok && targetCPU > 0 {
+		// HPAs that don't scale on CPU carry a zero target, which is left alone. HPA metrics have no
+		// merge key, so the patch carries the full list with the CPU target updated
+		metrics := make([]autoscalingv2.MetricSpec, 0, len(hpa.Spec.Metrics)+1)
+		found := false
+		for _, metric := range hpa.Spec.Metrics {
+			metric = *metric.DeepCopy()
+			if metric.Resource != nil && metric.Resource.Name == corev1.ResourceCPU {
+				metric.Resource.Target.Type = autoscalingv2.UtilizationMetricType
+				metric.Resource.Target.AverageUtilization = &targetCPU
+				found = true
+			}
+			metrics = append(metrics, metric)
+		}
+		if !found {
+			metrics = append(metrics, autoscalingv2.MetricSpec{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name: corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{
+						Type:               autoscalingv2.UtilizationMetricType,
+						AverageUtilization: &targetCPU,
+					},
+				},
+			})
+		}
+		spec["metrics"] = metrics
+	}
+	if name, target, ok := configMetricTarget(config); ok {
+		// Other metrics keep their target type; only the value moves
+		base := hpa.Spec.Metrics
+		if current, ok := spec["metrics"].([]autoscalingv2.MetricSpec); ok {
+			base = current
+		}
+		metrics := make([]autoscalingv2.MetricSpec, 0, len(base))
+		found := false
+		for _, metric := range base {
+			metric = *metric.DeepCopy()
+			if k8s.HPAMetricName(metric) == name {
+				found = setMetricTarget(k8s.HPAMetricTarget(&metric), target)
+			}
+			metrics = append(metrics, metric)
+		}
+		if !found {
+			return nil, fmt.Errorf("HPA %s/%s does not scale on metric %s", hpa.Namespace, hpa.Name, name)
+		}
+		spec["metrics"] = metrics
+	}
+	if len(spec) == 0 {
+		return nil, fmt.Errorf("recommendation %s does not change the HPA", rec.ID)
+	}
+
+	return newRecommendationPatch(rec, "HorizontalPodAutoscaler", hpa.Name, map[string]interface{}{"spec": spec})
+}
+
+// targetContainer returns the container a resource recommendation applies to: the named container
+// for container-scoped recommendations, otherwise the only analyzed container. Deployment-level
+// recommendations of pods with several app containers size the containers' summed requests, which
+// can't be set on any one of them, so they are refused in favor of the per-container recommendations.
+func (opt *OptimizerEngine) targetContainer(w *workload, rec *models.Recommendation) (*corev1.Container, error) {
+	kind := strings.ToLower(w.Kind)
+	containers := w.Template.Spec.Containers
+	if rec.Container != "" {
+		for i := range containers {
+			if containers[i].Name == rec.Container {
+				return &containers[i], nil
+			}
+		}
+		return nil, fmt.Errorf("container %s not found in %s %s/%s", rec.Container, kind, rec.Namespace, rec.Deployment)
+	}
+
+	appContainers, _ := opt.analyzer.partitionContainers(containers)
+	switch len(appContainers) {
+	case 0:
+		return nil, fmt.Errorf("%s %s/%s has no containers to update", kind, rec.Namespace, rec.Deployment)
+	case 1:
+		return &appContainers[0], nil
+	default:
+		return nil, fmt.Errorf("recommendation %s sizes the %d app containers of %s %s/%s together and cannot be applied to one of them; apply the per-container recommendations instead",
+			rec.ID, len(appContainers), kind, rec.Namespace, rec.Deployment)
+	}
+}
+
+// findWorkloadHPA returns the HPA scaling a workload of the given kind
+func (opt *OptimizerEngine) findWorkloadHPA(namespace, kind, name string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpaList, err := opt.k8sClient.Clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list HPAs: %w", err)
+	}
+
+	for i := range hpaList.Items {
+		target := hpaList.Items[i].Spec.ScaleTargetRef
+		if target.Name == name && (target.Kind == "" || target.Kind == kind) {
+			return &hpaList.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no HPA found for %s %s/%s", strings.ToLower(kind), namespace, name)
+}
+
+// liveConfig reads the live configuration a recommendation targets, in the same form as RecommendedConfig
+func (opt *OptimizerEngine) liveConfig(rec *models.Recommendation) (map[string]interface{}, error) {
+	switch recommendationType(rec.Type) {
+	case RecommendationTypeResource:
+		w, err := opt.analyzer.getWorkload(rec.Namespace, recommendationKind(rec), rec.Deployment)
+		if err != nil {
+			return nil, err
+		}
+		container, err := opt.targetContainer(w, rec)
+		if err != nil {
+			return nil, err
+		}
+
+		config := make(map[string]interface{})
+		for _, field := range resourceConfigKeys {
+			list := container.Resources.Requests
+			if field.limit {
+				list = container.Resources.Limits
+			}
+			if quantity, ok := list[field.resource]; ok {
+				config[field.key] = quantity.String()
+			}
+		}
+		return config, nil
+
+	case RecommendationTypeHPA:
+		hpa, err := opt.findWorkloadHPA(rec.Namespace, recommendationKind(rec), rec.Deployment)
+		if err != nil {
+			return nil, err
+		}
+
+		config := map[string]interface{}{"max_replicas": hpa.Spec.MaxReplicas}
+		if hpa.Spec.MinReplicas != nil {
+			config["min_replicas"] = *hpa.Spec.MinReplicas
+		}
+		for _, metric := range hpa.Spec.Metrics {
+			if metric.Resource != nil && metric.Resource.Name == corev1.ResourceCPU && metric.Resource.Target.AverageUtilization != nil {
+				config["target_cpu"] = *metric.Resource.Target.AverageUtilization
+			}
+		}
+		// Capture the target of the other metric the recommendation adjusts, if any
+		if recommended, ok := rec.RecommendedConfig.(map[string]interface{}); ok {
+			if name, _, ok := configMetricTarget(recommended); ok {
+				for _, target := range k8s.HPAMetricTargets(hpa) {
+					if target.Name == name {
+						config["metric"] = name
+						config["target"] = target.Target
+					}
+				}
+			}
+		}
+		return config, nil
+
+	default:
+		return nil, fmt.Errorf("%s recommendations cannot be applied automatically", rec.Type)
+	}
+}
+
+// sendRecommendationPatch applies a computed patch to the cluster
+func (opt *OptimizerEngine) sendRecommendationPatch(patch *models.RecommendationPatch) error {
+	ctx := context.Background()
+	data := []byte(patch.Patch)
+
+	var err error
+	switch patch.Kind {
+	case WorkloadKindDeployment:
+		_, err = opt.k8sClient.Clientset.AppsV1().Deployments(patch.Namespace).Patch(ctx, patch.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{})
+	case WorkloadKindStatefulSet:
+		_, err = opt.k8sClient.Clientset.AppsV1().StatefulSets(patch.Namespace).Patch(ctx, patch.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{})
+	case WorkloadKindDaemonSet:
+		_, err = opt.k8sClient.Clientset.AppsV1().DaemonSets(patch.Namespace).Patch(ctx, patch.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{})
+	case "HorizontalPodAutoscaler":
+		_, err = opt.k8sClient.Clientset.AutoscalingV2().HorizontalPodAutoscalers(patch.Namespace).Patch(ctx, patch.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{})
+	default:
+		return fmt.Errorf("unsupported patch target kind %s", patch.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to patch %s %s/%s: %w", patch.Kind, patch.Namespace, patch.Name, err)
+	}
+	return nil
+}
+
+// newRecommendationPatch encodes a patch body for the given target object
+func newRecommendationPatch(rec *models.Recommendation, kind, name string, body map[string]interface{}) (*models.RecommendationPatch, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patch: %w", err)
+	}
+
+	return &models.RecommendationPatch{
+		RecommendationID: rec.ID,
+		Kind:             kind,
+		Namespace:        rec.Namespace,
+		Name:             name,
+		PatchType:        string(types.StrategicMergePatchType),
+		Patch:            string(data),
+	}, nil
+}
+
+// configInt32 reads an integer value from a recommended config map. Values decoded from JSON
+// arrive as float64.
+func configInt32(config map[string]interface{}, key string) (int32, bool) {
+	switch v := config[key].(type) {
+	case int32:
+		return v, true
+	case int:
+		return int32(v), true
+	case int64:
+		return int32(v), true
+	case float64:
+		return int32(v), true
+	default:
+		return 0, false
+	}
+}
+
+// configMetricTarget returns the metric name and target value an HPA config adjusts, if any
+func configMetricTarget(config map[string]interface{}) (string, float64, bool) {
+	name, ok := config["metric"].(string)
+	if !ok || name == "" {
+		return "", 0, false
+	}
+	switch v := config["target"].(type) {
+	case float64:
+		return name, v, true
+	case int32:
+		return name, float64(v), true
+	case int:
+		return name, float64(v), true
+	default:
+		return "", 0, false
+	}
+}
+
+// setMetricTarget sets the value of an HPA metric target, keeping its type. It reports whether the
+// target type could hold the value.
+func setMetricTarget(target *autoscalingv2.MetricTarget, value float64) bool {
+	if target == nil {
+		return false
+	}
+	switch target.Type {
+	case autoscalingv2.UtilizationMetricType:
+		utilization := int32(math.Round(value))
+		target.AverageUtilization = &utilization
+	case autoscalingv2.AverageValueMetricType:
+		target.AverageValue = resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+	case autoscalingv2.ValueMetricType:
+		target.Value = resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+	default:
+		return false
+	}
+	return true
+}
+
+// isAppliedDuplicate reports whether a new recommendation repeats one that was already applied
+func isAppliedDuplicate(rec models.Recommendation, existing map[string]models.Recommendation) bool {
+	for _, other := range existing {
+		if other.AppliedAt.IsZero() {
+			continue
+		}
+		if other.Namespace == rec.Namespace && other.Deployment == rec.Deployment && other.Container == rec.Container && other.Type == rec.Type &&
+			reflect.DeepEqual(other.RecommendedConfig, rec.RecommendedConfig) {
+			return true
+		}
+	}
+	return false
+}
+
+// markApplied records when a recommendation was applied along with the configuration before and after
+func (opt *OptimizerEngine) markApplied(id string, appliedAt time.Time, previous, applied map[string]interface{}) {
+	opt.recommendationsMu.Lock()
+	defer opt.recommendationsMu.Unlock()
+
+	if rec, ok := opt.recommendations[id]; ok {
+		rec.AppliedAt = appliedAt
+		rec.PreviousConfig = previous
+		rec.AppliedConfig = applied
+		rec.RevertedAt = time.Time{}
+		opt.recommendations[id] = rec
+	}
+}
+
+// markReverted records that an applied recommendation was undone
+func (opt *OptimizerEngine) markReverted(id string, revertedAt time.Time) {
+	opt.recommendationsMu.Lock()
+	defer opt.recommendationsMu.Unlock()
+
+	if rec, ok := opt.recommendations[id]; ok {
+		rec.AppliedAt = time.Time{}
+		rec.RevertedAt = revertedAt
+		opt.recommendations[id] = rec
+	}
+}
+
+// invalidateAnalysis drops the cached analysis of a workload whose configuration changed
+func (opt *OptimizerEngine) invalidateAnalysis(namespace, kind, name string) {
+	opt.analysisCacheMu.Lock()
+	defer opt.analysisCacheMu.Unlock()
+
+	delete(opt.analysisCache, analysisCacheKey(namespace, kind, name))
+}
@@ -0,0 +1,20 @@
+package optimizer
+
+import "github.com/k8s-service-optimizer/backend/internal/models"
+
+// setBreakEven computes how many days of a recommendation's estimated savings pay back the
+// one-off cost of rolling it out. Recommendations without savings, or with no apply cost
+// configured, are left at zero.
+func (opt *OptimizerEngine) setBreakEven(recommendations []models.Recommendation) {
+	for i := range recommendations {
+		recommendations[i].BreakEvenDays = breakEvenDays(recommendations[i].EstimatedSavings, opt.cfg().ApplyCostEstimate)
+	}
+}
+
+// breakEvenDays divides the apply cost by the daily share of the monthly savings
+func breakEvenDays(monthlySavings, applyCost float64) float64 {
+	if monthlySavings <= 0 || applyCost <= 0 {
+		return 0
+	}
+	return applyCost / (monthlySavings / 30)
+}
@@ -0,0 +1,47 @@
+package optimizer
+
+import "sync"
+
+// analysisCall is an in-flight analysis whose result is shared by all callers
+type analysisCall struct {
+	wg     sync.WaitGroup
+	result *analysisResult
+	err    error
+}
+
+// analysisGroup coalesces concurrent analyses of the same deployment so that
+// only the first caller does the work and the rest wait for its result
+type analysisGroup struct {
+	mu    sync.Mutex
+	calls map[string]*analysisCall
+}
+
+// do runs fn once per key at a time; callers arriving while fn is running for
+// the same key receive the in-flight result instead of starting a new run. The
+// call is released even if fn panics, so waiters are never left blocked.
+func (g *analysisGroup) do(key string, fn func() (*analysisResult, error)) (*analysisResult, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*analysisCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.result, call.err
+	}
+
+	call := &analysisCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		call.wg.Done()
+	}()
+
+	call.result, call.err = fn()
+	return call.result, call.err
+}
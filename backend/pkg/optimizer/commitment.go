@@ -0,0 +1,107 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clusterRequestedCapacity sums the effective CPU (millicores) and memory (bytes) requests
+// of all running pods in the cluster
+func (opt *OptimizerEngine) clusterRequestedCapacity() (cpuMillis int64, memoryBytes int64, err error) {
+	ctx := context.Background()
+
+	pods, err := opt.k8sClient.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			cpuMillis += container.Resources.Requests.Cpu().MilliValue()
+			memoryBytes += container.Resources.Requests.Memory().Value()
+		}
+		cpuMillis += pod.Spec.Overhead.Cpu().MilliValue()
+		memoryBytes += pod.Spec.Overhead.Memory().Value()
+	}
+
+	return cpuMillis, memoryBytes, nil
+}
+
+// uncommittedMonthlyCost returns the monthly cost of requested capacity above the commitment,
+// which is the most cash that right-sizing can save
+func (opt *OptimizerEngine) uncommittedMonthlyCost() (float64, error) {
+	cpuMillis, memoryBytes, err := opt.clusterRequestedCapacity()
+	if err != nil {
+		return 0, err
+	}
+
+	committed := opt.cfg().CommittedCapacity
+	excessCPU := cpuMillis - committed.CPUMillis
+	if excessCPU < 0 {
+		excessCPU = 0
+	}
+	excessMemory := memoryBytes - committed.MemoryBytes
+	if excessMemory < 0 {
+		excessMemory = 0
+	}
+
+	return opt.recommendationGen.calculateCPUCost(excessCPU) + opt.recommendationGen.calculateMemoryCost(excessMemory), nil
+}
+
+// splitCommittedSavings divides each recommendation's estimated savings into cash savings and
+// reclaimable capacity. Cash is allocated largest-savings first from the uncommitted spend left
+// after recommendations already stored for other deployments have claimed their share.
+func (opt *OptimizerEngine) splitCommittedSavings(recommendations []models.Recommendation) {
+	if opt.cfg().CommittedCapacity.IsZero() {
+		for i := range recommendations {
+			recommendations[i].CashSavings = recommendations[i].EstimatedSavings
+			recommendations[i].ReclaimableSavings = 0
+		}
+		return
+	}
+
+	remaining, err := opt.uncommittedMonthlyCost()
+	if err != nil {
+		// Without cluster totals, report everything as reclaimable rather than overstate cash savings
+		remaining = 0
+	}
+
+	// Recommendations for these deployments are being replaced, so their old claims don't count
+	replaced := make(map[string]bool)
+	for _, rec := range recommendations {
+		replaced[rec.Namespace+"/"+rec.Deployment] = true
+	}
+
+	opt.recommendationsMu.RLock()
+	for _, rec := range opt.recommendations {
+		if !replaced[rec.Namespace+"/"+rec.Deployment] {
+			remaining -= rec.CashSavings
+		}
+	}
+	opt.recommendationsMu.RUnlock()
+
+	order := make([]int, len(recommendations))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return recommendations[order[a]].EstimatedSavings > recommendations[order[b]].EstimatedSavings
+	})
+
+	for _, i := range order {
+		rec := &recommendations[i]
+		cash := math.Max(0, math.Min(rec.EstimatedSavings, remaining))
+		rec.CashSavings = cash
+		rec.ReclaimableSavings = math.Max(0, rec.EstimatedSavings-cash)
+		remaining -= cash
+	}
+}
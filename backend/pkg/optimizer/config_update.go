@@ -0,0 +1,150 @@
+package optimizer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
+)
+
+// ConfigUpdate lists the configuration fields that are safe to change at runtime. Nil fields are
+// left unchanged.
+type ConfigUpdate struct {
+	CPUOverProvisionedThreshold     *float64
+	MemoryOverProvisionedThreshold  *float64
+	CPUUnderProvisionedThreshold    *float64
+	MemoryUnderProvisionedThreshold *float64
+	OptimalUtilizationMin           *float64
+	OptimalUtilizationMax           *float64
+	OverProvisionedBuffer           *float64
+	UnderProvisionedBuffer          *float64
+
+	// CPUCostPerVCPUHour and MemoryCostPerGBHour replace the default rates of the pricing provider in
+	// place, so an analyzer sharing it prices with them too; per-node rates are kept. They require a
+	// pricing.AdjustableProvider.
+	CPUCostPerVCPUHour  *float64
+	MemoryCostPerGBHour *float64
+	GPUCostPerHour      *float64
+}
+
+// apply returns a copy of config with the update applied
+func (u ConfigUpdate) apply(config Config) Config {
+	set := func(field *float64, value *float64) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	set(&config.CPUOverProvisionedThreshold, u.CPUOverProvisionedThreshold)
+	set(&config.MemoryOverProvisionedThreshold, u.MemoryOverProvisionedThreshold)
+	set(&config.CPUUnderProvisionedThreshold, u.CPUUnderProvisionedThreshold)
+	set(&config.MemoryUnderProvisionedThreshold, u.MemoryUnderProvisionedThreshold)
+	set(&config.OptimalUtilizationMin, u.OptimalUtilizationMin)
+	set(&config.OptimalUtilizationMax, u.OptimalUtilizationMax)
+	set(&config.OverProvisionedBuffer, u.OverProvisionedBuffer)
+	set(&config.UnderProvisionedBuffer, u.UnderProvisionedBuffer)
+	set(&config.GPUCostPerHour, u.GPUCostPerHour)
+	return config
+}
+
+// rates returns the pricing provider's default rates with the update applied, and whether the
+// update changes them at all
+func (u ConfigUpdate) rates(provider pricing.PricingProvider) (pricing.Rates, bool) {
+	rates := pricing.NodeRates(provider, "")
+	if u.CPUCostPerVCPUHour == nil && u.MemoryCostPerGBHour == nil {
+		return rates, false
+	}
+	if u.CPUCostPerVCPUHour != nil {
+		rates.CPUCostPerVCPUHour = *u.CPUCostPerVCPUHour
+	}
+	if u.MemoryCostPerGBHour != nil {
+		rates.MemoryCostPerGBHour = *u.MemoryCostPerGBHour
+	}
+	return rates, true
+}
+
+// validateTunables checks the runtime-tunable fields of a configuration
+func validateTunables(config Config) error {
+	fractions := []struct {
+		name  string
+		value float64
+	}{
+		{"CPUOverProvisionedThreshold", config.CPUOverProvisionedThreshold},
+		{"MemoryOverProvisionedThreshold", config.MemoryOverProvisionedThreshold},
+		{"CPUUnderProvisionedThreshold", config.CPUUnderProvisionedThreshold},
+		{"MemoryUnderProvisionedThreshold", config.MemoryUnderProvisionedThreshold},
+		{"OptimalUtilizationMin", config.OptimalUtilizationMin},
+		{"OptimalUtilizationMax", config.OptimalUtilizationMax},
+	}
+	for _, f := range fractions {
+		if f.value <= 0 || f.value > 1 {
+			return fmt.Errorf("%s must be in (0, 1], got %g", f.name, f.value)
+		}
+	}
+	if config.OptimalUtilizationMin >= config.OptimalUtilizationMax {
+		return fmt.Errorf("OptimalUtilizationMin (%g) must be less than OptimalUtilizationMax (%g)",
+			config.OptimalUtilizationMin, config.OptimalUtilizationMax)
+	}
+	if config.CPUOverProvisionedThreshold >= config.CPUUnderProvisionedThreshold {
+		return fmt.Errorf("CPUOverProvisionedThreshold (%g) must be less than CPUUnderProvisionedThreshold (%g)",
+			config.CPUOverProvisionedThreshold, config.CPUUnderProvisionedThreshold)
+	}
+	if config.MemoryOverProvisionedThreshold >= config.MemoryUnderProvisionedThreshold {
+		return fmt.Errorf("MemoryOverProvisionedThreshold (%g) must be less than MemoryUnderProvisionedThreshold (%g)",
+			config.MemoryOverProvisionedThreshold, config.MemoryUnderProvisionedThreshold)
+	}
+	if config.OverProvisionedBuffer < 1 || config.UnderProvisionedBuffer < 1 {
+		return fmt.Errorf("buffers must be at least 1, got %g and %g", config.OverProvisionedBuffer, config.UnderProvisionedBuffer)
+	}
+
+	if config.GPUCostPerHour < 0 {
+		return fmt.Errorf("cost rates must not be negative")
+	}
+	return nil
+}
+
+// validateRates checks updated CPU and memory rates and that the provider can take them
+func validateRates(provider pricing.PricingProvider, rates pricing.Rates) (pricing.AdjustableProvider, error) {
+	if rates.CPUCostPerVCPUHour < 0 || rates.MemoryCostPerGBHour < 0 {
+		return nil, fmt.Errorf("cost rates must not be negative")
+	}
+	adjustable, ok := provider.(pricing.AdjustableProvider)
+	if !ok {
+		return nil, fmt.Errorf("pricing provider %T does not support rate updates", provider)
+	}
+	return adjustable, nil
+}
+
+// UpdateConfig applies a validated update to the running configuration and drops cached analyses
+// and recommendation cooldowns, so the next analysis of every workload uses the new values
+func (opt *OptimizerEngine) UpdateConfig(update ConfigUpdate) (Config, error) {
+	opt.configMu.Lock()
+	defer opt.configMu.Unlock()
+
+	config := update.apply(*opt.cfg())
+	if err := validateTunables(config); err != nil {
+		return Config{}, err
+	}
+	rates, changed := update.rates(config.Pricing)
+	if changed {
+		provider, err := validateRates(config.Pricing, rates)
+		if err != nil {
+			return Config{}, err
+		}
+		provider.SetDefaultRates(rates)
+	}
+	opt.config.Store(&config)
+
+	opt.analysisCacheMu.Lock()
+	opt.analysisCache = make(map[string]*analysisResult)
+	opt.analysisCacheMu.Unlock()
+
+	opt.recommendationsMu.Lock()
+	// Expire cooldowns but keep each generation's IDs, so the next one still replaces them
+	for key, generation := range opt.generations {
+		generation.generatedAt = time.Time{}
+		opt.generations[key] = generation
+	}
+	opt.recommendationsMu.Unlock()
+
+	return config, nil
+}
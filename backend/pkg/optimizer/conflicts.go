@@ -0,0 +1,93 @@
+package optimizer
+
+import (
+	"fmt"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// flagConflicts marks recommendations for the same workload that over-shoot when applied together.
+// A scale-down and a request reduction are both sized from today's per-pod utilization, so applying
+// both removes the idle capacity twice: fewer pods, each with less CPU and memory. Both sides record
+// the other's ID in ConflictsWith and a rationale suggesting an order and a combined alternative.
+func flagConflicts(recommendations []models.Recommendation, analysis *analysisResult) {
+	var reductions []int
+	scaleDown := -1
+	for i := range recommendations {
+		switch {
+		case lowersRequests(&recommendations[i]):
+			reductions = append(reductions, i)
+		case lowersReplicas(&recommendations[i]):
+			scaleDown = i
+		}
+	}
+	if scaleDown < 0 || len(reductions) == 0 {
+		return
+	}
+
+	replicas := analysis.Deployment.CurrentReplicas
+	scaling := &recommendations[scaleDown]
+	for _, i := range reductions {
+		reduction := &recommendations[i]
+		reduction.ConflictsWith = append(reduction.ConflictsWith, scaling.ID)
+		scaling.ConflictsWith = append(scaling.ConflictsWith, reduction.ID)
+
+		reduction.Rationale = append(reduction.Rationale, fmt.Sprintf(
+			"Conflicts with scale-down %s: apply this reduction first and keep %d replicas; re-analyze before scaling down",
+			scaling.ID, replicas))
+	}
+
+	scaling.Rationale = append(scaling.Rationale, fmt.Sprintf(
+		"Conflicts with %d request reduction(s) sized from the same utilization; applying both over-shoots. "+
+			"Combined alternative: apply the reduction(s) at %d replicas and re-analyze before removing pods",
+		len(reductions), replicas))
+}
+
+// lowersRequests reports whether a resource recommendation lowers the CPU or memory request
+func lowersRequests(rec *models.Recommendation) bool {
+	if rec.Type != string(RecommendationTypeResource) {
+		return false
+	}
+	current, ok := rec.CurrentConfig.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	recommended, ok := rec.RecommendedConfig.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for key, resourceType := range map[string]string{"cpu_request": "cpu", "memory_request": "memory"} {
+		before, _ := current[key].(string)
+		after, _ := recommended[key].(string)
+		if before == "" || after == "" {
+			continue
+		}
+		if parseResourceQuantity(after, resourceType) < parseResourceQuantity(before, resourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// lowersReplicas reports whether a scaling recommendation removes replicas
+func lowersReplicas(rec *models.Recommendation) bool {
+	if rec.Type != string(RecommendationTypeScaling) {
+		return false
+	}
+	current, ok := rec.CurrentConfig.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	recommended, ok := rec.RecommendedConfig.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	before, ok := current["replicas"].(int32)
+	if !ok {
+		return false
+	}
+	after, ok := recommended["replicas"].(int32)
+	return ok && after < before
+}
@@ -0,0 +1,220 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// mirrorPodAnnotation marks the API mirror of a static pod, which is bound to its node
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// consolidationNode is a schedulable node with the requests of the pods that run on it
+type consolidationNode struct {
+	Name         string
+	InstanceType string
+	CPU          int64 // schedulable millicores: allocatable less node-bound pods
+	Memory       int64 // schedulable bytes: allocatable less node-bound pods
+	Pods         []podRequest
+	HourlyPrice  float64
+}
+
+// podRequest is the summed requests of a pod that could be rescheduled onto another node
+type podRequest struct {
+	Name   string // namespace/name
+	CPU    int64  // millicores
+	Memory int64  // bytes
+}
+
+// requested returns the summed requests of the node's movable pods
+func (n *consolidationNode) requested() (cpu, memory int64) {
+	for _, pod := range n.Pods {
+		cpu += pod.CPU
+		memory += pod.Memory
+	}
+	return cpu, memory
+}
+
+// RecommendNodeConsolidation estimates how many nodes the cluster's pods need by bin-packing their
+// requests onto as few schedulable nodes as possible, filling each to OptimalUtilizationMax, and
+// reports the nodes that could be drained and the monthly cost they would save. DaemonSet and
+// static pods stay on their nodes and reduce what those nodes can take. Node selectors, affinity,
+// taints and disruption budgets are not considered, so candidates should be checked before draining.
+// Returns nil when no node can be removed.
+func (opt *OptimizerEngine) RecommendNodeConsolidation() (*models.NodeConsolidation, error) {
+	ctx := context.Background()
+	nodeList, err := opt.k8sClient.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	podList, err := opt.k8sClient.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	nodes := opt.collectConsolidationNodes(nodeList.Items, podList.Items)
+	if len(nodes) < 2 {
+		return nil, nil
+	}
+
+	// Keep the most requested nodes, so the fewest pods move, and find the fewest that hold every pod
+	sort.SliceStable(nodes, func(i, j int) bool {
+		cpuI, memoryI := nodes[i].requested()
+		cpuJ, memoryJ := nodes[j].requested()
+		if fi, fj := requestFraction(cpuI, memoryI, nodes[i]), requestFraction(cpuJ, memoryJ, nodes[j]); fi != fj {
+			return fi > fj
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	var pods []podRequest
+	for _, node := range nodes {
+		pods = append(pods, node.Pods...)
+	}
+
+	target := len(nodes)
+	for keep := 1; keep < len(nodes); keep++ {
+		if opt.packPods(pods, nodes[:keep]) {
+			target = keep
+			break
+		}
+	}
+	if target == len(nodes) {
+		return nil, nil
+	}
+
+	consolidation := &models.NodeConsolidation{
+		CurrentNodes: len(nodes),
+		TargetNodes:  target,
+		Timestamp:    time.Now(),
+	}
+	for i, node := range nodes {
+		monthlyCost := node.HourlyPrice * 24 * 30
+		consolidation.CurrentMonthlyCost += monthlyCost
+		cpu, memory := node.requested()
+		consolidation.CPURequested += cpu
+		consolidation.MemoryRequested += memory
+		if i < target {
+			consolidation.TargetMonthlyCost += monthlyCost
+			continue
+		}
+		consolidation.Candidates = append(consolidation.Candidates, models.ConsolidationCandidate{
+			Node:            node.Name,
+			InstanceType:    node.InstanceType,
+			Pods:            len(node.Pods),
+			CPURequested:    cpu,
+			MemoryRequested: memory,
+			MonthlyCost:     monthlyCost,
+		})
+	}
+	consolidation.EstimatedSavings = consolidation.CurrentMonthlyCost - consolidation.TargetMonthlyCost
+	consolidation.Rationale = []string{
+		fmt.Sprintf("%d pods request %s CPU and %s memory across %d schedulable nodes",
+			len(pods), formatResourceQuantity(consolidation.CPURequested, "cpu"),
+			formatResourceQuantity(consolidation.MemoryRequested, "memory"), len(nodes)),
+		fmt.Sprintf("Their requests fit on %d nodes filled to at most %.0f%% of schedulable capacity; %d nodes could be drained",
+			target, opt.cfg().OptimalUtilizationMax*100, len(consolidation.Candidates)),
+	}
+
+	return consolidation, nil
+}
+
+// collectConsolidationNodes pairs each schedulable node with its movable pods. Cordoned nodes are
+// already leaving the cluster and are skipped, as are pods that have finished.
+func (opt *OptimizerEngine) collectConsolidationNodes(nodes []corev1.Node, pods []corev1.Pod) []*consolidationNode {
+	byName := make(map[string]*consolidationNode)
+	var result []*consolidationNode
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		cn := &consolidationNode{
+			Name:         node.Name,
+			InstanceType: node.Labels[instanceTypeLabel],
+			CPU:          node.Status.Allocatable.Cpu().MilliValue(),
+			Memory:       node.Status.Allocatable.Memory().Value(),
+		}
+		if cn.InstanceType == "" {
+			cn.InstanceType = unknownInstanceType
+		}
+		cn.HourlyPrice = opt.nodeHourlyPrice(cn.InstanceType, cn.Name, cn.CPU, cn.Memory)
+		byName[node.Name] = cn
+		result = append(result, cn)
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		node, ok := byName[pod.Spec.NodeName]
+		if !ok || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		request := podRequest{Name: pod.Namespace + "/" + pod.Name}
+		for _, container := range pod.Spec.Containers {
+			request.CPU += container.Resources.Requests.Cpu().MilliValue()
+			request.Memory += container.Resources.Requests.Memory().Value()
+		}
+		request.CPU += pod.Spec.Overhead.Cpu().MilliValue()
+		request.Memory += pod.Spec.Overhead.Memory().Value()
+
+		if _, mirror := pod.Annotations[mirrorPodAnnotation]; mirror || isDaemonSetPod(pod) {
+			node.CPU -= request.CPU
+			node.Memory -= request.Memory
+			continue
+		}
+		node.Pods = append(node.Pods, request)
+	}
+
+	return result
+}
+
+// packPods reports whether the pods fit on the nodes by first-fit decreasing, filling each node to
+// at most OptimalUtilizationMax of its schedulable capacity
+func (opt *OptimizerEngine) packPods(pods []podRequest, nodes []*consolidationNode) bool {
+	fill := opt.cfg().OptimalUtilizationMax
+	type bin struct{ cpu, memory float64 }
+	bins := make([]bin, len(nodes))
+	for i, node := range nodes {
+		bins[i] = bin{cpu: float64(node.CPU) * fill, memory: float64(node.Memory) * fill}
+	}
+
+	// Place the largest pods first, sized by their dominant share of the first kept node
+	sorted := append([]podRequest(nil), pods...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return requestFraction(sorted[i].CPU, sorted[i].Memory, nodes[0]) > requestFraction(sorted[j].CPU, sorted[j].Memory, nodes[0])
+	})
+
+	for _, pod := range sorted {
+		placed := false
+		for i := range bins {
+			if float64(pod.CPU) <= bins[i].cpu && float64(pod.Memory) <= bins[i].memory {
+				bins[i].cpu -= float64(pod.CPU)
+				bins[i].memory -= float64(pod.Memory)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFraction returns the larger of the CPU and memory shares a request takes of a node's
+// schedulable capacity
+func requestFraction(cpu, memory int64, node *consolidationNode) float64 {
+	var fraction float64
+	if node.CPU > 0 {
+		fraction = float64(cpu) / float64(node.CPU)
+	}
+	if node.Memory > 0 {
+		fraction = max(fraction, float64(memory)/float64(node.Memory))
+	}
+	return fraction
+}
@@ -0,0 +1,223 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnalyzeContainer analyzes a single container of a deployment across all of its pods and
+// returns the analysis along with recommendations targeting only that container
+func (opt *OptimizerEngine) AnalyzeContainer(namespace, name, container string) (*models.ContainerAnalysis, error) {
+	internal, err := opt.analyzer.analyzeContainer(namespace, name, container)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze container: %w", err)
+	}
+
+	recommendations := opt.recommendationGen.generateContainerRecommendations(internal)
+	opt.setBreakEven(recommendations)
+
+	opt.recommendationsMu.Lock()
+	for _, rec := range recommendations {
+		opt.recommendations[rec.ID] = rec
+	}
+	opt.recommendationsMu.Unlock()
+
+	public := opt.convertToPublicAnalysis(internal)
+
+	return &models.ContainerAnalysis{
+		Namespace:       namespace,
+		Deployment:      name,
+		Container:       container,
+		CPUUsage:        public.CPUUsage,
+		MemoryUsage:     public.MemoryUsage,
+		Provisional:     internal.Provisional,
+		Recommendations: recommendations,
+		Timestamp:       internal.Timestamp,
+	}, nil
+}
+
+// analyzeContainer performs resource analysis scoped to one container, using its requests and
+// limits and its per-container usage series from every pod of the deployment
+func (ra *resourceAnalyzer) analyzeContainer(namespace, name, containerName string) (*analysisResult, error) {
+	ctx := context.Background()
+
+	deployment, err := ra.optimizer.k8sClient.Clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, getError("deployment", err)
+	}
+
+	var container *corev1.Container
+	for i := range deployment.Spec.Template.Spec.Containers {
+		if deployment.Spec.Template.Spec.Containers[i].Name == containerName {
+			container = &deployment.Spec.Template.Spec.Containers[i]
+			break
+		}
+	}
+	if container == nil {
+		return nil, fmt.Errorf("container %s %w in deployment %s/%s", containerName, ErrNotFound, namespace, name)
+	}
+
+	metrics := &deploymentMetrics{
+		Namespace:        namespace,
+		Deployment:       name,
+		Container:        containerName,
+		CPURequested:     container.Resources.Requests.Cpu().MilliValue(),
+		CPULimit:         container.Resources.Limits.Cpu().MilliValue(),
+		MemoryRequested:  container.Resources.Requests.Memory().Value(),
+		MemoryLimit:      container.Resources.Limits.Memory().Value(),
+		Window:           ra.optimizer.cfg().AnalysisDuration,
+		CurrentReplicas:  k8s.DesiredReplicas(deployment.Spec.Replicas),
+		RestartBreakdown: make(map[string]int),
+		Timestamp:        time.Now(),
+	}
+
+	pods, err := ra.getDeploymentPods(deployment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment pods: %w", err)
+	}
+
+	duration := metrics.Window
+	var cpuPoints, memoryPoints []models.DataPoint
+	for _, pod := range pods {
+		resource := collector.ContainerResource(pod.Name, containerName)
+		if data, err := ra.optimizer.collector.GetTimeSeriesData(resource, "cpu", duration); err == nil {
+			cpuPoints = append(cpuPoints, ra.smoothSeries(data.Points)...)
+		}
+		if data, err := ra.optimizer.collector.GetTimeSeriesData(resource, "memory", duration); err == nil {
+			memoryPoints = append(memoryPoints, ra.smoothSeries(data.Points)...)
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == containerName {
+				metrics.RestartCount += status.RestartCount
+				addRestarts(metrics.RestartBreakdown, []corev1.ContainerStatus{status})
+				metrics.OOMKillCount += oomKills([]corev1.ContainerStatus{status})
+			}
+		}
+	}
+
+	metrics.CPUTimeSeries = cpuPoints
+	metrics.MemoryTimeSeries = memoryPoints
+	ra.applyUsageStatistics(metrics, cpuPoints, memoryPoints)
+
+	provisional, err := ra.checkDataSufficiency(len(cpuPoints))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &analysisResult{
+		Deployment:  *metrics,
+		Provisional: provisional,
+		Confidence:  1.0,
+		Timestamp:   time.Now(),
+	}
+
+	ra.analyzeReplicaConfidence(result)
+	if provisional && ra.optimizer.cfg().MinimumDataPoints > 0 {
+		result.Confidence *= float64(len(cpuPoints)) / float64(ra.optimizer.cfg().MinimumDataPoints)
+	}
+	ra.analyzeCoverage(result)
+	ra.analyzeScoped(result)
+
+	return result, nil
+}
+
+// analyzeContainers analyzes each container of a multi-container deployment on its own, carrying over
+// the deployment-level provisional state, churn and confidence. Containers without their own usage
+// series are skipped.
+func (ra *resourceAnalyzer) analyzeContainers(result *analysisResult) {
+	parent := &result.Deployment
+
+	names := make([]string, 0, len(parent.Containers))
+	for name := range parent.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cm := parent.Containers[name]
+		if len(cm.CPUTimeSeries) == 0 {
+			continue
+		}
+
+		metrics := deploymentMetrics{
+			Namespace:        parent.Namespace,
+			Deployment:       parent.Deployment,
+			Container:        name,
+			Window:           parent.Window,
+			CPURequested:     cm.CPURequested,
+			CPULimit:         cm.CPULimit,
+			MemoryRequested:  cm.MemoryRequested,
+			MemoryLimit:      cm.MemoryLimit,
+			CurrentReplicas:  parent.CurrentReplicas,
+			CPUTimeSeries:    cm.CPUTimeSeries,
+			MemoryTimeSeries: cm.MemoryTimeSeries,
+			Timestamp:        parent.Timestamp,
+		}
+		ra.applyUsageStatistics(&metrics, cm.CPUTimeSeries, cm.MemoryTimeSeries)
+
+		container := &analysisResult{
+			Deployment:  metrics,
+			Provisional: result.Provisional,
+			ChurnRate:   result.ChurnRate,
+			HighChurn:   result.HighChurn,
+			Confidence:  result.Confidence,
+			Coverage:    result.Coverage,
+			Timestamp:   result.Timestamp,
+		}
+		ra.analyzeScoped(container)
+
+		result.Containers = append(result.Containers, container)
+	}
+}
+
+// analyzeScoped runs the resource analysis and scoring for container-scoped metrics
+func (ra *resourceAnalyzer) analyzeScoped(result *analysisResult) {
+	ra.analyzeCPU(result)
+	ra.analyzeMemory(result)
+	ra.calculateScores(result)
+}
+
+// generateContainerRecommendations generates right-sizing recommendations for a container-scoped analysis
+func (rg *recommendationGenerator) generateContainerRecommendations(analysis *analysisResult) []models.Recommendation {
+	metrics := &analysis.Deployment
+
+	if rg.optimizer.isProtectedWorkload(metrics.Deployment) || len(metrics.CPUTimeSeries) == 0 {
+		return []models.Recommendation{}
+	}
+
+	recommendations := rg.generateScopedResourceRecommendations(analysis)
+	for i := range recommendations {
+		recommendations[i].Confidence = analysis.Confidence
+		rg.markRecencyWeighted(&recommendations[i], analysis)
+		if analysis.Provisional {
+			rg.markProvisional(&recommendations[i], analysis)
+		} else if rg.isLowConfidence(analysis) {
+			rg.markLowConfidence(&recommendations[i], analysis)
+		}
+	}
+
+	return recommendations
+}
+
+// generateScopedResourceRecommendations generates resource recommendations labeled with the container they target
+func (rg *recommendationGenerator) generateScopedResourceRecommendations(analysis *analysisResult) []models.Recommendation {
+	container := analysis.Deployment.Container
+
+	recommendations := rg.generateResourceRecommendations(analysis)
+	for i := range recommendations {
+		recommendations[i].Container = container
+		recommendations[i].Description = fmt.Sprintf("Container %s: %s", container, recommendations[i].Description)
+		rg.optimizer.scorer.scoreImpact(&recommendations[i], analysis)
+	}
+
+	return recommendations
+}
@@ -0,0 +1,55 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodeReservation holds the CPU and memory on a node that workloads cannot be packed into
+type nodeReservation struct {
+	CPU    int64 // millicores
+	Memory int64 // bytes
+}
+
+// daemonSetReservations returns the summed requests of the DaemonSet pods on each node. DaemonSets run
+// on every node, so their requests are unavailable to consolidated workloads wherever they land.
+// Returns an empty map when ReserveDaemonSetOverhead is off.
+func (opt *OptimizerEngine) daemonSetReservations() (map[string]nodeReservation, error) {
+	reservations := make(map[string]nodeReservation)
+	if !opt.cfg().ReserveDaemonSetOverhead {
+		return reservations, nil
+	}
+
+	pods, err := opt.k8sClient.Clientset.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName == "" || !isDaemonSetPod(pod) ||
+			pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		reservation := reservations[pod.Spec.NodeName]
+		for _, container := range pod.Spec.Containers {
+			reservation.CPU += container.Resources.Requests.Cpu().MilliValue()
+			reservation.Memory += container.Resources.Requests.Memory().Value()
+		}
+		reservation.CPU += pod.Spec.Overhead.Cpu().MilliValue()
+		reservation.Memory += pod.Spec.Overhead.Memory().Value()
+		reservations[pod.Spec.NodeName] = reservation
+	}
+
+	return reservations, nil
+}
+
+// isDaemonSetPod reports whether a pod is controlled by a DaemonSet
+func isDaemonSetPod(pod *corev1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
@@ -0,0 +1,31 @@
+package optimizer
+
+import (
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	// ErrNotFound is returned when a workload, container or recommendation does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrInsufficientData is returned when too little metric history has been collected to analyze
+	// a workload; callers can retry once more samples have accumulated
+	ErrInsufficientData = errors.New("insufficient data points for analysis")
+
+	// ErrNotApplied is returned when reverting a recommendation that has not been applied
+	ErrNotApplied = errors.New("not applied")
+
+	// ErrDrifted is returned when a revert is refused because the target changed after the apply
+	ErrDrifted = errors.New("changed since apply")
+)
+
+// getError wraps a failed Get of a Kubernetes object, marking missing objects with ErrNotFound
+func getError(kind string, err error) error {
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get %s: %w: %w", kind, ErrNotFound, err)
+	}
+	return fmt.Errorf("failed to get %s: %w", kind, err)
+}
@@ -0,0 +1,122 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// gpuResourceName is the extended resource through which pods request NVIDIA GPUs
+const gpuResourceName corev1.ResourceName = "nvidia.com/gpu"
+
+// containerGPUs returns the number of GPUs a container requests. Extended resources may be set as
+// limits only, in which case the request defaults to the limit.
+func containerGPUs(container corev1.Container) int64 {
+	if quantity, ok := container.Resources.Requests[gpuResourceName]; ok {
+		return quantity.Value()
+	}
+	if quantity, ok := container.Resources.Limits[gpuResourceName]; ok {
+		return quantity.Value()
+	}
+	return 0
+}
+
+// collectGPURequests sums the GPUs requested by the analyzed containers and records the container
+// holding them when exactly one container requests GPUs
+func collectGPURequests(metrics *deploymentMetrics, containers []corev1.Container) {
+	gpuContainers := 0
+	for _, container := range containers {
+		gpus := containerGPUs(container)
+		if gpus == 0 {
+			continue
+		}
+		metrics.GPURequested += gpus
+		metrics.GPUContainer = container.Name
+		gpuContainers++
+	}
+	if gpuContainers > 1 {
+		metrics.GPUContainer = ""
+	}
+}
+
+// applyGPUStatistics sets the GPU usage percentile of metrics from raw usage points
+func applyGPUStatistics(metrics *deploymentMetrics, points []models.DataPoint) {
+	metrics.GPUTimeSeries = points
+	if len(points) == 0 {
+		return
+	}
+
+	values := extractValues(points)
+	sort.Float64s(values)
+	metrics.GPUP95 = calculatePercentile(values, 95)
+}
+
+// analyzeGPU detects pods that request more GPUs than they consistently use. GPUs are integral, so the
+// needed count is P95 usage with the over-provisioned buffer, rounded up to a whole GPU. Nothing in the
+// collector reads GPU usage, so without a "gpu" series from a metric source (such as a DCGM exporter)
+// for every pod, or with fewer than MinimumDataPoints samples, GPUs are left alone.
+func (ra *resourceAnalyzer) analyzeGPU(result *analysisResult) {
+	metrics := &result.Deployment
+	if metrics.GPURequested == 0 || len(metrics.GPUTimeSeries) < ra.optimizer.cfg().MinimumDataPoints {
+		return
+	}
+
+	result.GPUUtilization = metrics.GPUP95 / float64(metrics.GPURequested)
+
+	needed := int64(math.Ceil(metrics.GPUP95 * ra.optimizer.cfg().OverProvisionedBuffer))
+	if needed < 1 {
+		needed = 1
+	}
+	if needed < metrics.GPURequested {
+		result.GPUOverProvisioned = true
+		result.RecommendedGPUs = needed
+	}
+}
+
+// generateGPURecommendation recommends requesting fewer whole GPUs per pod
+func (rg *recommendationGenerator) generateGPURecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+
+	if !analysis.GPUOverProvisioned || metrics.GPUContainer == "" {
+		return nil
+	}
+
+	// GPU requests and limits must match, so both move together
+	savings := (rg.calculateGPUCost(metrics.GPURequested) - rg.calculateGPUCost(analysis.RecommendedGPUs)) *
+		float64(metrics.CurrentReplicas)
+	priority := rg.optimizer.scorer.getPriorityLevel(analysis, savings)
+
+	return &models.Recommendation{
+		ID:         uuid.New().String(),
+		Type:       string(RecommendationTypeResource),
+		Namespace:  metrics.Namespace,
+		Deployment: metrics.Deployment,
+		Container:  metrics.GPUContainer,
+		Priority:   string(priority),
+		Description: fmt.Sprintf("Reduce GPUs per pod from %d to %d (P95 usage: %.2f GPUs, utilization: %.1f%%)",
+			metrics.GPURequested, analysis.RecommendedGPUs, metrics.GPUP95, analysis.GPUUtilization*100),
+		CurrentConfig:     convertResourceConfigToMap(resourceConfig{GPU: strconv.FormatInt(metrics.GPURequested, 10)}),
+		RecommendedConfig: convertResourceConfigToMap(resourceConfig{GPU: strconv.FormatInt(analysis.RecommendedGPUs, 10)}),
+		EstimatedSavings:  savings,
+		Impact:            rg.optimizer.scorer.formatImpactMessage(RecommendationTypeResource, analysis, savings),
+		Rationale: []string{
+			fmt.Sprintf("Pods request %d GPUs but P95 usage is %.2f GPUs (%.1f%% utilization)",
+				metrics.GPURequested, metrics.GPUP95, analysis.GPUUtilization*100),
+			fmt.Sprintf("Recommended GPUs = P95 %.2f x %.2f buffer, rounded up to a whole GPU",
+				metrics.GPUP95, rg.optimizer.cfg().OverProvisionedBuffer),
+			fmt.Sprintf("Each GPU costs $%.2f/hour across %d replicas", rg.optimizer.cfg().GPUCostPerHour, metrics.CurrentReplicas),
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+// calculateGPUCost calculates monthly cost for whole GPUs
+func (rg *recommendationGenerator) calculateGPUCost(gpus int64) float64 {
+	return float64(gpus) * rg.optimizer.cfg().GPUCostPerHour * 24 * 30 // Monthly cost
+}
@@ -0,0 +1,103 @@
+package optimizer
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+)
+
+// hpaTargetDeviationPoints is how far, in percentage points, a utilization metric may drift from its
+// target before the HPA target is reconsidered
+const hpaTargetDeviationPoints = 20
+
+// hpaTargetDeviationRatio is how far, relative to the target, a value metric may drift from it
+const hpaTargetDeviationRatio = 0.2
+
+// hpaMetricTargets returns the metrics the workload's HPA scales on. Analyses that only carry the
+// CPU target and current value report those as a single CPU utilization metric.
+func (m *deploymentMetrics) hpaMetricTargets() []models.HPAMetricTarget {
+	if len(m.HPAMetrics) > 0 || m.HPATargetCPU == 0 {
+		return m.HPAMetrics
+	}
+	return []models.HPAMetricTarget{{
+		Type:       string(autoscalingv2.ResourceMetricSourceType),
+		Name:       "cpu",
+		TargetType: string(autoscalingv2.UtilizationMetricType),
+		Target:     float64(m.HPATargetCPU),
+		Current:    float64(m.HPACurrentCPU),
+	}}
+}
+
+// isUtilization reports whether a metric is targeted as a percentage of requests
+func isUtilization(metric models.HPAMetricTarget) bool {
+	return metric.TargetType == string(autoscalingv2.UtilizationMetricType)
+}
+
+// isCPUUtilization reports whether a metric is the HPA's CPU utilization target
+func isCPUUtilization(metric models.HPAMetricTarget) bool {
+	return metric.Type == string(autoscalingv2.ResourceMetricSourceType) && metric.Name == "cpu" && isUtilization(metric)
+}
+
+// hpaTargetDeviates reports whether a metric's current value has drifted far enough from its target
+// that the target should be reconsidered
+func hpaTargetDeviates(metric models.HPAMetricTarget) bool {
+	if metric.Target <= 0 || metric.Current <= 0 {
+		return false
+	}
+	diff := math.Abs(metric.Current - metric.Target)
+	if isUtilization(metric) {
+		return diff > hpaTargetDeviationPoints
+	}
+	return diff/metric.Target > hpaTargetDeviationRatio
+}
+
+// hpaTargetMetric returns the metric whose target to adjust: the one furthest from its target, or
+// the CPU utilization metric when none has drifted
+func hpaTargetMetric(metrics []models.HPAMetricTarget) (models.HPAMetricTarget, bool) {
+	var chosen models.HPAMetricTarget
+	found := false
+	worst := 0.0
+	for _, metric := range metrics {
+		if !hpaTargetDeviates(metric) {
+			continue
+		}
+		if deviation := math.Abs(metric.Current-metric.Target) / metric.Target; deviation > worst {
+			chosen, worst, found = metric, deviation, true
+		}
+	}
+	if found {
+		return chosen, true
+	}
+
+	for _, metric := range metrics {
+		if isCPUUtilization(metric) && metric.Target > 0 && metric.Current > 0 {
+			return metric, true
+		}
+	}
+	return models.HPAMetricTarget{}, false
+}
+
+// recommendedHPATarget moves a metric's target towards its observed value. Utilization targets
+// follow the CPU rule: halfway up to a higher current value, or 10 points above a lower one, capped
+// at 80%. Value targets move halfway to the current value.
+func recommendedHPATarget(metric models.HPAMetricTarget) float64 {
+	if !isUtilization(metric) {
+		return (metric.Target + metric.Current) / 2
+	}
+
+	target, current := int32(metric.Target), int32(metric.Current)
+	if current > target {
+		return float64((target + current) / 2)
+	}
+	return float64(min(current+10, 80))
+}
+
+// formatHPAValue formats a target or current value, as a percentage for utilization metrics
+func formatHPAValue(metric models.HPAMetricTarget, value float64) string {
+	if isUtilization(metric) {
+		return strconv.Itoa(int(value)) + "%"
+	}
+	return strconv.FormatFloat(value, 'g', 4, 64)
+}
@@ -0,0 +1,129 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// analyzeIdle flags workloads whose P95 CPU stays at or below IdleCPUThresholdMillis and whose
+// memory barely moves over the whole window: running replicas that serve nothing. Provisional
+// analyses are skipped, as a short quiet stretch says little about a workload's purpose.
+func (ra *resourceAnalyzer) analyzeIdle(result *analysisResult) {
+	metrics := &result.Deployment
+	config := ra.optimizer.cfg()
+	if config.IdleCPUThresholdMillis <= 0 || result.Provisional || metrics.CurrentReplicas == 0 ||
+		len(metrics.CPUTimeSeries) == 0 || len(metrics.MemoryTimeSeries) == 0 {
+		return
+	}
+	if metrics.CPUP95 > config.IdleCPUThresholdMillis {
+		return
+	}
+	result.Idle = memoryVariation(metrics.MemoryTimeSeries) <= config.IdleMemoryVariation
+}
+
+// memoryVariation returns the coefficient of variation of memory usage, or +Inf without usage
+func memoryVariation(series []models.DataPoint) float64 {
+	values := extractValues(series)
+	mean := calculateAverage(values)
+	if mean <= 0 {
+		return math.Inf(1)
+	}
+	return math.Sqrt(calculateVariance(values)) / mean
+}
+
+// generateIdleRecommendation recommends scaling an idle workload to zero, or removing it, saving
+// its full monthly cost
+func (rg *recommendationGenerator) generateIdleRecommendation(analysis *analysisResult) *models.Recommendation {
+	if !analysis.Idle {
+		return nil
+	}
+	metrics := &analysis.Deployment
+
+	savings := rg.calculateReplicaCostSavings(metrics, int(metrics.CurrentReplicas))
+
+	action := "Scale %s to zero replicas or remove it"
+	if metrics.Kind == WorkloadKindDaemonSet {
+		action = "Remove %s"
+	}
+
+	return &models.Recommendation{
+		ID:          uuid.New().String(),
+		Type:        string(RecommendationTypeIdle),
+		Namespace:   metrics.Namespace,
+		Deployment:  metrics.Deployment,
+		Priority:    string(rg.optimizer.scorer.getPriorityLevel(analysis, savings)),
+		Description: fmt.Sprintf(action+": it has been idle for %s", metrics.Deployment, metrics.Window),
+		CurrentConfig: map[string]interface{}{
+			"replicas": metrics.CurrentReplicas,
+		},
+		RecommendedConfig: map[string]interface{}{
+			"replicas": 0,
+		},
+		EstimatedSavings: savings,
+		Impact:           rg.optimizer.scorer.formatImpactMessage(RecommendationTypeIdle, analysis, savings),
+		Rationale: []string{
+			fmt.Sprintf("P95 CPU usage is %s per pod, at or below the idle threshold of %s",
+				formatResourceQuantity(metrics.CPUP95, "cpu"),
+				formatResourceQuantity(rg.optimizer.cfg().IdleCPUThresholdMillis, "cpu")),
+			fmt.Sprintf("Memory usage stayed flat around %s (P95 %s)",
+				formatResourceQuantity(metrics.MemoryAverage, "memory"), formatResourceQuantity(metrics.MemoryP95, "memory")),
+			fmt.Sprintf("%d replicas cost $%.2f/month while doing no measurable work", metrics.CurrentReplicas, savings),
+			"Confirm nothing calls the workload or runs it on a schedule before removing it",
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+// FindIdleWorkloads analyzes every deployment in the namespaces and returns the idle ones, most
+// expensive first, each with the recommendation to scale it to zero
+func (opt *OptimizerEngine) FindIdleWorkloads(namespaces []string) ([]models.IdleWorkload, error) {
+	analyses, err := opt.AnalyzeAllDeployments(namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze deployments: %w", err)
+	}
+
+	idle := []models.IdleWorkload{}
+	for i := range analyses {
+		analysis := &analyses[i]
+		opt.analysisCacheMu.RLock()
+		internal, ok := opt.analysisCache[analysisCacheKey(analysis.Namespace, analysis.Kind, analysis.Deployment)]
+		opt.analysisCacheMu.RUnlock()
+		if !ok || !internal.Idle || opt.isProtectedWorkload(analysis.Deployment) {
+			continue
+		}
+
+		metrics := &internal.Deployment
+		workload := models.IdleWorkload{
+			Namespace:     metrics.Namespace,
+			Deployment:    metrics.Deployment,
+			Kind:          metrics.Kind,
+			Replicas:      metrics.CurrentReplicas,
+			CPUP95:        metrics.CPUP95,
+			MemoryAverage: metrics.MemoryAverage,
+			MonthlyCost:   opt.recommendationGen.calculateReplicaCostSavings(metrics, int(metrics.CurrentReplicas)),
+			Window:        metrics.Window,
+		}
+
+		recommendations, err := opt.GenerateRecommendations(analysis)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recommendations for %s/%s: %w",
+				analysis.Namespace, analysis.Deployment, err)
+		}
+		for _, rec := range recommendations {
+			if rec.Type == string(RecommendationTypeIdle) {
+				workload.RecommendationID = rec.ID
+			}
+		}
+		idle = append(idle, workload)
+	}
+
+	sort.SliceStable(idle, func(i, j int) bool {
+		return idle[i].MonthlyCost > idle[j].MonthlyCost
+	})
+	return idle, nil
+}
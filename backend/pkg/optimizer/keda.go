@@ -0,0 +1,140 @@
+package optimizer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// eventDrivenIdleFraction is the share of peak CPU demand at or below which a sample counts as idle
+const eventDrivenIdleFraction = 0.1
+
+// kedaScalers maps workload name fragments to the KEDA scaler that usually fits them
+var kedaScalers = []struct {
+	fragments []string
+	scaler    string
+	note      string
+}{
+	{[]string{"kafka"}, "kafka", "consumer group lag"},
+	{[]string{"rabbit", "amqp"}, "rabbitmq", "queue length"},
+	{[]string{"sqs"}, "aws-sqs-queue", "queue length"},
+	{[]string{"pubsub"}, "gcp-pubsub", "subscription backlog"},
+	{[]string{"servicebus"}, "azure-servicebus", "queue or subscription length"},
+	{[]string{"nats"}, "nats-jetstream", "pending messages"},
+	{[]string{"redis"}, "redis", "list length"},
+	{[]string{"cron", "nightly", "batch", "report"}, "cron", "the schedule the bursts follow"},
+}
+
+// analyzeEventDriven finds long idle stretches between bursts in the summed CPU demand. An
+// HPA-managed workload that sits at its minimum and is idle for long gaps is better served by
+// event-driven scaling, which can scale to zero and react to queue depth instead of CPU.
+func (ra *resourceAnalyzer) analyzeEventDriven(result *analysisResult) {
+	metrics := &result.Deployment
+	demand := metrics.CPUDemandSeries
+	minGap := ra.optimizer.cfg().EventDrivenIdleGap
+	if !metrics.HasHPA || minGap <= 0 || len(demand) < 2 {
+		return
+	}
+
+	peak := 0.0
+	for _, point := range demand {
+		if point.Value > peak {
+			peak = point.Value
+		}
+	}
+	if peak <= 0 {
+		return
+	}
+	idleLevel := peak * eventDrivenIdleFraction
+
+	var idleSamples int
+	var longest time.Duration
+	var idleSince time.Time
+	for _, point := range demand {
+		if point.Value <= idleLevel {
+			idleSamples++
+			if idleSince.IsZero() {
+				idleSince = point.Timestamp
+			}
+			continue
+		}
+		if !idleSince.IsZero() {
+			if gap := point.Timestamp.Sub(idleSince); gap > longest {
+				longest = gap
+			}
+			idleSince = time.Time{}
+		}
+	}
+	if !idleSince.IsZero() {
+		if gap := demand[len(demand)-1].Timestamp.Sub(idleSince); gap > longest {
+			longest = gap
+		}
+	}
+
+	result.LongestIdleGap = longest
+	result.IdleFraction = float64(idleSamples) / float64(len(demand))
+	result.EventDrivenCandidate = result.HPAIdleAtMinimum && longest >= minGap && idleSamples < len(demand)
+}
+
+// generateEventDrivenRecommendation recommends replacing a CPU HPA with KEDA event-driven scaling
+// for workloads that idle at their HPA minimum between bursts
+func (rg *recommendationGenerator) generateEventDrivenRecommendation(analysis *analysisResult) *models.Recommendation {
+	if !analysis.EventDrivenCandidate {
+		return nil
+	}
+	metrics := &analysis.Deployment
+
+	scaler, note := suggestKEDAScaler(metrics.Deployment)
+
+	// Scaling to zero frees the minimum replicas for the idle share of the time
+	savings := rg.calculateReplicaCostSavings(metrics, int(metrics.MinReplicas)) * analysis.IdleFraction
+
+	description := fmt.Sprintf("Replace the CPU-based HPA with event-driven (KEDA) scaling so %s can scale to zero between bursts, using the %s scaler (%s)",
+		metrics.Deployment, scaler, note)
+
+	currentConfig := HPAConfig{
+		MinReplicas: metrics.MinReplicas,
+		MaxReplicas: metrics.MaxReplicas,
+		TargetCPU:   metrics.HPATargetCPU,
+	}
+
+	return &models.Recommendation{
+		ID:            uuid.New().String(),
+		Type:          string(RecommendationTypeEventDriven),
+		Namespace:     metrics.Namespace,
+		Deployment:    metrics.Deployment,
+		Priority:      string(rg.optimizer.scorer.getPriorityLevel(analysis, savings)),
+		Description:   description,
+		CurrentConfig: convertHPAConfigToMap(currentConfig),
+		RecommendedConfig: map[string]interface{}{
+			"scaler":       scaler,
+			"min_replicas": 0,
+			"max_replicas": metrics.MaxReplicas,
+		},
+		EstimatedSavings: savings,
+		Impact:           rg.optimizer.scorer.formatImpactMessage(RecommendationTypeEventDriven, analysis, savings),
+		Rationale: []string{
+			fmt.Sprintf("Replicas were at or below the HPA minimum of %d more than 80%% of the time", metrics.MinReplicas),
+			fmt.Sprintf("CPU demand stayed under %.0f%% of its peak for up to %s at a stretch (%.0f%% of samples)",
+				eventDrivenIdleFraction*100, analysis.LongestIdleGap.Round(time.Minute), analysis.IdleFraction*100),
+			"A CPU-based HPA cannot scale below one replica or react to backlog before CPU rises",
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+// suggestKEDAScaler picks a KEDA scaler from the workload name, defaulting to a queue scaler
+func suggestKEDAScaler(name string) (scaler, note string) {
+	lower := strings.ToLower(name)
+	for _, candidate := range kedaScalers {
+		for _, fragment := range candidate.fragments {
+			if strings.Contains(lower, fragment) {
+				return candidate.scaler, candidate.note
+			}
+		}
+	}
+	return "rabbitmq, kafka or aws-sqs-queue", "the depth of the queue it consumes"
+}
@@ -0,0 +1,96 @@
+package optimizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	"sigs.k8s.io/yaml"
+)
+
+// patchAPIVersions maps the kinds recommendations patch to their API version
+var patchAPIVersions = map[string]string{
+	"Deployment":              "apps/v1",
+	"StatefulSet":             "apps/v1",
+	"DaemonSet":               "apps/v1",
+	"HorizontalPodAutoscaler": "autoscaling/v2",
+}
+
+// RecommendationManifest renders the patch applying a recommendation as a YAML strategic merge
+// patch, ready for `kubectl patch --patch-file` or a kustomize patches entry. Unlike
+// ApplyRecommendation it never changes the cluster.
+func (opt *OptimizerEngine) RecommendationManifest(recommendationID string) (string, error) {
+	opt.recommendationsMu.RLock()
+	rec, exists := opt.recommendations[recommendationID]
+	opt.recommendationsMu.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("recommendation %w: %s", ErrNotFound, recommendationID)
+	}
+
+	patch, err := opt.buildRecommendationPatch(&rec)
+	if err != nil {
+		return "", err
+	}
+	return renderPatchManifest(&rec, patch)
+}
+
+// RecommendationCommand returns the kubectl command that applies a recommendation: the patch
+// ApplyRecommendation would send, so it targets the same workload kind, container and HPA, or a scale
+// for scaling recommendations. Recommendations that cannot be applied directly return "".
+func (opt *OptimizerEngine) RecommendationCommand(recommendationID string) (string, error) {
+	opt.recommendationsMu.RLock()
+	rec, exists := opt.recommendations[recommendationID]
+	opt.recommendationsMu.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("recommendation %w: %s", ErrNotFound, recommendationID)
+	}
+
+	switch recommendationType(rec.Type) {
+	case RecommendationTypeResource, RecommendationTypeHPA:
+		patch, err := opt.buildRecommendationPatch(&rec)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("kubectl patch %s/%s -n %s --type strategic -p '%s'",
+			strings.ToLower(patch.Kind), patch.Name, patch.Namespace, patch.Patch), nil
+
+	case RecommendationTypeScaling:
+		config, _ := rec.RecommendedConfig.(map[string]interface{})
+		if replicas, ok := configInt32(config, "replicas"); ok {
+			return fmt.Sprintf("kubectl scale %s/%s -n %s --replicas=%d",
+				strings.ToLower(recommendationKind(&rec)), rec.Deployment, rec.Namespace, replicas), nil
+		}
+	}
+
+	return "", nil
+}
+
+// renderPatchManifest renders a computed patch as a YAML object carrying the target's apiVersion,
+// kind and metadata, preceded by a comment naming the recommendation
+func renderPatchManifest(rec *models.Recommendation, patch *models.RecommendationPatch) (string, error) {
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(patch.Patch), &body); err != nil {
+		return "", fmt.Errorf("failed to decode patch: %w", err)
+	}
+
+	body["apiVersion"] = patchAPIVersions[patch.Kind]
+	body["kind"] = patch.Kind
+	body["metadata"] = map[string]interface{}{
+		"name":      patch.Name,
+		"namespace": patch.Namespace,
+	}
+
+	data, err := yaml.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Recommendation %s: %s\n", rec.ID, rec.Description)
+	fmt.Fprintf(&b, "# Strategic merge patch for %s %s/%s\n", patch.Kind, patch.Namespace, patch.Name)
+	b.Write(data)
+	return b.String(), nil
+}
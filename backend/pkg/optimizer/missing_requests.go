@@ -0,0 +1,114 @@
+package optimizer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// Smallest requests suggested for a workload with no request, so near-idle pods still reserve something
+const (
+	minimumCPURequest    = 10               // 10m
+	minimumMemoryRequest = 32 * 1024 * 1024 // 32Mi
+)
+
+// generateMissingRequestsRecommendation recommends adding the CPU or memory request of a workload that
+// has none, sized from P95 usage. Limits are added alongside when they are missing too; existing limits
+// are kept and cap the request. Right-sizing skips these workloads since utilization of a missing
+// request is undefined.
+func (rg *recommendationGenerator) generateMissingRequestsRecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+	if !hasMissingRequests(metrics) {
+		return nil
+	}
+
+	buffer := rg.optimizer.cfg().OverProvisionedBuffer
+	currentConfig := resourceConfig{}
+	recommendedConfig := resourceConfig{}
+	if metrics.CPURequested > 0 {
+		currentConfig.CPURequest = formatResourceQuantity(metrics.CPURequested, "cpu")
+	}
+	if metrics.CPULimit > 0 {
+		currentConfig.CPULimit = formatResourceQuantity(metrics.CPULimit, "cpu")
+	}
+	if metrics.MemoryRequested > 0 {
+		currentConfig.MemoryRequest = formatResourceQuantity(metrics.MemoryRequested, "memory")
+	}
+	if metrics.MemoryLimit > 0 {
+		currentConfig.MemoryLimit = formatResourceQuantity(metrics.MemoryLimit, "memory")
+	}
+
+	var missing, rationale []string
+
+	if metrics.CPURequested == 0 {
+		request := missingRequest(rg.roundCPU(int64(float64(metrics.CPUP95)*buffer)), minimumCPURequest, metrics.CPULimit)
+		recommendedConfig.CPURequest = formatResourceQuantity(request, "cpu")
+		missing = append(missing, "CPU request")
+		if metrics.CPULimit == 0 {
+			recommendedConfig.CPULimit = formatLimit(rg.recommendedCPULimit(metrics, request), "cpu")
+			missing = append(missing, "CPU limit")
+		}
+		rationale = append(rationale,
+			fmt.Sprintf("No CPU request: the scheduler places pods as if they used no CPU, so nodes are overcommitted, and under contention the pods get the minimum CPU share (P95 usage: %s)",
+				formatResourceQuantity(metrics.CPUP95, "cpu")))
+	}
+
+	if metrics.MemoryRequested == 0 {
+		request := missingRequest(rg.roundMemory(int64(float64(metrics.MemoryP95)*buffer)), minimumMemoryRequest, metrics.MemoryLimit)
+		recommendedConfig.MemoryRequest = formatResourceQuantity(request, "memory")
+		missing = append(missing, "memory request")
+		if metrics.MemoryLimit == 0 {
+			recommendedConfig.MemoryLimit = formatLimit(rg.recommendedMemoryLimit(metrics, request), "memory")
+			missing = append(missing, "memory limit")
+		}
+		rationale = append(rationale,
+			fmt.Sprintf("No memory request: pods are scheduled onto nodes without room for their memory and are among the first evicted under memory pressure (P95 usage: %s)",
+				formatResourceQuantity(metrics.MemoryP95, "memory")))
+	}
+
+	if metrics.CPURequested == 0 && metrics.MemoryRequested == 0 && metrics.CPULimit == 0 && metrics.MemoryLimit == 0 {
+		rationale = append(rationale, "With no requests or limits the pods run in the BestEffort QoS class and are evicted before any other pod")
+	} else {
+		rationale = append(rationale, "Pods missing a request run in the Burstable QoS class at best and cannot be Guaranteed")
+	}
+	rationale = append(rationale, fmt.Sprintf("Recommended request = P95 usage x %.2f buffer; missing limits default to the usual limit for the request", buffer))
+
+	description := fmt.Sprintf("Add missing %s", strings.Join(missing, ", "))
+	if isBestEffort(metrics) {
+		description = fmt.Sprintf("Move BestEffort workload to Burstable QoS: add missing %s", strings.Join(missing, ", "))
+	}
+
+	return &models.Recommendation{
+		ID:                uuid.New().String(),
+		Type:              string(RecommendationTypeResource),
+		Namespace:         metrics.Namespace,
+		Deployment:        metrics.Deployment,
+		Priority:          string(PriorityHigh),
+		Description:       description,
+		CurrentConfig:     convertResourceConfigToMap(currentConfig),
+		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
+		EstimatedSavings:  0.0,
+		Impact:            "Reliability improvement - pods are scheduled by their real usage and protected from eviction ahead of other workloads",
+		Rationale:         rationale,
+		CreatedAt:         time.Now(),
+	}
+}
+
+// hasMissingRequests reports whether the workload lacks a CPU or memory request
+func hasMissingRequests(metrics *deploymentMetrics) bool {
+	return metrics.CPURequested == 0 || metrics.MemoryRequested == 0
+}
+
+// missingRequest returns a suggested request of at least minimum, capped at an existing limit
+func missingRequest(request, minimum, limit int64) int64 {
+	if request < minimum {
+		request = minimum
+	}
+	if limit > 0 && request > limit {
+		request = limit
+	}
+	return request
+}
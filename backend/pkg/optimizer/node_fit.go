@@ -0,0 +1,220 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// largestNodeAllocatable returns the largest allocatable CPU and memory of any node in the cluster, less
+// the requests of the DaemonSet pods on that node. CPU and memory may come from different nodes; both
+// are 0 when there are no nodes.
+func (ra *resourceAnalyzer) largestNodeAllocatable() (cpu, memory int64, err error) {
+	nodes, err := ra.optimizer.k8sClient.Clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	reservations, err := ra.optimizer.daemonSetReservations()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, node := range nodes.Items {
+		reserved := reservations[node.Name]
+		if nodeCPU := node.Status.Allocatable.Cpu().MilliValue() - reserved.CPU; nodeCPU > cpu {
+			cpu = nodeCPU
+		}
+		if nodeMemory := node.Status.Allocatable.Memory().Value() - reserved.Memory; nodeMemory > memory {
+			memory = nodeMemory
+		}
+	}
+
+	return cpu, memory, nil
+}
+
+// analyzeNodeFit flags limits, of the deployment and of each analyzed container, that exceed the
+// allocatable resources of the largest node and therefore can never be fully used
+func (ra *resourceAnalyzer) analyzeNodeFit(result *analysisResult) {
+	cpu, memory, err := ra.largestNodeAllocatable()
+	if err != nil {
+		return
+	}
+
+	for _, analysis := range append([]*analysisResult{result}, result.Containers...) {
+		metrics := &analysis.Deployment
+		analysis.LargestNodeCPU = cpu
+		analysis.LargestNodeMemory = memory
+		analysis.CPULimitExceedsNodes = cpu > 0 && metrics.CPULimit > cpu
+		analysis.MemoryLimitExceedsNodes = memory > 0 && metrics.MemoryLimit > memory
+	}
+}
+
+// analyzeNodeFraction flags pod requests larger than MaxNodeFractionPerPod of the largest node,
+// regardless of usage. It runs after analyzeNodeFit, which records the largest node.
+func (ra *resourceAnalyzer) analyzeNodeFraction(result *analysisResult) {
+	fraction := ra.optimizer.cfg().MaxNodeFractionPerPod
+	if fraction <= 0 {
+		return
+	}
+
+	metrics := &result.Deployment
+	result.CPURequestExceedsNodeFraction = result.LargestNodeCPU > 0 &&
+		float64(metrics.CPURequested) > fraction*float64(result.LargestNodeCPU)
+	result.MemoryRequestExceedsNodeFraction = result.LargestNodeMemory > 0 &&
+		float64(metrics.MemoryRequested) > fraction*float64(result.LargestNodeMemory)
+}
+
+// generateOversizedLimitRecommendation recommends a usable limit for resources whose limit exceeds the
+// largest node. Resources that are also being right-sized are left to that recommendation.
+func (rg *recommendationGenerator) generateOversizedLimitRecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+
+	fixCPU := analysis.CPULimitExceedsNodes && !analysis.CPUOverProvisioned && !analysis.CPUUnderProvisioned
+	fixMemory := analysis.MemoryLimitExceedsNodes && !analysis.MemoryOverProvisioned && !analysis.MemoryUnderProvisioned
+	if !fixCPU && !fixMemory {
+		return nil
+	}
+
+	var currentConfig, recommendedConfig resourceConfig
+	var changes, rationale []string
+
+	if fixCPU {
+		limit := rg.usableLimit(rg.recommendedCPULimit(metrics, metrics.CPURequested), rg.roundCPU(int64(float64(metrics.CPUP99)*rg.optimizer.cfg().UnderProvisionedBuffer)), analysis.LargestNodeCPU)
+		currentConfig.CPULimit = formatResourceQuantity(metrics.CPULimit, "cpu")
+		recommendedConfig.CPULimit = formatResourceQuantity(limit, "cpu")
+		changes = append(changes, fmt.Sprintf("CPU limit %s→%s", currentConfig.CPULimit, recommendedConfig.CPULimit))
+		rationale = append(rationale, fmt.Sprintf("CPU limit %s exceeds the largest node's allocatable %s",
+			currentConfig.CPULimit, formatResourceQuantity(analysis.LargestNodeCPU, "cpu")))
+	}
+
+	if fixMemory {
+		limit := rg.usableLimit(rg.recommendedMemoryLimit(metrics, metrics.MemoryRequested), rg.roundMemory(int64(float64(metrics.MemoryP99)*rg.optimizer.cfg().UnderProvisionedBuffer)), analysis.LargestNodeMemory)
+		currentConfig.MemoryLimit = formatResourceQuantity(metrics.MemoryLimit, "memory")
+		recommendedConfig.MemoryLimit = formatResourceQuantity(limit, "memory")
+		changes = append(changes, fmt.Sprintf("memory limit %s→%s", currentConfig.MemoryLimit, recommendedConfig.MemoryLimit))
+		rationale = append(rationale, fmt.Sprintf("Memory limit %s exceeds the largest node's allocatable %s",
+			currentConfig.MemoryLimit, formatResourceQuantity(analysis.LargestNodeMemory, "memory")))
+	}
+
+	rationale = append(rationale, fmt.Sprintf("Recommended limit = the larger of the usual limit for the request and P99 x %.2f buffer, capped at the largest node",
+		rg.optimizer.cfg().UnderProvisionedBuffer))
+
+	return &models.Recommendation{
+		ID:                uuid.New().String(),
+		Type:              string(RecommendationTypeResource),
+		Namespace:         metrics.Namespace,
+		Deployment:        metrics.Deployment,
+		Priority:          string(PriorityMedium),
+		Description:       fmt.Sprintf("Lower oversized limits that no node can satisfy: %s", strings.Join(changes, ", ")),
+		CurrentConfig:     convertResourceConfigToMap(currentConfig),
+		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
+		EstimatedSavings:  0.0,
+		Impact:            "Low risk - the current limit can never be reached; a realistic limit keeps bursts bounded and improves bin-packing",
+		Rationale:         rationale,
+		CreatedAt:         time.Now(),
+	}
+}
+
+// generateNodeFractionRecommendation recommends lowering requests that exceed the per-pod node fraction
+// policy to the policy maximum, spreading the capacity over more replicas. Requests of multi-container
+// pods cannot be split into per-container values, so those pods are only flagged. A resource is left
+// alone when even a single rounding increment would not lower its request.
+func (rg *recommendationGenerator) generateNodeFractionRecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+	config := rg.optimizer.cfg()
+	fraction := config.MaxNodeFractionPerPod
+
+	if len(analysis.Containers) > 0 {
+		return nil
+	}
+
+	cpuRequest := nodeFractionRequest(fraction, analysis.LargestNodeCPU, config.CPURoundingMillis)
+	memoryRequest := nodeFractionRequest(fraction, analysis.LargestNodeMemory, config.MemoryRoundingBytes)
+	fixCPU := analysis.CPURequestExceedsNodeFraction && cpuRequest < metrics.CPURequested
+	fixMemory := analysis.MemoryRequestExceedsNodeFraction && memoryRequest < metrics.MemoryRequested
+	if !fixCPU && !fixMemory {
+		return nil
+	}
+
+	var currentConfig, recommendedConfig resourceConfig
+	var changes, rationale []string
+	scale := 1.0
+
+	if fixCPU {
+		request := cpuRequest
+		currentConfig.CPURequest = formatResourceQuantity(metrics.CPURequested, "cpu")
+		recommendedConfig.CPURequest = formatResourceQuantity(request, "cpu")
+		if metrics.CPULimit > 0 && metrics.CPULimit != request {
+			currentConfig.CPULimit = formatResourceQuantity(metrics.CPULimit, "cpu")
+			recommendedConfig.CPULimit = formatLimit(rg.recommendedCPULimit(metrics, request), "cpu")
+		}
+		changes = append(changes, fmt.Sprintf("CPU request %s→%s", currentConfig.CPURequest, recommendedConfig.CPURequest))
+		rationale = append(rationale, fmt.Sprintf("CPU request %s is %.0f%% of the largest node's allocatable %s; policy allows at most %.0f%%",
+			currentConfig.CPURequest, float64(metrics.CPURequested)/float64(analysis.LargestNodeCPU)*100,
+			formatResourceQuantity(analysis.LargestNodeCPU, "cpu"), fraction*100))
+		scale = math.Max(scale, float64(metrics.CPURequested)/float64(request))
+	}
+
+	if fixMemory {
+		request := memoryRequest
+		currentConfig.MemoryRequest = formatResourceQuantity(metrics.MemoryRequested, "memory")
+		recommendedConfig.MemoryRequest = formatResourceQuantity(request, "memory")
+		if metrics.MemoryLimit > 0 && metrics.MemoryLimit != request {
+			currentConfig.MemoryLimit = formatResourceQuantity(metrics.MemoryLimit, "memory")
+			recommendedConfig.MemoryLimit = formatLimit(rg.recommendedMemoryLimit(metrics, request), "memory")
+		}
+		changes = append(changes, fmt.Sprintf("memory request %s→%s", currentConfig.MemoryRequest, recommendedConfig.MemoryRequest))
+		rationale = append(rationale, fmt.Sprintf("Memory request %s is %.0f%% of the largest node's allocatable %s; policy allows at most %.0f%%",
+			currentConfig.MemoryRequest, float64(metrics.MemoryRequested)/float64(analysis.LargestNodeMemory)*100,
+			formatResourceQuantity(analysis.LargestNodeMemory, "memory"), fraction*100))
+		scale = math.Max(scale, float64(metrics.MemoryRequested)/float64(request))
+	}
+
+	impact := "Medium risk - smaller pods schedule more easily and pack nodes more tightly"
+	if metrics.Kind != WorkloadKindDaemonSet && metrics.CurrentReplicas > 0 {
+		replicas := int32(math.Ceil(float64(metrics.CurrentReplicas) * scale))
+		rationale = append(rationale, fmt.Sprintf("Scale from %d to %d replicas to keep the same total capacity", metrics.CurrentReplicas, replicas))
+		impact += fmt.Sprintf("; scale to %d replicas to keep the same capacity", replicas)
+	}
+
+	return &models.Recommendation{
+		ID:                uuid.New().String(),
+		Type:              string(RecommendationTypeResource),
+		Namespace:         metrics.Namespace,
+		Deployment:        metrics.Deployment,
+		Priority:          string(PriorityMedium),
+		Description:       fmt.Sprintf("Lower requests above %.0f%% of a node: %s", fraction*100, strings.Join(changes, ", ")),
+		CurrentConfig:     convertResourceConfigToMap(currentConfig),
+		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
+		EstimatedSavings:  0.0,
+		Impact:            impact,
+		Rationale:         rationale,
+		CreatedAt:         time.Now(),
+	}
+}
+
+// nodeFractionRequest returns the largest request within fraction of allocatable, rounded down to the
+// increment but never below one increment (1 when rounding is disabled), so small nodes or small
+// fractions do not produce a zero request
+func nodeFractionRequest(fraction float64, allocatable, increment int64) int64 {
+	return max(roundDownToIncrement(int64(fraction*float64(allocatable)), increment), increment, 1)
+}
+
+// usableLimit returns the limit normally recommended for the request, raised to cover the sized
+// peak usage and capped at the largest node's allocatable
+func (rg *recommendationGenerator) usableLimit(limit, peak, allocatable int64) int64 {
+	if peak > limit {
+		limit = peak
+	}
+	if limit > allocatable {
+		limit = allocatable
+	}
+	return limit
+}
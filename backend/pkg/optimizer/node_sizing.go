@@ -0,0 +1,237 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// instanceTypeLabel is the well-known node label holding the cloud instance type
+const instanceTypeLabel = "node.kubernetes.io/instance-type"
+
+// unknownInstanceType groups nodes without an instance type label
+const unknownInstanceType = "unknown"
+
+// nodePool aggregates the allocatable capacity and P95 usage of the nodes of one instance type
+type nodePool struct {
+	InstanceType   string
+	Nodes          int
+	Node           string  // name of the largest node, whose rates price the pool
+	NodeCPU        int64   // allocatable millicores of the largest node
+	NodeMemory     int64   // allocatable bytes of the largest node
+	ReservedCPU    int64   // millicores reserved by DaemonSet pods on the most loaded node
+	ReservedMemory int64   // bytes reserved by DaemonSet pods on the most loaded node
+	CPUP95         float64 // millicores, summed per-node P95 usage
+	MemoryP95      float64 // bytes, summed per-node P95 usage
+	HourlyPrice    float64 // per node
+}
+
+// schedulableCPU returns the millicores of a node of the given size left for workloads after
+// the pool's DaemonSet reservation
+func (p *nodePool) schedulableCPU(nodeCPU int64) int64 {
+	return nodeCPU - p.ReservedCPU
+}
+
+// schedulableMemory returns the bytes of a node of the given size left for workloads after
+// the pool's DaemonSet reservation
+func (p *nodePool) schedulableMemory(nodeMemory int64) int64 {
+	return nodeMemory - p.ReservedMemory
+}
+
+// RecommendNodeSizing identifies node pools whose P95 CPU and memory utilization are both below
+// NodeUnderutilizedThreshold and suggests fewer nodes, or a cheaper instance type from
+// NodeInstanceTypes, sized so the pool runs at OptimalUtilizationMin at P95. Utilization is measured
+// against schedulable capacity: allocatable less the DaemonSet reservation every node carries.
+func (opt *OptimizerEngine) RecommendNodeSizing() ([]models.NodeSizingRecommendation, error) {
+	nodes, err := opt.k8sClient.Clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	reservations, err := opt.daemonSetReservations()
+	if err != nil {
+		return nil, err
+	}
+
+	pools := opt.collectNodePools(nodes.Items, reservations)
+
+	recommendations := []models.NodeSizingRecommendation{}
+	for _, pool := range pools {
+		if rec := opt.recommendPoolSizing(pool); rec != nil {
+			recommendations = append(recommendations, *rec)
+		}
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].EstimatedSavings > recommendations[j].EstimatedSavings
+	})
+
+	return recommendations, nil
+}
+
+// collectNodePools groups nodes by instance type and sums their P95 usage. Node usage includes the
+// DaemonSet pods, so demand is conservatively left as measured. Nodes without usage data are left
+// out, and pools without any are skipped.
+func (opt *OptimizerEngine) collectNodePools(nodes []corev1.Node, reservations map[string]nodeReservation) []*nodePool {
+	byType := make(map[string]*nodePool)
+	var types []string
+
+	for _, node := range nodes {
+		cpuData, err := opt.collector.GetTimeSeriesData("node/"+node.Name, "cpu", opt.cfg().AnalysisDuration)
+		if err != nil || len(cpuData.Points) == 0 {
+			continue
+		}
+		memoryData, err := opt.collector.GetTimeSeriesData("node/"+node.Name, "memory", opt.cfg().AnalysisDuration)
+		if err != nil || len(memoryData.Points) == 0 {
+			continue
+		}
+
+		instanceType := node.Labels[instanceTypeLabel]
+		if instanceType == "" {
+			instanceType = unknownInstanceType
+		}
+
+		pool, ok := byType[instanceType]
+		if !ok {
+			pool = &nodePool{InstanceType: instanceType}
+			byType[instanceType] = pool
+			types = append(types, instanceType)
+		}
+
+		pool.Nodes++
+		pool.CPUP95 += seriesPercentile(cpuData.Points, 95)
+		pool.MemoryP95 += seriesPercentile(memoryData.Points, 95)
+
+		allocCPU := node.Status.Allocatable.Cpu().MilliValue()
+		allocMemory := node.Status.Allocatable.Memory().Value()
+		if allocCPU > pool.NodeCPU {
+			pool.NodeCPU = allocCPU
+			pool.Node = node.Name
+		}
+		if allocMemory > pool.NodeMemory {
+			pool.NodeMemory = allocMemory
+		}
+
+		reserved := reservations[node.Name]
+		if reserved.CPU > pool.ReservedCPU {
+			pool.ReservedCPU = reserved.CPU
+		}
+		if reserved.Memory > pool.ReservedMemory {
+			pool.ReservedMemory = reserved.Memory
+		}
+	}
+
+	sort.Strings(types)
+	pools := make([]*nodePool, 0, len(types))
+	for _, instanceType := range types {
+		pool := byType[instanceType]
+		pool.HourlyPrice = opt.nodeHourlyPrice(instanceType, pool.Node, pool.NodeCPU, pool.NodeMemory)
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// recommendPoolSizing returns the cheapest node mix that keeps the pool at or below the target
+// utilization, or nil when the pool is not under-used or no cheaper mix exists
+func (opt *OptimizerEngine) recommendPoolSizing(pool *nodePool) *models.NodeSizingRecommendation {
+	nodeCPU, nodeMemory := pool.schedulableCPU(pool.NodeCPU), pool.schedulableMemory(pool.NodeMemory)
+	if nodeCPU <= 0 || nodeMemory <= 0 {
+		return nil
+	}
+
+	cpuCapacity := int64(pool.Nodes) * nodeCPU
+	memoryCapacity := int64(pool.Nodes) * nodeMemory
+	cpuUtilization := pool.CPUP95 / float64(cpuCapacity)
+	memoryUtilization := pool.MemoryP95 / float64(memoryCapacity)
+	threshold := opt.cfg().NodeUnderutilizedThreshold
+	if cpuUtilization >= threshold || memoryUtilization >= threshold {
+		return nil
+	}
+
+	currentCost := float64(pool.Nodes) * pool.HourlyPrice * 24 * 30
+
+	// Start from fewer nodes of the current type, then look for a cheaper type
+	bestType := pool.InstanceType
+	bestNodes := opt.nodesNeeded(pool, nodeCPU, nodeMemory)
+	bestCost := float64(bestNodes) * pool.HourlyPrice * 24 * 30
+	for _, candidate := range opt.cfg().NodeInstanceTypes {
+		candidateCPU, candidateMemory := pool.schedulableCPU(candidate.CPU), pool.schedulableMemory(candidate.Memory)
+		if candidate.Name == pool.InstanceType || candidateCPU <= 0 || candidateMemory <= 0 {
+			continue
+		}
+		nodes := opt.nodesNeeded(pool, candidateCPU, candidateMemory)
+		if cost := float64(nodes) * candidate.HourlyPrice * 24 * 30; cost < bestCost {
+			bestType, bestNodes, bestCost = candidate.Name, nodes, cost
+		}
+	}
+
+	if bestCost >= currentCost {
+		return nil
+	}
+
+	target := opt.cfg().OptimalUtilizationMin
+	rationale := []string{
+		fmt.Sprintf("%d %s nodes run at %.1f%% CPU and %.1f%% memory at P95, below the %.0f%% threshold",
+			pool.Nodes, pool.InstanceType, cpuUtilization*100, memoryUtilization*100, threshold*100),
+	}
+	if pool.ReservedCPU > 0 || pool.ReservedMemory > 0 {
+		rationale = append(rationale, fmt.Sprintf("DaemonSet pods reserve %s CPU and %s memory on every node",
+			formatResourceQuantity(pool.ReservedCPU, "cpu"), formatResourceQuantity(pool.ReservedMemory, "memory")))
+	}
+	rationale = append(rationale,
+		fmt.Sprintf("P95 demand of %s CPU and %s memory fits on %d %s nodes at %.0f%% utilization",
+			formatResourceQuantity(int64(pool.CPUP95), "cpu"), formatResourceQuantity(int64(pool.MemoryP95), "memory"),
+			bestNodes, bestType, target*100))
+
+	return &models.NodeSizingRecommendation{
+		InstanceType:            pool.InstanceType,
+		CurrentNodes:            pool.Nodes,
+		CPUUtilization:          cpuUtilization,
+		MemoryUtilization:       memoryUtilization,
+		DaemonSetCPU:            pool.ReservedCPU,
+		DaemonSetMemory:         pool.ReservedMemory,
+		CPUHeadroom:             cpuCapacity - int64(pool.CPUP95),
+		MemoryHeadroom:          memoryCapacity - int64(pool.MemoryP95),
+		RecommendedInstanceType: bestType,
+		RecommendedNodes:        bestNodes,
+		CurrentMonthlyCost:      currentCost,
+		RecommendedMonthlyCost:  bestCost,
+		EstimatedSavings:        currentCost - bestCost,
+		Rationale:               rationale,
+		Timestamp:               time.Now(),
+	}
+}
+
+// nodesNeeded returns how many nodes of the given schedulable size hold the pool's P95 demand at
+// OptimalUtilizationMin, and never fewer than one
+func (opt *OptimizerEngine) nodesNeeded(pool *nodePool, nodeCPU, nodeMemory int64) int {
+	target := opt.cfg().OptimalUtilizationMin
+	byCPU := math.Ceil(pool.CPUP95 / (float64(nodeCPU) * target))
+	byMemory := math.Ceil(pool.MemoryP95 / (float64(nodeMemory) * target))
+	return int(math.Max(1, math.Max(byCPU, byMemory)))
+}
+
+// nodeHourlyPrice returns the hourly price of an instance type from NodeInstanceTypes, falling back
+// to pricing the node's allocatable resources at its rates from the pricing provider
+func (opt *OptimizerEngine) nodeHourlyPrice(instanceType, node string, cpu, memory int64) float64 {
+	for _, candidate := range opt.cfg().NodeInstanceTypes {
+		if candidate.Name == instanceType {
+			return candidate.HourlyPrice
+		}
+	}
+	pricing := opt.cfg().Pricing
+	return convertMillicoresToVCPU(cpu)*pricing.CPUCostPerVCPUHour(node) + convertBytesToGB(memory)*pricing.MemoryCostPerGBHour(node)
+}
+
+// seriesPercentile returns a percentile of the values of a series
+func seriesPercentile(points []models.DataPoint, percentile float64) float64 {
+	values := extractValues(points)
+	sort.Float64s(values)
+	return calculatePercentile(values, percentile)
+}
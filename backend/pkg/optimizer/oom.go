@@ -0,0 +1,84 @@
+package optimizer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// oomKillHealthPenalty is the health score deduction per OOM kill, twice that of other restarts: an
+// OOM kill loses in-flight work and will recur until the memory limit is raised
+const oomKillHealthPenalty = 6.0
+
+// generateOOMKillRecommendation recommends raising the memory of a workload whose containers were
+// OOM-killed. Usage is cut off at the limit when a container is killed, so the limit is raised by the
+// under-provisioned buffer rather than sized from observed usage, and the request follows P95 usage
+// so the scheduler reserves what the workload really uses.
+func (rg *recommendationGenerator) generateOOMKillRecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+	if !analysis.MemoryOOMKilled {
+		return nil
+	}
+
+	buffer := rg.optimizer.cfg().UnderProvisionedBuffer
+
+	var request, limit int64
+	var sizing string
+	if metrics.MemoryLimit > 0 {
+		limit = rg.roundMemory(int64(float64(metrics.MemoryLimit) * buffer))
+		request = rg.roundMemory(int64(float64(metrics.MemoryP95) * buffer))
+		if request < metrics.MemoryRequested {
+			request = metrics.MemoryRequested
+		}
+		if request > limit || rg.optimizer.cfg().MemoryLimitRatio == MemoryLimitEqual {
+			request = limit
+		}
+		sizing = fmt.Sprintf("Recommended limit = current limit %s x %.2f buffer",
+			formatResourceQuantity(metrics.MemoryLimit, "memory"), buffer)
+	} else {
+		// Without a limit the kills came from node memory pressure: size from the peak
+		peak := metrics.MemoryMax
+		if metrics.MemoryP99 > peak {
+			peak = metrics.MemoryP99
+		}
+		request = rg.roundMemory(int64(float64(peak) * buffer))
+		if request < metrics.MemoryRequested {
+			request = metrics.MemoryRequested
+		}
+		limit = rg.recommendedMemoryLimit(metrics, request)
+		sizing = fmt.Sprintf("Recommended request = peak usage %s x %.2f buffer",
+			formatResourceQuantity(peak, "memory"), buffer)
+	}
+
+	currentConfig := resourceConfig{
+		MemoryRequest: formatResourceQuantity(metrics.MemoryRequested, "memory"),
+		MemoryLimit:   formatResourceQuantity(metrics.MemoryLimit, "memory"),
+	}
+	recommendedConfig := resourceConfig{
+		MemoryRequest: formatResourceQuantity(request, "memory"),
+		MemoryLimit:   formatResourceQuantity(limit, "memory"),
+	}
+
+	return &models.Recommendation{
+		ID:         uuid.New().String(),
+		Type:       string(RecommendationTypeResource),
+		Namespace:  metrics.Namespace,
+		Deployment: metrics.Deployment,
+		Priority:   string(PriorityHigh),
+		Description: fmt.Sprintf("Raise memory limit from %s to %s: %d containers were last terminated by the OOM killer",
+			formatResourceQuantity(metrics.MemoryLimit, "memory"), formatResourceQuantity(limit, "memory"), metrics.OOMKillCount),
+		CurrentConfig:     convertResourceConfigToMap(currentConfig),
+		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
+		EstimatedSavings:  0.0,
+		Impact:            "Stability improvement - fewer OOM kills and lost in-flight requests",
+		Rationale: []string{
+			fmt.Sprintf("%d containers have %s as their last termination reason",
+				metrics.OOMKillCount, RestartReasonOOMKilled),
+			"Killed containers never record the memory they needed, so observed usage understates demand",
+			sizing,
+		},
+		CreatedAt: time.Now(),
+	}
+}
@@ -3,11 +3,22 @@ package optimizer
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/internal/parallel"
 	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -16,6 +27,12 @@ type Optimizer interface {
 	// AnalyzeDeployment analyzes a specific deployment
 	AnalyzeDeployment(namespace, name string) (*models.Analysis, error)
 
+	// AnalyzeDeploymentWithWindow analyzes a specific deployment over a caller-specified time window
+	AnalyzeDeploymentWithWindow(namespace, name string, window time.Duration) (*models.Analysis, error)
+
+	// AnalyzeWorkload analyzes a Deployment, StatefulSet or DaemonSet
+	AnalyzeWorkload(namespace, kind, name string) (*models.Analysis, error)
+
 	// GenerateRecommendations generates optimization recommendations
 	GenerateRecommendations(analysis *models.Analysis) ([]models.Recommendation, error)
 
@@ -25,29 +42,98 @@ type Optimizer interface {
 	// EstimateCostSavings estimates cost savings from a recommendation
 	EstimateCostSavings(recommendation *models.Recommendation) (float64, error)
 
-	// ApplyRecommendation applies an optimization recommendation
-	ApplyRecommendation(recommendationID string) error
+	// ApplyRecommendation applies an optimization recommendation, or only computes the patch when dryRun is set
+	ApplyRecommendation(recommendationID string, dryRun bool) (*models.RecommendationPatch, error)
+
+	// RecommendationManifest renders the patch applying a recommendation as a YAML manifest
+	RecommendationManifest(recommendationID string) (string, error)
+
+	// RecommendationCommand returns the kubectl command that applies a recommendation, or "" if it has none
+	RecommendationCommand(recommendationID string) (string, error)
 
-	// GetAllRecommendations gets all active recommendations
+	// RevertRecommendation restores the configuration from before a recommendation was applied
+	RevertRecommendation(recommendationID string) error
+
+	// DismissRecommendation removes a recommendation and suppresses similar ones for a cooldown window
+	DismissRecommendation(recommendationID string) error
+
+	// GetAllRecommendations gets all recommendations, including applied ones
 	GetAllRecommendations() ([]models.Recommendation, error)
+
+	// GetActiveRecommendations gets the recommendations that are neither applied, dismissed nor superseded
+	GetActiveRecommendations() ([]models.Recommendation, error)
+
+	// GetRecommendationStats counts active recommendations by priority and type and sums their savings
+	GetRecommendationStats() map[string]interface{}
+
+	// GetTotalPotentialSavings sums the estimated monthly savings of all active recommendations
+	GetTotalPotentialSavings() (float64, error)
+
+	// AnalyzeContainer analyzes a single container of a deployment and recommends changes to it
+	AnalyzeContainer(namespace, name, container string) (*models.ContainerAnalysis, error)
+
+	// RecommendVPA computes VPA-style lower bound, target and upper bound per container of a deployment
+	RecommendVPA(namespace, name string) (*models.VPARecommendation, error)
+
+	// SimulateHPA replays historical CPU usage against a proposed HPA configuration
+	SimulateHPA(namespace, name string, proposed HPAConfig) (*models.HPASimulation, error)
+
+	// SimulateRecommendations projects the cluster's monthly cost if the given recommendations were applied
+	SimulateRecommendations(recommendationIDs []string) (*models.WhatIfSimulation, error)
+
+	// GetDeploymentSummaries returns the latest cached figures for every analyzed or recommended deployment
+	GetDeploymentSummaries() []models.DeploymentSummary
+
+	// RecommendNodeSizing suggests fewer or cheaper nodes for consistently under-used node pools
+	RecommendNodeSizing() ([]models.NodeSizingRecommendation, error)
+
+	// RecommendNodeConsolidation bin-packs pod requests onto fewer nodes and reports drainable nodes
+	RecommendNodeConsolidation() (*models.NodeConsolidation, error)
+
+	// FindIdleWorkloads returns the workloads in the namespaces that use almost no CPU and flat memory
+	FindIdleWorkloads(namespaces []string) ([]models.IdleWorkload, error)
+
+	// GetConfig returns the current optimizer configuration
+	GetConfig() Config
+
+	// UpdateConfig changes runtime-tunable thresholds, buffers and cost rates and invalidates cached analyses
+	UpdateConfig(update ConfigUpdate) (Config, error)
 }
 
 // OptimizerEngine implements the Optimizer interface
 type OptimizerEngine struct {
 	k8sClient         *k8s.Client
 	collector         collector.MetricsCollector
-	config            Config
+	config            atomic.Pointer[Config] // replaced wholesale by UpdateConfig, read through cfg
+	configMu          sync.Mutex             // serializes UpdateConfig
 	analyzer          *resourceAnalyzer
 	recommendationGen *recommendationGenerator
 	scorer            *scorer
 
-	// In-memory storage for recommendations
+	// In-memory storage for recommendations, dismissals (namespace/deployment/type -> dismissed at) and
+	// the latest generation per workload, for the recommendation cooldown and to drop superseded
+	// recommendations, all guarded by recommendationsMu
 	recommendations   map[string]models.Recommendation
+	dismissals        map[string]time.Time
+	generations       map[string]recommendationGeneration
 	recommendationsMu sync.RWMutex
 
 	// Cache for analysis results
 	analysisCache   map[string]*analysisResult
 	analysisCacheMu sync.RWMutex
+
+	// Coalesces concurrent analyses of the same deployment
+	inflight analysisGroup
+}
+
+// clampLimitRatio raises a limit ratio between 0 and 1 to 1, since a limit below the request is
+// rejected by the API server. Ratios of 0 or less mean no limit and are kept.
+func clampLimitRatio(name string, ratio float64) float64 {
+	if ratio > 0 && ratio < 1 {
+		slog.Warn("Limit ratio below 1 would recommend limits under requests; using 1", "field", name, "ratio", ratio)
+		return 1
+	}
+	return ratio
 }
 
 // New creates a new optimizer with default configuration
@@ -57,14 +143,25 @@ func New(k8sClient *k8s.Client, collector collector.MetricsCollector) *Optimizer
 
 // NewWithConfig creates a new optimizer with custom configuration
 func NewWithConfig(k8sClient *k8s.Client, collector collector.MetricsCollector, config Config) *OptimizerEngine {
+	if config.Pricing == nil {
+		config.Pricing = pricing.DefaultProvider()
+	}
+	config.CPULimitRatio = clampLimitRatio("CPULimitRatio", config.CPULimitRatio)
+	config.MemoryLimitRatio = clampLimitRatio("MemoryLimitRatio", config.MemoryLimitRatio)
+	config.protectedPatterns = compileWorkloadPatterns("ProtectedWorkloadPatterns", config.ProtectedWorkloadPatterns)
+	config.latencyCriticalPatterns = compileWorkloadPatterns("LatencyCriticalPatterns", config.LatencyCriticalPatterns)
+
 	opt := &OptimizerEngine{
 		k8sClient:       k8sClient,
 		collector:       collector,
-		config:          config,
 		recommendations: make(map[string]models.Recommendation),
+		dismissals:      make(map[string]time.Time),
+		generations:     make(map[string]recommendationGeneration),
 		analysisCache:   make(map[string]*analysisResult),
 	}
 
+	opt.config.Store(&config)
+
 	// Initialize components
 	opt.analyzer = newResourceAnalyzer(opt)
 	opt.recommendationGen = newRecommendationGenerator(opt)
@@ -73,19 +170,47 @@ func NewWithConfig(k8sClient *k8s.Client, collector collector.MetricsCollector,
 	return opt
 }
 
-// AnalyzeDeployment analyzes a specific deployment
+// AnalyzeDeployment analyzes a specific deployment over the configured analysis duration
 func (opt *OptimizerEngine) AnalyzeDeployment(namespace, name string) (*models.Analysis, error) {
-	// Perform internal analysis
-	internalAnalysis, err := opt.analyzer.analyzeDeployment(namespace, name)
+	return opt.AnalyzeDeploymentWithWindow(namespace, name, opt.cfg().AnalysisDuration)
+}
+
+// AnalyzeDeploymentWithWindow analyzes a specific deployment over the given time window. Only
+// analyses over the configured analysis duration are cached for recommendation generation.
+func (opt *OptimizerEngine) AnalyzeDeploymentWithWindow(namespace, name string, window time.Duration) (*models.Analysis, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("analysis window must be positive, got %v", window)
+	}
+
+	cacheKey := fmt.Sprintf("%s/%s", namespace, name)
+	defaultWindow := window == opt.cfg().AnalysisDuration
+
+	// Perform internal analysis, sharing the work with identical concurrent requests
+	analyze := func() (*analysisResult, error) {
+		return opt.analyzer.analyzeDeploymentWindow(namespace, name, window)
+	}
+
+	var internalAnalysis *analysisResult
+	var err error
+	if opt.cfg().CoalesceAnalyses {
+		inflightKey := cacheKey
+		if !defaultWindow {
+			inflightKey = fmt.Sprintf("%s@%s", cacheKey, window)
+		}
+		internalAnalysis, err = opt.inflight.do(inflightKey, analyze)
+	} else {
+		internalAnalysis, err = analyze()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze deployment: %w", err)
 	}
 
 	// Cache the internal analysis
-	cacheKey := fmt.Sprintf("%s/%s", namespace, name)
-	opt.analysisCacheMu.Lock()
-	opt.analysisCache[cacheKey] = internalAnalysis
-	opt.analysisCacheMu.Unlock()
+	if defaultWindow {
+		opt.analysisCacheMu.Lock()
+		opt.analysisCache[cacheKey] = internalAnalysis
+		opt.analysisCacheMu.Unlock()
+	}
 
 	// Convert to public Analysis model
 	analysis := opt.convertToPublicAnalysis(internalAnalysis)
@@ -96,14 +221,19 @@ func (opt *OptimizerEngine) AnalyzeDeployment(namespace, name string) (*models.A
 // GenerateRecommendations generates optimization recommendations
 func (opt *OptimizerEngine) GenerateRecommendations(analysis *models.Analysis) ([]models.Recommendation, error) {
 	// Get the internal analysis from cache
-	cacheKey := fmt.Sprintf("%s/%s", analysis.Namespace, analysis.Deployment)
+	cacheKey := analysisCacheKey(analysis.Namespace, analysis.Kind, analysis.Deployment)
+
+	// Serve the workload's current recommendations while it is within the cooldown
+	if recommendations, ok := opt.recentRecommendations(cacheKey); ok {
+		return recommendations, nil
+	}
 	opt.analysisCacheMu.RLock()
 	internalAnalysis, exists := opt.analysisCache[cacheKey]
 	opt.analysisCacheMu.RUnlock()
 
 	if !exists {
 		// If not in cache, re-analyze
-		_, err := opt.AnalyzeDeployment(analysis.Namespace, analysis.Deployment)
+		_, err := opt.AnalyzeWorkload(analysis.Namespace, analysis.Kind, analysis.Deployment)
 		if err != nil {
 			return nil, fmt.Errorf("failed to re-analyze deployment: %w", err)
 		}
@@ -119,13 +249,48 @@ func (opt *OptimizerEngine) GenerateRecommendations(analysis *models.Analysis) (
 		return nil, fmt.Errorf("failed to generate recommendations: %w", err)
 	}
 
-	// Store recommendations in memory
+	// Record the workload kind so applying patches the right object
+	for i := range recommendations {
+		recommendations[i].Kind = internalAnalysis.Deployment.Kind
+		if recommendations[i].Kind == "" {
+			recommendations[i].Kind = WorkloadKindDeployment
+		}
+	}
+
+	// Separate cash savings from capacity absorbed by commitments
+	opt.splitCommittedSavings(recommendations)
+	opt.setBreakEven(recommendations)
+
+	// Store recommendations in memory, skipping any that repeat an already applied change or were
+	// recently dismissed. They supersede the workload's previous generation, whose unapplied
+	// recommendations are dropped; applied ones are kept so they can be reverted.
 	opt.recommendationsMu.Lock()
+	if previous, ok := opt.generations[cacheKey]; ok {
+		for _, id := range previous.ids {
+			if rec, exists := opt.recommendations[id]; exists && rec.AppliedAt.IsZero() {
+				delete(opt.recommendations, id)
+			}
+		}
+	}
+	fresh := recommendations[:0]
 	for _, rec := range recommendations {
+		if isAppliedDuplicate(rec, opt.recommendations) || opt.isDismissed(rec) {
+			continue
+		}
 		opt.recommendations[rec.ID] = rec
+		fresh = append(fresh, rec)
 	}
+	recommendations = fresh
+	opt.generations[cacheKey] = newRecommendationGeneration(recommendations)
 	opt.recommendationsMu.Unlock()
 
+	// Advisory annotations are only written to Deployments
+	if opt.cfg().WriteAdvisoryAnnotations && internalAnalysis.Deployment.Kind == WorkloadKindDeployment {
+		if err := opt.writeAdvisoryAnnotations(analysis.Namespace, analysis.Deployment, recommendations); err != nil {
+			return recommendations, fmt.Errorf("failed to write advisory annotations: %w", err)
+		}
+	}
+
 	return recommendations, nil
 }
 
@@ -156,23 +321,184 @@ func (opt *OptimizerEngine) EstimateCostSavings(recommendation *models.Recommend
 	return recommendation.EstimatedSavings, nil
 }
 
-// ApplyRecommendation applies an optimization recommendation
-func (opt *OptimizerEngine) ApplyRecommendation(recommendationID string) error {
+// ApplyRecommendation applies an optimization recommendation by patching the target deployment
+// or HPA. With dryRun the patch is computed and returned without being sent.
+func (opt *OptimizerEngine) ApplyRecommendation(recommendationID string, dryRun bool) (*models.RecommendationPatch, error) {
 	// Get the recommendation
 	opt.recommendationsMu.RLock()
-	_, exists := opt.recommendations[recommendationID]
+	rec, exists := opt.recommendations[recommendationID]
 	opt.recommendationsMu.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("recommendation not found: %s", recommendationID)
+		return nil, fmt.Errorf("recommendation %w: %s", ErrNotFound, recommendationID)
+	}
+	if !rec.AppliedAt.IsZero() {
+		return nil, fmt.Errorf("recommendation %s was already applied at %s", recommendationID, rec.AppliedAt.Format(time.RFC3339))
+	}
+
+	patch, err := opt.buildRecommendationPatch(&rec)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		patch.DryRun = true
+		return patch, nil
+	}
+
+	// Snapshot the live configuration so the change can be reverted
+	previous, err := opt.liveConfig(&rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot current configuration: %w", err)
+	}
+
+	if err := opt.sendRecommendationPatch(patch); err != nil {
+		return nil, err
 	}
 
-	// Note: Actual application of recommendations is out of scope per requirements
-	// This method would implement the actual Kubernetes resource updates
-	return fmt.Errorf("applying recommendations is not implemented (out of scope)")
+	applied, err := opt.liveConfig(&rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot applied configuration: %w", err)
+	}
+
+	patch.AppliedAt = time.Now()
+	opt.markApplied(recommendationID, patch.AppliedAt, previous, applied)
+	opt.invalidateAnalysis(rec.Namespace, recommendationKind(&rec), rec.Deployment)
+
+	return patch, nil
 }
 
-// GetAllRecommendations gets all active recommendations
+// RevertRecommendation restores the configuration captured before an applied recommendation.
+// It refuses to revert when the live resource no longer matches what was applied.
+func (opt *OptimizerEngine) RevertRecommendation(recommendationID string) error {
+	opt.recommendationsMu.RLock()
+	rec, exists := opt.recommendations[recommendationID]
+	opt.recommendationsMu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("recommendation %w: %s", ErrNotFound, recommendationID)
+	}
+	if rec.AppliedAt.IsZero() {
+		return fmt.Errorf("recommendation %s %w", recommendationID, ErrNotApplied)
+	}
+
+	previous, ok := rec.PreviousConfig.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("recommendation %s has no recorded previous configuration", recommendationID)
+	}
+	applied, _ := rec.AppliedConfig.(map[string]interface{})
+
+	live, err := opt.liveConfig(&rec)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(live, applied) {
+		return fmt.Errorf("refusing to revert: %s/%s %w of recommendation %s", rec.Namespace, rec.Deployment, ErrDrifted, recommendationID)
+	}
+
+	// Restore previous values and remove anything the apply added
+	revert := make(map[string]interface{}, len(previous))
+	for key, value := range previous {
+		revert[key] = value
+	}
+	for key := range applied {
+		if _, ok := previous[key]; !ok {
+			revert[key] = nil
+		}
+	}
+
+	patch, err := opt.buildConfigPatch(&rec, revert)
+	if err != nil {
+		return err
+	}
+	if err := opt.sendRecommendationPatch(patch); err != nil {
+		return err
+	}
+
+	opt.markReverted(recommendationID, time.Now())
+	opt.invalidateAnalysis(rec.Namespace, recommendationKind(&rec), rec.Deployment)
+
+	return nil
+}
+
+// DismissRecommendation removes a reviewed recommendation and suppresses regenerating recommendations
+// of the same type for the same deployment until the dismissal cooldown passes
+func (opt *OptimizerEngine) DismissRecommendation(recommendationID string) error {
+	opt.recommendationsMu.Lock()
+	defer opt.recommendationsMu.Unlock()
+
+	rec, exists := opt.recommendations[recommendationID]
+	if !exists {
+		return fmt.Errorf("recommendation %w: %s", ErrNotFound, recommendationID)
+	}
+
+	delete(opt.recommendations, recommendationID)
+	opt.dismissals[dismissalKey(rec)] = time.Now()
+
+	return nil
+}
+
+// isDismissed reports whether a recommendation's deployment and type were dismissed within the
+// cooldown window, forgetting expired dismissals. Caller must hold the write lock.
+func (opt *OptimizerEngine) isDismissed(rec models.Recommendation) bool {
+	key := dismissalKey(rec)
+	dismissedAt, ok := opt.dismissals[key]
+	if !ok {
+		return false
+	}
+	if time.Since(dismissedAt) >= opt.cfg().DismissalCooldown {
+		delete(opt.dismissals, key)
+		return false
+	}
+	return true
+}
+
+// recommendationGeneration records when a workload's recommendations were last generated and their IDs
+type recommendationGeneration struct {
+	generatedAt time.Time
+	ids         []string
+}
+
+// newRecommendationGeneration records a generation of recommendations made now
+func newRecommendationGeneration(recommendations []models.Recommendation) recommendationGeneration {
+	ids := make([]string, len(recommendations))
+	for i, rec := range recommendations {
+		ids[i] = rec.ID
+	}
+	return recommendationGeneration{generatedAt: time.Now(), ids: ids}
+}
+
+// recentRecommendations returns the workload's recommendations from its last generation when that was
+// within the recommendation cooldown. Recommendations since applied, or dismissed along with others
+// of their type, are left out.
+func (opt *OptimizerEngine) recentRecommendations(cacheKey string) ([]models.Recommendation, bool) {
+	if opt.cfg().RecommendationCooldown <= 0 {
+		return nil, false
+	}
+
+	opt.recommendationsMu.Lock()
+	defer opt.recommendationsMu.Unlock()
+
+	generation, ok := opt.generations[cacheKey]
+	if !ok || time.Since(generation.generatedAt) >= opt.cfg().RecommendationCooldown {
+		return nil, false
+	}
+
+	recommendations := make([]models.Recommendation, 0, len(generation.ids))
+	for _, id := range generation.ids {
+		if rec, exists := opt.recommendations[id]; exists && rec.AppliedAt.IsZero() && !opt.isDismissed(rec) {
+			recommendations = append(recommendations, rec)
+		}
+	}
+	return recommendations, true
+}
+
+// dismissalKey identifies the recommendations a dismissal suppresses
+func dismissalKey(rec models.Recommendation) string {
+	return fmt.Sprintf("%s/%s/%s", rec.Namespace, rec.Deployment, rec.Type)
+}
+
+// GetAllRecommendations gets all recommendations, including applied ones, ordered by SortByPriority
 func (opt *OptimizerEngine) GetAllRecommendations() ([]models.Recommendation, error) {
 	opt.recommendationsMu.RLock()
 	defer opt.recommendationsMu.RUnlock()
@@ -181,6 +507,24 @@ func (opt *OptimizerEngine) GetAllRecommendations() ([]models.Recommendation, er
 	for _, rec := range opt.recommendations {
 		recommendations = append(recommendations, rec)
 	}
+	SortByPriority(recommendations)
+
+	return recommendations, nil
+}
+
+// GetActiveRecommendations gets the recommendations counted by GetRecommendationStats, ordered by
+// SortByPriority
+func (opt *OptimizerEngine) GetActiveRecommendations() ([]models.Recommendation, error) {
+	opt.recommendationsMu.RLock()
+	defer opt.recommendationsMu.RUnlock()
+
+	recommendations := make([]models.Recommendation, 0, len(opt.recommendations))
+	for _, rec := range opt.recommendations {
+		if opt.isActive(rec) {
+			recommendations = append(recommendations, rec)
+		}
+	}
+	SortByPriority(recommendations)
 
 	return recommendations, nil
 }
@@ -192,7 +536,7 @@ func (opt *OptimizerEngine) GetRecommendationByID(id string) (*models.Recommenda
 
 	rec, exists := opt.recommendations[id]
 	if !exists {
-		return nil, fmt.Errorf("recommendation not found: %s", id)
+		return nil, fmt.Errorf("recommendation %w: %s", ErrNotFound, id)
 	}
 
 	return &rec, nil
@@ -231,7 +575,12 @@ func (opt *OptimizerEngine) ClearCache() {
 
 // GetConfig returns the current optimizer configuration
 func (opt *OptimizerEngine) GetConfig() Config {
-	return opt.config
+	return *opt.cfg()
+}
+
+// cfg returns the current configuration, which must not be modified
+func (opt *OptimizerEngine) cfg() *Config {
+	return opt.config.Load()
 }
 
 // convertToPublicAnalysis converts internal analysisResult to public models.Analysis
@@ -241,8 +590,11 @@ func (opt *OptimizerEngine) convertToPublicAnalysis(internal *analysisResult) *m
 	return &models.Analysis{
 		Namespace:  metrics.Namespace,
 		Deployment: metrics.Deployment,
+		Kind:       metrics.Kind,
 		CPUUsage: models.ResourceAnalysis{
 			Requested:   metrics.CPURequested,
+			Overhead:    metrics.CPUOverhead,
+			Effective:   metrics.effectiveCPURequest(),
 			Current:     metrics.CPUCurrent,
 			P50:         metrics.CPUP50,
 			P95:         metrics.CPUP95,
@@ -254,6 +606,8 @@ func (opt *OptimizerEngine) convertToPublicAnalysis(internal *analysisResult) *m
 		},
 		MemoryUsage: models.ResourceAnalysis{
 			Requested:   metrics.MemoryRequested,
+			Overhead:    metrics.MemoryOverhead,
+			Effective:   metrics.effectiveMemoryRequest(),
 			Current:     metrics.MemoryCurrent,
 			P50:         metrics.MemoryP50,
 			P95:         metrics.MemoryP95,
@@ -269,9 +623,78 @@ func (opt *OptimizerEngine) convertToPublicAnalysis(internal *analysisResult) *m
 			Max:         metrics.MaxReplicas,
 			Recommended: opt.calculateRecommendedReplicas(internal),
 		},
-		HealthScore: opt.scorer.calculateHealthScore(internal),
-		Timestamp:   internal.Timestamp,
+		HealthScore:      opt.scorer.calculateHealthScore(internal),
+		RestartCount:     metrics.RestartCount,
+		RestartBreakdown: metrics.RestartBreakdown,
+		OOMKillCount:     metrics.OOMKillCount,
+		Protected:        opt.isProtectedWorkload(metrics.Deployment),
+		LatencyCritical:  metrics.LatencyCritical,
+		QoSClass:         metrics.QoSClass,
+		CPUThrottleRatio: metrics.CPUThrottleRatio,
+		Provisional:      internal.Provisional,
+		TooNew:           internal.TooNew,
+		Window:           internal.Deployment.Window,
+		DataPointCount:   len(internal.Deployment.CPUTimeSeries),
+		Confidence:       internal.Confidence,
+		Trends: models.TrendAnalysis{
+			CPU:    calculateResourceTrend(metrics.CPUTimeSeries),
+			Memory: calculateResourceTrend(metrics.MemoryTimeSeries),
+		},
+		Timestamp: internal.Timestamp,
+	}
+}
+
+// isProtectedWorkload reports whether a deployment name matches one of the protected workload patterns
+func (opt *OptimizerEngine) isProtectedWorkload(name string) bool {
+	return matchesWorkloadPattern(opt.cfg().protectedPatterns, name)
+}
+
+// workloadPattern is a compiled workload name pattern: a glob, or a regular expression
+type workloadPattern struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+// compileWorkloadPatterns compiles workload name patterns, which are globs, or regular expressions
+// when wrapped in slashes. Invalid patterns are logged and dropped, so they never match.
+func compileWorkloadPatterns(field string, patterns []string) []workloadPattern {
+	compiled := make([]workloadPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			regex, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				slog.Warn("Ignoring invalid workload pattern", "field", field, "pattern", pattern, "error", err)
+				continue
+			}
+			compiled = append(compiled, workloadPattern{regex: regex})
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			slog.Warn("Ignoring invalid workload pattern", "field", field, "pattern", pattern, "error", err)
+			continue
+		}
+		compiled = append(compiled, workloadPattern{glob: pattern})
 	}
+	return compiled
+}
+
+// matchesWorkloadPattern reports whether a workload name matches one of the compiled patterns
+func matchesWorkloadPattern(patterns []workloadPattern, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.regex != nil {
+			if pattern.regex.MatchString(name) {
+				return true
+			}
+			continue
+		}
+
+		if matched, _ := path.Match(pattern.glob, name); matched {
+			return true
+		}
+	}
+
+	return false
 }
 
 // calculateRecommendedReplicas calculates recommended replica count
@@ -285,7 +708,10 @@ func (opt *OptimizerEngine) calculateRecommendedReplicas(analysis *analysisResul
 
 	// Otherwise, calculate based on utilization
 	if analysis.CPUUtilization > 0.8 || analysis.MemoryUtilization > 0.8 {
-		// High utilization - recommend scaling up
+		// High utilization - recommend scaling up if the namespace quota allows it
+		if analysis.QuotaPressure != nil && analysis.QuotaPressure.NearLimit {
+			return metrics.CurrentReplicas
+		}
 		return metrics.CurrentReplicas + 1
 	} else if analysis.CPUUtilization < 0.5 && analysis.MemoryUtilization < 0.5 && metrics.CurrentReplicas > 1 {
 		// Low utilization - recommend scaling down
@@ -296,9 +722,14 @@ func (opt *OptimizerEngine) calculateRecommendedReplicas(analysis *analysisResul
 	return metrics.CurrentReplicas
 }
 
-// AnalyzeAllDeployments analyzes all deployments in given namespaces
+// AnalyzeAllDeployments analyzes all deployments in given namespaces, running up to AnalysisConcurrency
+// analyses at once. Deployments that fail to analyze are logged and skipped; results keep the listing order.
 func (opt *OptimizerEngine) AnalyzeAllDeployments(namespaces []string) ([]models.Analysis, error) {
-	var allAnalyses []models.Analysis
+	type target struct {
+		namespace string
+		name      string
+	}
+	var targets []target
 
 	for _, namespace := range namespaces {
 		// List all deployments in namespace
@@ -310,18 +741,31 @@ func (opt *OptimizerEngine) AnalyzeAllDeployments(namespaces []string) ([]models
 			return nil, fmt.Errorf("failed to list deployments in namespace %s: %w", namespace, err)
 		}
 
-		// Analyze each deployment
 		for _, deployment := range deploymentList.Items {
-			analysis, err := opt.AnalyzeDeployment(namespace, deployment.Name)
-			if err != nil {
-				// Log error but continue with other deployments
-				fmt.Printf("Warning: failed to analyze deployment %s/%s: %v\n", namespace, deployment.Name, err)
-				continue
-			}
-			allAnalyses = append(allAnalyses, *analysis)
+			targets = append(targets, target{namespace: namespace, name: deployment.Name})
 		}
 	}
 
+	type result struct {
+		analysis *models.Analysis
+		err      error
+	}
+	results := make([]result, len(targets))
+	parallel.ForEach(len(targets), opt.cfg().AnalysisConcurrency, func(i int) {
+		analysis, err := opt.AnalyzeDeployment(targets[i].namespace, targets[i].name)
+		results[i] = result{analysis: analysis, err: err}
+	})
+
+	var allAnalyses []models.Analysis
+	for i, r := range results {
+		if r.err != nil {
+			// Log error but continue with other deployments
+			slog.Warn("Failed to analyze deployment", "namespace", targets[i].namespace, "deployment", targets[i].name, "error", r.err)
+			continue
+		}
+		allAnalyses = append(allAnalyses, *r.analysis)
+	}
+
 	return allAnalyses, nil
 }
 
@@ -339,8 +783,7 @@ func (opt *OptimizerEngine) GenerateAllRecommendations(namespaces []string) ([]m
 		recs, err := opt.GenerateRecommendations(&analysis)
 		if err != nil {
 			// Log error but continue
-			fmt.Printf("Warning: failed to generate recommendations for %s/%s: %v\n",
-				analysis.Namespace, analysis.Deployment, err)
+			slog.Warn("Failed to generate recommendations", "namespace", analysis.Namespace, "deployment", analysis.Deployment, "error", err)
 			continue
 		}
 		allRecommendations = append(allRecommendations, recs...)
@@ -356,32 +799,60 @@ func (opt *OptimizerEngine) GetTotalPotentialSavings() (float64, error) {
 
 	totalSavings := 0.0
 	for _, rec := range opt.recommendations {
-		totalSavings += rec.EstimatedSavings
+		if opt.isActive(rec) {
+			totalSavings += rec.EstimatedSavings
+		}
 	}
 
 	return totalSavings, nil
 }
 
+// isActive reports whether a recommendation is still pending: not applied, not dismissed and part of
+// its workload's latest generation. Container-scoped recommendations from AnalyzeContainer are made
+// outside generations and stay active until applied or dismissed. Caller must hold recommendationsMu.
+func (opt *OptimizerEngine) isActive(rec models.Recommendation) bool {
+	if !rec.AppliedAt.IsZero() {
+		return false
+	}
+	if dismissedAt, ok := opt.dismissals[dismissalKey(rec)]; ok && time.Since(dismissedAt) < opt.cfg().DismissalCooldown {
+		return false
+	}
+
+	generation, ok := opt.generations[analysisCacheKey(rec.Namespace, recommendationKind(&rec), rec.Deployment)]
+	return !ok || rec.Container != "" || slices.Contains(generation.ids, rec.ID)
+}
+
 // GetRecommendationStats returns statistics about recommendations
 func (opt *OptimizerEngine) GetRecommendationStats() map[string]interface{} {
 	opt.recommendationsMu.RLock()
 	defer opt.recommendationsMu.RUnlock()
 
 	stats := map[string]interface{}{
-		"total":           len(opt.recommendations),
+		"total":           0,
 		"high_priority":   0,
 		"medium_priority": 0,
 		"low_priority":    0,
 		"by_type": map[string]int{
-			"resource": 0,
-			"hpa":      0,
-			"scaling":  0,
+			string(RecommendationTypeResource):    0,
+			string(RecommendationTypeHPA):         0,
+			string(RecommendationTypeScaling):     0,
+			string(RecommendationTypeQuota):       0,
+			string(RecommendationTypeEventDriven): 0,
+			string(RecommendationTypeIdle):        0,
 		},
 	}
 
 	totalSavings := 0.0
+	cashSavings := 0.0
+	deployments := make(map[string]bool)
 
 	for _, rec := range opt.recommendations {
+		if !opt.isActive(rec) {
+			continue
+		}
+		stats["total"] = stats["total"].(int) + 1
+		deployments[rec.Namespace+"/"+rec.Deployment] = true
+
 		// Count by priority
 		switch rec.Priority {
 		case "high":
@@ -401,9 +872,62 @@ func (opt *OptimizerEngine) GetRecommendationStats() map[string]interface{} {
 
 		// Sum savings
 		totalSavings += rec.EstimatedSavings
+		cashSavings += rec.CashSavings
 	}
 
 	stats["total_savings"] = totalSavings
+	stats["cash_savings"] = cashSavings
+	stats["reclaimable_savings"] = totalSavings - cashSavings
+	stats["affected_deployments"] = len(deployments)
 
 	return stats
 }
+
+// GetDeploymentSummaries returns per-deployment efficiency, cost and recommendation counts from the
+// analysis cache and stored recommendations without re-analyzing, sorted by namespace and name
+func (opt *OptimizerEngine) GetDeploymentSummaries() []models.DeploymentSummary {
+	summaries := make(map[string]*models.DeploymentSummary)
+	summary := func(namespace, name string) *models.DeploymentSummary {
+		key := fmt.Sprintf("%s/%s", namespace, name)
+		if summaries[key] == nil {
+			summaries[key] = &models.DeploymentSummary{Namespace: namespace, Deployment: name}
+		}
+		return summaries[key]
+	}
+
+	opt.analysisCacheMu.RLock()
+	for _, analysis := range opt.analysisCache {
+		metrics := &analysis.Deployment
+		replicas := float64(metrics.CurrentReplicas)
+		wastedCPU, wastedMemory := opt.scorer.calculateWastedResources(analysis)
+
+		s := summary(metrics.Namespace, metrics.Deployment)
+		s.Analyzed = true
+		s.EfficiencyScore = opt.scorer.calculateEfficiencyScore(analysis)
+		s.MonthlyCost = opt.recommendationGen.calculatePodCost(metrics) * replicas
+		s.WastedCost = opt.scorer.calculateWastedCost(wastedCPU, wastedMemory) * replicas
+		s.Timestamp = analysis.Timestamp
+	}
+	opt.analysisCacheMu.RUnlock()
+
+	opt.recommendationsMu.RLock()
+	for _, rec := range opt.recommendations {
+		if rec.AppliedAt.IsZero() {
+			summary(rec.Namespace, rec.Deployment).RecommendationCount++
+		}
+	}
+	opt.recommendationsMu.RUnlock()
+
+	result := make([]models.DeploymentSummary, 0, len(summaries))
+	for _, s := range summaries {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Deployment < result[j].Deployment
+	})
+
+	return result
+}
@@ -0,0 +1,3736 @@
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/collector"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeCollector is an in-memory MetricsCollector for testing
+type fakeCollector struct {
+	mu     sync.RWMutex
+	series map[string][]models.DataPoint
+}
+
+func newFakeCollector() *fakeCollector {
+	return &fakeCollector{series: make(map[string][]models.DataPoint)}
+}
+
+func (f *fakeCollector) Start() error { return nil }
+func (f *fakeCollector) Stop()        {}
+func (f *fakeCollector) CollectPodMetrics(namespace string) ([]models.PodMetrics, error) {
+	return []models.PodMetrics{}, nil
+}
+func (f *fakeCollector) CollectNodeMetrics() ([]models.NodeMetrics, error) {
+	return []models.NodeMetrics{}, nil
+}
+func (f *fakeCollector) CollectHPAMetrics(namespace string) ([]models.HPAMetrics, error) {
+	return []models.HPAMetrics{}, nil
+}
+
+func (f *fakeCollector) GetTimeSeriesData(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	cutoff := time.Now().Add(-duration)
+	points := []models.DataPoint{}
+	for _, point := range f.series[resource+"/"+metric] {
+		if point.Timestamp.After(cutoff) {
+			points = append(points, point)
+		}
+	}
+
+	return models.TimeSeriesData{Resource: resource, Metric: metric, Points: points}, nil
+}
+
+func (f *fakeCollector) GetTimeSeriesDataWithGaps(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
+	return f.GetTimeSeriesData(resource, metric, duration)
+}
+
+func (f *fakeCollector) GetResourcePercentiles(resource, metric string, duration time.Duration) (p50, p95, p99 float64, err error) {
+	return 0, 0, 0, nil
+}
+
+func (f *fakeCollector) add(resource, metric string, points []models.DataPoint) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.series[resource+"/"+metric] = append(f.series[resource+"/"+metric], points...)
+}
+
+func (f *fakeCollector) set(resource, metric string, points []models.DataPoint) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.series[resource+"/"+metric] = points
+}
+
+// steadySeries returns n points of the given value spaced one minute apart, ending now
+func steadySeries(n int, value float64) []models.DataPoint {
+	now := time.Now()
+	points := make([]models.DataPoint, n)
+	for i := range points {
+		points[i] = models.DataPoint{Timestamp: now.Add(-time.Duration(n-i) * time.Minute), Value: value}
+	}
+	return points
+}
+
+// newTestDeployment returns a single-container deployment selecting app=<name>
+func newTestDeployment(name string, replicas int32, cpu, memory string) *appsv1.Deployment {
+	labels := map[string]string{"app": name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name + "-uid")},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse(cpu),
+								corev1.ResourceMemory: resource.MustParse(memory),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// newTestReplicaSet returns the ReplicaSet through which a deployment owns its test pods
+func newTestReplicaSet(deployment *appsv1.Deployment) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            deployment.Name + "-rs",
+			Namespace:       deployment.Namespace,
+			UID:             deployment.UID + "-rs",
+			Labels:          deployment.Spec.Template.Labels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+		},
+	}
+}
+
+// newTestPod returns a running pod carrying the deployment's labels, owned by its test ReplicaSet
+func newTestPod(deployment *appsv1.Deployment, name string) *corev1.Pod {
+	rs := newTestReplicaSet(deployment)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       deployment.Namespace,
+			Labels:          deployment.Spec.Template.Labels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(rs, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))},
+		},
+		Spec:   deployment.Spec.Template.Spec,
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+// newTestEngine returns an optimizer backed by a fake clientset seeded with objects, plus a
+// ReplicaSet for every deployment, and a fake collector holding steady CPU and memory series
+// for every pod
+func newTestEngine(config Config, objects ...runtime.Object) (*OptimizerEngine, *fake.Clientset, *fakeCollector) {
+	seeded := append([]runtime.Object{}, objects...)
+	for _, obj := range objects {
+		if deployment, ok := obj.(*appsv1.Deployment); ok {
+			seeded = append(seeded, newTestReplicaSet(deployment))
+		}
+	}
+	clientset := fake.NewClientset(seeded...)
+	mc := newFakeCollector()
+
+	for _, obj := range objects {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			mc.add("pod/"+pod.Name, "cpu", steadySeries(30, 100))
+			mc.add("pod/"+pod.Name, "memory", steadySeries(30, 128*1024*1024))
+		}
+	}
+
+	return NewWithConfig(&k8s.Client{Clientset: clientset}, mc, config), clientset, mc
+}
+
+// newTestAnalysis returns an analysis result for a deployment using 100m CPU and
+// 128Mi memory at P95, with the given requests and limits
+func newTestAnalysis(cpuRequest, cpuLimit, memoryRequest, memoryLimit int64) *analysisResult {
+	analysis := &analysisResult{
+		Deployment: deploymentMetrics{
+			Namespace:       "default",
+			Deployment:      "web",
+			CPURequested:    cpuRequest,
+			CPULimit:        cpuLimit,
+			CPUP95:          100,
+			MemoryRequested: memoryRequest,
+			MemoryLimit:     memoryLimit,
+			MemoryP95:       128 * 1024 * 1024,
+			CurrentReplicas: 1,
+		},
+		OverallScore: 50,
+		Confidence:   1,
+	}
+
+	analysis.CPUUtilization = float64(analysis.Deployment.CPUP95) / float64(cpuRequest)
+	analysis.MemoryUtilization = float64(analysis.Deployment.MemoryP95) / float64(memoryRequest)
+	analysis.CPUOverProvisioned = analysis.CPUUtilization < 0.5
+	analysis.MemoryOverProvisioned = analysis.MemoryUtilization < 0.5
+
+	return analysis
+}
+
+// resourceRecommendations filters recommendations down to resource right-sizing ones
+func resourceRecommendations(recs []models.Recommendation) []models.Recommendation {
+	var result []models.Recommendation
+	for _, rec := range recs {
+		if rec.Type == string(RecommendationTypeResource) {
+			result = append(result, rec)
+		}
+	}
+	return result
+}
+
+// TestGuaranteedQoSPreserved tests that right-sizing a Guaranteed deployment keeps request == limit
+func TestGuaranteedQoSPreserved(t *testing.T) {
+	opt := NewWithConfig(nil, nil, DefaultConfig())
+	analysis := newTestAnalysis(1000, 1000, 1024*1024*1024, 1024*1024*1024)
+
+	recs, err := opt.recommendationGen.generateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+
+	resourceRecs := resourceRecommendations(recs)
+	if len(resourceRecs) == 0 {
+		t.Fatal("Expected resource recommendations for over-provisioned deployment")
+	}
+
+	for _, rec := range resourceRecs {
+		config := rec.RecommendedConfig.(map[string]interface{})
+		if config["cpu_request"] != config["cpu_limit"] {
+			t.Errorf("Expected cpu_request == cpu_limit, got %v and %v", config["cpu_request"], config["cpu_limit"])
+		}
+		if config["memory_request"] != config["memory_limit"] {
+			t.Errorf("Expected memory_request == memory_limit, got %v and %v", config["memory_request"], config["memory_limit"])
+		}
+	}
+}
+
+// TestGuaranteedQoSIgnored tests that the ignore strategy sizes limits independently
+func TestGuaranteedQoSIgnored(t *testing.T) {
+	config := DefaultConfig()
+	config.GuaranteedQoSStrategy = QoSStrategyIgnore
+	opt := NewWithConfig(nil, nil, config)
+	analysis := newTestAnalysis(1000, 1000, 1024*1024*1024, 1024*1024*1024)
+
+	recs, _ := opt.recommendationGen.generateRecommendations(analysis)
+	for _, rec := range resourceRecommendations(recs) {
+		config := rec.RecommendedConfig.(map[string]interface{})
+		if cpuLimit, ok := config["cpu_limit"]; ok && cpuLimit == config["cpu_request"] {
+			t.Errorf("Expected independent CPU limit with ignore strategy, got %v", cpuLimit)
+		}
+	}
+}
+
+// TestBurstableLimitsUnchanged tests that Burstable deployments keep the default 2x limit
+func TestBurstableLimitsUnchanged(t *testing.T) {
+	opt := NewWithConfig(nil, nil, DefaultConfig())
+	analysis := newTestAnalysis(1000, 2000, 1024*1024*1024, 2*1024*1024*1024)
+
+	if isGuaranteedQoS(&analysis.Deployment) {
+		t.Fatal("Expected Burstable deployment not to be detected as Guaranteed")
+	}
+
+	limit := opt.recommendationGen.recommendedCPULimit(&analysis.Deployment, 120)
+	if limit != 240 {
+		t.Errorf("Expected limit 240 for Burstable deployment, got %d", limit)
+	}
+}
+
+// newTestQuota returns a ResourceQuota with the given hard and used CPU requests
+func newTestQuota(namespace, hardCPU, usedCPU string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: namespace},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse(hardCPU),
+				corev1.ResourcePods:        resource.MustParse("20"),
+			},
+			Used: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse(usedCPU),
+				corev1.ResourcePods:        resource.MustParse("5"),
+			},
+		},
+	}
+}
+
+// TestAnalyzeQuotaPressure tests quota headroom reporting
+func TestAnalyzeQuotaPressure(t *testing.T) {
+	client := &k8s.Client{Clientset: fake.NewClientset(newTestQuota("default", "4", "3800m"))}
+	opt := NewWithConfig(client, nil, DefaultConfig())
+
+	pressure, err := opt.AnalyzeQuotaPressure("default")
+	if err != nil {
+		t.Fatalf("AnalyzeQuotaPressure failed: %v", err)
+	}
+
+	if pressure == nil {
+		t.Fatal("Expected quota pressure for namespace with a ResourceQuota")
+	}
+
+	if !pressure.NearLimit {
+		t.Errorf("Expected namespace at 95%% of CPU quota to be near limit")
+	}
+
+	top := pressure.Resources[0]
+	if top.Resource != string(corev1.ResourceRequestsCPU) {
+		t.Errorf("Expected requests.cpu to be the most constrained resource, got %s", top.Resource)
+	}
+	if top.Headroom != 200 {
+		t.Errorf("Expected 200m CPU headroom, got %d", top.Headroom)
+	}
+	for _, usage := range pressure.Resources {
+		if usage.Resource == string(corev1.ResourcePods) {
+			t.Errorf("Expected the pods object-count quota to be ignored")
+		}
+	}
+
+	// Namespaces without quota report no pressure
+	pressure, err = opt.AnalyzeQuotaPressure("other")
+	if err != nil || pressure != nil {
+		t.Errorf("Expected no pressure for namespace without quota, got %v, %v", pressure, err)
+	}
+}
+
+// TestScaleUpSuppressedNearQuota tests that scale-up is replaced by a quota recommendation
+func TestScaleUpSuppressedNearQuota(t *testing.T) {
+	client := &k8s.Client{Clientset: fake.NewClientset(newTestQuota("default", "4", "3800m"))}
+	opt := NewWithConfig(client, nil, DefaultConfig())
+
+	// Hot deployment: P95 above 80% of requests
+	analysis := newTestAnalysis(110, 220, 150*1024*1024, 300*1024*1024)
+
+	recs, _ := opt.recommendationGen.generateRecommendations(analysis)
+	if !hasRecommendationType(recs, RecommendationTypeScaling) {
+		t.Fatal("Expected scale-up recommendation without quota pressure")
+	}
+
+	pressure, err := opt.AnalyzeQuotaPressure("default")
+	if err != nil {
+		t.Fatalf("AnalyzeQuotaPressure failed: %v", err)
+	}
+	analysis.QuotaPressure = pressure
+
+	recs, _ = opt.recommendationGen.generateRecommendations(analysis)
+	if hasRecommendationType(recs, RecommendationTypeScaling) {
+		t.Error("Expected scale-up to be suppressed near quota")
+	}
+	if !hasRecommendationType(recs, RecommendationTypeQuota) {
+		t.Error("Expected a quota right-sizing recommendation near quota")
+	}
+	if replicas := opt.calculateRecommendedReplicas(analysis); replicas != analysis.Deployment.CurrentReplicas {
+		t.Errorf("Expected recommended replicas to stay at %d near quota, got %d",
+			analysis.Deployment.CurrentReplicas, replicas)
+	}
+}
+
+// hasRecommendationType reports whether any recommendation has the given type
+func hasRecommendationType(recs []models.Recommendation, recType recommendationType) bool {
+	for _, rec := range recs {
+		if rec.Type == string(recType) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestAnalyzeDeploymentCoalescing tests that concurrent analyses of the same deployment share one collection
+func TestAnalyzeDeploymentCoalescing(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "500m", "512Mi")
+	opt, clientset, _ := newTestEngine(DefaultConfig(), deployment, newTestPod(deployment, "web-abc"))
+
+	var collections int32
+	clientset.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		atomic.AddInt32(&collections, 1)
+		time.Sleep(50 * time.Millisecond)
+		return false, nil, nil
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := opt.AnalyzeDeployment("default", "web"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&collections); got != 1 {
+		t.Errorf("Expected deployment metrics to be collected once, got %d", got)
+	}
+
+	// Later requests run a fresh analysis
+	if _, err := opt.AnalyzeDeployment("default", "web"); err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&collections); got != 2 {
+		t.Errorf("Expected a second collection after the first completed, got %d", got)
+	}
+}
+
+// TestProtectedWorkloadPatterns tests that protected workloads get no recommendations
+func TestProtectedWorkloadPatterns(t *testing.T) {
+	config := DefaultConfig()
+	config.ProtectedWorkloadPatterns = []string{"ingress-nginx-*", "/^kube-(proxy|dns)$/", "/kube-(/", "[web"}
+	opt := NewWithConfig(nil, nil, config)
+
+	// The invalid regular expression and glob are dropped when the config is set
+	if got := len(opt.cfg().protectedPatterns); got != 2 {
+		t.Errorf("Expected 2 compiled patterns, got %d", got)
+	}
+
+	tests := []struct {
+		name      string
+		protected bool
+	}{
+		{"ingress-nginx-controller", true},
+		{"kube-dns", true},
+		{"kube-dns-autoscaler", false},
+		{"web", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := newTestAnalysis(1000, 2000, 1024*1024*1024, 2*1024*1024*1024)
+			analysis.Deployment.Deployment = tt.name
+
+			recs, err := opt.recommendationGen.generateRecommendations(analysis)
+			if err != nil {
+				t.Fatalf("generateRecommendations failed: %v", err)
+			}
+
+			if tt.protected && len(recs) != 0 {
+				t.Errorf("Expected no recommendations for protected workload, got %d", len(recs))
+			}
+			if !tt.protected && len(recs) == 0 {
+				t.Errorf("Expected recommendations for unprotected over-provisioned workload")
+			}
+
+			public := opt.convertToPublicAnalysis(analysis)
+			if public.Protected != tt.protected {
+				t.Errorf("Expected Protected=%v, got %v", tt.protected, public.Protected)
+			}
+		})
+	}
+}
+
+// TestRecommendationRationale tests that recommendations explain the values that triggered them
+func TestRecommendationRationale(t *testing.T) {
+	opt := NewWithConfig(nil, nil, DefaultConfig())
+	analysis := newTestAnalysis(1000, 2000, 256*1024*1024, 512*1024*1024)
+
+	rec := opt.recommendationGen.generateCPURecommendation(analysis)
+	if rec == nil {
+		t.Fatal("Expected CPU recommendation for over-provisioned deployment")
+	}
+
+	rationale := strings.Join(rec.Rationale, "\n")
+	for _, want := range []string{
+		"CPU utilization 10.0%",
+		"P95 100m / request 1",
+		"over-provisioned threshold of 50.0%",
+		"x 1.20 buffer",
+	} {
+		if !strings.Contains(rationale, want) {
+			t.Errorf("Expected rationale to contain %q, got:\n%s", want, rationale)
+		}
+	}
+}
+
+// TestRecommendationRounding tests that recommended values are rounded up to clean increments
+func TestRecommendationRounding(t *testing.T) {
+	opt := NewWithConfig(nil, nil, DefaultConfig())
+	analysis := newTestAnalysis(1000, 2000, 1024*1024*1024, 2*1024*1024*1024)
+
+	// P95 198m x 1.2 = 237m, P95 100Mi x 1.2 = 120Mi
+	analysis.Deployment.CPUP95 = 198
+	analysis.Deployment.MemoryP95 = 100 * 1024 * 1024
+
+	cpuRec := opt.recommendationGen.generateCPURecommendation(analysis)
+	if cpuRec == nil {
+		t.Fatal("Expected CPU recommendation")
+	}
+	cpuConfig := cpuRec.RecommendedConfig.(map[string]interface{})
+	if cpuConfig["cpu_request"] != "250m" {
+		t.Errorf("Expected 237m to round up to 250m, got %v", cpuConfig["cpu_request"])
+	}
+	if cpuConfig["cpu_limit"] != "500m" {
+		t.Errorf("Expected limit 500m, got %v", cpuConfig["cpu_limit"])
+	}
+
+	memoryRec := opt.recommendationGen.generateMemoryRecommendation(analysis)
+	if memoryRec == nil {
+		t.Fatal("Expected memory recommendation")
+	}
+	memoryConfig := memoryRec.RecommendedConfig.(map[string]interface{})
+	if memoryConfig["memory_request"] != "128Mi" {
+		t.Errorf("Expected 120Mi to round up to 128Mi, got %v", memoryConfig["memory_request"])
+	}
+
+	// Rounding never goes below the need and leaves aligned values alone
+	tests := []struct {
+		value, increment, expected int64
+	}{
+		{237, 50, 250},
+		{250, 50, 250},
+		{251, 50, 300},
+		{237, 0, 237},
+	}
+	for _, tt := range tests {
+		if got := roundUpToIncrement(tt.value, tt.increment); got != tt.expected {
+			t.Errorf("roundUpToIncrement(%d, %d) = %d, expected %d", tt.value, tt.increment, got, tt.expected)
+		}
+	}
+}
+
+// TestFormatResourceQuantity tests that formatting does not truncate non-integral values
+func TestFormatResourceQuantity(t *testing.T) {
+	tests := []struct {
+		value        int64
+		resourceType string
+		expected     string
+	}{
+		{250, "cpu", "250m"},
+		{2000, "cpu", "2"},
+		{1500, "cpu", "1500m"},
+		{128 * 1024 * 1024, "memory", "128Mi"},
+		{2 * 1024 * 1024 * 1024, "memory", "2Gi"},
+		{1536 * 1024 * 1024, "memory", "1536Mi"},
+	}
+
+	for _, tt := range tests {
+		if got := formatResourceQuantity(tt.value, tt.resourceType); got != tt.expected {
+			t.Errorf("formatResourceQuantity(%d, %s) = %s, expected %s", tt.value, tt.resourceType, got, tt.expected)
+		}
+	}
+}
+
+// TestChurnReducesStabilityAndConfidence tests that frequent rollouts lower stability and defer right-sizing
+func TestChurnReducesStabilityAndConfidence(t *testing.T) {
+	opt := NewWithConfig(nil, nil, DefaultConfig())
+
+	stable := newTestAnalysis(1000, 2000, 1024*1024*1024, 2*1024*1024*1024)
+	opt.analyzer.analyzeChurn(stable)
+	opt.analyzer.calculateScores(stable)
+
+	churning := newTestAnalysis(1000, 2000, 1024*1024*1024, 2*1024*1024*1024)
+	churning.Deployment.RolloutCount = 70 // ten rollouts a day over the 7-day window
+	opt.analyzer.analyzeChurn(churning)
+	opt.analyzer.calculateScores(churning)
+
+	if !churning.HighChurn || stable.HighChurn {
+		t.Errorf("Expected only the churning deployment to be high-churn, got stable=%v churning=%v",
+			stable.HighChurn, churning.HighChurn)
+	}
+
+	if churning.Confidence >= stable.Confidence {
+		t.Errorf("Expected churn to reduce confidence, got stable=%.2f churning=%.2f",
+			stable.Confidence, churning.Confidence)
+	}
+
+	if churning.StabilityScore >= stable.StabilityScore {
+		t.Errorf("Expected churn to reduce stability, got stable=%.1f churning=%.1f",
+			stable.StabilityScore, churning.StabilityScore)
+	}
+
+	if opt.scorer.calculateStabilityScore(churning) >= opt.scorer.calculateStabilityScore(stable) {
+		t.Error("Expected scorer stability to be lower for the churning deployment")
+	}
+
+	if recs := opt.recommendationGen.generateResourceRecommendations(churning); len(recs) != 0 {
+		t.Errorf("Expected right-sizing to be deferred for high-churn deployment, got %d recommendations", len(recs))
+	}
+	if recs := opt.recommendationGen.generateResourceRecommendations(stable); len(recs) == 0 {
+		t.Error("Expected right-sizing for stable over-provisioned deployment")
+	}
+}
+
+// TestCountRecentRollouts tests counting a deployment's recent ReplicaSets
+func TestCountRecentRollouts(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "500m", "512Mi")
+
+	newReplicaSet := func(name string, age time.Duration, owner *appsv1.Deployment) *appsv1.ReplicaSet {
+		rs := &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				Labels:            deployment.Spec.Template.Labels,
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+		}
+		if owner != nil {
+			rs.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner, appsv1.SchemeGroupVersion.WithKind("Deployment"))}
+		}
+		return rs
+	}
+
+	other := newTestDeployment("other", 1, "500m", "512Mi")
+	opt, _, _ := newTestEngine(DefaultConfig(), deployment,
+		newReplicaSet("web-1", 30*24*time.Hour, deployment),
+		newReplicaSet("web-2", 2*24*time.Hour, deployment),
+		newReplicaSet("web-3", time.Hour, deployment),
+		newReplicaSet("stray", time.Hour, other),
+	)
+
+	count, err := opt.analyzer.countRecentRollouts(deployment, time.Now().Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("countRecentRollouts failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rollouts in the last 7 days, got %d", count)
+	}
+}
+
+// TestPodOverheadIncludedInEffectiveRequests tests that RuntimeClass overhead is folded into requests and cost
+func TestPodOverheadIncludedInEffectiveRequests(t *testing.T) {
+	runtimeClass := &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "kata"},
+		Handler:    "kata",
+		Overhead: &nodev1.Overhead{
+			PodFixed: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+				corev1.ResourceMemory: resource.MustParse("160Mi"),
+			},
+		},
+	}
+
+	deployment := newTestDeployment("sandboxed", 3, "500m", "512Mi")
+	runtimeClassName := "kata"
+	deployment.Spec.Template.Spec.RuntimeClassName = &runtimeClassName
+
+	opt, _, _ := newTestEngine(DefaultConfig(), deployment, runtimeClass, newTestPod(deployment, "sandboxed-1"))
+
+	metrics, err := opt.analyzer.collectDeploymentMetrics("default", "sandboxed")
+	if err != nil {
+		t.Fatalf("collectDeploymentMetrics failed: %v", err)
+	}
+
+	if metrics.effectiveCPURequest() != 750 {
+		t.Errorf("Expected effective CPU request 750m, got %dm", metrics.effectiveCPURequest())
+	}
+	if metrics.effectiveMemoryRequest() != 672*1024*1024 {
+		t.Errorf("Expected effective memory request 672Mi, got %d", metrics.effectiveMemoryRequest())
+	}
+
+	// Removing a replica also frees its overhead
+	rg := opt.recommendationGen
+	withoutOverhead := rg.calculateCPUCost(metrics.CPURequested) + rg.calculateMemoryCost(metrics.MemoryRequested)
+	expected := rg.calculateCPUCost(750) + rg.calculateMemoryCost(672*1024*1024)
+	savings := rg.calculateReplicaCostSavings(metrics, 1)
+	if savings <= withoutOverhead || savings != expected {
+		t.Errorf("Expected per-replica cost %.4f including overhead (container-only %.4f), got %.4f",
+			expected, withoutOverhead, savings)
+	}
+
+	analysis, err := opt.AnalyzeDeployment("default", "sandboxed")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if analysis.CPUUsage.Overhead != 250 || analysis.CPUUsage.Effective != 750 {
+		t.Errorf("Expected CPU overhead 250m and effective 750m, got %d and %d",
+			analysis.CPUUsage.Overhead, analysis.CPUUsage.Effective)
+	}
+
+	// Overhead handling can be disabled
+	config := DefaultConfig()
+	config.IncludePodOverhead = false
+	opt, _, _ = newTestEngine(config, deployment, runtimeClass)
+	metrics, err = opt.analyzer.collectDeploymentMetrics("default", "sandboxed")
+	if err != nil {
+		t.Fatalf("collectDeploymentMetrics failed: %v", err)
+	}
+	if metrics.CPUOverhead != 0 || metrics.MemoryOverhead != 0 {
+		t.Errorf("Expected no overhead when disabled, got cpu=%d memory=%d", metrics.CPUOverhead, metrics.MemoryOverhead)
+	}
+}
+
+// TestWorkingSetMemoryAvoidsFalseUnderProvisioning tests that page cache does not trigger memory increases
+func TestWorkingSetMemoryAvoidsFalseUnderProvisioning(t *testing.T) {
+	newCachingAnalysis := func() *analysisResult {
+		// Total usage sits at 900Mi of a 1Gi limit, but only 300Mi is working set
+		analysis := newTestAnalysis(100, 200, 512*1024*1024, 1024*1024*1024)
+		analysis.Deployment.MemoryP95 = 900 * 1024 * 1024
+		analysis.Deployment.MemoryWorkingSetP95 = 300 * 1024 * 1024
+		return analysis
+	}
+
+	opt := NewWithConfig(nil, nil, DefaultConfig())
+	analysis := newCachingAnalysis()
+	opt.analyzer.analyzeMemory(analysis)
+
+	if analysis.MemoryUnderProvisioned {
+		t.Error("Expected working-set memory to prevent under-provisioning detection")
+	}
+	for _, rec := range opt.recommendationGen.generateResourceRecommendations(analysis) {
+		if strings.Contains(rec.Description, "Increase memory") {
+			t.Errorf("Expected no memory increase recommendation, got %q", rec.Description)
+		}
+	}
+
+	// Without working-set memory the same workload looks under-provisioned
+	config := DefaultConfig()
+	config.UseWorkingSetMemory = false
+	opt = NewWithConfig(nil, nil, config)
+	analysis = newCachingAnalysis()
+	opt.analyzer.analyzeMemory(analysis)
+
+	if !analysis.MemoryUnderProvisioned {
+		t.Error("Expected total memory usage to be flagged as under-provisioned")
+	}
+}
+
+// TestCollectWorkingSetMemorySeries tests that working-set series are summarized per deployment
+func TestCollectWorkingSetMemorySeries(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "500m", "1Gi")
+	pod := newTestPod(deployment, "web-1")
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, pod)
+	mc.add("pod/web-1", "memory_working_set", steadySeries(30, 300*1024*1024))
+
+	metrics, err := opt.analyzer.collectDeploymentMetrics("default", "web")
+	if err != nil {
+		t.Fatalf("collectDeploymentMetrics failed: %v", err)
+	}
+
+	if metrics.MemoryWorkingSetP95 != 300*1024*1024 {
+		t.Errorf("Expected working-set P95 of 300Mi, got %d", metrics.MemoryWorkingSetP95)
+	}
+}
+
+// TestWorkingSetMemoryIgnoredWithSidecars tests that the pod-level working set, which includes
+// excluded sidecars, is not compared against the app containers' memory limit
+func TestWorkingSetMemoryIgnoredWithSidecars(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "500m", "256Mi")
+	proxy := corev1.Container{Name: "istio-proxy"}
+	deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, proxy)
+	pod := newTestPod(deployment, "web-1")
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, pod)
+
+	// The proxy's 200Mi pushes the pod's working set past the app's 256Mi limit
+	mc.add("pod/web-1", "memory_working_set", steadySeries(30, 300*1024*1024))
+	mc.add("pod/web-1/container/app", "memory", steadySeries(30, 100*1024*1024))
+	mc.add("pod/web-1/container/istio-proxy", "memory", steadySeries(30, 200*1024*1024))
+
+	metrics, err := opt.analyzer.collectDeploymentMetrics("default", "web")
+	if err != nil {
+		t.Fatalf("collectDeploymentMetrics failed: %v", err)
+	}
+	if metrics.MemoryWorkingSetP95 != 0 {
+		t.Errorf("Expected the pod-level working set to be ignored, got %d", metrics.MemoryWorkingSetP95)
+	}
+	if got := metrics.memoryPressure(true); got != 100*1024*1024 {
+		t.Errorf("Expected memory pressure from the app container (100Mi), got %d", got)
+	}
+}
+
+// TestCommittedCapacitySavings tests that savings within committed capacity are reported as reclaimable only
+func TestCommittedCapacitySavings(t *testing.T) {
+	// The cluster requests 4 CPUs and 4Gi across four pods
+	deployment := newTestDeployment("web", 4, "1", "1Gi")
+	objects := []runtime.Object{deployment}
+	for _, name := range []string{"web-1", "web-2", "web-3", "web-4"} {
+		objects = append(objects, newTestPod(deployment, name))
+	}
+
+	newRecommendations := func() []models.Recommendation {
+		return []models.Recommendation{
+			{ID: "small", Namespace: "default", Deployment: "web", EstimatedSavings: 5},
+			{ID: "large", Namespace: "default", Deployment: "web", EstimatedSavings: 16.2},
+		}
+	}
+
+	// Commitment covers 3.5 of the 4 requested CPUs and all memory, leaving 500m on demand
+	config := DefaultConfig()
+	config.CommittedCapacity = CommittedCapacity{CPUMillis: 3500, MemoryBytes: 4 * 1024 * 1024 * 1024}
+	opt, _, _ := newTestEngine(config, objects...)
+
+	recs := newRecommendations()
+	opt.splitCommittedSavings(recs)
+
+	uncommitted := opt.recommendationGen.calculateCPUCost(500)
+	small, large := recs[0], recs[1]
+	if math.Abs(large.CashSavings-uncommitted) > 0.001 {
+		t.Errorf("Expected largest recommendation to claim the %.2f uncommitted spend, got %.2f", uncommitted, large.CashSavings)
+	}
+	if math.Abs(large.CashSavings+large.ReclaimableSavings-large.EstimatedSavings) > 0.001 {
+		t.Errorf("Expected cash and reclaimable savings to sum to the estimate, got %.2f + %.2f", large.CashSavings, large.ReclaimableSavings)
+	}
+	if small.CashSavings != 0 || small.ReclaimableSavings != small.EstimatedSavings {
+		t.Errorf("Expected savings below the commitment to be reclaimable only, got cash=%.2f reclaimable=%.2f",
+			small.CashSavings, small.ReclaimableSavings)
+	}
+
+	// A commitment larger than cluster requests yields no cash savings at all
+	config.CommittedCapacity = CommittedCapacity{CPUMillis: 8000, MemoryBytes: 8 * 1024 * 1024 * 1024}
+	opt, _, _ = newTestEngine(config, objects...)
+	recs = newRecommendations()
+	opt.splitCommittedSavings(recs)
+	for _, rec := range recs {
+		if rec.CashSavings != 0 || rec.ReclaimableSavings != rec.EstimatedSavings {
+			t.Errorf("Expected %s to be reclaimable only, got cash=%.2f reclaimable=%.2f", rec.ID, rec.CashSavings, rec.ReclaimableSavings)
+		}
+	}
+
+	// Without a commitment all savings are cash
+	opt, _, _ = newTestEngine(DefaultConfig(), objects...)
+	recs = newRecommendations()
+	opt.splitCommittedSavings(recs)
+	for _, rec := range recs {
+		if rec.CashSavings != rec.EstimatedSavings || rec.ReclaimableSavings != 0 {
+			t.Errorf("Expected %s to be all cash savings, got cash=%.2f reclaimable=%.2f", rec.ID, rec.CashSavings, rec.ReclaimableSavings)
+		}
+	}
+}
+
+// TestReplicaCountConfidence tests that few pods lower confidence in identical per-pod data
+func TestReplicaCountConfidence(t *testing.T) {
+	analyze := func(replicas int32) ([]models.Recommendation, *analysisResult) {
+		deployment := newTestDeployment("web", replicas, "1", "1Gi")
+		objects := []runtime.Object{deployment}
+		for i := int32(0); i < replicas; i++ {
+			objects = append(objects, newTestPod(deployment, fmt.Sprintf("web-%d", i)))
+		}
+
+		opt, _, _ := newTestEngine(DefaultConfig(), objects...)
+		analysis, err := opt.AnalyzeDeployment("default", "web")
+		if err != nil {
+			t.Fatalf("AnalyzeDeployment failed: %v", err)
+		}
+		recs, err := opt.GenerateRecommendations(analysis)
+		if err != nil {
+			t.Fatalf("GenerateRecommendations failed: %v", err)
+		}
+		return recs, opt.analysisCache["default/web"]
+	}
+
+	singleRecs, single := analyze(1)
+	manyRecs, many := analyze(10)
+
+	if many.Confidence != many.Coverage {
+		t.Errorf("Expected no replica discount with 10 replicas, got %.2f at %.2f coverage", many.Confidence, many.Coverage)
+	}
+	if single.Confidence >= many.Confidence {
+		t.Errorf("Expected 1 replica to have lower confidence than 10, got %.2f vs %.2f", single.Confidence, many.Confidence)
+	}
+
+	if len(singleRecs) == 0 || len(manyRecs) == 0 {
+		t.Fatal("Expected recommendations for both deployments")
+	}
+	if singleRecs[0].Confidence != single.Confidence || manyRecs[0].Confidence != many.Confidence {
+		t.Errorf("Expected recommendations to carry analysis confidence, got %.2f and %.2f",
+			singleRecs[0].Confidence, manyRecs[0].Confidence)
+	}
+}
+
+// TestSingleReplicaHighVarianceLowersPriority tests priority lowering for an erratic single pod
+func TestSingleReplicaHighVarianceLowersPriority(t *testing.T) {
+	opt := NewWithConfig(nil, nil, DefaultConfig())
+
+	priorities := func(replicas int32) []string {
+		analysis := newTestAnalysis(1000, 2000, 1024*1024*1024, 2*1024*1024*1024)
+		analysis.Deployment.CurrentReplicas = replicas
+		analysis.CPUVariance = 5000
+		recs, _ := opt.recommendationGen.generateRecommendations(analysis)
+
+		var result []string
+		for _, rec := range resourceRecommendations(recs) {
+			result = append(result, rec.Priority)
+		}
+		return result
+	}
+
+	single := priorities(1)
+	many := priorities(10)
+	if len(single) == 0 || len(single) != len(many) {
+		t.Fatalf("Expected matching resource recommendations, got %v and %v", single, many)
+	}
+	for i := range single {
+		if single[i] != lowerPriorityLevel(many[i]) {
+			t.Errorf("Expected single-replica priority %q to be one level below %q", single[i], many[i])
+		}
+	}
+}
+
+// TestExcludedContainersIgnoredInRightSizing tests that sidecar usage does not influence the app's recommendation
+func TestExcludedContainersIgnoredInRightSizing(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "1", "1Gi")
+	proxy := corev1.Container{
+		Name: "istio-proxy",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+		},
+	}
+	// Injected sidecars may come first in the container list
+	deployment.Spec.Template.Spec.Containers = append([]corev1.Container{proxy}, deployment.Spec.Template.Spec.Containers...)
+	pod := newTestPod(deployment, "web-1")
+
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, pod)
+
+	// The proxy is busy while the app itself idles at 100m
+	mc.add("pod/web-1", "cpu", steadySeries(30, 1000))
+	mc.add("pod/web-1/container/app", "cpu", steadySeries(30, 100))
+	mc.add("pod/web-1/container/app", "memory", steadySeries(30, 128*1024*1024))
+	mc.add("pod/web-1/container/istio-proxy", "cpu", steadySeries(30, 900))
+	mc.add("pod/web-1/container/istio-proxy", "memory", steadySeries(30, 64*1024*1024))
+
+	metrics, err := opt.analyzer.collectDeploymentMetrics("default", "web")
+	if err != nil {
+		t.Fatalf("collectDeploymentMetrics failed: %v", err)
+	}
+
+	if metrics.CPURequested != 1000 {
+		t.Errorf("Expected requests from the app container (1000m), got %dm", metrics.CPURequested)
+	}
+	if metrics.CPUP95 != 100 {
+		t.Errorf("Expected CPU P95 from the app container only (100m), got %dm", metrics.CPUP95)
+	}
+	if metrics.SidecarCPURequested != 100 || metrics.effectiveCPURequest() != 1100 {
+		t.Errorf("Expected sidecar request to count toward cost, got sidecar=%dm effective=%dm",
+			metrics.SidecarCPURequested, metrics.effectiveCPURequest())
+	}
+
+	analysis, err := opt.AnalyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	recs, err := opt.GenerateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+
+	found := false
+	for _, rec := range resourceRecommendations(recs) {
+		if strings.Contains(rec.Description, "CPU request") {
+			found = true
+			if !strings.HasPrefix(rec.Description, "Reduce CPU request from 1 to 150m") {
+				t.Errorf("Expected the app's CPU to be sized from its own usage, got %q", rec.Description)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("Expected a CPU right-sizing recommendation for the app container")
+	}
+}
+
+// TestMultiContainerRecommendations tests that multi-container deployments aggregate requests and
+// are right-sized per container
+func TestMultiContainerRecommendations(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "1", "1Gi")
+	worker := corev1.Container{
+		Name: "worker",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("200m"),
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+			},
+		},
+	}
+	deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, worker)
+	pod := newTestPod(deployment, "web-1")
+
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, pod)
+
+	// The app idles well below its request while the worker runs close to its own
+	mc.add("pod/web-1/container/app", "cpu", steadySeries(30, 100))
+	mc.add("pod/web-1/container/app", "memory", steadySeries(30, 128*1024*1024))
+	mc.add("pod/web-1/container/worker", "cpu", steadySeries(30, 180))
+	mc.add("pod/web-1/container/worker", "memory", steadySeries(30, 200*1024*1024))
+
+	metrics, err := opt.analyzer.collectDeploymentMetrics("default", "web")
+	if err != nil {
+		t.Fatalf("collectDeploymentMetrics failed: %v", err)
+	}
+	if metrics.CPURequested != 1200 {
+		t.Errorf("Expected CPU requests summed across containers (1200m), got %dm", metrics.CPURequested)
+	}
+	if metrics.MemoryRequested != 1280*1024*1024 {
+		t.Errorf("Expected memory requests summed across containers, got %d", metrics.MemoryRequested)
+	}
+	if len(metrics.Containers) != 2 || metrics.Containers["worker"] == nil || len(metrics.Containers["worker"].CPUTimeSeries) != 30 {
+		t.Fatalf("Expected per-container series for app and worker, got %+v", metrics.Containers)
+	}
+
+	analysis, err := opt.AnalyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	recs, err := opt.GenerateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+
+	resourceRecs := resourceRecommendations(recs)
+	if len(resourceRecs) == 0 {
+		t.Fatal("Expected a resource recommendation for the app container")
+	}
+	for _, rec := range resourceRecs {
+		if rec.Container != "app" {
+			t.Errorf("Expected only the over-provisioned app container to be right-sized, got %q: %s", rec.Container, rec.Description)
+		}
+		if !strings.HasPrefix(rec.Description, "Container app: ") {
+			t.Errorf("Expected the description to name the container, got %q", rec.Description)
+		}
+	}
+}
+
+// TestLowConfidenceFallback tests provisional recommendations for deployments with little history
+func TestLowConfidenceFallback(t *testing.T) {
+	deployment := newTestDeployment("fresh", 1, "1", "1Gi")
+	pod := newTestPod(deployment, "fresh-1")
+
+	// By default insufficient history is an error
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, pod)
+	mc.set("pod/fresh-1", "cpu", steadySeries(4, 100))
+	mc.set("pod/fresh-1", "memory", steadySeries(4, 128*1024*1024))
+	if _, err := opt.AnalyzeDeployment("default", "fresh"); err == nil {
+		t.Error("Expected an error for insufficient data without low-confidence recommendations")
+	}
+
+	config := DefaultConfig()
+	config.AllowLowConfidenceRecommendations = true
+	opt, _, mc = newTestEngine(config, deployment, pod)
+	mc.set("pod/fresh-1", "cpu", steadySeries(4, 100))
+	mc.set("pod/fresh-1", "memory", steadySeries(4, 128*1024*1024))
+
+	analysis, err := opt.AnalyzeDeployment("default", "fresh")
+	if err != nil {
+		t.Fatalf("Expected provisional analysis instead of an error, got: %v", err)
+	}
+	if !analysis.Provisional {
+		t.Error("Expected analysis to be marked provisional")
+	}
+
+	recs, err := opt.GenerateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	if len(recs) == 0 {
+		t.Fatal("Expected a provisional recommendation")
+	}
+	for _, rec := range recs {
+		if rec.Priority != "low" {
+			t.Errorf("Expected provisional recommendation to be low priority, got %s", rec.Priority)
+		}
+		if !strings.HasPrefix(rec.Description, "Provisional: ") {
+			t.Errorf("Expected provisional label, got %q", rec.Description)
+		}
+		if rec.Confidence <= 0 || rec.Confidence >= 0.5 {
+			t.Errorf("Expected low confidence for 4 of 10 data points, got %.2f", rec.Confidence)
+		}
+	}
+
+	// With no data at all, the fallback holds current values
+	opt, _, mc = newTestEngine(config, deployment, pod)
+	mc.set("pod/fresh-1", "cpu", nil)
+	mc.set("pod/fresh-1", "memory", nil)
+
+	analysis, err = opt.AnalyzeDeployment("default", "fresh")
+	if err != nil {
+		t.Fatalf("Expected provisional analysis with no data, got: %v", err)
+	}
+	recs, err = opt.GenerateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	if len(recs) != 1 || recs[0].EstimatedSavings != 0 || recs[0].Priority != "low" {
+		t.Fatalf("Expected a single low-priority hold recommendation, got %+v", recs)
+	}
+	current, _ := recs[0].CurrentConfig.(map[string]interface{})
+	recommended, _ := recs[0].RecommendedConfig.(map[string]interface{})
+	if current["cpu_request"] != "1" || recommended["cpu_request"] != "1" {
+		t.Errorf("Expected hold recommendation to keep the 1 CPU request, got %v -> %v", current, recommended)
+	}
+}
+
+// TestBurstyCPURaisesLimitOnly tests that bursty CPU keeps the request near P50 and raises only the limit
+func TestBurstyCPURaisesLimitOnly(t *testing.T) {
+	// Idles at 100m with rare bursts to 900m: P99/P50 = 9 while P95 is close to the 1 CPU limit
+	burstyAnalysis := func(opt *OptimizerEngine) *analysisResult {
+		analysis := newTestAnalysis(1000, 1000, 256*1024*1024, 512*1024*1024)
+		metrics := &analysis.Deployment
+		metrics.CPUP50, metrics.CPUP95, metrics.CPUP99, metrics.CPUAverage = 100, 900, 900, 180
+		analysis.CPUOverProvisioned = false
+		opt.analyzer.analyzeCPU(analysis)
+		return analysis
+	}
+
+	tests := []struct {
+		name          string
+		headroom      bool
+		expectRequest string
+		expectLimit   string
+	}{
+		{name: "limit headroom", headroom: true, expectRequest: "150m", expectLimit: "1100m"},
+		{name: "request inflation", headroom: false, expectRequest: "1350m", expectLimit: "2700m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.BurstyCPULimitHeadroom = tt.headroom
+			opt := NewWithConfig(&k8s.Client{Clientset: fake.NewClientset()}, newFakeCollector(), config)
+
+			analysis := burstyAnalysis(opt)
+			if analysis.CPUBursty != tt.headroom {
+				t.Fatalf("Expected CPUBursty=%v, got %v", tt.headroom, analysis.CPUBursty)
+			}
+
+			recs := opt.recommendationGen.generateResourceRecommendations(analysis)
+			if len(recs) != 1 {
+				t.Fatalf("Expected a single CPU recommendation, got %+v", recs)
+			}
+			recommended, _ := recs[0].RecommendedConfig.(map[string]interface{})
+			if recommended["cpu_request"] != tt.expectRequest || recommended["cpu_limit"] != tt.expectLimit {
+				t.Errorf("Expected request %s and limit %s, got %v", tt.expectRequest, tt.expectLimit, recommended)
+			}
+		})
+	}
+}
+
+// TestSimulateHPA tests replaying a known CPU series through the HPA algorithm
+func TestSimulateHPA(t *testing.T) {
+	deployment := newTestDeployment("web", 2, "100m", "128Mi")
+	pod := newTestPod(deployment, "web-1")
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, pod)
+
+	// Total CPU demand: a 3-minute spike to 300m between steady 100m
+	values := []float64{100, 100, 300, 300, 300, 100, 100, 100, 100, 100, 100, 100}
+	start := time.Now().Add(-time.Duration(len(values)) * time.Minute)
+	points := make([]models.DataPoint, len(values))
+	for i, value := range values {
+		points[i] = models.DataPoint{Timestamp: start.Add(time.Duration(i) * time.Minute), Value: value}
+	}
+	mc.set("pod/web-1", "cpu", points)
+
+	simulation, err := opt.SimulateHPA("default", "web", HPAConfig{MinReplicas: 1, MaxReplicas: 5, TargetCPU: 50})
+	if err != nil {
+		t.Fatalf("SimulateHPA failed: %v", err)
+	}
+
+	// Scales up immediately to the max, then holds for the 5-minute downscale stabilization window
+	expected := []int32{2, 2, 5, 5, 5, 5, 5, 5, 5, 2, 2, 2}
+	if len(simulation.Trajectory) != len(expected) {
+		t.Fatalf("Expected %d trajectory points, got %d", len(expected), len(simulation.Trajectory))
+	}
+	for i, point := range simulation.Trajectory {
+		if point.Replicas != expected[i] {
+			t.Errorf("Point %d: expected %d replicas, got %d", i, expected[i], point.Replicas)
+		}
+	}
+
+	if simulation.ScalingEvents != 2 {
+		t.Errorf("Expected 2 scaling events, got %d", simulation.ScalingEvents)
+	}
+	if simulation.PeakReplicas != 5 {
+		t.Errorf("Expected peak of 5 replicas, got %d", simulation.PeakReplicas)
+	}
+	if want := 45.0 / 12.0; math.Abs(simulation.AverageReplicas-want) > 1e-9 {
+		t.Errorf("Expected average of %.3f replicas, got %.3f", want, simulation.AverageReplicas)
+	}
+	podCost := opt.recommendationGen.calculatePodCost(&deploymentMetrics{CPURequested: 100, MemoryRequested: 128 * 1024 * 1024})
+	if want := podCost * simulation.AverageReplicas; math.Abs(simulation.EstimatedMonthlyCost-want) > 1e-9 {
+		t.Errorf("Expected estimated cost %.4f, got %.4f", want, simulation.EstimatedMonthlyCost)
+	}
+
+	// Invalid configurations are rejected
+	if _, err := opt.SimulateHPA("default", "web", HPAConfig{MinReplicas: 3, MaxReplicas: 2, TargetCPU: 50}); err == nil {
+		t.Error("Expected an error when max replicas is below min replicas")
+	}
+}
+
+// TestSmoothingAlpha tests that smoothing removes jitter from P95 without hiding sustained spikes
+func TestSmoothingAlpha(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "1", "1Gi")
+	pod := newTestPod(deployment, "web-1")
+
+	// Steady 100m with a one-sample 400m blip every 10 samples
+	jittery := steadySeries(30, 100)
+	for i := 5; i < len(jittery); i += 10 {
+		jittery[i].Value = 400
+	}
+
+	// Steady 100m, then a real 400m load for the last 10 samples
+	sustained := steadySeries(30, 100)
+	for i := 20; i < len(sustained); i++ {
+		sustained[i].Value = 400
+	}
+
+	analyze := func(alpha float64, cpu []models.DataPoint) *analysisResult {
+		config := DefaultConfig()
+		config.SmoothingAlpha = alpha
+		opt, _, mc := newTestEngine(config, deployment, pod)
+		mc.set("pod/web-1", "cpu", cpu)
+
+		result, err := opt.analyzer.analyzeDeployment("default", "web")
+		if err != nil {
+			t.Fatalf("analyzeDeployment failed: %v", err)
+		}
+		return result
+	}
+
+	raw := analyze(0, jittery)
+	smoothed := analyze(0.3, jittery)
+	if raw.Deployment.CPUP95 != 400 {
+		t.Fatalf("Expected raw P95 to follow the blips at 400m, got %dm", raw.Deployment.CPUP95)
+	}
+	if smoothed.Deployment.CPUP95 >= 250 {
+		t.Errorf("Expected smoothed P95 well below the 400m blips, got %dm", smoothed.Deployment.CPUP95)
+	}
+	if smoothed.CPUVariance >= raw.CPUVariance {
+		t.Errorf("Expected smoothing to reduce variance, got %.0f (raw %.0f)", smoothed.CPUVariance, raw.CPUVariance)
+	}
+
+	raw = analyze(0, sustained)
+	smoothed = analyze(0.3, sustained)
+	if smoothed.Deployment.CPUP95 != raw.Deployment.CPUP95 {
+		t.Errorf("Expected sustained spike to survive smoothing: P95 %dm, raw %dm", smoothed.Deployment.CPUP95, raw.Deployment.CPUP95)
+	}
+}
+
+// TestApplyRecommendation tests patching deployments and HPAs, dry runs and applied tracking
+func TestApplyRecommendation(t *testing.T) {
+	deployment := newTestDeployment("web", 2, "1", "1Gi")
+	targetCPU := int32(80)
+	minReplicas := int32(1)
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-hpa", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    4,
+			Metrics: []autoscalingv2.MetricSpec{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &targetCPU},
+				},
+			}},
+		},
+	}
+	opt, clientset, _ := newTestEngine(DefaultConfig(), deployment, hpa)
+
+	resourceRec := models.Recommendation{
+		ID:                "rec-resource",
+		Type:              string(RecommendationTypeResource),
+		Namespace:         "default",
+		Deployment:        "web",
+		RecommendedConfig: map[string]interface{}{"cpu_request": "250m", "memory_request": "512Mi", "cpu_limit": "0m"},
+	}
+	hpaRec := models.Recommendation{
+		ID:                "rec-hpa",
+		Type:              string(RecommendationTypeHPA),
+		Namespace:         "default",
+		Deployment:        "web",
+		RecommendedConfig: convertHPAConfigToMap(HPAConfig{MinReplicas: 2, MaxReplicas: 8, TargetCPU: 60}),
+	}
+	opt.recommendations[resourceRec.ID] = resourceRec
+	opt.recommendations[hpaRec.ID] = hpaRec
+
+	// A dry run returns the patch without changing the deployment
+	patch, err := opt.ApplyRecommendation("rec-resource", true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if !patch.DryRun || patch.Kind != "Deployment" || !strings.Contains(patch.Patch, `"cpu":"250m"`) {
+		t.Errorf("Unexpected dry-run patch: %+v", patch)
+	}
+	if strings.Contains(patch.Patch, "limits") {
+		t.Errorf("Expected zero limit to be left out of the patch, got %s", patch.Patch)
+	}
+	current, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if got := current.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().MilliValue(); got != 1000 {
+		t.Errorf("Expected dry run to leave the 1 CPU request, got %dm", got)
+	}
+
+	// A real apply patches the deployment and records the applied time
+	if _, err := opt.ApplyRecommendation("rec-resource", false); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	current, _ = clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	requests := current.Spec.Template.Spec.Containers[0].Resources.Requests
+	if requests.Cpu().MilliValue() != 250 || requests.Memory().Value() != 512*1024*1024 {
+		t.Errorf("Expected requests of 250m/512Mi, got %s/%s", requests.Cpu(), requests.Memory())
+	}
+	stored, _ := opt.GetRecommendationByID("rec-resource")
+	if stored.AppliedAt.IsZero() {
+		t.Error("Expected recommendation to be marked as applied")
+	}
+	if _, err := opt.ApplyRecommendation("rec-resource", false); err == nil {
+		t.Error("Expected an error when applying a recommendation twice")
+	}
+
+	// Regenerating the same change is not re-suggested
+	if !isAppliedDuplicate(models.Recommendation{
+		Type:              resourceRec.Type,
+		Namespace:         "default",
+		Deployment:        "web",
+		RecommendedConfig: resourceRec.RecommendedConfig,
+	}, opt.recommendations) {
+		t.Error("Expected a repeat of an applied recommendation to be detected")
+	}
+
+	// HPA recommendations patch the replica bounds and CPU target
+	if _, err := opt.ApplyRecommendation("rec-hpa", false); err != nil {
+		t.Fatalf("Apply HPA failed: %v", err)
+	}
+	updated, _ := clientset.AutoscalingV2().HorizontalPodAutoscalers("default").Get(context.Background(), "web-hpa", metav1.GetOptions{})
+	if *updated.Spec.MinReplicas != 2 || updated.Spec.MaxReplicas != 8 {
+		t.Errorf("Expected replica bounds 2-8, got %d-%d", *updated.Spec.MinReplicas, updated.Spec.MaxReplicas)
+	}
+	if len(updated.Spec.Metrics) != 1 || *updated.Spec.Metrics[0].Resource.Target.AverageUtilization != 60 {
+		t.Errorf("Expected a single CPU metric targeting 60%%, got %+v", updated.Spec.Metrics)
+	}
+}
+
+// TestRevertRecommendation tests restoring the pre-apply configuration and refusing on drift
+func TestRevertRecommendation(t *testing.T) {
+	deployment := newTestDeployment("web", 2, "1", "1Gi")
+	opt, clientset, _ := newTestEngine(DefaultConfig(), deployment)
+	ctx := context.Background()
+
+	opt.recommendations["rec-1"] = models.Recommendation{
+		ID:                "rec-1",
+		Type:              string(RecommendationTypeResource),
+		Namespace:         "default",
+		Deployment:        "web",
+		RecommendedConfig: map[string]interface{}{"cpu_request": "250m", "cpu_limit": "500m"},
+	}
+
+	if err := opt.RevertRecommendation("rec-1"); !errors.Is(err, ErrNotApplied) {
+		t.Errorf("Expected ErrNotApplied reverting a recommendation that was never applied, got %v", err)
+	}
+
+	if _, err := opt.ApplyRecommendation("rec-1", false); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if err := opt.RevertRecommendation("rec-1"); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+
+	current, _ := clientset.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	resources := current.Spec.Template.Spec.Containers[0].Resources
+	if resources.Requests.Cpu().MilliValue() != 1000 {
+		t.Errorf("Expected CPU request restored to 1, got %s", resources.Requests.Cpu())
+	}
+	if _, ok := resources.Limits[corev1.ResourceCPU]; ok {
+		t.Errorf("Expected the added CPU limit to be removed, got %s", resources.Limits.Cpu())
+	}
+	stored, _ := opt.GetRecommendationByID("rec-1")
+	if stored.RevertedAt.IsZero() || !stored.AppliedAt.IsZero() {
+		t.Errorf("Expected recommendation marked reverted, got applied=%v reverted=%v", stored.AppliedAt, stored.RevertedAt)
+	}
+
+	// Re-apply, then change the deployment behind the optimizer's back
+	if _, err := opt.ApplyRecommendation("rec-1", false); err != nil {
+		t.Fatalf("Re-apply failed: %v", err)
+	}
+	current, _ = clientset.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	current.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("300m")
+	if _, err := clientset.AppsV1().Deployments("default").Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if err := opt.RevertRecommendation("rec-1"); !errors.Is(err, ErrDrifted) {
+		t.Errorf("Expected revert to refuse with ErrDrifted when the deployment has drifted, got %v", err)
+	}
+	current, _ = clientset.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	if got := current.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().MilliValue(); got != 300 {
+		t.Errorf("Expected drifted request to be left alone, got %dm", got)
+	}
+}
+
+// TestDismissRecommendation tests that dismissed recommendations are removed and not regenerated
+// until the cooldown passes
+func TestDismissRecommendation(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "1", "1Gi")
+	opt, _, _ := newTestEngine(DefaultConfig(), deployment, newTestPod(deployment, "web-1"))
+
+	generate := func() []models.Recommendation {
+		analysis, err := opt.AnalyzeDeployment("default", "web")
+		if err != nil {
+			t.Fatalf("AnalyzeDeployment failed: %v", err)
+		}
+		recs, err := opt.GenerateRecommendations(analysis)
+		if err != nil {
+			t.Fatalf("GenerateRecommendations failed: %v", err)
+		}
+		return resourceRecommendations(recs)
+	}
+
+	recs := generate()
+	if len(recs) == 0 {
+		t.Fatal("Expected resource recommendations for an over-provisioned deployment")
+	}
+	if err := opt.DismissRecommendation(recs[0].ID); err != nil {
+		t.Fatalf("DismissRecommendation failed: %v", err)
+	}
+	if _, err := opt.GetRecommendationByID(recs[0].ID); err == nil {
+		t.Error("Expected the dismissed recommendation to be removed")
+	}
+	if err := opt.DismissRecommendation(recs[0].ID); err == nil {
+		t.Error("Expected dismissing an unknown recommendation to fail")
+	}
+
+	// Within the cooldown, resource recommendations for the deployment are suppressed
+	if regenerated := generate(); len(regenerated) != 0 {
+		t.Errorf("Expected no resource recommendations during the cooldown, got %d", len(regenerated))
+	}
+	// The dismissal covers the deployment's other resource recommendations too
+	if stats := opt.GetRecommendationStats(); stats["by_type"].(map[string]int)["resource"] != 0 {
+		t.Errorf("Expected dismissed resource recommendations to be left out of stats, got %v", stats["by_type"])
+	}
+
+	// Once the cooldown has passed they are generated again
+	opt.recommendationsMu.Lock()
+	for key := range opt.dismissals {
+		opt.dismissals[key] = time.Now().Add(-opt.cfg().DismissalCooldown)
+	}
+	opt.recommendationsMu.Unlock()
+	if regenerated := generate(); len(regenerated) == 0 {
+		t.Error("Expected resource recommendations after the cooldown")
+	}
+}
+
+// TestImpactScoreRanking tests that a low-risk moderate-savings recommendation outranks a
+// high-risk high-savings one
+func TestImpactScoreRanking(t *testing.T) {
+	opt := NewWithConfig(&k8s.Client{Clientset: fake.NewClientset()}, newFakeCollector(), DefaultConfig())
+
+	cpuRecommendation := func(analysis *analysisResult) models.Recommendation {
+		t.Helper()
+		recs, err := opt.recommendationGen.generateRecommendations(analysis)
+		if err != nil {
+			t.Fatalf("generateRecommendations failed: %v", err)
+		}
+		for _, rec := range resourceRecommendations(recs) {
+			if strings.Contains(rec.Description, "CPU request") {
+				return rec
+			}
+		}
+		t.Fatalf("Expected a CPU recommendation, got %+v", recs)
+		return models.Recommendation{}
+	}
+
+	// 1 CPU request reduced to 150m on a stable deployment
+	safe := cpuRecommendation(newTestAnalysis(1000, 0, 256*1024*1024, 0))
+
+	// 4 CPU request reduced to 150m on a deployment whose pods have been restarting
+	risky := newTestAnalysis(4000, 0, 256*1024*1024, 0)
+	risky.Deployment.RestartCount = 3
+	riskyRec := cpuRecommendation(risky)
+
+	if safe.Risk != string(RiskLow) || riskyRec.Risk != string(RiskHigh) {
+		t.Fatalf("Expected low and high risk, got %q and %q", safe.Risk, riskyRec.Risk)
+	}
+	if safe.EstimatedSavings >= riskyRec.EstimatedSavings {
+		t.Fatalf("Expected the risky change to save more, got $%.2f vs $%.2f", safe.EstimatedSavings, riskyRec.EstimatedSavings)
+	}
+	if !strings.HasPrefix(riskyRec.Impact, "High risk - ") {
+		t.Errorf("Expected the impact message to report high risk, got %q", riskyRec.Impact)
+	}
+
+	ranked := []models.Recommendation{riskyRec, safe}
+	SortByImpact(ranked)
+	if ranked[0].ID != safe.ID {
+		t.Errorf("Expected the low-risk recommendation (score %.1f) to outrank the high-risk one (score %.1f)",
+			safe.ImpactScore, riskyRec.ImpactScore)
+	}
+}
+
+// TestOversizedLimitRecommendation tests that a memory limit larger than any node is flagged and
+// replaced with a limit the nodes can satisfy
+func TestOversizedLimitRecommendation(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "100m", "200Mi")
+	deployment.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("64Gi"),
+	}
+
+	var nodes []runtime.Object
+	for _, name := range []string{"node-1", "node-2"} {
+		nodes = append(nodes, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("16Gi"),
+			}},
+		})
+	}
+
+	objects := append([]runtime.Object{deployment, newTestPod(deployment, "web-1")}, nodes...)
+	opt, _, _ := newTestEngine(DefaultConfig(), objects...)
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if !analysis.MemoryLimitExceedsNodes {
+		t.Fatal("Expected a 64Gi memory limit to exceed 16Gi nodes")
+	}
+	if analysis.CPULimitExceedsNodes {
+		t.Error("Expected an unset CPU limit not to be flagged")
+	}
+
+	recs, err := opt.recommendationGen.generateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+
+	var oversized *models.Recommendation
+	for i := range recs {
+		if current := recs[i].CurrentConfig.(map[string]interface{}); current["memory_limit"] == "64Gi" {
+			oversized = &recs[i]
+		}
+	}
+	if oversized == nil {
+		t.Fatal("Expected an oversized-limit recommendation")
+	}
+
+	recommended := oversized.RecommendedConfig.(map[string]interface{})
+	if got := recommended["memory_limit"]; got != "400Mi" {
+		t.Errorf("Expected memory limit 400Mi, got %v", got)
+	}
+	if _, ok := recommended["cpu_limit"]; ok {
+		t.Error("Expected the CPU limit to be left alone")
+	}
+	if oversized.Type != string(RecommendationTypeResource) {
+		t.Errorf("Expected a resource recommendation, got %s", oversized.Type)
+	}
+}
+
+// TestNodeFractionPolicy tests that a pod requesting 60% of a node's CPU is flagged by a 25% node
+// fraction policy and recommended down to 25% with more replicas
+func TestNodeFractionPolicy(t *testing.T) {
+	deployment := newTestDeployment("web", 2, "2400m", "1Gi")
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+		}},
+	}
+
+	config := DefaultConfig()
+	config.MaxNodeFractionPerPod = 0.25
+	opt, _, _ := newTestEngine(config, deployment, newTestPod(deployment, "web-1"), newTestPod(deployment, "web-2"), node)
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if !analysis.CPURequestExceedsNodeFraction {
+		t.Fatal("Expected a 2400m request on 4 CPU nodes to exceed the 25% policy")
+	}
+	if analysis.MemoryRequestExceedsNodeFraction {
+		t.Error("Expected a 1Gi request on 16Gi nodes to be within the policy")
+	}
+
+	recs, err := opt.recommendationGen.generateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+
+	var policy *models.Recommendation
+	for i := range recs {
+		if strings.HasPrefix(recs[i].Description, "Lower requests above 25% of a node") {
+			policy = &recs[i]
+		}
+	}
+	if policy == nil {
+		t.Fatal("Expected a node fraction policy recommendation")
+	}
+
+	recommended := policy.RecommendedConfig.(map[string]interface{})
+	if got := recommended["cpu_request"]; got != "1" {
+		t.Errorf("Expected CPU request 1 (25%% of 4 CPUs), got %v", got)
+	}
+	if _, ok := recommended["memory_request"]; ok {
+		t.Error("Expected the memory request to be left alone")
+	}
+	// 2 replicas x 2400m = 4800m total, at 1000m per pod
+	if !strings.Contains(policy.Impact, "scale to 5 replicas") {
+		t.Errorf("Expected 5 replicas to keep capacity, got %q", policy.Impact)
+	}
+
+	// Without the policy nothing is flagged
+	opt, _, _ = newTestEngine(DefaultConfig(), deployment, newTestPod(deployment, "web-1"), node)
+	analysis, err = opt.analyzer.analyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if analysis.CPURequestExceedsNodeFraction {
+		t.Error("Expected no flag with the policy disabled")
+	}
+}
+
+// TestNodeFractionPolicySmallNode tests that a node fraction below one rounding increment still
+// recommends one increment rather than a zero request
+func TestNodeFractionPolicySmallNode(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "200m", "64Mi")
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+		}},
+	}
+
+	// 4% of 1 CPU is 40m, which rounds down to 0 with the default 50m increment
+	config := DefaultConfig()
+	config.MaxNodeFractionPerPod = 0.04
+	opt, _, _ := newTestEngine(config, deployment, newTestPod(deployment, "web-1"), node)
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	rec := opt.recommendationGen.generateNodeFractionRecommendation(analysis)
+	if rec == nil {
+		t.Fatal("Expected a node fraction policy recommendation")
+	}
+	if got := rec.RecommendedConfig.(map[string]interface{})["cpu_request"]; got != "50m" {
+		t.Errorf("Expected CPU request to be clamped to one 50m increment, got %v", got)
+	}
+	if !strings.Contains(rec.Impact, "scale to 4 replicas") {
+		t.Errorf("Expected 4 replicas to keep 200m of capacity, got %q", rec.Impact)
+	}
+
+	// A request already at one increment cannot be lowered further
+	deployment = newTestDeployment("web", 1, "50m", "64Mi")
+	opt, _, _ = newTestEngine(config, deployment, newTestPod(deployment, "web-1"), node)
+	analysis, err = opt.analyzer.analyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if rec := opt.recommendationGen.generateNodeFractionRecommendation(analysis); rec != nil {
+		t.Errorf("Expected no recommendation for a request of one increment, got %q", rec.Description)
+	}
+}
+
+// TestRestartBreakdown tests that restarts are counted by termination reason and that OOM kills
+// and crash loops weigh more on stability than clean exits
+func TestRestartBreakdown(t *testing.T) {
+	withRestarts := func(pod *corev1.Pod, restarts int32, lastReason, waitingReason string) *corev1.Pod {
+		status := corev1.ContainerStatus{
+			Name:         "app",
+			RestartCount: restarts,
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Reason: lastReason},
+			},
+		}
+		if waitingReason != "" {
+			status.State.Waiting = &corev1.ContainerStateWaiting{Reason: waitingReason}
+		}
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{status}
+		return pod
+	}
+
+	unstable := newTestDeployment("unstable", 3, "500m", "512Mi")
+	clean := newTestDeployment("clean", 3, "500m", "512Mi")
+	opt, _, _ := newTestEngine(DefaultConfig(),
+		unstable, clean,
+		withRestarts(newTestPod(unstable, "unstable-1"), 2, "OOMKilled", ""),
+		withRestarts(newTestPod(unstable, "unstable-2"), 3, "Error", "CrashLoopBackOff"),
+		withRestarts(newTestPod(unstable, "unstable-3"), 1, "Completed", ""),
+		withRestarts(newTestPod(clean, "clean-1"), 6, "Completed", ""),
+	)
+
+	analysis, err := opt.AnalyzeDeployment("default", "unstable")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+
+	expected := map[string]int{RestartReasonOOMKilled: 2, RestartReasonCrashLoop: 3, RestartReasonCompleted: 1}
+	if analysis.RestartCount != 6 || len(analysis.RestartBreakdown) != len(expected) {
+		t.Fatalf("Expected 6 restarts broken down as %v, got %d: %v", expected, analysis.RestartCount, analysis.RestartBreakdown)
+	}
+	for reason, count := range expected {
+		if analysis.RestartBreakdown[reason] != count {
+			t.Errorf("Expected %d %s restarts, got %d", count, reason, analysis.RestartBreakdown[reason])
+		}
+	}
+
+	unstableResult, err := opt.analyzer.analyzeDeployment("default", "unstable")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	cleanResult, err := opt.analyzer.analyzeDeployment("default", "clean")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if unstableResult.StabilityScore >= cleanResult.StabilityScore {
+		t.Errorf("Expected OOM kills and crash loops to lower stability more than clean exits, got %.1f vs %.1f",
+			unstableResult.StabilityScore, cleanResult.StabilityScore)
+	}
+}
+
+// TestAdvisoryAnnotations tests that recommendations are written to the deployment as annotations
+// and that the annotations follow the recommendation when it changes
+func TestAdvisoryAnnotations(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "1", "1Gi")
+	config := DefaultConfig()
+	config.WriteAdvisoryAnnotations = true
+	opt, clientset, _ := newTestEngine(config, deployment, newTestPod(deployment, "web-1"))
+
+	annotations := func() map[string]string {
+		t.Helper()
+		updated, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get deployment: %v", err)
+		}
+		return updated.Annotations
+	}
+
+	analysis, err := opt.AnalyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if _, err := opt.GenerateRecommendations(analysis); err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+
+	got := annotations()
+	if got["optimizer.k8s.io/recommended-cpu"] != "150m" || got["optimizer.k8s.io/recommended-memory"] != "160Mi" {
+		t.Errorf("Expected recommended-cpu 150m and recommended-memory 160Mi, got %v", got)
+	}
+
+	// A changed recommendation replaces the annotations and drops ones no longer recommended
+	changed := []models.Recommendation{{
+		Type:              string(RecommendationTypeResource),
+		RecommendedConfig: map[string]interface{}{"cpu_request": "300m"},
+	}}
+	if err := opt.writeAdvisoryAnnotations("default", "web", changed); err != nil {
+		t.Fatalf("writeAdvisoryAnnotations failed: %v", err)
+	}
+
+	got = annotations()
+	if got["optimizer.k8s.io/recommended-cpu"] != "300m" {
+		t.Errorf("Expected recommended-cpu 300m, got %v", got)
+	}
+	if _, ok := got["optimizer.k8s.io/recommended-memory"]; ok {
+		t.Errorf("Expected the stale recommended-memory annotation to be removed, got %v", got)
+	}
+
+	updated, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if cpu := updated.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().String(); cpu != "1" {
+		t.Errorf("Expected the spec to be left unchanged, got CPU request %s", cpu)
+	}
+}
+
+// TestGetAllRecommendationsOrder tests that recommendations are returned in a stable order by
+// priority, then savings, then ID
+func TestGetAllRecommendationsOrder(t *testing.T) {
+	opt := NewWithConfig(&k8s.Client{Clientset: fake.NewClientset()}, newFakeCollector(), DefaultConfig())
+	for _, rec := range []models.Recommendation{
+		{ID: "c", Priority: "low", EstimatedSavings: 90},
+		{ID: "b", Priority: "high", EstimatedSavings: 10},
+		{ID: "e", Priority: "medium", EstimatedSavings: 30},
+		{ID: "a", Priority: "high", EstimatedSavings: 10},
+		{ID: "d", Priority: "high", EstimatedSavings: 50},
+	} {
+		opt.recommendations[rec.ID] = rec
+	}
+
+	want := []string{"d", "a", "b", "e", "c"}
+	for attempt := 0; attempt < 5; attempt++ {
+		recs, err := opt.GetAllRecommendations()
+		if err != nil {
+			t.Fatalf("GetAllRecommendations failed: %v", err)
+		}
+		got := make([]string, len(recs))
+		for i, rec := range recs {
+			got[i] = rec.ID
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("Expected order %v, got %v", want, got)
+		}
+	}
+}
+
+// TestLowConfidenceCapsPriority tests that analyses spanning little of the window cap recommendations
+// at low priority, except resource increases for under-provisioned deployments
+func TestLowConfidenceCapsPriority(t *testing.T) {
+	over := newTestDeployment("over", 3, "2", "2Gi")
+	under := newTestDeployment("under", 3, "100m", "128Mi")
+	under.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}
+	objects := []runtime.Object{over, under}
+	for i := 0; i < 3; i++ {
+		objects = append(objects, newTestPod(over, fmt.Sprintf("over-%d", i)), newTestPod(under, fmt.Sprintf("under-%d", i)))
+	}
+	opt, _, _ := newTestEngine(DefaultConfig(), objects...)
+
+	analysis, err := opt.AnalyzeDeployment("default", "over")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if analysis.Confidence >= 0.01 || analysis.DataPointCount != 90 {
+		t.Fatalf("Expected near-zero confidence from 30 minutes of samples per pod over a 7 day window, got %.4f from %d points",
+			analysis.Confidence, analysis.DataPointCount)
+	}
+
+	recs, err := opt.GenerateRecommendations(analysis)
+	if err != nil || len(recs) == 0 {
+		t.Fatalf("Expected recommendations, got %v", err)
+	}
+	for _, rec := range recs {
+		if rec.Priority != string(PriorityLow) {
+			t.Errorf("Expected low-confidence %s recommendation capped at low priority, got %s", rec.Type, rec.Priority)
+		}
+		if !strings.Contains(rec.Impact, "low confidence") {
+			t.Errorf("Expected low-confidence note in impact, got %q", rec.Impact)
+		}
+	}
+
+	analysis, err = opt.AnalyzeDeployment("default", "under")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	recs, err = opt.GenerateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	increases := 0
+	for _, rec := range recs {
+		if rec.Type == string(RecommendationTypeResource) {
+			increases++
+			if rec.Priority != string(PriorityHigh) {
+				t.Errorf("Expected under-provisioned resource recommendation to stay high priority, got %s", rec.Priority)
+			}
+		}
+	}
+	if increases == 0 {
+		t.Error("Expected a resource increase for the under-provisioned deployment")
+	}
+}
+
+// TestGPURecommendation tests that pods using fewer GPUs than requested get a whole-GPU reduction
+// that is priced per GPU-hour and applied to both the request and limit
+func TestGPURecommendation(t *testing.T) {
+	deployment := newTestDeployment("inference", 2, "500m", "256Mi")
+	deployment.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+		gpuResourceName: resource.MustParse("4"),
+	}
+	pods := []*corev1.Pod{newTestPod(deployment, "inference-1"), newTestPod(deployment, "inference-2")}
+	// Half an hour of samples is low confidence; priority is what's under test here
+	config := DefaultConfig()
+	config.LowConfidenceThreshold = 0
+	opt, _, mc := newTestEngine(config, deployment, pods[0], pods[1])
+	for _, pod := range pods {
+		mc.add("pod/"+pod.Name, "gpu", steadySeries(30, 1.3))
+	}
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "inference")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if analysis.Deployment.GPURequested != 4 {
+		t.Fatalf("Expected 4 GPUs requested, got %d", analysis.Deployment.GPURequested)
+	}
+	if !analysis.GPUOverProvisioned || analysis.RecommendedGPUs != 2 {
+		t.Fatalf("Expected 2 GPUs recommended (1.3 x 1.2 rounded up), got %d", analysis.RecommendedGPUs)
+	}
+
+	recs, err := opt.recommendationGen.generateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+	var gpuRec *models.Recommendation
+	for i := range recs {
+		if _, ok := recs[i].RecommendedConfig.(map[string]interface{})["gpu"]; ok {
+			gpuRec = &recs[i]
+		}
+	}
+	if gpuRec == nil {
+		t.Fatal("Expected a GPU recommendation")
+	}
+
+	if got := gpuRec.RecommendedConfig.(map[string]interface{})["gpu"]; got != "2" {
+		t.Errorf("Expected 2 GPUs recommended, got %v", got)
+	}
+	// 2 fewer GPUs x $2.50/hour x 720 hours x 2 replicas
+	if math.Abs(gpuRec.EstimatedSavings-7200) > 0.01 {
+		t.Errorf("Expected savings of $7200/month, got %.2f", gpuRec.EstimatedSavings)
+	}
+	if gpuRec.Priority != string(PriorityHigh) {
+		t.Errorf("Expected high priority for GPU savings, got %s", gpuRec.Priority)
+	}
+
+	opt.recommendations[gpuRec.ID] = *gpuRec
+	patch, err := opt.ApplyRecommendation(gpuRec.ID, true)
+	if err != nil {
+		t.Fatalf("ApplyRecommendation failed: %v", err)
+	}
+	if strings.Count(patch.Patch, `"nvidia.com/gpu":"2"`) != 2 {
+		t.Errorf("Expected the GPU request and limit to be patched to 2, got %s", patch.Patch)
+	}
+
+	// Without GPU usage from every pod, GPUs are not sized at all
+	mc.set("pod/inference-2", "gpu", nil)
+	analysis, err = opt.analyzer.analyzeDeployment("default", "inference")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if analysis.GPUOverProvisioned {
+		t.Errorf("Expected no GPU sizing when a pod reports no GPU usage, got %d GPUs recommended", analysis.RecommendedGPUs)
+	}
+}
+
+// TestRecommendNodeSizing tests that an under-used node pool is sized down to fewer nodes or a
+// cheaper instance type, and that a busy pool is left alone
+func TestRecommendNodeSizing(t *testing.T) {
+	newNode := func(name, instanceType, cpu, memory string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{instanceTypeLabel: instanceType}},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}},
+		}
+	}
+
+	config := DefaultConfig()
+	config.NodeInstanceTypes = []NodeInstanceType{
+		{Name: "m5.2xlarge", CPU: 8000, Memory: 32 * 1024 * 1024 * 1024, HourlyPrice: 0.384},
+		{Name: "m5.large", CPU: 2000, Memory: 8 * 1024 * 1024 * 1024, HourlyPrice: 0.096},
+		{Name: "c5.4xlarge", CPU: 16000, Memory: 32 * 1024 * 1024 * 1024, HourlyPrice: 0.68},
+	}
+
+	var objects []runtime.Object
+	for i := 1; i <= 4; i++ {
+		objects = append(objects, newNode(fmt.Sprintf("idle-%d", i), "m5.2xlarge", "8", "32Gi"))
+	}
+	objects = append(objects, newNode("busy-1", "c5.4xlarge", "16", "32Gi"))
+	opt, _, mc := newTestEngine(config, objects...)
+
+	// Four idle nodes each use 1 vCPU and 4Gi; the busy node uses 12 of 16 vCPUs
+	for i := 1; i <= 4; i++ {
+		mc.add(fmt.Sprintf("node/idle-%d", i), "cpu", steadySeries(30, 1000))
+		mc.add(fmt.Sprintf("node/idle-%d", i), "memory", steadySeries(30, 4*1024*1024*1024))
+	}
+	mc.add("node/busy-1", "cpu", steadySeries(30, 12000))
+	mc.add("node/busy-1", "memory", steadySeries(30, 8*1024*1024*1024))
+
+	recs, err := opt.RecommendNodeSizing()
+	if err != nil {
+		t.Fatalf("RecommendNodeSizing failed: %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("Expected one node sizing recommendation, got %d: %+v", len(recs), recs)
+	}
+
+	rec := recs[0]
+	if rec.InstanceType != "m5.2xlarge" || rec.CurrentNodes != 4 {
+		t.Errorf("Expected the 4-node m5.2xlarge pool, got %d %s", rec.CurrentNodes, rec.InstanceType)
+	}
+	if math.Abs(rec.CPUUtilization-0.125) > 0.001 || math.Abs(rec.MemoryUtilization-0.125) > 0.001 {
+		t.Errorf("Expected 12.5%% CPU and memory utilization, got %.3f and %.3f", rec.CPUUtilization, rec.MemoryUtilization)
+	}
+
+	// 4 vCPU and 16Gi at 70% fit on 3 m5.large ($207/month) rather than 1 m5.2xlarge ($276/month)
+	if rec.RecommendedInstanceType != "m5.large" || rec.RecommendedNodes != 3 {
+		t.Errorf("Expected 3 m5.large nodes, got %d %s", rec.RecommendedNodes, rec.RecommendedInstanceType)
+	}
+	if rec.EstimatedSavings <= 0 || math.Abs(rec.CurrentMonthlyCost-rec.RecommendedMonthlyCost-rec.EstimatedSavings) > 0.01 {
+		t.Errorf("Expected positive savings matching the cost difference, got %+v", rec)
+	}
+}
+
+// TestDaemonSetOverheadReservation tests that DaemonSet pod requests are reserved on every node,
+// reducing consolidation headroom and the largest schedulable node
+func TestDaemonSetOverheadReservation(t *testing.T) {
+	isController := true
+	var objects []runtime.Object
+	for i := 1; i <= 4; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		objects = append(objects,
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{instanceTypeLabel: "m5.2xlarge"}},
+				Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("8"),
+					corev1.ResourceMemory: resource.MustParse("32Gi"),
+				}},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "log-agent-" + name,
+					Namespace: "kube-system",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "apps/v1", Kind: "DaemonSet", Name: "log-agent", Controller: &isController,
+					}},
+				},
+				Spec: corev1.PodSpec{
+					NodeName: name,
+					Containers: []corev1.Container{{
+						Name: "agent",
+						Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						}},
+					}},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			})
+	}
+
+	nodeSizing := func(reserve bool) (models.NodeSizingRecommendation, int64) {
+		t.Helper()
+		config := DefaultConfig()
+		config.ReserveDaemonSetOverhead = reserve
+		opt, _, mc := newTestEngine(config, objects...)
+		for i := 1; i <= 4; i++ {
+			mc.add(fmt.Sprintf("node/node-%d", i), "cpu", steadySeries(30, 1000))
+			mc.add(fmt.Sprintf("node/node-%d", i), "memory", steadySeries(30, 4*1024*1024*1024))
+		}
+
+		recs, err := opt.RecommendNodeSizing()
+		if err != nil {
+			t.Fatalf("RecommendNodeSizing failed: %v", err)
+		}
+		if len(recs) != 1 {
+			t.Fatalf("Expected one node sizing recommendation, got %d", len(recs))
+		}
+
+		cpu, _, err := opt.analyzer.largestNodeAllocatable()
+		if err != nil {
+			t.Fatalf("largestNodeAllocatable failed: %v", err)
+		}
+		return recs[0], cpu
+	}
+
+	without, largestWithout := nodeSizing(false)
+	with, largestWith := nodeSizing(true)
+
+	// 4 nodes x 8 vCPU less 4 vCPU used, then less 1 vCPU per node for the DaemonSet
+	if without.CPUHeadroom != 28000 || with.CPUHeadroom != 24000 {
+		t.Errorf("Expected CPU headroom 28000m without and 24000m with the reservation, got %d and %d",
+			without.CPUHeadroom, with.CPUHeadroom)
+	}
+	if without.MemoryHeadroom-with.MemoryHeadroom != 4*2*1024*1024*1024 {
+		t.Errorf("Expected memory headroom to drop by 8Gi, got %d and %d", without.MemoryHeadroom, with.MemoryHeadroom)
+	}
+	if with.DaemonSetCPU != 1000 || with.DaemonSetMemory != 2*1024*1024*1024 {
+		t.Errorf("Expected 1 vCPU and 2Gi reserved per node, got %dm and %d", with.DaemonSetCPU, with.DaemonSetMemory)
+	}
+	if largestWithout != 8000 || largestWith != 7000 {
+		t.Errorf("Expected largest schedulable node CPU 8000m without and 7000m with the reservation, got %d and %d",
+			largestWithout, largestWith)
+	}
+}
+
+// TestAnalyzeAllDeploymentsConcurrency tests that deployments are analyzed by a bounded worker pool,
+// that results keep the listing order, and that failing deployments are skipped
+func TestAnalyzeAllDeploymentsConcurrency(t *testing.T) {
+	var objects []runtime.Object
+	for i := 0; i < 12; i++ {
+		deployment := newTestDeployment(fmt.Sprintf("app-%02d", i), 1, "500m", "512Mi")
+		objects = append(objects, deployment, newTestPod(deployment, deployment.Name+"-pod"))
+	}
+	// Without pods there is no usage data, so this deployment fails to analyze
+	objects = append(objects, newTestDeployment("app-idle", 1, "500m", "512Mi"))
+
+	config := DefaultConfig()
+	config.AnalysisConcurrency = 3
+	_, clientset, mc := newTestEngine(config, objects...)
+	slow := &slowCollector{fakeCollector: mc, delay: 5 * time.Millisecond}
+	opt := NewWithConfig(&k8s.Client{Clientset: clientset}, slow, config)
+
+	analyses, err := opt.AnalyzeAllDeployments([]string{"default"})
+	if err != nil {
+		t.Fatalf("AnalyzeAllDeployments failed: %v", err)
+	}
+
+	if len(analyses) != 12 {
+		t.Fatalf("Expected 12 analyses with the failing deployment skipped, got %d", len(analyses))
+	}
+	for i, analysis := range analyses {
+		if want := fmt.Sprintf("app-%02d", i); analysis.Deployment != want {
+			t.Errorf("Expected analysis %d to be %s, got %s", i, want, analysis.Deployment)
+		}
+	}
+
+	if got := atomic.LoadInt32(&slow.peak); got < 2 || got > 3 {
+		t.Errorf("Expected between 2 and 3 concurrent analyses, got %d", got)
+	}
+}
+
+// slowCollector delays time series reads and records the peak number of concurrent reads
+type slowCollector struct {
+	*fakeCollector
+	delay          time.Duration
+	inflight, peak int32
+}
+
+func (s *slowCollector) GetTimeSeriesData(resource, metric string, duration time.Duration) (models.TimeSeriesData, error) {
+	current := atomic.AddInt32(&s.inflight, 1)
+	defer atomic.AddInt32(&s.inflight, -1)
+	for {
+		observed := atomic.LoadInt32(&s.peak)
+		if current <= observed || atomic.CompareAndSwapInt32(&s.peak, observed, current) {
+			break
+		}
+	}
+
+	time.Sleep(s.delay)
+	return s.fakeCollector.GetTimeSeriesData(resource, metric, duration)
+}
+
+// TestBreakEvenDays tests that high-savings recommendations pay back the apply cost quickly and
+// marginal ones slowly
+func TestBreakEvenDays(t *testing.T) {
+	config := DefaultConfig()
+	config.ApplyCostEstimate = 150
+	opt, _, _ := newTestEngine(config)
+
+	recs := []models.Recommendation{
+		{ID: "high", EstimatedSavings: 900},
+		{ID: "marginal", EstimatedSavings: 5},
+		{ID: "none", EstimatedSavings: 0},
+	}
+	opt.setBreakEven(recs)
+
+	// $150 / ($900 / 30 days)
+	if math.Abs(recs[0].BreakEvenDays-5) > 0.01 {
+		t.Errorf("Expected high-savings break-even of 5 days, got %.2f", recs[0].BreakEvenDays)
+	}
+	// $150 / ($5 / 30 days)
+	if math.Abs(recs[1].BreakEvenDays-900) > 0.01 {
+		t.Errorf("Expected marginal break-even of 900 days, got %.2f", recs[1].BreakEvenDays)
+	}
+	if recs[0].BreakEvenDays >= recs[1].BreakEvenDays {
+		t.Errorf("Expected high-savings recommendation to break even sooner than the marginal one")
+	}
+	if recs[2].BreakEvenDays != 0 {
+		t.Errorf("Expected no break-even without savings, got %.2f", recs[2].BreakEvenDays)
+	}
+}
+
+// TestDeploymentPodsOwnership tests that deployments sharing a label only see the pods of their
+// own ReplicaSets
+func TestDeploymentPodsOwnership(t *testing.T) {
+	stable := newTestDeployment("web", 2, "500m", "512Mi")
+	canary := newTestDeployment("web-canary", 1, "500m", "512Mi")
+	canary.Spec.Template.Labels = map[string]string{"app": "web", "track": "canary"}
+	canary.Spec.Selector.MatchLabels = canary.Spec.Template.Labels
+
+	// The stable selector (app=web) also matches the canary pod
+	opt, _, _ := newTestEngine(DefaultConfig(), stable, canary,
+		newTestPod(stable, "web-1"),
+		newTestPod(stable, "web-2"),
+		newTestPod(canary, "web-canary-1"),
+	)
+
+	for _, tc := range []struct {
+		deployment *appsv1.Deployment
+		expected   []string
+	}{
+		{stable, []string{"web-1", "web-2"}},
+		{canary, []string{"web-canary-1"}},
+	} {
+		pods, err := opt.analyzer.getDeploymentPods(tc.deployment)
+		if err != nil {
+			t.Fatalf("getDeploymentPods failed: %v", err)
+		}
+		var names []string
+		for _, pod := range pods {
+			names = append(names, pod.Name)
+		}
+		slices.Sort(names)
+		if !slices.Equal(names, tc.expected) {
+			t.Errorf("Expected %s to own %v, got %v", tc.deployment.Name, tc.expected, names)
+		}
+	}
+}
+
+// TestMinWorkloadAge tests that a freshly created deployment reports its configuration as too new
+// to analyze instead of recommendations based on startup usage
+func TestMinWorkloadAge(t *testing.T) {
+	fresh := newTestDeployment("fresh", 2, "2", "4Gi")
+	fresh.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	settled := newTestDeployment("settled", 2, "2", "4Gi")
+	settled.CreationTimestamp = metav1.NewTime(time.Now().Add(-48 * time.Hour))
+
+	opt, _, _ := newTestEngine(DefaultConfig(), fresh, settled,
+		newTestPod(fresh, "fresh-1"), newTestPod(settled, "settled-1"))
+
+	analysis, err := opt.AnalyzeDeployment("default", "fresh")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if !analysis.TooNew {
+		t.Error("Expected a deployment created an hour ago to be too new to analyze")
+	}
+	if analysis.CPUUsage.Requested != 2000 || analysis.Replicas.Current != 2 {
+		t.Errorf("Expected the configuration to still be reported, got %dm CPU and %d replicas",
+			analysis.CPUUsage.Requested, analysis.Replicas.Current)
+	}
+	recs, err := opt.GenerateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	if len(recs) != 0 {
+		t.Errorf("Expected no recommendations for a too-new deployment, got %d", len(recs))
+	}
+
+	// The same over-provisioned configuration is right-sized once the deployment has settled
+	analysis, err = opt.AnalyzeDeployment("default", "settled")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if analysis.TooNew {
+		t.Error("Expected a two-day-old deployment to be analyzed")
+	}
+	if recs, _ := opt.GenerateRecommendations(analysis); len(recs) == 0 {
+		t.Error("Expected recommendations for a settled over-provisioned deployment")
+	}
+}
+
+// TestAnalyzeWorkloadStatefulSet tests that StatefulSets are analyzed like deployments from their
+// own pods, with scaling flagged as higher risk, and that DaemonSets get no scaling advice
+func TestAnalyzeWorkloadStatefulSet(t *testing.T) {
+	template := newTestDeployment("db", 3, "500m", "512Mi").Spec
+	replicas := int32(3)
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: "db-uid"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: template.Selector,
+			Template: template.Template,
+		},
+	}
+	daemonSet := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default", UID: "agent-uid"},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "agent"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "agent"}},
+				Spec:       template.Template.Spec,
+			},
+		},
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2},
+	}
+	newControlledPod := func(owner metav1.Object, kind, name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Labels:          labels,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner, appsv1.SchemeGroupVersion.WithKind(kind))},
+			},
+			Spec:   template.Template.Spec,
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	objects := []runtime.Object{statefulSet, daemonSet}
+	for i := 0; i < 3; i++ {
+		objects = append(objects, newControlledPod(statefulSet, "StatefulSet", fmt.Sprintf("db-%d", i), template.Template.Labels))
+	}
+	for i := 0; i < 2; i++ {
+		objects = append(objects, newControlledPod(daemonSet, "DaemonSet", fmt.Sprintf("agent-%d", i), daemonSet.Spec.Template.Labels))
+	}
+	opt, _, _ := newTestEngine(DefaultConfig(), objects...)
+
+	if _, err := opt.AnalyzeDeployment("default", "db"); err == nil {
+		t.Error("Expected AnalyzeDeployment to fail for a StatefulSet")
+	}
+
+	analysis, err := opt.AnalyzeWorkload("default", "statefulset", "db")
+	if err != nil {
+		t.Fatalf("AnalyzeWorkload failed: %v", err)
+	}
+	if analysis.Kind != WorkloadKindStatefulSet || analysis.Replicas.Current != 3 {
+		t.Errorf("Expected a 3-replica StatefulSet analysis, got %s with %d replicas", analysis.Kind, analysis.Replicas.Current)
+	}
+	if analysis.DataPointCount != 90 {
+		t.Errorf("Expected 30 samples from each of the 3 pods, got %d", analysis.DataPointCount)
+	}
+
+	recs, err := opt.GenerateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	var resourceRec, scalingRec *models.Recommendation
+	for i := range recs {
+		switch recs[i].Type {
+		case string(RecommendationTypeResource):
+			resourceRec = &recs[i]
+		case string(RecommendationTypeScaling):
+			scalingRec = &recs[i]
+		}
+	}
+	if resourceRec == nil {
+		t.Error("Expected a resource recommendation for the over-provisioned StatefulSet")
+	} else if resourceRec.Kind != WorkloadKindStatefulSet {
+		t.Errorf("Expected the recommendation to target a StatefulSet, got %q", resourceRec.Kind)
+	}
+	if scalingRec == nil {
+		t.Fatal("Expected a scale-down recommendation for the under-used StatefulSet")
+	}
+	if scalingRec.Risk != "high" {
+		t.Errorf("Expected StatefulSet scaling to be high risk, got %s", scalingRec.Risk)
+	}
+
+	analysis, err = opt.AnalyzeWorkload("default", "DaemonSet", "agent")
+	if err != nil {
+		t.Fatalf("AnalyzeWorkload failed: %v", err)
+	}
+	if analysis.Replicas.Current != 2 {
+		t.Errorf("Expected one DaemonSet pod per scheduled node, got %d", analysis.Replicas.Current)
+	}
+	recs, err = opt.GenerateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	for _, rec := range recs {
+		if rec.Type == string(RecommendationTypeScaling) {
+			t.Errorf("Expected no scaling recommendation for a DaemonSet, got %q", rec.Description)
+		}
+	}
+
+	if _, err := opt.AnalyzeWorkload("default", "CronJob", "report"); err == nil {
+		t.Error("Expected an error for an unsupported workload kind")
+	}
+}
+
+// TestEventDrivenScalingRecommendation tests that a workload idling at its HPA minimum with long
+// gaps between bursts is recommended KEDA scaling, and a steadily busy one is not
+func TestEventDrivenScalingRecommendation(t *testing.T) {
+	newScenario := func(name string, demand func(minute int) float64) (*OptimizerEngine, *analysisResult) {
+		deployment := newTestDeployment(name, 2, "500m", "512Mi")
+		minReplicas, targetCPU := int32(2), int32(70)
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-hpa", Namespace: "default"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: name},
+				MinReplicas:    &minReplicas,
+				MaxReplicas:    10,
+				Metrics: []autoscalingv2.MetricSpec{{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name:   corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &targetCPU},
+					},
+				}},
+			},
+		}
+		pods := []*corev1.Pod{newTestPod(deployment, name+"-1"), newTestPod(deployment, name+"-2")}
+		opt, _, mc := newTestEngine(DefaultConfig(), deployment, hpa, pods[0], pods[1])
+
+		// Six hours of samples every five minutes
+		now := time.Now()
+		var cpu, replicas []models.DataPoint
+		for minute := 0; minute < 360; minute += 5 {
+			ts := now.Add(-time.Duration(360-minute) * time.Minute)
+			cpu = append(cpu, models.DataPoint{Timestamp: ts, Value: demand(minute)})
+			count := 2.0
+			if demand(minute) > 300 {
+				count = 6
+			}
+			replicas = append(replicas, models.DataPoint{Timestamp: ts, Value: count})
+		}
+		for _, pod := range pods {
+			mc.set("pod/"+pod.Name, "cpu", cpu)
+		}
+		mc.set("hpa/"+hpa.Name, "current_replicas", replicas)
+
+		analysis, err := opt.analyzer.analyzeDeployment("default", name)
+		if err != nil {
+			t.Fatalf("analyzeDeployment failed: %v", err)
+		}
+		return opt, analysis
+	}
+
+	// Idle for 2.5 hours, a 30-minute burst, idle again, another burst
+	opt, analysis := newScenario("kafka-consumer", func(minute int) float64 {
+		if (minute >= 150 && minute < 180) || minute >= 330 {
+			return 450
+		}
+		return 2
+	})
+	if !analysis.HPAIdleAtMinimum || !analysis.EventDrivenCandidate {
+		t.Fatalf("Expected an event-driven candidate, got idle at minimum %v, longest idle gap %v",
+			analysis.HPAIdleAtMinimum, analysis.LongestIdleGap)
+	}
+	if analysis.LongestIdleGap < 2*time.Hour {
+		t.Errorf("Expected an idle gap of at least 2 hours, got %v", analysis.LongestIdleGap)
+	}
+
+	recs, err := opt.recommendationGen.generateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+	var kedaRec *models.Recommendation
+	for i := range recs {
+		if recs[i].Type == string(RecommendationTypeEventDriven) {
+			kedaRec = &recs[i]
+		}
+	}
+	if kedaRec == nil {
+		t.Fatal("Expected an event-driven scaling recommendation")
+	}
+	config := kedaRec.RecommendedConfig.(map[string]interface{})
+	if config["scaler"] != "kafka" || config["min_replicas"] != 0 {
+		t.Errorf("Expected the kafka scaler with scale to zero, got %v", config)
+	}
+	if kedaRec.EstimatedSavings <= 0 {
+		t.Errorf("Expected savings from scaling to zero while idle, got %.2f", kedaRec.EstimatedSavings)
+	}
+	if _, err := opt.buildConfigPatch(kedaRec, config); err == nil {
+		t.Error("Expected event-driven recommendations not to be applied automatically")
+	}
+
+	// Steady load never idles, so CPU autoscaling is fine
+	opt, analysis = newScenario("api", func(minute int) float64 { return 200 })
+	if analysis.EventDrivenCandidate {
+		t.Error("Expected no event-driven candidate for steady load")
+	}
+	recs, _ = opt.recommendationGen.generateRecommendations(analysis)
+	for _, rec := range recs {
+		if rec.Type == string(RecommendationTypeEventDriven) {
+			t.Errorf("Expected no event-driven recommendation for steady load, got %q", rec.Description)
+		}
+	}
+}
+
+// TestGuaranteedQoSRecommendation tests that latency-critical workloads, by annotation or name
+// pattern, are recommended request == limit at the right-sized values
+func TestGuaranteedQoSRecommendation(t *testing.T) {
+	annotated := newTestDeployment("checkout", 1, "500m", "512Mi")
+	annotated.Annotations = map[string]string{LatencyCriticalAnnotation: "true"}
+	gateway := newTestDeployment("api-gateway", 1, "500m", "512Mi")
+	regular := newTestDeployment("web", 1, "500m", "512Mi")
+
+	opt, _, _ := newTestEngine(DefaultConfig(), annotated, newTestPod(annotated, "checkout-1"),
+		gateway, newTestPod(gateway, "api-gateway-1"), regular, newTestPod(regular, "web-1"))
+
+	for _, name := range []string{"checkout", "api-gateway"} {
+		analysis, err := opt.analyzer.analyzeDeployment("default", name)
+		if err != nil {
+			t.Fatalf("analyzeDeployment(%s) failed: %v", name, err)
+		}
+		if !analysis.Deployment.LatencyCritical {
+			t.Fatalf("Expected %s to be latency-critical", name)
+		}
+
+		recs, err := opt.recommendationGen.generateRecommendations(analysis)
+		if err != nil {
+			t.Fatalf("generateRecommendations(%s) failed: %v", name, err)
+		}
+
+		var qos *models.Recommendation
+		for i := range recs {
+			if strings.Contains(recs[i].Description, "Guaranteed QoS") {
+				qos = &recs[i]
+			} else if recs[i].Type == string(RecommendationTypeResource) {
+				t.Errorf("Expected the Guaranteed QoS recommendation to replace right-sizing for %s, got %q", name, recs[i].Description)
+			}
+		}
+		if qos == nil {
+			t.Fatalf("Expected a Guaranteed QoS recommendation for %s", name)
+		}
+
+		// 100m P95 x 1.2 rounded to 50m, and 128Mi x 1.2 rounded to 32Mi
+		recommended := qos.RecommendedConfig.(map[string]interface{})
+		if recommended["cpu_request"] != "150m" || recommended["cpu_limit"] != "150m" {
+			t.Errorf("Expected CPU request and limit 150m for %s, got %v/%v", name, recommended["cpu_request"], recommended["cpu_limit"])
+		}
+		if recommended["memory_request"] != "160Mi" || recommended["memory_limit"] != "160Mi" {
+			t.Errorf("Expected memory request and limit 160Mi for %s, got %v/%v", name, recommended["memory_request"], recommended["memory_limit"])
+		}
+	}
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("analyzeDeployment(web) failed: %v", err)
+	}
+	recs, err := opt.recommendationGen.generateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("generateRecommendations(web) failed: %v", err)
+	}
+	for _, rec := range recs {
+		if strings.Contains(rec.Description, "Guaranteed QoS") {
+			t.Errorf("Expected no Guaranteed QoS recommendation for a regular workload, got %q", rec.Description)
+		}
+	}
+}
+
+// TestRecommendationCooldown tests that a workload's recommendations are not regenerated within the
+// cooldown and are once it has passed
+func TestRecommendationCooldown(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "1", "1Gi")
+	config := DefaultConfig()
+	config.RecommendationCooldown = time.Hour
+	opt, _, _ := newTestEngine(config, deployment, newTestPod(deployment, "web-1"))
+
+	analysis, err := opt.AnalyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	generate := func() []string {
+		recs, err := opt.GenerateRecommendations(analysis)
+		if err != nil {
+			t.Fatalf("GenerateRecommendations failed: %v", err)
+		}
+		ids := make([]string, len(recs))
+		for i, rec := range recs {
+			ids[i] = rec.ID
+		}
+		return ids
+	}
+
+	first := generate()
+	if len(first) == 0 {
+		t.Fatal("Expected recommendations for an over-provisioned deployment")
+	}
+
+	// Back-to-back refreshes within the cooldown return the same recommendations
+	for i := 0; i < 3; i++ {
+		if again := generate(); !slices.Equal(again, first) {
+			t.Fatalf("Expected the cached recommendations %v within the cooldown, got %v", first, again)
+		}
+	}
+	if all, _ := opt.GetAllRecommendations(); len(all) != len(first) {
+		t.Errorf("Expected no recommendations to be added within the cooldown, got %d stored for %d generated", len(all), len(first))
+	}
+
+	// Once the cooldown has passed they are regenerated
+	opt.recommendationsMu.Lock()
+	for key, generation := range opt.generations {
+		generation.generatedAt = time.Now().Add(-config.RecommendationCooldown)
+		opt.generations[key] = generation
+	}
+	opt.recommendationsMu.Unlock()
+	again := generate()
+	if len(again) == 0 || slices.Contains(first, again[0]) {
+		t.Errorf("Expected newly generated recommendations after the cooldown, got %v", again)
+	}
+
+	// The new generation replaces the previous one rather than adding to it
+	if all, _ := opt.GetAllRecommendations(); len(all) != len(again) {
+		t.Errorf("Expected only the latest generation to be stored, got %d stored for %d generated", len(all), len(again))
+	}
+	for _, id := range first {
+		if _, err := opt.GetRecommendationByID(id); err == nil {
+			t.Errorf("Expected superseded recommendation %s to be dropped", id)
+		}
+	}
+}
+
+// TestFlagConflicts tests that a scale-down and a request reduction for the same deployment are flagged
+func TestFlagConflicts(t *testing.T) {
+	deployment := newTestDeployment("web", 3, "1", "1Gi")
+	opt, _, _ := newTestEngine(DefaultConfig(), deployment,
+		newTestPod(deployment, "web-1"), newTestPod(deployment, "web-2"), newTestPod(deployment, "web-3"))
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	recs, err := opt.recommendationGen.generateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+
+	var scaleDown *models.Recommendation
+	var reductions []*models.Recommendation
+	for i := range recs {
+		switch {
+		case lowersReplicas(&recs[i]):
+			scaleDown = &recs[i]
+		case lowersRequests(&recs[i]):
+			reductions = append(reductions, &recs[i])
+		}
+	}
+	if scaleDown == nil || len(reductions) == 0 {
+		t.Fatalf("Expected both a scale-down and a request reduction for an idle 3-replica deployment, got %d recommendations", len(recs))
+	}
+
+	for _, reduction := range reductions {
+		if !slices.Contains(reduction.ConflictsWith, scaleDown.ID) {
+			t.Errorf("Expected %q to conflict with the scale-down, got %v", reduction.Description, reduction.ConflictsWith)
+		}
+		if !slices.Contains(scaleDown.ConflictsWith, reduction.ID) {
+			t.Errorf("Expected the scale-down to conflict with %q, got %v", reduction.Description, scaleDown.ConflictsWith)
+		}
+	}
+	if !strings.Contains(strings.Join(scaleDown.Rationale, "\n"), "Combined alternative") {
+		t.Errorf("Expected the scale-down rationale to suggest a combined alternative, got %v", scaleDown.Rationale)
+	}
+
+	// Recommendations that don't over-shoot together are left alone
+	for i := range recs {
+		if &recs[i] != scaleDown && !lowersRequests(&recs[i]) && len(recs[i].ConflictsWith) > 0 {
+			t.Errorf("Expected no conflicts on %q, got %v", recs[i].Description, recs[i].ConflictsWith)
+		}
+	}
+}
+
+// TestHPACustomMetricRecommendation tests HPA target recommendations for memory and external metrics
+func TestHPACustomMetricRecommendation(t *testing.T) {
+	deployment := newTestDeployment("worker", 2, "500m", "512Mi")
+	minReplicas := int32(2)
+	targetMemory, currentMemory := int32(60), int32(85)
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-hpa", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name:   corev1.ResourceMemory,
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &targetMemory},
+					},
+				},
+				{
+					Type: autoscalingv2.ExternalMetricSourceType,
+					External: &autoscalingv2.ExternalMetricSource{
+						Metric: autoscalingv2.MetricIdentifier{Name: "queue_depth"},
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: resource.NewQuantity(100, resource.DecimalSI)},
+					},
+				},
+			},
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 2,
+			CurrentMetrics: []autoscalingv2.MetricStatus{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricStatus{
+						Name:    corev1.ResourceMemory,
+						Current: autoscalingv2.MetricValueStatus{AverageUtilization: &currentMemory},
+					},
+				},
+				{
+					Type: autoscalingv2.ExternalMetricSourceType,
+					External: &autoscalingv2.ExternalMetricStatus{
+						Metric:  autoscalingv2.MetricIdentifier{Name: "queue_depth"},
+						Current: autoscalingv2.MetricValueStatus{AverageValue: resource.NewQuantity(150, resource.DecimalSI)},
+					},
+				},
+			},
+		},
+	}
+	opt, _, _ := newTestEngine(DefaultConfig(), deployment, hpa,
+		newTestPod(deployment, "worker-1"), newTestPod(deployment, "worker-2"))
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "worker")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if len(analysis.Deployment.HPAMetrics) != 2 {
+		t.Fatalf("Expected the memory and queue_depth HPA metrics, got %+v", analysis.Deployment.HPAMetrics)
+	}
+	if !analysis.HPANeedsOptimization {
+		t.Fatal("Expected HPA optimization for metrics far from their targets")
+	}
+
+	recs, err := opt.recommendationGen.generateRecommendations(analysis)
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+	var target *models.Recommendation
+	for i := range recs {
+		if strings.HasPrefix(recs[i].Description, "Adjust HPA") {
+			target = &recs[i]
+		}
+	}
+	if target == nil {
+		t.Fatal("Expected an HPA target recommendation")
+	}
+
+	// queue_depth is 50% over target, memory 25 points (42%) over, so queue_depth drives the recommendation
+	recommended := target.RecommendedConfig.(map[string]interface{})
+	if recommended["metric"] != "queue_depth" || recommended["target"] != 125.0 {
+		t.Errorf("Expected the queue_depth target moved to 125, got %v=%v", recommended["metric"], recommended["target"])
+	}
+	if !strings.Contains(target.Description, "queue_depth target from 100 to 125") {
+		t.Errorf("Unexpected description: %s", target.Description)
+	}
+	if !strings.Contains(strings.Join(target.Rationale, "\n"), "HPA current memory 85%") {
+		t.Errorf("Expected the memory deviation in the rationale, got %v", target.Rationale)
+	}
+
+	// Applying updates only the queue_depth target, keeping its AverageValue type
+	opt.recommendations[target.ID] = *target
+	patch, err := opt.ApplyRecommendation(target.ID, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if !strings.Contains(patch.Patch, `"averageValue":"125"`) || !strings.Contains(patch.Patch, `"averageUtilization":60`) {
+		t.Errorf("Expected the queue_depth target patched to 125 and memory left at 60%%, got %s", patch.Patch)
+	}
+}
+
+// TestMissingRequestsRecommendation tests that workloads without requests get them added from P95 usage
+func TestMissingRequestsRecommendation(t *testing.T) {
+	opt := NewWithConfig(nil, nil, DefaultConfig())
+
+	// No requests or limits at all: BestEffort
+	recs, err := opt.recommendationGen.generateRecommendations(newTestAnalysis(0, 0, 0, 0))
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+	resourceRecs := resourceRecommendations(recs)
+	if len(resourceRecs) != 1 {
+		t.Fatalf("Expected only the missing requests recommendation, got %d: %+v", len(resourceRecs), resourceRecs)
+	}
+	rec := resourceRecs[0]
+	if rec.Priority != string(PriorityHigh) {
+		t.Errorf("Expected high priority, got %s", rec.Priority)
+	}
+	if rec.Description != "Add missing CPU request, CPU limit, memory request, memory limit" {
+		t.Errorf("Unexpected description: %s", rec.Description)
+	}
+
+	// P95 x 1.2 rounded up: 120m -> 150m, 153.6Mi -> 160Mi, limits at 2x
+	recommended := rec.RecommendedConfig.(map[string]interface{})
+	expected := map[string]interface{}{"cpu_request": "150m", "cpu_limit": "300m", "memory_request": "160Mi", "memory_limit": "320Mi"}
+	if !reflect.DeepEqual(recommended, expected) {
+		t.Errorf("Expected %v, got %v", expected, recommended)
+	}
+	if !strings.Contains(strings.Join(rec.Rationale, "\n"), "BestEffort") {
+		t.Errorf("Expected the BestEffort QoS risk in the rationale, got %v", rec.Rationale)
+	}
+
+	// A CPU limit without a request keeps the limit and caps the request at it
+	recs, err = opt.recommendationGen.generateRecommendations(newTestAnalysis(0, 100, 256*1024*1024, 512*1024*1024))
+	if err != nil {
+		t.Fatalf("generateRecommendations failed: %v", err)
+	}
+	var missing *models.Recommendation
+	for i := range recs {
+		if strings.HasPrefix(recs[i].Description, "Add missing") {
+			missing = &recs[i]
+		}
+	}
+	if missing == nil {
+		t.Fatal("Expected a missing requests recommendation")
+	}
+	recommended = missing.RecommendedConfig.(map[string]interface{})
+	if len(recommended) != 1 || recommended["cpu_request"] != "100m" {
+		t.Errorf("Expected only a CPU request capped at the 100m limit, got %v", recommended)
+	}
+
+	// Workloads with both requests get none
+	recs, _ = opt.recommendationGen.generateRecommendations(newTestAnalysis(200, 400, 256*1024*1024, 512*1024*1024))
+	for _, rec := range recs {
+		if strings.HasPrefix(rec.Description, "Add missing") {
+			t.Errorf("Unexpected missing requests recommendation: %s", rec.Description)
+		}
+	}
+}
+
+// TestQoSClass tests QoS class detection and its effect on health and recommendations
+func TestQoSClass(t *testing.T) {
+	cpu, memory := resource.MustParse("500m"), resource.MustParse("256Mi")
+	limits := corev1.ResourceList{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory}
+
+	tests := []struct {
+		name     string
+		spec     corev1.PodSpec
+		expected corev1.PodQOSClass
+	}{
+		{"no resources", corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}, corev1.PodQOSBestEffort},
+		{"limits only", corev1.PodSpec{Containers: []corev1.Container{{Name: "app",
+			Resources: corev1.ResourceRequirements{Limits: limits}}}}, corev1.PodQOSGuaranteed},
+		{"requests below limits", corev1.PodSpec{Containers: []corev1.Container{{Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}, Limits: limits}}}}, corev1.PodQOSBurstable},
+		{"unbounded init container", corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers:     []corev1.Container{{Name: "app", Resources: corev1.ResourceRequirements{Limits: limits}}},
+		}, corev1.PodQOSBurstable},
+	}
+	for _, tt := range tests {
+		if class := podQoSClass(tt.spec); class != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, class)
+		}
+	}
+
+	bestEffort := newTestDeployment("batch", 2, "100m", "128Mi")
+	bestEffort.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{}
+	burstable := newTestDeployment("api", 2, "100m", "128Mi")
+	critical := newTestDeployment("checkout", 1, "100m", "128Mi")
+	critical.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{}
+	critical.Annotations = map[string]string{LatencyCriticalAnnotation: "true"}
+
+	// Short test series are low confidence, which would cap every priority at low
+	config := DefaultConfig()
+	config.LowConfidenceThreshold = 0
+	opt, _, _ := newTestEngine(config, bestEffort, burstable, critical,
+		newTestPod(bestEffort, "batch-1"), newTestPod(burstable, "api-1"), newTestPod(critical, "checkout-1"))
+
+	bestEffortAnalysis, err := opt.AnalyzeDeployment("default", "batch")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	burstableAnalysis, err := opt.AnalyzeDeployment("default", "api")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if bestEffortAnalysis.QoSClass != "BestEffort" || burstableAnalysis.QoSClass != "Burstable" {
+		t.Errorf("Expected BestEffort and Burstable, got %s and %s", bestEffortAnalysis.QoSClass, burstableAnalysis.QoSClass)
+	}
+	if bestEffortAnalysis.HealthScore >= burstableAnalysis.HealthScore {
+		t.Errorf("Expected BestEffort health %.0f below Burstable health %.0f", bestEffortAnalysis.HealthScore, burstableAnalysis.HealthScore)
+	}
+
+	// Ordinary BestEffort workloads are moved to Burstable by adding requests
+	analysis, err := opt.analyzer.analyzeDeployment("default", "batch")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	recs, _ := opt.recommendationGen.generateRecommendations(analysis)
+	if len(recs) == 0 || !strings.HasPrefix(recs[0].Description, "Move BestEffort workload to Burstable QoS") {
+		t.Errorf("Expected a move to Burstable QoS, got %+v", recs)
+	}
+
+	// Critical ones straight to Guaranteed, without a separate missing requests recommendation
+	analysis, err = opt.analyzer.analyzeDeployment("default", "checkout")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	recs, _ = opt.recommendationGen.generateRecommendations(analysis)
+	resourceRecs := resourceRecommendations(recs)
+	if len(resourceRecs) != 1 {
+		t.Fatalf("Expected one resource recommendation, got %+v", resourceRecs)
+	}
+	if !strings.HasPrefix(resourceRecs[0].Description, "Move BestEffort latency-critical workload to Guaranteed QoS") ||
+		resourceRecs[0].Priority != string(PriorityHigh) {
+		t.Errorf("Expected a high-priority move to Guaranteed QoS, got %s (%s)", resourceRecs[0].Description, resourceRecs[0].Priority)
+	}
+}
+
+// TestRecencyWeightedUsage tests that a recency half-life weights recent usage over old usage
+func TestRecencyWeightedUsage(t *testing.T) {
+	// Idle for four days, busy for the last four hours
+	now := time.Now()
+	points := make([]models.DataPoint, 100)
+	for i := range points {
+		value := 50.0
+		if i >= 96 {
+			value = 400
+		}
+		points[i] = models.DataPoint{Timestamp: now.Add(-time.Duration(100-i) * time.Hour), Value: value}
+	}
+
+	analyze := func(halfLife time.Duration) (*analysisResult, []models.Recommendation) {
+		config := DefaultConfig()
+		config.RecencyHalfLife = halfLife
+		config.LowConfidenceThreshold = 0
+
+		deployment := newTestDeployment("web", 1, "1000m", "128Mi")
+		opt, _, mc := newTestEngine(config, deployment, newTestPod(deployment, "web-1"))
+		mc.set("pod/web-1", "cpu", points)
+
+		analysis, err := opt.analyzer.analyzeDeployment("default", "web")
+		if err != nil {
+			t.Fatalf("analyzeDeployment failed: %v", err)
+		}
+		recs, err := opt.recommendationGen.generateRecommendations(analysis)
+		if err != nil {
+			t.Fatalf("generateRecommendations failed: %v", err)
+		}
+		return analysis, recs
+	}
+
+	analysis, recs := analyze(0)
+	if analysis.Deployment.CPUP95 != 50 || analysis.Deployment.RecencyWeighted {
+		t.Errorf("Expected unweighted P95 of 50m, got %dm (weighted: %v)", analysis.Deployment.CPUP95, analysis.Deployment.RecencyWeighted)
+	}
+	for _, rec := range recs {
+		if strings.Contains(rec.Description, "recency-weighted") {
+			t.Errorf("Unweighted recommendation marked as recency-weighted: %s", rec.Description)
+		}
+	}
+
+	analysis, recs = analyze(6 * time.Hour)
+	if analysis.Deployment.CPUP95 != 400 || !analysis.Deployment.RecencyWeighted {
+		t.Errorf("Expected weighted P95 of 400m, got %dm (weighted: %v)", analysis.Deployment.CPUP95, analysis.Deployment.RecencyWeighted)
+	}
+	if analysis.Deployment.CPUAverage <= 100 {
+		t.Errorf("Expected weighted average above 100m, got %dm", analysis.Deployment.CPUAverage)
+	}
+	if analysis.Deployment.CPUMax != 400 {
+		t.Errorf("Expected unweighted max of 400m, got %dm", analysis.Deployment.CPUMax)
+	}
+
+	resourceRecs := resourceRecommendations(recs)
+	if len(resourceRecs) == 0 {
+		t.Fatal("Expected a resource recommendation")
+	}
+	if !strings.HasSuffix(resourceRecs[0].Description, "(based on recency-weighted usage)") {
+		t.Errorf("Expected description to note recency weighting, got %s", resourceRecs[0].Description)
+	}
+	config := resourceRecs[0].RecommendedConfig.(map[string]interface{})
+	if got := config["cpu_request"]; got != "500m" {
+		t.Errorf("Expected CPU request sized from recent usage (500m), got %v", got)
+	}
+}
+
+// TestCPUThrottling tests that throttling at the CPU limit is flagged even when utilization of the
+// request looks healthy, and that a high-priority limit increase is recommended
+func TestCPUThrottling(t *testing.T) {
+	deployment := newTestDeployment("api", 1, "200m", "256Mi")
+	deployment.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("250m"),
+		corev1.ResourceMemory: resource.MustParse("512Mi"),
+	}
+	pod := newTestPod(deployment, "api-1")
+
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, pod)
+	mc.set("pod/api-1", "cpu", steadySeries(30, 150))
+	mc.set("pod/api-1", collector.MetricCPUThrottle, steadySeries(30, 0.3))
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "api")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if analysis.CPUUnderProvisioned || analysis.CPUOverProvisioned {
+		t.Fatalf("Expected healthy CPU utilization, got under %v, over %v", analysis.CPUUnderProvisioned, analysis.CPUOverProvisioned)
+	}
+	if !analysis.CPUThrottled || analysis.Deployment.CPUThrottleRatio != 0.3 {
+		t.Errorf("Expected CPU throttled at 30%%, got %v at %.2f", analysis.CPUThrottled, analysis.Deployment.CPUThrottleRatio)
+	}
+
+	recs, _ := opt.recommendationGen.generateRecommendations(analysis)
+	resourceRecs := resourceRecommendations(recs)
+	if len(resourceRecs) != 1 {
+		t.Fatalf("Expected one resource recommendation, got %+v", resourceRecs)
+	}
+	rec := resourceRecs[0]
+	if rec.Description != "Raise CPU limit from 250m to 400m: throttled in 30% of CPU periods" || rec.Priority != string(PriorityHigh) {
+		t.Errorf("Expected a high-priority limit increase, got %s (%s)", rec.Description, rec.Priority)
+	}
+	config := rec.RecommendedConfig.(map[string]interface{})
+	if config["cpu_request"] != "200m" || config["cpu_limit"] != "400m" {
+		t.Errorf("Expected only the limit raised, got %v", config)
+	}
+
+	// Throttling below the threshold is not flagged
+	mc.set("pod/api-1", collector.MetricCPUThrottle, steadySeries(30, 0.05))
+	analysis, err = opt.analyzer.analyzeDeployment("default", "api")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	if analysis.CPUThrottled {
+		t.Error("Expected throttling under the threshold not to be flagged")
+	}
+}
+
+// TestOOMKillRecommendation tests that OOM-killed containers raise the memory limit and cost more health
+// than other restarts
+func TestOOMKillRecommendation(t *testing.T) {
+	deployment := newTestDeployment("api", 1, "100m", "128Mi")
+	deployment.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("200m"),
+		corev1.ResourceMemory: resource.MustParse("256Mi"),
+	}
+	pod := newTestPod(deployment, "api-1")
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:         "app",
+		RestartCount: 3,
+		LastTerminationState: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: RestartReasonOOMKilled, ExitCode: 137},
+		},
+	}}
+
+	config := DefaultConfig()
+	config.LowConfidenceThreshold = 0
+	opt, _, mc := newTestEngine(config, deployment, pod)
+	mc.set("pod/api-1", "memory", steadySeries(30, 60*1024*1024))
+
+	analysis, err := opt.analyzer.analyzeDeployment("default", "api")
+	if err != nil {
+		t.Fatalf("analyzeDeployment failed: %v", err)
+	}
+	// Only the last of the three restarts is known to be an OOM kill
+	if !analysis.MemoryOOMKilled || analysis.Deployment.OOMKillCount != 1 {
+		t.Fatalf("Expected 1 OOM-killed container, got %v with %d", analysis.MemoryOOMKilled, analysis.Deployment.OOMKillCount)
+	}
+	if analysis.MemoryOverProvisioned {
+		t.Error("Expected low usage of an OOM-killed deployment not to count as over-provisioning")
+	}
+
+	recs, _ := opt.recommendationGen.generateRecommendations(analysis)
+	var oomRec *models.Recommendation
+	resourceRecs := resourceRecommendations(recs)
+	for i, rec := range resourceRecs {
+		if strings.HasPrefix(rec.Description, "Raise memory limit") {
+			oomRec = &resourceRecs[i]
+		} else if _, ok := rec.RecommendedConfig.(map[string]interface{})["memory_request"]; ok {
+			t.Errorf("Expected no other memory recommendation, got %s", rec.Description)
+		}
+	}
+	if oomRec == nil {
+		t.Fatalf("Expected an OOM kill recommendation, got %+v", recs)
+	}
+	if oomRec.Priority != string(PriorityHigh) {
+		t.Errorf("Expected high priority, got %s", oomRec.Priority)
+	}
+	recommended := oomRec.RecommendedConfig.(map[string]interface{})
+	if recommended["memory_limit"] != "384Mi" || recommended["memory_request"] != "128Mi" {
+		t.Errorf("Expected the limit raised to 384Mi and the request kept, got %v", recommended)
+	}
+
+	// The same restarts for another cause cost less health
+	oomHealth := opt.scorer.calculateHealthScore(analysis)
+	analysis.Deployment.OOMKillCount = 0
+	if health := opt.scorer.calculateHealthScore(analysis); health <= oomHealth {
+		t.Errorf("Expected OOM kills to lower health more than other restarts, got %.1f vs %.1f", oomHealth, health)
+	}
+}
+
+// TestRecommendationManifest tests rendering resource and HPA recommendations as YAML patches
+func TestRecommendationManifest(t *testing.T) {
+	deployment := newTestDeployment("web", 2, "1", "1Gi")
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-hpa", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MaxReplicas:    4,
+		},
+	}
+	opt, clientset, _ := newTestEngine(DefaultConfig(), deployment, hpa)
+
+	opt.recommendations["rec-resource"] = models.Recommendation{
+		ID:                "rec-resource",
+		Type:              string(RecommendationTypeResource),
+		Namespace:         "default",
+		Deployment:        "web",
+		Description:       "Reduce CPU request",
+		RecommendedConfig: map[string]interface{}{"cpu_request": "250m", "memory_limit": "512Mi"},
+	}
+	opt.recommendations["rec-hpa"] = models.Recommendation{
+		ID:                "rec-hpa",
+		Type:              string(RecommendationTypeHPA),
+		Namespace:         "default",
+		Deployment:        "web",
+		RecommendedConfig: map[string]interface{}{"min_replicas": 2, "max_replicas": 8},
+	}
+
+	manifest, err := opt.RecommendationManifest("rec-resource")
+	if err != nil {
+		t.Fatalf("RecommendationManifest failed: %v", err)
+	}
+	for _, want := range []string{
+		"# Recommendation rec-resource: Reduce CPU request\n",
+		"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: default\n",
+		"      containers:\n      - name: app\n        resources:\n          limits:\n            memory: 512Mi\n          requests:\n            cpu: 250m\n",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("Expected manifest to contain %q, got:\n%s", want, manifest)
+		}
+	}
+
+	manifest, err = opt.RecommendationManifest("rec-hpa")
+	if err != nil {
+		t.Fatalf("RecommendationManifest failed: %v", err)
+	}
+	if !strings.Contains(manifest, "apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: web-hpa\n") ||
+		!strings.Contains(manifest, "spec:\n  maxReplicas: 8\n  minReplicas: 2\n") {
+		t.Errorf("Expected an HPA spec patch, got:\n%s", manifest)
+	}
+
+	// Rendering a manifest leaves the cluster unchanged
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Errorf("Expected no patches, got %v", action)
+		}
+	}
+
+	if _, err := opt.RecommendationManifest("missing"); err == nil {
+		t.Error("Expected an error for an unknown recommendation")
+	}
+}
+
+// TestRecommendationCommand tests that ticket commands patch the recommendation's workload kind,
+// container and HPA the way ApplyRecommendation does
+func TestRecommendationCommand(t *testing.T) {
+	web := newTestDeployment("web", 2, "1", "1Gi")
+	worker := web.Spec.Template.Spec.Containers[0]
+	worker.Name = "worker"
+	web.Spec.Template.Spec.Containers = append(web.Spec.Template.Spec.Containers, worker)
+	template := newTestDeployment("db", 1, "1", "1Gi").Spec
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       appsv1.StatefulSetSpec{Selector: template.Selector, Template: template.Template},
+	}
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-autoscaler", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MaxReplicas:    4,
+		},
+	}
+	opt, _, _ := newTestEngine(DefaultConfig(), web, statefulSet, hpa)
+
+	for _, rec := range []models.Recommendation{
+		{ID: "rec-sts", Type: string(RecommendationTypeResource), Deployment: "db", Kind: WorkloadKindStatefulSet,
+			RecommendedConfig: map[string]interface{}{"cpu_request": "250m"}},
+		{ID: "rec-worker", Type: string(RecommendationTypeResource), Deployment: "web", Container: "worker",
+			RecommendedConfig: map[string]interface{}{"memory_limit": "512Mi"}},
+		{ID: "rec-hpa", Type: string(RecommendationTypeHPA), Deployment: "web",
+			RecommendedConfig: map[string]interface{}{"min_replicas": 2, "max_replicas": 8, "target_cpu": 60}},
+		{ID: "rec-idle", Type: string(RecommendationTypeIdle), Deployment: "web"},
+	} {
+		rec.Namespace = "default"
+		opt.recommendations[rec.ID] = rec
+	}
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"rec-sts", []string{"kubectl patch statefulset/db -n default --type strategic -p '", `"name":"app"`, `"cpu":"250m"`}},
+		{"rec-worker", []string{"kubectl patch deployment/web -n default", `"name":"worker"`, `"memory":"512Mi"`}},
+		{"rec-hpa", []string{"kubectl patch horizontalpodautoscaler/web-autoscaler -n default", `"maxReplicas":8`, `"averageUtilization":60`}},
+	}
+	for _, tt := range tests {
+		command, err := opt.RecommendationCommand(tt.id)
+		if err != nil {
+			t.Fatalf("RecommendationCommand(%s) failed: %v", tt.id, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(command, want) {
+				t.Errorf("Expected the %s command to contain %q, got %s", tt.id, want, command)
+			}
+		}
+	}
+
+	if command, err := opt.RecommendationCommand("rec-idle"); err != nil || command != "" {
+		t.Errorf("Expected no command for an idle recommendation, got %q, %v", command, err)
+	}
+	if _, err := opt.RecommendationCommand("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown recommendation, got %v", err)
+	}
+}
+
+// TestSimulateRecommendations tests projecting cluster cost for a set of recommendations
+func TestSimulateRecommendations(t *testing.T) {
+	deployment := newTestDeployment("web", 2, "1", "1Gi")
+	opt, clientset, _ := newTestEngine(DefaultConfig(), deployment, newTestPod(deployment, "web-1"))
+	if _, err := opt.AnalyzeDeployment("default", "web"); err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+
+	for _, rec := range []models.Recommendation{
+		{ID: "cpu-1", Type: string(RecommendationTypeResource), Namespace: "default", Deployment: "web", EstimatedSavings: 10},
+		{ID: "cpu-2", Type: string(RecommendationTypeResource), Namespace: "default", Deployment: "web", EstimatedSavings: 15, ConflictsWith: []string{"scale"}},
+		{ID: "scale", Type: string(RecommendationTypeScaling), Namespace: "default", Deployment: "web", EstimatedSavings: 20, ConflictsWith: []string{"cpu-2"}},
+		{ID: "applied", Type: string(RecommendationTypeHPA), Namespace: "default", Deployment: "web", EstimatedSavings: 5, AppliedAt: time.Now()},
+	} {
+		opt.recommendations[rec.ID] = rec
+	}
+
+	current := opt.GetDeploymentSummaries()[0].MonthlyCost
+	simulation, err := opt.SimulateRecommendations([]string{"cpu-1", "scale", "cpu-2", "applied"})
+	if err != nil {
+		t.Fatalf("SimulateRecommendations failed: %v", err)
+	}
+
+	if !slices.Equal(simulation.RecommendationIDs, []string{"scale", "cpu-2"}) {
+		t.Errorf("Expected the later resource recommendation and the scale-down counted, got %v", simulation.RecommendationIDs)
+	}
+	if math.Abs(simulation.CurrentMonthlyCost-current) > 0.001 || math.Abs(simulation.Delta+35) > 0.001 ||
+		math.Abs(simulation.ProjectedMonthlyCost-(current-35)) > 0.001 {
+		t.Errorf("Expected current %.2f less 35, got current %.2f, projected %.2f, delta %.2f",
+			current, simulation.CurrentMonthlyCost, simulation.ProjectedMonthlyCost, simulation.Delta)
+	}
+
+	conflicts := make(map[string]models.WhatIfConflict)
+	for _, conflict := range simulation.Conflicts {
+		conflicts[conflict.RecommendationID+">"+conflict.ConflictsWith] = conflict
+	}
+	if conflict, ok := conflicts["cpu-1>cpu-2"]; !ok || conflict.Counted {
+		t.Errorf("Expected cpu-1 superseded by cpu-2, got %+v", simulation.Conflicts)
+	}
+	if _, ok := conflicts["applied>"]; !ok {
+		t.Errorf("Expected the applied recommendation flagged, got %+v", simulation.Conflicts)
+	}
+	if conflict, ok := conflicts["scale>cpu-2"]; !ok || !conflict.Counted {
+		t.Errorf("Expected the scale-down and reduction flagged as over-shooting, got %+v", simulation.Conflicts)
+	}
+
+	// Simulating changes nothing
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "patch" || action.GetVerb() == "update" {
+			t.Errorf("Expected no writes, got %v", action)
+		}
+	}
+
+	if _, err := opt.SimulateRecommendations([]string{"missing"}); err == nil {
+		t.Error("Expected an error for an unknown recommendation")
+	}
+}
+
+// TestAnalysisTypedErrors tests that missing deployments and thin history are distinguishable
+func TestAnalysisTypedErrors(t *testing.T) {
+	opt, _, _ := newTestEngine(DefaultConfig(), newTestDeployment("web", 2, "500m", "512Mi"))
+
+	if _, err := opt.AnalyzeDeployment("default", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing deployment, got %v", err)
+	}
+	if _, err := opt.AnalyzeDeployment("default", "web"); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("Expected ErrInsufficientData for a deployment without history, got %v", err)
+	}
+	if _, err := opt.AnalyzeContainer("default", "web", "sidecar"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing container, got %v", err)
+	}
+	if err := opt.DismissRecommendation("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing recommendation, got %v", err)
+	}
+}
+
+// TestUpdateConfig tests validating runtime configuration updates and invalidating cached analyses
+func TestUpdateConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.MinWorkloadAge = 0
+	config.Pricing = pricing.NewStaticProvider(pricing.Rates{CPUCostPerVCPUHour: 0.03, MemoryCostPerGBHour: 0.004},
+		map[string]pricing.Rates{"spot-1": {CPUCostPerVCPUHour: 0.01, MemoryCostPerGBHour: 0.001}})
+	deployment := newTestDeployment("web", 1, "1000m", "512Mi")
+	opt, _, _ := newTestEngine(config, deployment, newTestPod(deployment, "web-1"))
+
+	analysis, err := opt.AnalyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if _, err := opt.GenerateRecommendations(analysis); err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	if !opt.analysisCache["default/web"].CPUOverProvisioned {
+		t.Fatal("Expected 10% CPU utilization to be over-provisioned under the default threshold")
+	}
+
+	invalid := 0.95
+	if _, err := opt.UpdateConfig(ConfigUpdate{OptimalUtilizationMin: &invalid}); err == nil {
+		t.Error("Expected an error for an optimal minimum above the maximum")
+	}
+	if opt.GetConfig().OptimalUtilizationMin != config.OptimalUtilizationMin || len(opt.analysisCache) == 0 {
+		t.Error("Expected a rejected update to change nothing")
+	}
+
+	threshold, rate := 0.05, 0.05
+	updated, err := opt.UpdateConfig(ConfigUpdate{CPUOverProvisionedThreshold: &threshold, CPUCostPerVCPUHour: &rate})
+	if err != nil {
+		t.Fatalf("UpdateConfig failed: %v", err)
+	}
+	if updated.CPUOverProvisionedThreshold != 0.05 || updated.Pricing.CPUCostPerVCPUHour("") != 0.05 ||
+		updated.Pricing.CPUCostPerVCPUHour("spot-1") != 0.01 || updated.Pricing.MemoryCostPerGBHour("") != 0.004 {
+		t.Errorf("Expected the threshold and default CPU rate updated and node rates kept, got %+v", updated)
+	}
+	// The provider is updated in place, so an analyzer sharing it sees the new rate
+	if config.Pricing.CPUCostPerVCPUHour("") != 0.05 {
+		t.Errorf("Expected the shared provider's default CPU rate to be 0.05, got %g", config.Pricing.CPUCostPerVCPUHour(""))
+	}
+	if _, cooling := opt.recentRecommendations("default/web"); len(opt.analysisCache) != 0 || cooling {
+		t.Error("Expected cached analyses and recommendation cooldowns to be dropped")
+	}
+
+	// The next analysis uses the new threshold: 10% utilization is no longer over-provisioned
+	if _, err := opt.AnalyzeDeployment("default", "web"); err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if opt.analysisCache["default/web"].CPUOverProvisioned {
+		t.Error("Expected CPU not to be over-provisioned under the lowered threshold")
+	}
+
+	// Rates of a provider that can't be adjusted are not silently replaced
+	fixed := DefaultConfig()
+	fixed.Pricing = fixedRateProvider{}
+	opt, _, _ = newTestEngine(fixed)
+	if _, err := opt.UpdateConfig(ConfigUpdate{CPUCostPerVCPUHour: &rate}); err == nil {
+		t.Error("Expected a rate update to be rejected for a non-adjustable provider")
+	}
+	if _, ok := opt.GetConfig().Pricing.(fixedRateProvider); !ok {
+		t.Errorf("Expected the provider to be kept, got %T", opt.GetConfig().Pricing)
+	}
+}
+
+// fixedRateProvider is a pricing provider whose rates can't be changed at runtime
+type fixedRateProvider struct{}
+
+func (fixedRateProvider) CPUCostPerVCPUHour(node string) float64  { return 0.03 }
+func (fixedRateProvider) MemoryCostPerGBHour(node string) float64 { return 0.004 }
+
+// TestRecommendNodeConsolidation tests bin-packing pod requests onto fewer nodes
+func TestRecommendNodeConsolidation(t *testing.T) {
+	isController := true
+	newPod := func(name, node, cpu, memory string, daemonSet bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName: node,
+				Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(cpu),
+						corev1.ResourceMemory: resource.MustParse(memory),
+					}},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		if daemonSet {
+			pod.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "DaemonSet", Name: "log-agent", Controller: &isController,
+			}}
+		}
+		return pod
+	}
+
+	config := DefaultConfig()
+	config.NodeInstanceTypes = []NodeInstanceType{
+		{Name: "m5.xlarge", CPU: 4000, Memory: 16 * 1024 * 1024 * 1024, HourlyPrice: 0.192},
+	}
+
+	var objects []runtime.Object
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		objects = append(objects,
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{instanceTypeLabel: "m5.xlarge"}},
+				Spec:       corev1.NodeSpec{Unschedulable: i == 5},
+				Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("16Gi"),
+				}},
+			},
+			newPod("log-agent-"+name, name, "500m", "512Mi", true))
+	}
+	// Four 1-vCPU pods spread over four nodes; node-4 runs only its DaemonSet pod
+	objects = append(objects,
+		newPod("web-1", "node-1", "1", "2Gi", false),
+		newPod("web-2", "node-1", "1", "2Gi", false),
+		newPod("api-1", "node-2", "1", "2Gi", false),
+		newPod("api-2", "node-3", "1", "2Gi", false))
+	opt, _, _ := newTestEngine(config, objects...)
+
+	consolidation, err := opt.RecommendNodeConsolidation()
+	if err != nil {
+		t.Fatalf("RecommendNodeConsolidation failed: %v", err)
+	}
+	if consolidation == nil {
+		t.Fatal("Expected a consolidation recommendation")
+	}
+
+	// 3.5 schedulable vCPUs filled to 90% take three 1-vCPU pods, so four pods need two nodes
+	if consolidation.CurrentNodes != 4 || consolidation.TargetNodes != 2 {
+		t.Errorf("Expected 4 schedulable nodes consolidated to 2, got %d to %d", consolidation.CurrentNodes, consolidation.TargetNodes)
+	}
+	var candidates []string
+	for _, candidate := range consolidation.Candidates {
+		candidates = append(candidates, candidate.Node)
+	}
+	if !reflect.DeepEqual(candidates, []string{"node-3", "node-4"}) {
+		t.Errorf("Expected the least requested nodes node-3 and node-4 as candidates, got %v", candidates)
+	}
+	if consolidation.CPURequested != 4000 {
+		t.Errorf("Expected 4 vCPUs of movable requests, got %dm", consolidation.CPURequested)
+	}
+	if want := 2 * 0.192 * 24 * 30; math.Abs(consolidation.EstimatedSavings-want) > 0.01 {
+		t.Errorf("Expected savings of %.2f, got %.2f", want, consolidation.EstimatedSavings)
+	}
+
+	// Fully used nodes cannot be consolidated
+	full, _, _ := newTestEngine(config,
+		objects[0], objects[1], objects[2], objects[3],
+		newPod("big-1", "node-1", "3", "2Gi", false),
+		newPod("big-2", "node-2", "3", "2Gi", false))
+	if consolidation, err := full.RecommendNodeConsolidation(); err != nil || consolidation != nil {
+		t.Errorf("Expected no consolidation for full nodes, got %+v, %v", consolidation, err)
+	}
+}
+
+// TestFindIdleWorkloads tests idle detection and the scale-to-zero recommendation
+func TestFindIdleWorkloads(t *testing.T) {
+	idleDeployment := newTestDeployment("legacy-worker", 3, "500m", "512Mi")
+	busyDeployment := newTestDeployment("api", 2, "500m", "512Mi")
+	opt, _, mc := newTestEngine(DefaultConfig(), idleDeployment, busyDeployment,
+		newTestPod(idleDeployment, "legacy-worker-1"), newTestPod(busyDeployment, "api-1"))
+	mc.set("pod/legacy-worker-1", "cpu", steadySeries(30, 2))
+	mc.set("pod/api-1", "cpu", steadySeries(30, 300))
+
+	idle, err := opt.FindIdleWorkloads([]string{"default"})
+	if err != nil {
+		t.Fatalf("FindIdleWorkloads failed: %v", err)
+	}
+	if len(idle) != 1 || idle[0].Deployment != "legacy-worker" {
+		t.Fatalf("expected only legacy-worker to be idle, got %+v", idle)
+	}
+	if idle[0].Replicas != 3 || idle[0].MonthlyCost <= 0 || idle[0].RecommendationID == "" {
+		t.Errorf("unexpected idle workload: %+v", idle[0])
+	}
+
+	rec, err := opt.GetRecommendationByID(idle[0].RecommendationID)
+	if err != nil {
+		t.Fatalf("idle recommendation not stored: %v", err)
+	}
+	recommended, _ := rec.RecommendedConfig.(map[string]interface{})
+	if rec.Type != string(RecommendationTypeIdle) || recommended["replicas"] != 0 {
+		t.Errorf("expected an idle scale-to-zero recommendation, got %+v", rec)
+	}
+	if rec.EstimatedSavings != idle[0].MonthlyCost {
+		t.Errorf("expected savings of the full monthly cost %.2f, got %.2f", idle[0].MonthlyCost, rec.EstimatedSavings)
+	}
+
+	recs, err := opt.GetRecommendationsForDeployment("default", "legacy-worker")
+	if err != nil {
+		t.Fatalf("GetRecommendationsForDeployment failed: %v", err)
+	}
+	for _, r := range recs {
+		if r.Type != string(RecommendationTypeIdle) {
+			t.Errorf("idle workload should only get the idle recommendation, got %s: %s", r.Type, r.Description)
+		}
+	}
+
+	// Memory that moves is a sign of work even when CPU is quiet
+	memory := steadySeries(30, 0)
+	for i := range memory {
+		memory[i].Value = float64(64+8*i) * 1024 * 1024
+	}
+	mc.set("pod/legacy-worker-1", "memory", memory)
+	opt.ClearCache()
+	opt.ClearRecommendations()
+	idle, err = opt.FindIdleWorkloads([]string{"default"})
+	if err != nil {
+		t.Fatalf("FindIdleWorkloads failed: %v", err)
+	}
+	if len(idle) != 0 {
+		t.Errorf("expected no idle workloads with changing memory, got %+v", idle)
+	}
+}
+
+// TestRecommendVPA tests VPA-style bounds from per-container usage percentiles
+func TestRecommendVPA(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "1", "1Gi")
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, newTestPod(deployment, "web-1"))
+
+	cpu := steadySeries(100, 0)
+	for i := range cpu {
+		cpu[i].Value = float64(100 + i) // P50 ~150m, P95 ~195m, P99 ~199m
+	}
+	mc.set("pod/web-1/container/app", "cpu", cpu)
+	mc.set("pod/web-1/container/app", "memory", steadySeries(100, 200*1024*1024))
+
+	vpa, err := opt.RecommendVPA("default", "web")
+	if err != nil {
+		t.Fatalf("RecommendVPA failed: %v", err)
+	}
+	if len(vpa.Containers) != 1 || vpa.Containers[0].Container != "app" {
+		t.Fatalf("expected one recommendation for container app, got %+v", vpa.Containers)
+	}
+	rec := vpa.Containers[0]
+	if rec.LowerBound.CPU != 149 {
+		t.Errorf("expected lower bound at P50 149m, got %dm", rec.LowerBound.CPU)
+	}
+	if rec.Target.CPU != 250 { // 194m x 1.2 rounded up to 50m
+		t.Errorf("expected target 250m, got %dm", rec.Target.CPU)
+	}
+	if rec.UpperBound.CPU < rec.Target.CPU || rec.LowerBound.CPU > rec.Target.CPU {
+		t.Errorf("expected lowerBound <= target <= upperBound, got %+v", rec)
+	}
+	if rec.Target.Memory != 256*1024*1024 { // 200Mi x 1.2 = 240Mi, rounded up to 32Mi
+		t.Errorf("expected memory target 256Mi, got %d", rec.Target.Memory)
+	}
+
+	mc.set("pod/web-1/container/app", "cpu", nil)
+	mc.set("pod/web-1/container/app", "memory", nil)
+	if _, err := opt.RecommendVPA("default", "web"); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("expected ErrInsufficientData without container usage, got %v", err)
+	}
+}
+
+// TestLimitPolicies tests that recommended limits follow CPULimitRatio and MemoryLimitRatio
+func TestLimitPolicies(t *testing.T) {
+	config := DefaultConfig()
+	config.CPULimitRatio = CPULimitNone
+	config.MemoryLimitRatio = MemoryLimitEqual
+	opt := NewWithConfig(nil, nil, config)
+
+	// Over-provisioned Burstable deployment: 1 CPU / 1Gi requested, 2 CPU / 2Gi limits
+	analysis := newTestAnalysis(1000, 2000, 1024*1024*1024, 2*1024*1024*1024)
+	recs := opt.recommendationGen.generateResourceRecommendations(analysis)
+	if len(recs) == 0 {
+		t.Fatal("Expected right-sizing recommendations")
+	}
+	for _, rec := range recs {
+		recommended := rec.RecommendedConfig.(map[string]interface{})
+		if _, ok := recommended["cpu_limit"]; ok {
+			t.Errorf("Expected no CPU limit under CPULimitNone, got %v in %q", recommended["cpu_limit"], rec.Description)
+		}
+		if request, ok := recommended["memory_request"]; ok && recommended["memory_limit"] != request {
+			t.Errorf("Expected memory limit equal to request %v, got %v", request, recommended["memory_limit"])
+		}
+	}
+
+	// Throttled workloads have their CPU limit removed rather than raised
+	throttled := newTestAnalysis(100, 200, 128*1024*1024, 128*1024*1024)
+	throttled.CPUThrottled = true
+	throttled.Deployment.CPUThrottleRatio = 0.3
+	rec := opt.recommendationGen.generateCPUThrottlingRecommendation(throttled)
+	if rec == nil {
+		t.Fatal("Expected a throttling recommendation")
+	}
+	recommended := rec.RecommendedConfig.(map[string]interface{})
+	if limit, ok := recommended["cpu_limit"]; !ok || limit != nil {
+		t.Errorf("Expected cpu_limit mapped to nil to remove the limit, got %v", recommended)
+	}
+
+	// Ratios other than the policies scale the request
+	config.CPULimitRatio = 1.5
+	config.MemoryLimitRatio = 1.25
+	opt = NewWithConfig(nil, nil, config)
+	if limit := opt.recommendationGen.recommendedCPULimit(&analysis.Deployment, 200); limit != 300 {
+		t.Errorf("Expected CPU limit 300m at 1.5x, got %dm", limit)
+	}
+	if limit := opt.recommendationGen.recommendedMemoryLimit(&analysis.Deployment, 1024); limit != 1280 {
+		t.Errorf("Expected memory limit 1280 at 1.25x, got %d", limit)
+	}
+
+	// Ratios below 1 would put limits under requests, so they are raised to 1
+	config.CPULimitRatio = 0.5
+	config.MemoryLimitRatio = 0.8
+	opt = NewWithConfig(nil, nil, config)
+	if got := opt.GetConfig(); got.CPULimitRatio != 1 || got.MemoryLimitRatio != 1 {
+		t.Errorf("Expected limit ratios below 1 raised to 1, got %g and %g", got.CPULimitRatio, got.MemoryLimitRatio)
+	}
+	if limit := opt.recommendationGen.recommendedCPULimit(&analysis.Deployment, 200); limit != 200 {
+		t.Errorf("Expected CPU limit equal to the 200m request, got %dm", limit)
+	}
+}
+
+// TestNilReplicasDefaultToOne tests that a deployment without spec.replicas is analyzed as one replica
+func TestNilReplicasDefaultToOne(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "500m", "512Mi")
+	deployment.Spec.Replicas = nil
+	opt, _, _ := newTestEngine(DefaultConfig(), deployment, newTestPod(deployment, "web-1"))
+
+	analysis, err := opt.AnalyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if analysis.Replicas.Current != 1 {
+		t.Errorf("expected nil replicas to default to 1, got %d", analysis.Replicas.Current)
+	}
+}
+
+// TestNilHPAMinReplicas tests that an HPA relying on the default minReplicas is analyzed with a minimum of 1
+func TestNilHPAMinReplicas(t *testing.T) {
+	deployment := newTestDeployment("web", 2, "500m", "512Mi")
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-hpa", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MaxReplicas:    4,
+		},
+	}
+	opt, _, _ := newTestEngine(DefaultConfig(), deployment, hpa, newTestPod(deployment, "web-1"))
+
+	analysis, err := opt.AnalyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+	if analysis.Replicas.Min != 1 || analysis.Replicas.Max != 4 {
+		t.Errorf("expected HPA replicas 1-4, got %d-%d", analysis.Replicas.Min, analysis.Replicas.Max)
+	}
+}
+
+// TestAnalysisTrends tests that growing CPU usage is reported as an increasing per-day trend and flat
+// memory as stable
+func TestAnalysisTrends(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "500m", "512Mi")
+	opt, _, mc := newTestEngine(DefaultConfig(), deployment, newTestPod(deployment, "web-1"))
+
+	// CPU grows by 5m every hour over two days
+	now := time.Now()
+	growing := make([]models.DataPoint, 48)
+	for i := range growing {
+		growing[i] = models.DataPoint{Timestamp: now.Add(-time.Duration(48-i) * time.Hour), Value: 100 + 5*float64(i)}
+	}
+	mc.set("pod/web-1", "cpu", growing)
+
+	analysis, err := opt.AnalyzeDeployment("default", "web")
+	if err != nil {
+		t.Fatalf("AnalyzeDeployment failed: %v", err)
+	}
+
+	cpu := analysis.Trends.CPU
+	if cpu.Direction != "increasing" {
+		t.Errorf("Expected increasing CPU trend, got %+v", cpu)
+	}
+	if cpu.SlopePerDay < 100 || cpu.SlopePerDay > 140 {
+		t.Errorf("Expected a CPU slope near 120m/day, got %.1f", cpu.SlopePerDay)
+	}
+	if cpu.PercentPerDay <= 0 || cpu.RSquared < 0.9 {
+		t.Errorf("Expected a positive, well-fitting CPU trend, got %+v", cpu)
+	}
+	if memory := analysis.Trends.Memory; memory.Direction != "stable" || memory.SlopePerDay != 0 {
+		t.Errorf("Expected stable memory trend, got %+v", memory)
+	}
+}
+
+// TestApplyDeploymentLevelMultiContainer tests that a deployment-level resource recommendation is not
+// applied to the first of several app containers, while container-scoped ones still are
+func TestApplyDeploymentLevelMultiContainer(t *testing.T) {
+	deployment := newTestDeployment("web", 1, "1", "1Gi")
+	worker := deployment.Spec.Template.Spec.Containers[0]
+	worker.Name = "worker"
+	deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, worker)
+	opt, _, _ := newTestEngine(DefaultConfig(), deployment)
+
+	for _, rec := range []models.Recommendation{
+		{ID: "rec-pod", Container: ""},
+		{ID: "rec-worker", Container: "worker"},
+	} {
+		rec.Type = string(RecommendationTypeResource)
+		rec.Namespace = "default"
+		rec.Deployment = "web"
+		rec.RecommendedConfig = map[string]interface{}{"cpu_request": "1500m", "memory_request": "1536Mi"}
+		opt.recommendations[rec.ID] = rec
+	}
+
+	if _, err := opt.ApplyRecommendation("rec-pod", true); err == nil || !strings.Contains(err.Error(), "per-container") {
+		t.Errorf("Expected the deployment-level recommendation to be refused, got %v", err)
+	}
+	if _, err := opt.RecommendationManifest("rec-pod"); err == nil {
+		t.Error("Expected no manifest for the deployment-level recommendation")
+	}
+
+	patch, err := opt.ApplyRecommendation("rec-worker", true)
+	if err != nil {
+		t.Fatalf("Dry run of the container recommendation failed: %v", err)
+	}
+	if !strings.Contains(patch.Patch, `"name":"worker"`) {
+		t.Errorf("Expected the patch to target the worker container, got %s", patch.Patch)
+	}
+}
+
+// TestApplyStatefulSetRecommendation tests that recommendations for a StatefulSet patch, render and
+// revert the StatefulSet rather than a Deployment of the same name
+func TestApplyStatefulSetRecommendation(t *testing.T) {
+	deployment := newTestDeployment("db", 1, "2", "2Gi")
+	template := newTestDeployment("db", 1, "1", "1Gi").Spec
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: "db-sts-uid"},
+		Spec:       appsv1.StatefulSetSpec{Selector: template.Selector, Template: template.Template},
+	}
+	opt, clientset, _ := newTestEngine(DefaultConfig(), deployment, statefulSet)
+	opt.recommendations["rec-sts"] = models.Recommendation{
+		ID:                "rec-sts",
+		Type:              string(RecommendationTypeResource),
+		Namespace:         "default",
+		Deployment:        "db",
+		Kind:              WorkloadKindStatefulSet,
+		RecommendedConfig: map[string]interface{}{"cpu_request": "250m", "memory_request": "512Mi"},
+	}
+
+	manifest, err := opt.RecommendationManifest("rec-sts")
+	if err != nil {
+		t.Fatalf("RecommendationManifest failed: %v", err)
+	}
+	if !strings.Contains(manifest, "kind: StatefulSet") || !strings.Contains(manifest, "apiVersion: apps/v1") {
+		t.Errorf("Expected a StatefulSet manifest, got:\n%s", manifest)
+	}
+
+	patch, err := opt.ApplyRecommendation("rec-sts", false)
+	if err != nil {
+		t.Fatalf("ApplyRecommendation failed: %v", err)
+	}
+	if patch.Kind != WorkloadKindStatefulSet {
+		t.Errorf("Expected a StatefulSet patch, got %s", patch.Kind)
+	}
+	current, _ := clientset.AppsV1().StatefulSets("default").Get(context.Background(), "db", metav1.GetOptions{})
+	if got := current.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().MilliValue(); got != 250 {
+		t.Errorf("Expected the StatefulSet CPU request to be 250m, got %dm", got)
+	}
+	untouched, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "db", metav1.GetOptions{})
+	if got := untouched.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().MilliValue(); got != 2000 {
+		t.Errorf("Expected the same-named Deployment to keep its 2 CPU request, got %dm", got)
+	}
+
+	if err := opt.RevertRecommendation("rec-sts"); err != nil {
+		t.Fatalf("RevertRecommendation failed: %v", err)
+	}
+	current, _ = clientset.AppsV1().StatefulSets("default").Get(context.Background(), "db", metav1.GetOptions{})
+	if got := current.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().MilliValue(); got != 1000 {
+		t.Errorf("Expected the revert to restore the 1 CPU request, got %dm", got)
+	}
+}
+
+// TestRecommendationStatsActiveOnly tests that stats, potential savings and the active list leave out applied,
+// dismissed and superseded recommendations
+func TestRecommendationStatsActiveOnly(t *testing.T) {
+	opt, _, _ := newTestEngine(DefaultConfig())
+	rec := func(id, deployment, recType string, savings float64) models.Recommendation {
+		return models.Recommendation{ID: id, Type: recType, Namespace: "default", Deployment: deployment,
+			Priority: string(PriorityHigh), EstimatedSavings: savings, CashSavings: savings}
+	}
+	active := rec("active", "web", string(RecommendationTypeResource), 10)
+	superseded := rec("superseded", "web", string(RecommendationTypeHPA), 20)
+	applied := rec("applied", "api", string(RecommendationTypeResource), 40)
+	applied.AppliedAt = time.Now()
+	dismissed := rec("dismissed", "worker", string(RecommendationTypeScaling), 80)
+	for _, r := range []models.Recommendation{active, superseded, applied, dismissed} {
+		opt.recommendations[r.ID] = r
+	}
+	opt.generations["default/web"] = recommendationGeneration{generatedAt: time.Now(), ids: []string{"active"}}
+	opt.dismissals[dismissalKey(dismissed)] = time.Now()
+
+	stats := opt.GetRecommendationStats()
+	if stats["total"] != 1 || stats["high_priority"] != 1 || stats["affected_deployments"] != 1 {
+		t.Errorf("Expected only the active recommendation to be counted, got %v", stats)
+	}
+	if stats["total_savings"] != 10.0 {
+		t.Errorf("Expected total savings of 10, got %v", stats["total_savings"])
+	}
+	if savings, _ := opt.GetTotalPotentialSavings(); savings != 10 {
+		t.Errorf("Expected potential savings of 10, got %v", savings)
+	}
+	if recs, _ := opt.GetActiveRecommendations(); len(recs) != 1 || recs[0].ID != "active" {
+		t.Errorf("Expected only the active recommendation to be listed, got %v", recs)
+	}
+}
@@ -0,0 +1,59 @@
+package optimizer
+
+import (
+	"sort"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// priorityRanks orders priorities from most to least urgent; unknown priorities sort last
+var priorityRanks = map[string]int{
+	string(PriorityHigh):   0,
+	string(PriorityMedium): 1,
+	string(PriorityLow):    2,
+}
+
+// priorityRank returns the sort rank of a priority
+func priorityRank(priority string) int {
+	if rank, ok := priorityRanks[priority]; ok {
+		return rank
+	}
+	return len(priorityRanks)
+}
+
+// SortByPriority orders recommendations by priority (high first), then estimated savings
+// (highest first), then ID so that the order is stable across calls
+func SortByPriority(recommendations []models.Recommendation) {
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if rankA, rankB := priorityRank(a.Priority), priorityRank(b.Priority); rankA != rankB {
+			return rankA < rankB
+		}
+		if a.EstimatedSavings != b.EstimatedSavings {
+			return a.EstimatedSavings > b.EstimatedSavings
+		}
+		return a.ID < b.ID
+	})
+}
+
+// SortBySavings orders recommendations by estimated savings (highest first), then ID
+func SortBySavings(recommendations []models.Recommendation) {
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.EstimatedSavings != b.EstimatedSavings {
+			return a.EstimatedSavings > b.EstimatedSavings
+		}
+		return a.ID < b.ID
+	})
+}
+
+// SortByCreatedAt orders recommendations by creation time (newest first), then ID
+func SortByCreatedAt(recommendations []models.Recommendation) {
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+}
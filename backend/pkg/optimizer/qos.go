@@ -0,0 +1,49 @@
+package optimizer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// bestEffortHealthPenalty is the health score deduction for BestEffort workloads, which are evicted
+// first under node pressure and get no guaranteed CPU or memory
+const bestEffortHealthPenalty = 15.0
+
+// podQoSClass returns the QoS class Kubernetes assigns to pods with the given spec, considering every
+// container including init containers. Pods are Guaranteed when every container sets CPU and memory
+// limits with requests equal to them (unset requests default to the limit), BestEffort when no
+// container sets any CPU or memory request or limit, and Burstable otherwise.
+func podQoSClass(spec corev1.PodSpec) corev1.PodQOSClass {
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+
+	anySet := false
+	guaranteed := len(containers) > 0
+	for _, container := range containers {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			request, hasRequest := container.Resources.Requests[name]
+			limit, hasLimit := container.Resources.Limits[name]
+			hasRequest = hasRequest && !request.IsZero()
+			hasLimit = hasLimit && !limit.IsZero()
+
+			if hasRequest || hasLimit {
+				anySet = true
+			}
+			if !hasLimit || (hasRequest && request.Cmp(limit) != 0) {
+				guaranteed = false
+			}
+		}
+	}
+
+	switch {
+	case !anySet:
+		return corev1.PodQOSBestEffort
+	case guaranteed:
+		return corev1.PodQOSGuaranteed
+	default:
+		return corev1.PodQOSBurstable
+	}
+}
+
+// isBestEffort reports whether the workload's pods run in the BestEffort QoS class
+func isBestEffort(metrics *deploymentMetrics) bool {
+	return metrics.QoSClass == string(corev1.PodQOSBestEffort)
+}
@@ -0,0 +1,147 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnalyzeQuotaPressure reports how much headroom a namespace has left under its
+// ResourceQuotas. Only compute resources count, since object-count and storage quotas do
+// not block a scale-up. It returns nil if the namespace has no compute quota.
+func (opt *OptimizerEngine) AnalyzeQuotaPressure(namespace string) (*models.QuotaPressure, error) {
+	ctx := context.Background()
+
+	quotaList, err := opt.k8sClient.Clientset.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource quotas in namespace %s: %w", namespace, err)
+	}
+
+	if len(quotaList.Items) == 0 {
+		return nil, nil
+	}
+
+	pressure := &models.QuotaPressure{
+		Namespace: namespace,
+		Timestamp: time.Now(),
+	}
+
+	for _, quota := range quotaList.Items {
+		for name, hard := range quota.Status.Hard {
+			if !isComputeQuotaResource(name) {
+				continue
+			}
+
+			used := quota.Status.Used[name]
+
+			usage := models.QuotaResourceUsage{
+				Quota:    quota.Name,
+				Resource: string(name),
+				Hard:     hard.Value(),
+				Used:     used.Value(),
+			}
+			if isCPUQuotaResource(name) {
+				usage.Hard = hard.MilliValue()
+				usage.Used = used.MilliValue()
+			}
+
+			usage.Headroom = usage.Hard - usage.Used
+			if usage.Hard > 0 {
+				usage.Utilization = float64(usage.Used) / float64(usage.Hard)
+			} else if usage.Used > 0 {
+				usage.Utilization = 1
+			}
+
+			if usage.Utilization > pressure.MaxUtilization {
+				pressure.MaxUtilization = usage.Utilization
+			}
+
+			pressure.Resources = append(pressure.Resources, usage)
+		}
+	}
+
+	if len(pressure.Resources) == 0 {
+		return nil, nil
+	}
+
+	// Most constrained resources first
+	sort.Slice(pressure.Resources, func(i, j int) bool {
+		return pressure.Resources[i].Utilization > pressure.Resources[j].Utilization
+	})
+
+	pressure.NearLimit = pressure.MaxUtilization >= opt.cfg().QuotaPressureThreshold
+
+	return pressure, nil
+}
+
+// isComputeQuotaResource reports whether a quota resource limits CPU or memory requests
+// or limits, as opposed to object counts or storage
+func isComputeQuotaResource(name corev1.ResourceName) bool {
+	switch name {
+	case corev1.ResourceCPU, corev1.ResourceMemory:
+		return true
+	}
+	s := string(name)
+	return strings.HasPrefix(s, "requests.") || strings.HasPrefix(s, "limits.")
+}
+
+// isCPUQuotaResource reports whether a quota resource is measured in CPU units
+func isCPUQuotaResource(name corev1.ResourceName) bool {
+	return name == corev1.ResourceCPU || strings.HasSuffix(string(name), ".cpu")
+}
+
+// generateQuotaRecommendation generates a recommendation to free namespace quota
+// in place of a scale-up that the quota would block
+func (rg *recommendationGenerator) generateQuotaRecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+	pressure := analysis.QuotaPressure
+
+	if pressure == nil || len(pressure.Resources) == 0 {
+		return nil
+	}
+
+	constrained := pressure.Resources[0]
+
+	description := fmt.Sprintf("Scale-up blocked by ResourceQuota %s (%s at %.1f%%); right-size over-provisioned workloads in namespace %s to free quota",
+		constrained.Quota, constrained.Resource, constrained.Utilization*100, metrics.Namespace)
+
+	currentConfig := map[string]interface{}{
+		"quota":    constrained.Quota,
+		"resource": constrained.Resource,
+		"used":     constrained.Used,
+		"hard":     constrained.Hard,
+		"headroom": constrained.Headroom,
+	}
+
+	recommendedConfig := map[string]interface{}{
+		"replicas": metrics.CurrentReplicas,
+	}
+
+	return &models.Recommendation{
+		ID:                uuid.New().String(),
+		Type:              string(RecommendationTypeQuota),
+		Namespace:         metrics.Namespace,
+		Deployment:        metrics.Deployment,
+		Priority:          string(PriorityMedium),
+		Description:       description,
+		CurrentConfig:     currentConfig,
+		RecommendedConfig: recommendedConfig,
+		EstimatedSavings:  0.0,
+		Impact:            "Low risk - freeing quota lets this deployment scale when load requires it",
+		Rationale: []string{
+			fmt.Sprintf("CPU utilization %.1f%% or memory utilization %.1f%% is above 80%%",
+				analysis.CPUUtilization*100, analysis.MemoryUtilization*100),
+			fmt.Sprintf("ResourceQuota %s has %d of %d %s used (%.1f%%), above the %.1f%% pressure threshold",
+				constrained.Quota, constrained.Used, constrained.Hard, constrained.Resource,
+				constrained.Utilization*100, rg.optimizer.cfg().QuotaPressureThreshold*100),
+		},
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,100 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// recencyWeights returns the weight of each point under exponential decay: a point loses half its
+// weight for every halfLife it is older than the newest point. Ages are measured from the newest
+// point rather than now, so a series that stopped reporting is not discounted as a whole.
+func recencyWeights(points []models.DataPoint, halfLife time.Duration) []float64 {
+	newest := points[0].Timestamp
+	for _, point := range points[1:] {
+		if point.Timestamp.After(newest) {
+			newest = point.Timestamp
+		}
+	}
+
+	weights := make([]float64, len(points))
+	for i, point := range points {
+		age := newest.Sub(point.Timestamp)
+		weights[i] = math.Pow(0.5, float64(age)/float64(halfLife))
+	}
+	return weights
+}
+
+// weightedUsage is a usage value with its recency weight
+type weightedUsage struct {
+	value  float64
+	weight float64
+}
+
+// sortedWeightedUsage pairs each point's value with its recency weight, sorted by value
+func sortedWeightedUsage(points []models.DataPoint, halfLife time.Duration) []weightedUsage {
+	weights := recencyWeights(points, halfLife)
+	usage := make([]weightedUsage, len(points))
+	for i, point := range points {
+		usage[i] = weightedUsage{value: point.Value, weight: weights[i]}
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].value < usage[j].value
+	})
+	return usage
+}
+
+// calculateWeightedPercentile returns the smallest value at or below which the given percentage of
+// the total weight lies, from usage sorted by value
+func calculateWeightedPercentile(usage []weightedUsage, percentile float64) float64 {
+	if len(usage) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, u := range usage {
+		total += u.weight
+	}
+
+	threshold := percentile / 100.0 * total
+	cumulative := 0.0
+	for _, u := range usage {
+		cumulative += u.weight
+		if cumulative >= threshold {
+			return u.value
+		}
+	}
+	return usage[len(usage)-1].value
+}
+
+// calculateWeightedAverage returns the weighted mean of usage
+func calculateWeightedAverage(usage []weightedUsage) float64 {
+	sum, total := 0.0, 0.0
+	for _, u := range usage {
+		sum += u.value * u.weight
+		total += u.weight
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// markRecencyWeighted notes in the description and rationale of a usage-based recommendation that it
+// was sized from recency-weighted usage
+func (rg *recommendationGenerator) markRecencyWeighted(rec *models.Recommendation, analysis *analysisResult) {
+	if !analysis.Deployment.RecencyWeighted {
+		return
+	}
+	if rec.Type != string(RecommendationTypeResource) && rec.Type != string(RecommendationTypeScaling) {
+		return
+	}
+
+	rec.Description += " (based on recency-weighted usage)"
+	rec.Rationale = append(rec.Rationale, fmt.Sprintf(
+		"Percentiles and averages weight recent usage more heavily, halving a sample's weight every %s of age",
+		rg.optimizer.cfg().RecencyHalfLife))
+}
@@ -2,6 +2,7 @@ package optimizer
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,9 +25,40 @@ func newRecommendationGenerator(opt *OptimizerEngine) *recommendationGenerator {
 func (rg *recommendationGenerator) generateRecommendations(analysis *analysisResult) ([]models.Recommendation, error) {
 	var recommendations []models.Recommendation
 
-	// Generate resource recommendations (CPU/Memory right-sizing)
-	resourceRecs := rg.generateResourceRecommendations(analysis)
-	recommendations = append(recommendations, resourceRecs...)
+	// Protected workloads are analyzed for health only
+	if rg.optimizer.isProtectedWorkload(analysis.Deployment.Deployment) {
+		return recommendations, nil
+	}
+
+	// Deployments younger than MinWorkloadAge have no steady-state usage to size from
+	if analysis.TooNew {
+		return recommendations, nil
+	}
+
+	// Without any usage data there is nothing to size from; hold at the current values
+	if analysis.Provisional && len(analysis.Deployment.CPUTimeSeries) == 0 {
+		return []models.Recommendation{rg.generateProvisionalHoldRecommendation(analysis)}, nil
+	}
+
+	// Generate resource recommendations (CPU/Memory right-sizing), per container for multi-container pods
+	if len(analysis.Containers) > 0 {
+		for _, container := range analysis.Containers {
+			recommendations = append(recommendations, rg.generateScopedResourceRecommendations(container)...)
+		}
+	} else {
+		resourceRecs := rg.generateResourceRecommendations(analysis)
+		recommendations = append(recommendations, resourceRecs...)
+	}
+
+	// Generate a GPU recommendation; GPUs are sized per pod whatever the container layout
+	if rec := rg.generateGPURecommendation(analysis); rec != nil {
+		recommendations = append(recommendations, *rec)
+	}
+
+	// Flag pods requesting more of a node than the platform policy allows, whatever their usage
+	if rec := rg.generateNodeFractionRecommendation(analysis); rec != nil {
+		recommendations = append(recommendations, *rec)
+	}
 
 	// Generate HPA recommendations if HPA exists
 	if analysis.Deployment.HasHPA {
@@ -34,18 +66,174 @@ func (rg *recommendationGenerator) generateRecommendations(analysis *analysisRes
 		recommendations = append(recommendations, hpaRecs...)
 	}
 
+	// Suggest event-driven scaling for workloads that idle between bursts
+	if rec := rg.generateEventDrivenRecommendation(analysis); rec != nil {
+		recommendations = append(recommendations, *rec)
+	}
+
 	// Generate scaling recommendations
 	scalingRecs := rg.generateScalingRecommendations(analysis)
 	recommendations = append(recommendations, scalingRecs...)
 
+	// Scaling an idle workload to zero supersedes resizing it
+	if rec := rg.generateIdleRecommendation(analysis); rec != nil {
+		recommendations = []models.Recommendation{*rec}
+	}
+
+	// A scale-down and a request reduction sized from the same utilization over-shoot together
+	flagConflicts(recommendations, analysis)
+
+	// A single, erratic pod is weak evidence, so don't let it drive urgent changes
+	lowerPriority := analysis.Deployment.CurrentReplicas == 1 && hasHighVariance(analysis)
+
+	for i := range recommendations {
+		recommendations[i].Confidence = analysis.Confidence
+		if recommendations[i].Risk == "" {
+			rg.optimizer.scorer.scoreImpact(&recommendations[i], analysis)
+		}
+		rg.markRecencyWeighted(&recommendations[i], analysis)
+		if lowerPriority {
+			recommendations[i].Priority = lowerPriorityLevel(recommendations[i].Priority)
+			recommendations[i].Rationale = append(recommendations[i].Rationale,
+				"Priority lowered: analysis is based on a single pod with highly variable usage")
+		}
+		if analysis.Provisional {
+			rg.markProvisional(&recommendations[i], analysis)
+		} else if rg.isLowConfidence(analysis) {
+			rg.markLowConfidence(&recommendations[i], analysis)
+		}
+	}
+
 	return recommendations, nil
 }
 
+// isLowConfidence reports whether an analysis falls below the low-confidence threshold
+func (rg *recommendationGenerator) isLowConfidence(analysis *analysisResult) bool {
+	return analysis.Confidence < rg.optimizer.cfg().LowConfidenceThreshold
+}
+
+// markLowConfidence notes the limited evidence behind a recommendation in its impact and caps it at
+// low priority, except for resource increases fixing an under-provisioned, throttled or OOM-killed deployment
+func (rg *recommendationGenerator) markLowConfidence(rec *models.Recommendation, analysis *analysisResult) {
+	rec.Impact = fmt.Sprintf("%s (low confidence: %.0f%% confidence, samples span %.0f%% of the %s analysis window)",
+		rec.Impact, analysis.Confidence*100, analysis.Coverage*100, analysis.Deployment.Window)
+
+	critical := rec.Type == string(RecommendationTypeResource) &&
+		(analysis.CPUUnderProvisioned || analysis.MemoryUnderProvisioned || analysis.CPUThrottled || analysis.MemoryOOMKilled)
+	if !critical {
+		rec.Priority = string(PriorityLow)
+	}
+}
+
+// markProvisional labels a recommendation built from insufficient history and drops it to low priority
+func (rg *recommendationGenerator) markProvisional(rec *models.Recommendation, analysis *analysisResult) {
+	rec.Priority = string(PriorityLow)
+	rec.Description = "Provisional: " + rec.Description
+	rec.Rationale = append(rec.Rationale, fmt.Sprintf(
+		"Based on only %d of the %d data points normally required; revisit once more history is collected",
+		len(analysis.Deployment.CPUTimeSeries), rg.optimizer.cfg().MinimumDataPoints))
+}
+
+// generateProvisionalHoldRecommendation keeps current resources for a deployment with no usage history
+func (rg *recommendationGenerator) generateProvisionalHoldRecommendation(analysis *analysisResult) models.Recommendation {
+	metrics := &analysis.Deployment
+
+	config := convertResourceConfigToMap(resourceConfig{
+		CPURequest:    formatResourceQuantity(metrics.CPURequested, "cpu"),
+		CPULimit:      formatResourceQuantity(metrics.CPULimit, "cpu"),
+		MemoryRequest: formatResourceQuantity(metrics.MemoryRequested, "memory"),
+		MemoryLimit:   formatResourceQuantity(metrics.MemoryLimit, "memory"),
+	})
+
+	return models.Recommendation{
+		ID:                uuid.New().String(),
+		Type:              string(RecommendationTypeResource),
+		Namespace:         metrics.Namespace,
+		Deployment:        metrics.Deployment,
+		Priority:          string(PriorityLow),
+		Description:       "Provisional: keep current CPU and memory settings until usage history is available",
+		CurrentConfig:     config,
+		RecommendedConfig: config,
+		EstimatedSavings:  0,
+		Impact:            "No change - insufficient usage history to size this deployment",
+		Confidence:        0,
+		Rationale: []string{fmt.Sprintf("No usage data collected yet; %d data points are normally required",
+			rg.optimizer.cfg().MinimumDataPoints)},
+		CreatedAt: time.Now(),
+	}
+}
+
+// hasHighVariance reports whether CPU or memory usage varies enough to penalize stability
+func hasHighVariance(analysis *analysisResult) bool {
+	return analysis.CPUVariance > 1000 || analysis.MemoryVariance > 1000000000 // 1GB variance
+}
+
+// lowerPriorityLevel returns the next lower priority level
+func lowerPriorityLevel(priority string) string {
+	switch priority {
+	case "high":
+		return "medium"
+	case "medium":
+		return "low"
+	default:
+		return priority
+	}
+}
+
 // generateResourceRecommendations generates CPU and memory right-sizing recommendations
 func (rg *recommendationGenerator) generateResourceRecommendations(analysis *analysisResult) []models.Recommendation {
 	var recommendations []models.Recommendation
 	metrics := &analysis.Deployment
 
+	// Limits larger than any node are configuration errors regardless of churn
+	if rec := rg.generateOversizedLimitRecommendation(analysis); rec != nil {
+		recommendations = append(recommendations, *rec)
+	}
+
+	// So are missing requests, which right-sizing can't size from. Latency-critical workloads get
+	// them from the Guaranteed QoS recommendation instead, which is then made whatever the churn.
+	guaranteedQoS := rg.recommendsGuaranteedQoS(analysis)
+	if !guaranteedQoS {
+		if rec := rg.generateMissingRequestsRecommendation(analysis); rec != nil {
+			recommendations = append(recommendations, *rec)
+		}
+	}
+
+	// Throttling at the CPU limit hurts latency now, so it is reported whatever the churn. An
+	// under-provisioned CPU recommendation raises the limit already.
+	if !guaranteedQoS && !analysis.CPUUnderProvisioned {
+		if rec := rg.generateCPUThrottlingRecommendation(analysis); rec != nil {
+			recommendations = append(recommendations, *rec)
+		}
+	}
+
+	// So are OOM kills, which replace the usual memory right-sizing
+	oomKilled := false
+	if !guaranteedQoS {
+		if rec := rg.generateOOMKillRecommendation(analysis); rec != nil {
+			recommendations = append(recommendations, *rec)
+			oomKilled = true
+		}
+	}
+
+	// Defer right-sizing for high-churn deployments: usage observed between frequent
+	// rollouts is a poor basis for reductions. Under-provisioning is still reported.
+	memoryResize := !oomKilled && (analysis.MemoryOverProvisioned || analysis.MemoryUnderProvisioned)
+	if analysis.HighChurn && !analysis.CPUUnderProvisioned && !analysis.MemoryUnderProvisioned &&
+		!(guaranteedQoS && hasMissingRequests(metrics)) {
+		return recommendations
+	}
+	oversizedLimits := len(recommendations)
+
+	// Latency-critical workloads are sized straight into Guaranteed QoS rather than given separate
+	// CPU and memory recommendations with burstable limits
+	if guaranteedQoS {
+		if rec := rg.generateGuaranteedQoSRecommendation(analysis); rec != nil {
+			recommendations = append(recommendations, *rec)
+		}
+		return recommendations
+	}
+
 	// Check if we need CPU adjustment
 	if analysis.CPUOverProvisioned || analysis.CPUUnderProvisioned {
 		rec := rg.generateCPURecommendation(analysis)
@@ -55,7 +243,7 @@ func (rg *recommendationGenerator) generateResourceRecommendations(analysis *ana
 	}
 
 	// Check if we need memory adjustment
-	if analysis.MemoryOverProvisioned || analysis.MemoryUnderProvisioned {
+	if memoryResize {
 		rec := rg.generateMemoryRecommendation(analysis)
 		if rec != nil {
 			recommendations = append(recommendations, *rec)
@@ -63,8 +251,7 @@ func (rg *recommendationGenerator) generateResourceRecommendations(analysis *ana
 	}
 
 	// Generate combined resource recommendation if both need adjustment
-	if (analysis.CPUOverProvisioned || analysis.CPUUnderProvisioned) &&
-		(analysis.MemoryOverProvisioned || analysis.MemoryUnderProvisioned) {
+	if (analysis.CPUOverProvisioned || analysis.CPUUnderProvisioned) && memoryResize {
 		rec := rg.generateCombinedResourceRecommendation(analysis)
 		if rec != nil {
 			recommendations = append(recommendations, *rec)
@@ -72,7 +259,7 @@ func (rg *recommendationGenerator) generateResourceRecommendations(analysis *ana
 	}
 
 	// If no specific issues but efficiency is low, suggest optimization
-	if len(recommendations) == 0 && analysis.OverallScore < 70 {
+	if len(recommendations) == oversizedLimits && analysis.OverallScore < 70 {
 		if metrics.CPURequested > 0 && metrics.MemoryRequested > 0 {
 			rec := rg.generateGeneralOptimizationRecommendation(analysis)
 			if rec != nil {
@@ -81,6 +268,14 @@ func (rg *recommendationGenerator) generateResourceRecommendations(analysis *ana
 		}
 	}
 
+	if rg.preservesGuaranteedQoS(metrics) {
+		for i := range recommendations {
+			recommendations[i].Description += " (limits kept equal to requests to preserve Guaranteed QoS)"
+			recommendations[i].Rationale = append(recommendations[i].Rationale,
+				"Requests equal limits (Guaranteed QoS), so limits move together with requests")
+		}
+	}
+
 	return recommendations
 }
 
@@ -92,13 +287,19 @@ func (rg *recommendationGenerator) generateCPURecommendation(analysis *analysisR
 		return nil
 	}
 
+	if rg.usesBurstyCPUSizing(analysis) {
+		return rg.generateBurstyCPURecommendation(analysis)
+	}
+
 	// Calculate recommended CPU
 	var recommendedCPU int64
+	var cpuBuffer float64
 	var description string
 
 	if analysis.CPUOverProvisioned {
 		// Reduce CPU: P95 usage * 1.2 (20% buffer)
-		recommendedCPU = int64(float64(metrics.CPUP95) * rg.optimizer.config.OverProvisionedBuffer)
+		cpuBuffer = rg.optimizer.cfg().OverProvisionedBuffer
+		recommendedCPU = rg.roundCPU(int64(float64(metrics.CPUP95) * cpuBuffer))
 		description = fmt.Sprintf("Reduce CPU request from %s to %s (P95 usage: %s, utilization: %.1f%%)",
 			formatResourceQuantity(metrics.CPURequested, "cpu"),
 			formatResourceQuantity(recommendedCPU, "cpu"),
@@ -106,7 +307,8 @@ func (rg *recommendationGenerator) generateCPURecommendation(analysis *analysisR
 			analysis.CPUUtilization*100)
 	} else if analysis.CPUUnderProvisioned {
 		// Increase CPU: P95 usage * 1.5 (50% buffer)
-		recommendedCPU = int64(float64(metrics.CPUP95) * rg.optimizer.config.UnderProvisionedBuffer)
+		cpuBuffer = rg.optimizer.cfg().UnderProvisionedBuffer
+		recommendedCPU = rg.roundCPU(int64(float64(metrics.CPUP95) * cpuBuffer))
 		description = fmt.Sprintf("Increase CPU request from %s to %s (P95 usage: %s, utilization: %.1f%%)",
 			formatResourceQuantity(metrics.CPURequested, "cpu"),
 			formatResourceQuantity(recommendedCPU, "cpu"),
@@ -132,11 +334,76 @@ func (rg *recommendationGenerator) generateCPURecommendation(analysis *analysisR
 
 	recommendedConfig := resourceConfig{
 		CPURequest: formatResourceQuantity(recommendedCPU, "cpu"),
-		CPULimit:   formatResourceQuantity(recommendedCPU*2, "cpu"), // Limit = 2x request
+		CPULimit:   formatLimit(rg.recommendedCPULimit(metrics, recommendedCPU), "cpu"),
+	}
+
+	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeResource, analysis, savings)
+
+	rationale := append(rg.cpuRationale(analysis), fmt.Sprintf("Recommended request = P95 %s x %.2f buffer",
+		formatResourceQuantity(metrics.CPUP95, "cpu"), cpuBuffer))
+
+	return &models.Recommendation{
+		ID:                uuid.New().String(),
+		Type:              string(RecommendationTypeResource),
+		Namespace:         metrics.Namespace,
+		Deployment:        metrics.Deployment,
+		Priority:          string(priority),
+		Description:       description,
+		CurrentConfig:     convertResourceConfigToMap(currentConfig),
+		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
+		EstimatedSavings:  savings,
+		Impact:            impact,
+		Rationale:         rationale,
+		CreatedAt:         time.Now(),
+	}
+}
+
+// generateBurstyCPURecommendation generates a CPU recommendation for bursty usage that keeps the
+// request near typical usage and raises the limit, so bursts run on idle node CPU without reserving it
+func (rg *recommendationGenerator) generateBurstyCPURecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+
+	recommendedCPU, recommendedLimit := rg.burstyCPUSizing(metrics)
+	if recommendedCPU == metrics.CPURequested && recommendedLimit == metrics.CPULimit {
+		return nil
+	}
+
+	description := fmt.Sprintf("Set CPU request to %s and raise limit from %s to %s for bursty usage (P50: %s, P99: %s)",
+		formatResourceQuantity(recommendedCPU, "cpu"),
+		formatResourceQuantity(metrics.CPULimit, "cpu"),
+		formatResourceQuantity(recommendedLimit, "cpu"),
+		formatResourceQuantity(metrics.CPUP50, "cpu"),
+		formatResourceQuantity(metrics.CPUP99, "cpu"))
+
+	// Calculate savings
+	savings := rg.calculateCPUCost(metrics.CPURequested) - rg.calculateCPUCost(recommendedCPU)
+
+	// Determine priority
+	priority := rg.optimizer.scorer.getPriorityLevel(analysis, savings)
+
+	currentConfig := resourceConfig{
+		CPURequest: formatResourceQuantity(metrics.CPURequested, "cpu"),
+		CPULimit:   formatResourceQuantity(metrics.CPULimit, "cpu"),
+	}
+
+	recommendedConfig := resourceConfig{
+		CPURequest: formatResourceQuantity(recommendedCPU, "cpu"),
+		CPULimit:   formatResourceQuantity(recommendedLimit, "cpu"),
 	}
 
 	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeResource, analysis, savings)
 
+	buffer := rg.optimizer.cfg().OverProvisionedBuffer
+	rationale := append(rg.cpuRationale(analysis),
+		fmt.Sprintf("CPU is bursty: P99 %s is at least %.1fx both P50 %s and mean %s",
+			formatResourceQuantity(metrics.CPUP99, "cpu"),
+			rg.optimizer.cfg().BurstyCPURatioThreshold,
+			formatResourceQuantity(metrics.CPUP50, "cpu"),
+			formatResourceQuantity(metrics.CPUAverage, "cpu")),
+		fmt.Sprintf("Recommended request = P50 %s x %.2f buffer; limit = P99 %s x %.2f buffer",
+			formatResourceQuantity(metrics.CPUP50, "cpu"), buffer,
+			formatResourceQuantity(metrics.CPUP99, "cpu"), buffer))
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeResource),
@@ -148,6 +415,7 @@ func (rg *recommendationGenerator) generateCPURecommendation(analysis *analysisR
 		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
 		EstimatedSavings:  savings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
@@ -162,11 +430,13 @@ func (rg *recommendationGenerator) generateMemoryRecommendation(analysis *analys
 
 	// Calculate recommended Memory
 	var recommendedMemory int64
+	var memoryBuffer float64
 	var description string
 
 	if analysis.MemoryOverProvisioned {
 		// Reduce Memory: P95 usage * 1.2 (20% buffer)
-		recommendedMemory = int64(float64(metrics.MemoryP95) * rg.optimizer.config.OverProvisionedBuffer)
+		memoryBuffer = rg.optimizer.cfg().OverProvisionedBuffer
+		recommendedMemory = rg.roundMemory(int64(float64(metrics.MemoryP95) * memoryBuffer))
 		description = fmt.Sprintf("Reduce memory request from %s to %s (P95 usage: %s, utilization: %.1f%%)",
 			formatResourceQuantity(metrics.MemoryRequested, "memory"),
 			formatResourceQuantity(recommendedMemory, "memory"),
@@ -174,7 +444,8 @@ func (rg *recommendationGenerator) generateMemoryRecommendation(analysis *analys
 			analysis.MemoryUtilization*100)
 	} else if analysis.MemoryUnderProvisioned {
 		// Increase Memory: P95 usage * 1.5 (50% buffer)
-		recommendedMemory = int64(float64(metrics.MemoryP95) * rg.optimizer.config.UnderProvisionedBuffer)
+		memoryBuffer = rg.optimizer.cfg().UnderProvisionedBuffer
+		recommendedMemory = rg.roundMemory(int64(float64(metrics.MemoryP95) * memoryBuffer))
 		description = fmt.Sprintf("Increase memory request from %s to %s (P95 usage: %s, utilization: %.1f%%)",
 			formatResourceQuantity(metrics.MemoryRequested, "memory"),
 			formatResourceQuantity(recommendedMemory, "memory"),
@@ -200,11 +471,14 @@ func (rg *recommendationGenerator) generateMemoryRecommendation(analysis *analys
 
 	recommendedConfig := resourceConfig{
 		MemoryRequest: formatResourceQuantity(recommendedMemory, "memory"),
-		MemoryLimit:   formatResourceQuantity(recommendedMemory*2, "memory"), // Limit = 2x request
+		MemoryLimit:   formatLimit(rg.recommendedMemoryLimit(metrics, recommendedMemory), "memory"),
 	}
 
 	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeResource, analysis, savings)
 
+	rationale := append(rg.memoryRationale(analysis), fmt.Sprintf("Recommended request = P95 %s x %.2f buffer",
+		formatResourceQuantity(metrics.MemoryP95, "memory"), memoryBuffer))
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeResource),
@@ -216,6 +490,7 @@ func (rg *recommendationGenerator) generateMemoryRecommendation(analysis *analys
 		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
 		EstimatedSavings:  savings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
@@ -231,19 +506,23 @@ func (rg *recommendationGenerator) generateCombinedResourceRecommendation(analys
 	// Calculate recommended CPU
 	var recommendedCPU int64
 	if analysis.CPUOverProvisioned {
-		recommendedCPU = int64(float64(metrics.CPUP95) * rg.optimizer.config.OverProvisionedBuffer)
+		recommendedCPU = rg.roundCPU(int64(float64(metrics.CPUP95) * rg.optimizer.cfg().OverProvisionedBuffer))
 	} else if analysis.CPUUnderProvisioned {
-		recommendedCPU = int64(float64(metrics.CPUP95) * rg.optimizer.config.UnderProvisionedBuffer)
+		recommendedCPU = rg.roundCPU(int64(float64(metrics.CPUP95) * rg.optimizer.cfg().UnderProvisionedBuffer))
 	} else {
 		recommendedCPU = metrics.CPURequested
 	}
+	recommendedCPULimit := rg.recommendedCPULimit(metrics, recommendedCPU)
+	if rg.usesBurstyCPUSizing(analysis) {
+		recommendedCPU, recommendedCPULimit = rg.burstyCPUSizing(metrics)
+	}
 
 	// Calculate recommended Memory
 	var recommendedMemory int64
 	if analysis.MemoryOverProvisioned {
-		recommendedMemory = int64(float64(metrics.MemoryP95) * rg.optimizer.config.OverProvisionedBuffer)
+		recommendedMemory = rg.roundMemory(int64(float64(metrics.MemoryP95) * rg.optimizer.cfg().OverProvisionedBuffer))
 	} else if analysis.MemoryUnderProvisioned {
-		recommendedMemory = int64(float64(metrics.MemoryP95) * rg.optimizer.config.UnderProvisionedBuffer)
+		recommendedMemory = rg.roundMemory(int64(float64(metrics.MemoryP95) * rg.optimizer.cfg().UnderProvisionedBuffer))
 	} else {
 		recommendedMemory = metrics.MemoryRequested
 	}
@@ -275,13 +554,15 @@ func (rg *recommendationGenerator) generateCombinedResourceRecommendation(analys
 
 	recommendedConfig := resourceConfig{
 		CPURequest:    formatResourceQuantity(recommendedCPU, "cpu"),
-		CPULimit:      formatResourceQuantity(recommendedCPU*2, "cpu"),
+		CPULimit:      formatLimit(recommendedCPULimit, "cpu"),
 		MemoryRequest: formatResourceQuantity(recommendedMemory, "memory"),
-		MemoryLimit:   formatResourceQuantity(recommendedMemory*2, "memory"),
+		MemoryLimit:   formatLimit(rg.recommendedMemoryLimit(metrics, recommendedMemory), "memory"),
 	}
 
 	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeResource, analysis, totalSavings)
 
+	rationale := append(rg.cpuRationale(analysis), rg.memoryRationale(analysis)...)
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeResource),
@@ -293,6 +574,7 @@ func (rg *recommendationGenerator) generateCombinedResourceRecommendation(analys
 		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
 		EstimatedSavings:  totalSavings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
@@ -322,9 +604,9 @@ func (rg *recommendationGenerator) generateHPARecommendations(analysis *analysis
 		}
 	}
 
-	// Check if target CPU should be adjusted
+	// Check if the target of the metric the HPA scales on should be adjusted
 	if analysis.HPANeedsOptimization {
-		rec := rg.generateTargetCPURecommendation(analysis)
+		rec := rg.generateHPATargetRecommendation(analysis)
 		if rec != nil {
 			recommendations = append(recommendations, *rec)
 		}
@@ -361,13 +643,13 @@ func (rg *recommendationGenerator) generateMinReplicasRecommendation(analysis *a
 	// Calculate potential savings
 	savings := rg.calculateReplicaCostSavings(metrics, 1)
 
-	currentConfig := hpaConfig{
+	currentConfig := HPAConfig{
 		MinReplicas: metrics.MinReplicas,
 		MaxReplicas: metrics.MaxReplicas,
 		TargetCPU:   metrics.HPATargetCPU,
 	}
 
-	recommendedConfig := hpaConfig{
+	recommendedConfig := HPAConfig{
 		MinReplicas: recommendedMin,
 		MaxReplicas: metrics.MaxReplicas,
 		TargetCPU:   metrics.HPATargetCPU,
@@ -376,6 +658,10 @@ func (rg *recommendationGenerator) generateMinReplicasRecommendation(analysis *a
 	priority := rg.optimizer.scorer.getPriorityLevel(analysis, savings)
 	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeHPA, analysis, savings)
 
+	rationale := []string{
+		fmt.Sprintf("Replicas were at or below the HPA minimum of %d more than 80%% of the time", metrics.MinReplicas),
+	}
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeHPA),
@@ -387,6 +673,7 @@ func (rg *recommendationGenerator) generateMinReplicasRecommendation(analysis *a
 		RecommendedConfig: convertHPAConfigToMap(recommendedConfig),
 		EstimatedSavings:  savings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
@@ -404,13 +691,13 @@ func (rg *recommendationGenerator) generateMaxReplicasRecommendation(analysis *a
 	// No savings, actually cost increase, but prevents performance issues
 	savings := 0.0
 
-	currentConfig := hpaConfig{
+	currentConfig := HPAConfig{
 		MinReplicas: metrics.MinReplicas,
 		MaxReplicas: metrics.MaxReplicas,
 		TargetCPU:   metrics.HPATargetCPU,
 	}
 
-	recommendedConfig := hpaConfig{
+	recommendedConfig := HPAConfig{
 		MinReplicas: metrics.MinReplicas,
 		MaxReplicas: recommendedMax,
 		TargetCPU:   metrics.HPATargetCPU,
@@ -419,6 +706,10 @@ func (rg *recommendationGenerator) generateMaxReplicasRecommendation(analysis *a
 	priority := rg.optimizer.scorer.getPriorityLevel(analysis, savings)
 	impact := "Medium risk - increasing capacity to handle peak load without performance degradation"
 
+	rationale := []string{
+		fmt.Sprintf("Replicas were at the HPA maximum of %d more than 10%% of the time", metrics.MaxReplicas),
+	}
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeHPA),
@@ -430,53 +721,51 @@ func (rg *recommendationGenerator) generateMaxReplicasRecommendation(analysis *a
 		RecommendedConfig: convertHPAConfigToMap(recommendedConfig),
 		EstimatedSavings:  savings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
 
-// generateTargetCPURecommendation generates recommendation to adjust target CPU
-func (rg *recommendationGenerator) generateTargetCPURecommendation(analysis *analysisResult) *models.Recommendation {
+// generateHPATargetRecommendation generates recommendation to adjust the target of whichever metric
+// the HPA scales on: CPU, memory or a custom or external metric
+func (rg *recommendationGenerator) generateHPATargetRecommendation(analysis *analysisResult) *models.Recommendation {
 	metrics := &analysis.Deployment
 
-	if metrics.HPATargetCPU == 0 || metrics.HPACurrentCPU == 0 {
+	metric, ok := hpaTargetMetric(metrics.hpaMetricTargets())
+	if !ok {
 		return nil
 	}
 
 	// Adjust target based on current vs target
-	var recommendedTarget int32
-	var description string
+	recommendedConfig := rg.recommendedHPAConfig(metrics, metric)
+	recommendedTarget := recommendedHPATarget(metric)
 
-	if metrics.HPACurrentCPU > metrics.HPATargetCPU {
-		// Current is higher than target, target might be too aggressive
-		recommendedTarget = (metrics.HPATargetCPU + metrics.HPACurrentCPU) / 2
+	var description string
+	if isCPUUtilization(metric) {
 		description = fmt.Sprintf("Adjust HPA target CPU from %d%% to %d%% (current avg: %d%%)",
-			metrics.HPATargetCPU, recommendedTarget, metrics.HPACurrentCPU)
+			metrics.HPATargetCPU, recommendedConfig.TargetCPU, metrics.HPACurrentCPU)
 	} else {
-		// Current is lower than target, target might be too conservative
-		recommendedTarget = metrics.HPACurrentCPU + 10
-		if recommendedTarget > 80 {
-			recommendedTarget = 80
-		}
-		description = fmt.Sprintf("Adjust HPA target CPU from %d%% to %d%% (current avg: %d%%)",
-			metrics.HPATargetCPU, recommendedTarget, metrics.HPACurrentCPU)
+		description = fmt.Sprintf("Adjust HPA %s target from %s to %s (current avg: %s)",
+			metric.Name, formatHPAValue(metric, metric.Target), formatHPAValue(metric, recommendedTarget),
+			formatHPAValue(metric, metric.Current))
 	}
 
-	currentConfig := hpaConfig{
+	currentConfig := HPAConfig{
 		MinReplicas: metrics.MinReplicas,
 		MaxReplicas: metrics.MaxReplicas,
 		TargetCPU:   metrics.HPATargetCPU,
 	}
-
-	recommendedConfig := hpaConfig{
-		MinReplicas: metrics.MinReplicas,
-		MaxReplicas: metrics.MaxReplicas,
-		TargetCPU:   recommendedTarget,
+	if recommendedConfig.Metric != "" {
+		currentConfig.Metric = metric.Name
+		currentConfig.Target = metric.Target
 	}
 
 	savings := 0.0
 	priority := PriorityMedium
 	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeHPA, analysis, savings)
 
+	rationale := rg.hpaTargetRationale(analysis)
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeHPA),
@@ -488,6 +777,7 @@ func (rg *recommendationGenerator) generateTargetCPURecommendation(analysis *ana
 		RecommendedConfig: convertHPAConfigToMap(recommendedConfig),
 		EstimatedSavings:  savings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
@@ -509,32 +799,43 @@ func (rg *recommendationGenerator) generateCombinedHPARecommendation(analysis *a
 		recommendedMax = metrics.MaxReplicas + 2
 	}
 
-	if analysis.HPANeedsOptimization && metrics.HPACurrentCPU > 0 {
-		if metrics.HPACurrentCPU > metrics.HPATargetCPU {
-			recommendedTarget = (metrics.HPATargetCPU + metrics.HPACurrentCPU) / 2
+	// Adjust the target of the metric the HPA scales on
+	targetChange := fmt.Sprintf("target CPU %d%%→%d%%", metrics.HPATargetCPU, recommendedTarget)
+	var recommendedMetric string
+	var recommendedMetricTarget float64
+	if metric, ok := hpaTargetMetric(metrics.hpaMetricTargets()); analysis.HPANeedsOptimization && ok {
+		adjusted := rg.recommendedHPAConfig(metrics, metric)
+		recommendedTarget = adjusted.TargetCPU
+		recommendedMetric, recommendedMetricTarget = adjusted.Metric, adjusted.Target
+		if isCPUUtilization(metric) {
+			targetChange = fmt.Sprintf("target CPU %d%%→%d%%", metrics.HPATargetCPU, recommendedTarget)
 		} else {
-			recommendedTarget = metrics.HPACurrentCPU + 10
-			if recommendedTarget > 80 {
-				recommendedTarget = 80
-			}
+			targetChange = fmt.Sprintf("%s target %s→%s", metric.Name,
+				formatHPAValue(metric, metric.Target), formatHPAValue(metric, recommendedMetricTarget))
 		}
 	}
 
-	description := fmt.Sprintf("Optimize HPA configuration: min replicas %d→%d, max replicas %d→%d, target CPU %d%%→%d%%",
+	description := fmt.Sprintf("Optimize HPA configuration: min replicas %d→%d, max replicas %d→%d, %s",
 		metrics.MinReplicas, recommendedMin,
 		metrics.MaxReplicas, recommendedMax,
-		metrics.HPATargetCPU, recommendedTarget)
+		targetChange)
 
-	currentConfig := hpaConfig{
+	currentConfig := HPAConfig{
 		MinReplicas: metrics.MinReplicas,
 		MaxReplicas: metrics.MaxReplicas,
 		TargetCPU:   metrics.HPATargetCPU,
 	}
+	if metric, ok := hpaTargetMetric(metrics.hpaMetricTargets()); ok && recommendedMetric != "" {
+		currentConfig.Metric = metric.Name
+		currentConfig.Target = metric.Target
+	}
 
-	recommendedConfig := hpaConfig{
+	recommendedConfig := HPAConfig{
 		MinReplicas: recommendedMin,
 		MaxReplicas: recommendedMax,
 		TargetCPU:   recommendedTarget,
+		Metric:      recommendedMetric,
+		Target:      recommendedMetricTarget,
 	}
 
 	// Calculate savings from reducing min replicas
@@ -546,6 +847,17 @@ func (rg *recommendationGenerator) generateCombinedHPARecommendation(analysis *a
 	priority := rg.optimizer.scorer.getPriorityLevel(analysis, savings)
 	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeHPA, analysis, savings)
 
+	var rationale []string
+	if analysis.HPAIdleAtMinimum {
+		rationale = append(rationale, fmt.Sprintf("Replicas were at or below the HPA minimum of %d more than 80%% of the time", metrics.MinReplicas))
+	}
+	if analysis.HPAHitCeiling {
+		rationale = append(rationale, fmt.Sprintf("Replicas were at the HPA maximum of %d more than 10%% of the time", metrics.MaxReplicas))
+	}
+	if analysis.HPANeedsOptimization {
+		rationale = append(rationale, rg.hpaTargetRationale(analysis)...)
+	}
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeHPA),
@@ -557,6 +869,7 @@ func (rg *recommendationGenerator) generateCombinedHPARecommendation(analysis *a
 		RecommendedConfig: convertHPAConfigToMap(recommendedConfig),
 		EstimatedSavings:  savings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
@@ -571,10 +884,21 @@ func (rg *recommendationGenerator) generateScalingRecommendations(analysis *anal
 		return recommendations
 	}
 
+	// A DaemonSet runs one pod per node; its replica count follows the nodes
+	if metrics.Kind == WorkloadKindDaemonSet {
+		return recommendations
+	}
+
 	// Check if we should scale up or down
 	if analysis.CPUUtilization > 0.8 || analysis.MemoryUtilization > 0.8 {
-		// High utilization - recommend scaling up
-		rec := rg.generateScaleUpRecommendation(analysis)
+		// High utilization - recommend scaling up, unless the namespace
+		// quota would reject the new pods
+		var rec *models.Recommendation
+		if analysis.QuotaPressure != nil && analysis.QuotaPressure.NearLimit {
+			rec = rg.generateQuotaRecommendation(analysis)
+		} else {
+			rec = rg.generateScaleUpRecommendation(analysis)
+		}
 		if rec != nil {
 			recommendations = append(recommendations, *rec)
 		}
@@ -610,6 +934,12 @@ func (rg *recommendationGenerator) generateScaleUpRecommendation(analysis *analy
 	priority := PriorityHigh
 	impact := "Medium risk - adding capacity to handle current load"
 
+	rationale := []string{
+		fmt.Sprintf("CPU utilization %.1f%% or memory utilization %.1f%% is above 80%%",
+			analysis.CPUUtilization*100, analysis.MemoryUtilization*100),
+		"No HPA manages this deployment's replicas",
+	}
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeScaling),
@@ -621,6 +951,7 @@ func (rg *recommendationGenerator) generateScaleUpRecommendation(analysis *analy
 		RecommendedConfig: convertScalingConfigToMap(recommendedConfig),
 		EstimatedSavings:  0.0, // Scaling up costs money
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
@@ -652,6 +983,12 @@ func (rg *recommendationGenerator) generateScaleDownRecommendation(analysis *ana
 	priority := rg.optimizer.scorer.getPriorityLevel(analysis, savings)
 	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeScaling, analysis, savings)
 
+	rationale := []string{
+		fmt.Sprintf("CPU utilization %.1f%% and memory utilization %.1f%% are both below 50%%",
+			analysis.CPUUtilization*100, analysis.MemoryUtilization*100),
+		fmt.Sprintf("%d replicas leave room to remove one", metrics.CurrentReplicas),
+	}
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeScaling),
@@ -663,6 +1000,7 @@ func (rg *recommendationGenerator) generateScaleDownRecommendation(analysis *ana
 		RecommendedConfig: convertScalingConfigToMap(recommendedConfig),
 		EstimatedSavings:  savings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
@@ -675,8 +1013,8 @@ func (rg *recommendationGenerator) generateGeneralOptimizationRecommendation(ana
 		analysis.OverallScore)
 
 	// Calculate optimal resources based on P95
-	recommendedCPU := int64(float64(metrics.CPUP95) * 1.2)
-	recommendedMemory := int64(float64(metrics.MemoryP95) * 1.2)
+	recommendedCPU := rg.roundCPU(int64(float64(metrics.CPUP95) * 1.2))
+	recommendedMemory := rg.roundMemory(int64(float64(metrics.MemoryP95) * 1.2))
 
 	currentConfig := resourceConfig{
 		CPURequest:    formatResourceQuantity(metrics.CPURequested, "cpu"),
@@ -687,9 +1025,9 @@ func (rg *recommendationGenerator) generateGeneralOptimizationRecommendation(ana
 
 	recommendedConfig := resourceConfig{
 		CPURequest:    formatResourceQuantity(recommendedCPU, "cpu"),
-		CPULimit:      formatResourceQuantity(recommendedCPU*2, "cpu"),
+		CPULimit:      formatLimit(rg.recommendedCPULimit(metrics, recommendedCPU), "cpu"),
 		MemoryRequest: formatResourceQuantity(recommendedMemory, "memory"),
-		MemoryLimit:   formatResourceQuantity(recommendedMemory*2, "memory"),
+		MemoryLimit:   formatLimit(rg.recommendedMemoryLimit(metrics, recommendedMemory), "memory"),
 	}
 
 	cpuSavings := rg.calculateCPUCost(metrics.CPURequested) - rg.calculateCPUCost(recommendedCPU)
@@ -699,6 +1037,12 @@ func (rg *recommendationGenerator) generateGeneralOptimizationRecommendation(ana
 	priority := PriorityLow
 	impact := rg.optimizer.scorer.formatImpactMessage(RecommendationTypeResource, analysis, totalSavings)
 
+	rationale := []string{
+		fmt.Sprintf("Overall efficiency score %.1f is below 70 without a single threshold being crossed", analysis.OverallScore),
+		fmt.Sprintf("Recommended requests = P95 usage (CPU %s, memory %s) x 1.20 buffer",
+			formatResourceQuantity(metrics.CPUP95, "cpu"), formatResourceQuantity(metrics.MemoryP95, "memory")),
+	}
+
 	return &models.Recommendation{
 		ID:                uuid.New().String(),
 		Type:              string(RecommendationTypeResource),
@@ -710,31 +1054,217 @@ func (rg *recommendationGenerator) generateGeneralOptimizationRecommendation(ana
 		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
 		EstimatedSavings:  totalSavings,
 		Impact:            impact,
+		Rationale:         rationale,
 		CreatedAt:         time.Now(),
 	}
 }
 
 // Cost calculation helper methods
 
-// calculateCPUCost calculates monthly cost for CPU (in millicores)
+// cpuRationale explains which CPU thresholds an analysis crossed
+func (rg *recommendationGenerator) cpuRationale(analysis *analysisResult) []string {
+	metrics := &analysis.Deployment
+	var rationale []string
+
+	if analysis.CPUOverProvisioned {
+		rationale = append(rationale, fmt.Sprintf("CPU utilization %.1f%% (P95 %s / request %s) is below the over-provisioned threshold of %.1f%%",
+			analysis.CPUUtilization*100,
+			formatResourceQuantity(metrics.CPUP95, "cpu"),
+			formatResourceQuantity(metrics.CPURequested, "cpu"),
+			rg.optimizer.cfg().CPUOverProvisionedThreshold*100))
+	}
+
+	if analysis.CPUUnderProvisioned && metrics.CPULimit > 0 {
+		rationale = append(rationale, fmt.Sprintf("CPU P95 %s is %.1f%% of the %s limit, above the under-provisioned threshold of %.1f%%",
+			formatResourceQuantity(metrics.CPUP95, "cpu"),
+			float64(metrics.CPUP95)/float64(metrics.CPULimit)*100,
+			formatResourceQuantity(metrics.CPULimit, "cpu"),
+			rg.optimizer.cfg().CPUUnderProvisionedThreshold*100))
+	}
+
+	return rationale
+}
+
+// memoryRationale explains which memory thresholds an analysis crossed
+func (rg *recommendationGenerator) memoryRationale(analysis *analysisResult) []string {
+	metrics := &analysis.Deployment
+	var rationale []string
+
+	if analysis.MemoryOverProvisioned {
+		rationale = append(rationale, fmt.Sprintf("Memory utilization %.1f%% (P95 %s / request %s) is below the over-provisioned threshold of %.1f%%",
+			analysis.MemoryUtilization*100,
+			formatResourceQuantity(metrics.MemoryP95, "memory"),
+			formatResourceQuantity(metrics.MemoryRequested, "memory"),
+			rg.optimizer.cfg().MemoryOverProvisionedThreshold*100))
+	}
+
+	if analysis.MemoryUnderProvisioned && metrics.MemoryLimit > 0 {
+		label := "Memory P95"
+		pressure := metrics.memoryPressure(rg.optimizer.cfg().UseWorkingSetMemory)
+		if pressure != metrics.MemoryP95 {
+			label = "Working-set memory P95"
+		}
+		rationale = append(rationale, fmt.Sprintf("%s %s is %.1f%% of the %s limit, above the under-provisioned threshold of %.1f%%",
+			label,
+			formatResourceQuantity(pressure, "memory"),
+			float64(pressure)/float64(metrics.MemoryLimit)*100,
+			formatResourceQuantity(metrics.MemoryLimit, "memory"),
+			rg.optimizer.cfg().MemoryUnderProvisionedThreshold*100))
+	}
+
+	return rationale
+}
+
+// hpaTargetRationale explains why an HPA's CPU target needs adjusting
+func (rg *recommendationGenerator) hpaTargetRationale(analysis *analysisResult) []string {
+	metrics := &analysis.Deployment
+	var rationale []string
+
+	for _, metric := range metrics.hpaMetricTargets() {
+		switch {
+		case isCPUUtilization(metric) && metric.Target > 0 && metric.Current > 0:
+			rationale = append(rationale, fmt.Sprintf("HPA current CPU %d%% differs from the %d%% target by %d points (threshold: 20)",
+				metrics.HPACurrentCPU, metrics.HPATargetCPU, absInt32(metrics.HPACurrentCPU-metrics.HPATargetCPU)))
+		case hpaTargetDeviates(metric) && isUtilization(metric):
+			rationale = append(rationale, fmt.Sprintf("HPA current %s %s differs from the %s target by %.0f points (threshold: %d)",
+				metric.Name, formatHPAValue(metric, metric.Current), formatHPAValue(metric, metric.Target),
+				math.Abs(metric.Current-metric.Target), hpaTargetDeviationPoints))
+		case hpaTargetDeviates(metric):
+			rationale = append(rationale, fmt.Sprintf("HPA current %s %s differs from the %s target by %.0f%% (threshold: %.0f%%)",
+				metric.Name, formatHPAValue(metric, metric.Current), formatHPAValue(metric, metric.Target),
+				math.Abs(metric.Current-metric.Target)/metric.Target*100, hpaTargetDeviationRatio*100))
+		}
+	}
+
+	if analysis.HPAScalingFrequency > 24 {
+		rationale = append(rationale, fmt.Sprintf("HPA scaled %.1f times per day (threshold: 24)", analysis.HPAScalingFrequency))
+	}
+
+	return rationale
+}
+
+// recommendedHPAConfig returns the HPA configuration with the target of metric moved towards its
+// observed value. CPU utilization is adjusted through TargetCPU; other metrics through Metric and Target.
+func (rg *recommendationGenerator) recommendedHPAConfig(metrics *deploymentMetrics, metric models.HPAMetricTarget) HPAConfig {
+	config := HPAConfig{
+		MinReplicas: metrics.MinReplicas,
+		MaxReplicas: metrics.MaxReplicas,
+		TargetCPU:   metrics.HPATargetCPU,
+	}
+	if isCPUUtilization(metric) {
+		config.TargetCPU = int32(recommendedHPATarget(metric))
+	} else {
+		config.Metric = metric.Name
+		config.Target = recommendedHPATarget(metric)
+	}
+	return config
+}
+
+// absInt32 returns the absolute value of an int32
+func absInt32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// roundCPU rounds a recommended CPU value (millicores) up to the configured increment
+func (rg *recommendationGenerator) roundCPU(millicores int64) int64 {
+	return roundUpToIncrement(millicores, rg.optimizer.cfg().CPURoundingMillis)
+}
+
+// roundMemory rounds a recommended memory value (bytes) up to the configured increment
+func (rg *recommendationGenerator) roundMemory(bytes int64) int64 {
+	return roundUpToIncrement(bytes, rg.optimizer.cfg().MemoryRoundingBytes)
+}
+
+// recommendedCPULimit returns the CPU limit to pair with a recommended request: CPULimitRatio times
+// the request, or 0 for no limit under CPULimitNone. Guaranteed QoS deployments keep request == limit
+// so that right-sizing does not silently change their QoS class.
+func (rg *recommendationGenerator) recommendedCPULimit(metrics *deploymentMetrics, request int64) int64 {
+	return rg.recommendedLimit(metrics, request, rg.optimizer.cfg().CPULimitRatio)
+}
+
+// recommendedMemoryLimit returns the memory limit to pair with a recommended request:
+// MemoryLimitRatio times the request, or the request itself under MemoryLimitEqual
+func (rg *recommendationGenerator) recommendedMemoryLimit(metrics *deploymentMetrics, request int64) int64 {
+	return rg.recommendedLimit(metrics, request, rg.optimizer.cfg().MemoryLimitRatio)
+}
+
+// recommendedLimit applies a limit ratio to a request; a ratio of 0 or less means no limit
+func (rg *recommendationGenerator) recommendedLimit(metrics *deploymentMetrics, request int64, ratio float64) int64 {
+	if rg.preservesGuaranteedQoS(metrics) {
+		return request
+	}
+	if ratio <= 0 {
+		return 0
+	}
+	return int64(float64(request) * ratio)
+}
+
+// formatLimit formats a recommended limit, or returns an empty string, which leaves the limit out of
+// the recommended config, when no limit is recommended
+func formatLimit(value int64, resourceType string) string {
+	if value <= 0 {
+		return ""
+	}
+	return formatResourceQuantity(value, resourceType)
+}
+
+// usesBurstyCPUSizing reports whether CPU should be sized with limit headroom rather than request
+// inflation. Guaranteed QoS deployments that must keep request == limit are sized normally, as are
+// deployments under CPULimitNone, whose bursts are not capped by a limit.
+func (rg *recommendationGenerator) usesBurstyCPUSizing(analysis *analysisResult) bool {
+	return analysis.CPUBursty && !rg.preservesGuaranteedQoS(&analysis.Deployment) &&
+		rg.optimizer.cfg().CPULimitRatio > 0
+}
+
+// burstyCPUSizing returns the request covering typical (P50) usage and the limit covering P99
+// bursts, never lowering an existing limit
+func (rg *recommendationGenerator) burstyCPUSizing(metrics *deploymentMetrics) (request, limit int64) {
+	buffer := rg.optimizer.cfg().OverProvisionedBuffer
+	request = rg.roundCPU(int64(float64(metrics.CPUP50) * buffer))
+	limit = rg.roundCPU(int64(float64(metrics.CPUP99) * buffer))
+	if metrics.CPULimit > limit {
+		limit = metrics.CPULimit
+	}
+	return request, limit
+}
+
+// preservesGuaranteedQoS reports whether recommendations for the deployment must keep request == limit.
+// Latency-critical workloads keep Guaranteed QoS regardless of the strategy.
+func (rg *recommendationGenerator) preservesGuaranteedQoS(metrics *deploymentMetrics) bool {
+	return isGuaranteedQoS(metrics) && (rg.optimizer.cfg().GuaranteedQoSStrategy != QoSStrategyIgnore || metrics.LatencyCritical)
+}
+
+// isGuaranteedQoS reports whether the deployment's pods run in the Guaranteed QoS class,
+// i.e. both CPU and memory requests are set and equal to their limits
+func isGuaranteedQoS(metrics *deploymentMetrics) bool {
+	return metrics.CPURequested > 0 && metrics.CPURequested == metrics.CPULimit &&
+		metrics.MemoryRequested > 0 && metrics.MemoryRequested == metrics.MemoryLimit
+}
+
+// calculateCPUCost calculates monthly cost for CPU (in millicores) at the default rate
 func (rg *recommendationGenerator) calculateCPUCost(millicores int64) float64 {
 	vcpus := convertMillicoresToVCPU(millicores)
-	hourlyRate := vcpus * rg.optimizer.config.CPUCostPerVCPUHour
+	hourlyRate := vcpus * rg.optimizer.cfg().Pricing.CPUCostPerVCPUHour("")
 	return hourlyRate * 24 * 30 // Monthly cost
 }
 
-// calculateMemoryCost calculates monthly cost for memory (in bytes)
+// calculateMemoryCost calculates monthly cost for memory (in bytes) at the default rate
 func (rg *recommendationGenerator) calculateMemoryCost(bytes int64) float64 {
 	gb := convertBytesToGB(bytes)
-	hourlyRate := gb * rg.optimizer.config.MemoryCostPerGBHour
+	hourlyRate := gb * rg.optimizer.cfg().Pricing.MemoryCostPerGBHour("")
 	return hourlyRate * 24 * 30 // Monthly cost
 }
 
 // calculateReplicaCostSavings calculates savings from reducing replicas
 func (rg *recommendationGenerator) calculateReplicaCostSavings(metrics *deploymentMetrics, replicaReduction int) float64 {
-	cpuCostPerReplica := rg.calculateCPUCost(metrics.CPURequested)
-	memoryCostPerReplica := rg.calculateMemoryCost(metrics.MemoryRequested)
-	costPerReplica := cpuCostPerReplica + memoryCostPerReplica
+	return rg.calculatePodCost(metrics) * float64(replicaReduction)
+}
 
-	return costPerReplica * float64(replicaReduction)
+// calculatePodCost calculates the monthly cost of one pod, including any RuntimeClass overhead
+func (rg *recommendationGenerator) calculatePodCost(metrics *deploymentMetrics) float64 {
+	return rg.calculateCPUCost(metrics.effectiveCPURequest()) + rg.calculateMemoryCost(metrics.effectiveMemoryRequest()) +
+		rg.calculateGPUCost(metrics.GPURequested)
 }
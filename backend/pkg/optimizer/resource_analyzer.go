@@ -3,18 +3,27 @@ package optimizer
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"math"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
 	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/analyzer"
+	"github.com/k8s-service-optimizer/backend/pkg/collector"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// sustainedSpikeSamples is the number of consecutive samples at an elevated level that
+// smoothing treats as real load rather than jitter
+const sustainedSpikeSamples = 3
+
 // resourceAnalyzer handles resource usage analysis
 type resourceAnalyzer struct {
 	optimizer *OptimizerEngine
@@ -27,24 +36,43 @@ func newResourceAnalyzer(opt *OptimizerEngine) *resourceAnalyzer {
 	}
 }
 
-// analyzeDeployment performs comprehensive analysis of a deployment
+// analyzeDeployment performs comprehensive analysis of a deployment over the configured analysis duration
 func (ra *resourceAnalyzer) analyzeDeployment(namespace, name string) (*analysisResult, error) {
-	// Collect deployment metrics
-	metrics, err := ra.collectDeploymentMetrics(namespace, name)
+	return ra.analyzeDeploymentWindow(namespace, name, ra.optimizer.cfg().AnalysisDuration)
+}
+
+// analyzeDeploymentWindow performs comprehensive analysis of a deployment over the given window
+func (ra *resourceAnalyzer) analyzeDeploymentWindow(namespace, name string, window time.Duration) (*analysisResult, error) {
+	return ra.analyzeWorkloadWindow(namespace, WorkloadKindDeployment, name, window)
+}
+
+// analyzeWorkloadWindow performs comprehensive analysis of a workload of any supported kind over the given window
+func (ra *resourceAnalyzer) analyzeWorkloadWindow(namespace, kind, name string, window time.Duration) (*analysisResult, error) {
+	// Collect workload metrics
+	metrics, err := ra.collectWorkloadMetricsWindow(namespace, kind, name, window)
 	if err != nil {
-		return nil, fmt.Errorf("failed to collect deployment metrics: %w", err)
+		return nil, fmt.Errorf("failed to collect %s metrics: %w", strings.ToLower(kind), err)
 	}
 
-	// Validate we have enough data
-	if len(metrics.CPUTimeSeries) < ra.optimizer.config.MinimumDataPoints {
-		return nil, fmt.Errorf("insufficient data points for analysis: got %d, need at least %d",
-			len(metrics.CPUTimeSeries), ra.optimizer.config.MinimumDataPoints)
+	// Usage of a freshly created deployment reflects startup, not steady state
+	if ra.isTooNew(metrics) {
+		return &analysisResult{
+			Deployment: *metrics,
+			TooNew:     true,
+			Timestamp:  time.Now(),
+		}, nil
+	}
+
+	provisional, err := ra.checkDataSufficiency(len(metrics.CPUTimeSeries))
+	if err != nil {
+		return nil, err
 	}
 
 	// Perform analysis
 	result := &analysisResult{
-		Deployment: *metrics,
-		Timestamp:  time.Now(),
+		Deployment:  *metrics,
+		Provisional: provisional,
+		Timestamp:   time.Now(),
 	}
 
 	// Analyze CPU
@@ -53,9 +81,41 @@ func (ra *resourceAnalyzer) analyzeDeployment(namespace, name string) (*analysis
 	// Analyze Memory
 	ra.analyzeMemory(result)
 
+	// Analyze GPUs if requested
+	ra.analyzeGPU(result)
+
+	// Detect workloads doing nothing at all
+	ra.analyzeIdle(result)
+
 	// Analyze HPA if it exists
 	if metrics.HasHPA {
 		ra.analyzeHPA(result)
+		ra.analyzeEventDriven(result)
+	}
+
+	// Analyze rollout churn and how many pods back the analysis
+	ra.analyzeChurn(result)
+	ra.analyzeReplicaConfidence(result)
+
+	// Scale confidence by how much of the required history is available
+	if provisional && ra.optimizer.cfg().MinimumDataPoints > 0 {
+		result.Confidence *= float64(len(metrics.CPUTimeSeries)) / float64(ra.optimizer.cfg().MinimumDataPoints)
+	}
+	ra.analyzeCoverage(result)
+
+	// Size each container of a multi-container pod on its own
+	ra.analyzeContainers(result)
+
+	// Flag limits no node can satisfy
+	ra.analyzeNodeFit(result)
+
+	// Flag requests above the per-pod node fraction policy
+	ra.analyzeNodeFraction(result)
+
+	// Check namespace quota headroom
+	pressure, err := ra.optimizer.AnalyzeQuotaPressure(namespace)
+	if err == nil {
+		result.QuotaPressure = pressure
 	}
 
 	// Calculate overall scores
@@ -64,132 +124,228 @@ func (ra *resourceAnalyzer) analyzeDeployment(namespace, name string) (*analysis
 	return result, nil
 }
 
-// collectDeploymentMetrics collects all relevant metrics for a deployment
+// isTooNew reports whether a deployment was created within MinWorkloadAge
+func (ra *resourceAnalyzer) isTooNew(metrics *deploymentMetrics) bool {
+	minAge := ra.optimizer.cfg().MinWorkloadAge
+	if minAge <= 0 || metrics.CreatedAt.IsZero() {
+		return false
+	}
+	return time.Since(metrics.CreatedAt) < minAge
+}
+
+// checkDataSufficiency validates there is enough data to analyze. With low-confidence recommendations
+// allowed, analysis continues with whatever data exists and is marked provisional.
+func (ra *resourceAnalyzer) checkDataSufficiency(points int) (provisional bool, err error) {
+	if points >= ra.optimizer.cfg().MinimumDataPoints {
+		return false, nil
+	}
+	if !ra.optimizer.cfg().AllowLowConfidenceRecommendations {
+		return false, fmt.Errorf("%w: got %d, need at least %d", ErrInsufficientData,
+			points, ra.optimizer.cfg().MinimumDataPoints)
+	}
+	return true, nil
+}
+
+// collectDeploymentMetrics collects all relevant metrics for a deployment over the configured analysis duration
 func (ra *resourceAnalyzer) collectDeploymentMetrics(namespace, name string) (*deploymentMetrics, error) {
+	return ra.collectDeploymentMetricsWindow(namespace, name, ra.optimizer.cfg().AnalysisDuration)
+}
+
+// collectDeploymentMetricsWindow collects all relevant metrics for a deployment over the given window
+func (ra *resourceAnalyzer) collectDeploymentMetricsWindow(namespace, name string, window time.Duration) (*deploymentMetrics, error) {
+	return ra.collectWorkloadMetricsWindow(namespace, WorkloadKindDeployment, name, window)
+}
+
+// collectWorkloadMetricsWindow collects all relevant metrics for a workload over the given window
+func (ra *resourceAnalyzer) collectWorkloadMetricsWindow(namespace, kind, name string, window time.Duration) (*deploymentMetrics, error) {
 	ctx := context.Background()
 
-	// Get deployment info
-	deployment, err := ra.optimizer.k8sClient.Clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	// Get workload info
+	workload, err := ra.getWorkload(namespace, kind, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get deployment: %w", err)
+		return nil, err
 	}
 
 	metrics := &deploymentMetrics{
 		Namespace:       namespace,
 		Deployment:      name,
-		CurrentReplicas: *deployment.Spec.Replicas,
+		Kind:            kind,
+		CreatedAt:       workload.CreatedAt,
+		LatencyCritical: ra.optimizer.isLatencyCritical(workload),
+		CurrentReplicas: workload.Replicas,
+		Window:          window,
 		Timestamp:       time.Now(),
 	}
 
-	// Extract resource requests and limits from deployment spec
-	if len(deployment.Spec.Template.Spec.Containers) > 0 {
-		container := deployment.Spec.Template.Spec.Containers[0]
+	// Extract resource requests and limits from deployment spec. Excluded sidecars
+	// are left out of right-sizing but their requests still count toward cost.
+	appContainers, sidecars := ra.partitionContainers(workload.Template.Spec.Containers)
+	for _, sidecar := range sidecars {
+		metrics.SidecarCPURequested += sidecar.Resources.Requests.Cpu().MilliValue()
+		metrics.SidecarMemoryRequested += sidecar.Resources.Requests.Memory().Value()
+	}
 
-		// CPU
-		if cpuReq, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
-			metrics.CPURequested = cpuReq.MilliValue()
-		}
-		if cpuLimit, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
-			metrics.CPULimit = cpuLimit.MilliValue()
+	// Aggregate requests and limits across the analyzed containers. A pod is only CPU or memory
+	// bounded when every container sets that limit, so the summed limit is otherwise left at 0.
+	cpuLimited, memoryLimited := len(appContainers) > 0, len(appContainers) > 0
+	for _, container := range appContainers {
+		cpuLimit, hasCPULimit := container.Resources.Limits[corev1.ResourceCPU]
+		memLimit, hasMemLimit := container.Resources.Limits[corev1.ResourceMemory]
+		cm := containerMetrics{
+			Name:            container.Name,
+			CPURequested:    container.Resources.Requests.Cpu().MilliValue(),
+			CPULimit:        cpuLimit.MilliValue(),
+			MemoryRequested: container.Resources.Requests.Memory().Value(),
+			MemoryLimit:     memLimit.Value(),
 		}
 
-		// Memory
-		if memReq, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
-			metrics.MemoryRequested = memReq.Value()
+		metrics.CPURequested += cm.CPURequested
+		metrics.CPULimit += cm.CPULimit
+		metrics.MemoryRequested += cm.MemoryRequested
+		metrics.MemoryLimit += cm.MemoryLimit
+		cpuLimited = cpuLimited && hasCPULimit
+		memoryLimited = memoryLimited && hasMemLimit
+
+		if len(appContainers) > 1 {
+			if metrics.Containers == nil {
+				metrics.Containers = make(map[string]*containerMetrics)
+			}
+			metrics.Containers[container.Name] = &cm
 		}
-		if memLimit, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
-			metrics.MemoryLimit = memLimit.Value()
+	}
+	if !cpuLimited {
+		metrics.CPULimit = 0
+	}
+	if !memoryLimited {
+		metrics.MemoryLimit = 0
+	}
+	collectGPURequests(metrics, appContainers)
+	metrics.QoSClass = string(podQoSClass(workload.Template.Spec))
+
+	// Fold in RuntimeClass pod overhead
+	if ra.optimizer.cfg().IncludePodOverhead {
+		if overhead := ra.getPodOverhead(workload.Template.Spec); overhead != nil {
+			metrics.CPUOverhead = overhead.Cpu().MilliValue()
+			metrics.MemoryOverhead = overhead.Memory().Value()
 		}
 	}
 
-	// Get pods belonging to this deployment
-	pods, err := ra.getDeploymentPods(deployment)
+	// Get pods belonging to this workload
+	pods, err := ra.getWorkloadPods(workload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get deployment pods: %w", err)
+		return nil, fmt.Errorf("failed to get %s pods: %w", strings.ToLower(kind), err)
 	}
 
 	// Collect metrics for each pod
-	duration := ra.optimizer.config.AnalysisDuration
+	duration := window
 	var allCPUPoints []models.DataPoint
 	var allMemoryPoints []models.DataPoint
+	var allWorkingSetPoints []models.DataPoint
+	var allThrottlePoints []models.DataPoint
+	var allGPUPoints []models.DataPoint
+	gpuPodsMissing := 0
+	var podCPUSeries [][]models.DataPoint
 	var restartCount int32
+	restartBreakdown := make(map[string]int)
 
 	for _, pod := range pods {
-		// Get CPU time series
-		cpuResource := fmt.Sprintf("pod/%s", pod.Name)
-		cpuData, err := ra.optimizer.collector.GetTimeSeriesData(cpuResource, "cpu", duration)
-		if err == nil {
-			allCPUPoints = append(allCPUPoints, cpuData.Points...)
+		// Get CPU and Memory time series, leaving out excluded sidecars when present
+		cpuPoints, memPoints := ra.getPodUsage(pod.Name, appContainers, len(sidecars) > 0, duration)
+		cpuPoints = ra.smoothSeries(cpuPoints)
+		memPoints = ra.smoothSeries(memPoints)
+		allCPUPoints = append(allCPUPoints, cpuPoints...)
+		podCPUSeries = append(podCPUSeries, cpuPoints)
+		allMemoryPoints = append(allMemoryPoints, memPoints...)
+
+		// Track each container's own usage so multi-container pods can be sized per container
+		for containerName, cm := range metrics.Containers {
+			containerResource := collector.ContainerResource(pod.Name, containerName)
+			if data, err := ra.optimizer.collector.GetTimeSeriesData(containerResource, "cpu", duration); err == nil {
+				cm.CPUTimeSeries = append(cm.CPUTimeSeries, ra.smoothSeries(data.Points)...)
+			}
+			if data, err := ra.optimizer.collector.GetTimeSeriesData(containerResource, "memory", duration); err == nil {
+				cm.MemoryTimeSeries = append(cm.MemoryTimeSeries, ra.smoothSeries(data.Points)...)
+			}
 		}
 
-		// Get Memory time series
 		memResource := fmt.Sprintf("pod/%s", pod.Name)
-		memData, err := ra.optimizer.collector.GetTimeSeriesData(memResource, "memory", duration)
-		if err == nil {
-			allMemoryPoints = append(allMemoryPoints, memData.Points...)
+
+		// Get working-set memory time series (only available from kubelet summaries). It is measured
+		// per pod, so it would count excluded sidecars against the app containers' limits; with
+		// sidecars excluded, memory pressure falls back to the app containers' own usage.
+		if len(sidecars) == 0 {
+			workingSetData, err := ra.optimizer.collector.GetTimeSeriesData(memResource, "memory_working_set", duration)
+			if err == nil {
+				allWorkingSetPoints = append(allWorkingSetPoints, workingSetData.Points...)
+			}
 		}
 
-		// Count restarts
+		// Get the share of CPU periods throttled at the limit (only when collected or ingested)
+		if throttleData, err := ra.optimizer.collector.GetTimeSeriesData(memResource, collector.MetricCPUThrottle, duration); err == nil {
+			allThrottlePoints = append(allThrottlePoints, throttleData.Points...)
+		}
+
+		// Get GPU usage time series for pods requesting GPUs (only when ingested from a metric source)
+		if metrics.GPURequested > 0 {
+			gpuData, err := ra.optimizer.collector.GetTimeSeriesData(memResource, "gpu", duration)
+			if err != nil || len(gpuData.Points) == 0 {
+				gpuPodsMissing++
+			} else {
+				allGPUPoints = append(allGPUPoints, ra.smoothSeries(gpuData.Points)...)
+			}
+		}
+
+		// Count restarts, by cause
 		for _, containerStatus := range pod.Status.ContainerStatuses {
 			restartCount += containerStatus.RestartCount
 		}
+		addRestarts(restartBreakdown, pod.Status.ContainerStatuses)
+		metrics.OOMKillCount += oomKills(pod.Status.ContainerStatuses)
 	}
 
 	metrics.RestartCount = restartCount
+	metrics.RestartBreakdown = restartBreakdown
+
+	// Count rollouts within the analysis window
+	rollouts, err := ra.countWorkloadRollouts(workload, time.Now().Add(-duration))
+	if err == nil {
+		metrics.RolloutCount = rollouts
+	}
 	metrics.CPUTimeSeries = allCPUPoints
+	metrics.CPUDemandSeries = sumSeriesByTimestamp(podCPUSeries)
 	metrics.MemoryTimeSeries = allMemoryPoints
 
-	// Calculate CPU statistics
-	if len(allCPUPoints) > 0 {
-		cpuValues := extractValues(allCPUPoints)
-		sort.Float64s(cpuValues)
-
-		metrics.CPUCurrent = int64(cpuValues[len(cpuValues)-1])
-		metrics.CPUP50 = int64(calculatePercentile(cpuValues, 50))
-		metrics.CPUP95 = int64(calculatePercentile(cpuValues, 95))
-		metrics.CPUP99 = int64(calculatePercentile(cpuValues, 99))
-		metrics.CPUAverage = int64(calculateAverage(cpuValues))
-		metrics.CPUMax = int64(cpuValues[len(cpuValues)-1])
+	ra.applyUsageStatistics(metrics, allCPUPoints, allMemoryPoints)
+	// A pod without GPU usage would read as idle GPUs, so size GPUs only when every pod reports them
+	if gpuPodsMissing > 0 {
+		allGPUPoints = nil
 	}
+	applyGPUStatistics(metrics, allGPUPoints)
 
-	// Calculate Memory statistics
-	if len(allMemoryPoints) > 0 {
-		memValues := extractValues(allMemoryPoints)
-		sort.Float64s(memValues)
+	metrics.CPUThrottleRatio = calculateAverage(extractValues(allThrottlePoints))
 
-		metrics.MemoryCurrent = int64(memValues[len(memValues)-1])
-		metrics.MemoryP50 = int64(calculatePercentile(memValues, 50))
-		metrics.MemoryP95 = int64(calculatePercentile(memValues, 95))
-		metrics.MemoryP99 = int64(calculatePercentile(memValues, 99))
-		metrics.MemoryAverage = int64(calculateAverage(memValues))
-		metrics.MemoryMax = int64(memValues[len(memValues)-1])
+	if len(allWorkingSetPoints) > 0 {
+		workingSetValues := extractValues(allWorkingSetPoints)
+		sort.Float64s(workingSetValues)
+		metrics.MemoryWorkingSetP95 = int64(calculatePercentile(workingSetValues, 95))
 	}
 
 	// Check for HPA
 	hpaList, err := ra.optimizer.k8sClient.Clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
 	if err == nil {
 		for _, hpa := range hpaList.Items {
-			if hpa.Spec.ScaleTargetRef.Name == name {
+			if hpa.Spec.ScaleTargetRef.Name == name && (hpa.Spec.ScaleTargetRef.Kind == "" || hpa.Spec.ScaleTargetRef.Kind == kind) {
 				metrics.HasHPA = true
-				metrics.MinReplicas = *hpa.Spec.MinReplicas
+				metrics.MinReplicas = k8s.HPAMinReplicas(&hpa)
 				metrics.MaxReplicas = hpa.Spec.MaxReplicas
 				metrics.HPADesiredReplicas = hpa.Status.DesiredReplicas
 
-				// Extract CPU target
-				for _, metric := range hpa.Spec.Metrics {
-					if metric.Resource != nil && metric.Resource.Name == corev1.ResourceCPU {
-						if metric.Resource.Target.AverageUtilization != nil {
-							metrics.HPATargetCPU = *metric.Resource.Target.AverageUtilization
-						}
-					}
-				}
-
-				// Extract current CPU
-				for _, current := range hpa.Status.CurrentMetrics {
-					if current.Resource != nil && current.Resource.Name == corev1.ResourceCPU {
-						if current.Resource.Current.AverageUtilization != nil {
-							metrics.HPACurrentCPU = *current.Resource.Current.AverageUtilization
-						}
+				// Extract the metrics the HPA scales on, keeping CPU utilization at hand
+				metrics.HPAMetrics = k8s.HPAMetricTargets(&hpa)
+				for _, target := range metrics.HPAMetrics {
+					if isCPUUtilization(target) {
+						metrics.HPATargetCPU = int32(target.Target)
+						metrics.HPACurrentCPU = int32(target.Current)
 					}
 				}
 
@@ -209,21 +365,215 @@ func (ra *resourceAnalyzer) collectDeploymentMetrics(namespace, name string) (*d
 	return metrics, nil
 }
 
+// deploymentPodLister is implemented by collectors that keep a short-lived cache of deployment pod lists
+type deploymentPodLister interface {
+	ListDeploymentPods(deployment *appsv1.Deployment) ([]corev1.Pod, error)
+}
+
 // getDeploymentPods gets all pods belonging to a deployment
 func (ra *resourceAnalyzer) getDeploymentPods(deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	// Share the collector's cached pod list when it keeps one
+	if lister, ok := ra.optimizer.collector.(deploymentPodLister); ok {
+		return lister.ListDeploymentPods(deployment)
+	}
+
+	return ra.optimizer.k8sClient.DeploymentPods(context.Background(), deployment)
+}
+
+// partitionContainers splits containers into those analyzed for right-sizing and excluded sidecars
+func (ra *resourceAnalyzer) partitionContainers(containers []corev1.Container) (app, sidecars []corev1.Container) {
+	for _, container := range containers {
+		if ra.isExcludedContainer(container.Name) {
+			sidecars = append(sidecars, container)
+		} else {
+			app = append(app, container)
+		}
+	}
+	return app, sidecars
+}
+
+// isExcludedContainer reports whether a container is excluded from right-sizing analysis
+func (ra *resourceAnalyzer) isExcludedContainer(name string) bool {
+	for _, excluded := range ra.optimizer.cfg().ExcludedContainers {
+		if name == excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// getPodUsage returns a pod's CPU and memory time series. When the pod has excluded sidecars,
+// usage is summed from the app containers' own series; if per-container data is unavailable
+// it falls back to the pod-level series.
+func (ra *resourceAnalyzer) getPodUsage(podName string, appContainers []corev1.Container, hasSidecars bool, duration time.Duration) (cpu, memory []models.DataPoint) {
+	if hasSidecars {
+		var cpuSeries, memorySeries [][]models.DataPoint
+		for _, container := range appContainers {
+			containerResource := collector.ContainerResource(podName, container.Name)
+			if data, err := ra.optimizer.collector.GetTimeSeriesData(containerResource, "cpu", duration); err == nil && len(data.Points) > 0 {
+				cpuSeries = append(cpuSeries, data.Points)
+			}
+			if data, err := ra.optimizer.collector.GetTimeSeriesData(containerResource, "memory", duration); err == nil && len(data.Points) > 0 {
+				memorySeries = append(memorySeries, data.Points)
+			}
+		}
+
+		if len(cpuSeries) > 0 || len(memorySeries) > 0 {
+			return sumSeriesByTimestamp(cpuSeries), sumSeriesByTimestamp(memorySeries)
+		}
+	}
+
+	podResource := fmt.Sprintf("pod/%s", podName)
+	if data, err := ra.optimizer.collector.GetTimeSeriesData(podResource, "cpu", duration); err == nil {
+		cpu = data.Points
+	}
+	if data, err := ra.optimizer.collector.GetTimeSeriesData(podResource, "memory", duration); err == nil {
+		memory = data.Points
+	}
+	return cpu, memory
+}
+
+// smoothSeries de-noises a single pod's series with exponential smoothing when SmoothingAlpha is set.
+// Smoothing lags real load changes, so each point is kept at least as high as the lowest raw value
+// of the last sustainedSpikeSamples points: a sustained spike survives while one-off jitter does not.
+func (ra *resourceAnalyzer) smoothSeries(points []models.DataPoint) []models.DataPoint {
+	alpha := ra.optimizer.cfg().SmoothingAlpha
+	if alpha <= 0 || alpha >= 1 || len(points) == 0 {
+		return points
+	}
+
+	smoothedValues := analyzer.ExponentialSmoothing(points, alpha)
+	smoothed := make([]models.DataPoint, len(points))
+	for i, point := range points {
+		value := smoothedValues[i]
+		if i+1 >= sustainedSpikeSamples {
+			floor := point.Value
+			for _, prev := range points[i+1-sustainedSpikeSamples : i] {
+				floor = math.Min(floor, prev.Value)
+			}
+			value = math.Max(value, floor)
+		}
+		smoothed[i] = models.DataPoint{Timestamp: point.Timestamp, Value: value}
+	}
+	return smoothed
+}
+
+// getPodOverhead returns the per-pod overhead for a workload's pods. An overhead set on the
+// pod template takes precedence; otherwise the RuntimeClass's fixed overhead is used. Failures to
+// read the RuntimeClass, other than it not existing, are logged and count as no overhead.
+func (ra *resourceAnalyzer) getPodOverhead(podSpec corev1.PodSpec) corev1.ResourceList {
+	if len(podSpec.Overhead) > 0 {
+		return podSpec.Overhead
+	}
+
+	if podSpec.RuntimeClassName == nil || *podSpec.RuntimeClassName == "" {
+		return nil
+	}
+
 	ctx := context.Background()
+	runtimeClass, err := ra.optimizer.k8sClient.Clientset.NodeV1().RuntimeClasses().Get(ctx, *podSpec.RuntimeClassName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			slog.Warn("Failed to read RuntimeClass for pod overhead", "runtime_class", *podSpec.RuntimeClassName, "error", err)
+		}
+		return nil
+	}
+	if runtimeClass.Overhead == nil {
+		return nil
+	}
+
+	return runtimeClass.Overhead.PodFixed
+}
 
-	// Build label selector from deployment selector
-	selector := metav1.FormatLabelSelector(deployment.Spec.Selector)
+// countRecentRollouts counts the deployment's ReplicaSets created since the given time.
+// Each rollout of a changed pod template creates a new ReplicaSet.
+func (ra *resourceAnalyzer) countRecentRollouts(deployment *appsv1.Deployment, since time.Time) (int, error) {
+	ctx := context.Background()
 
-	podList, err := ra.optimizer.k8sClient.Clientset.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: selector,
+	rsList, err := ra.optimizer.k8sClient.Clientset.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(deployment.Spec.Selector),
 	})
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+
+	count := 0
+	for _, rs := range rsList.Items {
+		if !metav1.IsControlledBy(&rs, deployment) {
+			continue
+		}
+		if rs.CreationTimestamp.Time.After(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// analyzeChurn derives the rollout rate and how much it lowers confidence in observed usage
+func (ra *resourceAnalyzer) analyzeChurn(result *analysisResult) {
+	metrics := &result.Deployment
+	threshold := ra.optimizer.cfg().ChurnRolloutsPerDayThreshold
+
+	window := metrics.Window
+	if window == 0 {
+		window = ra.optimizer.cfg().AnalysisDuration
+	}
+	windowDays := window.Hours() / 24
+	if windowDays > 0 {
+		result.ChurnRate = float64(metrics.RolloutCount) / windowDays
+	}
+
+	result.Confidence = 1.0
+	if threshold > 0 {
+		// Confidence halves when the rollout rate reaches the threshold
+		result.Confidence = 1.0 / (1.0 + result.ChurnRate/threshold)
+		result.HighChurn = result.ChurnRate > threshold
+	}
+}
+
+// analyzeReplicaConfidence discounts confidence when too few pods back the aggregate analysis,
+// since a single pod may be an unrepresentative outlier (stuck, restarting)
+func (ra *resourceAnalyzer) analyzeReplicaConfidence(result *analysisResult) {
+	minReplicas := ra.optimizer.cfg().MinReplicasForConfidence
+	replicas := result.Deployment.CurrentReplicas
+	if minReplicas <= 1 || replicas >= minReplicas {
+		return
+	}
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	// Scale linearly from 0.5 for a single pod up to full confidence at the minimum
+	factor := 0.5 + 0.5*float64(replicas-1)/float64(minReplicas-1)
+	result.Confidence *= factor
+}
+
+// analyzeCoverage scales confidence by the share of the analysis window that the collected samples
+// span, so a few minutes of data is not trusted like a full window
+func (ra *resourceAnalyzer) analyzeCoverage(result *analysisResult) {
+	metrics := &result.Deployment
+	result.Coverage = dataCoverage(metrics.CPUTimeSeries, metrics.Window)
+	result.Confidence *= result.Coverage
+}
+
+// dataCoverage returns the time spanned by the points as a 0-1 fraction of the window
+func dataCoverage(points []models.DataPoint, window time.Duration) float64 {
+	if len(points) < 2 || window <= 0 {
+		return 0
 	}
 
-	return podList.Items, nil
+	first, last := points[0].Timestamp, points[0].Timestamp
+	for _, point := range points[1:] {
+		if point.Timestamp.Before(first) {
+			first = point.Timestamp
+		}
+		if point.Timestamp.After(last) {
+			last = point.Timestamp
+		}
+	}
+
+	return math.Min(1, float64(last.Sub(first))/float64(window))
 }
 
 // analyzeCPU performs CPU usage analysis
@@ -236,18 +586,33 @@ func (ra *resourceAnalyzer) analyzeCPU(result *analysisResult) {
 	}
 
 	// Check for over-provisioning (P95 usage < 50% of requested)
-	if result.CPUUtilization < ra.optimizer.config.CPUOverProvisionedThreshold {
+	if result.CPUUtilization < ra.optimizer.cfg().CPUOverProvisionedThreshold {
 		result.CPUOverProvisioned = true
 	}
 
 	// Check for under-provisioning (P95 usage > 80% of limit)
 	if metrics.CPULimit > 0 {
 		utilizationVsLimit := float64(metrics.CPUP95) / float64(metrics.CPULimit)
-		if utilizationVsLimit > ra.optimizer.config.CPUUnderProvisionedThreshold {
+		if utilizationVsLimit > ra.optimizer.cfg().CPUUnderProvisionedThreshold {
 			result.CPUUnderProvisioned = true
 		}
 	}
 
+	// Check for throttling at the limit. Throttling caps usage below the limit, so utilization of the
+	// request understates demand and must not be read as over-provisioning.
+	threshold := ra.optimizer.cfg().CPUThrottleThreshold
+	if threshold > 0 && metrics.CPULimit > 0 && metrics.CPUThrottleRatio > threshold {
+		result.CPUThrottled = true
+		result.CPUOverProvisioned = false
+	}
+
+	// Check for bursty usage (P99 far above both P50 and the mean, i.e. rare spikes)
+	if ra.optimizer.cfg().BurstyCPULimitHeadroom && metrics.CPUP50 > 0 && metrics.CPUAverage > 0 {
+		threshold = ra.optimizer.cfg().BurstyCPURatioThreshold
+		result.CPUBursty = float64(metrics.CPUP99)/float64(metrics.CPUP50) >= threshold &&
+			float64(metrics.CPUP99)/float64(metrics.CPUAverage) >= threshold
+	}
+
 	// Calculate variance (stability metric)
 	if len(metrics.CPUTimeSeries) > 0 {
 		values := extractValues(metrics.CPUTimeSeries)
@@ -268,18 +633,26 @@ func (ra *resourceAnalyzer) analyzeMemory(result *analysisResult) {
 	}
 
 	// Check for over-provisioning (P95 usage < 50% of requested)
-	if result.MemoryUtilization < ra.optimizer.config.MemoryOverProvisionedThreshold {
+	if result.MemoryUtilization < ra.optimizer.cfg().MemoryOverProvisionedThreshold {
 		result.MemoryOverProvisioned = true
 	}
 
-	// Check for under-provisioning (P95 usage > 80% of limit)
+	// Check for under-provisioning (P95 usage > 80% of limit), preferring working-set memory
+	pressure := metrics.memoryPressure(ra.optimizer.cfg().UseWorkingSetMemory)
 	if metrics.MemoryLimit > 0 {
-		utilizationVsLimit := float64(metrics.MemoryP95) / float64(metrics.MemoryLimit)
-		if utilizationVsLimit > ra.optimizer.config.MemoryUnderProvisionedThreshold {
+		utilizationVsLimit := float64(pressure) / float64(metrics.MemoryLimit)
+		if utilizationVsLimit > ra.optimizer.cfg().MemoryUnderProvisionedThreshold {
 			result.MemoryUnderProvisioned = true
 		}
 	}
 
+	// Check for OOM kills. Killed containers never show the usage they needed, so low
+	// utilization must not be read as over-provisioning.
+	if metrics.OOMKillCount > 0 {
+		result.MemoryOOMKilled = true
+		result.MemoryOverProvisioned = false
+	}
+
 	// Calculate variance (stability metric)
 	if len(metrics.MemoryTimeSeries) > 0 {
 		values := extractValues(metrics.MemoryTimeSeries)
@@ -339,10 +712,9 @@ func (ra *resourceAnalyzer) analyzeHPA(result *analysisResult) {
 		}
 	}
 
-	// Check if target CPU is too aggressive or too conservative
-	if metrics.HPATargetCPU > 0 && metrics.HPACurrentCPU > 0 {
-		diff := math.Abs(float64(metrics.HPACurrentCPU - metrics.HPATargetCPU))
-		if diff > 20 { // More than 20% difference
+	// Check if any metric's target is too aggressive or too conservative
+	for _, target := range metrics.hpaMetricTargets() {
+		if hpaTargetDeviates(target) {
 			result.HPANeedsOptimization = true
 		}
 	}
@@ -367,9 +739,8 @@ func (ra *resourceAnalyzer) calculateScores(result *analysisResult) {
 	// Based on restart count, variance, and scaling patterns
 	stabilityScore := 100.0
 
-	// Penalize for restarts (each restart reduces score)
-	restartPenalty := float64(metrics.RestartCount) * 5.0
-	stabilityScore -= restartPenalty
+	// Penalize for restarts (each restart reduces score, OOM kills and crash loops the most)
+	stabilityScore -= ra.optimizer.restartPenalty(metrics)
 
 	// Penalize for high variance (unstable resource usage)
 	if result.CPUVariance > 1000 {
@@ -384,6 +755,9 @@ func (ra *resourceAnalyzer) calculateScores(result *analysisResult) {
 		stabilityScore -= 20.0
 	}
 
+	// Penalize for frequent rollouts (up to 30 points)
+	stabilityScore -= math.Min(30, result.ChurnRate*5)
+
 	result.StabilityScore = math.Max(0, stabilityScore)
 
 	// Cost Efficiency Score (20% weight)
@@ -424,8 +798,8 @@ func (ra *resourceAnalyzer) calculateResourceEfficiency(utilization, variance fl
 
 // calculateUtilizationScore calculates score based on utilization (0-100)
 func (ra *resourceAnalyzer) calculateUtilizationScore(utilization float64) float64 {
-	optMin := ra.optimizer.config.OptimalUtilizationMin
-	optMax := ra.optimizer.config.OptimalUtilizationMax
+	optMin := ra.optimizer.cfg().OptimalUtilizationMin
+	optMax := ra.optimizer.cfg().OptimalUtilizationMax
 
 	if utilization >= optMin && utilization <= optMax {
 		// Perfect range
@@ -464,6 +838,97 @@ func (ra *resourceAnalyzer) countScalingEvents(points []models.DataPoint) int {
 
 // Helper functions
 
+// applyUsageStatistics sets the CPU and memory percentiles of metrics from raw usage points, weighted
+// toward recent samples when RecencyHalfLife is set
+func (ra *resourceAnalyzer) applyUsageStatistics(metrics *deploymentMetrics, cpuPoints, memoryPoints []models.DataPoint) {
+	if halfLife := ra.optimizer.cfg().RecencyHalfLife; halfLife > 0 {
+		applyWeightedUsageStatistics(metrics, cpuPoints, memoryPoints, halfLife)
+		return
+	}
+
+	// Calculate CPU statistics
+	if len(cpuPoints) > 0 {
+		cpuValues := extractValues(cpuPoints)
+		sort.Float64s(cpuValues)
+
+		metrics.CPUCurrent = int64(cpuValues[len(cpuValues)-1])
+		metrics.CPUP50 = int64(calculatePercentile(cpuValues, 50))
+		metrics.CPUP95 = int64(calculatePercentile(cpuValues, 95))
+		metrics.CPUP99 = int64(calculatePercentile(cpuValues, 99))
+		metrics.CPUAverage = int64(calculateAverage(cpuValues))
+		metrics.CPUMax = int64(cpuValues[len(cpuValues)-1])
+	}
+
+	// Calculate Memory statistics
+	if len(memoryPoints) > 0 {
+		memValues := extractValues(memoryPoints)
+		sort.Float64s(memValues)
+
+		metrics.MemoryCurrent = int64(memValues[len(memValues)-1])
+		metrics.MemoryP50 = int64(calculatePercentile(memValues, 50))
+		metrics.MemoryP95 = int64(calculatePercentile(memValues, 95))
+		metrics.MemoryP99 = int64(calculatePercentile(memValues, 99))
+		metrics.MemoryAverage = int64(calculateAverage(memValues))
+		metrics.MemoryMax = int64(memValues[len(memValues)-1])
+	}
+}
+
+// applyWeightedUsageStatistics sets the CPU and memory percentiles and averages of metrics with each
+// point weighted by its recency. Current and max usage stay unweighted.
+func applyWeightedUsageStatistics(metrics *deploymentMetrics, cpuPoints, memoryPoints []models.DataPoint, halfLife time.Duration) {
+	if len(cpuPoints) > 0 {
+		usage := sortedWeightedUsage(cpuPoints, halfLife)
+
+		metrics.CPUCurrent = int64(usage[len(usage)-1].value)
+		metrics.CPUP50 = int64(calculateWeightedPercentile(usage, 50))
+		metrics.CPUP95 = int64(calculateWeightedPercentile(usage, 95))
+		metrics.CPUP99 = int64(calculateWeightedPercentile(usage, 99))
+		metrics.CPUAverage = int64(calculateWeightedAverage(usage))
+		metrics.CPUMax = int64(usage[len(usage)-1].value)
+		metrics.RecencyWeighted = true
+	}
+
+	if len(memoryPoints) > 0 {
+		usage := sortedWeightedUsage(memoryPoints, halfLife)
+
+		metrics.MemoryCurrent = int64(usage[len(usage)-1].value)
+		metrics.MemoryP50 = int64(calculateWeightedPercentile(usage, 50))
+		metrics.MemoryP95 = int64(calculateWeightedPercentile(usage, 95))
+		metrics.MemoryP99 = int64(calculateWeightedPercentile(usage, 99))
+		metrics.MemoryAverage = int64(calculateWeightedAverage(usage))
+		metrics.MemoryMax = int64(usage[len(usage)-1].value)
+		metrics.RecencyWeighted = true
+	}
+}
+
+// sumSeriesByTimestamp adds together the values of series sampled at the same timestamps
+func sumSeriesByTimestamp(series [][]models.DataPoint) []models.DataPoint {
+	if len(series) == 1 {
+		return series[0]
+	}
+
+	totals := make(map[time.Time]float64)
+	var timestamps []time.Time
+	for _, points := range series {
+		for _, point := range points {
+			if _, seen := totals[point.Timestamp]; !seen {
+				timestamps = append(timestamps, point.Timestamp)
+			}
+			totals[point.Timestamp] += point.Value
+		}
+	}
+
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i].Before(timestamps[j])
+	})
+
+	result := make([]models.DataPoint, len(timestamps))
+	for i, ts := range timestamps {
+		result[i] = models.DataPoint{Timestamp: ts, Value: totals[ts]}
+	}
+	return result
+}
+
 // extractValues extracts float64 values from data points
 func extractValues(points []models.DataPoint) []float64 {
 	values := make([]float64, len(points))
@@ -528,21 +993,37 @@ func calculateVariance(values []float64) float64 {
 // formatResourceQuantity formats a resource quantity from int64 value
 func formatResourceQuantity(value int64, resourceType string) string {
 	if resourceType == "cpu" {
-		// Convert millicores to string (e.g., 100m, 1000m = 1)
-		if value >= 1000 {
+		// Convert millicores to string (e.g., 100m, 1000m = 1, 1500m stays 1500m)
+		if value >= 1000 && value%1000 == 0 {
 			return fmt.Sprintf("%d", value/1000)
 		}
 		return fmt.Sprintf("%dm", value)
 	} else if resourceType == "memory" {
-		// Convert bytes to Mi or Gi
-		if value >= 1024*1024*1024 {
+		// Convert bytes to Gi when exact, otherwise Mi rounded up so we never format below the value
+		if value >= 1024*1024*1024 && value%(1024*1024*1024) == 0 {
 			return fmt.Sprintf("%dGi", value/(1024*1024*1024))
 		}
-		return fmt.Sprintf("%dMi", value/(1024*1024))
+		return fmt.Sprintf("%dMi", roundUpToIncrement(value, 1024*1024)/(1024*1024))
 	}
 	return fmt.Sprintf("%d", value)
 }
 
+// roundUpToIncrement rounds value up to the next multiple of increment; increments <= 0 disable rounding
+func roundUpToIncrement(value, increment int64) int64 {
+	if increment <= 0 || value%increment == 0 {
+		return value
+	}
+	return (value/increment + 1) * increment
+}
+
+// roundDownToIncrement rounds a value down to a multiple of increment; a non-positive increment leaves it unchanged
+func roundDownToIncrement(value, increment int64) int64 {
+	if increment <= 0 {
+		return value
+	}
+	return value / increment * increment
+}
+
 // parseResourceQuantity parses a resource quantity string to int64
 func parseResourceQuantity(value string, resourceType string) int64 {
 	quantity, err := resource.ParseQuantity(value)
@@ -584,16 +1065,24 @@ func convertResourceConfigToMap(config resourceConfig) map[string]interface{} {
 	if config.MemoryLimit != "" {
 		result["memory_limit"] = config.MemoryLimit
 	}
+	if config.GPU != "" {
+		result["gpu"] = config.GPU
+	}
 	return result
 }
 
 // convertHPAConfigToMap converts HPA config to map
-func convertHPAConfigToMap(config hpaConfig) map[string]interface{} {
-	return map[string]interface{}{
+func convertHPAConfigToMap(config HPAConfig) map[string]interface{} {
+	result := map[string]interface{}{
 		"min_replicas": config.MinReplicas,
 		"max_replicas": config.MaxReplicas,
 		"target_cpu":   config.TargetCPU,
 	}
+	if config.Metric != "" {
+		result["metric"] = config.Metric
+		result["target"] = config.Target
+	}
+	return result
 }
 
 // convertScalingConfigToMap converts scaling config to map
@@ -0,0 +1,73 @@
+package optimizer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Restart causes reported in a restart breakdown
+const (
+	RestartReasonOOMKilled = "OOMKilled"
+	RestartReasonError     = "Error"
+	RestartReasonCompleted = "Completed"
+	RestartReasonCrashLoop = "CrashLoopBackOff"
+	RestartReasonUnknown   = "Unknown"
+)
+
+// defaultRestartPenalty is the stability penalty per restart whose cause has no configured weight
+const defaultRestartPenalty = 5.0
+
+// restartReason returns the cause to attribute a container's restarts to. Kubernetes only keeps the
+// last termination, so a container in crash-loop back-off is reported as such and otherwise all of
+// its restarts are attributed to the reason of its last termination.
+func restartReason(status corev1.ContainerStatus) string {
+	if status.State.Waiting != nil && status.State.Waiting.Reason == RestartReasonCrashLoop {
+		return RestartReasonCrashLoop
+	}
+	if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason != "" {
+		return terminated.Reason
+	}
+	return RestartReasonUnknown
+}
+
+// addRestarts counts the restarts of the container statuses by cause into breakdown
+func addRestarts(breakdown map[string]int, statuses []corev1.ContainerStatus) {
+	for _, status := range statuses {
+		if status.RestartCount > 0 {
+			breakdown[restartReason(status)] += int(status.RestartCount)
+		}
+	}
+}
+
+// oomKills counts the containers whose last termination was an OOM kill, including those now in
+// crash-loop back-off. Kubernetes only keeps the last termination, so each such container counts once
+// whatever its RestartCount; earlier restarts may have had other causes.
+func oomKills(statuses []corev1.ContainerStatus) int32 {
+	var count int32
+	for _, status := range statuses {
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == RestartReasonOOMKilled {
+			count++
+		}
+	}
+	return count
+}
+
+// restartPenalty returns the stability penalty for a workload's restarts, weighting each cause by
+// RestartPenaltyWeights. Restarts without a known cause use the default weight.
+func (opt *OptimizerEngine) restartPenalty(metrics *deploymentMetrics) float64 {
+	penalty := 0.0
+	attributed := 0
+	for reason, count := range metrics.RestartBreakdown {
+		weight, ok := opt.cfg().RestartPenaltyWeights[reason]
+		if !ok {
+			weight = defaultRestartPenalty
+		}
+		penalty += weight * float64(count)
+		attributed += count
+	}
+
+	if unattributed := int(metrics.RestartCount) - attributed; unattributed > 0 {
+		penalty += defaultRestartPenalty * float64(unattributed)
+	}
+
+	return penalty
+}
@@ -3,6 +3,9 @@ package optimizer
 import (
 	"fmt"
 	"math"
+	"sort"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
 )
 
 // scorer handles efficiency scoring calculations
@@ -35,8 +38,8 @@ func (s *scorer) calculateResourceUtilizationScore(analysis *analysisResult) flo
 
 // calculateSingleResourceUtilizationScore calculates utilization score for a single resource
 func (s *scorer) calculateSingleResourceUtilizationScore(utilization float64) float64 {
-	optMin := s.optimizer.config.OptimalUtilizationMin
-	optMax := s.optimizer.config.OptimalUtilizationMax
+	optMin := s.optimizer.cfg().OptimalUtilizationMin
+	optMax := s.optimizer.cfg().OptimalUtilizationMax
 
 	if utilization >= optMin && utilization <= optMax {
 		// Optimal range - full score
@@ -67,9 +70,8 @@ func (s *scorer) calculateStabilityScore(analysis *analysisResult) float64 {
 	score := 100.0
 
 	// Factor 1: Restart count (0 restarts = perfect)
-	// Each restart reduces the score
-	restartPenalty := float64(metrics.RestartCount) * 5.0
-	score -= restartPenalty
+	// Each restart reduces the score, weighted by its cause
+	score -= s.optimizer.restartPenalty(metrics)
 
 	// Factor 2: Resource usage stability (low variance is good)
 	variancePenalty := s.calculateVariancePenalty(analysis.CPUVariance, analysis.MemoryVariance)
@@ -81,6 +83,9 @@ func (s *scorer) calculateStabilityScore(analysis *analysisResult) float64 {
 		score -= scalingPenalty
 	}
 
+	// Factor 4: Rollout churn (frequent redeploys mean usage rarely reaches steady state)
+	score -= math.Min(30, analysis.ChurnRate*5)
+
 	// Ensure score is between 0 and 100
 	return math.Max(0, math.Min(100, score))
 }
@@ -175,9 +180,9 @@ func (s *scorer) calculateWastedCost(wastedCPU, wastedMemory int64) float64 {
 	wastedVCPU := convertMillicoresToVCPU(wastedCPU)
 	wastedGB := convertBytesToGB(wastedMemory)
 
-	// Calculate hourly cost
-	hourlyCPUCost := wastedVCPU * s.optimizer.config.CPUCostPerVCPUHour
-	hourlyMemoryCost := wastedGB * s.optimizer.config.MemoryCostPerGBHour
+	// Calculate hourly cost at the default rates, since a deployment's pods span nodes
+	hourlyCPUCost := wastedVCPU * s.optimizer.cfg().Pricing.CPUCostPerVCPUHour("")
+	hourlyMemoryCost := wastedGB * s.optimizer.cfg().Pricing.MemoryCostPerGBHour("")
 
 	// Calculate monthly cost (24 hours * 30 days)
 	monthlyCost := (hourlyCPUCost + hourlyMemoryCost) * 24 * 30
@@ -209,11 +214,19 @@ func (s *scorer) calculateHealthScore(analysis *analysisResult) float64 {
 		score -= 20.0 // Critical: Memory under-provisioned
 	}
 
-	// Deduct for restarts
-	if metrics.RestartCount > 0 {
-		restartPenalty := math.Min(30, float64(metrics.RestartCount)*3)
+	// Deduct for BestEffort pods, the first evicted under node pressure
+	if isBestEffort(metrics) {
+		score -= bestEffortHealthPenalty
+	}
+
+	// Deduct for restarts, with OOM kills deducted separately and more heavily
+	if restarts := metrics.RestartCount - metrics.OOMKillCount; restarts > 0 {
+		restartPenalty := math.Min(30, float64(restarts)*3)
 		score -= restartPenalty
 	}
+	if metrics.OOMKillCount > 0 {
+		score -= math.Min(30, float64(metrics.OOMKillCount)*oomKillHealthPenalty)
+	}
 
 	// Deduct for HPA issues
 	if metrics.HasHPA {
@@ -278,45 +291,127 @@ func (s *scorer) getPriorityLevel(analysis *analysisResult, potentialSavings flo
 	return PriorityLow
 }
 
-// getRiskLevel determines the risk level of applying a recommendation
+// getRiskLevel describes the risk of applying a recommendation, e.g. "Low risk - ..."
 func (s *scorer) getRiskLevel(recType recommendationType, analysis *analysisResult) string {
+	level, reason := s.assessRisk(recType, analysis)
+	return fmt.Sprintf("%s risk - %s", riskLabels[level], reason)
+}
+
+// assessRisk determines the risk level of applying a recommendation and why
+func (s *scorer) assessRisk(recType recommendationType, analysis *analysisResult) (riskLevel, string) {
 	switch recType {
 	case RecommendationTypeResource:
 		// Reducing resources is lower risk if currently over-provisioned
 		// Increasing resources is low risk
 		if analysis.CPUUnderProvisioned || analysis.MemoryUnderProvisioned {
-			return "Low risk - increasing resources to prevent issues"
+			return RiskLow, "increasing resources to prevent issues"
 		}
 		if analysis.CPUOverProvisioned || analysis.MemoryOverProvisioned {
-			return "Low risk - reducing over-provisioned resources"
+			// Restarting pods may already be hitting limits the P95 doesn't show
+			if analysis.Deployment.RestartCount > 0 {
+				return RiskHigh, "reducing resources of pods that have restarted"
+			}
+			return RiskLow, "reducing over-provisioned resources"
 		}
-		return "Medium risk - adjusting resource allocation"
+		return RiskMedium, "adjusting resource allocation"
 
 	case RecommendationTypeHPA:
 		// HPA changes are generally medium risk
 		if analysis.HPAHitCeiling {
-			return "Low risk - increasing max replicas to handle load"
+			return RiskLow, "increasing max replicas to handle load"
 		}
-		return "Medium risk - optimizing autoscaling configuration"
+		return RiskMedium, "optimizing autoscaling configuration"
+
+	case RecommendationTypeEventDriven:
+		// Scaling from zero adds a cold start to the first event of each burst
+		return RiskMedium, "switching to event-driven scaling adds cold starts after scale to zero"
+
+	case RecommendationTypeIdle:
+		// Nothing in the metrics shows who would miss the workload
+		return RiskHigh, "scaling to zero stops the workload; callers and schedules are not visible in usage metrics"
 
 	case RecommendationTypeScaling:
+		// StatefulSets scale one ordinal at a time and pods own persistent state
+		if analysis.Deployment.Kind == WorkloadKindStatefulSet {
+			return RiskHigh, "scaling a StatefulSet adds or removes stateful pods in order"
+		}
 		// Scaling changes are low risk
-		return "Low risk - adjusting replica count for better efficiency"
+		return RiskLow, "adjusting replica count for better efficiency"
 
 	default:
-		return "Unknown risk"
+		return RiskUnknown, "unclassified change"
 	}
 }
 
+// scoreImpact records the risk level and composite impact score of a recommendation
+func (s *scorer) scoreImpact(rec *models.Recommendation, analysis *analysisResult) {
+	recType := recommendationType(rec.Type)
+	level, _ := s.assessRisk(recType, analysis)
+
+	rec.Risk = string(level)
+	rec.ImpactScore = s.calculateImpactScore(rec.EstimatedSavings, s.estimateHealthImprovement(recType, analysis), level)
+}
+
+// calculateImpactScore combines savings and health improvement into a 0-100 score discounted by risk.
+// Savings have diminishing returns (impactSavingsScale scores half), so a risky change needs far larger
+// savings to outrank a safe one.
+func (s *scorer) calculateImpactScore(savings, healthImprovement float64, level riskLevel) float64 {
+	savings = math.Max(0, savings)
+	savingsScore := 100 * savings / (savings + impactSavingsScale)
+	healthScore := math.Min(100, healthImprovement*2.5)
+
+	value := 0.7*savingsScore + 0.3*healthScore
+	return value * riskMultipliers[level]
+}
+
+// estimateHealthImprovement estimates how many health score points applying a recommendation of the
+// given type recovers, mirroring the deductions in calculateHealthScore
+func (s *scorer) estimateHealthImprovement(recType recommendationType, analysis *analysisResult) float64 {
+	improvement := 0.0
+
+	switch recType {
+	case RecommendationTypeResource:
+		for _, under := range []bool{analysis.CPUUnderProvisioned, analysis.MemoryUnderProvisioned} {
+			if under {
+				improvement += 20.0
+			}
+		}
+		for _, over := range []bool{analysis.CPUOverProvisioned, analysis.MemoryOverProvisioned} {
+			if over {
+				improvement += 5.0
+			}
+		}
+	case RecommendationTypeHPA:
+		if analysis.HPAHitCeiling {
+			improvement += 15.0
+		}
+		if analysis.HPANeedsOptimization {
+			improvement += 10.0
+		}
+	}
+
+	return improvement
+}
+
+// SortByImpact orders recommendations by descending impact score, breaking ties by savings
+func SortByImpact(recommendations []models.Recommendation) {
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].ImpactScore != recommendations[j].ImpactScore {
+			return recommendations[i].ImpactScore > recommendations[j].ImpactScore
+		}
+		return recommendations[i].EstimatedSavings > recommendations[j].EstimatedSavings
+	})
+}
+
 // formatImpactMessage creates a detailed impact message for a recommendation
 func (s *scorer) formatImpactMessage(recType recommendationType, analysis *analysisResult, savings float64) string {
 	riskLevel := s.getRiskLevel(recType, analysis)
 
 	if savings > 0 {
 		return fmt.Sprintf("%s - estimated savings of $%.2f/month based on %d-day usage patterns",
-			riskLevel, savings, int(s.optimizer.config.AnalysisDuration.Hours()/24))
+			riskLevel, savings, int(s.optimizer.cfg().AnalysisDuration.Hours()/24))
 	}
 
 	return fmt.Sprintf("%s - based on %d-day usage patterns",
-		riskLevel, int(s.optimizer.config.AnalysisDuration.Hours()/24))
+		riskLevel, int(s.optimizer.cfg().AnalysisDuration.Hours()/24))
 }
@@ -0,0 +1,241 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+const (
+	// hpaTolerance is the utilization ratio band around 1.0 within which the HPA does not scale
+	hpaTolerance = 0.1
+
+	// hpaDownscaleStabilization is the window over which the HPA takes the highest
+	// recommendation before scaling down
+	hpaDownscaleStabilization = 5 * time.Minute
+)
+
+// SimulateHPA replays a deployment's historical CPU usage through the HPA scaling algorithm
+// using the proposed configuration and reports the projected replica trajectory and cost
+func (opt *OptimizerEngine) SimulateHPA(namespace, name string, proposed HPAConfig) (*models.HPASimulation, error) {
+	if err := proposed.Validate(); err != nil {
+		return nil, err
+	}
+
+	metrics, err := opt.analyzer.collectDeploymentMetrics(namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect deployment metrics: %w", err)
+	}
+	if metrics.CPURequested == 0 {
+		return nil, fmt.Errorf("deployment %s/%s has no CPU request; HPA utilization is undefined", namespace, name)
+	}
+	if len(metrics.CPUDemandSeries) == 0 {
+		return nil, fmt.Errorf("%w: no CPU usage data for deployment %s/%s", ErrInsufficientData, namespace, name)
+	}
+
+	trajectory, scalingEvents := simulateHPAReplicas(metrics.CPUDemandSeries, metrics.CPURequested, metrics.CurrentReplicas, proposed)
+
+	simulation := &models.HPASimulation{
+		Namespace:     namespace,
+		Deployment:    name,
+		MinReplicas:   proposed.MinReplicas,
+		MaxReplicas:   proposed.MaxReplicas,
+		TargetCPU:     proposed.TargetCPU,
+		Trajectory:    trajectory,
+		ScalingEvents: scalingEvents,
+		Timestamp:     time.Now(),
+	}
+
+	var totalReplicas int64
+	for _, point := range trajectory {
+		totalReplicas += int64(point.Replicas)
+		if point.Replicas > simulation.PeakReplicas {
+			simulation.PeakReplicas = point.Replicas
+		}
+	}
+	simulation.AverageReplicas = float64(totalReplicas) / float64(len(trajectory))
+
+	podCost := opt.recommendationGen.calculatePodCost(metrics)
+	simulation.CurrentMonthlyCost = podCost * float64(metrics.CurrentReplicas)
+	simulation.EstimatedMonthlyCost = podCost * simulation.AverageReplicas
+
+	return simulation, nil
+}
+
+// SimulateRecommendations projects the cluster-wide monthly cost if the given recommendations were
+// applied, without applying them. The current cost is that of every analyzed deployment, as in
+// GetDeploymentSummaries, and each counted recommendation takes off its estimated savings. Of several
+// recommendations of the same type for the same workload only the last listed is counted, since
+// applying them in order leaves its configuration; already-applied recommendations are not counted.
+func (opt *OptimizerEngine) SimulateRecommendations(recommendationIDs []string) (*models.WhatIfSimulation, error) {
+	if len(recommendationIDs) == 0 {
+		return nil, fmt.Errorf("no recommendations to simulate")
+	}
+
+	opt.recommendationsMu.RLock()
+	selected := make([]models.Recommendation, 0, len(recommendationIDs))
+	for _, id := range recommendationIDs {
+		rec, exists := opt.recommendations[id]
+		if !exists {
+			opt.recommendationsMu.RUnlock()
+			return nil, fmt.Errorf("recommendation %w: %s", ErrNotFound, id)
+		}
+		selected = append(selected, rec)
+	}
+	opt.recommendationsMu.RUnlock()
+
+	simulation := &models.WhatIfSimulation{
+		RecommendationIDs: []string{},
+		Conflicts:         []models.WhatIfConflict{},
+		Timestamp:         time.Now(),
+	}
+	for _, summary := range opt.GetDeploymentSummaries() {
+		simulation.CurrentMonthlyCost += summary.MonthlyCost
+	}
+
+	// Keep the last recommendation of each type per workload
+	last := make(map[string]int)
+	target := func(rec *models.Recommendation) string {
+		return fmt.Sprintf("%s/%s/%s/%s", rec.Namespace, rec.Deployment, rec.Container, rec.Type)
+	}
+	for i := range selected {
+		last[target(&selected[i])] = i
+	}
+
+	counted := make(map[string]bool)
+	savings := 0.0
+	for i, rec := range selected {
+		switch {
+		case !rec.AppliedAt.IsZero():
+			simulation.Conflicts = append(simulation.Conflicts, models.WhatIfConflict{
+				RecommendationID: rec.ID,
+				Reason:           fmt.Sprintf("already applied at %s", rec.AppliedAt.Format(time.RFC3339)),
+			})
+			continue
+		case last[target(&rec)] != i:
+			later := selected[last[target(&rec)]]
+			if later.ID == rec.ID {
+				// Listed twice; counted once, at its last position
+				continue
+			}
+			simulation.Conflicts = append(simulation.Conflicts, models.WhatIfConflict{
+				RecommendationID: rec.ID,
+				ConflictsWith:    later.ID,
+				Reason:           fmt.Sprintf("superseded by a later %s recommendation for %s/%s", rec.Type, rec.Namespace, rec.Deployment),
+			})
+			continue
+		}
+
+		counted[rec.ID] = true
+		savings += rec.EstimatedSavings
+		simulation.RecommendationIDs = append(simulation.RecommendationIDs, rec.ID)
+	}
+
+	// Flag counted pairs already known to over-shoot together, such as a scale-down and a request reduction
+	for _, rec := range selected {
+		if !counted[rec.ID] {
+			continue
+		}
+		for _, other := range rec.ConflictsWith {
+			if counted[other] {
+				simulation.Conflicts = append(simulation.Conflicts, models.WhatIfConflict{
+					RecommendationID: rec.ID,
+					ConflictsWith:    other,
+					Reason:           "sized from the same utilization; applying both over-shoots, so projected savings are overstated",
+					Counted:          true,
+				})
+			}
+		}
+	}
+
+	simulation.ProjectedMonthlyCost = math.Max(0, simulation.CurrentMonthlyCost-savings)
+	simulation.Delta = simulation.ProjectedMonthlyCost - simulation.CurrentMonthlyCost
+
+	return simulation, nil
+}
+
+// Validate checks that the configuration describes a usable HPA
+func (c HPAConfig) Validate() error {
+	if c.MinReplicas < 1 {
+		return fmt.Errorf("min replicas must be at least 1")
+	}
+	if c.MaxReplicas < c.MinReplicas {
+		return fmt.Errorf("max replicas (%d) must not be less than min replicas (%d)", c.MaxReplicas, c.MinReplicas)
+	}
+	if c.TargetCPU <= 0 {
+		return fmt.Errorf("target CPU utilization must be positive")
+	}
+	return nil
+}
+
+// simulateHPAReplicas applies the HPA algorithm to each point of a summed CPU series.
+// desiredReplicas = ceil(currentReplicas * currentUtilization / targetUtilization), skipped
+// within the tolerance band, clamped to [min, max], and scale-downs use the highest
+// recommendation within the stabilization window.
+func simulateHPAReplicas(demand []models.DataPoint, cpuRequest int64, initialReplicas int32, config HPAConfig) ([]models.SimulatedReplicas, int) {
+	replicas := clampReplicas(initialReplicas, config)
+
+	type recommendation struct {
+		timestamp time.Time
+		replicas  int32
+	}
+	var history []recommendation
+
+	trajectory := make([]models.SimulatedReplicas, 0, len(demand))
+	scalingEvents := 0
+
+	for _, point := range demand {
+		utilization := point.Value / (float64(replicas) * float64(cpuRequest)) * 100
+		ratio := utilization / float64(config.TargetCPU)
+
+		desired := replicas
+		if math.Abs(ratio-1.0) > hpaTolerance {
+			desired = clampReplicas(int32(math.Ceil(float64(replicas)*ratio)), config)
+		}
+
+		// Drop recommendations that fell out of the stabilization window
+		cutoff := point.Timestamp.Add(-hpaDownscaleStabilization)
+		for len(history) > 0 && !history[0].timestamp.After(cutoff) {
+			history = history[1:]
+		}
+		history = append(history, recommendation{timestamp: point.Timestamp, replicas: desired})
+
+		if desired < replicas {
+			for _, rec := range history {
+				if rec.replicas > desired {
+					desired = rec.replicas
+				}
+			}
+			if desired > replicas {
+				desired = replicas
+			}
+		}
+
+		if desired != replicas {
+			scalingEvents++
+			replicas = desired
+		}
+
+		trajectory = append(trajectory, models.SimulatedReplicas{
+			Timestamp:   point.Timestamp,
+			CPUUsage:    int64(point.Value),
+			Utilization: utilization,
+			Replicas:    replicas,
+		})
+	}
+
+	return trajectory, scalingEvents
+}
+
+// clampReplicas bounds a replica count to the configured HPA range
+func clampReplicas(replicas int32, config HPAConfig) int32 {
+	if replicas < config.MinReplicas {
+		return config.MinReplicas
+	}
+	if replicas > config.MaxReplicas {
+		return config.MaxReplicas
+	}
+	return replicas
+}
@@ -0,0 +1,93 @@
+package optimizer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+)
+
+// generateCPUThrottlingRecommendation recommends raising the CPU limit of a workload throttled at it.
+// Only the limit moves: the request reserves capacity for typical usage, while the limit caps bursts.
+// Under CPULimitNone the limit is removed instead.
+func (rg *recommendationGenerator) generateCPUThrottlingRecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+	if !analysis.CPUThrottled {
+		return nil
+	}
+	if rg.optimizer.cfg().CPULimitRatio <= 0 && !rg.preservesGuaranteedQoS(metrics) {
+		return rg.generateCPULimitRemovalRecommendation(analysis)
+	}
+
+	buffer := rg.optimizer.cfg().UnderProvisionedBuffer
+	limit := rg.roundCPU(int64(float64(metrics.CPULimit) * buffer))
+
+	currentConfig := resourceConfig{
+		CPURequest: formatResourceQuantity(metrics.CPURequested, "cpu"),
+		CPULimit:   formatResourceQuantity(metrics.CPULimit, "cpu"),
+	}
+	recommendedConfig := resourceConfig{
+		CPURequest: formatResourceQuantity(metrics.CPURequested, "cpu"),
+		CPULimit:   formatResourceQuantity(limit, "cpu"),
+	}
+
+	return &models.Recommendation{
+		ID:         uuid.New().String(),
+		Type:       string(RecommendationTypeResource),
+		Namespace:  metrics.Namespace,
+		Deployment: metrics.Deployment,
+		Priority:   string(PriorityHigh),
+		Description: fmt.Sprintf("Raise CPU limit from %s to %s: throttled in %.0f%% of CPU periods",
+			formatResourceQuantity(metrics.CPULimit, "cpu"), formatResourceQuantity(limit, "cpu"), metrics.CPUThrottleRatio*100),
+		CurrentConfig:     convertResourceConfigToMap(currentConfig),
+		RecommendedConfig: convertResourceConfigToMap(recommendedConfig),
+		EstimatedSavings:  0.0,
+		Impact:            "Performance improvement - fewer throttled CPU periods and lower tail latency",
+		Rationale: []string{
+			fmt.Sprintf("Pods were throttled at their CPU limit in %.1f%% of CFS periods (threshold: %.1f%%)",
+				metrics.CPUThrottleRatio*100, rg.optimizer.cfg().CPUThrottleThreshold*100),
+			"Throttling caps usage below the limit, so utilization of the request understates demand",
+			"Every throttled period stalls the workload until the next period, adding directly to request latency",
+			fmt.Sprintf("Recommended limit = current limit %s x %.2f buffer", formatResourceQuantity(metrics.CPULimit, "cpu"), buffer),
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+// generateCPULimitRemovalRecommendation recommends removing the CPU limit of a throttled workload
+// when the limit policy is CPULimitNone. The recommended config maps cpu_limit to nil, which
+// removes the limit when applied.
+func (rg *recommendationGenerator) generateCPULimitRemovalRecommendation(analysis *analysisResult) *models.Recommendation {
+	metrics := &analysis.Deployment
+
+	currentConfig := resourceConfig{
+		CPURequest: formatResourceQuantity(metrics.CPURequested, "cpu"),
+		CPULimit:   formatResourceQuantity(metrics.CPULimit, "cpu"),
+	}
+	recommendedConfig := convertResourceConfigToMap(resourceConfig{
+		CPURequest: formatResourceQuantity(metrics.CPURequested, "cpu"),
+	})
+	recommendedConfig["cpu_limit"] = nil
+
+	return &models.Recommendation{
+		ID:         uuid.New().String(),
+		Type:       string(RecommendationTypeResource),
+		Namespace:  metrics.Namespace,
+		Deployment: metrics.Deployment,
+		Priority:   string(PriorityHigh),
+		Description: fmt.Sprintf("Remove CPU limit %s: throttled in %.0f%% of CPU periods",
+			formatResourceQuantity(metrics.CPULimit, "cpu"), metrics.CPUThrottleRatio*100),
+		CurrentConfig:     convertResourceConfigToMap(currentConfig),
+		RecommendedConfig: recommendedConfig,
+		EstimatedSavings:  0.0,
+		Impact:            "Performance improvement - no throttled CPU periods; bursts use idle CPU on the node",
+		Rationale: []string{
+			fmt.Sprintf("Pods were throttled at their CPU limit in %.1f%% of CFS periods (threshold: %.1f%%)",
+				metrics.CPUThrottleRatio*100, rg.optimizer.cfg().CPUThrottleThreshold*100),
+			"Every throttled period stalls the workload until the next period, adding directly to request latency",
+			"The limit policy is to run without CPU limits; the request still guarantees the pod's share under contention",
+		},
+		CreatedAt: time.Now(),
+	}
+}
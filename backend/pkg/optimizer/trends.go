@@ -0,0 +1,41 @@
+package optimizer
+
+import (
+	"math"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/analyzer"
+)
+
+const (
+	// trendStablePercentPerDay is the smallest daily change, relative to average usage, reported as
+	// a direction rather than stable
+	trendStablePercentPerDay = 1.0
+
+	// trendMinRSquared is the fit below which a slope is treated as noise and the trend as stable
+	trendMinRSquared = 0.3
+)
+
+// calculateResourceTrend fits a linear trend through a usage series and normalizes its slope to a
+// day. The series holds the samples of every pod, so the trend follows per-pod usage and is not
+// moved by the replica count.
+func calculateResourceTrend(points []models.DataPoint) models.ResourceTrend {
+	slopePerHour, rSquared := analyzer.LinearTrend(points)
+	trend := models.ResourceTrend{
+		SlopePerDay: slopePerHour * 24,
+		RSquared:    rSquared,
+		Direction:   "stable",
+	}
+
+	if average := calculateAverage(extractValues(points)); average > 0 {
+		trend.PercentPerDay = trend.SlopePerDay / average * 100
+	}
+	if rSquared >= trendMinRSquared && math.Abs(trend.PercentPerDay) >= trendStablePercentPerDay {
+		if trend.PercentPerDay > 0 {
+			trend.Direction = "increasing"
+		} else {
+			trend.Direction = "decreasing"
+		}
+	}
+	return trend
+}
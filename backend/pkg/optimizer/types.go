@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/k8s-service-optimizer/backend/internal/models"
+	"github.com/k8s-service-optimizer/backend/pkg/pricing"
 )
 
 // Config holds optimizer configuration
@@ -29,20 +30,184 @@ type Config struct {
 	// UnderProvisionedBuffer is the buffer to add to recommendations for under-provisioned resources (default: 1.5 = 50% buffer)
 	UnderProvisionedBuffer float64
 
-	// CPUCostPerVCPUHour is the cost per vCPU-hour for cost estimation (default: $0.03)
-	CPUCostPerVCPUHour float64
+	// Pricing supplies the CPU and memory rates for cost estimation; nil uses the default provider
+	// (default: pricing.DefaultProvider(), $0.03 per vCPU-hour and $0.004 per GB-hour)
+	Pricing pricing.PricingProvider
 
-	// MemoryCostPerGBHour is the cost per GB-hour for cost estimation (default: $0.004)
-	MemoryCostPerGBHour float64
+	// GPUCostPerHour is the cost per GPU-hour for cost estimation (default: $2.50)
+	GPUCostPerHour float64
+
+	// ApplyCostEstimate is the one-off engineering and rollout cost of applying a recommendation,
+	// used to compute its break-even time; 0 disables break-even (default: $100)
+	ApplyCostEstimate float64
 
 	// MinimumDataPoints is the minimum number of data points required for analysis (default: 10)
 	MinimumDataPoints int
 
+	// MinWorkloadAge is how old a deployment must be before its usage is analyzed. Younger deployments
+	// report their configuration only, since their metrics reflect startup rather than steady state
+	// (default: 24 hours, 0 disables)
+	MinWorkloadAge time.Duration
+
+	// AllowLowConfidenceRecommendations analyzes deployments with fewer than MinimumDataPoints
+	// and returns provisional, low-priority recommendations instead of an error (default: false)
+	AllowLowConfidenceRecommendations bool
+
 	// OptimalUtilizationMin is the minimum optimal resource utilization (default: 0.7 = 70%)
 	OptimalUtilizationMin float64
 
 	// OptimalUtilizationMax is the maximum optimal resource utilization (default: 0.9 = 90%)
 	OptimalUtilizationMax float64
+
+	// ChurnRolloutsPerDayThreshold is the rollout rate above which a deployment is considered high-churn
+	// and right-sizing is deferred (default: 2 rollouts per day)
+	ChurnRolloutsPerDayThreshold float64
+
+	// MinReplicasForConfidence is the number of pods needed before aggregate analysis is fully
+	// trusted; deployments with fewer pods get discounted confidence (default: 3)
+	MinReplicasForConfidence int32
+
+	// LowConfidenceThreshold is the analysis confidence below which recommendations are capped at low
+	// priority, unless they fix a critical under-provision. Confidence includes how much of the analysis
+	// window the collected samples span (default: 0.5)
+	LowConfidenceThreshold float64
+
+	// CPURoundingMillis rounds recommended CPU up to a multiple of this many millicores (default: 50m, 0 disables)
+	CPURoundingMillis int64
+
+	// MemoryRoundingBytes rounds recommended memory up to a multiple of this many bytes (default: 32Mi, 0 disables)
+	MemoryRoundingBytes int64
+
+	// QuotaPressureThreshold is the quota utilization above which a namespace is considered near its quota (default: 0.9 = 90%)
+	QuotaPressureThreshold float64
+
+	// ProtectedWorkloadPatterns are deployment name patterns that are analyzed for health only and
+	// never receive recommendations. Patterns are globs, or regular expressions when wrapped in slashes;
+	// invalid ones are logged and ignored (default: common ingress, CNI, CSI and DNS workloads)
+	ProtectedWorkloadPatterns []string
+
+	// LatencyCriticalPatterns are workload name patterns, in the ProtectedWorkloadPatterns syntax, of
+	// latency-critical workloads such as API gateways. These, and workloads annotated
+	// optimizer.k8s.io/latency-critical=true, are recommended Guaranteed QoS (default: *gateway*)
+	LatencyCriticalPatterns []string
+
+	// CoalesceAnalyses shares one analysis between identical concurrent requests (default: true)
+	CoalesceAnalyses bool
+
+	// AnalysisConcurrency is how many deployments AnalyzeAllDeployments analyzes at once (default: 8)
+	AnalysisConcurrency int
+
+	// GuaranteedQoSStrategy controls how deployments with request == limit are handled (default: "preserve")
+	GuaranteedQoSStrategy string
+
+	// CPULimitRatio is the CPU limit recommended for a CPU request, as a multiple of the request.
+	// CPULimitNone recommends no CPU limit: the limit is left out of recommended configs. Ratios
+	// between 0 and 1 would put the limit below the request and are raised to 1 (default: 2)
+	CPULimitRatio float64
+
+	// MemoryLimitRatio is the memory limit recommended for a memory request, as a multiple of the
+	// request. MemoryLimitEqual keeps the limit equal to the request; ratios between 0 and 1 are
+	// raised to it (default: 2)
+	MemoryLimitRatio float64
+
+	// UseWorkingSetMemory bases memory under-provisioning detection on working-set memory, which
+	// excludes reclaimable page cache, whenever the collector provides it (default: true)
+	UseWorkingSetMemory bool
+
+	// CommittedCapacity is cluster capacity prepaid through reserved instances or committed-use
+	// discounts. Freed capacity is only cash savings while cluster requests exceed the commitment;
+	// the rest is reported as reclaimable capacity (default: none)
+	CommittedCapacity CommittedCapacity
+
+	// ExcludedContainers are container names (typically injected sidecars) left out of right-sizing
+	// analysis. Their requests still count toward cost (default: istio-proxy, linkerd-proxy)
+	ExcludedContainers []string
+
+	// IncludePodOverhead folds RuntimeClass pod overhead into effective requests and cost (default: true)
+	IncludePodOverhead bool
+
+	// SmoothingAlpha exponentially smooths usage series before percentiles are computed, so single-sample
+	// jitter does not drive right-sizing. Lower values smooth more; 0 disables smoothing (default: 0)
+	SmoothingAlpha float64
+
+	// RecencyHalfLife weights usage samples by age when computing percentiles and averages: a sample's
+	// weight halves for every half-life it is older than the newest sample, so today's load outweighs
+	// last week's. 0 weights all samples equally (default: 0)
+	RecencyHalfLife time.Duration
+
+	// BurstyCPULimitHeadroom sizes bursty CPU workloads by keeping the request near typical (P50) usage
+	// and raising only the limit to cover P99 bursts, instead of inflating the request (default: true)
+	BurstyCPULimitHeadroom bool
+
+	// BurstyCPURatioThreshold is the P99/P50 and P99/mean CPU ratio at or above which usage is
+	// considered bursty (default: 3)
+	BurstyCPURatioThreshold float64
+
+	// CPUThrottleThreshold is the share of CFS periods throttled at the CPU limit above which CPU is
+	// flagged as throttled and a limit increase is recommended, however healthy utilization of the
+	// request looks (default: 0.1 = 10%, 0 disables)
+	CPUThrottleThreshold float64
+
+	// DismissalCooldown is how long a dismissed recommendation's namespace, deployment and type are
+	// suppressed from regeneration (default: 7 days)
+	DismissalCooldown time.Duration
+
+	// RecommendationCooldown is the shortest interval between regenerating a workload's recommendations;
+	// requests within it are served the recommendations already generated (default: 5 minutes, 0 disables)
+	RecommendationCooldown time.Duration
+
+	// WriteAdvisoryAnnotations writes the current resource recommendations to each analyzed deployment as
+	// optimizer.k8s.io/recommended-* annotations, without changing its spec (default: false)
+	WriteAdvisoryAnnotations bool
+
+	// NodeUnderutilizedThreshold is the P95 CPU and memory utilization below which a node pool is
+	// considered under-used and sized down (default: 0.5)
+	NodeUnderutilizedThreshold float64
+
+	// NodeInstanceTypes lists instance types with their allocatable capacity and price. Listed types
+	// price current nodes and may be suggested in node sizing; unlisted nodes are priced from their
+	// allocatable resources at the flat rates (default: none)
+	NodeInstanceTypes []NodeInstanceType
+
+	// EventDrivenIdleGap is the shortest idle stretch between CPU bursts for which a workload idling
+	// at its HPA minimum is recommended KEDA event-driven scaling instead; 0 disables (default: 1 hour)
+	EventDrivenIdleGap time.Duration
+
+	// IdleCPUThresholdMillis is the P95 CPU usage per pod, in millicores, at or below which a workload
+	// whose memory is also flat is considered idle and recommended for scale to zero or removal;
+	// 0 disables (default: 5)
+	IdleCPUThresholdMillis int64
+
+	// IdleMemoryVariation is the largest coefficient of variation (standard deviation over mean) of
+	// memory usage that still counts as flat for idle detection (default: 0.05)
+	IdleMemoryVariation float64
+
+	// ReserveDaemonSetOverhead subtracts the requests of DaemonSet pods, which run on every node, from
+	// node allocatable in node fit and node sizing (default: true)
+	ReserveDaemonSetOverhead bool
+
+	// MaxNodeFractionPerPod is the largest share of the biggest node's allocatable CPU or memory a
+	// single pod may request; larger requests are flagged and recommended down to it, with more
+	// replicas, to keep pods easy to schedule and bin-pack. 0 disables (default: 0)
+	MaxNodeFractionPerPod float64
+
+	// RestartPenaltyWeights is the stability score penalty per restart by cause, so that OOM kills and
+	// crash loops weigh more than clean exits; unlisted causes cost 5 points
+	// (default: OOMKilled 10, CrashLoopBackOff 10, Error 5, Completed 2)
+	RestartPenaltyWeights map[string]float64
+
+	// protectedPatterns and latencyCriticalPatterns are ProtectedWorkloadPatterns and
+	// LatencyCriticalPatterns compiled by NewWithConfig
+	protectedPatterns       []workloadPattern
+	latencyCriticalPatterns []workloadPattern
+}
+
+// NodeInstanceType describes a node instance type that node sizing may suggest
+type NodeInstanceType struct {
+	Name        string  // node.kubernetes.io/instance-type value
+	CPU         int64   // allocatable millicores
+	Memory      int64   // allocatable bytes
+	HourlyPrice float64 // per node
 }
 
 // DefaultConfig returns the default optimizer configuration
@@ -55,18 +220,86 @@ func DefaultConfig() Config {
 		MemoryUnderProvisionedThreshold: 0.8,
 		OverProvisionedBuffer:           1.2, // 20% buffer
 		UnderProvisionedBuffer:          1.5, // 50% buffer
-		CPUCostPerVCPUHour:              0.03,
-		MemoryCostPerGBHour:             0.004,
+		Pricing:                         pricing.DefaultProvider(),
+		GPUCostPerHour:                  2.50,
+		ApplyCostEstimate:               100,
 		MinimumDataPoints:               10,
+		MinWorkloadAge:                  24 * time.Hour,
 		OptimalUtilizationMin:           0.7,
 		OptimalUtilizationMax:           0.9,
+		ChurnRolloutsPerDayThreshold:    2,
+		MinReplicasForConfidence:        3,
+		LowConfidenceThreshold:          0.5,
+		CPURoundingMillis:               50,
+		MemoryRoundingBytes:             32 * 1024 * 1024, // 32Mi
+		QuotaPressureThreshold:          0.9,
+		ProtectedWorkloadPatterns:       []string{"*ingress*controller*", "coredns", "calico-*", "cilium*", "*csi*"},
+		LatencyCriticalPatterns:         []string{"*gateway*"},
+		CoalesceAnalyses:                true,
+		AnalysisConcurrency:             8,
+		GuaranteedQoSStrategy:           QoSStrategyPreserve,
+		CPULimitRatio:                   2,
+		MemoryLimitRatio:                2,
+		UseWorkingSetMemory:             true,
+		ExcludedContainers:              []string{"istio-proxy", "linkerd-proxy"},
+		IncludePodOverhead:              true,
+		BurstyCPULimitHeadroom:          true,
+		BurstyCPURatioThreshold:         3,
+		CPUThrottleThreshold:            0.1,
+		DismissalCooldown:               7 * 24 * time.Hour,
+		RecommendationCooldown:          5 * time.Minute,
+		NodeUnderutilizedThreshold:      0.5,
+		EventDrivenIdleGap:              time.Hour,
+		IdleCPUThresholdMillis:          5,
+		IdleMemoryVariation:             0.05,
+		ReserveDaemonSetOverhead:        true,
+		RestartPenaltyWeights: map[string]float64{
+			RestartReasonOOMKilled: 10,
+			RestartReasonCrashLoop: 10,
+			RestartReasonError:     5,
+			RestartReasonCompleted: 2,
+		},
 	}
 }
 
+// CommittedCapacity describes prepaid cluster capacity
+type CommittedCapacity struct {
+	CPUMillis   int64 // committed CPU in millicores
+	MemoryBytes int64 // committed memory in bytes
+}
+
+// IsZero reports whether no commitment is configured
+func (c CommittedCapacity) IsZero() bool {
+	return c.CPUMillis == 0 && c.MemoryBytes == 0
+}
+
+// Guaranteed QoS handling strategies
+const (
+	// QoSStrategyPreserve moves request and limit together so the QoS class stays Guaranteed
+	QoSStrategyPreserve = "preserve"
+
+	// QoSStrategyIgnore sizes limits independently, which may downgrade the QoS class to Burstable
+	QoSStrategyIgnore = "ignore"
+)
+
+// Limit policies
+const (
+	// CPULimitNone as CPULimitRatio recommends requests without a CPU limit, so pods may burst into
+	// idle CPU on the node
+	CPULimitNone = 0.0
+
+	// MemoryLimitEqual as MemoryLimitRatio recommends memory limits equal to requests, so pods are
+	// never OOM-killed for using memory the scheduler did not reserve
+	MemoryLimitEqual = 1.0
+)
+
 // deploymentMetrics holds aggregated metrics for a deployment
 type deploymentMetrics struct {
 	Namespace  string
-	Deployment string
+	Deployment string        // workload name, whatever its kind
+	Kind       string        // Deployment, StatefulSet or DaemonSet; empty means Deployment
+	Container  string        // set when the metrics are scoped to a single container
+	Window     time.Duration // time window the usage series cover
 
 	// CPU metrics (in millicores)
 	CPURequested int64
@@ -78,6 +311,10 @@ type deploymentMetrics struct {
 	CPUAverage   int64
 	CPUMax       int64
 
+	// CPUThrottleRatio is the average share of CFS periods throttled at the CPU limit; 0 without
+	// throttling data
+	CPUThrottleRatio float64
+
 	// Memory metrics (in bytes)
 	MemoryRequested int64
 	MemoryLimit     int64
@@ -88,6 +325,36 @@ type deploymentMetrics struct {
 	MemoryAverage   int64
 	MemoryMax       int64
 
+	// RecencyWeighted is set when the percentiles and averages above are weighted toward recent samples
+	RecencyWeighted bool
+
+	// Working-set memory (in bytes, excluding reclaimable page cache); 0 when unavailable or when
+	// excluded sidecars share the pod, as it is only measured per pod
+	MemoryWorkingSetP95 int64
+
+	// GPU metrics, in whole GPUs per pod. Usage is the "gpu" series: GPUs busy, summed across devices.
+	GPURequested  int64
+	GPUP95        float64
+	GPUContainer  string // the container requesting GPUs, when exactly one does
+	GPUTimeSeries []models.DataPoint
+
+	// Per-pod overhead from the pod's RuntimeClass (e.g. Kata, gVisor)
+	CPUOverhead    int64 // millicores
+	MemoryOverhead int64 // bytes
+
+	// Per-pod requests of excluded containers (sidecars), counted for cost only
+	SidecarCPURequested    int64 // millicores
+	SidecarMemoryRequested int64 // bytes
+
+	// CreatedAt is the deployment's creation time
+	CreatedAt time.Time
+
+	// LatencyCritical is set for workloads annotated or named as latency-critical
+	LatencyCritical bool
+
+	// QoSClass is the QoS class of the workload's pods: Guaranteed, Burstable or BestEffort
+	QoSClass string
+
 	// Replica information
 	CurrentReplicas int32
 	MinReplicas     int32
@@ -98,19 +365,61 @@ type deploymentMetrics struct {
 	HPATargetCPU       int32
 	HPACurrentCPU      int32
 	HPADesiredReplicas int32
+	HPAMetrics         []models.HPAMetricTarget // every metric the HPA scales on, including memory and custom metrics
 
 	// Stability metrics
-	RestartCount  int32
-	ScalingEvents int
+	RestartCount     int32
+	RestartBreakdown map[string]int // restarts by cause, e.g. OOMKilled, Error, CrashLoopBackOff
+	OOMKillCount     int32          // containers whose last termination was an OOM kill
+	ScalingEvents    int
+	RolloutCount     int // rollouts (new ReplicaSets) within the analysis window
+
+	// Per-container requests, limits and usage for multi-container pods, keyed by container name
+	Containers map[string]*containerMetrics
 
 	// Time series data for variance calculation
 	CPUTimeSeries     []models.DataPoint
+	CPUDemandSeries   []models.DataPoint // CPU summed across pods per timestamp
 	MemoryTimeSeries  []models.DataPoint
 	ReplicaTimeSeries []models.DataPoint
 
 	Timestamp time.Time
 }
 
+// containerMetrics holds the requests, limits and usage of one container across a deployment's pods
+type containerMetrics struct {
+	Name string
+
+	CPURequested    int64 // millicores
+	CPULimit        int64 // millicores
+	MemoryRequested int64 // bytes
+	MemoryLimit     int64 // bytes
+
+	CPUTimeSeries    []models.DataPoint
+	MemoryTimeSeries []models.DataPoint
+}
+
+// effectiveCPURequest returns the per-pod CPU reserved on the node: container requests plus
+// excluded sidecars and pod overhead
+func (m *deploymentMetrics) effectiveCPURequest() int64 {
+	return m.CPURequested + m.SidecarCPURequested + m.CPUOverhead
+}
+
+// memoryPressure returns the P95 memory used to detect under-provisioning. Total usage includes
+// page cache the kernel reclaims under pressure, so working-set memory is preferred when available.
+func (m *deploymentMetrics) memoryPressure(useWorkingSet bool) int64 {
+	if useWorkingSet && m.MemoryWorkingSetP95 > 0 {
+		return m.MemoryWorkingSetP95
+	}
+	return m.MemoryP95
+}
+
+// effectiveMemoryRequest returns the per-pod memory reserved on the node: container requests plus
+// excluded sidecars and pod overhead
+func (m *deploymentMetrics) effectiveMemoryRequest() int64 {
+	return m.MemoryRequested + m.SidecarMemoryRequested + m.MemoryOverhead
+}
+
 // analysisResult holds the results of resource analysis
 type analysisResult struct {
 	Deployment deploymentMetrics
@@ -121,6 +430,8 @@ type analysisResult struct {
 	CPUVariance         float64
 	CPUOverProvisioned  bool
 	CPUUnderProvisioned bool
+	CPUBursty           bool // rare spikes far above typical usage
+	CPUThrottled        bool // throttled at the limit more than CPUThrottleThreshold
 
 	// Memory analysis
 	MemoryUtilization      float64
@@ -128,6 +439,12 @@ type analysisResult struct {
 	MemoryVariance         float64
 	MemoryOverProvisioned  bool
 	MemoryUnderProvisioned bool
+	MemoryOOMKilled        bool // a container's last termination was an OOM kill
+
+	// GPU analysis
+	GPUUtilization     float64
+	GPUOverProvisioned bool
+	RecommendedGPUs    int64
 
 	// HPA analysis
 	HPANeedsOptimization bool
@@ -136,6 +453,42 @@ type analysisResult struct {
 	HPAHitCeiling        bool
 	HPAIdleAtMinimum     bool
 
+	// Event-driven scaling analysis
+	LongestIdleGap       time.Duration // longest stretch of CPU demand near zero
+	IdleFraction         float64       // share of samples with CPU demand near zero
+	EventDrivenCandidate bool          // idle at the HPA minimum with long gaps between bursts
+
+	// Idle is set when CPU stays near zero and memory is flat across the whole window
+	Idle bool
+
+	// Provisional is set when the analysis is based on fewer than MinimumDataPoints samples
+	Provisional bool
+
+	// TooNew is set when the deployment is younger than MinWorkloadAge; only its configuration is reported
+	TooNew bool
+
+	// Churn analysis
+	ChurnRate  float64 // rollouts per day
+	HighChurn  bool
+	Confidence float64 // 0-1 confidence that observed usage reflects steady state
+	Coverage   float64 // 0-1 share of the analysis window spanned by collected samples
+
+	// Namespace quota pressure (nil if the namespace has no ResourceQuota)
+	QuotaPressure *models.QuotaPressure
+
+	// Per-container analyses of multi-container pods, sorted by container name
+	Containers []*analysisResult
+
+	// Largest node allocatable in the cluster (0 if unknown) and whether limits exceed it
+	LargestNodeCPU          int64 // millicores
+	LargestNodeMemory       int64 // bytes
+	CPULimitExceedsNodes    bool
+	MemoryLimitExceedsNodes bool
+
+	// Whether pod requests exceed MaxNodeFractionPerPod of the largest node's allocatable
+	CPURequestExceedsNodeFraction    bool
+	MemoryRequestExceedsNodeFraction bool
+
 	// Overall scores
 	ResourceUtilizationScore float64
 	StabilityScore           float64
@@ -151,13 +504,19 @@ type resourceConfig struct {
 	CPULimit      string
 	MemoryRequest string
 	MemoryLimit   string
+	GPU           string // whole GPUs, set as both request and limit
 }
 
-// hpaConfig represents the current or recommended HPA configuration
-type hpaConfig struct {
-	MinReplicas int32
-	MaxReplicas int32
-	TargetCPU   int32
+// HPAConfig represents the current, recommended or proposed HPA configuration
+type HPAConfig struct {
+	MinReplicas int32 `json:"min_replicas"`
+	MaxReplicas int32 `json:"max_replicas"`
+	TargetCPU   int32 `json:"target_cpu"` // target average CPU utilization (percentage of request)
+
+	// Metric and Target adjust the target of another metric the HPA scales on, such as "memory" or
+	// a custom queue-depth metric; Target is in the metric's own unit
+	Metric string  `json:"metric,omitempty"`
+	Target float64 `json:"target,omitempty"`
 }
 
 // scalingConfig represents scaling-related configuration
@@ -174,6 +533,35 @@ const (
 	PriorityLow    recommendationPriority = "low"
 )
 
+// riskLevel classifies how risky applying a recommendation is
+type riskLevel string
+
+const (
+	RiskLow     riskLevel = "low"
+	RiskMedium  riskLevel = "medium"
+	RiskHigh    riskLevel = "high"
+	RiskUnknown riskLevel = "unknown"
+)
+
+// riskLabels are the capitalized risk levels used in impact messages
+var riskLabels = map[riskLevel]string{
+	RiskLow:     "Low",
+	RiskMedium:  "Medium",
+	RiskHigh:    "High",
+	RiskUnknown: "Unknown",
+}
+
+// riskMultipliers discount a recommendation's impact score by its risk
+var riskMultipliers = map[riskLevel]float64{
+	RiskLow:     1.0,
+	RiskMedium:  0.6,
+	RiskHigh:    0.2,
+	RiskUnknown: 0.5,
+}
+
+// impactSavingsScale is the monthly savings that earns half of the savings component of the impact score
+const impactSavingsScale = 50.0
+
 // recommendationType defines the type of recommendation
 type recommendationType string
 
@@ -181,4 +569,11 @@ const (
 	RecommendationTypeResource recommendationType = "resource"
 	RecommendationTypeHPA      recommendationType = "hpa"
 	RecommendationTypeScaling  recommendationType = "scaling"
+	RecommendationTypeQuota    recommendationType = "quota"
+
+	// RecommendationTypeEventDriven suggests replacing a CPU HPA with KEDA event-driven scaling
+	RecommendationTypeEventDriven recommendationType = "event-driven"
+
+	// RecommendationTypeIdle suggests scaling an idle workload to zero or removing it
+	RecommendationTypeIdle recommendationType = "idle"
 )
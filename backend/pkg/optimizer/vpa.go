@@ -0,0 +1,68 @@
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RecommendVPA computes Vertical Pod Autoscaler style bounds for every container of a deployment
+// from its per-container usage: lower bound = P50, target = P95 x OverProvisionedBuffer and upper
+// bound = P99 x OverProvisionedBuffer, the last two rounded up like other recommendations.
+// Containers without enough usage history are left out, as VPA does; if none has any, the error
+// wraps ErrInsufficientData.
+func (opt *OptimizerEngine) RecommendVPA(namespace, name string) (*models.VPARecommendation, error) {
+	deployment, err := opt.k8sClient.Clientset.AppsV1().Deployments(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, getError("deployment", err)
+	}
+
+	buffer := opt.cfg().OverProvisionedBuffer
+	rg := opt.recommendationGen
+	recommendation := &models.VPARecommendation{
+		Namespace:  namespace,
+		Deployment: name,
+		Buffer:     buffer,
+		Timestamp:  time.Now(),
+	}
+
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		internal, err := opt.analyzer.analyzeContainer(namespace, name, container.Name)
+		if errors.Is(err, ErrInsufficientData) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze container %s: %w", container.Name, err)
+		}
+		metrics := &internal.Deployment
+		if len(metrics.CPUTimeSeries) == 0 || len(metrics.MemoryTimeSeries) == 0 {
+			continue
+		}
+
+		recommendation.Containers = append(recommendation.Containers, models.VPAContainerRecommendation{
+			Container: container.Name,
+			LowerBound: models.VPAResources{
+				CPU:    metrics.CPUP50,
+				Memory: metrics.MemoryP50,
+			},
+			Target: models.VPAResources{
+				CPU:    rg.roundCPU(int64(float64(metrics.CPUP95) * buffer)),
+				Memory: rg.roundMemory(int64(float64(metrics.MemoryP95) * buffer)),
+			},
+			UpperBound: models.VPAResources{
+				CPU:    rg.roundCPU(int64(float64(metrics.CPUP99) * buffer)),
+				Memory: rg.roundMemory(int64(float64(metrics.MemoryP99) * buffer)),
+			},
+			Provisional: internal.Provisional,
+		})
+	}
+
+	if len(recommendation.Containers) == 0 {
+		return nil, fmt.Errorf("%w: no container of deployment %s/%s has usage history", ErrInsufficientData, namespace, name)
+	}
+	return recommendation, nil
+}
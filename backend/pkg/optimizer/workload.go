@@ -0,0 +1,161 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k8s-service-optimizer/backend/internal/k8s"
+	"github.com/k8s-service-optimizer/backend/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Workload kinds the optimizer can analyze
+const (
+	WorkloadKindDeployment  = "Deployment"
+	WorkloadKindStatefulSet = "StatefulSet"
+	WorkloadKindDaemonSet   = "DaemonSet"
+)
+
+// workload is the kind-independent view of a pod controller used for analysis
+type workload struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Object    metav1.Object // the controller, for ownership checks
+	Replicas  int32
+	Selector  *metav1.LabelSelector
+	Template  corev1.PodTemplateSpec
+	CreatedAt time.Time
+}
+
+// normalizeWorkloadKind returns the canonical kind name, matching case-insensitively.
+// An empty kind means Deployment.
+func normalizeWorkloadKind(kind string) (string, error) {
+	if kind == "" {
+		return WorkloadKindDeployment, nil
+	}
+	for _, known := range []string{WorkloadKindDeployment, WorkloadKindStatefulSet, WorkloadKindDaemonSet} {
+		if strings.EqualFold(kind, known) {
+			return known, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported workload kind %q", kind)
+}
+
+// analysisCacheKey identifies a workload's cached analysis. Deployments keep the plain
+// namespace/name key; other kinds are qualified by kind.
+func analysisCacheKey(namespace, kind, name string) string {
+	if kind == "" || kind == WorkloadKindDeployment {
+		return fmt.Sprintf("%s/%s", namespace, name)
+	}
+	return fmt.Sprintf("%s/%s/%s", namespace, strings.ToLower(kind), name)
+}
+
+// AnalyzeWorkload analyzes a Deployment, StatefulSet or DaemonSet over the configured analysis duration
+func (opt *OptimizerEngine) AnalyzeWorkload(namespace, kind, name string) (*models.Analysis, error) {
+	kind, err := normalizeWorkloadKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	if kind == WorkloadKindDeployment {
+		return opt.AnalyzeDeployment(namespace, name)
+	}
+
+	internalAnalysis, err := opt.analyzer.analyzeWorkloadWindow(namespace, kind, name, opt.cfg().AnalysisDuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze %s: %w", strings.ToLower(kind), err)
+	}
+
+	opt.analysisCacheMu.Lock()
+	opt.analysisCache[analysisCacheKey(namespace, kind, name)] = internalAnalysis
+	opt.analysisCacheMu.Unlock()
+
+	return opt.convertToPublicAnalysis(internalAnalysis), nil
+}
+
+// getWorkload fetches a workload and extracts its replicas, selector and pod template
+func (ra *resourceAnalyzer) getWorkload(namespace, kind, name string) (*workload, error) {
+	ctx := context.Background()
+	apps := ra.optimizer.k8sClient.Clientset.AppsV1()
+
+	w := &workload{Kind: kind, Namespace: namespace, Name: name, Replicas: 1}
+	switch kind {
+	case WorkloadKindDeployment:
+		deployment, err := apps.Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, getError("deployment", err)
+		}
+		w.Object, w.Selector, w.Template = deployment, deployment.Spec.Selector, deployment.Spec.Template
+		w.Replicas = k8s.DesiredReplicas(deployment.Spec.Replicas)
+	case WorkloadKindStatefulSet:
+		statefulSet, err := apps.StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, getError("statefulset", err)
+		}
+		w.Object, w.Selector, w.Template = statefulSet, statefulSet.Spec.Selector, statefulSet.Spec.Template
+		w.Replicas = k8s.DesiredReplicas(statefulSet.Spec.Replicas)
+	case WorkloadKindDaemonSet:
+		daemonSet, err := apps.DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, getError("daemonset", err)
+		}
+		// A DaemonSet runs one pod per eligible node
+		w.Object, w.Selector, w.Template = daemonSet, daemonSet.Spec.Selector, daemonSet.Spec.Template
+		w.Replicas = daemonSet.Status.DesiredNumberScheduled
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+	w.CreatedAt = w.Object.GetCreationTimestamp().Time
+
+	return w, nil
+}
+
+// getWorkloadPods gets the pods a workload controls. Deployments own pods through ReplicaSets;
+// StatefulSets and DaemonSets control their pods directly.
+func (ra *resourceAnalyzer) getWorkloadPods(w *workload) ([]corev1.Pod, error) {
+	if w.Kind == WorkloadKindDeployment {
+		return ra.getDeploymentPods(w.Object.(*appsv1.Deployment))
+	}
+
+	podList, err := ra.optimizer.k8sClient.Clientset.CoreV1().Pods(w.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(w.Selector),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]corev1.Pod, 0, len(podList.Items))
+	for i := range podList.Items {
+		if metav1.IsControlledBy(&podList.Items[i], w.Object) {
+			pods = append(pods, podList.Items[i])
+		}
+	}
+	return pods, nil
+}
+
+// countWorkloadRollouts counts the workload's pod template revisions created since the given time.
+// Deployments record revisions as ReplicaSets; StatefulSets and DaemonSets as ControllerRevisions.
+func (ra *resourceAnalyzer) countWorkloadRollouts(w *workload, since time.Time) (int, error) {
+	if w.Kind == WorkloadKindDeployment {
+		return ra.countRecentRollouts(w.Object.(*appsv1.Deployment), since)
+	}
+
+	revisions, err := ra.optimizer.k8sClient.Clientset.AppsV1().ControllerRevisions(w.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(w.Selector),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range revisions.Items {
+		if metav1.IsControlledBy(&revisions.Items[i], w.Object) && revisions.Items[i].CreationTimestamp.Time.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
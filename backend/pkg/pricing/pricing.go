@@ -0,0 +1,109 @@
+package pricing
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Default rates, matching typical on-demand cloud pricing
+const (
+	DefaultCPUCostPerVCPUHour  = 0.03  // $0.03 per vCPU-hour (1000 millicores = 1 vCPU)
+	DefaultMemoryCostPerGBHour = 0.004 // $0.004 per GB-hour (1024 MB = 1 GB)
+)
+
+// PricingProvider prices CPU and memory on the node a workload runs on. Callers that don't know
+// the node pass an empty name and get the provider's default rates.
+type PricingProvider interface {
+	// CPUCostPerVCPUHour is the cost per vCPU-hour on the node
+	CPUCostPerVCPUHour(node string) float64
+
+	// MemoryCostPerGBHour is the cost per GB-hour on the node
+	MemoryCostPerGBHour(node string) float64
+}
+
+// Rates are the per-unit prices of CPU and memory
+type Rates struct {
+	CPUCostPerVCPUHour  float64
+	MemoryCostPerGBHour float64
+}
+
+// AdjustableProvider is a provider whose default rates can be changed at runtime. Sharing one
+// between the analyzer and optimizer keeps their costs consistent when rates are updated.
+type AdjustableProvider interface {
+	PricingProvider
+
+	// SetDefaultRates replaces the rates of nodes that have no rates of their own
+	SetDefaultRates(rates Rates)
+}
+
+// StaticProvider prices nodes from a fixed map of rates, falling back to default rates for nodes
+// that aren't listed. The default rates can be replaced with SetDefaultRates.
+type StaticProvider struct {
+	Default Rates
+	Nodes   map[string]Rates // keyed by node name
+
+	mu sync.RWMutex // guards Default after construction
+}
+
+// NewStaticProvider creates a provider pricing the listed nodes at their rates and all others at
+// the default rates
+func NewStaticProvider(defaults Rates, nodes map[string]Rates) *StaticProvider {
+	return &StaticProvider{
+		Default: defaults,
+		Nodes:   nodes,
+	}
+}
+
+// DefaultProvider returns a provider pricing every node at DefaultCPUCostPerVCPUHour and
+// DefaultMemoryCostPerGBHour
+func DefaultProvider() PricingProvider {
+	return NewStaticProvider(Rates{
+		CPUCostPerVCPUHour:  DefaultCPUCostPerVCPUHour,
+		MemoryCostPerGBHour: DefaultMemoryCostPerGBHour,
+	}, nil)
+}
+
+// CPUCostPerVCPUHour returns the node's CPU rate, or the default rate if it isn't listed
+func (p *StaticProvider) CPUCostPerVCPUHour(node string) float64 {
+	return p.rates(node).CPUCostPerVCPUHour
+}
+
+// MemoryCostPerGBHour returns the node's memory rate, or the default rate if it isn't listed
+func (p *StaticProvider) MemoryCostPerGBHour(node string) float64 {
+	return p.rates(node).MemoryCostPerGBHour
+}
+
+// SetDefaultRates replaces the rates of unlisted nodes; listed nodes keep their rates
+func (p *StaticProvider) SetDefaultRates(rates Rates) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Default = rates
+}
+
+// MarshalJSON encodes the default and per-node rates
+func (p *StaticProvider) MarshalJSON() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return json.Marshal(struct {
+		Default Rates
+		Nodes   map[string]Rates
+	}{p.Default, p.Nodes})
+}
+
+// rates returns the rates of a node
+func (p *StaticProvider) rates(node string) Rates {
+	if rates, ok := p.Nodes[node]; ok {
+		return rates
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.Default
+}
+
+// NodeRates returns a provider's rates for a node
+func NodeRates(provider PricingProvider, node string) Rates {
+	return Rates{
+		CPUCostPerVCPUHour:  provider.CPUCostPerVCPUHour(node),
+		MemoryCostPerGBHour: provider.MemoryCostPerGBHour(node),
+	}
+}
@@ -0,0 +1,32 @@
+package pricing
+
+import "testing"
+
+// TestDefaultProvider tests that the default provider reproduces the flat default rates
+func TestDefaultProvider(t *testing.T) {
+	provider := DefaultProvider()
+
+	for _, node := range []string{"", "node-1"} {
+		if rate := provider.CPUCostPerVCPUHour(node); rate != 0.03 {
+			t.Errorf("Expected CPU rate 0.03 for node %q, got %f", node, rate)
+		}
+		if rate := provider.MemoryCostPerGBHour(node); rate != 0.004 {
+			t.Errorf("Expected memory rate 0.004 for node %q, got %f", node, rate)
+		}
+	}
+}
+
+// TestStaticProvider tests that listed nodes use their rates and others the defaults
+func TestStaticProvider(t *testing.T) {
+	provider := NewStaticProvider(
+		Rates{CPUCostPerVCPUHour: 0.04, MemoryCostPerGBHour: 0.005},
+		map[string]Rates{"spot-1": {CPUCostPerVCPUHour: 0.012, MemoryCostPerGBHour: 0.0015}},
+	)
+
+	if rates := NodeRates(provider, "spot-1"); rates != (Rates{CPUCostPerVCPUHour: 0.012, MemoryCostPerGBHour: 0.0015}) {
+		t.Errorf("Expected the listed rates for spot-1, got %+v", rates)
+	}
+	if rates := NodeRates(provider, "node-1"); rates != provider.Default {
+		t.Errorf("Expected the default rates for an unlisted node, got %+v", rates)
+	}
+}